@@ -7,13 +7,17 @@ package v1alpha1
 
 import (
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // AIGatewayRoute combines multiple AIServiceBackends and attaching them to Gateway(s) resources.
 //
@@ -34,7 +38,8 @@ import (
 //   - EnvoyExtensionPolicy of the Envoy Gateway API to attach the AI Gateway filter into the HTTPRoute.
 //     The name of the EnvoyExtensionPolicy is `ai-eg-route-extproc-${name}` which is the same as the Deployment, etc.
 //   - HTTPRouteFilter of the Envoy Gateway API per namespace for automatic hostname rewrite.
-//     The name of the HTTPRouteFilter is `ai-eg-host-rewrite`.
+//     The name of the HTTPRouteFilter is `ai-eg-host-rewrite`. It is not attached to the rules of the backends
+//     whose hostnameRewrite is "Disabled".
 //
 // All of these resources are created in the same namespace as the AIGatewayRoute. Note that this is the implementation
 // detail subject to change. If you want to customize the default behavior of the Envoy AI Gateway, you can use these
@@ -45,6 +50,8 @@ type AIGatewayRoute struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// Spec defines the details of the AIGatewayRoute.
 	Spec AIGatewayRouteSpec `json:"spec,omitempty"`
+	// Status defines the status details of the AIGatewayRoute.
+	Status AIGatewayRouteStatus `json:"status,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -56,10 +63,53 @@ type AIGatewayRouteList struct {
 	Items           []AIGatewayRoute `json:"items"`
 }
 
+// AIGatewayRouteStatus contains the conditions by the reconciliation result.
+type AIGatewayRouteStatus struct {
+	// Conditions is the list of conditions by the reconciliation result. The known condition types are:
+	//
+	//   - "Accepted", which is set to False with the reason "NoMatchingParent" when any of the target Gateways
+	//     does not exist or belongs to a GatewayClass not managed by Envoy Gateway.
+	//   - "ResolvedRefs", which is set to False when any of the TargetRefs cannot be resolved.
+	//   - "Programmed", which is set to True once all the parents of the generated HTTPRoute have accepted it.
+	//   - "ExtProcDisruptionAllowed", which is set when the PodDisruptionBudget of the external processor is
+	//     configured, and is False with the reason "SingleReplica" when the deployment runs a single replica.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// AIGatewayRouteConditionProgrammed is the condition type of the AIGatewayRoute that indicates whether the
+	// HTTPRoute generated for the AIGatewayRoute has been accepted by all of its parent Gateways.
+	AIGatewayRouteConditionProgrammed = "Programmed"
+	// AIGatewayRouteReasonPending is the reason of the Programmed condition when the generated HTTPRoute has not
+	// been accepted by its parent Gateways yet.
+	AIGatewayRouteReasonPending = "Pending"
+	// AIGatewayRouteConditionExtProcDisruptionAllowed is the condition type of the AIGatewayRoute that indicates
+	// whether the PodDisruptionBudget of the external processor allows the voluntary disruptions such as node drains.
+	AIGatewayRouteConditionExtProcDisruptionAllowed = "ExtProcDisruptionAllowed"
+	// AIGatewayRouteReasonSingleReplica is the reason of the ExtProcDisruptionAllowed condition when the external
+	// processor deployment runs a single replica, in which case the PodDisruptionBudget may block the node drains.
+	AIGatewayRouteReasonSingleReplica = "SingleReplica"
+	// AIGatewayRouteReasonReplicated is the reason of the ExtProcDisruptionAllowed condition when the external
+	// processor deployment runs, or can be scaled to, multiple replicas.
+	AIGatewayRouteReasonReplicated = "Replicated"
+)
+
 // AIGatewayRouteSpec details the AIGatewayRoute configuration.
+//
+// +kubebuilder:validation:XValidation:rule="!(has(self.defaultBackend) && has(self.disableDefaultRoute) && self.disableDefaultRoute)", message="defaultBackend cannot be set when disableDefaultRoute is true"
+// +kubebuilder:validation:XValidation:rule="!(has(self.overrideErrorResponses) && self.overrideErrorResponses && self.schema.name != 'OpenAI')", message="overrideErrorResponses is only supported for the OpenAI schema"
 type AIGatewayRouteSpec struct {
 	// TargetRefs are the names of the Gateway resources this AIGatewayRoute is being attached to.
 	//
+	// When SectionName is set, the AIGatewayRoute is only attached to the listener of the Gateway with that name.
+	// Otherwise, it is attached to all the listeners of the Gateway. When the listener does not exist in the Gateway,
+	// the ResolvedRefs condition in the status is set to False.
+	//
 	// +kubebuilder:validation:MinItems=1
 	// +kubebuilder:validation:MaxItems=128
 	TargetRefs []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName `json:"targetRefs"`
@@ -67,10 +117,12 @@ type AIGatewayRouteSpec struct {
 	// Based on this schema, the ai-gateway will perform the necessary transformation to the
 	// output schema specified in the selected AIServiceBackend during the routing process.
 	//
-	// Currently, the only supported schema is OpenAI as the input schema.
+	// Currently, OpenAI and AWSBedrock are supported as the input schema. With AWSBedrock, the clients speak the
+	// AWS Bedrock Converse API at the path "/model/{modelId}/converse", and the model name used for the routing,
+	// i.e. `x-ai-eg-model` header, is extracted from the path instead of the request body.
 	//
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:XValidation:rule="self.name == 'OpenAI'"
+	// +kubebuilder:validation:XValidation:rule="self.name == 'OpenAI' || self.name == 'AWSBedrock'"
 	APISchema VersionedAPISchema `json:"schema"`
 	// Rules is the list of AIGatewayRouteRule that this AIGatewayRoute will match the traffic to.
 	// Each rule is a subset of the HTTPRoute in the Gateway API (https://gateway-api.sigs.k8s.io/api-types/httproute/).
@@ -86,6 +138,9 @@ type AIGatewayRouteSpec struct {
 	// How multiple rules are matched is the same as the Gateway API. See for the details:
 	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPRoute
 	//
+	// The precedence among the rules matching the same request can be set with the priority of the rules,
+	// independently of the order in this list. See AIGatewayRouteRule.Priority for the details.
+	//
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MaxItems=128
 	Rules []AIGatewayRouteRule `json:"rules"`
@@ -103,6 +158,10 @@ type AIGatewayRouteSpec struct {
 	// The AI Gateway filter will capture each specified number and store it in the Envoy's dynamic
 	// metadata per HTTP request. The namespaced key is "io.envoy.ai_gateway",
 	//
+	// Alongside the costs, the model name and the selected backend name in the form of "name.namespace" are stored
+	// with the keys "model" and "backend", which are "unknown" if not known, for example, when the request is
+	// rejected before a backend is selected.
+	//
 	// For example, let's say we have the following LLMRequestCosts configuration:
 	// ```yaml
 	//	llmRequestCosts:
@@ -192,6 +251,356 @@ type AIGatewayRouteSpec struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=36
 	LLMRequestCosts []LLMRequestCost `json:"llmRequestCosts,omitempty"`
+
+	// EmitCostHeaders enables exposing the token usage of the chat completion responses to the clients.
+	//
+	// When enabled, the input, output, and total token counts are returned in the x-ai-eg-input-tokens,
+	// x-ai-eg-output-tokens, and x-ai-eg-total-tokens response headers for non-streaming requests.
+	// For streaming requests, the same values are returned as HTTP trailers since the response headers
+	// have already been sent when the token usage is known. Note that the trailers are only delivered
+	// to the clients that support them, such as HTTP/2 clients.
+	//
+	// The values are the same as the ones captured for LLMRequestCosts.
+	//
+	// +optional
+	EmitCostHeaders bool `json:"emitCostHeaders,omitempty"`
+
+	// EmitLatencyHeaders enables exposing the latencies of the chat completion responses to the clients.
+	//
+	// When enabled, the time from forwarding the request to the backend to receiving the first chunk of the response
+	// body, i.e. the time to first token, and the total duration of the upstream request are returned in milliseconds
+	// in the x-ai-eg-time-to-first-token-ms and x-ai-eg-upstream-duration-ms response headers for non-streaming
+	// requests, and as HTTP trailers of the same names for streaming requests.
+	//
+	// Regardless of this, the same values are set to the dynamic metadata in the io.envoy.ai_gateway namespace with
+	// the keys "time_to_first_token_ms" and "upstream_duration_ms" so that they can be included in the access logs.
+	//
+	// +optional
+	EmitLatencyHeaders bool `json:"emitLatencyHeaders,omitempty"`
+
+	// EstimateInputTokens enables estimating the input tokens of the chat completion requests before they are
+	// forwarded to the backends, so that the rate limits can consume the budget up front instead of only after
+	// the response, when a single large prompt can already exceed it.
+	//
+	// When enabled, the estimate is set to the dynamic metadata in the io.envoy.ai_gateway namespace with the key
+	// "estimated_input_tokens" when the request is forwarded, which can be used as the request cost of the rate limit.
+	// At the end of the response, the actual input tokens reported by the backend in excess of the estimate is set once
+	// with the key "estimated_input_tokens_delta" to charge the rest of the budget. This is zero when the estimate was
+	// too large, since the rate limit cannot refund the budget: the excess is only recovered when the window resets.
+	//
+	// The estimate is based on the rule of thumb of four characters per token of the text of the messages and the
+	// tool definitions, with a fixed number of tokens for each message and each image or audio content part.
+	//
+	// +optional
+	EstimateInputTokens bool `json:"estimateInputTokens,omitempty"`
+
+	// DefaultBackend is the name of the AIServiceBackend that the catch-all "/" rule of the generated HTTPRoute
+	// routes the requests to when no backend is selected, for example, the requests to the paths other than
+	// the LLM endpoints. It must be referenced by one of the rules. Defaults to the first backend of the rules.
+	//
+	// The requests to the LLM endpoints whose model matches no rule are sent to this backend as well, with the
+	// authentication and the translation for the backend, except in the shared deployment of the external processor,
+	// where they are rejected with 404 Not Found.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	DefaultBackend string `json:"defaultBackend,omitempty"`
+
+	// DisableDefaultRoute omits the backend of the catch-all "/" rule of the generated HTTPRoute. When true,
+	// the requests to the paths other than the LLM endpoints are rejected by the external processor with
+	// 404 Not Found in the OpenAI error format instead of being forwarded to the default backend, and so are the
+	// requests whose model matches no rule.
+	//
+	// +optional
+	DisableDefaultRoute bool `json:"disableDefaultRoute,omitempty"`
+
+	// PathPrefix is the path prefix under which the LLM endpoints are exposed to the clients, for example,
+	// "/llm" to serve /llm/v1/chat/completions behind an existing ingress. The prefix is removed from the path
+	// before the request is sent to the backend. When set, the catch-all rule of the generated HTTPRoute
+	// matches the prefix instead of "/".
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(/[^/?#]+)+$`
+	PathPrefix string `json:"pathPrefix,omitempty"`
+
+	// ModelHeaderName is the name of the request header to which the model name extracted from the request is set,
+	// for example, to avoid the collision with an existing header of the platform. Defaults to "x-ai-eg-model".
+	//
+	// The matches of the rules on the `x-ai-eg-model` header are matched against this header instead, so the rules
+	// do not have to be rewritten when this is changed.
+	//
+	// This must be the same among the AIGatewayRoutes sharing the external processor deployment.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=256
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9!#$%&'*+\-.^_\x60|~]+$`
+	ModelHeaderName string `json:"modelHeaderName,omitempty"`
+
+	// MetadataNamespace is the namespace of the dynamic metadata written by the AI Gateway filter, such as the costs
+	// calculated with LLMRequestCosts. Defaults to "io.envoy.ai_gateway".
+	//
+	// Setting a distinct namespace per tenant prevents the rate limit policies of a tenant from reading the costs
+	// of another. The BackendTrafficPolicy referring to the costs must use the same namespace, which the one
+	// generated from RateLimit does automatically.
+	//
+	// This must be the same among the AIGatewayRoutes sharing the external processor deployment.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_.\-]+$`
+	MetadataNamespace string `json:"metadataNamespace,omitempty"`
+
+	// DisableRequestIDPropagation disables the propagation of the request ID between the clients and the backends.
+	//
+	// By default, the x-request-id header of the request, or the one generated when absent, is forwarded to the
+	// backend, and the request ID assigned by the backend, such as the x-request-id header of OpenAI or the
+	// x-amzn-requestid header of AWS, is returned to the client in the x-ai-eg-upstream-request-id response header.
+	// Both are also set to the dynamic metadata in the io.envoy.ai_gateway namespace with the keys "request_id" and
+	// "upstream_request_id" so that they can be included in the access logs to correlate with the provider support.
+	//
+	// +optional
+	DisableRequestIDPropagation bool `json:"disableRequestIDPropagation,omitempty"`
+
+	// EmitConfigVersionHeader enables returning the version of the configuration that served the request in the
+	// x-ai-eg-config-version response header. This is for debugging the rollouts, where it is otherwise not
+	// possible to tell from a response whether the external processor has picked up the updated configuration.
+	//
+	// The version is the UUID of the configuration generated by the controller each time the configuration changes,
+	// which is also available in the logs of the external processor as "config_uuid".
+	//
+	// +optional
+	EmitConfigVersionHeader bool `json:"emitConfigVersionHeader,omitempty"`
+
+	// CORS configures the Cross-Origin Resource Sharing of the route so that the browsers can call the LLM endpoints
+	// directly. The controller generates the SecurityPolicy of Envoy Gateway with this CORS configuration targeting
+	// the generated HTTPRoute, and the preflight requests are answered by Envoy without reaching the backends.
+	//
+	// Note that Envoy Gateway does not merge the SecurityPolicies targeting the same HTTPRoute, so this must not be
+	// set when another SecurityPolicy targets the HTTPRoute.
+	//
+	// +optional
+	CORS *egv1a1.CORS `json:"cors,omitempty"`
+
+	// RateLimit configures the rate limiting of the route by the costs calculated with LLMRequestCosts, so that
+	// the BackendTrafficPolicy does not have to be written by hand as shown in LLMRequestCosts. The controller
+	// generates the BackendTrafficPolicy of Envoy Gateway with a global rate limit rule per limit targeting
+	// the generated HTTPRoute, where the response cost of each rule is read from the dynamic metadata of
+	// the referenced LLMRequestCost so that the metadata keys always match.
+	//
+	// This requires the global rate limiting to be enabled in Envoy Gateway. Note that Envoy Gateway does not merge
+	// the BackendTrafficPolicies targeting the same HTTPRoute, so this must not be set when another
+	// BackendTrafficPolicy targets the HTTPRoute.
+	//
+	// +optional
+	RateLimit *AIGatewayRouteRateLimit `json:"rateLimit,omitempty"`
+
+	// StateStore configures the store of the state shared by the replicas of the external processor, such as
+	// the counters of the concurrency limit, so that the limits apply across the replicas instead of per replica.
+	// When the store is unreachable, each replica falls back to its own state, i.e. the limits apply per replica,
+	// until the store is reachable again. The store is skipped for a few seconds after a failure, and the number of
+	// the requests falling back is exported as the ai_gateway_extproc_state_store_fallbacks_total metric.
+	// RateLimit is not affected since it is enforced by Envoy Gateway.
+	//
+	// The AIGatewayRoutes sharing the external processor deployment must have the same state store. The ones with
+	// a different state store from the first AIGatewayRoute in the order of the names are left out of the shared
+	// external processor.
+	//
+	// +optional
+	StateStore *AIGatewayRouteStateStore `json:"stateStore,omitempty"`
+
+	// OverrideErrorResponses enables overriding the 502, 503 and 504 local replies of Envoy to the requests of
+	// the generated HTTPRoute with the OpenAI errors. This is only supported with the OpenAI schema.
+	//
+	// When the backend cannot be reached, for example, because of a DNS resolution or connection failure, Envoy
+	// responds with its plain text body and the AI Gateway filter never sees the response. When this is true,
+	// the extension server adds the local reply config to the listener overriding these responses with a JSON body
+	// whose error type is "upstream_unavailable". Only the responses generated by Envoy on the upstream failures are
+	// overridden, and the error responses from the backends are returned as-is.
+	//
+	// +optional
+	OverrideErrorResponses bool `json:"overrideErrorResponses,omitempty"`
+
+	// ExcludePaths is the list of the paths of the requests that bypass the AI Gateway filter, for example, the health
+	// checks or the static assets served on the same listener, so that they are not buffered nor processed by
+	// the external processor.
+	//
+	// The requests on the paths are routed to the default backend, i.e. the one the catch-all rule routes to, via
+	// the separate HTTPRoute named "ai-eg-route-excluded-<name>". The controller disables the external processing
+	// for the HTTPRoute with the EnvoyExtensionPolicy of the same name targeting it, which takes precedence over
+	// the one targeting the Gateway. Note that this disables the other extensions configured with
+	// EnvoyExtensionPolicy for the Gateway as well.
+	//
+	// The paths are matched as they are, that is, PathPrefix is not prepended. Since the more specific match wins
+	// across the HTTPRoutes, the excluded paths take precedence over the default rule matching "/" or PathPrefix.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	ExcludePaths []AIGatewayRouteExcludePath `json:"excludePaths,omitempty"`
+
+	// AllowedEndpoints is the list of the endpoints exposed by the route. The requests to the other endpoints are
+	// rejected by the external processor with 404 Not Found in the OpenAI error format, even when the external
+	// processor supports them, for example, to expose the chat completions without the models listing.
+	//
+	// When not set, all the endpoints supported by the external processor are exposed.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	// +listType=set
+	AllowedEndpoints []AIGatewayRouteEndpoint `json:"allowedEndpoints,omitempty"`
+
+	// ModelPolicy restricts the models that can be requested through the route regardless of which rule would match.
+	// The requests for the other models are rejected by the external processor with 403 Forbidden in the OpenAI error
+	// format before the backend is selected.
+	//
+	// When not set, all the models are allowed.
+	//
+	// This must be the same among the AIGatewayRoutes sharing the external processor deployment.
+	//
+	// +optional
+	ModelPolicy *AIGatewayRouteModelPolicy `json:"modelPolicy,omitempty"`
+}
+
+// AIGatewayRouteModelPolicy is the list of the model patterns allowed or denied by the AIGatewayRoute.
+//
+// Each pattern is either the exact model name, or the prefix of the model names followed by "*", such as "gpt-4o*".
+// A model is allowed when it matches none of the Deny patterns, and either Allow is empty or the model matches one
+// of the Allow patterns.
+type AIGatewayRouteModelPolicy struct {
+	// Allow is the list of the patterns of the allowed models. When empty, all the models not denied are allowed.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MinLength=1
+	// +kubebuilder:validation:items:Pattern=`^[^*]*\*?$`
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny is the list of the patterns of the denied models. These take precedence over Allow.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:MinLength=1
+	// +kubebuilder:validation:items:Pattern=`^[^*]*\*?$`
+	Deny []string `json:"deny,omitempty"`
+}
+
+// AIGatewayRouteEndpoint is the endpoint exposed by the AIGatewayRoute.
+//
+// +kubebuilder:validation:Enum=ChatCompletions;Completions;Embeddings;Models;Converse
+type AIGatewayRouteEndpoint string
+
+const (
+	// AIGatewayRouteEndpointChatCompletions is the OpenAI chat completions endpoint, "/v1/chat/completions".
+	AIGatewayRouteEndpointChatCompletions AIGatewayRouteEndpoint = "ChatCompletions"
+	// AIGatewayRouteEndpointCompletions is the OpenAI legacy completions endpoint, "/v1/completions".
+	AIGatewayRouteEndpointCompletions AIGatewayRouteEndpoint = "Completions"
+	// AIGatewayRouteEndpointEmbeddings is the OpenAI embeddings endpoint, "/v1/embeddings".
+	AIGatewayRouteEndpointEmbeddings AIGatewayRouteEndpoint = "Embeddings"
+	// AIGatewayRouteEndpointModels is the OpenAI models listing endpoint, "/v1/models".
+	AIGatewayRouteEndpointModels AIGatewayRouteEndpoint = "Models"
+	// AIGatewayRouteEndpointConverse is the AWS Bedrock Converse endpoint, "/model/{modelId}/converse", served when
+	// the APISchema of the route is AWSBedrock.
+	AIGatewayRouteEndpointConverse AIGatewayRouteEndpoint = "Converse"
+)
+
+// AIGatewayRouteExcludePath is a path of the requests bypassing the AI Gateway filter.
+type AIGatewayRouteExcludePath struct {
+	// Type specifies how to match against the path. Either "Exact" or "PathPrefix". Defaults to "PathPrefix".
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Exact;PathPrefix
+	// +kubebuilder:default=PathPrefix
+	Type *gwapiv1.PathMatchType `json:"type,omitempty"`
+	// Value is the path to match against, for example, "/health".
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^/[^?#]*$`
+	Value string `json:"value"`
+}
+
+// AIGatewayRouteRateLimit configures the rate limiting of the AIGatewayRoute.
+type AIGatewayRouteRateLimit struct {
+	// ClientSelectorHeader is the name of the request header whose distinct values have separate budgets,
+	// for example, "x-user-id" to limit each user. When not set, all the requests to the route share the budgets.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ClientSelectorHeader string `json:"clientSelectorHeader,omitempty"`
+
+	// Limits is the list of the limits. Each limit has its own budget, and the request is rate limited when any of
+	// the budgets is exhausted.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=36
+	Limits []AIGatewayRouteRateLimitRule `json:"limits"`
+}
+
+// AIGatewayRouteStateStoreType specifies the type of the AIGatewayRouteStateStore.
+//
+// +kubebuilder:validation:Enum=Redis
+type AIGatewayRouteStateStoreType string
+
+const (
+	// AIGatewayRouteStateStoreTypeRedis is the Redis state store.
+	AIGatewayRouteStateStoreTypeRedis AIGatewayRouteStateStoreType = "Redis"
+)
+
+// AIGatewayRouteStateStore configures the store of the state shared by the replicas of the external processor.
+type AIGatewayRouteStateStore struct {
+	// Type is the type of the store. Currently, only Redis is supported.
+	//
+	// +kubebuilder:validation:Required
+	Type AIGatewayRouteStateStoreType `json:"type"`
+
+	// Address is the address of the store in the form of "host:port".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// TLS enables TLS for the connections to the store.
+	//
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+
+	// KeyPrefix is prepended to the keys of the state in the store. Defaults to "<namespace>/<name>:" of
+	// the AIGatewayRoute, so that the AIGatewayRoutes sharing the store do not share the state.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+
+	// PasswordSecretRef is the reference to the secret containing the password of the store in the "password" key.
+	// The secret must be in the same namespace as the AIGatewayRoute, and is mounted to the external processor.
+	//
+	// +optional
+	PasswordSecretRef *corev1.LocalObjectReference `json:"passwordSecretRef,omitempty"`
+}
+
+// AIGatewayRouteRateLimitRule configures a limit of the AIGatewayRoute.
+type AIGatewayRouteRateLimitRule struct {
+	// MetadataKey is the metadata key of the LLMRequestCost of the route whose cost consumes the budget, for example,
+	// "llm_total_token" to limit the total tokens. The cost is consumed once the response completes, so the request
+	// exhausting the budget is not rate limited but the subsequent ones are.
+	//
+	// When not set, each request consumes one from the budget, i.e. the number of the requests is limited.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	MetadataKey string `json:"metadataKey,omitempty"`
+
+	// Requests is the budget per unit, which is the number of the requests, or the cost of the LLMRequestCost
+	// when MetadataKey is set.
+	//
+	// +kubebuilder:validation:Required
+	Requests uint `json:"requests"`
+
+	// Unit is the interval of the budget. One of "Second", "Minute", "Hour" or "Day".
+	//
+	// +kubebuilder:validation:Required
+	Unit egv1a1.RateLimitUnit `json:"unit"`
 }
 
 // AIGatewayRouteRule is a rule that defines the routing behavior of the AIGatewayRoute.
@@ -212,6 +621,138 @@ type AIGatewayRouteRule struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=128
 	Matches []AIGatewayRouteRuleMatch `json:"matches,omitempty"`
+
+	// SessionAffinity configures the sticky selection of the backend among BackendRefs.
+	//
+	// When this is set and the request has the specified header, the backend is selected
+	// by consistent hashing over the header value while respecting the weights of the backends.
+	// This is useful, for example, to keep requests from the same user on the same backend
+	// to benefit from the prompt caching of the backend. When the header is absent, the backend
+	// is selected randomly based on the weights.
+	//
+	// +optional
+	SessionAffinity *AIGatewayRouteRuleSessionAffinity `json:"sessionAffinity,omitempty"`
+
+	// ModelDefaults specifies the default values of the inference parameters that are applied
+	// when the request matching this rule does not set them.
+	//
+	// +optional
+	ModelDefaults *AIGatewayRouteRuleModelDefaults `json:"modelDefaults,omitempty"`
+
+	// ModelLimits specifies the maximum values of the inference parameters of the requests matching this rule.
+	// The values exceeding the maximums are clamped, or the request is rejected when Strict is true.
+	//
+	// +optional
+	ModelLimits *AIGatewayRouteRuleModelLimits `json:"modelLimits,omitempty"`
+
+	// Mirror configures the mirroring of the requests matching this rule to a shadow backend, for example,
+	// to evaluate a candidate model with the production traffic. The responses from the shadow backend are
+	// never returned to the clients.
+	//
+	// +optional
+	Mirror *AIGatewayRouteRuleMirror `json:"mirror,omitempty"`
+
+	// HeaderModifications adds, sets, or removes the request headers of the requests matching this rule
+	// before they are sent to the selected backend. This is applied before the HeaderModifications of the AIServiceBackend.
+	//
+	// See AIServiceBackendSpec.HeaderModifications for the substitutions supported in the header values.
+	//
+	// +optional
+	HeaderModifications *gwapiv1.HTTPHeaderFilter `json:"headerModifications,omitempty"`
+
+	// Priority is the precedence of this rule when the request matches more than one rule. The rule with
+	// the greater priority wins regardless of the specificity of the matches. Among the matching rules with
+	// the same priority, the more specific match wins, i.e. the exact match over the prefix matches and the longer
+	// prefix over the shorter ones, and then the rule listed later wins. Defaults to 0.
+	//
+	// The controller orders the rules by the descending priority, keeping the order of this list among
+	// the rules with the same priority, when generating the HTTPRoute and the configuration of the AI Gateway filter.
+	// The same order applies to the first backend of the rules that DefaultBackend defaults to.
+	//
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+}
+
+// AIGatewayRouteRuleMirror specifies the shadow backend that the requests are mirrored to.
+//
+// The request is mirrored by Envoy with the requestMirror filter of the generated HTTPRoute as translated for
+// the selected backend, including the headers set by its BackendSecurityPolicy. Hence, the shadow backend must have
+// the same schema as all the backends of the rule, and must be trusted with the credentials of the selected backend.
+// The BackendSecurityPolicy of the shadow backend is not applied.
+type AIGatewayRouteRuleMirror struct {
+	// Name is the name of the AIServiceBackend that the requests are mirrored to.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Percent is the percentage of the requests to be mirrored. Defaults to 100.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Percent *int32 `json:"percent,omitempty"`
+}
+
+// AIGatewayRouteRuleModelDefaults specifies the default values of the inference parameters.
+//
+// The floating point values are specified as strings, e.g. "0.2".
+type AIGatewayRouteRuleModelDefaults struct {
+	// Temperature is the default sampling temperature.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Temperature *string `json:"temperature,omitempty"`
+	// TopP is the default nucleus sampling probability.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	TopP *string `json:"topP,omitempty"`
+	// MaxTokens is the default maximum number of tokens to generate, which is set to the "max_tokens" of the requests
+	// setting neither "max_completion_tokens" nor "max_tokens".
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxTokens *int64 `json:"maxTokens,omitempty"`
+}
+
+// AIGatewayRouteRuleModelLimits specifies the maximum values of the inference parameters.
+//
+// The floating point values are specified as strings, e.g. "1.0".
+type AIGatewayRouteRuleModelLimits struct {
+	// MaxTemperature is the maximum sampling temperature.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	MaxTemperature *string `json:"maxTemperature,omitempty"`
+	// MaxTopP is the maximum nucleus sampling probability.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	MaxTopP *string `json:"maxTopP,omitempty"`
+	// MaxTokens is the maximum number of tokens to generate, which limits both of "max_completion_tokens" and
+	// "max_tokens" of the requests.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxTokens *int64 `json:"maxTokens,omitempty"`
+	// MaxStopSequences is the maximum number of the stop sequences.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxStopSequences *int32 `json:"maxStopSequences,omitempty"`
+	// Strict rejects the requests exceeding the limits with 400 Bad Request instead of clamping the values.
+	//
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+}
+
+// AIGatewayRouteRuleSessionAffinity specifies how to stick the requests to a backend.
+type AIGatewayRouteRuleSessionAffinity struct {
+	// Header is the name of the request header whose value is used as the hashing key, e.g. "x-user-id".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Header string `json:"header"`
 }
 
 // AIGatewayRouteRuleBackendRef is a reference to a AIServiceBackend with a weight.
@@ -226,28 +767,71 @@ type AIGatewayRouteRuleBackendRef struct {
 	// the BackendRef in the Gateway API. See for the details:
 	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.BackendRef
 	//
+	// A weight of zero means the backend is valid but receives no traffic. When all the backends of the rule have
+	// zero weight, the requests matching the rule are rejected with 503 Service Unavailable.
+	//
 	// Default is 1.
 	//
 	// +optional
 	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000
 	// +kubebuilder:default=1
-	Weight int `json:"weight,omitempty"`
+	Weight *int32 `json:"weight,omitempty"`
 }
 
 type AIGatewayRouteRuleMatch struct {
 	// Headers specifies HTTP request header matchers. See HeaderMatch in the Gateway API for the details:
 	// https://gateway-api.sigs.k8s.io/reference/spec/#gateway.networking.k8s.io%2fv1.HTTPHeaderMatch
 	//
-	// Currently, only the exact header matching is supported.
+	// Currently, only the exact header matching is supported, plus the prefix matching on the
+	// x-ai-eg-model header. For example, the prefix "claude-" matches all the claude models.
 	//
 	// +listType=map
 	// +listMapKey=name
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
-	// +kubebuilder:validation:XValidation:rule="self.all(match, match.type != 'RegularExpression')", message="currently only exact match is supported"
-	Headers []gwapiv1.HTTPHeaderMatch `json:"headers,omitempty"`
+	// +kubebuilder:validation:XValidation:rule="self.all(match, match.type != 'RegularExpression')", message="currently only exact and prefix matches are supported"
+	// +kubebuilder:validation:XValidation:rule="self.all(match, match.type != 'Prefix' || match.name.lowerAscii() == 'x-ai-eg-model')", message="prefix match is only supported for the x-ai-eg-model header"
+	Headers []AIGatewayRouteRuleHeaderMatch `json:"headers,omitempty"`
+}
+
+// AIGatewayRouteRuleHeaderMatch is the same as HTTPHeaderMatch of the Gateway API except that
+// the Type additionally supports the Prefix match.
+type AIGatewayRouteRuleHeaderMatch struct {
+	// Type specifies how to match against the value of the header.
+	//
+	// +optional
+	// +kubebuilder:default=Exact
+	Type *AIGatewayRouteRuleHeaderMatchType `json:"type,omitempty"`
+	// Name is the name of the HTTP Header to be matched. Name matching is case insensitive.
+	//
+	// +kubebuilder:validation:Required
+	Name gwapiv1.HTTPHeaderName `json:"name"`
+	// Value is the value of HTTP Header to be matched.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=4096
+	Value string `json:"value"`
 }
 
+// AIGatewayRouteRuleHeaderMatchType specifies the semantics of how the header value is matched.
+//
+// +kubebuilder:validation:Enum=Exact;RegularExpression;Prefix
+type AIGatewayRouteRuleHeaderMatchType string
+
+const (
+	// AIGatewayRouteRuleHeaderMatchTypeExact matches the header value exactly.
+	AIGatewayRouteRuleHeaderMatchTypeExact AIGatewayRouteRuleHeaderMatchType = "Exact"
+	// AIGatewayRouteRuleHeaderMatchTypeRegularExpression matches the header value with a regular expression.
+	// This is not supported yet.
+	AIGatewayRouteRuleHeaderMatchTypeRegularExpression AIGatewayRouteRuleHeaderMatchType = "RegularExpression"
+	// AIGatewayRouteRuleHeaderMatchTypePrefix matches the header value by the prefix.
+	AIGatewayRouteRuleHeaderMatchTypePrefix AIGatewayRouteRuleHeaderMatchType = "Prefix"
+)
+
+// +kubebuilder:validation:XValidation:rule="!has(self.sharedDeployment) || !self.sharedDeployment || !has(self.externalProcessor) || ((!has(self.externalProcessor.deploymentMode) || self.externalProcessor.deploymentMode != 'Sidecar') && !has(self.externalProcessor.horizontalPodAutoscaler))", message="sharedDeployment cannot be used with the Sidecar deployment mode or horizontalPodAutoscaler"
+// +kubebuilder:validation:XValidation:rule="!has(self.sharedDeployment) || !self.sharedDeployment || !has(self.externalProcessor) || !has(self.externalProcessor.podDisruptionBudget)", message="sharedDeployment cannot be used with podDisruptionBudget"
 type AIGatewayFilterConfig struct {
 	// Type specifies the type of the filter configuration.
 	//
@@ -261,8 +845,53 @@ type AIGatewayFilterConfig struct {
 	//
 	// +optional
 	ExternalProcessor *AIGatewayFilterConfigExternalProcessor `json:"externalProcessor,omitempty"`
+
+	// FailureMode specifies how the requests are handled when the filter is unavailable, for example, when
+	// the external processor Deployment is down. Defaults to "FailClosed".
+	//
+	// In the "FailClosed" mode, the requests fail with an error.
+	//
+	// In the "FailOpen" mode, the requests pass through the filter untranslated and are routed to the default
+	// backend by the catch-all rule of the generated HTTPRoute. Since the untranslated requests can only be served
+	// by the backend with the same schema as the clients, the default backend must have the OpenAI schema, and
+	// the AIGatewayRoute is rejected by the controller otherwise. When DisableDefaultRoute is true, the requests
+	// have no backend to be routed to and still fail. Note that the requests are neither authenticated against
+	// the backend nor subject to the token usage based rate limiting in this case.
+	//
+	// +kubebuilder:validation:Enum=FailClosed;FailOpen
+	// +optional
+	FailureMode AIGatewayFilterConfigFailureMode `json:"failureMode,omitempty"`
+
+	// SharedDeployment, when true, runs the external processor of this AIGatewayRoute in the Deployment shared by all
+	// the AIGatewayRoutes in the same namespace that set this, instead of a dedicated Deployment per AIGatewayRoute.
+	// This saves resources when a namespace has many small AIGatewayRoutes.
+	//
+	// The configuration of the shared external processor is the merge of the ones of the participating AIGatewayRoutes:
+	// the rules are concatenated in the order of the names of the AIGatewayRoutes, and so are the LLMRequestCosts where
+	// the first one wins for the same metadata key. The settings that apply to the whole external processor, i.e.,
+	// the ExternalProcessor Deployment configuration, the ModelHeaderName, the MetadataNamespace, the ModelPolicy
+	// and the StateStore, must be the same as the ones of the first AIGatewayRoute in the name order. Otherwise, the
+	// AIGatewayRoute is not included in the shared external processor and the error is reported as an event of it.
+	// The dedicated Deployment of the AIGatewayRoute is deleted when this is set. The EnvoyExtensionPolicy is still
+	// created per AIGatewayRoute, and refers to the shared Service. Deleting an AIGatewayRoute only removes its rules,
+	// and the shared Deployment is deleted when no AIGatewayRoute in the namespace sets this.
+	//
+	// This cannot be used with the Sidecar deployment mode, the HorizontalPodAutoscaler or the PodDisruptionBudget.
+	//
+	// +optional
+	SharedDeployment bool `json:"sharedDeployment,omitempty"`
 }
 
+// AIGatewayFilterConfigFailureMode specifies how the requests are handled when the filter is unavailable.
+type AIGatewayFilterConfigFailureMode string
+
+const (
+	// AIGatewayFilterConfigFailureModeFailClosed fails the requests when the filter is unavailable.
+	AIGatewayFilterConfigFailureModeFailClosed AIGatewayFilterConfigFailureMode = "FailClosed"
+	// AIGatewayFilterConfigFailureModeFailOpen passes the requests through untranslated when the filter is unavailable.
+	AIGatewayFilterConfigFailureModeFailOpen AIGatewayFilterConfigFailureMode = "FailOpen"
+)
+
 // AIGatewayFilterConfigType specifies the type of the filter configuration.
 //
 // +kubebuilder:validation:Enum=ExternalProcessor;DynamicModule
@@ -273,21 +902,157 @@ const (
 	AIGatewayFilterConfigTypeDynamicModule     AIGatewayFilterConfigType = "DynamicModule" // Reserved for https://github.com/envoyproxy/ai-gateway/issues/90
 )
 
+// +kubebuilder:validation:XValidation:rule="!(has(self.replicas) && has(self.horizontalPodAutoscaler))", message="replicas and horizontalPodAutoscaler are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!has(self.deploymentMode) || self.deploymentMode != 'Sidecar' || !(has(self.replicas) || has(self.horizontalPodAutoscaler))", message="replicas and horizontalPodAutoscaler cannot be set in the Sidecar deployment mode"
+// +kubebuilder:validation:XValidation:rule="!has(self.deploymentMode) || self.deploymentMode != 'Sidecar' || !has(self.podDisruptionBudget)", message="podDisruptionBudget cannot be set in the Sidecar deployment mode"
 type AIGatewayFilterConfigExternalProcessor struct {
+	// DeploymentMode specifies how the external processor is deployed. Defaults to "Deployment".
+	//
+	// In the "Deployment" mode, the external processor runs as a standalone Deployment, and Envoy
+	// reaches it via a Service over TCP.
+	//
+	// In the "Sidecar" mode, the external processor runs as a sidecar container of the Envoy proxy pods, and
+	// Envoy reaches it via a unix domain socket shared in an emptyDir volume. The controller generates an EnvoyProxy
+	// resource that has the same name as the external processor (ai-eg-route-extproc-<route name>), and the Gateway
+	// must reference it via spec.infrastructure.parametersRef. Since the sidecar mounts the ConfigMap and the Secrets
+	// in the namespace of the AIGatewayRoute, the Envoy proxy pods must run in the same namespace. The Backend API
+	// of Envoy Gateway must be enabled as the EnvoyExtensionPolicy refers to the socket via a Backend resource.
+	// In this mode, only Resources, ImagePullSecrets, and OTLPEndpoint are applied to the sidecar container.
+	//
+	// +kubebuilder:validation:Enum=Deployment;Sidecar
+	// +optional
+	DeploymentMode AIGatewayFilterConfigExternalProcessorDeploymentMode `json:"deploymentMode,omitempty"`
 	// Replicas is the number of desired pods of the external processor deployment.
+	// This cannot be set together with HorizontalPodAutoscaler.
 	//
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
+	// HorizontalPodAutoscaler configures the HorizontalPodAutoscaler for the external processor deployment.
+	// When this is set, the controller creates the HorizontalPodAutoscaler targeting the deployment and stops
+	// managing the replicas of the deployment. Removing this deletes the HorizontalPodAutoscaler.
+	//
+	// +optional
+	HorizontalPodAutoscaler *AIGatewayFilterConfigExternalProcessorHPA `json:"horizontalPodAutoscaler,omitempty"`
+	// PodDisruptionBudget configures the PodDisruptionBudget for the external processor deployment, so that
+	// the voluntary disruptions such as node drains do not take all the replicas down at once. When this is set,
+	// the controller creates the PodDisruptionBudget selecting the external processor pods. Removing this deletes
+	// the PodDisruptionBudget.
+	//
+	// Note that the PodDisruptionBudget may block the node drains when the deployment runs a single replica, in
+	// which case the ExtProcDisruptionAllowed condition of the AIGatewayRoute is set to False.
+	//
+	// +optional
+	PodDisruptionBudget *AIGatewayFilterConfigExternalProcessorPDB `json:"podDisruptionBudget,omitempty"`
+	// DeploymentStrategy is the strategy used to replace the external processor pods with new ones.
+	//
+	// When not specified, the RollingUpdate strategy with maxUnavailable=0 and maxSurge=1 is used so that the
+	// rollouts never reduce the serving capacity. The same values are used for the parameters of the RollingUpdate
+	// strategy that are not specified.
+	//
+	// +optional
+	DeploymentStrategy *appsv1.DeploymentStrategy `json:"deploymentStrategy,omitempty"`
+	// RevisionHistoryLimit is the number of old ReplicaSets of the external processor deployment to retain for the
+	// rollback. Defaults to 10, which is the default of the Deployment.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 	// Resources required by the external processor container.
 	// More info: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
 	//
+	// When not set, the default resources configured on the controller are used, which are 100m CPU and 128Mi memory
+	// requests and 512Mi memory limit unless overridden.
+	//
 	// +optional
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ImagePullSecrets is the list of references to secrets used to pull the external processor image.
+	// When not specified, the controller-wide default set via the -extProcImagePullSecret flag is used, if any.
+	//
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// NodeSelector is the node selector of the external processor pods.
+	// More info: https://kubernetes.io/docs/concepts/scheduling-eviction/assign-pod-node/
+	//
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are the tolerations of the external processor pods.
+	// More info: https://kubernetes.io/docs/concepts/scheduling-eviction/taint-and-toleration/
+	//
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity is the scheduling constraints of the external processor pods.
+	//
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PodAnnotations are the annotations added to the external processor pods.
+	//
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+	// ServiceAccountName is the name of the ServiceAccount used to run the external processor pods.
+	// When not specified, the default ServiceAccount of the namespace is used.
+	//
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// OTLPEndpoint is the OTLP gRPC endpoint that the external processor exports the tracing spans to,
+	// for example, "otel-collector.monitoring:4317". The span of each request joins the trace of Envoy
+	// via the traceparent header. Tracing is disabled when not specified.
+	//
+	// +optional
+	OTLPEndpoint string `json:"otlpEndpoint,omitempty"`
 	// TODO: maybe adding the option not to deploy the external processor filter and let the user deploy it manually?
 	// 	Not sure if it is worth it as we are migrating to dynamic modules.
 }
 
+// AIGatewayFilterConfigExternalProcessorDeploymentMode specifies how the external processor is deployed.
+type AIGatewayFilterConfigExternalProcessorDeploymentMode string
+
+const (
+	// AIGatewayFilterConfigExternalProcessorDeploymentModeDeployment runs the external processor as a standalone Deployment.
+	AIGatewayFilterConfigExternalProcessorDeploymentModeDeployment AIGatewayFilterConfigExternalProcessorDeploymentMode = "Deployment"
+	// AIGatewayFilterConfigExternalProcessorDeploymentModeSidecar runs the external processor as a sidecar of the Envoy proxy pods.
+	AIGatewayFilterConfigExternalProcessorDeploymentModeSidecar AIGatewayFilterConfigExternalProcessorDeploymentMode = "Sidecar"
+)
+
+// AIGatewayFilterConfigExternalProcessorHPA configures the HorizontalPodAutoscaler of the external processor deployment.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.minReplicas) || self.minReplicas <= self.maxReplicas", message="minReplicas must be less than or equal to maxReplicas"
+type AIGatewayFilterConfigExternalProcessorHPA struct {
+	// MinReplicas is the lower limit for the number of replicas. Defaults to 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit for the number of replicas.
+	//
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// Metrics contains the specifications for which to use to calculate the desired replica count.
+	// When not specified, the default of the HorizontalPodAutoscaler, which is 80% average CPU utilization, is used.
+	// More info: https://kubernetes.io/docs/tasks/run-application/horizontal-pod-autoscale/
+	//
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+}
+
+// AIGatewayFilterConfigExternalProcessorPDB configures the PodDisruptionBudget of the external processor deployment.
+// Exactly one of MinAvailable and MaxUnavailable must be set.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.minAvailable) != has(self.maxUnavailable)", message="exactly one of minAvailable and maxUnavailable must be set"
+type AIGatewayFilterConfigExternalProcessorPDB struct {
+	// MinAvailable is the number or the percentage of the external processor pods that must be available after
+	// the eviction.
+	//
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or the percentage of the external processor pods that can be unavailable after
+	// the eviction.
+	//
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // AIServiceBackend is a resource that represents a single backend for AIGatewayRoute.
 // A backend is a service that handles traffic with a concrete API specification.
@@ -302,8 +1067,35 @@ type AIServiceBackend struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// Spec defines the details of AIServiceBackend.
 	Spec AIServiceBackendSpec `json:"spec,omitempty"`
+	// Status defines the status details of the AIServiceBackend.
+	Status AIServiceBackendStatus `json:"status,omitempty"`
+}
+
+// AIServiceBackendStatus contains the conditions by the reconciliation result.
+type AIServiceBackendStatus struct {
+	// Conditions is the list of conditions by the reconciliation result. The known condition types are:
+	//
+	//   - "ResolvedRefs", which is set to False with the reason "InvalidKind" when the BackendRef is neither a
+	//     Service nor a Backend of Envoy Gateway, or with the reason "BackendNotFound" when the referenced object
+	//     does not exist.
+	//   - "PortDefaulted", which is set to True when the BackendRef references a Backend of Envoy Gateway with the
+	//     FQDN endpoints without the port, and the port is defaulted to 443.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// AIServiceBackendConditionPortDefaulted is the condition type of the AIServiceBackend that indicates whether the
+	// port of the BackendRef referencing a Backend of Envoy Gateway with the FQDN endpoints is defaulted to 443.
+	AIServiceBackendConditionPortDefaulted = "PortDefaulted"
+	// AIServiceBackendReasonFQDNBackend is the reason of the PortDefaulted condition when the port is defaulted.
+	AIServiceBackendReasonFQDNBackend = "FQDNBackend"
+)
+
 // +kubebuilder:object:root=true
 
 // AIServiceBackendList contains a list of AIServiceBackends.
@@ -314,6 +1106,12 @@ type AIServiceBackendList struct {
 }
 
 // AIServiceBackendSpec details the AIServiceBackend configuration.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.guardrailConfig) || self.schema.name == 'AWSBedrock'", message="guardrailConfig is only supported for the AWSBedrock schema"
+// +kubebuilder:validation:XValidation:rule="!has(self.openAI) || self.schema.name == 'OpenAI'", message="openAI is only supported for the OpenAI schema"
+// +kubebuilder:validation:XValidation:rule="!has(self.awsBedrock) || self.schema.name == 'AWSBedrock'", message="awsBedrock is only supported for the AWSBedrock schema"
+// +kubebuilder:validation:XValidation:rule="!has(self.parameterNormalization) || self.schema.name == 'AWSBedrock'", message="parameterNormalization is only supported for the AWSBedrock schema"
+// +kubebuilder:validation:XValidation:rule="!has(self.pathOverride) || self.schema.name != 'AWSBedrock' || self.pathOverride.contains('{model}')", message="pathOverride must contain {model} for the AWSBedrock schema"
 type AIServiceBackendSpec struct {
 	// APISchema specifies the API schema of the output format of requests from
 	// Envoy that this AIServiceBackend can accept as incoming requests.
@@ -326,7 +1124,8 @@ type AIServiceBackendSpec struct {
 	APISchema VersionedAPISchema `json:"schema"`
 	// BackendRef is the reference to the Backend resource that this AIServiceBackend corresponds to.
 	//
-	// A backend can be of either k8s Service or Backend resource of Envoy Gateway.
+	// A backend can be of either k8s Service or Backend resource of Envoy Gateway. When the port is not set for
+	// the Backend resource with the FQDN endpoints, it defaults to 443.
 	//
 	// This is required to be set.
 	//
@@ -339,10 +1138,351 @@ type AIServiceBackendSpec struct {
 	// +optional
 	BackendSecurityPolicyRef *gwapiv1.LocalObjectReference `json:"backendSecurityPolicyRef,omitempty"`
 
+	// GuardrailConfig is the AWS Bedrock guardrail configuration that is applied to every request
+	// sent to this backend. This is only valid when the APISchema is AWSBedrock.
+	//
+	// When the guardrail intervenes, the finish_reason of the OpenAI response is set to "content_filter".
+	//
+	// +optional
+	GuardrailConfig *AWSBedrockGuardrailConfig `json:"guardrailConfig,omitempty"`
+
+	// OpenAI is the OpenAI specific configuration of this backend. This is only valid when the APISchema is OpenAI.
+	//
+	// +optional
+	OpenAI *AIServiceBackendOpenAIConfig `json:"openAI,omitempty"`
+
+	// AWSBedrock is the AWS Bedrock specific configuration of this backend. This is only valid when the APISchema is
+	// AWSBedrock.
+	//
+	// +optional
+	AWSBedrock *AIServiceBackendAWSBedrockConfig `json:"awsBedrock,omitempty"`
+
+	// UnsupportedFieldPolicy specifies how the fields of the OpenAI requests that this backend does not support are
+	// handled, for example, "logit_bias", "seed", "frequency_penalty", "presence_penalty", "logprobs" and
+	// "top_logprobs" for the AWSBedrock schema, and "logit_bias", "logprobs", "top_logprobs", "user" and "metadata"
+	// for the Mistral schema.
+	// Defaults to "Ignore".
+	//
+	// In the "Ignore" mode, the fields are silently dropped. In the "Warn" mode, the fields are dropped and listed
+	// in the "x-ai-eg-dropped-params" response header. In the "Reject" mode, the request is rejected with
+	// 400 Bad Request listing the fields.
+	//
+	// This currently only takes effect for the AWSBedrock and Mistral schemas. With the InvokeModel API of
+	// the AWSBedrock schema, only "logprobs" and "top_logprobs" are treated as unsupported.
+	//
+	// +kubebuilder:validation:Enum=Ignore;Warn;Reject
+	// +optional
+	UnsupportedFieldPolicy UnsupportedFieldPolicy `json:"unsupportedFieldPolicy,omitempty"`
+
+	// ParameterNormalization rescales the temperature and the top_p of the OpenAI requests to the ranges of the
+	// models of this backend. For example, OpenAI accepts the temperature from 0 to 2 while the Amazon Titan and the
+	// Anthropic Claude models on AWS Bedrock accept it from 0 to 1, so the same prompt behaves very differently
+	// after the translation without the normalization. This is only valid when the APISchema is AWSBedrock.
+	//
+	// +optional
+	ParameterNormalization *ParameterNormalization `json:"parameterNormalization,omitempty"`
+
+	// HeaderModifications adds, sets, or removes the request headers of the requests sent to this backend,
+	// for example, to set the provider-specific headers like "anthropic-beta" or to strip the internal headers.
+	//
+	// The values of the added or set headers can contain the following substitutions:
+	//   - ${model}: the model name of the request.
+	//   - ${backend}: the name of the selected backend, in the format of "<name>.<namespace>".
+	//
+	// The headers set by the BackendSecurityPolicy, such as "Authorization", cannot be modified.
+	//
+	// +optional
+	HeaderModifications *gwapiv1.HTTPHeaderFilter `json:"headerModifications,omitempty"`
+
+	// TrafficPolicy configures the circuit breakers and the outlier detection of the Envoy cluster of this backend.
+	//
+	// When not set, the defaults tuned for the long-lived LLM requests are used. See AIServiceBackendTrafficPolicy
+	// for the details.
+	//
+	// +optional
+	TrafficPolicy *AIServiceBackendTrafficPolicy `json:"trafficPolicy,omitempty"`
+
+	// PathOverride is the path of the requests sent to this backend, replacing the default path of the APISchema,
+	// for example, "/serving/v2/chat/completions" for an OpenAI compatible API exposed on a nonstandard path.
+	// For the AWSBedrock schema, this replaces the "/model/{model}/converse" path and must contain "{model}".
+	//
+	// The "{model}" in the path is replaced with the escaped model name of the request. The same path is used for
+	// the streaming requests, except that for the AWSBedrock schema the operation at the end of the path, "converse"
+	// or "invoke", is replaced with its streaming counterpart, "converse-stream" or "invoke-with-response-stream".
+	//
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^/[^?#\s]*$`
+	// +optional
+	PathOverride string `json:"pathOverride,omitempty"`
+
+	// HostnameRewrite specifies whether the Host header of the requests sent to this backend is rewritten to the
+	// hostname of the backend via the `ai-eg-host-rewrite` HTTPRouteFilter. Defaults to "Enabled".
+	//
+	// Set this to "Disabled" for the backends that validate the Host header against their own virtual host,
+	// for example, in-cluster services that expect the Host header of the original request.
+	//
+	// +kubebuilder:validation:Enum=Enabled;Disabled
+	// +kubebuilder:default=Enabled
+	// +optional
+	HostnameRewrite HostnameRewrite `json:"hostnameRewrite,omitempty"`
+
 	// TODO: maybe add backend-level LLMRequestCost configuration that overrides the AIGatewayRoute-level LLMRequestCost.
 	// 	That may be useful for the backend that has a different cost calculation logic.
 }
 
+// AIServiceBackendTrafficPolicy configures the cluster-level traffic management of the AIServiceBackend.
+type AIServiceBackendTrafficPolicy struct {
+	// CircuitBreaker configures the circuit breakers of the cluster.
+	//
+	// When not set, the maximum number of connections, pending requests, and parallel requests are all 4096,
+	// which is higher than the Envoy default of 1024 since the LLM requests, notably the streaming ones,
+	// are long-lived and hold the connections and requests much longer than the typical HTTP requests.
+	//
+	// maxRequestsPerConnection is not supported since it is not a circuit breaker threshold of the cluster.
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="!has(self.maxRequestsPerConnection)",message="maxRequestsPerConnection is not supported"
+	CircuitBreaker *egv1a1.CircuitBreaker `json:"circuitBreaker,omitempty"`
+
+	// OutlierDetection configures the outlier detection of the cluster, i.e. ejecting the endpoints
+	// returning the consecutive errors from the load balancing.
+	//
+	// When not set, the endpoint is ejected for 30s after 5 consecutive 5xx errors, evaluated every 10s,
+	// and up to 50% of the endpoints can be ejected at the same time.
+	//
+	// +optional
+	OutlierDetection *egv1a1.PassiveHealthCheck `json:"outlierDetection,omitempty"`
+
+	// HealthCheck configures the active health checking of the cluster, i.e. sending the HTTP requests to the
+	// endpoints periodically and excluding the unhealthy ones from the load balancing until they recover.
+	//
+	// When not set, the active health checking is disabled.
+	//
+	// +optional
+	HealthCheck *AIServiceBackendHealthCheck `json:"healthCheck,omitempty"`
+}
+
+// AIServiceBackendHealthCheck configures the active health checking of the AIServiceBackend.
+//
+// The health check requests are sent by Envoy directly, and do not go through the AI Gateway filter. Hence, they do
+// not carry the credentials of the BackendSecurityPolicy. For the endpoints requiring the authentication, include
+// the status code returned for the unauthenticated requests, such as 401, in ExpectedStatuses so that the DNS
+// and the connection failures as well as the 5xx responses are still detected.
+type AIServiceBackendHealthCheck struct {
+	// Path is the path of the health check requests. Defaults to "/".
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:default="/"
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Hostname is the value of the Host header of the health check requests. Defaults to the name of the cluster.
+	//
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+	// Interval is the interval between the health check requests. Defaults to 10s.
+	//
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:default="10s"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// Timeout is the timeout of the health check request. Defaults to 1s.
+	//
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:default="1s"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// HealthyThreshold is the number of the consecutive successful health checks required before an unhealthy
+	// endpoint is marked healthy. Defaults to 1.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	HealthyThreshold *uint32 `json:"healthyThreshold,omitempty"`
+	// UnhealthyThreshold is the number of the consecutive failed health checks required before a healthy endpoint
+	// is marked unhealthy. Defaults to 3.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	// +optional
+	UnhealthyThreshold *uint32 `json:"unhealthyThreshold,omitempty"`
+	// ExpectedStatuses is the list of the status codes of the healthy responses. Defaults to [200].
+	//
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:Minimum=100
+	// +kubebuilder:validation:items:Maximum=599
+	// +optional
+	ExpectedStatuses []int32 `json:"expectedStatuses,omitempty"`
+}
+
+// AWSBedrockGuardrailConfig specifies the guardrail to apply to the AWS Bedrock Converse API requests.
+// See https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_GuardrailConfiguration.html
+type AWSBedrockGuardrailConfig struct {
+	// Identifier is the identifier of the guardrail, either the ID or the ARN.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Identifier string `json:"identifier"`
+	// Version is the version of the guardrail, e.g. "1" or "DRAFT".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+	// Trace specifies the trace behavior of the guardrail. Defaults to disabled on the AWS side when unset.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=enabled;disabled;enabled_full
+	Trace *string `json:"trace,omitempty"`
+}
+
+// UnsupportedFieldPolicy specifies how the request fields not supported by the backend are handled.
+type UnsupportedFieldPolicy string
+
+const (
+	// UnsupportedFieldPolicyIgnore silently drops the unsupported fields.
+	UnsupportedFieldPolicyIgnore UnsupportedFieldPolicy = "Ignore"
+	// UnsupportedFieldPolicyWarn drops the unsupported fields and lists them in the response header.
+	UnsupportedFieldPolicyWarn UnsupportedFieldPolicy = "Warn"
+	// UnsupportedFieldPolicyReject rejects the request setting the unsupported fields.
+	UnsupportedFieldPolicyReject UnsupportedFieldPolicy = "Reject"
+)
+
+// HostnameRewrite specifies whether the Host header is rewritten to the hostname of the backend.
+type HostnameRewrite string
+
+const (
+	// HostnameRewriteEnabled rewrites the Host header to the hostname of the backend.
+	HostnameRewriteEnabled HostnameRewrite = "Enabled"
+	// HostnameRewriteDisabled keeps the Host header of the original request.
+	HostnameRewriteDisabled HostnameRewrite = "Disabled"
+)
+
+// AIServiceBackendOpenAIConfig specifies the OpenAI specific configuration of the AIServiceBackend.
+type AIServiceBackendOpenAIConfig struct {
+	// Organization is the OpenAI organization ID set to the "OpenAI-Organization" header of the requests sent to
+	// this backend, which determines the organization the usage is billed to.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Organization string `json:"organization,omitempty"`
+	// Project is the OpenAI project ID set to the "OpenAI-Project" header of the requests sent to this backend,
+	// which determines the project the usage is billed to.
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	Project string `json:"project,omitempty"`
+}
+
+// AIServiceBackendAWSBedrockConfig specifies the AWS Bedrock specific configuration of the AIServiceBackend.
+type AIServiceBackendAWSBedrockConfig struct {
+	// BedrockAPI is the AWS Bedrock API that the requests are translated to. Defaults to "Converse".
+	//
+	// "InvokeModel" is for the models not supported by the Converse API, such as the older Amazon Titan Text
+	// models. The request and response bodies are translated to the model-specific formats of the InvokeModel API,
+	// and currently the Anthropic Claude and the Amazon Titan Text models are supported. The streaming requests are
+	// sent to the InvokeModelWithResponseStream API.
+	//
+	// +kubebuilder:validation:Enum=Converse;InvokeModel
+	// +kubebuilder:default=Converse
+	// +optional
+	BedrockAPI AWSBedrockAPI `json:"bedrockAPI,omitempty"`
+
+	// ModelPrefix is prepended to the model ID of the requests sent to this backend, unless the model ID already
+	// starts with it or is an ARN. For example, "us." sends the requests for "anthropic.claude-3-5-sonnet-20240620-v1:0"
+	// to the US cross-region inference profile "us.anthropic.claude-3-5-sonnet-20240620-v1:0".
+	//
+	// The model ID, including the ARNs of the models and the inference profiles, is URL-escaped in the request path.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=64
+	ModelPrefix string `json:"modelPrefix,omitempty"`
+
+	// ModelFamilies extends the built-in table of the model families of which the models need special handling in
+	// the translation of the Converse API. For example, the Amazon Titan Text models are known to reject the system
+	// messages, so the system and developer messages are folded into the first user message for them.
+	//
+	// These are looked up in order before the built-in ones, and the first one of which the ModelPrefix matches the
+	// model ID wins. This can also override the built-in model families.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	ModelFamilies []AWSBedrockModelFamily `json:"modelFamilies,omitempty"`
+}
+
+// AWSBedrockModelFamily specifies the capabilities of the AWS Bedrock models of which the model IDs start with the
+// ModelPrefix.
+type AWSBedrockModelFamily struct {
+	// ModelPrefix is the prefix of the model IDs of the family, e.g. "amazon.titan-text". The region prefix of the
+	// cross-region inference profile, such as "us.", is not part of the model ID matched against it, and neither are
+	// the ARN parts other than the model ID. The empty prefix matches all the models.
+	//
+	// +kubebuilder:validation:MaxLength=128
+	ModelPrefix string `json:"modelPrefix"`
+
+	// NoSystemMessages is true when the models of the family reject the system content blocks of the Converse API.
+	// The system and developer messages are then folded into the beginning of the first user message, separated from
+	// its content by a "---" line, instead of being sent as the system content blocks.
+	//
+	// +optional
+	NoSystemMessages bool `json:"noSystemMessages,omitempty"`
+}
+
+// ParameterNormalization specifies how the sampling parameters of the requests are rescaled to the ranges of the
+// models of the backend.
+//
+// The rescaled values are clamped to the valid range of the target model when the model family is known, that is,
+// the Amazon Titan, Amazon Nova, Anthropic Claude, Meta Llama and Mistral models. The values of the other models are
+// only rescaled in the "Explicit" mode, and left as-is in the "Auto" mode.
+//
+// +kubebuilder:validation:XValidation:rule="self.mode == 'Explicit' || (!has(self.temperatureScale) && !has(self.topPScale))", message="temperatureScale and topPScale can only be set in the Explicit mode"
+type ParameterNormalization struct {
+	// Mode is the mode of the normalization.
+	//
+	// In the "Auto" mode, the scale factors are derived from the model family of the request, e.g. the temperature
+	// is halved for the models accepting it from 0 to 1. In the "Explicit" mode, the values are multiplied by the
+	// TemperatureScale and the TopPScale.
+	//
+	// +kubebuilder:validation:Enum=Auto;Explicit
+	// +kubebuilder:validation:Required
+	Mode ParameterNormalizationMode `json:"mode"`
+	// TemperatureScale is the factor multiplied to the temperature in the "Explicit" mode, e.g. "0.5".
+	// The temperature is not rescaled when unset.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	TemperatureScale *string `json:"temperatureScale,omitempty"`
+	// TopPScale is the factor multiplied to the top_p in the "Explicit" mode, e.g. "0.5".
+	// The top_p is not rescaled when unset.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	TopPScale *string `json:"topPScale,omitempty"`
+	// Debug records the adjusted parameters in the "x-ai-eg-normalized-params" response header, for example,
+	// "temperature=1.6->0.8".
+	//
+	// +optional
+	Debug bool `json:"debug,omitempty"`
+}
+
+// ParameterNormalizationMode is the mode of the ParameterNormalization.
+type ParameterNormalizationMode string
+
+const (
+	// ParameterNormalizationModeAuto derives the scale factors from the model family of the request.
+	ParameterNormalizationModeAuto ParameterNormalizationMode = "Auto"
+	// ParameterNormalizationModeExplicit uses the scale factors specified in the ParameterNormalization.
+	ParameterNormalizationModeExplicit ParameterNormalizationMode = "Explicit"
+)
+
+// AWSBedrockAPI is the AWS Bedrock API that the requests are translated to.
+type AWSBedrockAPI string
+
+const (
+	// AWSBedrockAPIConverse is the Converse API.
+	AWSBedrockAPIConverse AWSBedrockAPI = "Converse"
+	// AWSBedrockAPIInvokeModel is the InvokeModel API.
+	AWSBedrockAPIInvokeModel AWSBedrockAPI = "InvokeModel"
+)
+
 // VersionedAPISchema defines the API schema of either AIGatewayRoute (the input) or AIServiceBackend (the output).
 //
 // This allows the ai-gateway to understand the input and perform the necessary transformation
@@ -353,7 +1493,7 @@ type AIServiceBackendSpec struct {
 type VersionedAPISchema struct {
 	// Name is the name of the API schema of the AIGatewayRoute or AIServiceBackend.
 	//
-	// +kubebuilder:validation:Enum=OpenAI;AWSBedrock
+	// +kubebuilder:validation:Enum=OpenAI;AWSBedrock;Cohere;Mistral
 	Name APISchema `json:"name"`
 
 	// Version is the version of the API schema.
@@ -372,6 +1512,15 @@ const (
 	//
 	// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_Operations_Amazon_Bedrock_Runtime.html
 	APISchemaAWSBedrock APISchema = "AWSBedrock"
+	// APISchemaCohere is the Cohere schema.
+	//
+	// https://docs.cohere.com/v1/reference/chat
+	APISchemaCohere APISchema = "Cohere"
+	// APISchemaMistral is the Mistral "La Plateforme" schema, which is compatible with OpenAI except for
+	// a few request fields and the error response.
+	//
+	// https://docs.mistral.ai/api/#tag/chat
+	APISchemaMistral APISchema = "Mistral"
 )
 
 const (
@@ -384,11 +1533,23 @@ const (
 type BackendSecurityPolicyType string
 
 const (
-	BackendSecurityPolicyTypeAPIKey         BackendSecurityPolicyType = "APIKey"
-	BackendSecurityPolicyTypeAWSCredentials BackendSecurityPolicyType = "AWSCredentials"
+	BackendSecurityPolicyTypeAPIKey           BackendSecurityPolicyType = "APIKey"
+	BackendSecurityPolicyTypeAWSCredentials   BackendSecurityPolicyType = "AWSCredentials"
+	BackendSecurityPolicyTypeAzureCredentials BackendSecurityPolicyType = "AzureCredentials"
+)
+
+const (
+	// BackendSecurityPolicyConditionCredentialsRotated is the condition type of the BackendSecurityPolicy that
+	// indicates whether the temporary credentials obtained by the controller, such as the AWS credentials exchanged
+	// from the OIDC token or the Azure access token, have been rotated successfully.
+	BackendSecurityPolicyConditionCredentialsRotated = "CredentialsRotated"
+	// BackendSecurityPolicyConditionResolvedRefs is the condition type of the BackendSecurityPolicy that is set
+	// to False when a secret in another namespace is referenced without the ReferenceGrant permitting it.
+	BackendSecurityPolicyConditionResolvedRefs = "ResolvedRefs"
 )
 
 // +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
 
 // BackendSecurityPolicy specifies configuration for authentication and authorization rules on the traffic
 // exiting the gateway to the backend.
@@ -396,6 +1557,23 @@ type BackendSecurityPolicy struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	Spec              BackendSecurityPolicySpec `json:"spec,omitempty"`
+	// Status defines the status details of the BackendSecurityPolicy.
+	Status BackendSecurityPolicyStatus `json:"status,omitempty"`
+}
+
+// BackendSecurityPolicyStatus contains the conditions by the reconciliation result.
+type BackendSecurityPolicyStatus struct {
+	// Conditions is the list of conditions by the reconciliation result. The known condition types are:
+	//
+	//   - "CredentialsRotated", which is set for the policies whose credentials are rotated by the controller.
+	//   - "ResolvedRefs", which is set to False with the reason "RefNotPermitted" when a secret in another namespace
+	//     is referenced without the ReferenceGrant permitting it. The secret is not used in that case.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:MaxItems=8
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // BackendSecurityPolicySpec specifies authentication rules on access the provider from the Gateway.
@@ -404,12 +1582,14 @@ type BackendSecurityPolicy struct {
 // Only one type of BackendSecurityPolicy can be defined.
 // +kubebuilder:validation:MaxProperties=2
 type BackendSecurityPolicySpec struct {
-	// Type specifies the auth mechanism used to access the provider. Currently, only "APIKey", AND "AWSCredentials" are supported.
+	// Type specifies the auth mechanism used to access the provider. Currently, only "APIKey", "AWSCredentials",
+	// and "AzureCredentials" are supported.
 	//
-	// +kubebuilder:validation:Enum=APIKey;AWSCredentials
+	// +kubebuilder:validation:Enum=APIKey;AWSCredentials;AzureCredentials
 	Type BackendSecurityPolicyType `json:"type"`
 
-	// APIKey is a mechanism to access a backend(s). The API key will be injected into the Authorization header.
+	// APIKey is a mechanism to access a backend(s). The API key will be injected into the Authorization header
+	// unless the header is customized by BackendSecurityPolicyAPIKey.HeaderName.
 	//
 	// +optional
 	APIKey *BackendSecurityPolicyAPIKey `json:"apiKey,omitempty"`
@@ -418,6 +1598,13 @@ type BackendSecurityPolicySpec struct {
 	//
 	// +optional
 	AWSCredentials *BackendSecurityPolicyAWSCredentials `json:"awsCredentials,omitempty"`
+
+	// AzureCredentials is a mechanism to access a backend(s) such as Azure OpenAI with the Microsoft Entra ID
+	// access token. The controller obtains the token with the client credentials flow and keeps it refreshed,
+	// and the token will be injected into the Authorization header.
+	//
+	// +optional
+	AzureCredentials *BackendSecurityPolicyAzureCredentials `json:"azureCredentials,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -433,8 +1620,48 @@ type BackendSecurityPolicyList struct {
 type BackendSecurityPolicyAPIKey struct {
 	// SecretRef is the reference to the secret containing the API key.
 	// ai-gateway must be given the permission to read this secret.
-	// The key of the secret should be "apiKey".
+	// The key of the secret should be "apiKey", unless Keys is set.
+	//
+	// The secret in another namespace can only be referenced when a ReferenceGrant in that namespace permits
+	// the BackendSecurityPolicy in the namespace of the policy to refer to the secret.
 	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
+
+	// Keys enables the rotation among multiple API keys in the secret, for example, to spread the load across
+	// the API keys with their own rate limits. When set, the keys of the secret should be "apiKey-0", "apiKey-1",
+	// and so on instead of "apiKey", and each request uses one of them picked by the weighted round-robin.
+	//
+	// +optional
+	Keys *BackendSecurityPolicyAPIKeys `json:"keys,omitempty"`
+
+	// HeaderName is the name of the request header carrying the API key, for example, "x-api-key" for the
+	// self-hosted OpenAI-compatible servers not accepting the "Authorization" header. When set to other than
+	// "Authorization", the "Authorization" header sent by the client is removed. Defaults to "Authorization".
+	//
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`
+	HeaderName *string `json:"headerName,omitempty"`
+
+	// ValueTemplate is the value of the header where "{key}" is replaced with the API key, for example,
+	// "{key}" to send the raw API key. Defaults to "Bearer {key}".
+	//
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self.contains('{key}')", message="valueTemplate must contain {key}"
+	ValueTemplate *string `json:"valueTemplate,omitempty"`
+}
+
+// BackendSecurityPolicyAPIKeys configures the rotation among multiple API keys.
+//
+// An API key that the backend has just rejected with the status code 401 or 429 is temporarily skipped
+// as long as the other API keys are available.
+type BackendSecurityPolicyAPIKeys struct {
+	// Weights are the weights of the API keys in the order of their indexes, i.e. the first weight is
+	// of "apiKey-0". The API keys without a weight have the weight of 1.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	// +kubebuilder:validation:items:Minimum=1
+	Weights []int32 `json:"weights,omitempty"`
 }
 
 // BackendSecurityPolicyAWSCredentials contains the supported authentication mechanisms to access aws
@@ -461,7 +1688,9 @@ type BackendSecurityPolicyAWSCredentials struct {
 type AWSCredentialsFile struct {
 	// SecretRef is the reference to the credential file.
 	//
-	// The secret should contain the AWS credentials file keyed on "credentials".
+	// The secret should contain the AWS credentials file keyed on "credentials". The secret in another namespace
+	// can only be referenced when a ReferenceGrant in that namespace permits the BackendSecurityPolicy in the
+	// namespace of the policy to refer to the secret.
 	SecretRef *gwapiv1.SecretObjectReference `json:"secretRef"`
 
 	// Profile is the profile to use in the credentials file.
@@ -497,7 +1726,53 @@ type AWSOIDCExchangeToken struct {
 	AwsRoleArn string `json:"awsRoleArn"`
 }
 
+// BackendSecurityPolicyAzureCredentials contains the Microsoft Entra ID client credentials to obtain the access token.
+// Exactly one of ClientSecretRef and OIDCExchangeToken must be specified.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.clientSecretRef) != has(self.oidcExchangeToken)", message="exactly one of clientSecretRef or oidcExchangeToken must be specified"
+type BackendSecurityPolicyAzureCredentials struct {
+	// TenantID is the ID of the Microsoft Entra ID tenant of the application.
+	//
+	// +kubebuilder:validation:MinLength=1
+	TenantID string `json:"tenantID"`
+
+	// ClientID is the application (client) ID registered in the tenant.
+	//
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// Scope is the scope of the access token.
+	//
+	// +kubebuilder:default="https://cognitiveservices.azure.com/.default"
+	// +optional
+	Scope string `json:"scope,omitempty"`
+
+	// ClientSecretRef is the reference to the secret containing the client secret of the application.
+	// ai-gateway must be given the permission to read this secret.
+	// The key of the secret should be "clientSecret".
+	//
+	// +optional
+	ClientSecretRef *gwapiv1.SecretObjectReference `json:"clientSecretRef,omitempty"`
+
+	// OIDCExchangeToken specifies the oidc configurations used to obtain an oidc token. The oidc token will be
+	// used as the client assertion of the federated identity credential of the application instead of the client secret.
+	//
+	// +optional
+	OIDCExchangeToken *AzureOIDCExchangeToken `json:"oidcExchangeToken,omitempty"`
+}
+
+// AzureOIDCExchangeToken specifies credentials to obtain oidc token from a sso server.
+// For Azure, the controller will exchange the oidc token for the Microsoft Entra ID access token.
+type AzureOIDCExchangeToken struct {
+	// OIDC is used to obtain oidc tokens via an SSO server which will be used as the client assertion.
+	//
+	// +kubebuilder:validation:Required
+	OIDC egv1a1.OIDC `json:"oidc"`
+}
+
 // LLMRequestCost configures each request cost.
+//
+// +kubebuilder:validation:XValidation:rule="self.type != 'ModelPriceTable' || has(self.modelPriceTable)", message="modelPriceTable must be set for the ModelPriceTable type"
 type LLMRequestCost struct {
 	// MetadataKey is the key of the metadata to store this cost of the request.
 	//
@@ -505,9 +1780,9 @@ type LLMRequestCost struct {
 	MetadataKey string `json:"metadataKey"`
 	// Type specifies the type of the request cost. The default is "OutputToken",
 	// and it uses "output token" as the cost. The other types are "InputToken", "TotalToken",
-	// and "CEL".
+	// "CEL" and "ModelPriceTable".
 	//
-	// +kubebuilder:validation:Enum=OutputToken;InputToken;TotalToken;CEL
+	// +kubebuilder:validation:Enum=OutputToken;InputToken;TotalToken;CEL;ModelPriceTable
 	Type LLMRequestCostType `json:"type"`
 	// CEL is the CEL expression to calculate the cost of the request.
 	// The CEL expression must return a signed or unsigned integer. If the
@@ -515,11 +1790,12 @@ type LLMRequestCost struct {
 	//
 	// The expression can use the following variables:
 	//
-	//	* model: the model name extracted from the request content. Type: string.
-	//	* backend: the backend name in the form of "name.namespace". Type: string.
+	//	* model: the model name extracted from the request content, or "unknown" if it is not known. Type: string.
+	//	* backend: the backend name in the form of "name.namespace", or "unknown" if no backend is selected. Type: string.
 	//	* input_tokens: the number of input tokens. Type: unsigned integer.
 	//	* output_tokens: the number of output tokens. Type: unsigned integer.
 	//	* total_tokens: the total number of tokens. Type: unsigned integer.
+	//	* user: the "user" field of the request, or empty if it is not set. Type: string.
 	//
 	// For example, the following expressions are valid:
 	//
@@ -527,9 +1803,66 @@ type LLMRequestCost struct {
 	//	* "backend == 'foo.default' ?  input_tokens + output_tokens : total_tokens"
 	//	* "input_tokens + output_tokens + total_tokens"
 	//	* "input_tokens * output_tokens"
+	//	* "user == 'free-tier' ? total_tokens * uint(2) : total_tokens"
 	//
 	// +optional
 	CEL *string `json:"cel,omitempty"`
+	// ModelPriceTable is the per-model price table to calculate the cost of the request.
+	// This must be set when the Type is "ModelPriceTable".
+	//
+	// +optional
+	ModelPriceTable *LLMRequestCostModelPriceTable `json:"modelPriceTable,omitempty"`
+}
+
+// LLMRequestCostModelPriceTable calculates the request cost from the per-model token prices as
+//
+//	(input_tokens * inputTokenPrice + output_tokens * outputTokenPrice) * multiplier
+//
+// rounded to the nearest integer, since the cost stored in the metadata must be integral.
+// The model is the one extracted from the request content.
+//
+// +kubebuilder:validation:XValidation:rule="!(has(self.strict) && self.strict && has(self.default))", message="default cannot be set in the strict mode"
+type LLMRequestCostModelPriceTable struct {
+	// Prices maps the model names to their prices. A key ending with "*" matches the models by the prefix,
+	// e.g. "gpt-4o*" matches "gpt-4o" and "gpt-4o-mini". The exact match takes precedence over the prefix
+	// matches, and the longest prefix wins among the prefix matches.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinProperties=1
+	// +kubebuilder:validation:MaxProperties=128
+	Prices map[string]LLMRequestCostModelPrice `json:"prices"`
+	// Default is the price of the models that do not match any of the prices.
+	// When unset, the cost of such models is zero unless Strict is true.
+	//
+	// +optional
+	Default *LLMRequestCostModelPrice `json:"default,omitempty"`
+	// Strict fails the cost calculation of the models that do not match any of the prices
+	// instead of falling back to the default.
+	//
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+	// Multiplier scales the cost before rounding it to an integer, e.g. 1000000 to store the cost in
+	// micro-units of the prices. The default is 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Multiplier *int64 `json:"multiplier,omitempty"`
+}
+
+// LLMRequestCostModelPrice specifies the price per token of a model.
+//
+// The floating point values are specified as strings, e.g. "0.15".
+type LLMRequestCostModelPrice struct {
+	// InputTokenPrice is the price per input token.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	InputTokenPrice string `json:"inputTokenPrice"`
+	// OutputTokenPrice is the price per output token.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	OutputTokenPrice string `json:"outputTokenPrice"`
 }
 
 // LLMRequestCostType specifies the type of the LLMRequestCost.
@@ -544,6 +1877,8 @@ const (
 	LLMRequestCostTypeTotalToken LLMRequestCostType = "TotalToken"
 	// LLMRequestCostTypeCEL is for calculating the cost using the CEL expression.
 	LLMRequestCostTypeCEL LLMRequestCostType = "CEL"
+	// LLMRequestCostTypeModelPriceTable is for calculating the cost using the per-model price table.
+	LLMRequestCostTypeModelPriceTable LLMRequestCostType = "ModelPriceTable"
 )
 
 const (
@@ -10,9 +10,14 @@
 package v1alpha1
 
 import (
+	apiv1alpha1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
-	"sigs.k8s.io/gateway-api/apis/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	apisv1 "sigs.k8s.io/gateway-api/apis/v1"
 	"sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
@@ -44,11 +49,62 @@ func (in *AIGatewayFilterConfigExternalProcessor) DeepCopyInto(out *AIGatewayFil
 		*out = new(int32)
 		**out = **in
 	}
+	if in.HorizontalPodAutoscaler != nil {
+		in, out := &in.HorizontalPodAutoscaler, &out.HorizontalPodAutoscaler
+		*out = new(AIGatewayFilterConfigExternalProcessorHPA)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodDisruptionBudget != nil {
+		in, out := &in.PodDisruptionBudget, &out.PodDisruptionBudget
+		*out = new(AIGatewayFilterConfigExternalProcessorPDB)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeploymentStrategy != nil {
+		in, out := &in.DeploymentStrategy, &out.DeploymentStrategy
+		*out = new(appsv1.DeploymentStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = new(corev1.ResourceRequirements)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayFilterConfigExternalProcessor.
@@ -61,12 +117,65 @@ func (in *AIGatewayFilterConfigExternalProcessor) DeepCopy() *AIGatewayFilterCon
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayFilterConfigExternalProcessorHPA) DeepCopyInto(out *AIGatewayFilterConfigExternalProcessorHPA) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]v2.MetricSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayFilterConfigExternalProcessorHPA.
+func (in *AIGatewayFilterConfigExternalProcessorHPA) DeepCopy() *AIGatewayFilterConfigExternalProcessorHPA {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayFilterConfigExternalProcessorHPA)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayFilterConfigExternalProcessorPDB) DeepCopyInto(out *AIGatewayFilterConfigExternalProcessorPDB) {
+	*out = *in
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayFilterConfigExternalProcessorPDB.
+func (in *AIGatewayFilterConfigExternalProcessorPDB) DeepCopy() *AIGatewayFilterConfigExternalProcessorPDB {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayFilterConfigExternalProcessorPDB)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRoute) DeepCopyInto(out *AIGatewayRoute) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRoute.
@@ -87,6 +196,26 @@ func (in *AIGatewayRoute) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteExcludePath) DeepCopyInto(out *AIGatewayRouteExcludePath) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(apisv1.PathMatchType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteExcludePath.
+func (in *AIGatewayRouteExcludePath) DeepCopy() *AIGatewayRouteExcludePath {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteExcludePath)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteList) DeepCopyInto(out *AIGatewayRouteList) {
 	*out = *in
@@ -119,13 +248,75 @@ func (in *AIGatewayRouteList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteModelPolicy) DeepCopyInto(out *AIGatewayRouteModelPolicy) {
+	*out = *in
+	if in.Allow != nil {
+		in, out := &in.Allow, &out.Allow
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deny != nil {
+		in, out := &in.Deny, &out.Deny
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteModelPolicy.
+func (in *AIGatewayRouteModelPolicy) DeepCopy() *AIGatewayRouteModelPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteModelPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRateLimit) DeepCopyInto(out *AIGatewayRouteRateLimit) {
+	*out = *in
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make([]AIGatewayRouteRateLimitRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRateLimit.
+func (in *AIGatewayRouteRateLimit) DeepCopy() *AIGatewayRouteRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRateLimitRule) DeepCopyInto(out *AIGatewayRouteRateLimitRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRateLimitRule.
+func (in *AIGatewayRouteRateLimitRule) DeepCopy() *AIGatewayRouteRateLimitRule {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRateLimitRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
 	*out = *in
 	if in.BackendRefs != nil {
 		in, out := &in.BackendRefs, &out.BackendRefs
 		*out = make([]AIGatewayRouteRuleBackendRef, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Matches != nil {
 		in, out := &in.Matches, &out.Matches
@@ -134,6 +325,36 @@ func (in *AIGatewayRouteRule) DeepCopyInto(out *AIGatewayRouteRule) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SessionAffinity != nil {
+		in, out := &in.SessionAffinity, &out.SessionAffinity
+		*out = new(AIGatewayRouteRuleSessionAffinity)
+		**out = **in
+	}
+	if in.ModelDefaults != nil {
+		in, out := &in.ModelDefaults, &out.ModelDefaults
+		*out = new(AIGatewayRouteRuleModelDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ModelLimits != nil {
+		in, out := &in.ModelLimits, &out.ModelLimits
+		*out = new(AIGatewayRouteRuleModelLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(AIGatewayRouteRuleMirror)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeaderModifications != nil {
+		in, out := &in.HeaderModifications, &out.HeaderModifications
+		*out = new(apisv1.HTTPHeaderFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRule.
@@ -149,6 +370,11 @@ func (in *AIGatewayRouteRule) DeepCopy() *AIGatewayRouteRule {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteRuleBackendRef) DeepCopyInto(out *AIGatewayRouteRuleBackendRef) {
 	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleBackendRef.
@@ -161,12 +387,32 @@ func (in *AIGatewayRouteRuleBackendRef) DeepCopy() *AIGatewayRouteRuleBackendRef
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleHeaderMatch) DeepCopyInto(out *AIGatewayRouteRuleHeaderMatch) {
+	*out = *in
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(AIGatewayRouteRuleHeaderMatchType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleHeaderMatch.
+func (in *AIGatewayRouteRuleHeaderMatch) DeepCopy() *AIGatewayRouteRuleHeaderMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleHeaderMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteRuleMatch) DeepCopyInto(out *AIGatewayRouteRuleMatch) {
 	*out = *in
 	if in.Headers != nil {
 		in, out := &in.Headers, &out.Headers
-		*out = make([]v1.HTTPHeaderMatch, len(*in))
+		*out = make([]AIGatewayRouteRuleHeaderMatch, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -183,6 +429,106 @@ func (in *AIGatewayRouteRuleMatch) DeepCopy() *AIGatewayRouteRuleMatch {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleMirror) DeepCopyInto(out *AIGatewayRouteRuleMirror) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleMirror.
+func (in *AIGatewayRouteRuleMirror) DeepCopy() *AIGatewayRouteRuleMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleModelDefaults) DeepCopyInto(out *AIGatewayRouteRuleModelDefaults) {
+	*out = *in
+	if in.Temperature != nil {
+		in, out := &in.Temperature, &out.Temperature
+		*out = new(string)
+		**out = **in
+	}
+	if in.TopP != nil {
+		in, out := &in.TopP, &out.TopP
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxTokens != nil {
+		in, out := &in.MaxTokens, &out.MaxTokens
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleModelDefaults.
+func (in *AIGatewayRouteRuleModelDefaults) DeepCopy() *AIGatewayRouteRuleModelDefaults {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleModelDefaults)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleModelLimits) DeepCopyInto(out *AIGatewayRouteRuleModelLimits) {
+	*out = *in
+	if in.MaxTemperature != nil {
+		in, out := &in.MaxTemperature, &out.MaxTemperature
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxTopP != nil {
+		in, out := &in.MaxTopP, &out.MaxTopP
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxTokens != nil {
+		in, out := &in.MaxTokens, &out.MaxTokens
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxStopSequences != nil {
+		in, out := &in.MaxStopSequences, &out.MaxStopSequences
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleModelLimits.
+func (in *AIGatewayRouteRuleModelLimits) DeepCopy() *AIGatewayRouteRuleModelLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleModelLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteRuleSessionAffinity) DeepCopyInto(out *AIGatewayRouteRuleSessionAffinity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteRuleSessionAffinity.
+func (in *AIGatewayRouteRuleSessionAffinity) DeepCopy() *AIGatewayRouteRuleSessionAffinity {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteRuleSessionAffinity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
 	*out = *in
@@ -213,6 +559,38 @@ func (in *AIGatewayRouteSpec) DeepCopyInto(out *AIGatewayRouteSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CORS != nil {
+		in, out := &in.CORS, &out.CORS
+		*out = new(apiv1alpha1.CORS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(AIGatewayRouteRateLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StateStore != nil {
+		in, out := &in.StateStore, &out.StateStore
+		*out = new(AIGatewayRouteStateStore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludePaths != nil {
+		in, out := &in.ExcludePaths, &out.ExcludePaths
+		*out = make([]AIGatewayRouteExcludePath, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowedEndpoints != nil {
+		in, out := &in.AllowedEndpoints, &out.AllowedEndpoints
+		*out = make([]AIGatewayRouteEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.ModelPolicy != nil {
+		in, out := &in.ModelPolicy, &out.ModelPolicy
+		*out = new(AIGatewayRouteModelPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteSpec.
@@ -225,12 +603,55 @@ func (in *AIGatewayRouteSpec) DeepCopy() *AIGatewayRouteSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteStateStore) DeepCopyInto(out *AIGatewayRouteStateStore) {
+	*out = *in
+	if in.PasswordSecretRef != nil {
+		in, out := &in.PasswordSecretRef, &out.PasswordSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteStateStore.
+func (in *AIGatewayRouteStateStore) DeepCopy() *AIGatewayRouteStateStore {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteStateStore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIGatewayRouteStatus) DeepCopyInto(out *AIGatewayRouteStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIGatewayRouteStatus.
+func (in *AIGatewayRouteStatus) DeepCopy() *AIGatewayRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AIGatewayRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackend) DeepCopyInto(out *AIServiceBackend) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackend.
@@ -251,6 +672,66 @@ func (in *AIServiceBackend) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendAWSBedrockConfig) DeepCopyInto(out *AIServiceBackendAWSBedrockConfig) {
+	*out = *in
+	if in.ModelFamilies != nil {
+		in, out := &in.ModelFamilies, &out.ModelFamilies
+		*out = make([]AWSBedrockModelFamily, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendAWSBedrockConfig.
+func (in *AIServiceBackendAWSBedrockConfig) DeepCopy() *AIServiceBackendAWSBedrockConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendAWSBedrockConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendHealthCheck) DeepCopyInto(out *AIServiceBackendHealthCheck) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HealthyThreshold != nil {
+		in, out := &in.HealthyThreshold, &out.HealthyThreshold
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.UnhealthyThreshold != nil {
+		in, out := &in.UnhealthyThreshold, &out.UnhealthyThreshold
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.ExpectedStatuses != nil {
+		in, out := &in.ExpectedStatuses, &out.ExpectedStatuses
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendHealthCheck.
+func (in *AIServiceBackendHealthCheck) DeepCopy() *AIServiceBackendHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackendList) DeepCopyInto(out *AIServiceBackendList) {
 	*out = *in
@@ -283,6 +764,21 @@ func (in *AIServiceBackendList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendOpenAIConfig) DeepCopyInto(out *AIServiceBackendOpenAIConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendOpenAIConfig.
+func (in *AIServiceBackendOpenAIConfig) DeepCopy() *AIServiceBackendOpenAIConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendOpenAIConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AIServiceBackendSpec) DeepCopyInto(out *AIServiceBackendSpec) {
 	*out = *in
@@ -290,9 +786,39 @@ func (in *AIServiceBackendSpec) DeepCopyInto(out *AIServiceBackendSpec) {
 	in.BackendRef.DeepCopyInto(&out.BackendRef)
 	if in.BackendSecurityPolicyRef != nil {
 		in, out := &in.BackendSecurityPolicyRef, &out.BackendSecurityPolicyRef
-		*out = new(v1.LocalObjectReference)
+		*out = new(apisv1.LocalObjectReference)
 		**out = **in
 	}
+	if in.GuardrailConfig != nil {
+		in, out := &in.GuardrailConfig, &out.GuardrailConfig
+		*out = new(AWSBedrockGuardrailConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OpenAI != nil {
+		in, out := &in.OpenAI, &out.OpenAI
+		*out = new(AIServiceBackendOpenAIConfig)
+		**out = **in
+	}
+	if in.AWSBedrock != nil {
+		in, out := &in.AWSBedrock, &out.AWSBedrock
+		*out = new(AIServiceBackendAWSBedrockConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ParameterNormalization != nil {
+		in, out := &in.ParameterNormalization, &out.ParameterNormalization
+		*out = new(ParameterNormalization)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeaderModifications != nil {
+		in, out := &in.HeaderModifications, &out.HeaderModifications
+		*out = new(apisv1.HTTPHeaderFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TrafficPolicy != nil {
+		in, out := &in.TrafficPolicy, &out.TrafficPolicy
+		*out = new(AIServiceBackendTrafficPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendSpec.
@@ -305,12 +831,99 @@ func (in *AIServiceBackendSpec) DeepCopy() *AIServiceBackendSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendStatus) DeepCopyInto(out *AIServiceBackendStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendStatus.
+func (in *AIServiceBackendStatus) DeepCopy() *AIServiceBackendStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AIServiceBackendTrafficPolicy) DeepCopyInto(out *AIServiceBackendTrafficPolicy) {
+	*out = *in
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(apiv1alpha1.CircuitBreaker)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(apiv1alpha1.PassiveHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(AIServiceBackendHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AIServiceBackendTrafficPolicy.
+func (in *AIServiceBackendTrafficPolicy) DeepCopy() *AIServiceBackendTrafficPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(AIServiceBackendTrafficPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBedrockGuardrailConfig) DeepCopyInto(out *AWSBedrockGuardrailConfig) {
+	*out = *in
+	if in.Trace != nil {
+		in, out := &in.Trace, &out.Trace
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBedrockGuardrailConfig.
+func (in *AWSBedrockGuardrailConfig) DeepCopy() *AWSBedrockGuardrailConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBedrockGuardrailConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AWSBedrockModelFamily) DeepCopyInto(out *AWSBedrockModelFamily) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AWSBedrockModelFamily.
+func (in *AWSBedrockModelFamily) DeepCopy() *AWSBedrockModelFamily {
+	if in == nil {
+		return nil
+	}
+	out := new(AWSBedrockModelFamily)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AWSCredentialsFile) DeepCopyInto(out *AWSCredentialsFile) {
 	*out = *in
 	if in.SecretRef != nil {
 		in, out := &in.SecretRef, &out.SecretRef
-		*out = new(v1.SecretObjectReference)
+		*out = new(apisv1.SecretObjectReference)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -341,12 +954,29 @@ func (in *AWSOIDCExchangeToken) DeepCopy() *AWSOIDCExchangeToken {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureOIDCExchangeToken) DeepCopyInto(out *AzureOIDCExchangeToken) {
+	*out = *in
+	in.OIDC.DeepCopyInto(&out.OIDC)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureOIDCExchangeToken.
+func (in *AzureOIDCExchangeToken) DeepCopy() *AzureOIDCExchangeToken {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureOIDCExchangeToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicy) DeepCopyInto(out *BackendSecurityPolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicy.
@@ -372,9 +1002,24 @@ func (in *BackendSecurityPolicyAPIKey) DeepCopyInto(out *BackendSecurityPolicyAP
 	*out = *in
 	if in.SecretRef != nil {
 		in, out := &in.SecretRef, &out.SecretRef
-		*out = new(v1.SecretObjectReference)
+		*out = new(apisv1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = new(BackendSecurityPolicyAPIKeys)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HeaderName != nil {
+		in, out := &in.HeaderName, &out.HeaderName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ValueTemplate != nil {
+		in, out := &in.ValueTemplate, &out.ValueTemplate
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAPIKey.
@@ -387,6 +1032,26 @@ func (in *BackendSecurityPolicyAPIKey) DeepCopy() *BackendSecurityPolicyAPIKey {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyAPIKeys) DeepCopyInto(out *BackendSecurityPolicyAPIKeys) {
+	*out = *in
+	if in.Weights != nil {
+		in, out := &in.Weights, &out.Weights
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAPIKeys.
+func (in *BackendSecurityPolicyAPIKeys) DeepCopy() *BackendSecurityPolicyAPIKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyAPIKeys)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyAWSCredentials) DeepCopyInto(out *BackendSecurityPolicyAWSCredentials) {
 	*out = *in
@@ -412,6 +1077,31 @@ func (in *BackendSecurityPolicyAWSCredentials) DeepCopy() *BackendSecurityPolicy
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyAzureCredentials) DeepCopyInto(out *BackendSecurityPolicyAzureCredentials) {
+	*out = *in
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(apisv1.SecretObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OIDCExchangeToken != nil {
+		in, out := &in.OIDCExchangeToken, &out.OIDCExchangeToken
+		*out = new(AzureOIDCExchangeToken)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyAzureCredentials.
+func (in *BackendSecurityPolicyAzureCredentials) DeepCopy() *BackendSecurityPolicyAzureCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyAzureCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackendSecurityPolicyList) DeepCopyInto(out *BackendSecurityPolicyList) {
 	*out = *in
@@ -457,6 +1147,11 @@ func (in *BackendSecurityPolicySpec) DeepCopyInto(out *BackendSecurityPolicySpec
 		*out = new(BackendSecurityPolicyAWSCredentials)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AzureCredentials != nil {
+		in, out := &in.AzureCredentials, &out.AzureCredentials
+		*out = new(BackendSecurityPolicyAzureCredentials)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicySpec.
@@ -469,6 +1164,28 @@ func (in *BackendSecurityPolicySpec) DeepCopy() *BackendSecurityPolicySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSecurityPolicyStatus) DeepCopyInto(out *BackendSecurityPolicyStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSecurityPolicyStatus.
+func (in *BackendSecurityPolicyStatus) DeepCopy() *BackendSecurityPolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSecurityPolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LLMRequestCost) DeepCopyInto(out *LLMRequestCost) {
 	*out = *in
@@ -477,6 +1194,11 @@ func (in *LLMRequestCost) DeepCopyInto(out *LLMRequestCost) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ModelPriceTable != nil {
+		in, out := &in.ModelPriceTable, &out.ModelPriceTable
+		*out = new(LLMRequestCostModelPriceTable)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMRequestCost.
@@ -489,6 +1211,78 @@ func (in *LLMRequestCost) DeepCopy() *LLMRequestCost {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLMRequestCostModelPrice) DeepCopyInto(out *LLMRequestCostModelPrice) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMRequestCostModelPrice.
+func (in *LLMRequestCostModelPrice) DeepCopy() *LLMRequestCostModelPrice {
+	if in == nil {
+		return nil
+	}
+	out := new(LLMRequestCostModelPrice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLMRequestCostModelPriceTable) DeepCopyInto(out *LLMRequestCostModelPriceTable) {
+	*out = *in
+	if in.Prices != nil {
+		in, out := &in.Prices, &out.Prices
+		*out = make(map[string]LLMRequestCostModelPrice, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Default != nil {
+		in, out := &in.Default, &out.Default
+		*out = new(LLMRequestCostModelPrice)
+		**out = **in
+	}
+	if in.Multiplier != nil {
+		in, out := &in.Multiplier, &out.Multiplier
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLMRequestCostModelPriceTable.
+func (in *LLMRequestCostModelPriceTable) DeepCopy() *LLMRequestCostModelPriceTable {
+	if in == nil {
+		return nil
+	}
+	out := new(LLMRequestCostModelPriceTable)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParameterNormalization) DeepCopyInto(out *ParameterNormalization) {
+	*out = *in
+	if in.TemperatureScale != nil {
+		in, out := &in.TemperatureScale, &out.TemperatureScale
+		*out = new(string)
+		**out = **in
+	}
+	if in.TopPScale != nil {
+		in, out := &in.TopPScale, &out.TopPScale
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParameterNormalization.
+func (in *ParameterNormalization) DeepCopy() *ParameterNormalization {
+	if in == nil {
+		return nil
+	}
+	out := new(ParameterNormalization)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionedAPISchema) DeepCopyInto(out *VersionedAPISchema) {
 	*out = *in
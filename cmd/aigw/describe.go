@@ -0,0 +1,121 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// describedExtProcConfig is the response body of the "GET /debug/config" endpoint of the external processor.
+type describedExtProcConfig struct {
+	UUID     string                       `json:"uuid"`
+	LoadedAt time.Time                    `json:"loadedAt"`
+	Schema   filterapi.VersionedAPISchema `json:"schema"`
+	Rules    []struct {
+		Headers  []filterapi.HeaderMatch `json:"headers"`
+		Priority int                     `json:"priority"`
+		Default  bool                    `json:"default"`
+		Backends []struct {
+			Name        string                       `json:"name"`
+			Schema      filterapi.VersionedAPISchema `json:"schema"`
+			Weight      int                          `json:"weight"`
+			Translators map[string]string            `json:"translators"`
+		} `json:"backends"`
+	} `json:"rules"`
+}
+
+// describeExtProc fetches the configuration loaded by the running external processor from its debug endpoints given
+// in the args, and writes the rules and the translators selected for the backends to the stdout.
+func describeExtProc(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("aigw describe-extproc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("debugAddr", "localhost:1064",
+		"address of the debug endpoints of the external processor, for example, the local port forwarded by "+
+			"\"kubectl port-forward <pod> 1064\".")
+	rawJSON := fs.Bool("json", false, "write the response of the debug endpoint as-is.")
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(fs.Output(), "Usage: aigw describe-extproc [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("no argument is accepted")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+*addr+"/debug/config", nil)
+	if err != nil {
+		return fmt.Errorf("invalid debug address %q: %w", *addr, err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get the config of the external processor: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the config of the external processor: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from the external processor: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	if *rawJSON {
+		_, err = stdout.Write(body)
+		return err
+	}
+	var config describedExtProcConfig
+	if err = json.Unmarshal(body, &config); err != nil {
+		return fmt.Errorf("failed to parse the config of the external processor: %w", err)
+	}
+	return writeExtProcConfig(stdout, &config)
+}
+
+// writeExtProcConfig writes the configuration in the table of the backends of the rules.
+func writeExtProcConfig(out io.Writer, config *describedExtProcConfig) error {
+	_, _ = fmt.Fprintf(out, "UUID:      %s\nLoaded at: %s\nSchema:    %s\n\n",
+		config.UUID, config.LoadedAt.Format(time.RFC3339), config.Schema.Name)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RULE\tMATCH\tBACKEND\tSCHEMA\tWEIGHT\tCHAT COMPLETIONS\tCONVERSE")
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		var matches []string
+		for _, h := range rule.Headers {
+			op := "="
+			if h.Type != nil && *h.Type != "" && *h.Type != gwapiv1.HeaderMatchExact {
+				op = fmt.Sprintf(" %s ", *h.Type)
+			}
+			matches = append(matches, fmt.Sprintf("%s%s%s", h.Name, op, h.Value))
+		}
+		if rule.Priority != 0 {
+			matches = append(matches, fmt.Sprintf("priority=%d", rule.Priority))
+		}
+		if rule.Default {
+			matches = append(matches, "default")
+		}
+		match := cmp.Or(strings.Join(matches, ","), "-")
+		for _, b := range rule.Backends {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\n", i, match, b.Name, b.Schema.Name, b.Weight,
+				cmp.Or(b.Translators["chatCompletions"], "-"), cmp.Or(b.Translators["converse"], "-"))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,83 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const describedConfigJSON = `{
+  "uuid": "some-uuid",
+  "loadedAt": "2025-01-02T03:04:05Z",
+  "schema": {"name": "OpenAI"},
+  "rules": [
+    {
+      "headers": [{"name": "x-ai-eg-model", "value": "claude"}, {"type": "Prefix", "name": "x-team", "value": "ml"}],
+      "priority": 1,
+      "backends": [
+        {
+          "name": "bedrock", "schema": {"name": "AWSBedrock"}, "weight": 1,
+          "translators": {"chatCompletions": "openAIToAWSBedrockTranslatorV1ChatCompletion", "converse": "awsBedrockToAWSBedrockTranslatorConverse"}
+        }
+      ]
+    },
+    {
+      "default": true,
+      "backends": [
+        {"name": "cohere", "schema": {"name": "Cohere"}, "weight": 2, "translators": {"chatCompletions": "openAIToCohereTranslatorV1ChatCompletion"}}
+      ]
+    }
+  ]
+}
+`
+
+func Test_describeExtProc(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/debug/config", r.URL.Path)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(describedConfigJSON))
+	}))
+	t.Cleanup(srv.Close)
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	t.Run("table", func(t *testing.T) {
+		status = http.StatusOK
+		var stdout, stderr bytes.Buffer
+		require.Equal(t, 0, run(t.Context(), []string{"describe-extproc", "-debugAddr", addr}, nil, &stdout, &stderr))
+		require.Empty(t, stderr.String())
+		require.Equal(t, `UUID:      some-uuid
+Loaded at: 2025-01-02T03:04:05Z
+Schema:    OpenAI
+
+RULE  MATCH                                             BACKEND  SCHEMA      WEIGHT  CHAT COMPLETIONS                              CONVERSE
+0     x-ai-eg-model=claude,x-team Prefix ml,priority=1  bedrock  AWSBedrock  1       openAIToAWSBedrockTranslatorV1ChatCompletion  awsBedrockToAWSBedrockTranslatorConverse
+1     default                                           cohere   Cohere      2       openAIToCohereTranslatorV1ChatCompletion      -
+`, stdout.String())
+	})
+	t.Run("json", func(t *testing.T) {
+		status = http.StatusOK
+		var stdout bytes.Buffer
+		require.NoError(t, describeExtProc(t.Context(), []string{"-debugAddr", addr, "-json"}, &stdout, nil))
+		require.Equal(t, describedConfigJSON, stdout.String())
+	})
+	t.Run("error status", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		err := describeExtProc(t.Context(), []string{"-debugAddr", addr}, nil, nil)
+		require.ErrorContains(t, err, "unexpected status 500 from the external processor")
+	})
+	t.Run("unexpected argument", func(t *testing.T) {
+		var stderr bytes.Buffer
+		require.Equal(t, 1, run(t.Context(), []string{"describe-extproc", "foo"}, nil, nil, &stderr))
+		require.Equal(t, "error: no argument is accepted\n", stderr.String())
+	})
+}
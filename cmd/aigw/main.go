@@ -0,0 +1,70 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: aigw <command> [arguments]
+
+Commands:
+  translate [files...]  Render the external processor filter configuration of the AIGatewayRoutes
+                        in the given manifest files, or the standard input when no file is given.
+  run [flags] <file>    Run the external processor locally with the simplified configuration in the file,
+                        and optionally write the Envoy configuration to be used with it. See "aigw run -h".
+  describe-extproc      Describe the rules and the translators loaded by the running external processor via its
+                        debug endpoints. See "aigw describe-extproc -h".
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the aigw command with the given arguments, and returns the exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 1
+	}
+	switch args[0] {
+	case "translate":
+		if err := translate(ctx, args[1:], stdin, stdout); err != nil {
+			_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "run":
+		if err := runStandalone(ctx, args[1:], stdout, stderr); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "describe-extproc":
+		if err := describeExtProc(ctx, args[1:], stdout, stderr); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			_, _ = fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	case "help", "-h", "-help", "--help":
+		_, _ = fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 1
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_run(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		expCode   int
+		expStdout string
+		expStderr string
+	}{
+		{name: "no command", expCode: 1, expStderr: usage},
+		{name: "help", args: []string{"help"}, expStdout: usage},
+		{name: "unknown command", args: []string{"foo"}, expCode: 1, expStderr: "unknown command \"foo\"\n\n" + usage},
+		{
+			name:      "translate error",
+			args:      []string{"translate", "testdata/nonexistent.yaml"},
+			expCode:   1,
+			expStderr: "error: failed to open testdata/nonexistent.yaml: open testdata/nonexistent.yaml: no such file or directory\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			require.Equal(t, tc.expCode, run(t.Context(), tc.args, strings.NewReader(""), &stdout, &stderr))
+			require.Equal(t, tc.expStdout, stdout.String())
+			require.Equal(t, tc.expStderr, stderr.String())
+		})
+	}
+}
+
+func Test_translate(t *testing.T) {
+	for _, name := range []string{"basic", "advanced"} {
+		t.Run(name, func(t *testing.T) {
+			input := filepath.Join("testdata", name+".yaml")
+			golden, err := os.ReadFile(filepath.Join("testdata", name+".golden.yaml"))
+			require.NoError(t, err)
+
+			t.Run("file", func(t *testing.T) {
+				var out bytes.Buffer
+				require.NoError(t, translate(t.Context(), []string{input}, nil, &out))
+				require.Equal(t, string(golden), out.String())
+			})
+			t.Run("stdin", func(t *testing.T) {
+				f, err := os.Open(input)
+				require.NoError(t, err)
+				defer func() { _ = f.Close() }()
+				var out bytes.Buffer
+				require.NoError(t, translate(t.Context(), nil, f, &out))
+				require.Equal(t, string(golden), out.String())
+			})
+		})
+	}
+
+	t.Run("multiple files", func(t *testing.T) {
+		basic, err := os.ReadFile("testdata/basic.golden.yaml")
+		require.NoError(t, err)
+		advanced, err := os.ReadFile("testdata/advanced.golden.yaml")
+		require.NoError(t, err)
+		var out bytes.Buffer
+		require.NoError(t, translate(t.Context(), []string{"testdata/basic.yaml", "testdata/advanced.yaml"}, nil, &out))
+		require.Equal(t, string(basic)+"---\n"+string(advanced), out.String())
+	})
+
+	for _, tc := range []struct {
+		name     string
+		manifest string
+		expErr   string
+	}{
+		{
+			name:     "no route",
+			manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n",
+			expErr:   "no AIGatewayRoute found",
+		},
+		{
+			name:     "invalid manifest",
+			manifest: "apiVersion: aigateway.envoyproxy.io/v1alpha1\nkind: AIGatewayRoute\nspec:\n  unknownField: foo\n",
+			expErr:   `failed to parse stdin: failed to parse AIGatewayRoute: error unmarshaling JSON: while decoding JSON: json: unknown field "unknownField"`,
+		},
+		{
+			name: "missing backend",
+			manifest: `apiVersion: aigateway.envoyproxy.io/v1alpha1
+kind: AIGatewayRoute
+metadata:
+  name: route
+spec:
+  schema:
+    name: OpenAI
+  rules:
+  - backendRefs:
+    - name: missing
+`,
+			expErr: `failed to translate AIGatewayRoute default/route: failed to get AIServiceBackend missing.default: aiservicebackends.aigateway.envoyproxy.io "missing" not found`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := translate(t.Context(), nil, strings.NewReader(tc.manifest), &bytes.Buffer{})
+			require.EqualError(t, err, tc.expErr)
+		})
+	}
+}
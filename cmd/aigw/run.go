@@ -0,0 +1,385 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"syscall"
+	"text/template"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/envoyproxy/ai-gateway/cmd/extproc/mainlib"
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc"
+)
+
+const (
+	// runSelectedBackendHeaderKey and runModelNameHeaderKey are the header keys of the filter configuration generated
+	// by the run command, which the generated Envoy configuration routes on.
+	runSelectedBackendHeaderKey = "x-ai-eg-selected-backend"
+	runModelNameHeaderKey       = "x-ai-eg-model"
+	// runMetadataNamespace is the dynamic metadata namespace of the filter configuration generated by the run command.
+	runMetadataNamespace = "io.envoy.ai_gateway"
+)
+
+// runConfig is the simplified configuration of the run command.
+//
+// # Example configuration:
+//
+//	backends:
+//	- name: openai
+//	  schema: OpenAI
+//	  address: https://api.openai.com
+//	  apiKeyEnv: OPENAI_API_KEY
+//	- name: bedrock
+//	  schema: AWSBedrock
+//	  address: https://bedrock-runtime.us-east-1.amazonaws.com
+//	  awsRegion: us-east-1
+//	  awsCredentialsFile: ~/.aws/credentials
+//	rules:
+//	- model: gpt-4o-mini
+//	  backends:
+//	  - name: openai
+//	- model: us.meta.llama3-2-1b-instruct-v1:0
+//	  backends:
+//	  - name: bedrock
+type runConfig struct {
+	// Backends is the list of the backends that the rules refer to.
+	Backends []runBackend `json:"backends"`
+	// Rules is the list of the routing rules matched by the model name of the request.
+	Rules []runRule `json:"rules"`
+}
+
+// runBackend is a backend of [runConfig].
+type runBackend struct {
+	// Name is the unique name of the backend.
+	Name string `json:"name"`
+	// Schema is the API schema of the backend, either "OpenAI" or "AWSBedrock".
+	Schema filterapi.APISchemaName `json:"schema"`
+	// Address is the base URL of the backend, for example, "https://api.openai.com" or "http://localhost:8080".
+	Address string `json:"address"`
+	// APIKeyEnv is the environment variable holding the API key of the backend. Optional.
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"`
+	// AWSRegion is the AWS region of the backend, required for the AWSBedrock schema.
+	AWSRegion string `json:"awsRegion,omitempty"`
+	// AWSCredentialsFile is the path to the AWS shared credentials file used to sign the requests. Optional.
+	AWSCredentialsFile string `json:"awsCredentialsFile,omitempty"`
+}
+
+// runRule is a routing rule of [runConfig].
+type runRule struct {
+	// Model is the model name of the requests matching this rule.
+	Model string `json:"model"`
+	// Backends is the list of the backends that the requests matching this rule are routed to.
+	Backends []runRuleBackend `json:"backends"`
+}
+
+// runRuleBackend is a backend reference of [runRule].
+type runRuleBackend struct {
+	// Name is the name of the backend in [runConfig.Backends].
+	Name string `json:"name"`
+	// Weight is the weight of the backend in the routing decision. Defaults to 1.
+	Weight *int `json:"weight,omitempty"`
+}
+
+// runFlags is the flags of the run command.
+type runFlags struct {
+	extProcAddr  string
+	envoyConfig  string
+	listenerPort int
+	caBundle     string
+	logLevel     slog.Level
+}
+
+// runStandalone runs the external processor with the filter configuration generated from the run configuration
+// given in the args, and optionally writes the Envoy configuration to be used with it. This blocks until the ctx
+// is canceled or the process receives SIGINT or SIGTERM.
+func runStandalone(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags, configPath, err := parseRunFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", configPath, err)
+	}
+	var config runConfig
+	if err = yaml.UnmarshalStrict(raw, &config); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	// The API keys are passed to the external processor via the files as in Kubernetes.
+	secretsDir, err := os.MkdirTemp("", "aigw-run-")
+	if err != nil {
+		return fmt.Errorf("failed to create the temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(secretsDir) }()
+	filterConfig, err := config.filterConfig(secretsDir, os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", flags.extProcAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", flags.extProcAddr, err)
+	}
+	if flags.envoyConfig != "" {
+		envoyConfig, err := config.envoyConfig(lis.Addr().(*net.TCPAddr), flags.listenerPort, cmp.Or(flags.caBundle, systemCABundle()))
+		if err != nil {
+			_ = lis.Close()
+			return err
+		}
+		if err = os.WriteFile(flags.envoyConfig, envoyConfig, 0o600); err != nil {
+			_ = lis.Close()
+			return fmt.Errorf("failed to write the Envoy configuration: %w", err)
+		}
+		_, _ = fmt.Fprintf(stdout, "Envoy configuration is written to %s. Start Envoy with:\n\n"+
+			"  func-e run -c %s\n\nand send the requests to http://localhost:%d/v1/chat/completions\n",
+			flags.envoyConfig, flags.envoyConfig, flags.listenerPort)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: flags.logLevel}))
+	server, err := extproc.NewServer(logger)
+	if err != nil {
+		_ = lis.Close()
+		return fmt.Errorf("failed to create the external processor server: %w", err)
+	}
+	mainlib.RegisterProcessors(server)
+	if err = server.LoadConfig(ctx, filterConfig); err != nil {
+		_ = lis.Close()
+		return fmt.Errorf("failed to load the filter configuration: %w", err)
+	}
+
+	s := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(s, server)
+	grpc_health_v1.RegisterHealthServer(s, server)
+	go func() {
+		<-ctx.Done()
+		s.GracefulStop()
+	}()
+	logger.Info("external processor is listening", slog.String("address", lis.Addr().String()))
+	if err = s.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("failed to serve the external processor: %w", err)
+	}
+	return nil
+}
+
+// parseRunFlags parses the flags of the run command, and returns the path to the run configuration.
+func parseRunFlags(args []string, stderr io.Writer) (flags runFlags, configPath string, err error) {
+	fs := flag.NewFlagSet("aigw run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&flags.extProcAddr, "extProcAddr", "localhost:1063", "TCP address the external processor listens on.")
+	fs.StringVar(&flags.envoyConfig, "envoyConfig", "",
+		"path to write the Envoy configuration with the external processing filter and the clusters of the backends. "+
+			"Not written when empty.")
+	fs.IntVar(&flags.listenerPort, "listenerPort", 1975, "port of the listener of the Envoy configuration.")
+	fs.StringVar(&flags.caBundle, "caBundle", "",
+		"path to the CA certificates that Envoy verifies the https backends with. Defaults to the system CA bundle.")
+	logLevel := fs.String("logLevel", "info", "log level. One of 'debug', 'info', 'warn', or 'error'.")
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(fs.Output(), "Usage: aigw run [flags] <config.yaml>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+	if fs.NArg() != 1 {
+		return flags, "", errors.New("exactly one configuration file must be given")
+	}
+	if err = flags.logLevel.UnmarshalText([]byte(*logLevel)); err != nil {
+		return flags, "", fmt.Errorf("invalid log level: %w", err)
+	}
+	return flags, fs.Arg(0), nil
+}
+
+// filterConfig generates the filter configuration. The API keys read via the getenv are written to the files
+// in the secretsDir.
+func (c *runConfig) filterConfig(secretsDir string, getenv func(string) string) (*filterapi.Config, error) {
+	backends := make(map[string]filterapi.Backend, len(c.Backends))
+	for i := range c.Backends {
+		b := &c.Backends[i]
+		if _, err := b.endpoint(); err != nil {
+			return nil, err
+		}
+		if _, ok := backends[b.Name]; ok {
+			return nil, fmt.Errorf("duplicate backend %q", b.Name)
+		}
+		backend := filterapi.Backend{Name: b.Name, Schema: filterapi.VersionedAPISchema{Name: b.Schema}}
+		switch b.Schema {
+		case filterapi.APISchemaOpenAI:
+		case filterapi.APISchemaAWSBedrock:
+			if b.AWSRegion == "" {
+				return nil, fmt.Errorf("backend %q: awsRegion is required for the AWSBedrock schema", b.Name)
+			}
+			backend.Auth = &filterapi.BackendAuth{
+				AWSAuth: &filterapi.AWSAuth{Region: b.AWSRegion, CredentialFileName: b.AWSCredentialsFile},
+			}
+		default:
+			return nil, fmt.Errorf("backend %q: unsupported schema %q", b.Name, b.Schema)
+		}
+		if b.APIKeyEnv != "" {
+			if backend.Auth != nil {
+				return nil, fmt.Errorf("backend %q: apiKeyEnv cannot be used with the AWSBedrock schema", b.Name)
+			}
+			apiKey := getenv(b.APIKeyEnv)
+			if apiKey == "" {
+				return nil, fmt.Errorf("backend %q: environment variable %s is not set", b.Name, b.APIKeyEnv)
+			}
+			filename := filepath.Join(secretsDir, b.Name)
+			if err := os.WriteFile(filename, []byte(apiKey), 0o600); err != nil {
+				return nil, fmt.Errorf("failed to write the API key of the backend %q: %w", b.Name, err)
+			}
+			backend.Auth = &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Filename: filename}}
+		}
+		backends[b.Name] = backend
+	}
+
+	config := &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		SelectedBackendHeaderKey: runSelectedBackendHeaderKey,
+		ModelNameHeaderKey:       runModelNameHeaderKey,
+		MetadataNamespace:        runMetadataNamespace,
+	}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.Model == "" {
+			return nil, fmt.Errorf("rule %d: model is required", i)
+		}
+		if len(r.Backends) == 0 {
+			return nil, fmt.Errorf("rule %d: at least one backend is required", i)
+		}
+		rule := filterapi.RouteRule{Headers: []filterapi.HeaderMatch{{Name: runModelNameHeaderKey, Value: r.Model}}}
+		for _, ref := range r.Backends {
+			backend, ok := backends[ref.Name]
+			if !ok {
+				return nil, fmt.Errorf("rule %d: backend %q is not defined", i, ref.Name)
+			}
+			backend.Weight = 1
+			if ref.Weight != nil {
+				backend.Weight = *ref.Weight
+			}
+			rule.Backends = append(rule.Backends, backend)
+		}
+		config.Rules = append(config.Rules, rule)
+	}
+	return config, nil
+}
+
+// runBackendNameRegexp is the valid backend name, which is used as a part of the cluster name of Envoy.
+var runBackendNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// endpoint parses the address of the backend.
+func (b *runBackend) endpoint() (*runEndpoint, error) {
+	if !runBackendNameRegexp.MatchString(b.Name) {
+		return nil, fmt.Errorf("backend name must consist of alphanumeric characters, '-' or '_' but got %q", b.Name)
+	}
+	u, err := url.Parse(b.Address)
+	if err != nil {
+		return nil, fmt.Errorf("backend %q: invalid address: %w", b.Name, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || (u.Path != "" && u.Path != "/") {
+		return nil, fmt.Errorf("backend %q: address must be http(s)://<host>[:<port>] but got %q", b.Name, b.Address)
+	}
+	e := &runEndpoint{Name: b.Name, Host: u.Hostname(), TLS: u.Scheme == "https", Port: 80}
+	if e.TLS {
+		e.Port = 443
+	}
+	if p := u.Port(); p != "" {
+		if e.Port, err = strconv.Atoi(p); err != nil {
+			return nil, fmt.Errorf("backend %q: invalid port: %w", b.Name, err)
+		}
+	}
+	e.Static = net.ParseIP(e.Host) != nil
+	return e, nil
+}
+
+// runEndpoint is the endpoint of a backend rendered into the Envoy configuration.
+type runEndpoint struct {
+	Name   string
+	Host   string
+	Port   int
+	TLS    bool
+	Static bool
+}
+
+//go:embed run_envoy.yaml.tmpl
+var runEnvoyConfigTemplate string
+
+var runEnvoyConfig = template.Must(template.New("envoy").Parse(runEnvoyConfigTemplate))
+
+// systemCABundleFiles is the well-known paths to the system CA bundle on the major distributions.
+var systemCABundleFiles = []string{
+	"/etc/ssl/certs/ca-certificates.crt", // Debian, Ubuntu, Arch and Gentoo.
+	"/etc/pki/tls/certs/ca-bundle.crt",   // Fedora and RHEL.
+	"/etc/ssl/ca-bundle.pem",             // OpenSUSE.
+	"/etc/ssl/cert.pem",                  // macOS and Alpine.
+}
+
+// systemCABundle returns the path to the system CA bundle, or empty if not found.
+func systemCABundle() string {
+	for _, f := range systemCABundleFiles {
+		if _, err := os.Stat(f); err == nil {
+			return f
+		}
+	}
+	return ""
+}
+
+// envoyConfig renders the Envoy configuration listening on the listenerPort, and routing the requests to
+// the backends selected by the external processor listening on the extProcAddr. The certificates of the https
+// backends are verified with the CA certificates in the caBundle file.
+func (c *runConfig) envoyConfig(extProcAddr *net.TCPAddr, listenerPort int, caBundle string) ([]byte, error) {
+	endpoints := make([]*runEndpoint, len(c.Backends))
+	for i := range c.Backends {
+		e, err := c.Backends[i].endpoint()
+		if err != nil {
+			return nil, err
+		}
+		if e.TLS && caBundle == "" {
+			return nil, fmt.Errorf("backend %q: no system CA bundle is found to verify the certificate: set -caBundle", e.Name)
+		}
+		endpoints[i] = e
+	}
+	extProcHost := "127.0.0.1"
+	if !extProcAddr.IP.IsUnspecified() {
+		extProcHost = extProcAddr.IP.String()
+	}
+	var buf bytes.Buffer
+	err := runEnvoyConfig.Execute(&buf, map[string]any{
+		"ListenerPort":             listenerPort,
+		"ExtProcHost":              extProcHost,
+		"ExtProcPort":              extProcAddr.Port,
+		"SelectedBackendHeaderKey": runSelectedBackendHeaderKey,
+		"MetadataNamespace":        runMetadataNamespace,
+		"Backends":                 endpoints,
+		"CABundle":                 caBundle,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the Envoy configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,241 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bootstrapv3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/yaml"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func requireRunConfig(t *testing.T) *runConfig {
+	raw, err := os.ReadFile("testdata/run.yaml")
+	require.NoError(t, err)
+	var config runConfig
+	require.NoError(t, yaml.UnmarshalStrict(raw, &config))
+	return &config
+}
+
+func Test_runConfig_filterConfig(t *testing.T) {
+	config := requireRunConfig(t)
+	secretsDir := t.TempDir()
+	getenv := func(key string) string {
+		require.Equal(t, "TEST_OPENAI_API_KEY", key)
+		return "sk-test"
+	}
+	filterConfig, err := config.filterConfig(secretsDir, getenv)
+	require.NoError(t, err)
+
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	apiKeyFile := filepath.Join(secretsDir, "openai")
+	require.Equal(t, &filterapi.Config{
+		Schema:                   openAISchema,
+		SelectedBackendHeaderKey: "x-ai-eg-selected-backend",
+		ModelNameHeaderKey:       "x-ai-eg-model",
+		MetadataNamespace:        "io.envoy.ai_gateway",
+		Rules: []filterapi.RouteRule{
+			{
+				Headers: []filterapi.HeaderMatch{{Name: "x-ai-eg-model", Value: "gpt-4o-mini"}},
+				Backends: []filterapi.Backend{
+					{
+						Name: "openai", Schema: openAISchema, Weight: 1,
+						Auth: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Filename: apiKeyFile}},
+					},
+					{Name: "local", Schema: openAISchema, Weight: 3},
+				},
+			},
+			{
+				Headers: []filterapi.HeaderMatch{{Name: "x-ai-eg-model", Value: "us.meta.llama3-2-1b-instruct-v1:0"}},
+				Backends: []filterapi.Backend{
+					{
+						Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1,
+						Auth: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{Region: "us-east-1"}},
+					},
+				},
+			},
+		},
+	}, filterConfig)
+	apiKey, err := os.ReadFile(apiKeyFile)
+	require.NoError(t, err)
+	require.Equal(t, "sk-test", string(apiKey))
+
+	for _, tc := range []struct {
+		name   string
+		config string
+		expErr string
+	}{
+		{
+			name:   "invalid name",
+			config: "backends: [{name: 'a b', schema: OpenAI, address: 'http://localhost'}]",
+			expErr: `backend name must consist of alphanumeric characters, '-' or '_' but got "a b"`,
+		},
+		{
+			name:   "invalid address",
+			config: "backends: [{name: a, schema: OpenAI, address: 'localhost:8080'}]",
+			expErr: `backend "a": address must be http(s)://<host>[:<port>] but got "localhost:8080"`,
+		},
+		{
+			name:   "address with path",
+			config: "backends: [{name: a, schema: OpenAI, address: 'http://localhost/v1'}]",
+			expErr: `backend "a": address must be http(s)://<host>[:<port>] but got "http://localhost/v1"`,
+		},
+		{
+			name:   "duplicate backend",
+			config: "backends: [{name: a, schema: OpenAI, address: 'http://a'}, {name: a, schema: OpenAI, address: 'http://b'}]",
+			expErr: `duplicate backend "a"`,
+		},
+		{
+			name:   "unsupported schema",
+			config: "backends: [{name: a, schema: Foo, address: 'http://a'}]",
+			expErr: `backend "a": unsupported schema "Foo"`,
+		},
+		{
+			name:   "missing region",
+			config: "backends: [{name: a, schema: AWSBedrock, address: 'https://a'}]",
+			expErr: `backend "a": awsRegion is required for the AWSBedrock schema`,
+		},
+		{
+			name:   "api key for bedrock",
+			config: "backends: [{name: a, schema: AWSBedrock, address: 'https://a', awsRegion: us-east-1, apiKeyEnv: TEST_OPENAI_API_KEY}]",
+			expErr: `backend "a": apiKeyEnv cannot be used with the AWSBedrock schema`,
+		},
+		{
+			name:   "unset api key",
+			config: "backends: [{name: a, schema: OpenAI, address: 'http://a', apiKeyEnv: UNSET}]",
+			expErr: `backend "a": environment variable UNSET is not set`,
+		},
+		{
+			name:   "missing model",
+			config: "rules: [{backends: [{name: a}]}]",
+			expErr: "rule 0: model is required",
+		},
+		{
+			name:   "no backend",
+			config: "rules: [{model: foo}]",
+			expErr: "rule 0: at least one backend is required",
+		},
+		{
+			name:   "undefined backend",
+			config: "rules: [{model: foo, backends: [{name: a}]}]",
+			expErr: `rule 0: backend "a" is not defined`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var config runConfig
+			require.NoError(t, yaml.UnmarshalStrict([]byte(tc.config), &config))
+			_, err := config.filterConfig(t.TempDir(), func(key string) string {
+				if key == "TEST_OPENAI_API_KEY" {
+					return "sk-test"
+				}
+				return ""
+			})
+			require.EqualError(t, err, tc.expErr)
+		})
+	}
+}
+
+func Test_runConfig_envoyConfig(t *testing.T) {
+	config := requireRunConfig(t)
+	envoyConfig, err := config.envoyConfig(&net.TCPAddr{IP: net.IPv4zero, Port: 1063}, 1975, "/etc/ssl/certs/ca-certificates.crt")
+	require.NoError(t, err)
+	golden, err := os.ReadFile("testdata/run_envoy.golden.yaml")
+	require.NoError(t, err)
+	require.Equal(t, string(golden), string(envoyConfig))
+
+	// The configuration must be a valid Envoy bootstrap.
+	j, err := yaml.YAMLToJSON(envoyConfig)
+	require.NoError(t, err)
+	var bootstrap bootstrapv3.Bootstrap
+	require.NoError(t, protojson.Unmarshal(j, &bootstrap))
+	require.NoError(t, bootstrap.ValidateAll())
+	require.Len(t, bootstrap.StaticResources.Clusters, 4)
+
+	// The https backends cannot be verified without the CA bundle.
+	_, err = config.envoyConfig(&net.TCPAddr{IP: net.IPv4zero, Port: 1063}, 1975, "")
+	require.EqualError(t, err, `backend "openai": no system CA bundle is found to verify the certificate: set -caBundle`)
+}
+
+func Test_runStandalone(t *testing.T) {
+	t.Run("invalid args", func(t *testing.T) {
+		for _, tc := range []struct {
+			args   []string
+			expErr string
+		}{
+			{args: nil, expErr: "exactly one configuration file must be given"},
+			{args: []string{"-logLevel", "foo", "testdata/run.yaml"}, expErr: `invalid log level: slog: level string "foo": unknown name`},
+			{args: []string{"testdata/nonexistent.yaml"}, expErr: "failed to read testdata/nonexistent.yaml: open testdata/nonexistent.yaml: no such file or directory"},
+			{args: []string{"testdata/basic.yaml"}, expErr: `failed to parse testdata/basic.yaml: error unmarshaling JSON: while decoding JSON: json: unknown field "apiVersion"`},
+		} {
+			err := runStandalone(t.Context(), tc.args, &bytes.Buffer{}, &bytes.Buffer{})
+			require.ErrorContains(t, err, tc.expErr)
+		}
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		t.Setenv("TEST_OPENAI_API_KEY", "sk-test")
+		envoyConfigPath := filepath.Join(t.TempDir(), "envoy.yaml")
+		ctx, cancel := context.WithCancel(t.Context())
+		var stdout bytes.Buffer
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- runStandalone(ctx, []string{
+				"-extProcAddr", "127.0.0.1:0", "-envoyConfig", envoyConfigPath, "-caBundle", "/etc/ssl/cert.pem", "testdata/run.yaml",
+			}, &stdout, &bytes.Buffer{})
+		}()
+
+		// The listening port is only known from the written Envoy configuration.
+		var extProcPort int
+		require.Eventually(t, func() bool {
+			raw, err := os.ReadFile(envoyConfigPath)
+			if err != nil {
+				return false
+			}
+			j, err := yaml.YAMLToJSON(raw)
+			require.NoError(t, err)
+			var bootstrap bootstrapv3.Bootstrap
+			require.NoError(t, protojson.Unmarshal(j, &bootstrap))
+			for _, c := range bootstrap.StaticResources.Clusters {
+				if c.Name == "aigw_extproc" {
+					extProcPort = int(c.LoadAssignment.Endpoints[0].LbEndpoints[0].GetEndpoint().Address.GetSocketAddress().GetPortValue())
+				}
+			}
+			return extProcPort != 0
+		}, 10*time.Second, 100*time.Millisecond)
+
+		conn, err := grpc.NewClient(net.JoinHostPort("127.0.0.1", fmt.Sprint(extProcPort)),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		require.Eventually(t, func() bool {
+			res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			return err == nil && res.Status == grpc_health_v1.HealthCheckResponse_SERVING
+		}, 10*time.Second, 100*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-errCh)
+		require.Contains(t, stdout.String(), "func-e run -c "+envoyConfigPath)
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+	"github.com/envoyproxy/ai-gateway/internal/controller"
+)
+
+// translate reads the AIGatewayRoute, AIServiceBackend and BackendSecurityPolicy manifests from the files,
+// or the stdin when no file is given, and writes the filter configuration of each AIGatewayRoute to the out.
+// The configurations are separated by the YAML document separator in the order of the AIGatewayRoutes.
+//
+// Manifests of the other kinds are ignored so that a whole directory of manifests can be passed as-is.
+func translate(ctx context.Context, files []string, stdin io.Reader, out io.Writer) error {
+	var objs []client.Object
+	if len(files) == 0 {
+		parsed, err := parseManifests(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to parse stdin: %w", err)
+		}
+		objs = parsed
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		parsed, err := parseManifests(f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		objs = append(objs, parsed...)
+	}
+
+	scheme := runtime.NewScheme()
+	controller.MustInitializeScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	var routes []*aigv1a1.AIGatewayRoute
+	for _, obj := range objs {
+		if route, ok := obj.(*aigv1a1.AIGatewayRoute); ok {
+			routes = append(routes, route)
+		}
+	}
+	if len(routes) == 0 {
+		return errors.New("no AIGatewayRoute found")
+	}
+	for i, route := range routes {
+		// The UUID is only used to detect the config updates at runtime, so it is left empty for the stable output.
+		ec, err := controller.NewFilterConfig(ctx, c, route, "")
+		if err != nil {
+			return fmt.Errorf("failed to translate AIGatewayRoute %s/%s: %w", route.Namespace, route.Name, err)
+		}
+		marshaled, err := yaml.Marshal(ec)
+		if err != nil {
+			return fmt.Errorf("failed to marshal filter config: %w", err)
+		}
+		if i > 0 {
+			if _, err = io.WriteString(out, "---\n"); err != nil {
+				return err
+			}
+		}
+		if _, err = out.Write(marshaled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseManifests parses the multi-document YAML into the objects of the kinds relevant to the filter configuration.
+// The namespace defaults to "default" when not specified, in the same way as kubectl.
+func parseManifests(r io.Reader) ([]client.Object, error) {
+	var objs []client.Object
+	reader := k8syaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		var typeMeta metav1.TypeMeta
+		if err = yaml.Unmarshal(doc, &typeMeta); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if typeMeta.GroupVersionKind().Group != aigv1a1.GroupName {
+			continue
+		}
+		var obj client.Object
+		switch typeMeta.Kind {
+		case "AIGatewayRoute":
+			obj = &aigv1a1.AIGatewayRoute{}
+		case "AIServiceBackend":
+			obj = &aigv1a1.AIServiceBackend{}
+		case "BackendSecurityPolicy":
+			obj = &aigv1a1.BackendSecurityPolicy{}
+		default:
+			continue
+		}
+		if err = yaml.UnmarshalStrict(doc, obj); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", typeMeta.Kind, err)
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace("default")
+		}
+		objs = append(objs, obj)
+	}
+}
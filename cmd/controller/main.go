@@ -11,26 +11,47 @@ import (
 	"log/slog"
 	"net"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/envoyproxy/gateway/proto/extension"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/envoyproxy/ai-gateway/internal/controller"
 	"github.com/envoyproxy/ai-gateway/internal/extensionserver"
 )
 
+// managerFlags is the flags of the controller manager, such as the tuning of the leader election.
+type managerFlags struct {
+	leaseDuration, renewDeadline, retryPeriod time.Duration
+	healthProbeAddr                           string
+	metricsAddr                               string
+}
+
 // parseAndValidateFlags parses the command-line arguments provided in args,
 // validates them, and returns the parsed configuration.
 func parseAndValidateFlags(args []string) (
 	extProcLogLevel string,
 	extProcImage string,
+	extProcImagePullSecret string,
 	enableLeaderElection bool,
 	logLevel zapcore.Level,
 	extensionServerPort string,
+	extProcDefaultResources corev1.ResourceRequirements,
+	watchNamespaces []string,
+	namespaceLabelSelector labels.Selector,
+	manager managerFlags,
 	err error,
 ) {
 	fs := flag.NewFlagSet("AI Gateway Controller", flag.ContinueOnError)
@@ -45,6 +66,12 @@ func parseAndValidateFlags(args []string) (
 		"docker.io/envoyproxy/ai-gateway-extproc:latest",
 		"The image for the external processor",
 	)
+	extProcImagePullSecretPtr := fs.String(
+		"extProcImagePullSecret",
+		"",
+		"The name of the secret used to pull the external processor image. "+
+			"This can be overridden per AIGatewayRoute via the filter configuration.",
+	)
 	enableLeaderElectionPtr := fs.Bool(
 		"enableLeaderElection",
 		true,
@@ -60,6 +87,67 @@ func parseAndValidateFlags(args []string) (
 		":1063",
 		"gRPC port for the extension server",
 	)
+	extProcDefaultRequestsCPUPtr := fs.String(
+		"extProcDefaultRequestsCPU",
+		"100m",
+		"The default CPU request of the external processor container used when the AIGatewayRoute does not specify "+
+			"the resources. Empty means no request.",
+	)
+	extProcDefaultRequestsMemoryPtr := fs.String(
+		"extProcDefaultRequestsMemory",
+		"128Mi",
+		"The default memory request of the external processor container used when the AIGatewayRoute does not specify "+
+			"the resources. Empty means no request.",
+	)
+	extProcDefaultLimitsCPUPtr := fs.String(
+		"extProcDefaultLimitsCPU",
+		"",
+		"The default CPU limit of the external processor container used when the AIGatewayRoute does not specify "+
+			"the resources. Empty means no limit.",
+	)
+	extProcDefaultLimitsMemoryPtr := fs.String(
+		"extProcDefaultLimitsMemory",
+		"512Mi",
+		"The default memory limit of the external processor container used when the AIGatewayRoute does not specify "+
+			"the resources. Empty means no limit.",
+	)
+	watchNamespacesPtr := fs.String(
+		"watchNamespaces",
+		"",
+		"The comma-separated list of the namespaces that the controller watches. Empty means all namespaces.",
+	)
+	namespaceLabelSelectorPtr := fs.String(
+		"namespaceLabelSelector",
+		"",
+		"The label selector of the namespaces that the controller reconciles the resources in, for example, "+
+			"'team=ai' or 'ai-gateway/ignore notin (true)'. Empty means all namespaces.",
+	)
+	fs.DurationVar(&manager.leaseDuration,
+		"leaseDuration",
+		15*time.Second,
+		"The duration that the non-leader candidates wait to force acquire the leadership of the controller manager.",
+	)
+	fs.DurationVar(&manager.renewDeadline,
+		"renewDeadline",
+		10*time.Second,
+		"The duration that the leader of the controller manager retries to renew the leadership before giving it up.",
+	)
+	fs.DurationVar(&manager.retryPeriod,
+		"retryPeriod",
+		2*time.Second,
+		"The duration that the candidates of the leader of the controller manager wait between the tries of the actions.",
+	)
+	fs.StringVar(&manager.healthProbeAddr,
+		"healthProbeAddr",
+		":8081",
+		"The address of the /healthz and /readyz endpoints of the controller, which include the status of the extension "+
+			"server. Empty disables them.",
+	)
+	fs.StringVar(&manager.metricsAddr,
+		"metricsAddr",
+		":8080",
+		"The address of the metrics endpoint of the controller. \"0\" disables it.",
+	)
 
 	if err = fs.Parse(args); err != nil {
 		err = fmt.Errorf("failed to parse flags: %w", err)
@@ -77,7 +165,62 @@ func parseAndValidateFlags(args []string) (
 		err = fmt.Errorf("invalid log level: %q", *logLevelPtr)
 		return
 	}
-	return *extProcLogLevelPtr, *extProcImagePtr, *enableLeaderElectionPtr, zapLogLevel, *extensionServerPortPtr, nil
+
+	for _, q := range []struct {
+		flag  string
+		value string
+		list  *corev1.ResourceList
+		name  corev1.ResourceName
+	}{
+		{"extProcDefaultRequestsCPU", *extProcDefaultRequestsCPUPtr, &extProcDefaultResources.Requests, corev1.ResourceCPU},
+		{"extProcDefaultRequestsMemory", *extProcDefaultRequestsMemoryPtr, &extProcDefaultResources.Requests, corev1.ResourceMemory},
+		{"extProcDefaultLimitsCPU", *extProcDefaultLimitsCPUPtr, &extProcDefaultResources.Limits, corev1.ResourceCPU},
+		{"extProcDefaultLimitsMemory", *extProcDefaultLimitsMemoryPtr, &extProcDefaultResources.Limits, corev1.ResourceMemory},
+	} {
+		if q.value == "" {
+			continue
+		}
+		var quantity resource.Quantity
+		if quantity, err = resource.ParseQuantity(q.value); err != nil {
+			err = fmt.Errorf("invalid %s: %q", q.flag, q.value)
+			return
+		}
+		if *q.list == nil {
+			*q.list = corev1.ResourceList{}
+		}
+		(*q.list)[q.name] = quantity
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, hasRequest := extProcDefaultResources.Requests[name]
+		limit, hasLimit := extProcDefaultResources.Limits[name]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			err = fmt.Errorf("the default %s request of the external processor %s exceeds the limit %s", name, request.String(), limit.String())
+			return
+		}
+	}
+	for _, ns := range strings.Split(*watchNamespacesPtr, ",") {
+		if ns = strings.TrimSpace(ns); ns == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			err = fmt.Errorf("invalid namespace %q in watchNamespaces: %s", ns, strings.Join(errs, ", "))
+			return
+		}
+		watchNamespaces = append(watchNamespaces, ns)
+	}
+	if manager.retryPeriod <= 0 || manager.renewDeadline <= manager.retryPeriod || manager.leaseDuration <= manager.renewDeadline {
+		err = fmt.Errorf("leaseDuration %s, renewDeadline %s and retryPeriod %s must be positive and decreasing in this order",
+			manager.leaseDuration, manager.renewDeadline, manager.retryPeriod)
+		return
+	}
+	if *namespaceLabelSelectorPtr != "" {
+		if namespaceLabelSelector, err = labels.Parse(*namespaceLabelSelectorPtr); err != nil {
+			err = fmt.Errorf("invalid namespaceLabelSelector: %w", err)
+			return
+		}
+	}
+	return *extProcLogLevelPtr, *extProcImagePtr, *extProcImagePullSecretPtr, *enableLeaderElectionPtr, zapLogLevel,
+		*extensionServerPortPtr, extProcDefaultResources, watchNamespaces, namespaceLabelSelector, manager, nil
 }
 
 func main() {
@@ -85,9 +228,14 @@ func main() {
 
 	flagExtProcLogLevel,
 		flagExtProcImage,
+		flagExtProcImagePullSecret,
 		flagEnableLeaderElection,
 		zapLogLevel,
 		flagExtensionServerPort,
+		flagExtProcDefaultResources,
+		flagWatchNamespaces,
+		flagNamespaceLabelSelector,
+		flagManager,
 		err := parseAndValidateFlags(os.Args[1:])
 	if err != nil {
 		setupLog.Error(err, "failed to parse and validate flags")
@@ -108,9 +256,18 @@ func main() {
 
 	ctx := ctrl.SetupSignalHandler()
 
+	// The extension server reads the HTTPRoutes generated by the controller directly from the API server.
+	scheme := runtime.NewScheme()
+	controller.MustInitializeScheme(scheme)
+	k8sClient, err := client.New(k8sConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "failed to create k8s client")
+		os.Exit(1)
+	}
+
 	// Start the extension server running alongside the controller.
 	s := grpc.NewServer()
-	extSrv := extensionserver.New(setupLog)
+	extSrv := extensionserver.New(k8sClient, setupLog)
 	extension.RegisterEnvoyGatewayExtensionServer(s, extSrv)
 	grpc_health_v1.RegisterHealthServer(s, extSrv)
 	go func() {
@@ -125,9 +282,20 @@ func main() {
 
 	// Start the controller.
 	if err := controller.StartControllers(ctx, k8sConfig, ctrl.Log.WithName("controller"), controller.Options{
-		ExtProcImage:         flagExtProcImage,
-		ExtProcLogLevel:      flagExtProcLogLevel,
-		EnableLeaderElection: flagEnableLeaderElection,
+		ExtProcImage:            flagExtProcImage,
+		ExtProcImagePullSecret:  flagExtProcImagePullSecret,
+		ExtProcLogLevel:         flagExtProcLogLevel,
+		EnableLeaderElection:    flagEnableLeaderElection,
+		ExtProcDefaultResources: flagExtProcDefaultResources,
+		WatchNamespaces:         flagWatchNamespaces,
+		NamespaceLabelSelector:  flagNamespaceLabelSelector,
+		LeaseDuration:           flagManager.leaseDuration,
+		RenewDeadline:           flagManager.renewDeadline,
+		RetryPeriod:             flagManager.retryPeriod,
+		HealthProbeBindAddress:  flagManager.healthProbeAddr,
+		MetricsBindAddress:      flagManager.metricsAddr,
+		// The extension server is checked through its listener so that the probes fail when it stops serving.
+		HealthChecks: map[string]healthz.Checker{"extension-server": extensionserver.HealthChecker(lis.Addr().String())},
 	}); err != nil {
 		setupLog.Error(err, "failed to start controller")
 	}
@@ -7,18 +7,36 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func Test_parseAndValidateFlags(t *testing.T) {
 	t.Run("no flags", func(t *testing.T) {
-		extProcLogLevel, extProcImage, enableLeaderElection, logLevel, extensionServerPort, err := parseAndValidateFlags([]string{})
+		extProcLogLevel, extProcImage, extProcImagePullSecret, enableLeaderElection, logLevel, extensionServerPort, extProcDefaultResources, watchNamespaces, namespaceLabelSelector, manager, err := parseAndValidateFlags([]string{})
 		require.Equal(t, "info", extProcLogLevel)
 		require.Equal(t, "docker.io/envoyproxy/ai-gateway-extproc:latest", extProcImage)
+		require.Empty(t, extProcImagePullSecret)
 		require.True(t, enableLeaderElection)
 		require.Equal(t, "info", logLevel.String())
 		require.Equal(t, ":1063", extensionServerPort)
+		require.Equal(t, corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		}, extProcDefaultResources)
+		require.Empty(t, watchNamespaces)
+		require.Nil(t, namespaceLabelSelector)
+		require.Equal(t, managerFlags{
+			leaseDuration:   15 * time.Second,
+			renewDeadline:   10 * time.Second,
+			retryPeriod:     2 * time.Second,
+			healthProbeAddr: ":8081",
+			metricsAddr:     ":8080",
+		}, manager)
 		require.NoError(t, err)
 	})
 	t.Run("all flags", func(t *testing.T) {
@@ -33,16 +51,43 @@ func Test_parseAndValidateFlags(t *testing.T) {
 				args := []string{
 					tc.dash + "extProcLogLevel=debug",
 					tc.dash + "extProcImage=example.com/extproc:latest",
+					tc.dash + "extProcImagePullSecret=my-registry-secret",
 					tc.dash + "enableLeaderElection=false",
 					tc.dash + "logLevel=debug",
 					tc.dash + "port=:8080",
+					tc.dash + "extProcDefaultRequestsCPU=",
+					tc.dash + "extProcDefaultRequestsMemory=256Mi",
+					tc.dash + "extProcDefaultLimitsCPU=2",
+					tc.dash + "extProcDefaultLimitsMemory=1Gi",
+					tc.dash + "watchNamespaces=foo, bar,",
+					tc.dash + "namespaceLabelSelector=team=ai,env notin (dev)",
+					tc.dash + "leaseDuration=1m",
+					tc.dash + "renewDeadline=30s",
+					tc.dash + "retryPeriod=5s",
+					tc.dash + "healthProbeAddr=:9091",
+					tc.dash + "metricsAddr=0",
 				}
-				extProcLogLevel, extProcImage, enableLeaderElection, logLevel, extensionServerPort, err := parseAndValidateFlags(args)
+				extProcLogLevel, extProcImage, extProcImagePullSecret, enableLeaderElection, logLevel, extensionServerPort, extProcDefaultResources, watchNamespaces, namespaceLabelSelector, manager, err := parseAndValidateFlags(args)
 				require.Equal(t, "debug", extProcLogLevel)
 				require.Equal(t, "example.com/extproc:latest", extProcImage)
+				require.Equal(t, "my-registry-secret", extProcImagePullSecret)
 				require.False(t, enableLeaderElection)
 				require.Equal(t, "debug", logLevel.String())
 				require.Equal(t, ":8080", extensionServerPort)
+				require.Equal(t, corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				}, extProcDefaultResources)
+				require.Equal(t, []string{"foo", "bar"}, watchNamespaces)
+				require.True(t, namespaceLabelSelector.Matches(labels.Set{"team": "ai", "env": "prod"}))
+				require.False(t, namespaceLabelSelector.Matches(labels.Set{"team": "ai", "env": "dev"}))
+				require.Equal(t, managerFlags{
+					leaseDuration:   time.Minute,
+					renewDeadline:   30 * time.Second,
+					retryPeriod:     5 * time.Second,
+					healthProbeAddr: ":9091",
+					metricsAddr:     "0",
+				}, manager)
 				require.NoError(t, err)
 			})
 		}
@@ -64,9 +109,49 @@ func Test_parseAndValidateFlags(t *testing.T) {
 				flags:  []string{"--logLevel=invalid"},
 				expErr: "invalid log level: \"invalid\"",
 			},
+			{
+				name:   "invalid extProcDefaultRequestsCPU",
+				flags:  []string{"--extProcDefaultRequestsCPU=lots"},
+				expErr: "invalid extProcDefaultRequestsCPU: \"lots\"",
+			},
+			{
+				name:   "invalid extProcDefaultLimitsMemory",
+				flags:  []string{"--extProcDefaultLimitsMemory=1GB"},
+				expErr: "invalid extProcDefaultLimitsMemory: \"1GB\"",
+			},
+			{
+				name:   "request exceeding limit",
+				flags:  []string{"--extProcDefaultRequestsMemory=1Gi"},
+				expErr: "the default memory request of the external processor 1Gi exceeds the limit 512Mi",
+			},
+			{
+				name:   "invalid watchNamespaces",
+				flags:  []string{"--watchNamespaces=foo,Bar_"},
+				expErr: "invalid namespace \"Bar_\" in watchNamespaces",
+			},
+			{
+				name:   "invalid namespaceLabelSelector",
+				flags:  []string{"--namespaceLabelSelector=team in ai"},
+				expErr: "invalid namespaceLabelSelector",
+			},
+			{
+				name:   "invalid leaseDuration",
+				flags:  []string{"--leaseDuration=15"},
+				expErr: "failed to parse flags",
+			},
+			{
+				name:   "renewDeadline not shorter than leaseDuration",
+				flags:  []string{"--renewDeadline=15s"},
+				expErr: "leaseDuration 15s, renewDeadline 15s and retryPeriod 2s must be positive and decreasing in this order",
+			},
+			{
+				name:   "non-positive retryPeriod",
+				flags:  []string{"--retryPeriod=0s"},
+				expErr: "leaseDuration 15s, renewDeadline 10s and retryPeriod 0s must be positive and decreasing in this order",
+			},
 		} {
 			t.Run(tc.name, func(t *testing.T) {
-				_, _, _, _, _, err := parseAndValidateFlags(tc.flags)
+				_, _, _, _, _, _, _, _, _, _, err := parseAndValidateFlags(tc.flags)
 				require.ErrorContains(t, err, tc.expErr)
 			})
 		}
@@ -10,9 +10,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
@@ -20,18 +22,36 @@ import (
 	"time"
 
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
 	"github.com/envoyproxy/ai-gateway/internal/extproc"
 	"github.com/envoyproxy/ai-gateway/internal/version"
 )
 
 // extProcFlags is the struct that holds the flags passed to the external processor.
 type extProcFlags struct {
-	configPath  string     // path to the configuration file.
-	extProcAddr string     // gRPC address for the external processor.
-	logLevel    slog.Level // log level for the external processor.
+	configPath   string     // path to the configuration file.
+	extProcAddr  string     // gRPC address for the external processor.
+	logLevel     slog.Level // log level for the external processor.
+	debugAddr    string     // HTTP address for the debug endpoints. Disabled when empty.
+	metricsAddr  string     // HTTP address for the Prometheus metrics. Disabled when empty.
+	otlpEndpoint string     // OTLP gRPC endpoint to export the tracing spans to. Disabled when empty.
+	validateOnly bool       // validate the configuration file and exit without starting the server.
+	// debugTranslate enables the translation dry-run endpoint on debugAddr.
+	debugTranslate bool
+	// maxBufferedBytes is the maximum number of bytes of the request bodies buffered by all the in-flight requests.
+	maxBufferedBytes int64
+	// staticConfigPath is the path to the static configuration file merged with the one at configPath. Optional.
+	staticConfigPath string
 }
 
 // parseAndValidateFlags parses and validates the flas passed to the external processor.
@@ -48,11 +68,53 @@ func parseAndValidateFlags(args []string) (extProcFlags, error) {
 		"path to the configuration file. The file must be in YAML format specified in filterapi.Config type. "+
 			"The configuration file is watched for changes.",
 	)
+	fs.StringVar(&flags.staticConfigPath,
+		"staticConfigPath",
+		"",
+		"path to the static configuration file merged with the one at configPath, for example, to add the backends "+
+			"built into the external processor. The rules of both files are concatenated, and the backends of the same name "+
+			"are resolved in favor of configPath. The schema, the metadataNamespace and the header keys must agree. "+
+			"The file is watched for changes as well.",
+	)
 	fs.StringVar(&flags.extProcAddr,
 		"extProcAddr",
 		":1063",
 		"gRPC address for the external processor. For example, :1063 or unix:///tmp/ext_proc.sock",
 	)
+	fs.StringVar(&flags.debugAddr,
+		"debugAddr",
+		"",
+		"HTTP address for the debug endpoints, for example, localhost:1064. The debug endpoints are disabled when empty.",
+	)
+	fs.StringVar(&flags.metricsAddr,
+		"metricsAddr",
+		"",
+		"HTTP address for the Prometheus metrics served on GET /metrics, for example, :1065. The metrics are disabled when empty.",
+	)
+	fs.BoolVar(&flags.debugTranslate,
+		"debugTranslate",
+		false,
+		"enable the POST /debug/translate endpoint on debugAddr, which runs the translators on the given request or "+
+			"response without sending anything to the backends. Must not be enabled on the address reachable by the clients.",
+	)
+	fs.StringVar(&flags.otlpEndpoint,
+		"otlpEndpoint",
+		"",
+		"OTLP gRPC endpoint to export the tracing spans to, for example, otel-collector:4317 or http://otel-collector:4317. "+
+			"Tracing is disabled when empty.",
+	)
+	fs.BoolVar(&flags.validateOnly,
+		"validateOnly",
+		false,
+		"validate the configuration file at configPath and exit without starting the external processor. "+
+			"The exit code is non-zero when the configuration is invalid.",
+	)
+	fs.Int64Var(&flags.maxBufferedBytes,
+		"maxBufferedBytes",
+		0,
+		"maximum number of bytes of the request bodies buffered by all the in-flight requests. The requests exceeding "+
+			"the limit are rejected with 503 Service Unavailable. Unlimited when zero. Overridden by maxBufferedBytes of the configuration file.",
+	)
 	logLevelPtr := fs.String(
 		"logLevel",
 		"info",
@@ -66,6 +128,9 @@ func parseAndValidateFlags(args []string) (extProcFlags, error) {
 	if flags.configPath == "" {
 		errs = append(errs, fmt.Errorf("configPath must be provided"))
 	}
+	if flags.maxBufferedBytes < 0 {
+		errs = append(errs, fmt.Errorf("maxBufferedBytes must not be negative"))
+	}
 	if err := flags.logLevel.UnmarshalText([]byte(*logLevelPtr)); err != nil {
 		errs = append(errs, fmt.Errorf("failed to unmarshal log level: %w", err))
 	}
@@ -80,6 +145,18 @@ func Main() {
 	if err != nil {
 		log.Fatalf("failed to parse and validate extProcFlags: %v", err)
 	}
+	if flags.validateOnly {
+		os.Exit(validateConfig(flags.configPath, os.Stdout, os.Stderr))
+	}
+
+	// exitCode is set when the external processor stops because of an error. This is deferred first so that
+	// the other deferred functions, such as the tracer shutdown, run before exiting.
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
 
 	l := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: flags.logLevel}))
 
@@ -87,6 +164,7 @@ func Main() {
 		slog.String("version", version.Version),
 		slog.String("address", flags.extProcAddr),
 		slog.String("configPath", flags.configPath),
+		slog.String("staticConfigPath", flags.staticConfigPath),
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -97,6 +175,20 @@ func Main() {
 		cancel()
 	}()
 
+	if flags.otlpEndpoint != "" {
+		tp, err := newTracerProvider(ctx, flags.otlpEndpoint)
+		if err != nil {
+			log.Fatalf("failed to create tracer provider: %v", err)
+		}
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		defer func() {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer shutdownCancel()
+			_ = tp.Shutdown(shutdownCtx)
+		}()
+	}
+
 	lis, err := net.Listen(listenAddress(flags.extProcAddr))
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
@@ -106,21 +198,159 @@ func Main() {
 	if err != nil {
 		log.Fatalf("failed to create external processor server: %v", err)
 	}
-	server.Register("/v1/chat/completions", extproc.NewChatCompletionProcessor)
-	server.Register("/v1/models", extproc.NewModelsProcessor)
+	server.SetMaxBufferedBytes(flags.maxBufferedBytes)
+	server.SetDebugTranslate(flags.debugTranslate)
+	RegisterProcessors(server)
 
-	if err := extproc.StartConfigWatcher(ctx, flags.configPath, server, l, time.Second*5); err != nil {
+	var watcher *extproc.ConfigWatcher
+	if flags.staticConfigPath != "" {
+		watcher, err = extproc.NewMergedConfigWatcher(ctx, flags.staticConfigPath, flags.configPath, server, l, time.Second*5)
+	} else {
+		watcher, err = extproc.NewConfigWatcher(ctx, flags.configPath, server, l, time.Second*5)
+	}
+	if err != nil {
 		log.Fatalf("failed to start config watcher: %v", err)
 	}
 
+	if flags.debugAddr != "" {
+		serveHTTP(ctx, flags.debugAddr, server.DebugHandler(), "debug endpoints", l)
+	}
+	if flags.metricsAddr != "" {
+		serveHTTP(ctx, flags.metricsAddr, server.MetricsHandler(), "metrics", l)
+	}
+
 	s := grpc.NewServer()
 	extprocv3.RegisterExternalProcessorServer(s, server)
 	grpc_health_v1.RegisterHealthServer(s, server)
+
+	// The server and the config watcher are stopped together: when either fails irrecoverably, the context of the
+	// group is canceled and the server is stopped gracefully.
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		return superviseConfigWatcher(gctx, watcher, server.SetServing, l, defaultWatcherBackoff)
+	})
+	g.Go(func() error {
+		<-gctx.Done()
+		s.GracefulStop()
+		return nil
+	})
+	g.Go(func() error {
+		if err := s.Serve(lis); err != nil {
+			return fmt.Errorf("failed to serve: %w", err)
+		}
+		return nil
+	})
+	if err = g.Wait(); err != nil {
+		l.Error("external processor stopped", slog.String("error", err.Error()))
+		exitCode = 1
+	}
+}
+
+// RegisterProcessors registers the built-in processors and the custom ones registered via the x package to the server.
+func RegisterProcessors(server *extproc.Server) {
+	server.Register("/v1/chat/completions", extproc.NewChatCompletionProcessor)
+	server.Register("/v1/models", extproc.NewModelsProcessor)
+	server.Register("/model/{modelId}/converse", extproc.NewConverseProcessor)
+	// The custom processors are registered after the built-in ones so that they take precedence.
+	for path, newProcessor := range x.ProcessorFactories() {
+		server.Register(path, extproc.NewCustomProcessorFactory(newProcessor))
+	}
+}
+
+// watcherBackoff is the backoff of restarting the config watcher.
+type watcherBackoff struct {
+	// initial is the delay before the first restart, doubled on every consecutive failure up to max.
+	initial, max time.Duration
+	// maxRetries is the number of consecutive failed restarts after which the watcher is irrecoverable.
+	maxRetries int
+}
+
+// defaultWatcherBackoff gives up on the config watcher after about five minutes of the config file unavailable.
+var defaultWatcherBackoff = watcherBackoff{initial: time.Second, max: 30 * time.Second, maxRetries: 15}
+
+// configWatcher is the interface of [extproc.ConfigWatcher] for testing purposes.
+type configWatcher interface {
+	Load(ctx context.Context) error
+	Run(ctx context.Context) error
+}
+
+// superviseConfigWatcher runs the config watcher until the context is done, in which case this returns nil.
+//
+// When the watcher fails because a config file is unavailable, the health is set to NOT_SERVING via setServing, and the
+// config file is retried with the exponential backoff. Once it loads again, the health is set back to SERVING and the
+// watcher is restarted. This returns an error when the config file is still unavailable after the maximum retries.
+func superviseConfigWatcher(ctx context.Context, w configWatcher, setServing func(bool), l *slog.Logger, b watcherBackoff) error {
+	for {
+		err := w.Run(ctx)
+		if err == nil {
+			return nil
+		}
+		setServing(false)
+		l.Error("config watcher failed; restarting", slog.String("error", err.Error()))
+		delay := b.initial
+		for retries := 0; ; retries++ {
+			if retries == b.maxRetries {
+				return fmt.Errorf("config watcher is irrecoverable after %d retries: %w", retries, err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, b.max)
+			// Errors other than the unavailable config file, such as an invalid config, keep the last valid config
+			// active, so the watcher can be restarted.
+			if err = w.Load(ctx); err == nil || !errors.Is(err, extproc.ErrConfigFileUnavailable) {
+				break
+			}
+			l.Error("config file is still unavailable", slog.Int("retries", retries+1), slog.String("error", err.Error()))
+		}
+		l.Info("config watcher recovered")
+		setServing(true)
+	}
+}
+
+// validateConfig validates the configuration file at the given path and returns the exit code.
+// Each validation error is written to stderr on its own line.
+func validateConfig(configPath string, stdout, stderr io.Writer) int {
+	if err := extproc.ValidateConfigFile(configPath); err != nil {
+		_, _ = fmt.Fprintf(stderr, "invalid configuration %s:\n%v\n", configPath, err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "configuration %s is valid\n", configPath)
+	return 0
+}
+
+// newTracerProvider creates the tracer provider exporting the spans to the given OTLP gRPC endpoint.
+func newTracerProvider(ctx context.Context, endpoint string) (*sdktrace.TracerProvider, error) {
+	var opt otlptracegrpc.Option
+	if strings.Contains(endpoint, "://") {
+		opt = otlptracegrpc.WithEndpointURL(endpoint)
+	} else {
+		opt = otlptracegrpc.WithEndpoint(endpoint)
+	}
+	exporter, err := otlptracegrpc.New(ctx, opt, otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "ai-gateway-extproc"))),
+	), nil
+}
+
+// serveHTTP serves the handler on the address in the background until the context is done.
+func serveHTTP(ctx context.Context, addr string, handler http.Handler, what string, l *slog.Logger) {
+	httpServer := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 5 * time.Second}
 	go func() {
 		<-ctx.Done()
-		s.GracefulStop()
+		_ = httpServer.Close()
+	}()
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.Error("failed to serve the "+what, slog.String("error", err.Error()))
+		}
 	}()
-	_ = s.Serve(lis)
 }
 
 // listenAddress returns the network and address for the given address flag.
@@ -6,21 +6,40 @@
 package mainlib
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc"
 )
 
 func Test_parseAndValidateFlags(t *testing.T) {
 	t.Run("ok extProcFlags", func(t *testing.T) {
 		for _, tc := range []struct {
-			name       string
-			args       []string
-			configPath string
-			addr       string
-			logLevel   slog.Level
+			name         string
+			args         []string
+			configPath   string
+			staticPath   string
+			addr         string
+			logLevel     slog.Level
+			debugAddr    string
+			metricsAddr  string
+			otlpEndpoint string
+			validateOnly bool
+			maxBuffered  int64
+			translate    bool
 		}{
 			{
 				name:       "minimal extProcFlags",
@@ -61,31 +80,64 @@ func Test_parseAndValidateFlags(t *testing.T) {
 				name: "all extProcFlags",
 				args: []string{
 					"-configPath", "/path/to/config.yaml",
+					"-staticConfigPath", "/path/to/static.yaml",
 					"-extProcAddr", "unix:///tmp/ext_proc.sock",
 					"-logLevel", "debug",
+					"-debugAddr", "localhost:1064",
+					"-metricsAddr", ":1065",
+					"-otlpEndpoint", "otel-collector:4317",
+					"-validateOnly",
+					"-maxBufferedBytes", "104857600",
+					"-debugTranslate",
 				},
-				configPath: "/path/to/config.yaml",
-				addr:       "unix:///tmp/ext_proc.sock",
-				logLevel:   slog.LevelDebug,
+				configPath:   "/path/to/config.yaml",
+				staticPath:   "/path/to/static.yaml",
+				addr:         "unix:///tmp/ext_proc.sock",
+				logLevel:     slog.LevelDebug,
+				debugAddr:    "localhost:1064",
+				metricsAddr:  ":1065",
+				otlpEndpoint: "otel-collector:4317",
+				validateOnly: true,
+				maxBuffered:  104857600,
+				translate:    true,
 			},
 		} {
 			t.Run(tc.name, func(t *testing.T) {
 				flags, err := parseAndValidateFlags(tc.args)
 				require.NoError(t, err)
 				assert.Equal(t, tc.configPath, flags.configPath)
+				assert.Equal(t, tc.staticPath, flags.staticConfigPath)
 				assert.Equal(t, tc.addr, flags.extProcAddr)
 				assert.Equal(t, tc.logLevel, flags.logLevel)
+				assert.Equal(t, tc.debugAddr, flags.debugAddr)
+				assert.Equal(t, tc.metricsAddr, flags.metricsAddr)
+				assert.Equal(t, tc.otlpEndpoint, flags.otlpEndpoint)
+				assert.Equal(t, tc.validateOnly, flags.validateOnly)
+				assert.Equal(t, tc.maxBuffered, flags.maxBufferedBytes)
+				assert.Equal(t, tc.translate, flags.debugTranslate)
 			})
 		}
 	})
 
 	t.Run("invalid extProcFlags", func(t *testing.T) {
-		_, err := parseAndValidateFlags([]string{"-logLevel", "invalid"})
+		_, err := parseAndValidateFlags([]string{"-logLevel", "invalid", "-maxBufferedBytes", "-1"})
 		assert.EqualError(t, err, `configPath must be provided
+maxBufferedBytes must not be negative
 failed to unmarshal log level: slog: level string "invalid": unknown name`)
 	})
 }
 
+func Test_newTracerProvider(t *testing.T) {
+	for _, endpoint := range []string{"localhost:4317", "http://localhost:4317"} {
+		t.Run(endpoint, func(t *testing.T) {
+			tp, err := newTracerProvider(t.Context(), endpoint)
+			require.NoError(t, err)
+			require.NotNil(t, tp)
+			require.NoError(t, tp.Shutdown(t.Context()))
+		})
+	}
+}
+
 func TestListenAddress(t *testing.T) {
 	tests := []struct {
 		addr        string
@@ -104,3 +156,167 @@ func TestListenAddress(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateConfig(t *testing.T) {
+	validPath := filepath.Join(t.TempDir(), "valid.yaml")
+	require.NoError(t, os.WriteFile(validPath, []byte(filterapi.DefaultConfig), 0o600))
+	invalidPath := filepath.Join(t.TempDir(), "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte(`schema:
+  name: OpenAI
+rules:
+- backends:
+  - name: foo
+    schema:
+      name: Unknown
+  headers:
+  - value: bar
+`), 0o600))
+
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 0, validateConfig(validPath, &stdout, &stderr))
+	require.Equal(t, "configuration "+validPath+" is valid\n", stdout.String())
+	require.Empty(t, stderr.String())
+
+	stdout.Reset()
+	require.Equal(t, 1, validateConfig(invalidPath, &stdout, &stderr))
+	require.Empty(t, stdout.String())
+	require.Equal(t, "invalid configuration "+invalidPath+`:
+line 7: rules[0].backends[0].schema.name: unknown API schema name "Unknown"
+line 9: rules[0].headers[0].name: header match name must not be empty
+`, stderr.String())
+}
+
+// nopConfigReceiver is the [extproc.ConfigReceiver] ignoring the configs.
+type nopConfigReceiver struct{}
+
+func (nopConfigReceiver) LoadConfig(context.Context, *filterapi.Config) error { return nil }
+
+// servingRecorder records the values passed to the setServing function.
+type servingRecorder struct {
+	mu     sync.Mutex
+	values []bool
+}
+
+func (r *servingRecorder) setServing(serving bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values = append(r.values, serving)
+}
+
+func (r *servingRecorder) get() []bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.values)
+}
+
+func Test_superviseConfigWatcher(t *testing.T) {
+	const tick = 10 * time.Millisecond
+	backoff := watcherBackoff{initial: tick, max: 4 * tick, maxRetries: 5}
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("config file transiently missing", func(t *testing.T) {
+		tmpdir := t.TempDir()
+		staticPath, path := filepath.Join(tmpdir, "static.yaml"), filepath.Join(tmpdir, "config.yaml")
+		require.NoError(t, os.WriteFile(staticPath, []byte(filterapi.DefaultConfig), 0o600))
+		w, err := extproc.NewMergedConfigWatcher(t.Context(), staticPath, path, nopConfigReceiver{}, l, tick)
+		require.NoError(t, err)
+
+		// The backoff is long enough for the file to be restored before the watcher is irrecoverable.
+		var rec servingRecorder
+		ctx, cancel := context.WithCancel(t.Context())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- superviseConfigWatcher(ctx, w, rec.setServing, l,
+				watcherBackoff{initial: tick, max: 100 * tick, maxRetries: 10})
+		}()
+
+		require.NoError(t, os.Remove(staticPath))
+		require.Eventually(t, func() bool {
+			return slices.Equal(rec.get(), []bool{false})
+		}, time.Second, tick)
+		require.NoError(t, os.WriteFile(staticPath, []byte(filterapi.DefaultConfig), 0o600))
+		require.Eventually(t, func() bool {
+			return slices.Equal(rec.get(), []bool{false, true})
+		}, 5*time.Second, tick)
+
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("irrecoverable", func(t *testing.T) {
+		tmpdir := t.TempDir()
+		staticPath, path := filepath.Join(tmpdir, "static.yaml"), filepath.Join(tmpdir, "config.yaml")
+		require.NoError(t, os.WriteFile(staticPath, []byte(filterapi.DefaultConfig), 0o600))
+		w, err := extproc.NewMergedConfigWatcher(t.Context(), staticPath, path, nopConfigReceiver{}, l, tick)
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(staticPath))
+
+		var rec servingRecorder
+		err = superviseConfigWatcher(t.Context(), w, rec.setServing, l, backoff)
+		require.ErrorIs(t, err, extproc.ErrConfigFileUnavailable)
+		require.ErrorContains(t, err, "config watcher is irrecoverable after 5 retries")
+		require.Equal(t, []bool{false}, rec.get())
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		w := &fakeConfigWatcher{runErrs: []error{extproc.ErrConfigFileUnavailable}, loadErrs: []error{extproc.ErrConfigFileUnavailable}}
+		ctx, cancel := context.WithCancel(t.Context())
+		var rec servingRecorder
+		w.onLoad = cancel
+		require.NoError(t, superviseConfigWatcher(ctx, w, rec.setServing, l, backoff))
+		require.Equal(t, []bool{false}, rec.get())
+	})
+
+	t.Run("backoff is reset on recovery", func(t *testing.T) {
+		unavailable := extproc.ErrConfigFileUnavailable
+		w := &fakeConfigWatcher{
+			// Each restart fails maxRetries-1 times before recovering, which never exhausts the retries.
+			runErrs:  []error{unavailable, unavailable, unavailable},
+			loadErrs: slices.Repeat([]error{unavailable, unavailable, unavailable, unavailable, nil}, 3),
+		}
+		var rec servingRecorder
+		require.NoError(t, superviseConfigWatcher(t.Context(), w, rec.setServing, l, backoff))
+		require.Equal(t, []bool{false, true, false, true, false, true}, rec.get())
+		require.Equal(t, 4, w.runs)
+	})
+
+	t.Run("invalid config restarts the watcher", func(t *testing.T) {
+		w := &fakeConfigWatcher{
+			runErrs:  []error{extproc.ErrConfigFileUnavailable},
+			loadErrs: []error{errors.New("invalid config")},
+		}
+		var rec servingRecorder
+		require.NoError(t, superviseConfigWatcher(t.Context(), w, rec.setServing, l, backoff))
+		require.Equal(t, []bool{false, true}, rec.get())
+	})
+}
+
+// fakeConfigWatcher is the configWatcher returning the given errors in order. Run returns nil once runErrs is
+// exhausted, as if the context is done.
+type fakeConfigWatcher struct {
+	runErrs, loadErrs []error
+	runs              int
+	onLoad            func()
+}
+
+func (f *fakeConfigWatcher) Run(context.Context) error {
+	f.runs++
+	if len(f.runErrs) == 0 {
+		return nil
+	}
+	err := f.runErrs[0]
+	f.runErrs = f.runErrs[1:]
+	return err
+}
+
+func (f *fakeConfigWatcher) Load(context.Context) error {
+	if f.onLoad != nil {
+		f.onLoad()
+	}
+	if len(f.loadErrs) == 0 {
+		return nil
+	}
+	err := f.loadErrs[0]
+	f.loadErrs = f.loadErrs[1:]
+	return err
+}
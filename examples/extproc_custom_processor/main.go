@@ -0,0 +1,103 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/cmd/extproc/mainlib"
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+// newTranscriptionProcessor implements [x.NewCustomProcessorFn].
+func newTranscriptionProcessor(config *filterapi.Config, requestHeaders map[string]string, logger *slog.Logger) (x.Processor, error) {
+	// The config is the one currently loaded, so the processor follows the configuration updates.
+	return &transcriptionProcessor{config: config, requestHeaders: requestHeaders, logger: logger}, nil
+}
+
+// transcriptionProcessor implements [x.Processor] for the audio transcription requests.
+type transcriptionProcessor struct {
+	config         *filterapi.Config
+	requestHeaders map[string]string
+	logger         *slog.Logger
+}
+
+// ProcessRequestHeaders implements [x.Processor.ProcessRequestHeaders].
+func (p *transcriptionProcessor) ProcessRequestHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	model := p.requestHeaders[p.config.ModelNameHeaderKey]
+	backend := p.selectBackend(model)
+	if backend == nil {
+		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
+				Body:   []byte(fmt.Sprintf(`{"error":{"type":"invalid_request_error","message":"model %q not found"}}`, model)),
+			},
+		}}, nil
+	}
+	p.logger.Debug("routing transcription request", slog.String("model", model), slog.String("backend", backend.Name))
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestHeaders{
+		RequestHeaders: &extprocv3.HeadersResponse{Response: &extprocv3.CommonResponse{
+			HeaderMutation: &extprocv3.HeaderMutation{SetHeaders: []*corev3.HeaderValueOption{
+				{Header: &corev3.HeaderValue{Key: p.config.SelectedBackendHeaderKey, RawValue: []byte(backend.Name)}},
+			}},
+			ClearRouteCache: true,
+		}},
+	}}, nil
+}
+
+// selectBackend returns the first backend with a positive weight of the rule matching the model, if any.
+func (p *transcriptionProcessor) selectBackend(model string) *filterapi.Backend {
+	for i := range p.config.Rules {
+		rule := &p.config.Rules[i]
+		for _, h := range rule.Headers {
+			if string(h.Name) != p.config.ModelNameHeaderKey || h.Value != model {
+				continue
+			}
+			for j := range rule.Backends {
+				if rule.Backends[j].Weight > 0 {
+					return &rule.Backends[j]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ProcessRequestBody implements [x.Processor.ProcessRequestBody].
+func (p *transcriptionProcessor) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	// The multipart request body is passed through as is.
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_RequestBody{}}, nil
+}
+
+// ProcessResponseHeaders implements [x.Processor.ProcessResponseHeaders].
+func (p *transcriptionProcessor) ProcessResponseHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{}}, nil
+}
+
+// ProcessResponseBody implements [x.Processor.ProcessResponseBody].
+func (p *transcriptionProcessor) ProcessResponseBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
+}
+
+// ProcessResponseTrailers implements [x.Processor.ProcessResponseTrailers].
+func (p *transcriptionProcessor) ProcessResponseTrailers(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseTrailers{}}, nil
+}
+
+// This demonstrates how to build a custom processor for the external processor.
+func main() {
+	// Registers the custom processor for the path not supported by the built-in processors.
+	x.RegisterProcessorFactory("/v1/audio/transcriptions", newTranscriptionProcessor)
+	// Executes the main function of the external processor.
+	mainlib.Main()
+}
@@ -0,0 +1,107 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/cmd/extproc/mainlib"
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+// toySchemaName is the API schema name of the toy inference server to be set in the backend schema.
+const toySchemaName = "Toy"
+
+// newCustomTranslatorFactory implements [x.NewCustomTranslatorFactory].
+func newCustomTranslatorFactory(input, output filterapi.VersionedAPISchema, path string) x.Translator {
+	if input.Name != filterapi.APISchemaOpenAI || output.Name != toySchemaName || path != "/v1/chat/completions" {
+		// Falls through to the built-in translators.
+		return nil
+	}
+	return &toyTranslator{}
+}
+
+// toyRequest is the request body of the toy inference server.
+type toyRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+// toyResponse is the response body of the toy inference server.
+type toyResponse struct {
+	Output       string `json:"output"`
+	InputTokens  uint32 `json:"input_tokens"`
+	OutputTokens uint32 `json:"output_tokens"`
+}
+
+// toyTranslator implements [x.Translator] between the OpenAI chat completion API and the toy inference server.
+type toyTranslator struct {
+	model string
+}
+
+// RequestBody implements [x.Translator.RequestBody].
+func (t *toyTranslator) RequestBody(body []byte) (path string, newBody []byte, err error) {
+	var req struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err = json.Unmarshal(body, &req); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal the chat completion request: %w", err)
+	}
+	t.model = req.Model
+	var prompt strings.Builder
+	for _, m := range req.Messages {
+		fmt.Fprintf(&prompt, "%s: %s\n", m.Role, m.Content)
+	}
+	newBody, err = json.Marshal(toyRequest{Model: req.Model, Prompt: prompt.String()})
+	return "/generate", newBody, err
+}
+
+// ResponseBody implements [x.Translator.ResponseBody].
+func (t *toyTranslator) ResponseBody(responseHeaders map[string]string, body []byte, endOfStream bool) (newBody []byte, usage x.TokenUsage, err error) {
+	// The toy inference server does not stream the response, and its errors are passed through as is.
+	if responseHeaders[":status"] != "200" || !endOfStream {
+		return body, usage, nil
+	}
+	var resp toyResponse
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return nil, usage, fmt.Errorf("failed to unmarshal the toy response: %w", err)
+	}
+	usage = x.TokenUsage{
+		InputTokens:  resp.InputTokens,
+		OutputTokens: resp.OutputTokens,
+		TotalTokens:  resp.InputTokens + resp.OutputTokens,
+	}
+	newBody, err = json.Marshal(map[string]any{
+		"object": "chat.completion",
+		"model":  t.model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]string{"role": "assistant", "content": resp.Output},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]uint32{
+			"prompt_tokens":     usage.InputTokens,
+			"completion_tokens": usage.OutputTokens,
+			"total_tokens":      usage.TotalTokens,
+		},
+	})
+	return newBody, usage, err
+}
+
+// This demonstrates how to build a custom translator for the external processor.
+func main() {
+	// Initializes the custom translator factory.
+	x.NewCustomTranslatorFactory = newCustomTranslatorFactory
+	// Executes the main function of the external processor.
+	mainlib.Main()
+}
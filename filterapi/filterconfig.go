@@ -54,8 +54,11 @@ modelNameHeaderKey: x-ai-eg-model
 //	  headers:
 //	  - name: x-ai-eg-model
 //	    value: llama3.3333
+//	  sessionAffinity:
+//	    headerName: x-user-id
 //	- backends:
 //	  - name: openai
+//	    weight: 1
 //	    schema:
 //	      name: OpenAI
 //	  headers:
@@ -66,17 +69,22 @@ modelNameHeaderKey: x-ai-eg-model
 // The model name header `x-ai-eg-model` is used in the header matching to make the routing decision. **After** the routing decision is made,
 // the selected backend name is populated in the header `x-ai-eg-selected-backend`. For example, when the model name is `llama3.3333`,
 // the request is routed to either backends `kserve` or `awsbedrock` with weights 1 and 10 respectively, and the selected
-// backend, say `awsbedrock`, is populated in the header `x-ai-eg-selected-backend`.
+// backend, say `awsbedrock`, is populated in the header `x-ai-eg-selected-backend`. Since the rule for `llama3.3333`
+// has the session affinity configured, requests with the same `x-user-id` header value always end up with the same backend.
 //
 // From Envoy configuration perspective, configuring the header matching based on `x-ai-eg-selected-backend` is enough to route the request to the selected backend.
 // That is because the matching decision is made by the filter and the selected backend is populated in the header `x-ai-eg-selected-backend`.
 type Config struct {
 	// UUID is the unique identifier of the filter configuration assigned by the AI Gateway when the configuration is updated.
 	UUID string `json:"uuid,omitempty"`
+	// RouteName is the name of the route that the filter configuration is generated for, in the form of
+	// "<namespace>/<name>" of the AIGatewayRoute. This is used to identify the route in the access log. Optional.
+	RouteName string `json:"routeName,omitempty"`
 	// MetadataNamespace is the namespace of the dynamic metadata to be used by the filter.
 	MetadataNamespace string `json:"metadataNamespace"`
 	// LLMRequestCost configures the cost of each LLM-related request. Optional. If this is provided, the filter will populate
 	// the "calculated" cost in the filter metadata at the end of the response body processing.
+	// The model and the backend names are populated as well with the keys "model" and "backend", or "unknown" if not known.
 	LLMRequestCosts []LLMRequestCost `json:"llmRequestCosts,omitempty"`
 	// InputSchema specifies the API schema of the input format of requests to the filter.
 	Schema VersionedAPISchema `json:"schema"`
@@ -88,6 +96,173 @@ type Config struct {
 	// Rules is the routing rules to be used by the filter to make the routing decision.
 	// Inside the routing rules, the header ModelNameHeaderKey may be used to make the routing decision.
 	Rules []RouteRule `json:"rules"`
+	// ConcurrencyLimit configures the limit of the in-flight requests per client identity. Optional.
+	ConcurrencyLimit *ConcurrencyLimit `json:"concurrencyLimit,omitempty"`
+	// StateStore configures the store of the state shared by the replicas of the external processor, such as the
+	// counters of ConcurrencyLimit. Optional. When unset, the state is kept in each replica, so the limits apply
+	// per replica.
+	StateStore *StateStore `json:"stateStore,omitempty"`
+	// MaxChoices is the maximum value of the OpenAI `n` parameter accepted for the backends that do not support
+	// multiple choices natively, such as AWS Bedrock. The filter fans out such a request into n upstream requests
+	// and merges the responses into a single response with n choices. Streaming requests with n > 1 are rejected
+	// for these backends. Optional. Defaults to 4 when unset.
+	MaxChoices int `json:"maxChoices,omitempty"`
+	// MaxStreamBufferSize is the maximum number of bytes of a streaming response that are buffered while they
+	// cannot be parsed into complete events, for example, when the upstream sends a malformed framing.
+	// When exceeded, the stream is aborted with an error. Optional. Defaults to 4MiB when unset.
+	MaxStreamBufferSize int `json:"maxStreamBufferSize,omitempty"`
+	// MaxBufferedBytes is the maximum number of bytes of the request bodies buffered by all the in-flight requests
+	// of the external processor. The request that would exceed the limit is rejected with 503 Service Unavailable.
+	// Optional. Defaults to the value of the -maxBufferedBytes flag of the external processor, which is unlimited by default.
+	MaxBufferedBytes int64 `json:"maxBufferedBytes,omitempty"`
+	// AllowRemoteImages enables the filter to fetch the images referred by the http(s) URLs in the chat completion
+	// requests and to inline them into the requests for the backends that only accept the image bytes, such as
+	// AWS Bedrock. Each image must be PNG, JPEG, GIF or WebP of at most 3.75MB, and is fetched within 10 seconds.
+	// When unset, such requests are rejected with 400 Bad Request. The requests to the OpenAI schema backends are
+	// passed through untouched regardless of this. Optional.
+	AllowRemoteImages bool `json:"allowRemoteImages,omitempty"`
+	// PathPrefix is the path prefix under which the endpoints such as /v1/chat/completions are exposed, for example,
+	// "/llm". The filter removes the prefix from the request path before it selects the processor for the path,
+	// and the backend receives the path without the prefix. The requests without the prefix are processed as well.
+	// Optional.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// EmitCostHeaders enables the filter to expose the token usage of the chat completion response to the client.
+	// The input, output, and total token counts are set to the x-ai-eg-input-tokens, x-ai-eg-output-tokens, and
+	// x-ai-eg-total-tokens headers for non-streaming responses, and to the trailers of the same names for streaming
+	// responses since the headers have already been sent. The values are the same as the ones used for LLMRequestCosts.
+	EmitCostHeaders bool `json:"emitCostHeaders,omitempty"`
+	// EmitLatencyHeaders enables the filter to expose the latencies of the chat completion response to the client.
+	// The time from forwarding the request to the backend to the first response body chunk, i.e. the time to first
+	// token, and the total duration of the upstream request are set to the x-ai-eg-time-to-first-token-ms and
+	// x-ai-eg-upstream-duration-ms headers in milliseconds, or to the trailers of the same names for the streaming
+	// responses. The same values are always set to the dynamic metadata with the keys "time_to_first_token_ms" and
+	// "upstream_duration_ms".
+	EmitLatencyHeaders bool `json:"emitLatencyHeaders,omitempty"`
+	// EstimateInputTokens enables the filter to estimate the input tokens of the chat completion request before it is
+	// forwarded to the backend. The estimate is set to the dynamic metadata with the key "estimated_input_tokens"
+	// when the request is forwarded, and the actual input tokens minus the estimate is set with the key
+	// "estimated_input_tokens_delta" at the end of the response. The estimator can be replaced via
+	// x.CustomInputTokenEstimator.
+	EstimateInputTokens bool `json:"estimateInputTokens,omitempty"`
+	// DefaultRouteDisabled is true when the catch-all route of the HTTPRoute has no backend. In that case, the filter
+	// responds to the requests for the paths it does not process with 404 Not Found in the OpenAI error format.
+	DefaultRouteDisabled bool `json:"defaultRouteDisabled,omitempty"`
+	// AccessLog enables the structured access log record emitted by the filter for each request when the request
+	// ends. The records are written to stdout as JSON lines unless the custom sink is registered via
+	// x.CustomAccessLogSink. Optional. The access log is disabled when unset.
+	AccessLog *AccessLogConfig `json:"accessLog,omitempty"`
+	// Streaming configures the handling of the streaming responses. Optional.
+	Streaming *StreamingConfig `json:"streaming,omitempty"`
+	// RequestIDPropagationDisabled disables the propagation of the request ID. By default, the filter forwards the
+	// x-request-id header of the request to the backend, generating one if absent, and returns the request ID assigned
+	// by the backend in the x-ai-eg-upstream-request-id response header. Both are also set to the dynamic metadata
+	// with the keys "request_id" and "upstream_request_id" so that the support tickets can be correlated.
+	RequestIDPropagationDisabled bool `json:"requestIDPropagationDisabled,omitempty"`
+	// PassiveHealthCheck enables the passive health checking of the backends by the router. Optional. The backends
+	// are never removed from the selection when unset.
+	PassiveHealthCheck *PassiveHealthCheck `json:"passiveHealthCheck,omitempty"`
+	// EmitConfigVersionHeader enables the filter to return the UUID of this configuration in the
+	// x-ai-eg-config-version response header, so that it can be told which configuration served the request
+	// while the new one is being rolled out to the external processors.
+	EmitConfigVersionHeader bool `json:"emitConfigVersionHeader,omitempty"`
+	// AllowedPaths is the list of the paths of the endpoints exposed by the filter, such as "/v1/chat/completions".
+	// The path can contain the path parameters in the same form as the registered processors, such as
+	// "/model/{modelId}/converse". The requests to the other paths are rejected with 404 Not Found in the OpenAI
+	// error format. Optional. All the registered paths are exposed when empty.
+	AllowedPaths []string `json:"allowedPaths,omitempty"`
+	// ModelPolicy restricts the models that can be requested regardless of the backend that would match. Optional.
+	// All the models are allowed when unset.
+	ModelPolicy *ModelPolicy `json:"modelPolicy,omitempty"`
+}
+
+// ModelPolicy restricts the models that can be requested through the filter.
+//
+// Each pattern is either the exact model name, or the prefix of the model names followed by "*", such as "gpt-4o*".
+// A model is allowed when it matches none of the Deny patterns, and either Allow is empty or the model matches one
+// of the Allow patterns. The requests for the other models are rejected with 403 Forbidden in the OpenAI error
+// format before the backend is selected.
+type ModelPolicy struct {
+	// Allow is the list of the patterns of the allowed models. Optional. All the models not denied are allowed when
+	// empty.
+	Allow []string `json:"allow,omitempty"`
+	// Deny is the list of the patterns of the denied models, which take precedence over Allow. Optional.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// PassiveHealthCheck configures the passive health checking of the backends by the router.
+//
+// The router counts the consecutive 5xx responses of each backend, including the ones generated by Envoy when the
+// backend cannot be connected, and removes the backend from the selection for the cooldown once the count reaches
+// the threshold. The backend is still selected when all the backends of the rule are removed, so that the rule
+// does not fail closed only because of the passive health checking.
+type PassiveHealthCheck struct {
+	// ConsecutiveFailures is the number of the consecutive 5xx responses after which the backend is removed from
+	// the selection. Optional. Defaults to 5 when unset or zero.
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+	// Cooldown is the duration, in the Go duration format such as "30s", for which the backend is removed from
+	// the selection. Optional. Defaults to 30s when unset.
+	Cooldown string `json:"cooldown,omitempty"`
+}
+
+// StreamingConfig configures the handling of the streaming responses.
+type StreamingConfig struct {
+	// HeartbeatInterval is the interval, in the Go duration format such as "15s", after which the filter injects
+	// the SSE comment line ": heartbeat" into the streaming response when nothing has been forwarded from the
+	// backend. This keeps the intermediate proxies from closing the idle connections while the model is slow
+	// to produce the following tokens, e.g., while reasoning or calling a tool. The heartbeats are only sent after
+	// the first chunk of the response body since Envoy does not accept the streamed body before that. Optional.
+	// The heartbeats are disabled when unset or zero.
+	//
+	// This requires the response body mode of the external processing filter to be overridable to
+	// FULL_DUPLEX_STREAMED.
+	HeartbeatInterval string `json:"heartbeatInterval,omitempty"`
+}
+
+// AccessLogConfig configures the access log of the filter.
+type AccessLogConfig struct {
+	// SampleRate is the fraction of the requests to be logged, between 0 and 1. Optional. Defaults to 1, i.e.
+	// all the requests are logged.
+	SampleRate *float64 `json:"sampleRate,omitempty"`
+}
+
+// ConcurrencyLimit configures the maximum number of in-flight requests per client identity.
+//
+// The client identity is the value of the request header IdentityHeaderKey. Requests without the header are not limited.
+// When a new request would exceed the limit, it is rejected with 429 Too Many Requests and an OpenAI error body.
+// The counters are released when the external processing stream ends, including when the client aborts the request.
+type ConcurrencyLimit struct {
+	// IdentityHeaderKey is the request header whose value identifies the client.
+	IdentityHeaderKey string `json:"identityHeaderKey"`
+	// MaxStreamingRequests is the maximum number of concurrent streaming requests per identity.
+	// Zero means unlimited.
+	MaxStreamingRequests int `json:"maxStreamingRequests,omitempty"`
+	// MaxNonStreamingRequests is the maximum number of concurrent non-streaming requests per identity.
+	// This is counted separately from the streaming requests. Zero means unlimited.
+	MaxNonStreamingRequests int `json:"maxNonStreamingRequests,omitempty"`
+}
+
+// StateStoreType specifies the type of the StateStore.
+type StateStoreType string
+
+// StateStoreTypeRedis is the Redis state store.
+const StateStoreTypeRedis StateStoreType = "Redis"
+
+// StateStore configures the store of the state shared by the replicas of the external processor.
+//
+// When the store is unreachable, the external processor falls back to the state local to the replica, i.e. it fails
+// open to the per-replica limits, until the store is reachable again.
+type StateStore struct {
+	// Type is the type of the store. Only StateStoreTypeRedis is supported.
+	Type StateStoreType `json:"type"`
+	// Address is the address of the store in the form of "host:port".
+	Address string `json:"address"`
+	// TLS enables TLS for the connections to the store.
+	TLS bool `json:"tls,omitempty"`
+	// KeyPrefix is prepended to the keys of the state, so that multiple external processors can share the store.
+	// Optional.
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// PasswordFile is the path to the file containing the password of the store. Optional.
+	PasswordFile string `json:"passwordFile,omitempty"`
 }
 
 // LLMRequestCost specifies "where" the request cost is stored in the filter metadata as well as
@@ -105,6 +280,32 @@ type LLMRequestCost struct {
 	// CEL is the CEL expression to calculate the cost of the request.
 	// This is not empty when the Type is LLMRequestCostTypeCEL.
 	CEL string `json:"cel,omitempty"`
+	// ModelPriceTable is the per-model price table to calculate the cost of the request.
+	// This is not nil when the Type is LLMRequestCostTypeModelPriceTable.
+	ModelPriceTable *ModelPriceTable `json:"modelPriceTable,omitempty"`
+}
+
+// ModelPriceTable corresponds to LLMRequestCostModelPriceTable in api/v1alpha1/api.go.
+//
+// The cost is calculated as (input_tokens * InputTokenPrice + output_tokens * OutputTokenPrice) * Multiplier,
+// rounded to the nearest integer.
+type ModelPriceTable struct {
+	// Prices maps the model names to their prices. A key ending with "*" matches the models by the prefix.
+	Prices map[string]ModelPrice `json:"prices"`
+	// Default is the price of the models that do not match any of the prices. Optional.
+	Default *ModelPrice `json:"default,omitempty"`
+	// Strict fails the cost calculation of the models that do not match any of the prices.
+	Strict bool `json:"strict,omitempty"`
+	// Multiplier scales the cost before rounding it to an integer. Zero is treated as 1.
+	Multiplier int64 `json:"multiplier,omitempty"`
+}
+
+// ModelPrice is the price per token of a model.
+type ModelPrice struct {
+	// InputTokenPrice is the price per input token.
+	InputTokenPrice float64 `json:"inputTokenPrice"`
+	// OutputTokenPrice is the price per output token.
+	OutputTokenPrice float64 `json:"outputTokenPrice"`
 }
 
 // LLMRequestCostType specifies the kind of the request cost calculation.
@@ -119,6 +320,8 @@ const (
 	LLMRequestCostTypeTotalToken LLMRequestCostType = "TotalToken"
 	// LLMRequestCostTypeCEL specifies that the request cost is calculated from the CEL expression.
 	LLMRequestCostTypeCEL LLMRequestCostType = "CEL"
+	// LLMRequestCostTypeModelPriceTable specifies that the request cost is calculated from the per-model price table.
+	LLMRequestCostTypeModelPriceTable LLMRequestCostType = "ModelPriceTable"
 )
 
 // VersionedAPISchema corresponds to VersionedAPISchema in api/v1alpha1/api.go.
@@ -135,20 +338,80 @@ type APISchemaName string
 const (
 	APISchemaOpenAI     APISchemaName = "OpenAI"
 	APISchemaAWSBedrock APISchemaName = "AWSBedrock"
+	APISchemaCohere     APISchemaName = "Cohere"
+	APISchemaMistral    APISchemaName = "Mistral"
 )
 
 // HeaderMatch is an alias for HTTPHeaderMatch of the Gateway API.
+//
+// In addition to the match types of the Gateway API, the Type can be [HeaderMatchPrefix].
 type HeaderMatch = gwapiv1.HTTPHeaderMatch
 
+// HeaderMatchPrefix is the header match type that matches the header value by the prefix.
+const HeaderMatchPrefix gwapiv1.HeaderMatchType = "Prefix"
+
 // RouteRule corresponds to AIGatewayRoute in api/v1alpha1/api.go
 // besides the `Backends` field is modified to abstract the concept of a backend
 // at Envoy Gateway level to a simple name.
 type RouteRule struct {
 	// Headers is the list of headers to match for the routing decision.
-	// Currently, only exact match is supported.
+	// Currently, only exact and prefix matches are supported.
 	Headers []HeaderMatch `json:"headers"`
 	// Backends is the list of backends to which the request should be routed to when the headers match.
 	Backends []Backend `json:"backends"`
+	// SessionAffinity configures the sticky backend selection for this rule. Optional.
+	// When this is not set, the backend is selected randomly based on the weights.
+	SessionAffinity *SessionAffinity `json:"sessionAffinity,omitempty"`
+	// ModelDefaults is the default values of the inference parameters applied when the request does not set them. Optional.
+	ModelDefaults *ModelDefaults `json:"modelDefaults,omitempty"`
+	// ModelLimits is the maximum values of the inference parameters. Optional.
+	ModelLimits *ModelLimits `json:"modelLimits,omitempty"`
+	// HeaderModifications is the modifications of the request headers applied before the ones of the backend. Optional.
+	HeaderModifications *HeaderModifications `json:"headerModifications,omitempty"`
+	// Default marks the rule selected when no rule matches the request. By default, such a request is rejected with
+	// 404 Not Found and the OpenAI error of the type "model_not_found". The headers of the default rule are still
+	// matched as usual. At most one rule can be the default.
+	Default bool `json:"default,omitempty"`
+	// Priority is the precedence of this rule when the request matches more than one rule. The rule with the greater
+	// priority wins over the more specific matches of the other rules. Optional, defaults to 0.
+	Priority int `json:"priority,omitempty"`
+}
+
+// ModelDefaults corresponds to AIGatewayRouteRuleModelDefaults in api/v1alpha1/api.go.
+type ModelDefaults struct {
+	// Temperature is the default sampling temperature.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// TopP is the default nucleus sampling probability.
+	TopP *float64 `json:"topP,omitempty"`
+	// MaxTokens is the default maximum number of tokens to generate, which is set when the request sets neither
+	// max_completion_tokens nor max_tokens.
+	MaxTokens *int64 `json:"maxTokens,omitempty"`
+}
+
+// ModelLimits corresponds to AIGatewayRouteRuleModelLimits in api/v1alpha1/api.go.
+type ModelLimits struct {
+	// MaxTemperature is the maximum sampling temperature.
+	MaxTemperature *float64 `json:"maxTemperature,omitempty"`
+	// MaxTopP is the maximum nucleus sampling probability.
+	MaxTopP *float64 `json:"maxTopP,omitempty"`
+	// MaxTokens is the maximum number of tokens to generate, which limits both of max_completion_tokens and
+	// max_tokens.
+	MaxTokens *int64 `json:"maxTokens,omitempty"`
+	// MaxStopSequences is the maximum number of the stop sequences.
+	MaxStopSequences *int `json:"maxStopSequences,omitempty"`
+	// Strict rejects the requests exceeding the limits instead of clamping the values.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// SessionAffinity corresponds to AIGatewayRouteRuleSessionAffinity in api/v1alpha1/api.go.
+//
+// When the header is present in the request, the backend is selected by consistent hashing over
+// the header value while respecting the backend weights. Therefore, requests with the same header value
+// are routed to the same backend as long as the set of backends does not change. When the header
+// is absent, the backend is selected randomly based on the weights.
+type SessionAffinity struct {
+	// HeaderName is the name of the request header whose value is used as the hashing key.
+	HeaderName string `json:"headerName"`
 }
 
 // Backend corresponds to AIGatewayRouteRuleBackendRef in api/v1alpha1/api.go
@@ -159,11 +422,137 @@ type Backend struct {
 	Name string `json:"name"`
 	// Schema specifies the API schema of the output format of requests from.
 	Schema VersionedAPISchema `json:"schema"`
-	// Weight is the weight of the backend in the routing decision.
+	// Weight is the weight of the backend in the routing decision. The backend with zero weight never receives the
+	// traffic, and the rule with no backend with a positive weight rejects the requests with 503 Service Unavailable.
 	Weight int `json:"weight"`
 	// Auth is the authn/z configuration for the backend. Optional.
 	// TODO: refactor after https://github.com/envoyproxy/ai-gateway/pull/43.
 	Auth *BackendAuth `json:"auth,omitempty"`
+	// GuardrailConfig is the AWS Bedrock guardrail configuration applied to every request to this backend. Optional.
+	GuardrailConfig *GuardrailConfig `json:"guardrailConfig,omitempty"`
+	// OpenAI is the OpenAI specific configuration of the backend. Optional.
+	OpenAI *OpenAIConfig `json:"openAI,omitempty"`
+	// AWSBedrock is the AWS Bedrock specific configuration of the backend. Optional.
+	AWSBedrock *AWSBedrockConfig `json:"awsBedrock,omitempty"`
+	// UnsupportedFieldPolicy specifies how the request fields not supported by the backend are handled.
+	// Optional, and defaults to [UnsupportedFieldPolicyIgnore].
+	UnsupportedFieldPolicy UnsupportedFieldPolicy `json:"unsupportedFieldPolicy,omitempty"`
+	// ParameterNormalization rescales the sampling parameters of the requests to the ranges of the models. Optional.
+	ParameterNormalization *ParameterNormalization `json:"parameterNormalization,omitempty"`
+	// HeaderModifications is the modifications of the request headers sent to this backend. Optional.
+	HeaderModifications *HeaderModifications `json:"headerModifications,omitempty"`
+	// PathOverride is the path of the requests sent to this backend replacing the one of the API schema, where
+	// "{model}" is replaced with the escaped model name. For the AWSBedrock schema, the operation at the end of the path
+	// is replaced with its streaming counterpart for the streaming requests. Optional.
+	PathOverride string `json:"pathOverride,omitempty"`
+}
+
+// HeaderModifications corresponds to the HeaderModifications of AIGatewayRouteRule and AIServiceBackendSpec
+// in api/v1alpha1/api.go.
+//
+// The values of the added or set headers can contain the substitutions "${model}" and "${backend}",
+// which are replaced with the model name of the request and the name of the selected backend.
+type HeaderModifications struct {
+	// Add is the headers appended to the existing values.
+	Add []Header `json:"add,omitempty"`
+	// Set is the headers overwriting the existing values.
+	Set []Header `json:"set,omitempty"`
+	// Remove is the names of the headers to be removed.
+	Remove []string `json:"remove,omitempty"`
+}
+
+// Header is the name and value of a header.
+type Header struct {
+	// Name is the name of the header.
+	Name string `json:"name"`
+	// Value is the value of the header.
+	Value string `json:"value"`
+}
+
+// UnsupportedFieldPolicy corresponds to UnsupportedFieldPolicy in api/v1alpha1/api.go.
+type UnsupportedFieldPolicy string
+
+const (
+	// UnsupportedFieldPolicyIgnore silently drops the unsupported fields.
+	UnsupportedFieldPolicyIgnore UnsupportedFieldPolicy = "Ignore"
+	// UnsupportedFieldPolicyWarn drops the unsupported fields and lists them in the response header.
+	UnsupportedFieldPolicyWarn UnsupportedFieldPolicy = "Warn"
+	// UnsupportedFieldPolicyReject rejects the request setting the unsupported fields with 400 Bad Request.
+	UnsupportedFieldPolicyReject UnsupportedFieldPolicy = "Reject"
+)
+
+// ParameterNormalization corresponds to ParameterNormalization in api/v1alpha1/api.go.
+type ParameterNormalization struct {
+	// Mode is the mode of the normalization.
+	Mode ParameterNormalizationMode `json:"mode"`
+	// TemperatureScale is the factor multiplied to the temperature in [ParameterNormalizationModeExplicit]. Optional.
+	TemperatureScale *float64 `json:"temperatureScale,omitempty"`
+	// TopPScale is the factor multiplied to the top_p in [ParameterNormalizationModeExplicit]. Optional.
+	TopPScale *float64 `json:"topPScale,omitempty"`
+	// Debug records the adjusted parameters in the response header.
+	Debug bool `json:"debug,omitempty"`
+}
+
+// ParameterNormalizationMode corresponds to ParameterNormalizationMode in api/v1alpha1/api.go.
+type ParameterNormalizationMode string
+
+const (
+	// ParameterNormalizationModeAuto derives the scale factors from the model family of the request.
+	ParameterNormalizationModeAuto ParameterNormalizationMode = "Auto"
+	// ParameterNormalizationModeExplicit uses the scale factors of the [ParameterNormalization].
+	ParameterNormalizationModeExplicit ParameterNormalizationMode = "Explicit"
+)
+
+// AWSBedrockConfig corresponds to AIServiceBackendAWSBedrockConfig in api/v1alpha1/api.go.
+type AWSBedrockConfig struct {
+	// BedrockAPI is the AWS Bedrock API that the requests are translated to. Optional, and defaults to
+	// [AWSBedrockAPIConverse].
+	BedrockAPI AWSBedrockAPI `json:"bedrockAPI,omitempty"`
+	// ModelPrefix is prepended to the model ID of the requests, such as "us." of the cross-region inference profiles.
+	// Optional.
+	ModelPrefix string `json:"modelPrefix,omitempty"`
+	// ModelFamilies extends the built-in table of the model families of which the models need the special handling
+	// in the translation, such as the Amazon Titan Text models rejecting the system messages. These are looked up in
+	// order before the built-in ones, and the first one of which the prefix matches the model ID wins. Optional.
+	ModelFamilies []AWSBedrockModelFamily `json:"modelFamilies,omitempty"`
+}
+
+// AWSBedrockModelFamily corresponds to AWSBedrockModelFamily in api/v1alpha1/api.go.
+type AWSBedrockModelFamily struct {
+	// ModelPrefix is the prefix of the model IDs of the family without the region prefix of the cross-region
+	// inference profile, e.g. "amazon.titan-text". The empty prefix matches all the models.
+	ModelPrefix string `json:"modelPrefix"`
+	// NoSystemMessages is true when the models reject the system content blocks of the Converse API. The system and
+	// developer messages are folded into the first user message instead.
+	NoSystemMessages bool `json:"noSystemMessages,omitempty"`
+}
+
+// AWSBedrockAPI corresponds to AWSBedrockAPI in api/v1alpha1/api.go.
+type AWSBedrockAPI string
+
+const (
+	// AWSBedrockAPIConverse is the Converse API.
+	AWSBedrockAPIConverse AWSBedrockAPI = "Converse"
+	// AWSBedrockAPIInvokeModel is the InvokeModel API.
+	AWSBedrockAPIInvokeModel AWSBedrockAPI = "InvokeModel"
+)
+
+// OpenAIConfig corresponds to AIServiceBackendOpenAIConfig in api/v1alpha1/api.go.
+type OpenAIConfig struct {
+	// Organization is the value of the "OpenAI-Organization" header. Optional.
+	Organization string `json:"organization,omitempty"`
+	// Project is the value of the "OpenAI-Project" header. Optional.
+	Project string `json:"project,omitempty"`
+}
+
+// GuardrailConfig corresponds to AWSBedrockGuardrailConfig in api/v1alpha1/api.go.
+type GuardrailConfig struct {
+	// Identifier is the identifier of the guardrail.
+	Identifier string `json:"identifier"`
+	// Version is the version of the guardrail.
+	Version string `json:"version"`
+	// Trace is the trace behavior of the guardrail. Optional.
+	Trace string `json:"trace,omitempty"`
 }
 
 // BackendAuth corresponds partially to BackendSecurityPolicy in api/v1alpha1/api.go.
@@ -172,6 +561,8 @@ type BackendAuth struct {
 	APIKey *APIKeyAuth `json:"apiKey,omitempty"`
 	// AWSAuth specifies the location of the AWS credential file and region.
 	AWSAuth *AWSAuth `json:"aws,omitempty"`
+	// AzureAuth specifies the location of the Azure access token file.
+	AzureAuth *AzureAuth `json:"azure,omitempty"`
 }
 
 // AWSAuth defines the credentials needed to access AWS.
@@ -180,9 +571,25 @@ type AWSAuth struct {
 	Region             string `json:"region"`
 }
 
+// AzureAuth defines the file containing the Microsoft Entra ID access token that will be mounted to the external proc.
+type AzureAuth struct {
+	Filename string `json:"filename"`
+}
+
 // APIKeyAuth defines the file that will be mounted to the external proc.
 type APIKeyAuth struct {
 	Filename string `json:"filename"`
+	// Directory is the directory containing the API keys in the files "apiKey-0", "apiKey-1", and so on, which are
+	// rotated per request by the weighted round-robin. When set, Filename is not used.
+	Directory string `json:"directory,omitempty"`
+	// Weights is the weights of the API keys in Directory in the order of their indexes. The API keys without
+	// a weight, or with zero, have the weight of 1.
+	Weights []int `json:"weights,omitempty"`
+	// HeaderName is the name of the request header carrying the API key. Defaults to "Authorization".
+	// When set to other than "Authorization", the "Authorization" header sent by the client is removed.
+	HeaderName string `json:"headerName,omitempty"`
+	// ValueTemplate is the value of the header where "{key}" is replaced with the API key. Defaults to "Bearer {key}".
+	ValueTemplate string `json:"valueTemplate,omitempty"`
 }
 
 // UnmarshalConfigYaml reads the file at the given path and unmarshals it into a Config struct.
@@ -7,7 +7,14 @@
 package x
 
 import (
+	"context"
 	"errors"
+	"log/slog"
+	"maps"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 )
@@ -19,6 +26,10 @@ var NewCustomRouter NewCustomRouterFn
 // ErrNoMatchingRule is the error the router function must return if there is no matching rule.
 var ErrNoMatchingRule = errors.New("no matching rule found")
 
+// ErrNoAvailableBackend is the error the router function must return if the matching rule has no backend that can
+// receive the traffic, for example, when all the backends of the rule have zero weight.
+var ErrNoAvailableBackend = errors.New("no backend with a non-zero weight")
+
 // NewCustomRouterFn is the function signature for [NewCustomRouter].
 //
 // It accepts the exptproc config passed to the AI Gateway filter and returns a [Router].
@@ -39,3 +50,151 @@ type Router interface {
 	// Returns the backend.
 	Calculate(requestHeaders map[string]string) (backend *filterapi.Backend, err error)
 }
+
+// NewCustomTranslatorFactory is the function to create a custom translator for the requests routed to a backend.
+// This is nil by default and can be set by the custom build of external processor, for example, to support the
+// proprietary API of an in-house inference server. When set, this is consulted before the built-in translators.
+var NewCustomTranslatorFactory NewCustomTranslatorFactoryFn
+
+// NewCustomTranslatorFactoryFn is the function signature for [NewCustomTranslatorFactory].
+//
+// It accepts the API schema of the requests, the API schema of the selected backend and the request path, and returns
+// a [Translator]. This is called per request once the backend is selected. Returning nil falls through to the
+// built-in translator of the schema pair.
+type NewCustomTranslatorFactoryFn func(input, output filterapi.VersionedAPISchema, path string) Translator
+
+// Translator is the interface for the translator of the request and the response between the API schemas.
+//
+// Translator is created per request and does not need to be goroutine-safe.
+type Translator interface {
+	// RequestBody translates the request body in the input schema to the one in the output schema.
+	//
+	// Returns the path of the backend request, which can be empty to keep the original one, and the translated body.
+	RequestBody(body []byte) (path string, newBody []byte, err error)
+	// ResponseBody translates the response body in the output schema to the one in the input schema. This is called
+	// for each chunk of the streaming response, or once with the entire body otherwise, including the error responses.
+	// The response headers include the ":status" pseudo header.
+	//
+	// Returns the translated body and the token usage found in the body, if any.
+	ResponseBody(responseHeaders map[string]string, body []byte, endOfStream bool) (newBody []byte, usage TokenUsage, err error)
+}
+
+// TokenUsage is the token usage reported by the backend, which is used for the token rate limiting.
+type TokenUsage struct {
+	// InputTokens is the number of the input tokens.
+	InputTokens uint32
+	// OutputTokens is the number of the output tokens.
+	OutputTokens uint32
+	// TotalTokens is the total number of the tokens.
+	TotalTokens uint32
+}
+
+// CustomInputTokenEstimator is the estimator of the input tokens used instead of the default one when
+// filterapi.Config.EstimateInputTokens is enabled, for example, to use the tokenizer of the model. This is nil by
+// default and can be set by the custom build of external processor.
+var CustomInputTokenEstimator InputTokenEstimator
+
+// InputTokenEstimator is the interface to estimate the number of the input tokens of the request before it is
+// forwarded to the backend.
+//
+// InputTokenEstimator must be goroutine-safe as it is shared across multiple requests.
+type InputTokenEstimator interface {
+	// EstimateInputTokens returns the estimated number of the input tokens of the request body in the OpenAI chat
+	// completion schema for the model. The request is forwarded without the estimate if this returns an error.
+	EstimateInputTokens(model string, body []byte) (uint32, error)
+}
+
+// CustomAccessLogSink is the sink of the access log records used instead of the default one writing the records
+// to stdout as JSON lines, for example, to send the records to Kafka. This is nil by default and can be set by
+// the custom build of external processor. The records are only emitted when filterapi.Config.AccessLog is set.
+var CustomAccessLogSink AccessLogSink
+
+// AccessLogSink is the interface for the sink of the access log records.
+//
+// AccessLogSink must be goroutine-safe as it is shared across multiple requests.
+type AccessLogSink interface {
+	// Write writes the access log record of the request. This is called once per request when the request ends,
+	// and must not block for long since it is called in the request path. The record must not be retained
+	// after the call returns.
+	Write(ctx context.Context, record *AccessLogRecord)
+}
+
+// AccessLogRecord is the access log record of a request processed by the AI Gateway filter.
+type AccessLogRecord struct {
+	// Timestamp is the time when the request headers were received.
+	Timestamp time.Time `json:"timestamp"`
+	// RequestID is the value of the x-request-id header set by Envoy, to correlate with the Envoy access log.
+	// When the header is absent, this is the request ID generated by the filter and sent to the backend.
+	RequestID string `json:"request_id,omitempty"`
+	// UpstreamRequestID is the request ID assigned by the backend, if returned in the response headers.
+	UpstreamRequestID string `json:"upstream_request_id,omitempty"`
+	// Route is the filterapi.Config.RouteName of the configuration that processed the request.
+	Route string `json:"route,omitempty"`
+	// Path is the path of the request.
+	Path string `json:"path,omitempty"`
+	// Backend is the name of the selected backend. Empty if the request was not routed to any backend.
+	Backend string `json:"backend,omitempty"`
+	// Model is the model name of the request.
+	Model string `json:"model,omitempty"`
+	// Status is the HTTP status code of the response. Zero if the response status is not known to the filter,
+	// for example, when the stream was aborted before the response.
+	Status int `json:"status,omitempty"`
+	// Stream is true if the request is a streaming request.
+	Stream bool `json:"stream"`
+	// InputTokens is the number of the input tokens reported by the backend.
+	InputTokens uint32 `json:"input_tokens"`
+	// OutputTokens is the number of the output tokens reported by the backend.
+	OutputTokens uint32 `json:"output_tokens"`
+	// TotalTokens is the total number of the tokens reported by the backend.
+	TotalTokens uint32 `json:"total_tokens"`
+	// DurationMillis is the duration from when the request headers were received to when the request ended.
+	DurationMillis int64 `json:"duration_ms"`
+	// TimeToFirstTokenMillis is the duration from when the request was forwarded to the backend to when the first
+	// chunk of the response body was received. Zero if the response body was not received.
+	TimeToFirstTokenMillis int64 `json:"time_to_first_token_ms,omitempty"`
+	// Error is the error that the filter failed to process the request with, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// processorFactories is the custom processor factories registered by [RegisterProcessorFactory], keyed by the path.
+var processorFactories = map[string]NewCustomProcessorFn{}
+
+// RegisterProcessorFactory registers the factory of the custom processor for the requests to the given path, for
+// example, to support an endpoint such as "/v1/audio/transcriptions" that the built-in processors do not handle.
+// The path can contain the path parameters of the form "{name}", each matching a single non-empty path segment.
+// The custom processor takes precedence over the built-in one registered for the same path, if any.
+//
+// This must be called by the custom build of external processor before the external processor starts, e.g. before
+// calling mainlib.Main. Registering the same path again replaces the previous factory.
+func RegisterProcessorFactory(path string, factory NewCustomProcessorFn) {
+	processorFactories[path] = factory
+}
+
+// ProcessorFactories returns the custom processor factories registered by [RegisterProcessorFactory], keyed by the path.
+func ProcessorFactories() map[string]NewCustomProcessorFn {
+	return maps.Clone(processorFactories)
+}
+
+// NewCustomProcessorFn is the function signature of the factory passed to [RegisterProcessorFactory].
+//
+// It accepts the extproc config currently loaded by the AI Gateway filter, the request headers and the logger of the
+// request, and returns a [Processor]. This is called per request, so the processor always sees the latest config
+// once the new configuration is loaded. The config must not be modified.
+type NewCustomProcessorFn func(config *filterapi.Config, requestHeaders map[string]string, logger *slog.Logger) (Processor, error)
+
+// Processor is the interface for the processor of the requests to a path, which handles the messages of
+// the Envoy external processing protocol for the request.
+//
+// Processor is created per request and does not need to be goroutine-safe.
+type Processor interface {
+	// ProcessRequestHeaders processes the request headers message.
+	ProcessRequestHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error)
+	// ProcessRequestBody processes the request body message.
+	ProcessRequestBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error)
+	// ProcessResponseHeaders processes the response headers message.
+	ProcessResponseHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error)
+	// ProcessResponseBody processes the response body message.
+	ProcessResponseBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error)
+	// ProcessResponseTrailers processes the response trailers message.
+	ProcessResponseTrailers(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error)
+}
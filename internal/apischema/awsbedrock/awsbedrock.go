@@ -98,7 +98,20 @@ type SystemContentBlock struct {
 	GuardContent *GuardrailConverseContentBlock `json:"guardContent,omitempty"`
 
 	// A system prompt for the model.
-	Text string `json:"text"`
+	Text string `json:"text,omitempty"`
+
+	// CachePoint marks the end of the system prompt prefix to be cached.
+	CachePoint *CachePointBlock `json:"cachePoint,omitempty"`
+}
+
+// CachePointTypeDefault is the only type of [CachePointBlock].
+const CachePointTypeDefault = "default"
+
+// CachePointBlock defines a position in the prompt up to which the prefix is cached:
+// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_CachePointBlock.html
+type CachePointBlock struct {
+	// The type of the cache point. Only "default" is supported.
+	Type string `json:"type"`
 }
 
 // GuardrailConfiguration Configuration information for a guardrail that you use with the Converse
@@ -169,6 +182,9 @@ type ConverseInput struct {
 	// ModelId is a required field
 	ModelID *string `json:"modelId"`
 
+	// Key-value pairs that you can use to filter invocation logs.
+	RequestMetadata map[string]string `json:"requestMetadata,omitempty"`
+
 	// A system prompt to pass to the model.
 	System []*SystemContentBlock `json:"system,omitempty"`
 
@@ -332,6 +348,33 @@ type ContentBlock struct {
 
 	// Information about a tool use request from a model.
 	ToolUse *ToolUseBlock `json:"toolUse,omitempty"`
+
+	// Contains content regarding the reasoning that is carried out by the model.
+	ReasoningContent *ReasoningContentBlock `json:"reasoningContent,omitempty"`
+
+	// CachePoint marks the end of the message prefix to be cached.
+	CachePoint *CachePointBlock `json:"cachePoint,omitempty"`
+}
+
+// ReasoningContentBlock contains the reasoning that the model used to return the output.
+// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ReasoningContentBlock.html
+type ReasoningContentBlock struct {
+	// The reasoning that the model used to return the output.
+	ReasoningText *ReasoningTextBlock `json:"reasoningText,omitempty"`
+
+	// The content in the reasoning that was encrypted by the model provider for safety reasons.
+	RedactedContent []byte `json:"redactedContent,omitempty"`
+}
+
+// ReasoningTextBlock contains the reasoning that the model used to return the output.
+// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ReasoningTextBlock.html
+type ReasoningTextBlock struct {
+	// The reasoning that the model used to return the output.
+	Text string `json:"text"`
+
+	// A token that verifies that the reasoning text was generated by the model. This must be passed back
+	// unmodified in the subsequent requests of the multi-turn conversation.
+	Signature *string `json:"signature,omitempty"`
 }
 
 // ConverseMetrics Metrics for a call to Converse (https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_Converse.html).
@@ -381,6 +424,10 @@ type TokenUsage struct {
 	InputTokens  int `json:"inputTokens"`
 	OutputTokens int `json:"outputTokens"`
 	TotalTokens  int `json:"totalTokens"`
+	// The number of the input tokens read from the prompt cache.
+	CacheReadInputTokens int `json:"cacheReadInputTokens,omitempty"`
+	// The number of the input tokens written to the prompt cache.
+	CacheWriteInputTokens int `json:"cacheWriteInputTokens,omitempty"`
 }
 
 // ConverseStreamEvent is the union of all possible event types in the AWS Bedrock API:
@@ -397,8 +444,17 @@ type ConverseStreamEvent struct {
 // ConverseStreamEventContentBlockDelta is defined in the AWS Bedrock API:
 // https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ContentBlockDelta.html
 type ConverseStreamEventContentBlockDelta struct {
-	Text    *string            `json:"text,omitempty"`
-	ToolUse *ToolUseBlockDelta `json:"toolUse,omitempty"`
+	Text             *string                     `json:"text,omitempty"`
+	ToolUse          *ToolUseBlockDelta          `json:"toolUse,omitempty"`
+	ReasoningContent *ReasoningContentBlockDelta `json:"reasoningContent,omitempty"`
+}
+
+// ReasoningContentBlockDelta is the delta of the reasoning content block. Only one of the fields is set.
+// https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ReasoningContentBlockDelta.html
+type ReasoningContentBlockDelta struct {
+	Text            *string `json:"text,omitempty"`
+	Signature       *string `json:"signature,omitempty"`
+	RedactedContent []byte  `json:"redactedContent,omitempty"`
 }
 
 // ContentBlockStart is the start information.
@@ -508,3 +564,179 @@ type ToolSpecification struct {
 	// Name is a required field
 	Name *string `json:"name"`
 }
+
+// InvokeModelAnthropicVersion is the "anthropic_version" of the Anthropic Claude requests sent to the InvokeModel API.
+const InvokeModelAnthropicVersion = "bedrock-2023-05-31"
+
+// InvokeModelAnthropicRequest is the request body of the InvokeModel API for the Anthropic Claude models,
+// which follows the Anthropic Messages API.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-anthropic-claude-messages.html
+type InvokeModelAnthropicRequest struct {
+	AnthropicVersion string                          `json:"anthropic_version"`
+	MaxTokens        int64                           `json:"max_tokens"`
+	System           string                          `json:"system,omitempty"`
+	Messages         []InvokeModelAnthropicMessage   `json:"messages"`
+	Temperature      *float64                        `json:"temperature,omitempty"`
+	TopP             *float64                        `json:"top_p,omitempty"`
+	StopSequences    []string                        `json:"stop_sequences,omitempty"`
+	Tools            []InvokeModelAnthropicTool      `json:"tools,omitempty"`
+	ToolChoice       *InvokeModelAnthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// InvokeModelAnthropicMessage is a message of the Anthropic Messages API.
+type InvokeModelAnthropicMessage struct {
+	Role    string                             `json:"role"`
+	Content []InvokeModelAnthropicContentBlock `json:"content"`
+}
+
+// InvokeModelAnthropicContentBlock is a content block of the Anthropic Messages API. Type is one of "text", "image",
+// "tool_use" and "tool_result", and the fields corresponding to the type are set.
+type InvokeModelAnthropicContentBlock struct {
+	Type string `json:"type"`
+	// Text is set for the "text" type.
+	Text string `json:"text,omitempty"`
+	// Source is set for the "image" type.
+	Source *InvokeModelAnthropicImageSource `json:"source,omitempty"`
+	// ID, Name and Input are set for the "tool_use" type.
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Input any    `json:"input,omitempty"`
+	// ToolUseID and Content are set for the "tool_result" type.
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+// InvokeModelAnthropicImageSource is the base64-encoded image of the Anthropic Messages API.
+type InvokeModelAnthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      []byte `json:"data"`
+}
+
+// InvokeModelAnthropicTool is a tool definition of the Anthropic Messages API.
+type InvokeModelAnthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+// InvokeModelAnthropicToolChoice is the tool choice of the Anthropic Messages API. Type is one of "auto", "any"
+// and "tool", and Name is set for the "tool" type.
+type InvokeModelAnthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// InvokeModelAnthropicResponse is the response body of the InvokeModel API for the Anthropic Claude models.
+type InvokeModelAnthropicResponse struct {
+	ID         string                             `json:"id"`
+	Role       string                             `json:"role"`
+	Content    []InvokeModelAnthropicContentBlock `json:"content"`
+	StopReason *string                            `json:"stop_reason"`
+	Usage      *InvokeModelAnthropicUsage         `json:"usage,omitempty"`
+}
+
+// InvokeModelAnthropicUsage is the token usage of the Anthropic Messages API.
+type InvokeModelAnthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+}
+
+// InvokeModelAnthropicStreamEvent is an event of the streaming response of the Anthropic Messages API, decoded from
+// the bytes of [InvokeModelStreamChunk]. Type is one of "message_start", "content_block_start", "content_block_delta",
+// "content_block_stop", "message_delta" and "message_stop".
+type InvokeModelAnthropicStreamEvent struct {
+	Type string `json:"type"`
+	// Message is set for the "message_start" type.
+	Message *InvokeModelAnthropicResponse `json:"message,omitempty"`
+	// Index is the index of the content block for the "content_block_*" types.
+	Index int `json:"index"`
+	// ContentBlock is set for the "content_block_start" type.
+	ContentBlock *InvokeModelAnthropicContentBlock `json:"content_block,omitempty"`
+	// Delta is set for the "content_block_delta" and "message_delta" types.
+	Delta *InvokeModelAnthropicStreamDelta `json:"delta,omitempty"`
+	// InvocationMetrics is set for the "message_stop" type.
+	InvocationMetrics *InvokeModelInvocationMetrics `json:"amazon-bedrock-invocationMetrics,omitempty"`
+}
+
+// InvokeModelAnthropicStreamDelta is the delta of [InvokeModelAnthropicStreamEvent]. Type is "text_delta" or
+// "input_json_delta" for the "content_block_delta" events, and StopReason is set for the "message_delta" events.
+type InvokeModelAnthropicStreamDelta struct {
+	Type        string  `json:"type,omitempty"`
+	Text        string  `json:"text,omitempty"`
+	PartialJSON string  `json:"partial_json,omitempty"`
+	StopReason  *string `json:"stop_reason,omitempty"`
+}
+
+// Stop reasons of the Anthropic Messages API.
+const (
+	InvokeModelAnthropicStopReasonEndTurn      = "end_turn"
+	InvokeModelAnthropicStopReasonMaxTokens    = "max_tokens"
+	InvokeModelAnthropicStopReasonStopSequence = "stop_sequence"
+	InvokeModelAnthropicStopReasonToolUse      = "tool_use"
+)
+
+// InvokeModelTitanTextRequest is the request body of the InvokeModel API for the Amazon Titan Text models.
+// https://docs.aws.amazon.com/bedrock/latest/userguide/model-parameters-titan-text.html
+type InvokeModelTitanTextRequest struct {
+	InputText            string                          `json:"inputText"`
+	TextGenerationConfig *InvokeModelTitanTextGeneration `json:"textGenerationConfig,omitempty"`
+}
+
+// InvokeModelTitanTextGeneration is the inference parameters of the Amazon Titan Text models.
+type InvokeModelTitanTextGeneration struct {
+	MaxTokenCount *int64   `json:"maxTokenCount,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+// InvokeModelTitanTextResponse is the response body of the InvokeModel API for the Amazon Titan Text models.
+type InvokeModelTitanTextResponse struct {
+	InputTextTokenCount int                          `json:"inputTextTokenCount"`
+	Results             []InvokeModelTitanTextResult `json:"results"`
+}
+
+// InvokeModelTitanTextResult is a generated result of the Amazon Titan Text models.
+type InvokeModelTitanTextResult struct {
+	TokenCount       int     `json:"tokenCount"`
+	OutputText       string  `json:"outputText"`
+	CompletionReason *string `json:"completionReason"`
+}
+
+// InvokeModelTitanTextStreamChunk is a chunk of the streaming response of the Amazon Titan Text models, decoded from
+// the bytes of [InvokeModelStreamChunk].
+type InvokeModelTitanTextStreamChunk struct {
+	OutputText       string  `json:"outputText"`
+	CompletionReason *string `json:"completionReason"`
+	// InvocationMetrics is set for the last chunk.
+	InvocationMetrics *InvokeModelInvocationMetrics `json:"amazon-bedrock-invocationMetrics,omitempty"`
+}
+
+// Completion reasons of the Amazon Titan Text models.
+const (
+	InvokeModelTitanTextCompletionReasonFinish          = "FINISH"
+	InvokeModelTitanTextCompletionReasonLength          = "LENGTH"
+	InvokeModelTitanTextCompletionReasonContentFiltered = "CONTENT_FILTERED"
+)
+
+// InvokeModelStreamChunk is the payload of the event stream messages of the InvokeModelWithResponseStream API.
+// Bytes is the model-specific JSON chunk, which is base64-encoded in the payload.
+type InvokeModelStreamChunk struct {
+	Bytes []byte `json:"bytes"`
+}
+
+// InvokeModelInvocationMetrics is the "amazon-bedrock-invocationMetrics" of the last chunk of the streaming response
+// of the InvokeModelWithResponseStream API.
+type InvokeModelInvocationMetrics struct {
+	InputTokenCount  int `json:"inputTokenCount"`
+	OutputTokenCount int `json:"outputTokenCount"`
+}
+
+// Response headers of the InvokeModel API reporting the token usage.
+const (
+	InvokeModelInputTokenCountHeader  = "x-amzn-bedrock-input-token-count"
+	InvokeModelOutputTokenCountHeader = "x-amzn-bedrock-output-token-count"
+)
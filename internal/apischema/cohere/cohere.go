@@ -0,0 +1,215 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package cohere
+
+const (
+	// ChatRoleUser is a ChatMessage role enum value.
+	ChatRoleUser = "USER"
+
+	// ChatRoleChatbot is a ChatMessage role enum value.
+	ChatRoleChatbot = "CHATBOT"
+
+	// ChatRoleSystem is a ChatMessage role enum value.
+	ChatRoleSystem = "SYSTEM"
+
+	// ChatRoleTool is a ChatMessage role enum value.
+	ChatRoleTool = "TOOL"
+
+	// FinishReasonComplete is a FinishReason enum value.
+	FinishReasonComplete = "COMPLETE"
+
+	// FinishReasonStopSequence is a FinishReason enum value.
+	FinishReasonStopSequence = "STOP_SEQUENCE"
+
+	// FinishReasonMaxTokens is a FinishReason enum value.
+	FinishReasonMaxTokens = "MAX_TOKENS"
+
+	// FinishReasonErrorToxic is a FinishReason enum value.
+	FinishReasonErrorToxic = "ERROR_TOXIC"
+
+	// FinishReasonError is a FinishReason enum value.
+	FinishReasonError = "ERROR"
+
+	// StreamEventTypeStreamStart is a StreamEvent event_type enum value.
+	StreamEventTypeStreamStart = "stream-start"
+
+	// StreamEventTypeTextGeneration is a StreamEvent event_type enum value.
+	StreamEventTypeTextGeneration = "text-generation"
+
+	// StreamEventTypeToolCallsGeneration is a StreamEvent event_type enum value.
+	StreamEventTypeToolCallsGeneration = "tool-calls-generation"
+
+	// StreamEventTypeStreamEnd is a StreamEvent event_type enum value.
+	StreamEventTypeStreamEnd = "stream-end"
+)
+
+// ChatRequest is the request body of the Cohere v1 chat endpoint.
+// https://docs.cohere.com/v1/reference/chat
+type ChatRequest struct {
+	// Message is the chat message from the user to the model.
+	// This can be empty when ToolResults is set.
+	Message string `json:"message"`
+
+	// Model is the name of a compatible Cohere model.
+	Model string `json:"model,omitempty"`
+
+	// Stream enables the streaming of the partial progress as newline-delimited JSON events.
+	Stream bool `json:"stream,omitempty"`
+
+	// Preamble replaces the default system message of the model.
+	Preamble string `json:"preamble,omitempty"`
+
+	// ChatHistory is the list of the previous messages between the user and the model.
+	ChatHistory []ChatMessage `json:"chat_history,omitempty"`
+
+	// MaxTokens is the maximum number of tokens the model will generate as part of the response.
+	MaxTokens *int64 `json:"max_tokens,omitempty"`
+
+	// Temperature is the degree of randomness in the generation.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// P is the nucleus sampling parameter, corresponding to top_p of OpenAI.
+	P *float64 `json:"p,omitempty"`
+
+	// Seed is used for the deterministic sampling.
+	Seed *int `json:"seed,omitempty"`
+
+	// StopSequences is the list of strings that stop the generation when generated.
+	StopSequences []string `json:"stop_sequences,omitempty"`
+
+	// FrequencyPenalty reduces the repetitiveness of the generated tokens.
+	FrequencyPenalty *float32 `json:"frequency_penalty,omitempty"`
+
+	// PresencePenalty reduces the repetitiveness of the generated tokens.
+	PresencePenalty *float32 `json:"presence_penalty,omitempty"`
+
+	// Tools is the list of the tools available to the model.
+	Tools []Tool `json:"tools,omitempty"`
+
+	// ToolResults is the list of the results of the tool calls requested by the model in the previous turn.
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+}
+
+// ChatMessage is a message in the chat history.
+// https://docs.cohere.com/v1/reference/chat#request.body.chat_history
+type ChatMessage struct {
+	// Role is one of USER, CHATBOT, SYSTEM, or TOOL.
+	Role string `json:"role"`
+
+	// Message is the content of the message. Not used by the TOOL role.
+	Message string `json:"message,omitempty"`
+
+	// ToolCalls is the list of the tool calls generated by the model. Only used by the CHATBOT role.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolResults is the list of the results of the tool calls. Only used by the TOOL role.
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+}
+
+// Tool is a tool available to the model.
+// https://docs.cohere.com/v1/reference/chat#request.body.tools
+type Tool struct {
+	// Name is the name of the tool to be called.
+	Name string `json:"name"`
+
+	// Description is the description of what the tool does.
+	Description string `json:"description"`
+
+	// ParameterDefinitions is the input parameters of the tool keyed by the parameter name.
+	ParameterDefinitions map[string]ToolParameterDefinition `json:"parameter_definitions,omitempty"`
+}
+
+// ToolParameterDefinition is the definition of a single input parameter of a tool.
+type ToolParameterDefinition struct {
+	// Description is the description of the parameter.
+	Description string `json:"description,omitempty"`
+
+	// Type is the type of the parameter, for example, "str" or "int".
+	Type string `json:"type"`
+
+	// Required is true when the parameter must be passed to the tool.
+	Required bool `json:"required,omitempty"`
+}
+
+// ToolCall is a tool call generated by the model.
+type ToolCall struct {
+	// Name is the name of the tool to call.
+	Name string `json:"name"`
+
+	// Parameters is the input parameters of the tool call.
+	Parameters map[string]any `json:"parameters"`
+}
+
+// ToolResult is the result of a tool call.
+type ToolResult struct {
+	// Call is the tool call that the outputs are produced by.
+	Call ToolCall `json:"call"`
+
+	// Outputs is the list of the outputs of the tool call.
+	Outputs []map[string]any `json:"outputs"`
+}
+
+// ChatResponse is the non-streaming response body of the Cohere v1 chat endpoint.
+// https://docs.cohere.com/v1/reference/chat#response
+type ChatResponse struct {
+	// Text is the content generated by the model.
+	Text string `json:"text"`
+
+	// GenerationID is the unique identifier of the generated reply.
+	GenerationID string `json:"generation_id,omitempty"`
+
+	// FinishReason is the reason the model stopped generating tokens.
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// ToolCalls is the list of the tool calls generated by the model.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// Meta is the metadata of the response including the token usage.
+	Meta *ResponseMeta `json:"meta,omitempty"`
+}
+
+// ResponseMeta is the metadata of the chat response.
+type ResponseMeta struct {
+	// BilledUnits is the number of the billed units of the request.
+	BilledUnits *BilledUnits `json:"billed_units,omitempty"`
+}
+
+// BilledUnits is the number of the billed units of the request.
+type BilledUnits struct {
+	// InputTokens is the number of the billed input tokens.
+	InputTokens float64 `json:"input_tokens"`
+
+	// OutputTokens is the number of the billed output tokens.
+	OutputTokens float64 `json:"output_tokens"`
+}
+
+// StreamEvent is a single newline-delimited JSON event of the streaming response of the Cohere v1 chat endpoint.
+// https://docs.cohere.com/v1/reference/chat-stream
+type StreamEvent struct {
+	// EventType is the type of the event, for example, "stream-start" or "text-generation".
+	EventType string `json:"event_type"`
+
+	// IsFinished is true for the last event of the stream.
+	IsFinished bool `json:"is_finished"`
+
+	// Text is the generated text of the "text-generation" event.
+	Text string `json:"text,omitempty"`
+
+	// ToolCalls is the list of the tool calls of the "tool-calls-generation" event.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// FinishReason is the reason the model stopped generating tokens in the "stream-end" event.
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// Response is the full response of the "stream-end" event.
+	Response *ChatResponse `json:"response,omitempty"`
+}
+
+// Error is the error response body of the Cohere API.
+type Error struct {
+	// Message is the human-readable description of the error.
+	Message string `json:"message"`
+}
@@ -0,0 +1,32 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+// Package mistral contains the types of the Mistral "La Plateforme" chat completion API that differ from the OpenAI
+// ones. The rest of the API is compatible with OpenAI.
+//
+// https://docs.mistral.ai/api/#tag/chat
+package mistral
+
+import "encoding/json"
+
+const (
+	// ToolChoiceAny is the tool_choice value forcing the model to call a tool, which is "required" in OpenAI.
+	ToolChoiceAny = "any"
+	// ErrorObject is the value of the object field of the error response body.
+	ErrorObject = "error"
+)
+
+// Error is the error response body of the Mistral API.
+type Error struct {
+	// Object is always "error".
+	Object string `json:"object"`
+	// Message is either the human-readable description of the error, or the validation error details object
+	// for the 422 responses.
+	Message json.RawMessage `json:"message"`
+	// Type is the type of the error, for example, "invalid_request_error".
+	Type string `json:"type"`
+	// Param is the parameter related to the error, if any.
+	Param *string `json:"param,omitempty"`
+}
@@ -45,6 +45,17 @@ const (
 	ChatCompletionContentPartImageTypeImageURL        ChatCompletionContentPartImageType      = "image_url"
 )
 
+// CacheControlTypeEphemeral is the only type of [CacheControl].
+const CacheControlTypeEphemeral = "ephemeral"
+
+// CacheControl marks the end of the prompt prefix to be cached by the backend.
+// This is not part of the OpenAI API but follows the Anthropic API, and is translated
+// to the cache points of the backends supporting the prompt caching, such as AWS Bedrock.
+type CacheControl struct {
+	// The type of the cache control. Only "ephemeral" is supported.
+	Type string `json:"type"`
+}
+
 // ChatCompletionContentPartTextParam Learn about
 // [text inputs](https://platform.openai.com/docs/guides/text-generation).
 type ChatCompletionContentPartTextParam struct {
@@ -52,6 +63,8 @@ type ChatCompletionContentPartTextParam struct {
 	Text string `json:"text"`
 	// The type of the content part.
 	Type string `json:"type"`
+	// CacheControl marks the prompt prefix up to this content part to be cached. Optional.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 type ChatCompletionContentPartRefusalParam struct {
@@ -104,6 +117,8 @@ type ChatCompletionContentPartImageParam struct {
 	ImageURL ChatCompletionContentPartImageImageURLParam `json:"image_url"`
 	// The type of the content part.
 	Type ChatCompletionContentPartImageType `json:"type"`
+	// CacheControl marks the prompt prefix up to this content part to be cached. Optional.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // ChatCompletionContentPartUserUnionParam Learn about
@@ -149,6 +164,20 @@ func (c *ChatCompletionContentPartUserUnionParam) UnmarshalJSON(data []byte) err
 	return nil
 }
 
+// MarshalJSON implements [json.Marshaler].
+func (c ChatCompletionContentPartUserUnionParam) MarshalJSON() ([]byte, error) {
+	switch {
+	case c.TextContent != nil:
+		return json.Marshal(c.TextContent)
+	case c.InputAudioContent != nil:
+		return json.Marshal(c.InputAudioContent)
+	case c.ImageContent != nil:
+		return json.Marshal(c.ImageContent)
+	default:
+		return nil, fmt.Errorf("no content is set in ChatCompletionContentPartUserUnionParam")
+	}
+}
+
 type StringOrArray struct {
 	Value interface{}
 }
@@ -171,6 +200,58 @@ func (s *StringOrArray) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("cannot unmarshal JSON data as string or array of string")
 }
 
+// MarshalJSON implements [json.Marshaler].
+func (s StringOrArray) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value)
+}
+
+// ChatCompletionStopMaxSequences is the maximum number of the stop sequences of the chat completion request.
+const ChatCompletionStopMaxSequences = 4
+
+// ChatCompletionStop is the stop sequences of the chat completion request, which can be either a string or
+// an array of strings.
+type ChatCompletionStop struct {
+	// Sequences is the stop sequences.
+	Sequences []string
+	// IsString is true if the stop sequence is given as a string instead of an array, so that it is marshalled back
+	// in the same shape.
+	IsString bool
+}
+
+// Values returns the stop sequences, or nil if s is nil.
+func (s *ChatCompletionStop) Values() []string {
+	if s == nil {
+		return nil
+	}
+	return s.Sequences
+}
+
+// UnmarshalJSON implements [json.Unmarshaler].
+func (s *ChatCompletionStop) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = ChatCompletionStop{Sequences: []string{str}, IsString: true}
+		return nil
+	}
+	var arr []string
+	if err := json.Unmarshal(data, &arr); err == nil {
+		*s = ChatCompletionStop{Sequences: arr}
+		return nil
+	}
+	return fmt.Errorf("cannot unmarshal JSON data as string or array of string")
+}
+
+// MarshalJSON implements [json.Marshaler].
+func (s ChatCompletionStop) MarshalJSON() ([]byte, error) {
+	if s.IsString && len(s.Sequences) == 1 {
+		return json.Marshal(s.Sequences[0])
+	}
+	if s.Sequences == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.Sequences)
+}
+
 type StringOrUserRoleContentUnion struct {
 	Value interface{}
 }
@@ -193,6 +274,11 @@ func (s *StringOrUserRoleContentUnion) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("cannot unmarshal JSON data as string or array of content parts")
 }
 
+// MarshalJSON implements [json.Marshaler].
+func (s StringOrUserRoleContentUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value)
+}
+
 type ChatCompletionMessageParamUnion struct {
 	Value interface{}
 	Type  string
@@ -253,6 +339,11 @@ func (c *ChatCompletionMessageParamUnion) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON implements [json.Marshaler].
+func (c ChatCompletionMessageParamUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Value)
+}
+
 // ChatCompletionUserMessageParam Messages sent by an end user, containing prompts or additional context
 // information.
 type ChatCompletionUserMessageParam struct {
@@ -324,6 +415,8 @@ type ChatCompletionAssistantMessageParamContent struct {
 	Refusal *string `json:"refusal,omitempty"`
 	// The text content.
 	Text *string `json:"text,omitempty"`
+	// CacheControl marks the prompt prefix up to this content part to be cached. Optional.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
 }
 
 // ChatCompletionAssistantMessageParam Messages sent by the model in response to user messages.
@@ -343,6 +436,11 @@ type ChatCompletionAssistantMessageParam struct {
 	Refusal string `json:"refusal,omitempty"`
 	// The tool calls generated by the model, such as function calls.
 	ToolCalls []ChatCompletionMessageToolCallParam `json:"tool_calls,omitempty"`
+	// The reasoning of the model returned in the previous response, if any.
+	// See [ChatCompletionResponseChoiceMessage.ReasoningContent].
+	ReasoningContent *string `json:"reasoning_content,omitempty"` //nolint:tagliatelle //follow openai api
+	// The signature of the reasoning returned in the previous response, if any.
+	ReasoningSignature *string `json:"reasoning_signature,omitempty"` //nolint:tagliatelle //follow openai api
 }
 
 // ChatCompletionMessageToolCallType The type of the tool. Currently, only `function` is supported.
@@ -364,6 +462,8 @@ type ChatCompletionMessageToolCallFunctionParam struct {
 }
 
 type ChatCompletionMessageToolCallParam struct {
+	// Index is the index of the tool call in the list of tool calls. This is only set in the streaming chunks.
+	Index *int64 `json:"index,omitempty"`
 	// The ID of the tool call.
 	ID string `json:"id"`
 	// The function that the model called.
@@ -431,6 +531,12 @@ type ChatCompletionRequest struct {
 	// refs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-max_tokens
 	MaxTokens *int64 `json:"max_tokens,omitempty"` //nolint:tagliatelle //follow openai api
 
+	// MaxCompletionTokens is an upper bound for the number of tokens that can be generated for a completion,
+	// including visible output tokens and reasoning tokens. This takes precedence over MaxTokens when both are set.
+	// See [ChatCompletionRequest.MaxOutputTokens].
+	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-max_completion_tokens
+	MaxCompletionTokens *int64 `json:"max_completion_tokens,omitempty"` //nolint:tagliatelle //follow openai api
+
 	// N: LLM Gateway does not support multiple completions.
 	// The only accepted value is 1.
 	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-n
@@ -456,7 +562,7 @@ type ChatCompletionRequest struct {
 	// Stop string / array / null Defaults to null
 	// Up to 4 sequences where the API will stop generating further tokens.
 	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-stop
-	Stop []*string `json:"stop,omitempty"`
+	Stop *ChatCompletionStop `json:"stop,omitempty"`
 
 	// Stream: If set, partial message deltas will be sent, like in ChatGPT.
 	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-stream
@@ -485,15 +591,38 @@ type ChatCompletionRequest struct {
 	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-tool_choice
 	ToolChoice any `json:"tool_choice,omitempty"` //nolint:tagliatelle //follow openai api
 
-	// ParallelToolCalls enables multiple tools to be returned by the model.
+	// ParallelToolCalls enables multiple tools to be returned by the model. Defaults to true when unset.
 	// Docs: https://platform.openai.com/docs/guides/function-calling/parallel-function-calling
-	ParallelToolCalls bool `json:"parallel_tool_calls,omitempty"` //nolint:tagliatelle //follow openai api
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"` //nolint:tagliatelle //follow openai api
 
 	// User: A unique identifier representing your end-user, which can help OpenAI to monitor and detect abuse.
 	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-user
 	User string `json:"user,omitempty"`
+
+	// Metadata: Set of key-value pairs attached to the request. This can have up to
+	// ChatCompletionMetadataMaxKeys keys, and the values can be up to ChatCompletionMetadataMaxValueLength
+	// characters long.
+	// Docs: https://platform.openai.com/docs/api-reference/chat/create#chat-create-metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// MaxOutputTokens returns the maximum number of the tokens generated for the request, which is the
+// max_completion_tokens, or the deprecated max_tokens when max_completion_tokens is not set. This returns nil when
+// neither is set.
+func (c *ChatCompletionRequest) MaxOutputTokens() *int64 {
+	if c.MaxCompletionTokens != nil {
+		return c.MaxCompletionTokens
+	}
+	return c.MaxTokens
+}
+
+const (
+	// ChatCompletionMetadataMaxKeys is the maximum number of the keys in ChatCompletionRequest.Metadata.
+	ChatCompletionMetadataMaxKeys = 16
+	// ChatCompletionMetadataMaxValueLength is the maximum length of the values in ChatCompletionRequest.Metadata.
+	ChatCompletionMetadataMaxValueLength = 256
+)
+
 type StreamOptions struct {
 	// If set, an additional chunk will be streamed before the data: [DONE] message.
 	// The usage field on this chunk shows the token usage statistics for the entire request,
@@ -562,6 +691,22 @@ type LogProbs struct {
 // ChatCompletionResponse represents a response from /v1/chat/completions.
 // https://platform.openai.com/docs/api-reference/chat/object
 type ChatCompletionResponse struct {
+	// ID is a unique identifier for the chat completion.
+	// https://platform.openai.com/docs/api-reference/chat/object#chat/object-id
+	ID string `json:"id,omitempty"`
+
+	// Created is the Unix timestamp (in seconds) of when the chat completion was created.
+	// https://platform.openai.com/docs/api-reference/chat/object#chat/object-created
+	Created int64 `json:"created,omitempty"`
+
+	// Model is the model used for the chat completion.
+	// https://platform.openai.com/docs/api-reference/chat/object#chat/object-model
+	Model string `json:"model,omitempty"`
+
+	// SystemFingerprint represents the backend configuration that the model runs with.
+	// https://platform.openai.com/docs/api-reference/chat/object#chat/object-system_fingerprint
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
 	// Choices are described in the OpenAI API documentation:
 	// https://platform.openai.com/docs/api-reference/chat/object#chat/object-choices
 	Choices []ChatCompletionResponseChoice `json:"choices,omitempty"`
@@ -660,6 +805,14 @@ type ChatCompletionResponseChoiceMessage struct {
 
 	// The tool calls generated by the model, such as function calls.
 	ToolCalls []ChatCompletionMessageToolCallParam `json:"tool_calls,omitempty"`
+
+	// ReasoningContent is the reasoning of the model that led to the content, if the backend supports it.
+	// This is not part of the OpenAI API, but follows the convention of the OpenAI compatible APIs.
+	ReasoningContent *string `json:"reasoning_content,omitempty"` //nolint:tagliatelle //follow openai api
+
+	// ReasoningSignature is the signature of the ReasoningContent that the backend requires to be passed back
+	// unmodified in the subsequent assistant messages.
+	ReasoningSignature *string `json:"reasoning_signature,omitempty"` //nolint:tagliatelle //follow openai api
 }
 
 // ChatCompletionResponseUsage is described in the OpenAI API documentation:
@@ -668,14 +821,40 @@ type ChatCompletionResponseUsage struct {
 	CompletionTokens int `json:"completion_tokens,omitempty"`
 	PromptTokens     int `json:"prompt_tokens,omitempty"`
 	TotalTokens      int `json:"total_tokens,omitempty"`
+	// CacheReadInputTokens is the number of the prompt tokens read from the prompt cache.
+	// This is not part of the OpenAI API, and is only set by the backends supporting the prompt caching.
+	CacheReadInputTokens int `json:"cache_read_input_tokens,omitempty"`
+	// CacheWriteInputTokens is the number of the prompt tokens written to the prompt cache.
+	// This is not part of the OpenAI API, and is only set by the backends supporting the prompt caching.
+	CacheWriteInputTokens int `json:"cache_write_input_tokens,omitempty"`
 }
 
 // ChatCompletionResponseChunk is described in the OpenAI API documentation:
 // https://platform.openai.com/docs/api-reference/chat/streaming#chat-create-messages
 type ChatCompletionResponseChunk struct {
+	// ID is a unique identifier for the chat completion. Each chunk has the same ID.
+	// https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-id
+	ID string `json:"id,omitempty"`
+
+	// Created is the Unix timestamp (in seconds) of when the chat completion was created. Each chunk has the same
+	// timestamp.
+	// https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-created
+	Created int64 `json:"created,omitempty"`
+
+	// Model is the model used for the chat completion.
+	// https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-model
+	Model string `json:"model,omitempty"`
+
+	// SystemFingerprint represents the backend configuration that the model runs with.
+	// https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-system_fingerprint
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
 	// Choices are described in the OpenAI API documentation:
 	// https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-choices
-	Choices []ChatCompletionResponseChunkChoice `json:"choices,omitempty"`
+	//
+	// This is not omitted when empty since the last chunk carrying the usage has the empty choices, and nil is
+	// encoded as the empty array rather than null.
+	Choices []ChatCompletionResponseChunkChoice `json:"choices"`
 
 	// Object is always "chat.completion.chunk" for completions.
 	// https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-object
@@ -686,6 +865,15 @@ type ChatCompletionResponseChunk struct {
 	Usage *ChatCompletionResponseUsage `json:"usage,omitempty"`
 }
 
+// MarshalJSON implements [json.Marshaler].
+func (c ChatCompletionResponseChunk) MarshalJSON() ([]byte, error) {
+	type chunk ChatCompletionResponseChunk
+	if c.Choices == nil {
+		c.Choices = []ChatCompletionResponseChunkChoice{}
+	}
+	return json.Marshal(chunk(c))
+}
+
 // String implements fmt.Stringer.
 func (c *ChatCompletionResponseChunk) String() string {
 	buf, _ := json.Marshal(c)
@@ -697,14 +885,22 @@ func (c *ChatCompletionResponseChunk) String() string {
 type ChatCompletionResponseChunkChoice struct {
 	Delta        *ChatCompletionResponseChunkChoiceDelta `json:"delta,omitempty"`
 	FinishReason ChatCompletionChoicesFinishReason       `json:"finish_reason,omitempty"`
+	// Logprobs is the log probability information of the tokens in the Delta, which is only set when the request
+	// sets LogProbs to true.
+	Logprobs *ChatCompletionChoicesLogprobs `json:"logprobs,omitempty"`
 }
 
 // ChatCompletionResponseChunkChoiceDelta is described in the OpenAI API documentation:
 // https://platform.openai.com/docs/api-reference/chat/streaming#chat/streaming-choices
+//
+// The Role is only set in the first chunk of the stream, and omitted in the subsequent chunks.
 type ChatCompletionResponseChunkChoiceDelta struct {
 	Content   *string                              `json:"content,omitempty"`
-	Role      string                               `json:"role"`
+	Role      string                               `json:"role,omitempty"`
 	ToolCalls []ChatCompletionMessageToolCallParam `json:"tool_calls,omitempty"`
+	// ReasoningContent and ReasoningSignature are the deltas of the fields of [ChatCompletionResponseChoiceMessage].
+	ReasoningContent   *string `json:"reasoning_content,omitempty"`   //nolint:tagliatelle //follow openai api
+	ReasoningSignature *string `json:"reasoning_signature,omitempty"` //nolint:tagliatelle //follow openai api
 }
 
 // Error is described in the OpenAI API documentation
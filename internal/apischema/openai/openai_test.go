@@ -267,3 +267,104 @@ func TestModelListMarshal(t *testing.T) {
 	// Unmarshalling initializes other fields in time.Time we're not interested with. Just compare the actual time.
 	require.Equal(t, time.Time(model.Created).Unix(), time.Time(out.Data[0].Created).Unix())
 }
+
+func TestChatCompletionMessageMarshal(t *testing.T) {
+	raw := `[
+{"role":"system","content":"you are a helpful assistant"},
+{"role":"user","content":[{"type":"text","text":"what do you see"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]},
+{"role":"tool","content":"sunny","tool_call_id":"call_1"}
+]`
+	var messages []ChatCompletionMessageParamUnion
+	require.NoError(t, json.Unmarshal([]byte(raw), &messages))
+	b, err := json.Marshal(messages)
+	require.NoError(t, err)
+	require.JSONEq(t, raw, string(b))
+
+	_, err = json.Marshal(ChatCompletionContentPartUserUnionParam{})
+	require.ErrorContains(t, err, "no content is set")
+}
+
+func TestChatCompletionRequest_MaxOutputTokens(t *testing.T) {
+	for _, tc := range []struct {
+		raw string
+		exp *int64
+	}{
+		{raw: `{}`},
+		{raw: `{"max_tokens":100}`, exp: ptr.To[int64](100)},
+		{raw: `{"max_completion_tokens":200}`, exp: ptr.To[int64](200)},
+		{raw: `{"max_tokens":100,"max_completion_tokens":200}`, exp: ptr.To[int64](200)},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			var req ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.raw), &req))
+			require.Equal(t, tc.exp, req.MaxOutputTokens())
+			b, err := json.Marshal(&req)
+			require.NoError(t, err)
+			require.Contains(t, string(b), tc.raw[1:len(tc.raw)-1])
+		})
+	}
+}
+
+func TestChatCompletionStop(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  string
+		exp  *ChatCompletionStop
+	}{
+		{name: "string", raw: `{"stop":"END"}`, exp: &ChatCompletionStop{Sequences: []string{"END"}, IsString: true}},
+		{name: "array", raw: `{"stop":["a","b"]}`, exp: &ChatCompletionStop{Sequences: []string{"a", "b"}}},
+		{name: "single element array", raw: `{"stop":["a"]}`, exp: &ChatCompletionStop{Sequences: []string{"a"}}},
+		{name: "more than four", raw: `{"stop":["a","b","c","d","e"]}`, exp: &ChatCompletionStop{Sequences: []string{"a", "b", "c", "d", "e"}}},
+		{name: "null", raw: `{"stop":null}`},
+		{name: "absent", raw: `{}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var req ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.raw), &req))
+			require.Equal(t, tc.exp, req.Stop)
+			if tc.exp == nil {
+				require.Nil(t, req.Stop.Values())
+				return
+			}
+			require.Equal(t, tc.exp.Sequences, req.Stop.Values())
+			// The stop sequences are marshalled back in the same shape.
+			b, err := json.Marshal(struct {
+				Stop *ChatCompletionStop `json:"stop"`
+			}{Stop: req.Stop})
+			require.NoError(t, err)
+			require.JSONEq(t, tc.raw, string(b))
+		})
+	}
+
+	var req ChatCompletionRequest
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"stop":1}`), &req), "cannot unmarshal JSON data as string or array of string")
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"stop":["a",1]}`), &req), "cannot unmarshal JSON data as string or array of string")
+}
+
+func TestChatCompletionResponseChunkLogprobs(t *testing.T) {
+	raw := `{"id":"chatcmpl-foo","model":"gpt-4o","object":"chat.completion.chunk","choices":[{"delta":{"content":"Hi"},` +
+		`"logprobs":{"content":[{"token":"Hi","bytes":[72,105],"logprob":-0.25,"top_logprobs":[{"token":"Hi","bytes":[72,105],"logprob":-0.25}]}]}}]}`
+	var chunk ChatCompletionResponseChunk
+	require.NoError(t, json.Unmarshal([]byte(raw), &chunk))
+	require.Len(t, chunk.Choices, 1)
+	require.NotNil(t, chunk.Choices[0].Logprobs)
+	require.Equal(t, -0.25, chunk.Choices[0].Logprobs.Content[0].Logprob)
+	b, err := json.Marshal(chunk)
+	require.NoError(t, err)
+	require.JSONEq(t, raw, string(b))
+
+	// The chunk without the log probabilities omits the field.
+	b, err = json.Marshal(ChatCompletionResponseChunk{Choices: []ChatCompletionResponseChunkChoice{{Delta: &ChatCompletionResponseChunkChoiceDelta{}}}})
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "logprobs")
+}
+
+func TestChatCompletionResponseChunk_MarshalJSON(t *testing.T) {
+	// The usage chunk without the choices has the empty array rather than null.
+	b, err := json.Marshal(ChatCompletionResponseChunk{Object: "chat.completion.chunk", Usage: &ChatCompletionResponseUsage{TotalTokens: 1}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"object":"chat.completion.chunk","choices":[],"usage":{"total_tokens":1}}`, string(b))
+	b, err = json.Marshal(&ChatCompletionResponseChunk{})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"choices":[]}`, string(b))
+}
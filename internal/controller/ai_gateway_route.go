@@ -6,19 +6,37 @@
 package controller
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"path"
+	"slices"
+	"strconv"
+	"strings"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	uuid2 "k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -26,24 +44,65 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	"sigs.k8s.io/yaml"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
+	"github.com/envoyproxy/ai-gateway/internal/extensionserver"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
 
 const (
 	managedByLabel             = "app.kubernetes.io/managed-by"
+	managedByLabelValue        = "envoy-ai-gateway"
 	expProcConfigFileName      = "extproc-config.yaml"
 	selectedBackendHeaderKey   = "x-ai-eg-selected-backend"
 	hostRewriteHTTPFilterName  = "ai-eg-host-rewrite"
 	extProcConfigAnnotationKey = "aigateway.envoyproxy.io/extproc-config-uuid"
+	// extProcSecretsHashAnnotationKey is set to the extproc configmap with the hash of the versions of the secrets
+	// mounted in the external processor, so that the rotation of a secret reloads the configuration with a new UUID
+	// even though the configuration itself, which only refers to the file paths, is the same.
+	extProcSecretsHashAnnotationKey = "aigateway.envoyproxy.io/extproc-secrets-hash" // #nosec G101
+	// extProcPodAnnotationKeysAnnotationKey is set to the pod template of the external processor deployment with
+	// the comma-separated keys of the PodAnnotations applied, so that only these are removed when they are removed
+	// from the filter config while the annotations set by others, e.g., "kubectl rollout restart", are kept.
+	extProcPodAnnotationKeysAnnotationKey = "aigateway.envoyproxy.io/pod-annotation-keys"
 	// mountedExtProcSecretPath specifies the secret file mounted on the external proc. The idea is to update the mounted.
 	//
 	//	secret with backendSecurityPolicy auth instead of mounting new secret files to the external proc.
 	mountedExtProcSecretPath = "/etc/backend_security_policy" // #nosec G101
+	// stateStoreVolumeName is the name of the volume of the secret of the state store password, which is mounted at
+	// stateStoreMountPath.
+	stateStoreVolumeName = "state-store"
+	stateStoreMountPath  = "/etc/state_store"
+	// stateStorePasswordFile is the path to the state store password in the external processor.
+	stateStorePasswordFile = stateStoreMountPath + "/password" // #nosec G101
+	// aiGatewayRouteLabel is set to the resources created for an AIGatewayRoute with the name of the route as the value,
+	// truncated by labelValue.
+	// This is used to garbage-collect the resources that are no longer referenced by any AIGatewayRoute.
+	aiGatewayRouteLabel = "aigateway.envoyproxy.io/ai-gateway-route"
+	// labelValueHashLength is the length of the hash suffixed to the label values truncated by labelValue.
+	labelValueHashLength = 10
+	// aiGatewayRouteFinalizer is the finalizer of AIGatewayRoute to clean up the resources shared in the namespace,
+	// such as the host rewrite HTTPRouteFilter, when the last AIGatewayRoute in the namespace is deleted.
+	aiGatewayRouteFinalizer = "aigateway.envoyproxy.io/ai-gateway-route-finalizer"
+	// extProcDebugAddr is the address of the debug endpoints of the external processor, such as "GET /debug/config".
+	// This is bound to localhost so that the endpoints are only reachable through "kubectl port-forward".
+	extProcDebugAddr = "localhost:1064"
+	// extProcMetricsPort is the port of the Prometheus metrics of the external processor served on "GET /metrics".
+	extProcMetricsPort = 1065
+	// extProcSocketDir is the directory shared between Envoy and the external processor in the sidecar mode.
+	extProcSocketDir = "/var/run/ai-gateway"
+	// extProcSocketVolumeName is the name of the emptyDir volume mounted on extProcSocketDir in the sidecar mode.
+	extProcSocketVolumeName = "ai-eg-extproc-socket"
+	// envoyContainerName is the name of the Envoy container in the proxy pods created by Envoy Gateway.
+	envoyContainerName = "envoy"
+	// sharedExtProcName is the name of the external processor resources shared by the AIGatewayRoutes in the namespace
+	// with the shared deployment. These are not labeled with aiGatewayRouteLabel, and are deleted when no such
+	// AIGatewayRoute remains in the namespace.
+	sharedExtProcName = "ai-eg-shared-extproc"
 )
 
 // AIGatewayRouteController implements [reconcile.TypedReconciler].
@@ -52,32 +111,45 @@ const (
 //
 // Exported for testing purposes.
 type AIGatewayRouteController struct {
-	client client.Client
-	kube   kubernetes.Interface
-	logger logr.Logger
+	client   client.Client
+	kube     kubernetes.Interface
+	logger   logr.Logger
+	recorder record.EventRecorder
 
-	extProcImage           string
-	extProcImagePullPolicy corev1.PullPolicy
-	extProcLogLevel        string
+	extProcImage            string
+	extProcImagePullPolicy  corev1.PullPolicy
+	extProcImagePullSecrets []corev1.LocalObjectReference
+	extProcLogLevel         string
+	extProcDefaultResources corev1.ResourceRequirements
 }
 
 // NewAIGatewayRouteController creates a new reconcile.TypedReconciler[reconcile.Request] for the AIGatewayRoute resource.
+//
+// The extProcDefaultResources is the resources of the external processor container used when the AIGatewayRoute
+// does not specify them. The recorder emits the Kubernetes Events on the sync failures and once the AIGatewayRoute
+// is programmed.
 func NewAIGatewayRouteController(
-	client client.Client, kube kubernetes.Interface, logger logr.Logger,
-	extProcImage, extProcLogLevel string,
+	client client.Client, kube kubernetes.Interface, logger logr.Logger, recorder record.EventRecorder,
+	extProcImage, extProcImagePullSecret, extProcLogLevel string, extProcDefaultResources corev1.ResourceRequirements,
 ) *AIGatewayRouteController {
-	return &AIGatewayRouteController{
-		client:                 client,
-		kube:                   kube,
-		logger:                 logger,
-		extProcImage:           extProcImage,
-		extProcImagePullPolicy: corev1.PullIfNotPresent,
-		extProcLogLevel:        extProcLogLevel,
+	c := &AIGatewayRouteController{
+		client:                  client,
+		kube:                    kube,
+		logger:                  logger,
+		recorder:                recorder,
+		extProcImage:            extProcImage,
+		extProcImagePullPolicy:  corev1.PullIfNotPresent,
+		extProcLogLevel:         extProcLogLevel,
+		extProcDefaultResources: extProcDefaultResources,
+	}
+	if extProcImagePullSecret != "" {
+		c.extProcImagePullSecrets = []corev1.LocalObjectReference{{Name: extProcImagePullSecret}}
 	}
+	return c
 }
 
 // Reconcile implements [reconcile.TypedReconciler].
-func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.Request) (res reconcile.Result, err error) {
 	logger := log.FromContext(ctx)
 	logger.Info("Reconciling AIGatewayRoute", "namespace", req.Namespace, "name", req.Name)
 
@@ -86,22 +158,447 @@ func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.
 		if client.IgnoreNotFound(err) == nil {
 			c.logger.Info("Deleting AIGatewayRoute",
 				"namespace", req.Namespace, "name", req.Name)
-			return ctrl.Result{}, nil
+			return ctrl.Result{}, c.deleteOrphanedResources(ctx, req.Namespace)
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !aiGatewayRoute.DeletionTimestamp.IsZero() {
+		c.logger.Info("Finalizing AIGatewayRoute", "namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
+		if err := c.deleteOrphanedResources(ctx, aiGatewayRoute.Namespace); err != nil {
+			return ctrl.Result{}, err
+		}
+		if ctrlutil.RemoveFinalizer(&aiGatewayRoute, aiGatewayRouteFinalizer) {
+			if err := c.client.Update(ctx, &aiGatewayRoute); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to remove finalizer: %w", err)
+			}
 		}
+		return ctrl.Result{}, nil
+	}
+	if ctrlutil.AddFinalizer(&aiGatewayRoute, aiGatewayRouteFinalizer) {
+		if err := c.client.Update(ctx, &aiGatewayRoute); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to add finalizer: %w", err)
+		}
+	}
+	defer func() {
+		if err != nil {
+			c.recorder.Event(&aiGatewayRoute, corev1.EventTypeWarning, eventReason(err), err.Error())
+		}
+	}()
+
+	if err := c.updateTargetRefConditions(ctx, &aiGatewayRoute); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	// TODO: merge this into syncAIGatewayRoute. This is a left over from the previous sink based implementation.
-	c.logger.Info("Ensuring extproc configmap exists", "namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
-	if err := c.ensuresExtProcConfigMapExists(ctx, &aiGatewayRoute); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to ensure extproc configmap exists: %w", err)
+	if !extProcShared(&aiGatewayRoute) {
+		c.logger.Info("Ensuring extproc configmap exists", "namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
+		if err := c.ensuresExtProcConfigMapExists(ctx, &aiGatewayRoute); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to ensure extproc configmap exists: %w", err)
+		}
 	}
 	// TODO: merge this into syncAIGatewayRoute. This is a left over from the previous sink based implementation.
+	if err := c.validateFailOpenDefaultBackend(ctx, &aiGatewayRoute); err != nil {
+		return ctrl.Result{}, err
+	}
 	c.logger.Info("Reconciling extension policy", "namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
 	if err := c.reconcileExtProcExtensionPolicy(ctx, &aiGatewayRoute); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to reconcile extension policy: %w", err)
 	}
-	return reconcile.Result{}, c.syncAIGatewayRoute(ctx, &aiGatewayRoute)
+	if err := c.syncAIGatewayRoute(ctx, &aiGatewayRoute); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := c.updateProgrammedCondition(ctx, &aiGatewayRoute); err != nil {
+		return ctrl.Result{}, err
+	}
+	return reconcile.Result{}, c.deleteOrphanedResources(ctx, aiGatewayRoute.Namespace)
+}
+
+// gatewayToAIGatewayRoutes maps the Gateway to the reconcile requests of the AIGatewayRoutes targeting it, so that
+// the AIGatewayRoutes created before the Gateway recover once it is created, and reflect its changes.
+func (c *AIGatewayRouteController) gatewayToAIGatewayRoutes(ctx context.Context, gateway client.Object) []reconcile.Request {
+	key := fmt.Sprintf("%s.%s", gateway.GetName(), gateway.GetNamespace())
+	var aiGatewayRoutes aigv1a1.AIGatewayRouteList
+	if err := c.client.List(ctx, &aiGatewayRoutes, client.MatchingFields{k8sClientIndexGatewayToReferencingAIGatewayRoute: key}); err != nil {
+		c.logger.Error(err, "failed to list AIGatewayRoutes referencing Gateway", "gateway", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(aiGatewayRoutes.Items))
+	for i := range aiGatewayRoutes.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&aiGatewayRoutes.Items[i])})
+	}
+	return requests
+}
+
+// updateTargetRefConditions updates the Accepted and ResolvedRefs conditions of the AIGatewayRoute status based on
+// whether the Gateways referenced by the TargetRefs exist and are managed by Envoy Gateway, and whether the listeners
+// referenced by the SectionName of the TargetRefs exist in the Gateways.
+func (c *AIGatewayRouteController) updateTargetRefConditions(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	accepted := metav1.Condition{
+		Type:               string(gwapiv1.RouteConditionAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gwapiv1.RouteReasonAccepted),
+		Message:            "All target Gateways are managed by Envoy Gateway",
+		ObservedGeneration: aiGatewayRoute.Generation,
+	}
+	message, err := c.unacceptedTargetRef(ctx, aiGatewayRoute)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		c.logger.Info("Unaccepted target reference", "namespace", aiGatewayRoute.Namespace,
+			"name", aiGatewayRoute.Name, "message", message)
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = string(gwapiv1.RouteReasonNoMatchingParent)
+		accepted.Message = message
+	}
+
+	resolvedRefs := metav1.Condition{
+		Type:               string(gwapiv1.RouteConditionResolvedRefs),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gwapiv1.RouteReasonResolvedRefs),
+		Message:            "All target references are resolved",
+		ObservedGeneration: aiGatewayRoute.Generation,
+	}
+	message, err = c.unresolvedTargetRef(ctx, aiGatewayRoute)
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		c.logger.Info("Unresolved target reference", "namespace", aiGatewayRoute.Namespace,
+			"name", aiGatewayRoute.Name, "message", message)
+		resolvedRefs.Status = metav1.ConditionFalse
+		resolvedRefs.Reason = string(gwapiv1.RouteReasonNoMatchingParent)
+		resolvedRefs.Message = message
+	}
+	return c.setStatusConditions(ctx, aiGatewayRoute, accepted, resolvedRefs)
+}
+
+// setStatusConditions sets the conditions of the AIGatewayRoute status and updates it only when any of them changed.
+func (c *AIGatewayRouteController) setStatusConditions(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute, conditions ...metav1.Condition) error {
+	var changed bool
+	for _, condition := range conditions {
+		if meta.SetStatusCondition(&aiGatewayRoute.Status.Conditions, condition) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if err := c.client.Status().Update(ctx, aiGatewayRoute); err != nil {
+		return fmt.Errorf("failed to update AIGatewayRoute status: %w", err)
+	}
+	return nil
+}
+
+// unacceptedTargetRef returns the message describing the first target Gateway that does not exist or belongs to
+// a GatewayClass not managed by Envoy Gateway, or an empty string if all of them are accepted.
+func (c *AIGatewayRouteController) unacceptedTargetRef(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) (string, error) {
+	for _, ref := range aiGatewayRoute.Spec.TargetRefs {
+		if ref.Kind != "Gateway" {
+			continue
+		}
+		var gateway gwapiv1.Gateway
+		if err := c.client.Get(ctx, client.ObjectKey{Name: string(ref.Name), Namespace: aiGatewayRoute.Namespace}, &gateway); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Sprintf("Gateway %s is not found", ref.Name), nil
+			}
+			return "", fmt.Errorf("failed to get Gateway %s: %w", ref.Name, err)
+		}
+		var gatewayClass gwapiv1.GatewayClass
+		if err := c.client.Get(ctx, client.ObjectKey{Name: string(gateway.Spec.GatewayClassName)}, &gatewayClass); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return "", fmt.Errorf("failed to get GatewayClass %s: %w", gateway.Spec.GatewayClassName, err)
+			}
+		} else if gatewayClass.Spec.ControllerName == egv1a1.GatewayControllerName {
+			continue
+		}
+		return fmt.Sprintf("Gateway %s belongs to GatewayClass %s that is not managed by Envoy Gateway",
+			ref.Name, gateway.Spec.GatewayClassName), nil
+	}
+	return "", nil
+}
+
+// updateProgrammedCondition updates the Programmed condition of the AIGatewayRoute status based on the parent
+// status of the generated HTTPRoute, which is set by Envoy Gateway. The changes of the HTTPRoute status trigger
+// the reconciliation of the owning AIGatewayRoute, so this eventually reflects the latest status.
+func (c *AIGatewayRouteController) updateProgrammedCondition(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	var httpRoute gwapiv1.HTTPRoute
+	if err := c.client.Get(ctx, client.ObjectKeyFromObject(aiGatewayRoute), &httpRoute); err != nil {
+		return fmt.Errorf("failed to get HTTPRoute: %w", err)
+	}
+	programmed := metav1.Condition{
+		Type:               aigv1a1.AIGatewayRouteConditionProgrammed,
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gwapiv1.RouteReasonAccepted),
+		Message:            "The HTTPRoute is accepted by all the parent Gateways",
+		ObservedGeneration: aiGatewayRoute.Generation,
+	}
+	if message := unacceptedHTTPRouteParent(&httpRoute); message != "" {
+		programmed.Status = metav1.ConditionFalse
+		programmed.Reason = aigv1a1.AIGatewayRouteReasonPending
+		programmed.Message = message
+	}
+	wasProgrammed := meta.IsStatusConditionTrue(aiGatewayRoute.Status.Conditions, aigv1a1.AIGatewayRouteConditionProgrammed)
+	if err := c.setStatusConditions(ctx, aiGatewayRoute, programmed); err != nil {
+		return err
+	}
+	if !wasProgrammed && programmed.Status == metav1.ConditionTrue {
+		c.recorder.Event(aiGatewayRoute, corev1.EventTypeNormal, EventReasonProgrammed, programmed.Message)
+	}
+	return nil
+}
+
+// unacceptedHTTPRouteParent returns the message describing why the HTTPRoute is not accepted by its parents,
+// or an empty string if all the parents have accepted the current generation of the HTTPRoute.
+func unacceptedHTTPRouteParent(httpRoute *gwapiv1.HTTPRoute) string {
+	if len(httpRoute.Status.Parents) == 0 {
+		return fmt.Sprintf("HTTPRoute %s has not been accepted by any parent Gateway yet", httpRoute.Name)
+	}
+	for i := range httpRoute.Status.Parents {
+		parent := &httpRoute.Status.Parents[i]
+		cond := meta.FindStatusCondition(parent.Conditions, string(gwapiv1.RouteConditionAccepted))
+		switch {
+		case cond == nil || cond.ObservedGeneration < httpRoute.Generation:
+			return fmt.Sprintf("HTTPRoute %s has not been accepted by Gateway %s yet", httpRoute.Name, parent.ParentRef.Name)
+		case cond.Status != metav1.ConditionTrue:
+			return fmt.Sprintf("HTTPRoute %s is not accepted by Gateway %s: %s", httpRoute.Name, parent.ParentRef.Name, cond.Message)
+		}
+	}
+	return ""
+}
+
+// unresolvedTargetRef returns the message describing the first target reference whose SectionName does not match
+// any listener of the referenced Gateway, or an empty string if all of them are resolved.
+func (c *AIGatewayRouteController) unresolvedTargetRef(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) (string, error) {
+	for _, ref := range aiGatewayRoute.Spec.TargetRefs {
+		if ref.SectionName == nil || ref.Kind != "Gateway" {
+			continue
+		}
+		var gateway gwapiv1.Gateway
+		if err := c.client.Get(ctx, client.ObjectKey{Name: string(ref.Name), Namespace: aiGatewayRoute.Namespace}, &gateway); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Sprintf("Gateway %s is not found", ref.Name), nil
+			}
+			return "", fmt.Errorf("failed to get Gateway %s: %w", ref.Name, err)
+		}
+		if !slices.ContainsFunc(gateway.Spec.Listeners, func(l gwapiv1.Listener) bool {
+			return l.Name == *ref.SectionName
+		}) {
+			return fmt.Sprintf("listener %s is not found in Gateway %s", *ref.SectionName, ref.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// deleteOrphanedResources deletes the resources labeled with aiGatewayRouteLabel in the namespace whose AIGatewayRoute
+// no longer exists. When no AIGatewayRoute remains in the namespace, this also deletes the host rewrite HTTPRouteFilter.
+//
+// This also syncs the shared external processor with the remaining AIGatewayRoutes using it, so that the rules of
+// the deleted ones are removed from its configuration.
+func (c *AIGatewayRouteController) deleteOrphanedResources(ctx context.Context, namespace string) error {
+	var routes aigv1a1.AIGatewayRouteList
+	if err := c.client.List(ctx, &routes, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list AIGatewayRoutes: %w", err)
+	}
+	var liveRoutes []string
+	var sharedRoutes []*aigv1a1.AIGatewayRoute
+	for i := range routes.Items {
+		if routes.Items[i].DeletionTimestamp.IsZero() {
+			liveRoutes = append(liveRoutes, labelValue(routes.Items[i].Name))
+			if extProcShared(&routes.Items[i]) {
+				sharedRoutes = append(sharedRoutes, &routes.Items[i])
+			}
+		}
+	}
+	if err := c.syncSharedExtProc(ctx, namespace, sharedRoutes); err != nil {
+		return fmt.Errorf("failed to sync shared extproc: %w", err)
+	}
+	selector, err := orphanedResourceSelector(liveRoutes)
+	if err != nil {
+		return fmt.Errorf("failed to build label selector: %w", err)
+	}
+
+	clientListOpts := []client.ListOption{client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}}
+	var policies egv1a1.EnvoyExtensionPolicyList
+	if err = c.client.List(ctx, &policies, clientListOpts...); err != nil {
+		return fmt.Errorf("failed to list EnvoyExtensionPolicies: %w", err)
+	}
+	for i := range policies.Items {
+		if err = c.deleteOrphan(ctx, "EnvoyExtensionPolicy", &policies.Items[i]); err != nil {
+			return err
+		}
+	}
+	var securityPolicies egv1a1.SecurityPolicyList
+	if err = c.client.List(ctx, &securityPolicies, clientListOpts...); err != nil {
+		return fmt.Errorf("failed to list SecurityPolicies: %w", err)
+	}
+	for i := range securityPolicies.Items {
+		if err = c.deleteOrphan(ctx, "SecurityPolicy", &securityPolicies.Items[i]); err != nil {
+			return err
+		}
+	}
+	var trafficPolicies egv1a1.BackendTrafficPolicyList
+	if err = c.client.List(ctx, &trafficPolicies, clientListOpts...); err != nil {
+		return fmt.Errorf("failed to list BackendTrafficPolicies: %w", err)
+	}
+	for i := range trafficPolicies.Items {
+		if err = c.deleteOrphan(ctx, "BackendTrafficPolicy", &trafficPolicies.Items[i]); err != nil {
+			return err
+		}
+	}
+	var httpRoutes gwapiv1.HTTPRouteList
+	if err = c.client.List(ctx, &httpRoutes, clientListOpts...); err != nil {
+		return fmt.Errorf("failed to list HTTPRoutes: %w", err)
+	}
+	for i := range httpRoutes.Items {
+		if err = c.deleteOrphan(ctx, "HTTPRoute", &httpRoutes.Items[i]); err != nil {
+			return err
+		}
+	}
+	var envoyProxies egv1a1.EnvoyProxyList
+	if err = c.client.List(ctx, &envoyProxies, clientListOpts...); err != nil {
+		return fmt.Errorf("failed to list EnvoyProxies: %w", err)
+	}
+	for i := range envoyProxies.Items {
+		if err = c.deleteOrphan(ctx, "EnvoyProxy", &envoyProxies.Items[i]); err != nil {
+			return err
+		}
+	}
+	var backends egv1a1.BackendList
+	if err = c.client.List(ctx, &backends, clientListOpts...); err != nil {
+		return fmt.Errorf("failed to list Backends: %w", err)
+	}
+	for i := range backends.Items {
+		if err = c.deleteOrphan(ctx, "Backend", &backends.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: selector.String()}
+	deleteOpts := metav1.DeleteOptions{}
+	configMaps, err := c.kube.CoreV1().ConfigMaps(namespace).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list ConfigMaps: %w", err)
+	}
+	for _, cm := range configMaps.Items {
+		c.logger.Info("Deleting orphaned ConfigMap", "namespace", namespace, "name", cm.Name)
+		if err = c.kube.CoreV1().ConfigMaps(namespace).Delete(ctx, cm.Name, deleteOpts); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ConfigMap %s.%s: %w", cm.Name, namespace, err)
+		}
+	}
+	deployments, err := c.kube.AppsV1().Deployments(namespace).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list Deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		c.logger.Info("Deleting orphaned Deployment", "namespace", namespace, "name", d.Name)
+		if err = c.kube.AppsV1().Deployments(namespace).Delete(ctx, d.Name, deleteOpts); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete Deployment %s.%s: %w", d.Name, namespace, err)
+		}
+	}
+	services, err := c.kube.CoreV1().Services(namespace).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list Services: %w", err)
+	}
+	for _, s := range services.Items {
+		c.logger.Info("Deleting orphaned Service", "namespace", namespace, "name", s.Name)
+		if err = c.kube.CoreV1().Services(namespace).Delete(ctx, s.Name, deleteOpts); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete Service %s.%s: %w", s.Name, namespace, err)
+		}
+	}
+	hpas, err := c.kube.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list HorizontalPodAutoscalers: %w", err)
+	}
+	for _, h := range hpas.Items {
+		c.logger.Info("Deleting orphaned HorizontalPodAutoscaler", "namespace", namespace, "name", h.Name)
+		if err = c.kube.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, h.Name, deleteOpts); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete HorizontalPodAutoscaler %s.%s: %w", h.Name, namespace, err)
+		}
+	}
+	pdbs, err := c.kube.PolicyV1().PodDisruptionBudgets(namespace).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	for _, p := range pdbs.Items {
+		c.logger.Info("Deleting orphaned PodDisruptionBudget", "namespace", namespace, "name", p.Name)
+		if err = c.kube.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, p.Name, deleteOpts); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete PodDisruptionBudget %s.%s: %w", p.Name, namespace, err)
+		}
+	}
+
+	if len(liveRoutes) == 0 {
+		filter := &egv1a1.HTTPRouteFilter{ObjectMeta: metav1.ObjectMeta{Name: hostRewriteHTTPFilterName, Namespace: namespace}}
+		if err = c.deleteOrphan(ctx, "HTTPRouteFilter", filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOrphan deletes the orphaned object via the controller-runtime client, ignoring the not found error.
+func (c *AIGatewayRouteController) deleteOrphan(ctx context.Context, kind string, obj client.Object) error {
+	c.logger.Info("Deleting orphaned "+kind, "namespace", obj.GetNamespace(), "name", obj.GetName())
+	if err := c.client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete %s %s.%s: %w", kind, obj.GetName(), obj.GetNamespace(), err)
+	}
+	return nil
+}
+
+// orphanedResourceSelector returns the label selector that matches the resources created for an AIGatewayRoute
+// that is not in the given live routes.
+func orphanedResourceSelector(liveRoutes []string) (labels.Selector, error) {
+	exists, err := labels.NewRequirement(aiGatewayRouteLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	selector := labels.NewSelector().Add(*exists)
+	if len(liveRoutes) > 0 {
+		notIn, err := labels.NewRequirement(aiGatewayRouteLabel, selection.NotIn, liveRoutes)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*notIn)
+	}
+	return selector, nil
+}
+
+// mergeLabels returns the new labels with all the given labels, where the later one takes precedence.
+func mergeLabels(labelSets ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, l := range labelSets {
+		maps.Copy(merged, l)
+	}
+	return merged
+}
+
+// aiGatewayRouteLabels returns the labels set to the resources created for the given AIGatewayRoute.
+func aiGatewayRouteLabels(aiGatewayRoute *aigv1a1.AIGatewayRoute) map[string]string {
+	return map[string]string{managedByLabel: managedByLabelValue, aiGatewayRouteLabel: labelValue(aiGatewayRoute.Name)}
+}
+
+// labelValue returns the value as-is if it fits in a label value. Otherwise, the value is truncated and suffixed with
+// the hash of the whole value so that the long names sharing the same prefix still result in distinct label values.
+func labelValue(value string) string {
+	if len(value) <= validation.LabelValueMaxLength {
+		return value
+	}
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:labelValueHashLength]
+	prefix := strings.TrimRight(value[:validation.LabelValueMaxLength-labelValueHashLength-1], "-_.")
+	return prefix + "-" + hash
+}
+
+// extProcPolicyTargetRefs returns the target references of the extension policy of the external processor. The
+// section names are dropped since EnvoyExtensionPolicy does not support them, and the HTTPRoute is attached only to
+// the sections by its parent references instead. See [httpRouteParentRefs].
+func extProcPolicyTargetRefs(aiGatewayRoute *aigv1a1.AIGatewayRoute) []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName {
+	targetRefs := make([]gwapiv1a2.LocalPolicyTargetReferenceWithSectionName, len(aiGatewayRoute.Spec.TargetRefs))
+	for i, ref := range aiGatewayRoute.Spec.TargetRefs {
+		targetRefs[i] = gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{LocalPolicyTargetReference: ref.LocalPolicyTargetReference}
+	}
+	return targetRefs
 }
 
 // reconcileExtProcExtensionPolicy creates or updates the extension policy for the external process.
@@ -109,7 +606,17 @@ func (c *AIGatewayRouteController) Reconcile(ctx context.Context, req reconcile.
 func (c *AIGatewayRouteController) reconcileExtProcExtensionPolicy(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) (err error) {
 	var existingPolicy egv1a1.EnvoyExtensionPolicy
 	if err = c.client.Get(ctx, client.ObjectKey{Name: extProcName(aiGatewayRoute), Namespace: aiGatewayRoute.Namespace}, &existingPolicy); err == nil {
-		existingPolicy.Spec.PolicyTargetReferences.TargetRefs = aiGatewayRoute.Spec.TargetRefs
+		existingPolicy.Spec.PolicyTargetReferences.TargetRefs = extProcPolicyTargetRefs(aiGatewayRoute)
+		if len(existingPolicy.Spec.ExtProc) > 0 {
+			// The backend changes when the deployment mode of the external processor is switched.
+			existingPolicy.Spec.ExtProc[0].BackendCluster.BackendRefs = extProcBackendRefs(aiGatewayRoute)
+			existingPolicy.Spec.ExtProc[0].FailOpen = ptr.To(extProcFailOpen(aiGatewayRoute))
+			existingPolicy.Spec.ExtProc[0].Metadata = &egv1a1.ExtProcMetadata{
+				WritableNamespaces: []string{metadataNamespace(aiGatewayRoute)},
+			}
+		}
+		// Labels the policy created before the labels were introduced.
+		existingPolicy.Labels = mergeLabels(existingPolicy.Labels, aiGatewayRouteLabels(aiGatewayRoute))
 		if err = c.client.Update(ctx, &existingPolicy); err != nil {
 			return fmt.Errorf("failed to update extension policy: %w", err)
 		}
@@ -119,27 +626,22 @@ func (c *AIGatewayRouteController) reconcileExtProcExtensionPolicy(ctx context.C
 	}
 
 	pm := egv1a1.BufferedExtProcBodyProcessingMode
-	port := gwapiv1.PortNumber(1063)
-	objNs := gwapiv1.Namespace(aiGatewayRoute.Namespace)
 	extPolicy := &egv1a1.EnvoyExtensionPolicy{
-		ObjectMeta: metav1.ObjectMeta{Name: extProcName(aiGatewayRoute), Namespace: aiGatewayRoute.Namespace},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: extProcName(aiGatewayRoute), Namespace: aiGatewayRoute.Namespace, Labels: aiGatewayRouteLabels(aiGatewayRoute),
+		},
 		Spec: egv1a1.EnvoyExtensionPolicySpec{
-			PolicyTargetReferences: egv1a1.PolicyTargetReferences{TargetRefs: aiGatewayRoute.Spec.TargetRefs},
+			PolicyTargetReferences: egv1a1.PolicyTargetReferences{TargetRefs: extProcPolicyTargetRefs(aiGatewayRoute)},
 			ExtProc: []egv1a1.ExtProc{{
 				ProcessingMode: &egv1a1.ExtProcProcessingMode{
 					AllowModeOverride: true, // Streaming completely overrides the buffered mode.
 					Request:           &egv1a1.ProcessingModeOptions{Body: &pm},
 					Response:          &egv1a1.ProcessingModeOptions{Body: &pm},
 				},
-				BackendCluster: egv1a1.BackendCluster{BackendRefs: []egv1a1.BackendRef{{
-					BackendObjectReference: gwapiv1.BackendObjectReference{
-						Name:      gwapiv1.ObjectName(extProcName(aiGatewayRoute)),
-						Namespace: &objNs,
-						Port:      &port,
-					},
-				}}},
+				BackendCluster: egv1a1.BackendCluster{BackendRefs: extProcBackendRefs(aiGatewayRoute)},
+				FailOpen:       ptr.To(extProcFailOpen(aiGatewayRoute)),
 				Metadata: &egv1a1.ExtProcMetadata{
-					WritableNamespaces: []string{aigv1a1.AIGatewayFilterMetadataNamespace},
+					WritableNamespaces: []string{metadataNamespace(aiGatewayRoute)},
 				},
 			}},
 		},
@@ -153,6 +655,269 @@ func (c *AIGatewayRouteController) reconcileExtProcExtensionPolicy(ctx context.C
 	return
 }
 
+// reconcileCORSSecurityPolicy creates or updates the SecurityPolicy configuring the CORS of the generated HTTPRoute,
+// or deletes it when the CORS is not configured.
+func (c *AIGatewayRouteController) reconcileCORSSecurityPolicy(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	name := corsSecurityPolicyName(aiGatewayRoute)
+	if aiGatewayRoute.Spec.CORS == nil {
+		policy := &egv1a1.SecurityPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace}}
+		if err := c.client.Delete(ctx, policy); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete SecurityPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		return nil
+	}
+
+	spec := egv1a1.SecurityPolicySpec{
+		PolicyTargetReferences: egv1a1.PolicyTargetReferences{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName, Kind: "HTTPRoute", Name: gwapiv1.ObjectName(aiGatewayRoute.Name),
+				},
+			}},
+		},
+		CORS: aiGatewayRoute.Spec.CORS.DeepCopy(),
+	}
+	var policy egv1a1.SecurityPolicy
+	err := c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: aiGatewayRoute.Namespace}, &policy)
+	if apierrors.IsNotFound(err) {
+		policy = egv1a1.SecurityPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace, Labels: aiGatewayRouteLabels(aiGatewayRoute)},
+			Spec:       spec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, &policy, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for SecurityPolicy: %w", err))
+		}
+		if err = c.client.Create(ctx, &policy); err != nil {
+			return fmt.Errorf("failed to create SecurityPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get SecurityPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	policy.Spec = spec
+	if err = c.client.Update(ctx, &policy); err != nil {
+		return fmt.Errorf("failed to update SecurityPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	return nil
+}
+
+// corsSecurityPolicyName returns the name of the SecurityPolicy configuring the CORS of the route.
+func corsSecurityPolicyName(route *aigv1a1.AIGatewayRoute) string {
+	return fmt.Sprintf("ai-eg-route-cors-%s", route.Name)
+}
+
+// reconcileExcludedPaths creates or updates the HTTPRoute routing the excluded paths to the default backend, and
+// the EnvoyExtensionPolicy disabling the external processing for it, or deletes them when no path is excluded.
+func (c *AIGatewayRouteController) reconcileExcludedPaths(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	name := excludedPathsName(aiGatewayRoute)
+	if len(aiGatewayRoute.Spec.ExcludePaths) == 0 {
+		for kind, obj := range map[string]client.Object{
+			"EnvoyExtensionPolicy": &egv1a1.EnvoyExtensionPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace}},
+			"HTTPRoute":            &gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace}},
+		} {
+			if err := c.client.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete %s %s.%s: %w", kind, name, aiGatewayRoute.Namespace, err)
+			}
+		}
+		return nil
+	}
+
+	backendName := defaultBackendName(aiGatewayRoute)
+	backend, err := c.backend(ctx, aiGatewayRoute.Namespace, backendName)
+	if apierrors.IsNotFound(err) {
+		return withEventReason(EventReasonBackendNotFound,
+			fmt.Errorf("AIServiceBackend %s.%s not found", backendName, aiGatewayRoute.Namespace))
+	} else if err != nil {
+		return fmt.Errorf("failed to get AIServiceBackend %s.%s: %w", backendName, aiGatewayRoute.Namespace, err)
+	}
+	if err = defaultBackendRefPort(ctx, c.client, backend); err != nil {
+		return err
+	}
+	matches := make([]gwapiv1.HTTPRouteMatch, len(aiGatewayRoute.Spec.ExcludePaths))
+	for i, p := range aiGatewayRoute.Spec.ExcludePaths {
+		matches[i] = gwapiv1.HTTPRouteMatch{Path: &gwapiv1.HTTPPathMatch{
+			Type: ptr.To(ptr.Deref(p.Type, gwapiv1.PathMatchPathPrefix)), Value: ptr.To(p.Value),
+		}}
+	}
+	encodedTrafficPolicies, err := json.Marshal([]*aigv1a1.AIServiceBackendTrafficPolicy{backend.Spec.TrafficPolicy})
+	if err != nil {
+		return fmt.Errorf("failed to marshal traffic policies: %w", err)
+	}
+	routeSpec := gwapiv1.HTTPRouteSpec{
+		CommonRouteSpec: gwapiv1.CommonRouteSpec{ParentRefs: httpRouteParentRefs(aiGatewayRoute)},
+		Rules: []gwapiv1.HTTPRouteRule{{
+			Matches: matches,
+			BackendRefs: []gwapiv1.HTTPBackendRef{
+				{BackendRef: gwapiv1.BackendRef{BackendObjectReference: backend.Spec.BackendRef}},
+			},
+			Filters: backendRewriteFilters(backend, []gwapiv1.HTTPRouteFilter{{
+				Type: gwapiv1.HTTPRouteFilterExtensionRef,
+				ExtensionRef: &gwapiv1.LocalObjectReference{
+					Group: "gateway.envoyproxy.io", Kind: "HTTPRouteFilter", Name: hostRewriteHTTPFilterName,
+				},
+			}}),
+		}},
+	}
+	var httpRoute gwapiv1.HTTPRoute
+	err = c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: aiGatewayRoute.Namespace}, &httpRoute)
+	if apierrors.IsNotFound(err) {
+		httpRoute = gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace}}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, &httpRoute, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for HTTPRoute: %w", err))
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get HTTPRoute %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	httpRoute.Spec = routeSpec
+	httpRoute.Labels = mergeLabels(httpRoute.Labels, aiGatewayRouteLabels(aiGatewayRoute))
+	if httpRoute.Annotations == nil {
+		httpRoute.Annotations = make(map[string]string)
+	}
+	httpRoute.Annotations[extensionserver.TrafficPoliciesAnnotationKey] = string(encodedTrafficPolicies)
+	if httpRoute.ResourceVersion == "" {
+		err = c.client.Create(ctx, &httpRoute)
+	} else {
+		err = c.client.Update(ctx, &httpRoute)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply HTTPRoute %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+
+	// The policy targeting the HTTPRoute takes precedence over the one targeting the Gateway, so the policy without
+	// any extension disables the external processing for the HTTPRoute.
+	policySpec := egv1a1.EnvoyExtensionPolicySpec{
+		PolicyTargetReferences: egv1a1.PolicyTargetReferences{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName, Kind: "HTTPRoute", Name: gwapiv1.ObjectName(name),
+				},
+			}},
+		},
+	}
+	var policy egv1a1.EnvoyExtensionPolicy
+	err = c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: aiGatewayRoute.Namespace}, &policy)
+	if apierrors.IsNotFound(err) {
+		policy = egv1a1.EnvoyExtensionPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace, Labels: aiGatewayRouteLabels(aiGatewayRoute)},
+			Spec:       policySpec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, &policy, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for EnvoyExtensionPolicy: %w", err))
+		}
+		if err = c.client.Create(ctx, &policy); err != nil {
+			return fmt.Errorf("failed to create EnvoyExtensionPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get EnvoyExtensionPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	policy.Spec = policySpec
+	if err = c.client.Update(ctx, &policy); err != nil {
+		return fmt.Errorf("failed to update EnvoyExtensionPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	return nil
+}
+
+// excludedPathsName returns the name of the HTTPRoute and the EnvoyExtensionPolicy generated for the excluded paths
+// of the route.
+func excludedPathsName(route *aigv1a1.AIGatewayRoute) string {
+	return fmt.Sprintf("ai-eg-route-excluded-%s", route.Name)
+}
+
+// reconcileTrafficPolicy creates or updates the BackendTrafficPolicy configuring the rate limiting of the generated
+// HTTPRoute, or deletes it when the rate limit is not configured.
+func (c *AIGatewayRouteController) reconcileTrafficPolicy(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	name := trafficPolicyName(aiGatewayRoute)
+	if aiGatewayRoute.Spec.RateLimit == nil {
+		policy := &egv1a1.BackendTrafficPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace}}
+		if err := c.client.Delete(ctx, policy); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete BackendTrafficPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		return nil
+	}
+
+	rateLimit, err := newRateLimitSpec(aiGatewayRoute)
+	if err != nil {
+		return err
+	}
+	spec := egv1a1.BackendTrafficPolicySpec{
+		PolicyTargetReferences: egv1a1.PolicyTargetReferences{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName, Kind: "HTTPRoute", Name: gwapiv1.ObjectName(aiGatewayRoute.Name),
+				},
+			}},
+		},
+		RateLimit: rateLimit,
+	}
+	var policy egv1a1.BackendTrafficPolicy
+	err = c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: aiGatewayRoute.Namespace}, &policy)
+	if apierrors.IsNotFound(err) {
+		policy = egv1a1.BackendTrafficPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace, Labels: aiGatewayRouteLabels(aiGatewayRoute)},
+			Spec:       spec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, &policy, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for BackendTrafficPolicy: %w", err))
+		}
+		if err = c.client.Create(ctx, &policy); err != nil {
+			return fmt.Errorf("failed to create BackendTrafficPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get BackendTrafficPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	policy.Spec = spec
+	if err = c.client.Update(ctx, &policy); err != nil {
+		return fmt.Errorf("failed to update BackendTrafficPolicy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	return nil
+}
+
+// newRateLimitSpec returns the global rate limit of Envoy Gateway with a rule per limit of the route. The limits with
+// the metadata key consume the cost set to the dynamic metadata by the AI Gateway filter on the response path.
+func newRateLimitSpec(aiGatewayRoute *aigv1a1.AIGatewayRoute) (*egv1a1.RateLimitSpec, error) {
+	rl := aiGatewayRoute.Spec.RateLimit
+	var clientSelectors []egv1a1.RateLimitSelectCondition
+	if rl.ClientSelectorHeader != "" {
+		clientSelectors = []egv1a1.RateLimitSelectCondition{{
+			Headers: []egv1a1.HeaderMatch{{Name: rl.ClientSelectorHeader, Type: ptr.To(egv1a1.HeaderMatchDistinct)}},
+		}}
+	}
+	rules := make([]egv1a1.RateLimitRule, 0, len(rl.Limits))
+	for i, limit := range rl.Limits {
+		rule := egv1a1.RateLimitRule{
+			ClientSelectors: clientSelectors,
+			Limit:           egv1a1.RateLimitValue{Requests: limit.Requests, Unit: limit.Unit},
+		}
+		if limit.MetadataKey != "" {
+			if !slices.ContainsFunc(aiGatewayRoute.Spec.LLMRequestCosts, func(c aigv1a1.LLMRequestCost) bool {
+				return c.MetadataKey == limit.MetadataKey
+			}) {
+				return nil, fmt.Errorf("rateLimit.limits[%d]: metadataKey %q does not match any of llmRequestCosts", i, limit.MetadataKey)
+			}
+			rule.Cost = &egv1a1.RateLimitCost{
+				// Only checks the budget on the request path since the cost is not known until the response completes.
+				Request: &egv1a1.RateLimitCostSpecifier{From: egv1a1.RateLimitCostFromNumber, Number: ptr.To[uint64](0)},
+				Response: &egv1a1.RateLimitCostSpecifier{
+					From: egv1a1.RateLimitCostFromMetadata,
+					Metadata: &egv1a1.RateLimitCostMetadata{
+						Namespace: metadataNamespace(aiGatewayRoute),
+						Key:       limit.MetadataKey,
+					},
+				},
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return &egv1a1.RateLimitSpec{Type: egv1a1.GlobalRateLimitType, Global: &egv1a1.GlobalRateLimit{Rules: rules}}, nil
+}
+
+// trafficPolicyName returns the name of the BackendTrafficPolicy generated for the route.
+func trafficPolicyName(route *aigv1a1.AIGatewayRoute) string {
+	return fmt.Sprintf("ai-eg-route-traffic-%s", route.Name)
+}
+
 // ensuresExtProcConfigMapExists ensures that a configmap exists for the external process.
 // This must happen before the external processor deployment is created.
 func (c *AIGatewayRouteController) ensuresExtProcConfigMapExists(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) (err error) {
@@ -164,6 +929,7 @@ func (c *AIGatewayRouteController) ensuresExtProcConfigMapExists(ctx context.Con
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      name,
 				Namespace: aiGatewayRoute.Namespace,
+				Labels:    aiGatewayRouteLabels(aiGatewayRoute),
 			},
 			Data: map[string]string{expProcConfigFileName: filterapi.DefaultConfig},
 		}
@@ -179,19 +945,192 @@ func extProcName(route *aigv1a1.AIGatewayRoute) string {
 	return fmt.Sprintf("ai-eg-route-extproc-%s", route.Name)
 }
 
-func applyExtProcDeploymentConfigUpdate(d *appsv1.DeploymentSpec, filterConfig *aigv1a1.AIGatewayFilterConfig) {
-	if filterConfig == nil || filterConfig.ExternalProcessor == nil {
-		d.Replicas = nil
-		d.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
-		return
-	}
-	extProc := filterConfig.ExternalProcessor
-	if resource := extProc.Resources; resource != nil {
-		d.Template.Spec.Containers[0].Resources = *resource
-	} else {
-		d.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{}
+// extProcSidecarMode returns true if the external processor of the route runs as a sidecar of the Envoy proxy pods.
+func extProcSidecarMode(route *aigv1a1.AIGatewayRoute) bool {
+	fc := route.Spec.FilterConfig
+	return fc != nil && fc.ExternalProcessor != nil &&
+		fc.ExternalProcessor.DeploymentMode == aigv1a1.AIGatewayFilterConfigExternalProcessorDeploymentModeSidecar
+}
+
+// extProcShared returns true if the external processor of the route runs in the Deployment shared in the namespace.
+func extProcShared(route *aigv1a1.AIGatewayRoute) bool {
+	fc := route.Spec.FilterConfig
+	return fc != nil && fc.SharedDeployment
+}
+
+// extProcFailOpen returns true if the requests should pass through untranslated when the external processor is
+// unavailable.
+func extProcFailOpen(route *aigv1a1.AIGatewayRoute) bool {
+	fc := route.Spec.FilterConfig
+	return fc != nil && fc.FailureMode == aigv1a1.AIGatewayFilterConfigFailureModeFailOpen
+}
+
+// validateFailOpenDefaultBackend validates that the default backend has the OpenAI schema in the FailOpen failure mode
+// since the requests routed to it untranslated when the external processor is unavailable would break other schemas.
+func (c *AIGatewayRouteController) validateFailOpenDefaultBackend(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	if !extProcFailOpen(aiGatewayRoute) || aiGatewayRoute.Spec.DisableDefaultRoute {
+		return nil
+	}
+	// The backend of the catch-all rule generated by newHTTPRoute.
+	name := defaultBackendName(aiGatewayRoute)
+	if name == "" {
+		return nil
+	}
+	backend, err := c.backend(ctx, aiGatewayRoute.Namespace, name)
+	if apierrors.IsNotFound(err) {
+		return withEventReason(EventReasonBackendNotFound, fmt.Errorf("AIServiceBackend %s.%s not found", name, aiGatewayRoute.Namespace))
+	} else if err != nil {
+		return fmt.Errorf("failed to get AIServiceBackend %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	}
+	if schema := backend.Spec.APISchema.Name; schema != aigv1a1.APISchemaOpenAI {
+		return fmt.Errorf("failureMode FailOpen requires the default backend %s to have the OpenAI schema, but got %s", name, schema)
+	}
+	return nil
+}
+
+// extProcSocketPath returns the path of the unix domain socket that the external processor listens on in the sidecar mode.
+func extProcSocketPath(route *aigv1a1.AIGatewayRoute) string {
+	return path.Join(extProcSocketDir, extProcName(route)+".sock")
+}
+
+// extProcBackendRefs returns the backend references of the external processor in the EnvoyExtensionPolicy.
+// This is the Service in the Deployment mode, the shared Service with the shared deployment, and the Backend of
+// the unix domain socket in the Sidecar mode.
+func extProcBackendRefs(route *aigv1a1.AIGatewayRoute) []egv1a1.BackendRef {
+	objNs := gwapiv1.Namespace(route.Namespace)
+	ref := gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(extProcName(route)), Namespace: &objNs}
+	if extProcShared(route) {
+		ref.Name = sharedExtProcName
+	}
+	if extProcSidecarMode(route) {
+		ref.Group = ptr.To[gwapiv1.Group]("gateway.envoyproxy.io")
+		ref.Kind = ptr.To[gwapiv1.Kind]("Backend")
+	} else {
+		ref.Port = ptr.To[gwapiv1.PortNumber](1063)
+	}
+	return []egv1a1.BackendRef{{BackendObjectReference: ref}}
+}
+
+// applyExtProcDeploymentConfigUpdate applies the external processor configuration of the filter config to the
+// deployment. The defaultImagePullSecrets and defaultResources are used when the filter config does not specify them.
+func applyExtProcDeploymentConfigUpdate(d *appsv1.DeploymentSpec, filterConfig *aigv1a1.AIGatewayFilterConfig,
+	defaultImagePullSecrets []corev1.LocalObjectReference, defaultResources corev1.ResourceRequirements,
+) {
+	podSpec := &d.Template.Spec
+	if filterConfig == nil || filterConfig.ExternalProcessor == nil {
+		d.Replicas = nil
+		d.Strategy = extProcDeploymentStrategy(nil)
+		d.RevisionHistoryLimit = ptr.To(defaultExtProcRevisionHistoryLimit)
+		podSpec.Containers[0].Resources = *defaultResources.DeepCopy()
+		podSpec.ImagePullSecrets = defaultImagePullSecrets
+		podSpec.NodeSelector = nil
+		podSpec.Tolerations = nil
+		podSpec.Affinity = nil
+		podSpec.ServiceAccountName = ""
+		setExtProcPodAnnotations(&d.Template, nil)
+		setExtProcOTLPEndpointArg(&podSpec.Containers[0], "")
+		return
+	}
+	extProc := filterConfig.ExternalProcessor
+	if resource := extProc.Resources; resource != nil {
+		podSpec.Containers[0].Resources = *resource
+	} else {
+		podSpec.Containers[0].Resources = *defaultResources.DeepCopy()
+	}
+	// When the HorizontalPodAutoscaler is configured, it owns the replicas of the deployment.
+	if extProc.HorizontalPodAutoscaler == nil {
+		d.Replicas = extProc.Replicas
+	}
+	if len(extProc.ImagePullSecrets) > 0 {
+		podSpec.ImagePullSecrets = extProc.ImagePullSecrets
+	} else {
+		podSpec.ImagePullSecrets = defaultImagePullSecrets
+	}
+	d.Strategy = extProcDeploymentStrategy(extProc.DeploymentStrategy)
+	d.RevisionHistoryLimit = ptr.To(ptr.Deref(extProc.RevisionHistoryLimit, defaultExtProcRevisionHistoryLimit))
+	podSpec.NodeSelector = extProc.NodeSelector
+	podSpec.Tolerations = extProc.Tolerations
+	podSpec.Affinity = extProc.Affinity
+	podSpec.ServiceAccountName = extProc.ServiceAccountName
+	setExtProcPodAnnotations(&d.Template, extProc.PodAnnotations)
+	setExtProcOTLPEndpointArg(&podSpec.Containers[0], extProc.OTLPEndpoint)
+}
+
+// setExtProcPodAnnotations merges the annotations into a copy of the annotations of the pod template, replacing
+// the ones applied previously. See extProcPodAnnotationKeysAnnotationKey.
+func setExtProcPodAnnotations(template *corev1.PodTemplateSpec, annotations map[string]string) {
+	merged := maps.Clone(template.Annotations)
+	if applied, ok := merged[extProcPodAnnotationKeysAnnotationKey]; ok {
+		for _, key := range strings.Split(applied, ",") {
+			delete(merged, key)
+		}
+		delete(merged, extProcPodAnnotationKeysAnnotationKey)
+	}
+	if len(annotations) > 0 {
+		if merged == nil {
+			merged = make(map[string]string, len(annotations)+1)
+		}
+		maps.Copy(merged, annotations)
+		merged[extProcPodAnnotationKeysAnnotationKey] = strings.Join(slices.Sorted(maps.Keys(annotations)), ",")
+	}
+	if len(merged) == 0 {
+		merged = nil
 	}
-	d.Replicas = extProc.Replicas
+	template.Annotations = merged
+}
+
+// defaultExtProcRevisionHistoryLimit is the revision history limit of the external processor deployment when not
+// specified, which is the default of the Deployment. This is set explicitly so that the deployment defaulted by the
+// API server is not considered changed.
+const defaultExtProcRevisionHistoryLimit int32 = 10
+
+// extProcDeploymentStrategy returns the strategy of the external processor deployment. The parameters of the
+// RollingUpdate strategy default to maxUnavailable=0 and maxSurge=1 so that the rollouts never reduce the serving
+// capacity, and are always set so that the deployment defaulted by the API server is not considered changed.
+func extProcDeploymentStrategy(strategy *appsv1.DeploymentStrategy) appsv1.DeploymentStrategy {
+	if strategy != nil && strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+	rollingUpdate := &appsv1.RollingUpdateDeployment{}
+	if strategy != nil && strategy.RollingUpdate != nil {
+		rollingUpdate = strategy.RollingUpdate.DeepCopy()
+	}
+	if rollingUpdate.MaxUnavailable == nil {
+		rollingUpdate.MaxUnavailable = ptr.To(intstr.FromInt32(0))
+	}
+	if rollingUpdate.MaxSurge == nil {
+		rollingUpdate.MaxSurge = ptr.To(intstr.FromInt32(1))
+	}
+	return appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType, RollingUpdate: rollingUpdate}
+}
+
+// parseOptionalFloat parses the floating point value specified as a string in the API, if set.
+func parseOptionalFloat(s *string) (*float64, error) {
+	if s == nil {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(*s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// setExtProcOTLPEndpointArg sets the -otlpEndpoint arg of the external processor container, or removes it when
+// the endpoint is empty.
+func setExtProcOTLPEndpointArg(container *corev1.Container, endpoint string) {
+	args := make([]string, 0, len(container.Args)+2)
+	for i := 0; i < len(container.Args); i++ {
+		if container.Args[i] == "-otlpEndpoint" {
+			i++ // Skip the value.
+			continue
+		}
+		args = append(args, container.Args[i])
+	}
+	if endpoint != "" {
+		args = append(args, "-otlpEndpoint", endpoint)
+	}
+	container.Args = args
 }
 
 // syncAIGatewayRoute implements syncAIGatewayRouteFn.
@@ -205,6 +1144,8 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      hostRewriteHTTPFilterName,
 				Namespace: aiGatewayRoute.Namespace,
+				// This is shared by all the AIGatewayRoutes in the namespace, hence not labeled with the route.
+				Labels: map[string]string{managedByLabel: managedByLabelValue},
 			},
 			Spec: egv1a1.HTTPRouteFilterSpec{
 				URLRewrite: &egv1a1.HTTPURLRewriteFilter{
@@ -259,155 +1200,778 @@ func (c *AIGatewayRouteController) syncAIGatewayRoute(ctx context.Context, aiGat
 		}
 	}
 
-	// Update the extproc configmap.
-	uuid := string(uuid2.NewUUID())
-	if err = c.updateExtProcConfigMap(ctx, aiGatewayRoute, uuid); err != nil {
-		return fmt.Errorf("failed to update extproc configmap: %w", err)
-	}
-
-	// Deploy extproc deployment with potential updates.
-	err = c.syncExtProcDeployment(ctx, aiGatewayRoute)
+	if err = c.reconcileCORSSecurityPolicy(ctx, aiGatewayRoute); err != nil {
+		return fmt.Errorf("failed to reconcile CORS security policy: %w", err)
+	}
+	if err = c.reconcileTrafficPolicy(ctx, aiGatewayRoute); err != nil {
+		return fmt.Errorf("failed to reconcile traffic policy: %w", err)
+	}
+	if err = c.reconcileExcludedPaths(ctx, aiGatewayRoute); err != nil {
+		return fmt.Errorf("failed to reconcile excluded paths: %w", err)
+	}
+
+	if extProcShared(aiGatewayRoute) {
+		// The dedicated external processor, including its HorizontalPodAutoscaler and PodDisruptionBudget, is deleted
+		// first so that nothing is left over when switching to the shared one fails below.
+		if err = c.deleteExtProcSidecar(ctx, aiGatewayRoute); err != nil {
+			return fmt.Errorf("failed to delete extproc sidecar: %w", err)
+		}
+		if err = c.deleteExtProcDeployment(ctx, aiGatewayRoute.Namespace, extProcName(aiGatewayRoute)); err != nil {
+			return err
+		}
+		name := extProcName(aiGatewayRoute)
+		if err = c.kube.CoreV1().ConfigMaps(aiGatewayRoute.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ConfigMap %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		if meta.RemoveStatusCondition(&aiGatewayRoute.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed) {
+			if err = c.client.Status().Update(ctx, aiGatewayRoute); err != nil {
+				return fmt.Errorf("failed to update AIGatewayRoute status: %w", err)
+			}
+		}
+		// The shared external processor is synced with all the AIGatewayRoutes using it by deleteOrphanedResources,
+		// but the configuration is built here as well to report the errors of this AIGatewayRoute.
+		return c.sharedExtProcError(ctx, aiGatewayRoute)
+	}
+
+	// Update the extproc configmap.
+	uuid, err := c.updateExtProcConfigMap(ctx, aiGatewayRoute)
+	if err != nil {
+		return fmt.Errorf("failed to update extproc configmap: %w", err)
+	}
+
+	if extProcSidecarMode(aiGatewayRoute) {
+		if err = c.syncExtProcSidecar(ctx, aiGatewayRoute); err != nil {
+			return withEventReason(EventReasonExtProcDeploymentFailed, fmt.Errorf("failed to sync extproc sidecar: %w", err))
+		}
+		// The pods are owned by Envoy Gateway, and the sidecar picks up the new config via the volume update.
+		return nil
+	}
+	if err = c.deleteExtProcSidecar(ctx, aiGatewayRoute); err != nil {
+		return fmt.Errorf("failed to delete extproc sidecar: %w", err)
+	}
+
+	// Deploy extproc deployment with potential updates.
+	err = c.syncExtProcDeployment(ctx, aiGatewayRoute)
+	if err != nil {
+		return withEventReason(EventReasonExtProcDeploymentFailed, fmt.Errorf("failed to sync extproc deployment: %w", err))
+	}
+
+	if err = c.syncExtProcHPA(ctx, aiGatewayRoute); err != nil {
+		return withEventReason(EventReasonExtProcDeploymentFailed, fmt.Errorf("failed to sync extproc horizontal pod autoscaler: %w", err))
+	}
+
+	if err = c.syncExtProcPDB(ctx, aiGatewayRoute); err != nil {
+		return withEventReason(EventReasonExtProcDeploymentFailed, fmt.Errorf("failed to sync extproc pod disruption budget: %w", err))
+	}
+
+	// Annotate the pods not annotated with the current config yet, which includes the ones left over when the
+	// previous reconciliation failed after updating the configmap.
+	err = c.annotateExtProcPods(ctx, aiGatewayRoute.Namespace, extProcName(aiGatewayRoute), uuid)
+	if err != nil {
+		return fmt.Errorf("failed to annotate extproc pods: %w", err)
+	}
+	return nil
+}
+
+// syncSharedExtProc syncs the external processor shared by the given AIGatewayRoutes in the namespace, or deletes it
+// when there is none.
+//
+// The configuration of the shared external processor is the merge of the filter configurations of the AIGatewayRoutes
+// in the order of their names, so that it is deterministic: The rules are concatenated in that order, the request
+// costs are merged by the metadata key, and the AIGatewayRoutes conflicting with the first one are skipped.
+func (c *AIGatewayRouteController) syncSharedExtProc(ctx context.Context, namespace string, routes []*aigv1a1.AIGatewayRoute) error {
+	if len(routes) == 0 {
+		if err := c.deleteExtProcDeployment(ctx, namespace, sharedExtProcName); err != nil {
+			return err
+		}
+		if err := c.kube.CoreV1().ConfigMaps(namespace).Delete(ctx, sharedExtProcName, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ConfigMap %s.%s: %w", sharedExtProcName, namespace, err)
+		}
+		return nil
+	}
+	ec, validRoutes, _ := c.newSharedFilterConfig(ctx, namespace, routes)
+	if len(validRoutes) == 0 {
+		return nil
+	}
+	sharedLabels := map[string]string{"app": sharedExtProcName, managedByLabel: managedByLabelValue}
+	secretsHash, err := c.extProcSecretsHash(ctx, namespace, validRoutes...)
+	if err != nil {
+		return err
+	}
+	configMap, err := c.kube.CoreV1().ConfigMaps(namespace).Get(ctx, sharedExtProcName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		var data string
+		if data, _, err = extProcConfigData(ec, "", true); err != nil {
+			return err
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: sharedExtProcName, Namespace: namespace, Labels: sharedLabels,
+				Annotations: map[string]string{extProcSecretsHashAnnotationKey: secretsHash},
+			},
+			Data: map[string]string{expProcConfigFileName: data},
+		}
+		if _, err = c.kube.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create configmap %s: %w", sharedExtProcName, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get configmap %s: %w", sharedExtProcName, err)
+	} else {
+		var data string
+		var changed bool
+		secretsRotated := configMap.Annotations[extProcSecretsHashAnnotationKey] != secretsHash
+		if data, changed, err = extProcConfigData(ec, configMap.Data[expProcConfigFileName], secretsRotated); err != nil {
+			return err
+		}
+		if changed {
+			if configMap.Data == nil {
+				configMap.Data = make(map[string]string)
+			}
+			configMap.Data[expProcConfigFileName] = data
+			if configMap.Annotations == nil {
+				configMap.Annotations = make(map[string]string)
+			}
+			configMap.Annotations[extProcSecretsHashAnnotationKey] = secretsHash
+			if _, err = c.kube.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update configmap %s: %w", sharedExtProcName, err)
+			}
+		}
+	}
+
+	if err = c.syncExtProcDeploymentOf(ctx, namespace, sharedExtProcName, sharedLabels, nil, validRoutes...); err != nil {
+		return fmt.Errorf("failed to sync shared extproc deployment: %w", err)
+	}
+	return c.annotateExtProcPods(ctx, namespace, sharedExtProcName, ec.UUID)
+}
+
+// sharedExtProcError returns the error why the AIGatewayRoute is skipped in the configuration of the shared external
+// processor, if any.
+func (c *AIGatewayRouteController) sharedExtProcError(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	var routes aigv1a1.AIGatewayRouteList
+	if err := c.client.List(ctx, &routes, client.InNamespace(aiGatewayRoute.Namespace)); err != nil {
+		return fmt.Errorf("failed to list AIGatewayRoutes: %w", err)
+	}
+	sharedRoutes := []*aigv1a1.AIGatewayRoute{aiGatewayRoute}
+	for i := range routes.Items {
+		if r := &routes.Items[i]; r.Name != aiGatewayRoute.Name && r.DeletionTimestamp.IsZero() && extProcShared(r) {
+			sharedRoutes = append(sharedRoutes, r)
+		}
+	}
+	_, _, skipped := c.newSharedFilterConfig(ctx, aiGatewayRoute.Namespace, sharedRoutes)
+	if err := skipped[aiGatewayRoute.Name]; err != nil {
+		return withEventReason(EventReasonExtProcDeploymentFailed, fmt.Errorf("AIGatewayRoute is not included in the shared extproc: %w", err))
+	}
+	return nil
+}
+
+// newSharedFilterConfig returns the merged filter configuration of the AIGatewayRoutes sharing the external processor
+// in the name order, the AIGatewayRoutes included in it, and the errors of the skipped ones by their names. The
+// AIGatewayRoutes whose configuration cannot be built or conflicts with the included ones are skipped, and their
+// errors are reported when they are reconciled.
+func (c *AIGatewayRouteController) newSharedFilterConfig(ctx context.Context, namespace string, routes []*aigv1a1.AIGatewayRoute) (
+	merged *filterapi.Config, included []*aigv1a1.AIGatewayRoute, skipped map[string]error,
+) {
+	routes = slices.SortedFunc(slices.Values(routes), func(a, b *aigv1a1.AIGatewayRoute) int { return cmp.Compare(a.Name, b.Name) })
+	skipped = make(map[string]error)
+	var ruleIndexOffset int
+	for _, route := range routes {
+		ec, err := newFilterConfig(ctx, c.client, route, "", ruleIndexOffset)
+		if err == nil && merged != nil {
+			err = sharedExtProcConflict(route, ec, included[0], merged)
+		}
+		if err != nil {
+			c.logger.Error(err, "skipping AIGatewayRoute in the shared extproc config", "namespace", namespace, "name", route.Name)
+			skipped[route.Name] = err
+			continue
+		}
+		ruleIndexOffset += len(route.Spec.Rules)
+		included = append(included, route)
+		if merged == nil {
+			merged = ec
+			merged.RouteName = fmt.Sprintf("%s/%s", namespace, sharedExtProcName)
+			continue
+		}
+		merged.Rules = append(merged.Rules, ec.Rules...)
+		for _, cost := range ec.LLMRequestCosts {
+			if !slices.ContainsFunc(merged.LLMRequestCosts, func(c filterapi.LLMRequestCost) bool {
+				return c.MetadataKey == cost.MetadataKey
+			}) {
+				merged.LLMRequestCosts = append(merged.LLMRequestCosts, cost)
+			}
+		}
+	}
+	// The extproc cannot tell which HTTPRoute the request matching no rule is routed by, so it rejects such requests
+	// instead of selecting the default backend of one of the AIGatewayRoutes.
+	if len(included) > 1 {
+		merged.Rules = slices.DeleteFunc(merged.Rules, func(r filterapi.RouteRule) bool { return r.Default })
+	}
+	return merged, included, skipped
+}
+
+// sharedExtProcConflict returns the error if the settings of the AIGatewayRoute that apply to the whole shared
+// external processor differ from the ones of the first AIGatewayRoute included in it, since they cannot be merged
+// and silently using the first ones would ignore the others.
+func sharedExtProcConflict(route *aigv1a1.AIGatewayRoute, ec *filterapi.Config, first *aigv1a1.AIGatewayRoute, merged *filterapi.Config) error {
+	if ec.ModelNameHeaderKey != merged.ModelNameHeaderKey || ec.MetadataNamespace != merged.MetadataNamespace {
+		return fmt.Errorf("modelHeaderName %q and metadataNamespace %q differ from %q and %q of AIGatewayRoute %s sharing the extproc",
+			ec.ModelNameHeaderKey, ec.MetadataNamespace, merged.ModelNameHeaderKey, merged.MetadataNamespace, first.Name)
+	}
+	// The model policy applies to all the requests processed by the extproc.
+	if !equality.Semantic.DeepEqual(ec.ModelPolicy, merged.ModelPolicy) {
+		return fmt.Errorf("modelPolicy differs from the one of AIGatewayRoute %s sharing the extproc", first.Name)
+	}
+	// The state store is a single connection of the extproc.
+	if !equality.Semantic.DeepEqual(route.Spec.StateStore, first.Spec.StateStore) {
+		return fmt.Errorf("stateStore differs from the one of AIGatewayRoute %s sharing the extproc", first.Name)
+	}
+	// The Deployment configuration such as the resources and the replicas applies to the single shared Deployment.
+	if !equality.Semantic.DeepEqual(extProcConfig(route), extProcConfig(first)) {
+		return fmt.Errorf("filterConfig.externalProcessor differs from the one of AIGatewayRoute %s sharing the extproc", first.Name)
+	}
+	return nil
+}
+
+// extProcConfig returns the external processor configuration of the AIGatewayRoute, or nil if not set.
+func extProcConfig(route *aigv1a1.AIGatewayRoute) *aigv1a1.AIGatewayFilterConfigExternalProcessor {
+	if fc := route.Spec.FilterConfig; fc != nil {
+		return fc.ExternalProcessor
+	}
+	return nil
+}
+
+// updateExtProcConfigMap updates the external processor configmap with the new AIGatewayRoute, and returns the UUID
+// of the stored configuration. The configmap is left as-is when the configuration is the same as the stored one
+// except for the UUID, and none of the mounted secrets has been rotated.
+func (c *AIGatewayRouteController) updateExtProcConfigMap(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) (uuid string, err error) {
+	configMap, err := c.kube.CoreV1().ConfigMaps(aiGatewayRoute.Namespace).Get(ctx, extProcName(aiGatewayRoute), metav1.GetOptions{})
+	if err != nil {
+		// This is a bug since we should have created the configmap before sending the AIGatewayRoute to the configSink.
+		panic(fmt.Errorf("failed to get configmap %s: %w", extProcName(aiGatewayRoute), err))
+	}
+
+	ec, err := NewFilterConfig(ctx, c.client, aiGatewayRoute, "")
+	if err != nil {
+		return "", err
+	}
+	secretsHash, err := c.extProcSecretsHash(ctx, aiGatewayRoute.Namespace, aiGatewayRoute)
+	if err != nil {
+		return "", err
+	}
+	secretsRotated := configMap.Annotations[extProcSecretsHashAnnotationKey] != secretsHash
+	data, changed, err := extProcConfigData(ec, configMap.Data[expProcConfigFileName], secretsRotated)
+	if err != nil || !changed {
+		return ec.UUID, err
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[expProcConfigFileName] = data
+	if configMap.Annotations == nil {
+		configMap.Annotations = make(map[string]string)
+	}
+	configMap.Annotations[extProcSecretsHashAnnotationKey] = secretsHash
+	if _, err := c.kube.CoreV1().ConfigMaps(aiGatewayRoute.Namespace).Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to update configmap %s: %w", configMap.Name, err)
+	}
+	return ec.UUID, nil
+}
+
+// extProcSecretsHash returns the hash of the names and the resource versions of the secrets mounted in the external
+// processor of the AIGatewayRoutes. The credentials are read from the mounted files when the configuration is loaded,
+// so the configuration has to be reloaded when any of them changes. The secrets that do not exist yet are hashed
+// with the empty version so that their creation also reloads the configuration.
+func (c *AIGatewayRouteController) extProcSecretsHash(ctx context.Context, namespace string, aiGatewayRoutes ...*aigv1a1.AIGatewayRoute) (string, error) {
+	spec := &corev1.PodSpec{
+		Volumes:    make([]corev1.Volume, 1),
+		Containers: []corev1.Container{{VolumeMounts: make([]corev1.VolumeMount, 1)}},
+	}
+	spec, err := c.mountBackendSecurityPolicySecrets(ctx, spec, aiGatewayRoutes...)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, volume := range spec.Volumes[1:] {
+		name := volume.Secret.SecretName
+		var secret corev1.Secret
+		if err = c.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &secret); client.IgnoreNotFound(err) != nil {
+			return "", fmt.Errorf("failed to get secret %s: %w", name, err)
+		}
+		_, _ = fmt.Fprintf(h, "%s/%s\n", name, secret.ResourceVersion)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// extProcConfigData returns the marshaled filter configuration to store in place of the stored one. The UUID of the
+// stored configuration is reused when nothing else differs and reload is false so that the no-op reconciliations
+// neither reload the configuration in the external processor nor annotate the pods. Otherwise, a new UUID is set to
+// the configuration, and changed is true.
+func extProcConfigData(ec *filterapi.Config, stored string, reload bool) (data string, changed bool, err error) {
+	var storedConfig filterapi.Config
+	if !reload && stored != "" && yaml.Unmarshal([]byte(stored), &storedConfig) == nil && storedConfig.UUID != "" {
+		ec.UUID = storedConfig.UUID
+		marshaled, err := yaml.Marshal(ec)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to marshal extproc config: %w", err)
+		}
+		if string(marshaled) == stored {
+			return stored, false, nil
+		}
+	}
+	ec.UUID = string(uuid2.NewUUID())
+	marshaled, err := yaml.Marshal(ec)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal extproc config: %w", err)
+	}
+	return string(marshaled), true, nil
+}
+
+// NewFilterConfig builds the external processor filter configuration for the AIGatewayRoute. The referenced
+// AIServiceBackends and BackendSecurityPolicies are read from the given reader, which does not have to be backed
+// by a live cluster. The credentials are not read: the configuration only refers to the file paths where the
+// secrets are mounted in the external processor.
+func NewFilterConfig(ctx context.Context, r client.Reader, aiGatewayRoute *aigv1a1.AIGatewayRoute, uuid string) (*filterapi.Config, error) {
+	return newFilterConfig(ctx, r, aiGatewayRoute, uuid, 0)
+}
+
+// modelHeaderName returns the name of the header to which the model name is set for the AIGatewayRoute.
+func modelHeaderName(aiGatewayRoute *aigv1a1.AIGatewayRoute) string {
+	// Envoy passes the request header names to the external processor in lower case.
+	return cmp.Or(strings.ToLower(aiGatewayRoute.Spec.ModelHeaderName), aigv1a1.AIModelHeaderKey)
+}
+
+// metadataNamespace returns the namespace of the dynamic metadata written by the AI Gateway filter for the
+// AIGatewayRoute.
+func metadataNamespace(aiGatewayRoute *aigv1a1.AIGatewayRoute) string {
+	return cmp.Or(aiGatewayRoute.Spec.MetadataNamespace, aigv1a1.AIGatewayFilterMetadataNamespace)
+}
+
+// newFilterConfig implements [NewFilterConfig]. The ruleIndexOffset is added to the index of the rules to derive
+// the paths of the backend security policy secrets, so that the rules of the AIGatewayRoutes sharing the external
+// processor do not collide. See mountBackendSecurityPolicySecrets.
+func newFilterConfig(ctx context.Context, r client.Reader, aiGatewayRoute *aigv1a1.AIGatewayRoute, uuid string, ruleIndexOffset int) (*filterapi.Config, error) {
+	var err error
+	ec := &filterapi.Config{UUID: uuid, RouteName: fmt.Sprintf("%s/%s", aiGatewayRoute.Namespace, aiGatewayRoute.Name)}
+	spec := &aiGatewayRoute.Spec
+
+	ec.Schema.Name = filterapi.APISchemaName(spec.APISchema.Name)
+	ec.Schema.Version = spec.APISchema.Version
+	ec.ModelNameHeaderKey = modelHeaderName(aiGatewayRoute)
+	ec.SelectedBackendHeaderKey = selectedBackendHeaderKey
+	ec.Rules = make([]filterapi.RouteRule, len(spec.Rules))
+	// The rules are placed in the order of the precedence while i is the index in the spec, which derives the paths of
+	// the secrets and is reported in the errors.
+	for k, i := range ruleOrder(spec.Rules) {
+		rule := &spec.Rules[i]
+		dst := &ec.Rules[k]
+		dst.Priority = int(ptr.Deref(rule.Priority, 0))
+		dst.Backends = make([]filterapi.Backend, len(rule.BackendRefs))
+		for j := range rule.BackendRefs {
+			backend := &rule.BackendRefs[j]
+			b := &dst.Backends[j]
+			if err = newFilterBackend(ctx, r, aiGatewayRoute.Namespace, backend.Name, ruleIndexOffset+i, j, b); err != nil {
+				return nil, err
+			}
+			b.Weight = int(ptr.Deref(backend.Weight, 1))
+		}
+		dst.HeaderModifications = newHeaderModifications(rule.HeaderModifications)
+		if sa := rule.SessionAffinity; sa != nil {
+			// Envoy passes the request header names to the external processor in lower case.
+			dst.SessionAffinity = &filterapi.SessionAffinity{HeaderName: strings.ToLower(sa.Header)}
+		}
+		if md := rule.ModelDefaults; md != nil {
+			defaults := &filterapi.ModelDefaults{MaxTokens: md.MaxTokens}
+			if defaults.Temperature, err = parseOptionalFloat(md.Temperature); err != nil {
+				return nil, fmt.Errorf("invalid modelDefaults.temperature of rule %d: %w", i, err)
+			}
+			if defaults.TopP, err = parseOptionalFloat(md.TopP); err != nil {
+				return nil, fmt.Errorf("invalid modelDefaults.topP of rule %d: %w", i, err)
+			}
+			dst.ModelDefaults = defaults
+		}
+		if ml := rule.ModelLimits; ml != nil {
+			limits := &filterapi.ModelLimits{MaxTokens: ml.MaxTokens, Strict: ml.Strict}
+			if ml.MaxStopSequences != nil {
+				limits.MaxStopSequences = ptr.To(int(*ml.MaxStopSequences))
+			}
+			if limits.MaxTemperature, err = parseOptionalFloat(ml.MaxTemperature); err != nil {
+				return nil, fmt.Errorf("invalid modelLimits.maxTemperature of rule %d: %w", i, err)
+			}
+			if limits.MaxTopP, err = parseOptionalFloat(ml.MaxTopP); err != nil {
+				return nil, fmt.Errorf("invalid modelLimits.maxTopP of rule %d: %w", i, err)
+			}
+			dst.ModelLimits = limits
+		}
+		dst.Headers = make([]filterapi.HeaderMatch, len(rule.Matches))
+		for j, match := range rule.Matches {
+			dst.Headers[j].Name = match.Headers[0].Name
+			if strings.EqualFold(string(match.Headers[0].Name), aigv1a1.AIModelHeaderKey) {
+				dst.Headers[j].Name = gwapiv1.HTTPHeaderName(ec.ModelNameHeaderKey)
+			}
+			dst.Headers[j].Value = match.Headers[0].Value
+			dst.Headers[j].Type = (*gwapiv1.HeaderMatchType)(match.Headers[0].Type)
+		}
+	}
+
+	if !spec.DisableDefaultRoute {
+		if err = appendDefaultFilterRule(ctx, r, aiGatewayRoute, ruleIndexOffset, ec); err != nil {
+			return nil, err
+		}
+	}
+
+	ec.MetadataNamespace = metadataNamespace(aiGatewayRoute)
+	for _, cost := range aiGatewayRoute.Spec.LLMRequestCosts {
+		fc := filterapi.LLMRequestCost{MetadataKey: cost.MetadataKey}
+		switch cost.Type {
+		case aigv1a1.LLMRequestCostTypeInputToken:
+			fc.Type = filterapi.LLMRequestCostTypeInputToken
+		case aigv1a1.LLMRequestCostTypeOutputToken:
+			fc.Type = filterapi.LLMRequestCostTypeOutputToken
+		case aigv1a1.LLMRequestCostTypeTotalToken:
+			fc.Type = filterapi.LLMRequestCostTypeTotalToken
+		case aigv1a1.LLMRequestCostTypeCEL:
+			fc.Type = filterapi.LLMRequestCostTypeCEL
+			expr := *cost.CEL
+			// Sanity check the CEL expression.
+			_, err = llmcostcel.NewProgram(expr)
+			if err != nil {
+				return nil, withEventReason(EventReasonInvalidCELExpression, fmt.Errorf("invalid CEL expression: %w", err))
+			}
+			fc.CEL = expr
+		case aigv1a1.LLMRequestCostTypeModelPriceTable:
+			fc.Type = filterapi.LLMRequestCostTypeModelPriceTable
+			if cost.ModelPriceTable == nil {
+				return nil, fmt.Errorf("modelPriceTable is not set for request cost %s", cost.MetadataKey)
+			}
+			if fc.ModelPriceTable, err = newModelPriceTable(cost.ModelPriceTable); err != nil {
+				return nil, fmt.Errorf("invalid modelPriceTable of request cost %s: %w", cost.MetadataKey, err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown request cost type: %s", cost.Type)
+		}
+		ec.LLMRequestCosts = append(ec.LLMRequestCosts, fc)
+	}
+	ec.EmitCostHeaders = aiGatewayRoute.Spec.EmitCostHeaders
+	ec.EmitLatencyHeaders = aiGatewayRoute.Spec.EmitLatencyHeaders
+	ec.EstimateInputTokens = aiGatewayRoute.Spec.EstimateInputTokens
+	ec.DefaultRouteDisabled = aiGatewayRoute.Spec.DisableDefaultRoute
+	ec.PathPrefix = aiGatewayRoute.Spec.PathPrefix
+	ec.RequestIDPropagationDisabled = aiGatewayRoute.Spec.DisableRequestIDPropagation
+	ec.EmitConfigVersionHeader = aiGatewayRoute.Spec.EmitConfigVersionHeader
+	for _, endpoint := range aiGatewayRoute.Spec.AllowedEndpoints {
+		path, ok := aiGatewayRouteEndpointPaths[endpoint]
+		if !ok {
+			return nil, fmt.Errorf("unknown endpoint: %s", endpoint)
+		}
+		ec.AllowedPaths = append(ec.AllowedPaths, path)
+	}
+	if mp := aiGatewayRoute.Spec.ModelPolicy; mp != nil {
+		ec.ModelPolicy = &filterapi.ModelPolicy{Allow: mp.Allow, Deny: mp.Deny}
+	}
+	if ss := aiGatewayRoute.Spec.StateStore; ss != nil {
+		ec.StateStore = &filterapi.StateStore{
+			Type:      filterapi.StateStoreType(ss.Type),
+			Address:   ss.Address,
+			TLS:       ss.TLS,
+			KeyPrefix: cmp.Or(ss.KeyPrefix, fmt.Sprintf("%s/%s:", aiGatewayRoute.Namespace, aiGatewayRoute.Name)),
+		}
+		if ss.PasswordSecretRef != nil {
+			ec.StateStore.PasswordFile = stateStorePasswordFile
+		}
+	}
+	return ec, nil
+}
+
+// aiGatewayRouteEndpointPaths maps the endpoints of the AIGatewayRoute to the paths of the external processor.
+var aiGatewayRouteEndpointPaths = map[aigv1a1.AIGatewayRouteEndpoint]string{
+	aigv1a1.AIGatewayRouteEndpointChatCompletions: "/v1/chat/completions",
+	aigv1a1.AIGatewayRouteEndpointCompletions:     "/v1/completions",
+	aigv1a1.AIGatewayRouteEndpointEmbeddings:      "/v1/embeddings",
+	aigv1a1.AIGatewayRouteEndpointModels:          "/v1/models",
+	aigv1a1.AIGatewayRouteEndpointConverse:        "/model/{modelId}/converse",
+}
+
+// appendDefaultFilterRule appends the default rule selected by the external processor when no rule matches the
+// request. The rule has the same backend and weight as the catch-all rule of the HTTPRoute, so that the requests
+// routed by the catch-all rule are authenticated and translated for the backend. See newHTTPRoute.
+func appendDefaultFilterRule(ctx context.Context, r client.Reader, aiGatewayRoute *aigv1a1.AIGatewayRoute, ruleIndexOffset int, ec *filterapi.Config) error {
+	name := defaultBackendName(aiGatewayRoute)
+	if name == "" {
+		// The HTTPRoute has no catch-all rule without any backend.
+		return nil
+	}
+	// The backend takes the indexes of its first reference for the secret volume name, which is mounted already.
+	ruleIndex, backendIndex, weight := -1, -1, int32(0)
+	for i := range aiGatewayRoute.Spec.Rules {
+		for j, br := range aiGatewayRoute.Spec.Rules[i].BackendRefs {
+			if br.Name != name {
+				continue
+			}
+			if ruleIndex < 0 {
+				ruleIndex, backendIndex = i, j
+			}
+			weight = max(weight, ptr.Deref(br.Weight, 1))
+		}
+	}
+	if ruleIndex < 0 {
+		return fmt.Errorf("default backend %s is not referenced by any rule", name)
+	}
+	rule := filterapi.RouteRule{Headers: []filterapi.HeaderMatch{}, Backends: make([]filterapi.Backend, 1), Default: true}
+	if err := newFilterBackend(ctx, r, aiGatewayRoute.Namespace, name, ruleIndexOffset+ruleIndex, backendIndex, &rule.Backends[0]); err != nil {
+		return err
+	}
+	rule.Backends[0].Weight = int(weight)
+	ec.Rules = append(ec.Rules, rule)
+	return nil
+}
+
+// newFilterBackend reads the AIServiceBackend of the name and its BackendSecurityPolicy from the reader, and fills in
+// the dst with the schema, guardrail, OpenAI and AWS Bedrock configurations and auth of the backend. The ruleIndex
+// and backendIndex determine the secret volume name mounted on the external processor.
+func newFilterBackend(ctx context.Context, r client.Reader, namespace, name string, ruleIndex, backendIndex int, dst *filterapi.Backend) error {
+	key := fmt.Sprintf("%s.%s", name, namespace)
+	dst.Name = key
+	backendObj := &aigv1a1.AIServiceBackend{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, backendObj); err != nil {
+		err = fmt.Errorf("failed to get AIServiceBackend %s: %w", key, err)
+		if apierrors.IsNotFound(err) {
+			err = withEventReason(EventReasonBackendNotFound, err)
+		}
+		return err
+	}
+	dst.Schema.Name = filterapi.APISchemaName(backendObj.Spec.APISchema.Name)
+	dst.Schema.Version = backendObj.Spec.APISchema.Version
+	if gc := backendObj.Spec.GuardrailConfig; gc != nil {
+		dst.GuardrailConfig = &filterapi.GuardrailConfig{
+			Identifier: gc.Identifier,
+			Version:    gc.Version,
+			Trace:      ptr.Deref(gc.Trace, ""),
+		}
+	}
+	if oc := backendObj.Spec.OpenAI; oc != nil {
+		dst.OpenAI = &filterapi.OpenAIConfig{Organization: oc.Organization, Project: oc.Project}
+	}
+	if bc := backendObj.Spec.AWSBedrock; bc != nil {
+		dst.AWSBedrock = &filterapi.AWSBedrockConfig{
+			BedrockAPI: filterapi.AWSBedrockAPI(bc.BedrockAPI), ModelPrefix: bc.ModelPrefix,
+		}
+		for _, f := range bc.ModelFamilies {
+			dst.AWSBedrock.ModelFamilies = append(dst.AWSBedrock.ModelFamilies, filterapi.AWSBedrockModelFamily{
+				ModelPrefix: f.ModelPrefix, NoSystemMessages: f.NoSystemMessages,
+			})
+		}
+	}
+	dst.UnsupportedFieldPolicy = filterapi.UnsupportedFieldPolicy(backendObj.Spec.UnsupportedFieldPolicy)
+	if pn := backendObj.Spec.ParameterNormalization; pn != nil {
+		normalization := &filterapi.ParameterNormalization{Mode: filterapi.ParameterNormalizationMode(pn.Mode), Debug: pn.Debug}
+		var err error
+		if normalization.TemperatureScale, err = parseOptionalFloat(pn.TemperatureScale); err != nil {
+			return fmt.Errorf("invalid parameterNormalization.temperatureScale of AIServiceBackend %s: %w", key, err)
+		}
+		if normalization.TopPScale, err = parseOptionalFloat(pn.TopPScale); err != nil {
+			return fmt.Errorf("invalid parameterNormalization.topPScale of AIServiceBackend %s: %w", key, err)
+		}
+		dst.ParameterNormalization = normalization
+	}
+	dst.HeaderModifications = newHeaderModifications(backendObj.Spec.HeaderModifications)
+	dst.PathOverride = backendObj.Spec.PathOverride
+
+	if bspRef := backendObj.Spec.BackendSecurityPolicyRef; bspRef != nil {
+		volumeName := backendSecurityPolicyVolumeName(
+			ruleIndex, backendIndex, string(backendObj.Spec.BackendSecurityPolicyRef.Name),
+		)
+		backendSecurityPolicy := &aigv1a1.BackendSecurityPolicy{}
+		if err := r.Get(ctx, client.ObjectKey{Name: string(bspRef.Name), Namespace: namespace}, backendSecurityPolicy); err != nil {
+			return fmt.Errorf("failed to get BackendSecurityPolicy %s: %w", bspRef.Name, err)
+		}
+		// The secret not permitted is not mounted, hence the backend is left without the auth.
+		if granted, err := backendSecurityPolicySecretsGranted(ctx, r, backendSecurityPolicy); err != nil {
+			return err
+		} else if !granted {
+			return nil
+		}
+
+		switch backendSecurityPolicy.Spec.Type {
+		case aigv1a1.BackendSecurityPolicyTypeAPIKey:
+			dst.Auth = &filterapi.BackendAuth{
+				APIKey: newAPIKeyAuth(backendSecurityPolicy.Spec.APIKey, backendSecurityMountPath(volumeName)),
+			}
+		case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
+			if backendSecurityPolicy.Spec.AWSCredentials == nil {
+				return fmt.Errorf("AWSCredentials type selected but not defined %s", backendSecurityPolicy.Name)
+			}
+			if awsCred := backendSecurityPolicy.Spec.AWSCredentials; awsCred.CredentialsFile != nil || awsCred.OIDCExchangeToken != nil {
+				dst.Auth = &filterapi.BackendAuth{
+					AWSAuth: &filterapi.AWSAuth{
+						CredentialFileName: path.Join(backendSecurityMountPath(volumeName), "/credentials"),
+						Region:             backendSecurityPolicy.Spec.AWSCredentials.Region,
+					},
+				}
+			}
+		case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
+			if backendSecurityPolicy.Spec.AzureCredentials == nil {
+				return fmt.Errorf("AzureCredentials type selected but not defined %s", backendSecurityPolicy.Name)
+			}
+			dst.Auth = &filterapi.BackendAuth{
+				AzureAuth: &filterapi.AzureAuth{
+					Filename: path.Join(backendSecurityMountPath(volumeName), "/"+rotators.AzureAccessTokenKey),
+				},
+			}
+		default:
+			return fmt.Errorf("invalid backend security type %s for policy %s", backendSecurityPolicy.Spec.Type,
+				backendSecurityPolicy.Name)
+		}
+	}
+	return nil
+}
+
+// newAPIKeyAuth returns the API key auth reading the secret referenced by the API key spec mounted at mountPath.
+// The secret is not read here: when the API keys are rotated, the external processor discovers the API keys
+// "apiKey-0", "apiKey-1", ... in the mounted directory.
+func newAPIKeyAuth(spec *aigv1a1.BackendSecurityPolicyAPIKey, mountPath string) *filterapi.APIKeyAuth {
+	apiKey := &filterapi.APIKeyAuth{Filename: path.Join(mountPath, "/apiKey")}
+	if spec == nil {
+		return apiKey
+	}
+	if spec.Keys != nil {
+		apiKey = &filterapi.APIKeyAuth{Directory: mountPath}
+		for _, w := range spec.Keys.Weights {
+			apiKey.Weights = append(apiKey.Weights, int(w))
+		}
+	}
+	apiKey.HeaderName = ptr.Deref(spec.HeaderName, "")
+	apiKey.ValueTemplate = ptr.Deref(spec.ValueTemplate, "")
+	return apiKey
+}
+
+// newHeaderModifications converts the header modifications of the AIGatewayRoute or AIServiceBackend to the filter
+// configuration. This returns nil when f is nil.
+func newHeaderModifications(f *gwapiv1.HTTPHeaderFilter) *filterapi.HeaderModifications {
+	if f == nil {
+		return nil
+	}
+	// Envoy passes the request header names to the external processor in lower case.
+	toHeaders := func(headers []gwapiv1.HTTPHeader) []filterapi.Header {
+		var ret []filterapi.Header
+		for _, h := range headers {
+			ret = append(ret, filterapi.Header{Name: strings.ToLower(string(h.Name)), Value: h.Value})
+		}
+		return ret
+	}
+	hm := &filterapi.HeaderModifications{Add: toHeaders(f.Add), Set: toHeaders(f.Set)}
+	for _, name := range f.Remove {
+		hm.Remove = append(hm.Remove, strings.ToLower(name))
+	}
+	return hm
+}
+
+// newModelPriceTable converts the model price table of the AIGatewayRoute to the filter configuration.
+func newModelPriceTable(t *aigv1a1.LLMRequestCostModelPriceTable) (*filterapi.ModelPriceTable, error) {
+	table := &filterapi.ModelPriceTable{
+		Prices:     make(map[string]filterapi.ModelPrice, len(t.Prices)),
+		Strict:     t.Strict,
+		Multiplier: ptr.Deref(t.Multiplier, 1),
+	}
+	for model, price := range t.Prices {
+		p, err := newModelPrice(&price)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price of model %s: %w", model, err)
+		}
+		table.Prices[model] = *p
+	}
+	if t.Default != nil {
+		p, err := newModelPrice(t.Default)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default price: %w", err)
+		}
+		table.Default = p
+	}
+	return table, nil
+}
+
+func newModelPrice(p *aigv1a1.LLMRequestCostModelPrice) (*filterapi.ModelPrice, error) {
+	input, err := strconv.ParseFloat(p.InputTokenPrice, 64)
 	if err != nil {
-		return fmt.Errorf("failed to sync extproc deployment: %w", err)
+		return nil, fmt.Errorf("invalid inputTokenPrice: %w", err)
 	}
-
-	// Annotate all pods with the new config.
-	err = c.annotateExtProcPods(ctx, aiGatewayRoute, uuid)
+	output, err := strconv.ParseFloat(p.OutputTokenPrice, 64)
 	if err != nil {
-		return fmt.Errorf("failed to annotate extproc pods: %w", err)
+		return nil, fmt.Errorf("invalid outputTokenPrice: %w", err)
 	}
-	return nil
+	return &filterapi.ModelPrice{InputTokenPrice: input, OutputTokenPrice: output}, nil
 }
 
-// updateExtProcConfigMap updates the external processor configmap with the new AIGatewayRoute.
-func (c *AIGatewayRouteController) updateExtProcConfigMap(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute, uuid string) error {
-	configMap, err := c.kube.CoreV1().ConfigMaps(aiGatewayRoute.Namespace).Get(ctx, extProcName(aiGatewayRoute), metav1.GetOptions{})
-	if err != nil {
-		// This is a bug since we should have created the configmap before sending the AIGatewayRoute to the configSink.
-		panic(fmt.Errorf("failed to get configmap %s: %w", extProcName(aiGatewayRoute), err))
+// backendRewriteFilters returns the host rewrite filters to attach to the rule of the given backend. Since each
+// generated rule routes to exactly one backend, the filters never need to differ within a rule.
+func backendRewriteFilters(b *aigv1a1.AIServiceBackend, rewriteFilters []gwapiv1.HTTPRouteFilter) []gwapiv1.HTTPRouteFilter {
+	if b.Spec.HostnameRewrite == aigv1a1.HostnameRewriteDisabled {
+		return nil
 	}
+	return rewriteFilters
+}
 
-	ec := &filterapi.Config{UUID: uuid}
-	spec := &aiGatewayRoute.Spec
-
-	ec.Schema.Name = filterapi.APISchemaName(spec.APISchema.Name)
-	ec.Schema.Version = spec.APISchema.Version
-	ec.ModelNameHeaderKey = aigv1a1.AIModelHeaderKey
-	ec.SelectedBackendHeaderKey = selectedBackendHeaderKey
-	ec.Rules = make([]filterapi.RouteRule, len(spec.Rules))
-	for i := range spec.Rules {
-		rule := &spec.Rules[i]
-		ec.Rules[i].Backends = make([]filterapi.Backend, len(rule.BackendRefs))
-		for j := range rule.BackendRefs {
-			backend := &rule.BackendRefs[j]
-			key := fmt.Sprintf("%s.%s", backend.Name, aiGatewayRoute.Namespace)
-			ec.Rules[i].Backends[j].Name = key
-			ec.Rules[i].Backends[j].Weight = backend.Weight
-			var backendObj *aigv1a1.AIServiceBackend
-			backendObj, err = c.backend(ctx, aiGatewayRoute.Namespace, backend.Name)
-			if err != nil {
-				return fmt.Errorf("failed to get AIServiceBackend %s: %w", key, err)
-			}
-			ec.Rules[i].Backends[j].Schema.Name = filterapi.APISchemaName(backendObj.Spec.APISchema.Name)
-			ec.Rules[i].Backends[j].Schema.Version = backendObj.Spec.APISchema.Version
-
-			if bspRef := backendObj.Spec.BackendSecurityPolicyRef; bspRef != nil {
-				volumeName := backendSecurityPolicyVolumeName(
-					i, j, string(backendObj.Spec.BackendSecurityPolicyRef.Name),
-				)
-				var backendSecurityPolicy *aigv1a1.BackendSecurityPolicy
-				backendSecurityPolicy, err = c.backendSecurityPolicy(ctx, aiGatewayRoute.Namespace, string(bspRef.Name))
-				if err != nil {
-					return fmt.Errorf("failed to get BackendSecurityPolicy %s: %w", bspRef.Name, err)
-				}
-
-				switch backendSecurityPolicy.Spec.Type {
-				case aigv1a1.BackendSecurityPolicyTypeAPIKey:
-					ec.Rules[i].Backends[j].Auth = &filterapi.BackendAuth{
-						APIKey: &filterapi.APIKeyAuth{Filename: path.Join(backendSecurityMountPath(volumeName), "/apiKey")},
-					}
-				case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
-					if backendSecurityPolicy.Spec.AWSCredentials == nil {
-						return fmt.Errorf("AWSCredentials type selected but not defined %s", backendSecurityPolicy.Name)
-					}
-					if awsCred := backendSecurityPolicy.Spec.AWSCredentials; awsCred.CredentialsFile != nil || awsCred.OIDCExchangeToken != nil {
-						ec.Rules[i].Backends[j].Auth = &filterapi.BackendAuth{
-							AWSAuth: &filterapi.AWSAuth{
-								CredentialFileName: path.Join(backendSecurityMountPath(volumeName), "/credentials"),
-								Region:             backendSecurityPolicy.Spec.AWSCredentials.Region,
-							},
-						}
-					}
-				default:
-					return fmt.Errorf("invalid backend security type %s for policy %s", backendSecurityPolicy.Spec.Type,
-						backendSecurityPolicy.Name)
-				}
-			}
-		}
-		ec.Rules[i].Headers = make([]filterapi.HeaderMatch, len(rule.Matches))
-		for j, match := range rule.Matches {
-			ec.Rules[i].Headers[j].Name = match.Headers[0].Name
-			ec.Rules[i].Headers[j].Value = match.Headers[0].Value
-		}
+// ruleOrder returns the indexes of the rules in the order of the precedence, that is, the descending order of
+// the priority. The rules with the same priority keep the order of the list.
+func ruleOrder(rules []aigv1a1.AIGatewayRouteRule) []int {
+	order := make([]int, len(rules))
+	for i := range order {
+		order[i] = i
 	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(ptr.Deref(rules[b].Priority, 0), ptr.Deref(rules[a].Priority, 0))
+	})
+	return order
+}
 
-	ec.MetadataNamespace = aigv1a1.AIGatewayFilterMetadataNamespace
-	for _, cost := range aiGatewayRoute.Spec.LLMRequestCosts {
-		fc := filterapi.LLMRequestCost{MetadataKey: cost.MetadataKey}
-		switch cost.Type {
-		case aigv1a1.LLMRequestCostTypeInputToken:
-			fc.Type = filterapi.LLMRequestCostTypeInputToken
-		case aigv1a1.LLMRequestCostTypeOutputToken:
-			fc.Type = filterapi.LLMRequestCostTypeOutputToken
-		case aigv1a1.LLMRequestCostTypeTotalToken:
-			fc.Type = filterapi.LLMRequestCostTypeTotalToken
-		case aigv1a1.LLMRequestCostTypeCEL:
-			fc.Type = filterapi.LLMRequestCostTypeCEL
-			expr := *cost.CEL
-			// Sanity check the CEL expression.
-			_, err = llmcostcel.NewProgram(expr)
-			if err != nil {
-				return fmt.Errorf("invalid CEL expression: %w", err)
+// defaultBackendName returns the name of the AIServiceBackend the catch-all rule routes to, which is DefaultBackend
+// if set, or the first backend with a positive weight in the order of the rules' precedence. When all the backends have zero weight, this returns the first
+// one, or empty if the route has no backend.
+func defaultBackendName(aiGatewayRoute *aigv1a1.AIGatewayRoute) string {
+	if aiGatewayRoute.Spec.DefaultBackend != "" {
+		return aiGatewayRoute.Spec.DefaultBackend
+	}
+	var first string
+	for _, i := range ruleOrder(aiGatewayRoute.Spec.Rules) {
+		for _, br := range aiGatewayRoute.Spec.Rules[i].BackendRefs {
+			if ptr.Deref(br.Weight, 1) > 0 {
+				return br.Name
+			}
+			if first == "" {
+				first = br.Name
 			}
-			fc.CEL = expr
-		default:
-			return fmt.Errorf("unknown request cost type: %s", cost.Type)
 		}
-		ec.LLMRequestCosts = append(ec.LLMRequestCosts, fc)
 	}
-
-	marshaled, err := yaml.Marshal(ec)
-	if err != nil {
-		return fmt.Errorf("failed to marshal extproc config: %w", err)
-	}
-	if configMap.Data == nil {
-		configMap.Data = make(map[string]string)
-	}
-	configMap.Data[expProcConfigFileName] = string(marshaled)
-	if _, err := c.kube.CoreV1().ConfigMaps(aiGatewayRoute.Namespace).Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
-		return fmt.Errorf("failed to update configmap %s: %w", configMap.Name, err)
-	}
-	return nil
+	return first
 }
 
 // newHTTPRoute updates the HTTPRoute with the new AIGatewayRoute.
 func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv1.HTTPRoute, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
 	var backends []*aigv1a1.AIServiceBackend
-	dedup := make(map[string]struct{})
-	for _, rule := range aiGatewayRoute.Spec.Rules {
-		for _, br := range rule.BackendRefs {
+	// weights is the largest weight of each backend among the rules referencing it, so that the backend
+	// with zero weight in all the rules never receives the traffic from Envoy either.
+	weights := make(map[string]int32)
+	for _, i := range ruleOrder(aiGatewayRoute.Spec.Rules) {
+		for _, br := range aiGatewayRoute.Spec.Rules[i].BackendRefs {
 			key := fmt.Sprintf("%s.%s", br.Name, aiGatewayRoute.Namespace)
-			if _, ok := dedup[key]; ok {
+			weight := ptr.Deref(br.Weight, 1)
+			if w, ok := weights[key]; ok {
+				weights[key] = max(w, weight)
 				continue
 			}
-			dedup[key] = struct{}{}
+			weights[key] = weight
 			backend, err := c.backend(ctx, aiGatewayRoute.Namespace, br.Name)
 			if err != nil {
-				return fmt.Errorf("AIServiceBackend %s not found", key)
+				return withEventReason(EventReasonBackendNotFound, fmt.Errorf("AIServiceBackend %s not found", key))
+			}
+			if err = defaultBackendRefPort(ctx, c.client, backend); err != nil {
+				return err
 			}
 			backends = append(backends, backend)
 		}
 	}
+	mirrors, err := c.newRequestMirrorFilters(ctx, aiGatewayRoute, backends)
+	if err != nil {
+		return err
+	}
 
 	rewriteFilters := []gwapiv1.HTTPRouteFilter{
 		{
@@ -420,62 +1984,162 @@ func (c *AIGatewayRouteController) newHTTPRoute(ctx context.Context, dst *gwapiv
 		},
 	}
 	rules := make([]gwapiv1.HTTPRouteRule, len(backends))
+	// trafficPolicies is the traffic policies of the backends indexed by the rule, passed to the extension server
+	// to configure the clusters generated by Envoy Gateway for each rule.
+	trafficPolicies := make([]*aigv1a1.AIServiceBackendTrafficPolicy, len(backends), len(backends)+1)
 	for i, b := range backends {
+		trafficPolicies[i] = b.Spec.TrafficPolicy
 		key := fmt.Sprintf("%s.%s", b.Name, b.Namespace)
 		rule := gwapiv1.HTTPRouteRule{
 			BackendRefs: []gwapiv1.HTTPBackendRef{
-				{BackendRef: gwapiv1.BackendRef{BackendObjectReference: b.Spec.BackendRef}},
+				{BackendRef: gwapiv1.BackendRef{BackendObjectReference: b.Spec.BackendRef, Weight: ptr.To(weights[key])}},
 			},
 			Matches: []gwapiv1.HTTPRouteMatch{
 				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: selectedBackendHeaderKey, Value: key}}},
 			},
-			Filters: rewriteFilters,
+			Filters: backendRewriteFilters(b, rewriteFilters),
+		}
+		if mirror, ok := mirrors[key]; ok {
+			rule.Filters = append(slices.Clone(rule.Filters), gwapiv1.HTTPRouteFilter{
+				Type:          gwapiv1.HTTPRouteFilterRequestMirror,
+				RequestMirror: mirror,
+			})
 		}
 		rules[i] = rule
 	}
 
-	// Adds the default route rule with "/" path.
+	// Adds the default route rule with "/" path, or the path prefix if set. The rule is needed even when the default
+	// route is disabled since the external processor only runs on the requests matching any of the rules. In that case,
+	// the rule has no backend, and the external processor responds to the requests it does not process with 404.
 	if len(rules) > 0 {
-		rules = append(rules, gwapiv1.HTTPRouteRule{
+		defaultRule := gwapiv1.HTTPRouteRule{
 			Matches: []gwapiv1.HTTPRouteMatch{
-				{Path: &gwapiv1.HTTPPathMatch{Value: ptr.To("/")}},
-			},
-			BackendRefs: []gwapiv1.HTTPBackendRef{
-				{BackendRef: gwapiv1.BackendRef{BackendObjectReference: backends[0].Spec.BackendRef}},
+				{Path: &gwapiv1.HTTPPathMatch{Value: ptr.To(cmp.Or(aiGatewayRoute.Spec.PathPrefix, "/"))}},
 			},
 			Filters: rewriteFilters,
-		})
+		}
+		if !aiGatewayRoute.Spec.DisableDefaultRoute {
+			// When all the backends have zero weight, the catch-all rule fails closed as well with the zero weight.
+			name := defaultBackendName(aiGatewayRoute)
+			i := slices.IndexFunc(backends, func(b *aigv1a1.AIServiceBackend) bool { return b.Name == name })
+			if i < 0 {
+				return fmt.Errorf("default backend %s is not referenced by any rule", name)
+			}
+			defaultBackend := backends[i]
+			defaultWeight := weights[fmt.Sprintf("%s.%s", defaultBackend.Name, defaultBackend.Namespace)]
+			defaultRule.BackendRefs = []gwapiv1.HTTPBackendRef{
+				{BackendRef: gwapiv1.BackendRef{BackendObjectReference: defaultBackend.Spec.BackendRef, Weight: ptr.To(defaultWeight)}},
+			}
+			defaultRule.Filters = backendRewriteFilters(defaultBackend, rewriteFilters)
+			trafficPolicies = append(trafficPolicies, defaultBackend.Spec.TrafficPolicy)
+		}
+		rules = append(rules, defaultRule)
 	}
 
 	dst.Spec.Rules = rules
+	encodedTrafficPolicies, err := json.Marshal(trafficPolicies)
+	if err != nil {
+		return fmt.Errorf("failed to marshal traffic policies: %w", err)
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = make(map[string]string)
+	}
+	dst.Annotations[extensionserver.TrafficPoliciesAnnotationKey] = string(encodedTrafficPolicies)
+
+	dst.Spec.CommonRouteSpec.ParentRefs = httpRouteParentRefs(aiGatewayRoute)
+	dst.Labels = mergeLabels(dst.Labels, aiGatewayRouteLabels(aiGatewayRoute))
+	// The local replies of Envoy are overridden by the extension server for the HTTPRoutes with the label. The label is
+	// removed when the override is disabled so that Envoy Gateway translates the HTTPRoute again.
+	if overrideErrorResponses(aiGatewayRoute) {
+		dst.Labels[extensionserver.OverrideErrorResponsesLabelKey] = "true"
+	} else {
+		delete(dst.Labels, extensionserver.OverrideErrorResponsesLabelKey)
+	}
+	return nil
+}
+
+// overrideErrorResponses returns true if the local replies of Envoy to the requests of the route are overridden with
+// the OpenAI errors, which requires the OpenAI schema.
+func overrideErrorResponses(aiGatewayRoute *aigv1a1.AIGatewayRoute) bool {
+	return aiGatewayRoute.Spec.OverrideErrorResponses && aiGatewayRoute.Spec.APISchema.Name == aigv1a1.APISchemaOpenAI
+}
 
+// httpRouteParentRefs returns the parent references of the HTTPRoutes generated for the AIGatewayRoute.
+func httpRouteParentRefs(aiGatewayRoute *aigv1a1.AIGatewayRoute) []gwapiv1.ParentReference {
 	targetRefs := aiGatewayRoute.Spec.TargetRefs
 	egNs := gwapiv1.Namespace(aiGatewayRoute.Namespace)
 	parentRefs := make([]gwapiv1.ParentReference, len(targetRefs))
 	for i, egRef := range targetRefs {
 		egName := egRef.Name
 		parentRefs[i] = gwapiv1.ParentReference{
-			Name:      egName,
-			Namespace: &egNs,
+			Name:        egName,
+			Namespace:   &egNs,
+			SectionName: egRef.SectionName,
 		}
 	}
-	dst.Spec.CommonRouteSpec.ParentRefs = parentRefs
-	return nil
+	return parentRefs
+}
+
+// newRequestMirrorFilters returns the requestMirror filters of the HTTPRoute keyed by the backend name with the namespace.
+// The request is mirrored by Envoy as translated for the selected backend, so the mirror backend must have the same
+// schema as all the backends of the rule. When a backend is referenced by multiple rules with a mirror, the mirror of
+// the first rule is used.
+func (c *AIGatewayRouteController) newRequestMirrorFilters(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute,
+	backends []*aigv1a1.AIServiceBackend,
+) (map[string]*gwapiv1.HTTPRequestMirrorFilter, error) {
+	mirrors := make(map[string]*gwapiv1.HTTPRequestMirrorFilter)
+	for i := range aiGatewayRoute.Spec.Rules {
+		rule := &aiGatewayRoute.Spec.Rules[i]
+		if rule.Mirror == nil {
+			continue
+		}
+		mirrorBackend, err := c.backend(ctx, aiGatewayRoute.Namespace, rule.Mirror.Name)
+		if err != nil {
+			return nil, withEventReason(EventReasonBackendNotFound,
+				fmt.Errorf("mirror AIServiceBackend %s.%s not found", rule.Mirror.Name, aiGatewayRoute.Namespace))
+		}
+		if err = defaultBackendRefPort(ctx, c.client, mirrorBackend); err != nil {
+			return nil, err
+		}
+		for _, br := range rule.BackendRefs {
+			key := fmt.Sprintf("%s.%s", br.Name, aiGatewayRoute.Namespace)
+			if _, ok := mirrors[key]; ok {
+				continue
+			}
+			j := slices.IndexFunc(backends, func(b *aigv1a1.AIServiceBackend) bool { return b.Name == br.Name })
+			if j < 0 {
+				continue
+			}
+			if schema := backends[j].Spec.APISchema; schema != mirrorBackend.Spec.APISchema {
+				return nil, fmt.Errorf("mirror AIServiceBackend %s.%s has the schema %s different from %s of AIServiceBackend %s: "+
+					"the requests are mirrored as translated for the selected backend", rule.Mirror.Name, aiGatewayRoute.Namespace,
+					mirrorBackend.Spec.APISchema.Name, schema.Name, key)
+			}
+			mirrors[key] = &gwapiv1.HTTPRequestMirrorFilter{
+				BackendRef: mirrorBackend.Spec.BackendRef,
+				Percent:    rule.Mirror.Percent,
+			}
+		}
+	}
+	return mirrors, nil
 }
 
-// annotateExtProcPods annotates the external processor pods with the new config uuid.
+// annotateExtProcPods annotates the external processor pods with the new config uuid unless already annotated.
 // This is necessary to make the config update faster.
 //
 // See https://neonmirrors.net/post/2022-12/reducing-pod-volume-update-times/ for explanation.
-func (c *AIGatewayRouteController) annotateExtProcPods(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute, uuid string) error {
-	pods, err := c.kube.CoreV1().Pods(aiGatewayRoute.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("app=%s", extProcName(aiGatewayRoute)),
+func (c *AIGatewayRouteController) annotateExtProcPods(ctx context.Context, namespace, name, uuid string) error {
+	pods, err := c.kube.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s", labelValue(name)),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to list pods: %w", err)
 	}
 
 	for _, pod := range pods.Items {
+		if pod.Annotations[extProcConfigAnnotationKey] == uuid {
+			continue
+		}
 		c.logger.Info("annotating pod", "namespace", pod.Namespace, "name", pod.Name)
 		_, err = c.kube.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType,
 			[]byte(fmt.Sprintf(
@@ -488,68 +2152,243 @@ func (c *AIGatewayRouteController) annotateExtProcPods(ctx context.Context, aiGa
 	return nil
 }
 
+// newExtProcContainer returns the external processor container of the name listening on the TCP port 1063, serving
+// the debug endpoints on extProcDebugAddr and the metrics on extProcMetricsPort.
+func (c *AIGatewayRouteController) newExtProcContainer(name string) corev1.Container {
+	return corev1.Container{
+		Name:            name,
+		Image:           c.extProcImage,
+		ImagePullPolicy: c.extProcImagePullPolicy,
+		Ports: []corev1.ContainerPort{
+			{Name: "grpc", ContainerPort: 1063, Protocol: corev1.ProtocolTCP},
+			{Name: "metrics", ContainerPort: extProcMetricsPort, Protocol: corev1.ProtocolTCP},
+		},
+		Args: []string{
+			"-configPath", "/etc/ai-gateway/extproc/" + expProcConfigFileName,
+			"-logLevel", c.extProcLogLevel,
+			"-debugAddr", extProcDebugAddr,
+			"-metricsAddr", fmt.Sprintf(":%d", extProcMetricsPort),
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      "config",
+				MountPath: "/etc/ai-gateway/extproc",
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// applyExtProcContainerUpdate reconciles the fields of the existing extproc container that are derived from the
+// controller flags, such as the image and the log level, with the desired container.
+//
+// The other fields, such as the resources and the volume mounts, are reconciled separately.
+func applyExtProcContainerUpdate(container *corev1.Container, desired corev1.Container) {
+	container.Image = desired.Image
+	container.ImagePullPolicy = desired.ImagePullPolicy
+	container.Args = desired.Args
+	container.Ports = desired.Ports
+}
+
+// extProcConfigVolume returns the volume of the external processor configmap of the name.
+func extProcConfigVolume(name string) corev1.Volume {
+	return corev1.Volume{
+		Name: "config",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			},
+		},
+	}
+}
+
+// syncExtProcSidecar syncs the EnvoyProxy that injects the external processor as a sidecar of the Envoy proxy pods,
+// and the Backend of the unix domain socket that the EnvoyExtensionPolicy refers to. This also deletes the resources
+// of the Deployment mode, if any, so that the deployment mode can be switched.
+func (c *AIGatewayRouteController) syncExtProcSidecar(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	name := extProcName(aiGatewayRoute)
+	patch, err := c.newExtProcSidecarPatch(ctx, aiGatewayRoute)
+	if err != nil {
+		return err
+	}
+	envoyProxySpec := egv1a1.EnvoyProxySpec{
+		Provider: &egv1a1.EnvoyProxyProvider{
+			Type: egv1a1.ProviderTypeKubernetes,
+			Kubernetes: &egv1a1.EnvoyProxyKubernetesProvider{
+				EnvoyDeployment: &egv1a1.KubernetesDeploymentSpec{
+					Patch: &egv1a1.KubernetesPatchSpec{Type: ptr.To(egv1a1.StrategicMerge), Value: apiextensionsv1.JSON{Raw: patch}},
+				},
+			},
+		},
+	}
+	var envoyProxy egv1a1.EnvoyProxy
+	err = c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: aiGatewayRoute.Namespace}, &envoyProxy)
+	if apierrors.IsNotFound(err) {
+		envoyProxy = egv1a1.EnvoyProxy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace, Labels: aiGatewayRouteLabels(aiGatewayRoute)},
+			Spec:       envoyProxySpec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, &envoyProxy, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for EnvoyProxy: %w", err))
+		}
+		if err = c.client.Create(ctx, &envoyProxy); err != nil {
+			return fmt.Errorf("failed to create EnvoyProxy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get EnvoyProxy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	} else {
+		envoyProxy.Spec = envoyProxySpec
+		if err = c.client.Update(ctx, &envoyProxy); err != nil {
+			return fmt.Errorf("failed to update EnvoyProxy %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+	}
+
+	backendSpec := egv1a1.BackendSpec{
+		Endpoints: []egv1a1.BackendEndpoint{{Unix: &egv1a1.UnixSocket{Path: extProcSocketPath(aiGatewayRoute)}}},
+	}
+	var backend egv1a1.Backend
+	err = c.client.Get(ctx, client.ObjectKey{Name: name, Namespace: aiGatewayRoute.Namespace}, &backend)
+	if apierrors.IsNotFound(err) {
+		backend = egv1a1.Backend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: aiGatewayRoute.Namespace, Labels: aiGatewayRouteLabels(aiGatewayRoute)},
+			Spec:       backendSpec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, &backend, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for Backend: %w", err))
+		}
+		if err = c.client.Create(ctx, &backend); err != nil {
+			return fmt.Errorf("failed to create Backend %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get Backend %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	} else {
+		backend.Spec = backendSpec
+		if err = c.client.Update(ctx, &backend); err != nil {
+			return fmt.Errorf("failed to update Backend %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+	}
+
+	// Delete the resources of the Deployment mode.
+	return c.deleteExtProcDeployment(ctx, aiGatewayRoute.Namespace, name)
+}
+
+// deleteExtProcDeployment deletes the external processor Deployment, Service and HorizontalPodAutoscaler of the name,
+// if any, so that the deployment mode can be switched.
+func (c *AIGatewayRouteController) deleteExtProcDeployment(ctx context.Context, namespace, name string) error {
+	if err := c.kube.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete deployment %s.%s: %w", name, namespace, err)
+	}
+	if err := c.kube.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete Service %s.%s: %w", name, namespace, err)
+	}
+	if err := c.kube.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete HorizontalPodAutoscaler %s.%s: %w", name, namespace, err)
+	}
+	if err := c.kube.PolicyV1().PodDisruptionBudgets(namespace).Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete PodDisruptionBudget %s.%s: %w", name, namespace, err)
+	}
+	return nil
+}
+
+// newExtProcSidecarPatch returns the strategic merge patch of the Envoy proxy Deployment that injects the external
+// processor container listening on the unix domain socket, the socket volume shared with the Envoy container,
+// and the volumes of the configmap and the backend security policy secrets.
+func (c *AIGatewayRouteController) newExtProcSidecarPatch(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) ([]byte, error) {
+	container := c.newExtProcContainer(extProcName(aiGatewayRoute))
+	container.Ports = nil
+	container.Args = append(container.Args, "-extProcAddr", "unix://"+extProcSocketPath(aiGatewayRoute))
+	podSpec, err := c.mountBackendSecurityPolicySecrets(ctx, &corev1.PodSpec{
+		Containers: []corev1.Container{container},
+		Volumes:    []corev1.Volume{extProcConfigVolume(extProcName(aiGatewayRoute))},
+	}, aiGatewayRoute)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount backend security policy secrets: %w", err)
+	}
+	socketMount := corev1.VolumeMount{Name: extProcSocketVolumeName, MountPath: extProcSocketDir}
+	container = podSpec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, socketMount)
+	podSpec.ImagePullSecrets = c.extProcImagePullSecrets
+	container.Resources = *c.extProcDefaultResources.DeepCopy()
+	if ep := aiGatewayRoute.Spec.FilterConfig.ExternalProcessor; ep != nil {
+		if ep.Resources != nil {
+			container.Resources = *ep.Resources
+		}
+		if len(ep.ImagePullSecrets) > 0 {
+			podSpec.ImagePullSecrets = ep.ImagePullSecrets
+		}
+		setExtProcOTLPEndpointArg(&container, ep.OTLPEndpoint)
+	}
+	podSpec.Containers = []corev1.Container{
+		{Name: envoyContainerName, VolumeMounts: []corev1.VolumeMount{socketMount}},
+		container,
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: extProcSocketVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	return json.Marshal(map[string]any{"spec": map[string]any{"template": map[string]any{"spec": podSpec}}})
+}
+
+// deleteExtProcSidecar deletes the EnvoyProxy and the Backend of the sidecar mode, if any, so that the deployment
+// mode can be switched.
+func (c *AIGatewayRouteController) deleteExtProcSidecar(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	meta := metav1.ObjectMeta{Name: extProcName(aiGatewayRoute), Namespace: aiGatewayRoute.Namespace}
+	if err := c.client.Delete(ctx, &egv1a1.EnvoyProxy{ObjectMeta: meta}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete EnvoyProxy %s.%s: %w", meta.Name, meta.Namespace, err)
+	}
+	if err := c.client.Delete(ctx, &egv1a1.Backend{ObjectMeta: meta}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete Backend %s.%s: %w", meta.Name, meta.Namespace, err)
+	}
+	return nil
+}
+
 // syncExtProcDeployment syncs the external processor's Deployment and Service.
 func (c *AIGatewayRouteController) syncExtProcDeployment(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
-	name := extProcName(aiGatewayRoute)
-	labels := map[string]string{"app": name, managedByLabel: "envoy-ai-gateway"}
+	return c.syncExtProcDeploymentOf(ctx, aiGatewayRoute.Namespace, extProcName(aiGatewayRoute),
+		aiGatewayRouteLabels(aiGatewayRoute), aiGatewayRoute, aiGatewayRoute)
+}
 
-	deployment, err := c.kube.AppsV1().Deployments(aiGatewayRoute.Namespace).Get(ctx, extProcName(aiGatewayRoute), metav1.GetOptions{})
+// syncExtProcDeploymentOf syncs the external processor's Deployment and Service of the name serving the given
+// AIGatewayRoutes, mounting the secrets of all of them. The Deployment is configured with the filter config of the
+// first one. The owner, if non-nil, is set as the controller reference of the resources.
+func (c *AIGatewayRouteController) syncExtProcDeploymentOf(ctx context.Context, namespace, name string, extraLabels map[string]string,
+	owner *aigv1a1.AIGatewayRoute, routes ...*aigv1a1.AIGatewayRoute,
+) error {
+	podLabels := map[string]string{"app": labelValue(name), managedByLabel: managedByLabelValue}
+	objectLabels := mergeLabels(podLabels, extraLabels)
+	filterConfig := routes[0].Spec.FilterConfig
+
+	deployment, err := c.kube.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if client.IgnoreNotFound(err) == nil {
 			deployment = &appsv1.Deployment{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      name,
-					Namespace: aiGatewayRoute.Namespace,
-					Labels:    labels,
+					Namespace: namespace,
+					Labels:    objectLabels,
 				},
 				Spec: appsv1.DeploymentSpec{
-					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Selector: &metav1.LabelSelector{MatchLabels: podLabels},
 					Template: corev1.PodTemplateSpec{
-						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
 						Spec: corev1.PodSpec{
-							Containers: []corev1.Container{
-								{
-									Name:            name,
-									Image:           c.extProcImage,
-									ImagePullPolicy: c.extProcImagePullPolicy,
-									Ports:           []corev1.ContainerPort{{Name: "grpc", ContainerPort: 1063}},
-									Args: []string{
-										"-configPath", "/etc/ai-gateway/extproc/" + expProcConfigFileName,
-										"-logLevel", c.extProcLogLevel,
-									},
-									VolumeMounts: []corev1.VolumeMount{
-										{
-											Name:      "config",
-											MountPath: "/etc/ai-gateway/extproc",
-											ReadOnly:  true,
-										},
-									},
-								},
-							},
-							Volumes: []corev1.Volume{
-								{
-									Name: "config",
-									VolumeSource: corev1.VolumeSource{
-										ConfigMap: &corev1.ConfigMapVolumeSource{
-											LocalObjectReference: corev1.LocalObjectReference{Name: extProcName(aiGatewayRoute)},
-										},
-									},
-								},
-							},
+							Containers: []corev1.Container{c.newExtProcContainer(name)},
+							Volumes:    []corev1.Volume{extProcConfigVolume(name)},
 						},
 					},
 				},
 			}
-			if err = ctrlutil.SetControllerReference(aiGatewayRoute, deployment, c.client.Scheme()); err != nil {
-				panic(fmt.Errorf("BUG: failed to set controller reference for deployment: %w", err))
+			if owner != nil {
+				if err = ctrlutil.SetControllerReference(owner, deployment, c.client.Scheme()); err != nil {
+					panic(fmt.Errorf("BUG: failed to set controller reference for deployment: %w", err))
+				}
 			}
 			var updatedSpec *corev1.PodSpec
-			updatedSpec, err = c.mountBackendSecurityPolicySecrets(ctx, &deployment.Spec.Template.Spec, aiGatewayRoute)
+			updatedSpec, err = c.mountBackendSecurityPolicySecrets(ctx, &deployment.Spec.Template.Spec, routes...)
 			if err == nil {
 				deployment.Spec.Template.Spec = *updatedSpec
 			}
-			applyExtProcDeploymentConfigUpdate(&deployment.Spec, aiGatewayRoute.Spec.FilterConfig)
-			_, err = c.kube.AppsV1().Deployments(aiGatewayRoute.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
+			applyExtProcDeploymentConfigUpdate(&deployment.Spec, filterConfig, c.extProcImagePullSecrets, c.extProcDefaultResources)
+			_, err = c.kube.AppsV1().Deployments(namespace).Create(ctx, deployment, metav1.CreateOptions{})
 			if err != nil {
 				return fmt.Errorf("failed to create deployment: %w", err)
 			}
@@ -558,14 +2397,21 @@ func (c *AIGatewayRouteController) syncExtProcDeployment(ctx context.Context, ai
 			return fmt.Errorf("failed to get deployment: %w", err)
 		}
 	} else {
+		current := deployment.DeepCopy()
+		deployment.Labels = mergeLabels(deployment.Labels, objectLabels)
+		applyExtProcContainerUpdate(&deployment.Spec.Template.Spec.Containers[0], c.newExtProcContainer(name))
 		var updatedSpec *corev1.PodSpec
-		updatedSpec, err = c.mountBackendSecurityPolicySecrets(ctx, &deployment.Spec.Template.Spec, aiGatewayRoute)
+		updatedSpec, err = c.mountBackendSecurityPolicySecrets(ctx, &deployment.Spec.Template.Spec, routes...)
 		if err == nil {
 			deployment.Spec.Template.Spec = *updatedSpec
 		}
-		applyExtProcDeploymentConfigUpdate(&deployment.Spec, aiGatewayRoute.Spec.FilterConfig)
-		if _, err = c.kube.AppsV1().Deployments(aiGatewayRoute.Namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
-			return fmt.Errorf("failed to update deployment: %w", err)
+		applyExtProcDeploymentConfigUpdate(&deployment.Spec, filterConfig, c.extProcImagePullSecrets, c.extProcDefaultResources)
+		// Skip the no-op update so that the generation of the deployment is not bumped on every reconciliation.
+		if !equality.Semantic.DeepEqual(current, deployment) {
+			if _, err = c.kube.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update deployment: %w", err)
+			}
+			c.logger.Info("Updated deployment", "name", name)
 		}
 	}
 
@@ -573,11 +2419,11 @@ func (c *AIGatewayRouteController) syncExtProcDeployment(ctx context.Context, ai
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: aiGatewayRoute.Namespace,
-			Labels:    labels,
+			Namespace: namespace,
+			Labels:    objectLabels,
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: labels,
+			Selector: podLabels,
 			Ports: []corev1.ServicePort{
 				{
 					Name:        "grpc",
@@ -588,36 +2434,214 @@ func (c *AIGatewayRouteController) syncExtProcDeployment(ctx context.Context, ai
 			},
 		},
 	}
-	if err = ctrlutil.SetControllerReference(aiGatewayRoute, service, c.client.Scheme()); err != nil {
-		panic(fmt.Errorf("BUG: failed to set controller reference for service: %w", err))
+	if owner != nil {
+		if err = ctrlutil.SetControllerReference(owner, service, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for service: %w", err))
+		}
+	}
+	if _, err = c.kube.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{}); client.IgnoreAlreadyExists(err) != nil {
+		return fmt.Errorf("failed to create Service %s.%s: %w", name, namespace, err)
+	}
+	return nil
+}
+
+// syncExtProcHPA creates, updates, or deletes the HorizontalPodAutoscaler of the extproc deployment
+// depending on the filter configuration of the given AIGatewayRoute.
+func (c *AIGatewayRouteController) syncExtProcHPA(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	name := extProcName(aiGatewayRoute)
+	hpas := c.kube.AutoscalingV2().HorizontalPodAutoscalers(aiGatewayRoute.Namespace)
+
+	var hpaConfig *aigv1a1.AIGatewayFilterConfigExternalProcessorHPA
+	if fc := aiGatewayRoute.Spec.FilterConfig; fc != nil && fc.ExternalProcessor != nil {
+		hpaConfig = fc.ExternalProcessor.HorizontalPodAutoscaler
+	}
+	if hpaConfig == nil {
+		if err := hpas.Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete HorizontalPodAutoscaler %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		return nil
+	}
+
+	spec := autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name},
+		MinReplicas:    hpaConfig.MinReplicas,
+		MaxReplicas:    hpaConfig.MaxReplicas,
+		Metrics:        hpaConfig.Metrics,
+	}
+	hpa, err := hpas.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		hpa = &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: aiGatewayRoute.Namespace,
+				Labels:    mergeLabels(map[string]string{"app": labelValue(name), managedByLabel: managedByLabelValue}, aiGatewayRouteLabels(aiGatewayRoute)),
+			},
+			Spec: spec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, hpa, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for HorizontalPodAutoscaler: %w", err))
+		}
+		if _, err = hpas.Create(ctx, hpa, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create HorizontalPodAutoscaler %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		c.logger.Info("Created HorizontalPodAutoscaler", "name", name)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get HorizontalPodAutoscaler %s.%s: %w", name, aiGatewayRoute.Namespace, err)
 	}
-	if _, err = c.kube.CoreV1().Services(aiGatewayRoute.Namespace).Create(ctx, service, metav1.CreateOptions{}); client.IgnoreAlreadyExists(err) != nil {
-		return fmt.Errorf("failed to create Service %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+
+	hpa.Spec = spec
+	if _, err = hpas.Update(ctx, hpa, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update HorizontalPodAutoscaler %s.%s: %w", name, aiGatewayRoute.Namespace, err)
 	}
 	return nil
 }
 
+// syncExtProcPDB creates, updates, or deletes the PodDisruptionBudget of the extproc deployment based on
+// the PodDisruptionBudget configuration of the AIGatewayRoute, and updates the ExtProcDisruptionAllowed condition.
+func (c *AIGatewayRouteController) syncExtProcPDB(ctx context.Context, aiGatewayRoute *aigv1a1.AIGatewayRoute) error {
+	name := extProcName(aiGatewayRoute)
+	pdbs := c.kube.PolicyV1().PodDisruptionBudgets(aiGatewayRoute.Namespace)
+
+	var extProc *aigv1a1.AIGatewayFilterConfigExternalProcessor
+	if fc := aiGatewayRoute.Spec.FilterConfig; fc != nil {
+		extProc = fc.ExternalProcessor
+	}
+	if extProc == nil || extProc.PodDisruptionBudget == nil {
+		if err := pdbs.Delete(ctx, name, metav1.DeleteOptions{}); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete PodDisruptionBudget %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		if meta.RemoveStatusCondition(&aiGatewayRoute.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed) {
+			if err := c.client.Status().Update(ctx, aiGatewayRoute); err != nil {
+				return fmt.Errorf("failed to update AIGatewayRoute status: %w", err)
+			}
+		}
+		return nil
+	}
+
+	spec := policyv1.PodDisruptionBudgetSpec{
+		MinAvailable:   extProc.PodDisruptionBudget.MinAvailable,
+		MaxUnavailable: extProc.PodDisruptionBudget.MaxUnavailable,
+		Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": labelValue(name), managedByLabel: managedByLabelValue}},
+	}
+	pdb, err := pdbs.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		pdb = &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: aiGatewayRoute.Namespace,
+				Labels:    mergeLabels(map[string]string{"app": labelValue(name), managedByLabel: managedByLabelValue}, aiGatewayRouteLabels(aiGatewayRoute)),
+			},
+			Spec: spec,
+		}
+		if err = ctrlutil.SetControllerReference(aiGatewayRoute, pdb, c.client.Scheme()); err != nil {
+			panic(fmt.Errorf("BUG: failed to set controller reference for PodDisruptionBudget: %w", err))
+		}
+		if _, err = pdbs.Create(ctx, pdb, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create PodDisruptionBudget %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+		c.logger.Info("Created PodDisruptionBudget", "name", name)
+	} else if err != nil {
+		return fmt.Errorf("failed to get PodDisruptionBudget %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+	} else {
+		pdb.Spec = spec
+		if _, err = pdbs.Update(ctx, pdb, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update PodDisruptionBudget %s.%s: %w", name, aiGatewayRoute.Namespace, err)
+		}
+	}
+
+	disruptionAllowed := metav1.Condition{
+		Type:               aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed,
+		Status:             metav1.ConditionTrue,
+		Reason:             aigv1a1.AIGatewayRouteReasonReplicated,
+		Message:            "The external processor deployment runs multiple replicas",
+		ObservedGeneration: aiGatewayRoute.Generation,
+	}
+	if extProcSingleReplica(extProc) {
+		c.logger.Info("PodDisruptionBudget of the single replica external processor may block node drains",
+			"namespace", aiGatewayRoute.Namespace, "name", aiGatewayRoute.Name)
+		disruptionAllowed.Status = metav1.ConditionFalse
+		disruptionAllowed.Reason = aigv1a1.AIGatewayRouteReasonSingleReplica
+		disruptionAllowed.Message = "The external processor deployment runs a single replica, " +
+			"so the PodDisruptionBudget may block node drains"
+	}
+	return c.setStatusConditions(ctx, aiGatewayRoute, disruptionAllowed)
+}
+
+// extProcSingleReplica returns true if the external processor deployment runs, and cannot be scaled beyond,
+// a single replica.
+func extProcSingleReplica(extProc *aigv1a1.AIGatewayFilterConfigExternalProcessor) bool {
+	if hpa := extProc.HorizontalPodAutoscaler; hpa != nil {
+		return hpa.MaxReplicas <= 1
+	}
+	return ptr.Deref(extProc.Replicas, 1) <= 1
+}
+
 // mountBackendSecurityPolicySecrets will mount secrets based on backendSecurityPolicies attached to AIServiceBackend.
-func (c *AIGatewayRouteController) mountBackendSecurityPolicySecrets(ctx context.Context, spec *corev1.PodSpec, aiGatewayRoute *aigv1a1.AIGatewayRoute) (*corev1.PodSpec, error) {
+//
+// When the external processor is shared by multiple AIGatewayRoutes, the rules are indexed in the order of the given
+// routes in the same way as the merged filter configuration. See newFilterConfig.
+func (c *AIGatewayRouteController) mountBackendSecurityPolicySecrets(ctx context.Context, spec *corev1.PodSpec, aiGatewayRoutes ...*aigv1a1.AIGatewayRoute) (*corev1.PodSpec, error) {
 	// Mount from scratch to avoid secrets that should be unmounted.
 	// Only keep the original mount which should be the config volume.
 	spec.Volumes = spec.Volumes[:1]
 	container := &spec.Containers[0]
 	container.VolumeMounts = container.VolumeMounts[:1]
 
+	var ruleIndexOffset int
+	for _, aiGatewayRoute := range aiGatewayRoutes {
+		if err := c.mountRouteBackendSecurityPolicySecrets(ctx, spec, aiGatewayRoute, ruleIndexOffset); err != nil {
+			return nil, err
+		}
+		ruleIndexOffset += len(aiGatewayRoute.Spec.Rules)
+	}
+
+	// The shared configuration takes the state store of the first AIGatewayRoute. See newSharedFilterConfig.
+	if len(aiGatewayRoutes) > 0 {
+		if ss := aiGatewayRoutes[0].Spec.StateStore; ss != nil && ss.PasswordSecretRef != nil {
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name: stateStoreVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: ss.PasswordSecretRef.Name},
+				},
+			})
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      stateStoreVolumeName,
+				MountPath: stateStoreMountPath,
+				ReadOnly:  true,
+			})
+		}
+	}
+	return spec, nil
+}
+
+// mountRouteBackendSecurityPolicySecrets mounts the secrets of the AIGatewayRoute with its rules indexed from
+// the ruleIndexOffset.
+func (c *AIGatewayRouteController) mountRouteBackendSecurityPolicySecrets(ctx context.Context, spec *corev1.PodSpec,
+	aiGatewayRoute *aigv1a1.AIGatewayRoute, ruleIndexOffset int,
+) error {
+	container := &spec.Containers[0]
 	for i := range aiGatewayRoute.Spec.Rules {
 		rule := &aiGatewayRoute.Spec.Rules[i]
 		for j := range rule.BackendRefs {
-			backendRef := &rule.BackendRefs[j]
-			backend, err := c.backend(ctx, aiGatewayRoute.Namespace, backendRef.Name)
+			name := rule.BackendRefs[j].Name
+			backend, err := c.backend(ctx, aiGatewayRoute.Namespace, name)
 			if err != nil {
-				return nil, fmt.Errorf("failed to get backend %s: %w", backendRef.Name, err)
+				return fmt.Errorf("failed to get backend %s: %w", name, err)
 			}
 
 			if backendSecurityPolicyRef := backend.Spec.BackendSecurityPolicyRef; backendSecurityPolicyRef != nil {
 				backendSecurityPolicy, err := c.backendSecurityPolicy(ctx, aiGatewayRoute.Namespace, string(backendSecurityPolicyRef.Name))
 				if err != nil {
-					return nil, fmt.Errorf("failed to get backend security policy %s: %w", backendSecurityPolicyRef.Name, err)
+					return fmt.Errorf("failed to get backend security policy %s: %w", backendSecurityPolicyRef.Name, err)
+				}
+
+				// The secret in another namespace without the ReferenceGrant permitting it is not mounted. This is
+				// reported by the ResolvedRefs condition of the BackendSecurityPolicy.
+				if granted, err := backendSecurityPolicySecretsGranted(ctx, c.client, backendSecurityPolicy); err != nil {
+					return err
+				} else if !granted {
+					continue
 				}
 
 				var secretName string
@@ -630,11 +2654,13 @@ func (c *AIGatewayRouteController) mountBackendSecurityPolicySecrets(ctx context
 					} else {
 						secretName = rotators.GetBSPSecretName(backendSecurityPolicy.Name)
 					}
+				case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
+					secretName = rotators.GetBSPSecretName(backendSecurityPolicy.Name)
 				default:
-					return nil, fmt.Errorf("backend security policy %s is not supported", backendSecurityPolicy.Spec.Type)
+					return fmt.Errorf("backend security policy %s is not supported", backendSecurityPolicy.Spec.Type)
 				}
 
-				volumeName := backendSecurityPolicyVolumeName(i, j, string(backend.Spec.BackendSecurityPolicyRef.Name))
+				volumeName := backendSecurityPolicyVolumeName(ruleIndexOffset+i, j, string(backend.Spec.BackendSecurityPolicyRef.Name))
 				spec.Volumes = append(spec.Volumes, corev1.Volume{
 					Name: volumeName,
 					VolumeSource: corev1.VolumeSource{
@@ -650,7 +2676,20 @@ func (c *AIGatewayRouteController) mountBackendSecurityPolicySecrets(ctx context
 			}
 		}
 	}
-	return spec, nil
+	return nil
+}
+
+// ruleBackendNames returns the names of the AIServiceBackends referenced by the rule followed by the mirror backend,
+// if any.
+func ruleBackendNames(rule *aigv1a1.AIGatewayRouteRule) []string {
+	names := make([]string, 0, len(rule.BackendRefs)+1)
+	for i := range rule.BackendRefs {
+		names = append(names, rule.BackendRefs[i].Name)
+	}
+	if rule.Mirror != nil {
+		names = append(names, rule.Mirror.Name)
+	}
+	return names
 }
 
 func (c *AIGatewayRouteController) backend(ctx context.Context, namespace, name string) (*aigv1a1.AIServiceBackend, error) {
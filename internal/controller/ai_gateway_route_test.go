@@ -7,8 +7,10 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,13 +18,21 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	uuid2 "k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	fake2 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,11 +44,12 @@ import (
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
+	"github.com/envoyproxy/ai-gateway/internal/extensionserver"
 )
 
 func TestAIGatewayRouteController_Reconcile(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
-	c := NewAIGatewayRouteController(fakeClient, fake2.NewClientset(), ctrl.Log, "gcr.io/ai-gateway/extproc:latest", "info")
+	c := NewAIGatewayRouteController(fakeClient, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
 
 	err := fakeClient.Create(t.Context(), &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"}})
 	require.NoError(t, err)
@@ -67,12 +78,318 @@ func TestAIGatewayRouteController_Reconcile(t *testing.T) {
 	require.Len(t, updated.Spec.TargetRefs, 1)
 	require.Equal(t, "mytarget", string(updated.Spec.TargetRefs[0].Name))
 	require.Equal(t, aigv1a1.APISchemaOpenAI, updated.Spec.APISchema.Name)
+	require.Equal(t, []string{aiGatewayRouteFinalizer}, updated.Finalizers)
+
+	var filter egv1a1.HTTPRouteFilter
+	err = fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: hostRewriteHTTPFilterName}, &filter)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{managedByLabel: managedByLabelValue}, filter.Labels)
 
 	// Test the case where the AIGatewayRoute is being deleted.
 	err = fakeClient.Delete(t.Context(), &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"}})
 	require.NoError(t, err)
 	_, err = c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "myroute"}})
 	require.NoError(t, err)
+
+	// The finalizer must be removed, and the host rewrite filter must be deleted as this was the last route.
+	err = fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: "myroute"}, &updated)
+	require.True(t, apierrors.IsNotFound(err), "expected not found but got %v", err)
+	err = fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: hostRewriteHTTPFilterName}, &filter)
+	require.True(t, apierrors.IsNotFound(err), "expected not found but got %v", err)
+	var policy egv1a1.EnvoyExtensionPolicy
+	err = fakeClient.Get(t.Context(), types.NamespacedName{Namespace: "default", Name: extProcName(&updated)}, &policy)
+	require.True(t, apierrors.IsNotFound(err), "expected not found but got %v", err)
+}
+
+func TestAIGatewayRouteController_Reconcile_events(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	recorder := record.NewFakeRecorder(10)
+	c := NewAIGatewayRouteController(fakeClient, fake2.NewClientset(), ctrl.Log, recorder, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
+
+	err := fakeClient.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+			Rules:     []aigv1a1.AIGatewayRouteRule{{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "missing"}}}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "myroute"}})
+	require.ErrorContains(t, err, "AIServiceBackend missing.default not found")
+	require.Equal(t, "Warning BackendNotFound "+err.Error(), <-recorder.Events)
+	require.Empty(t, recorder.Events)
+}
+
+func TestAIGatewayRouteController_deleteOrphanedResources(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewAIGatewayRouteController(fakeClient, kube, ctrl.Log, &record.FakeRecorder{}, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
+
+	// The name longer than a label value must still be matched by the label selector.
+	live := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "live-" + strings.Repeat("a", 80), Namespace: "ns"}}
+	require.NoError(t, fakeClient.Create(t.Context(), live))
+	gone := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "gone", Namespace: "ns"}}
+	for _, route := range []*aigv1a1.AIGatewayRoute{live, gone} {
+		require.NoError(t, fakeClient.Create(t.Context(), &egv1a1.EnvoyExtensionPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}))
+		require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: route.Name, Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}))
+		require.NoError(t, fakeClient.Create(t.Context(), &egv1a1.SecurityPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: corsSecurityPolicyName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}))
+		require.NoError(t, fakeClient.Create(t.Context(), &egv1a1.BackendTrafficPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: trafficPolicyName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}))
+		_, err := kube.CoreV1().ConfigMaps("ns").Create(t.Context(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+		_, err = kube.AppsV1().Deployments("ns").Create(t.Context(), &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+		_, err = kube.CoreV1().Services("ns").Create(t.Context(), &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+		_, err = kube.AutoscalingV2().HorizontalPodAutoscalers("ns").Create(t.Context(), &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns", Labels: aiGatewayRouteLabels(route)},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	// Resources not created by the controller must not be touched.
+	require.NoError(t, fakeClient.Create(t.Context(), &egv1a1.EnvoyExtensionPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-policy", Namespace: "ns"},
+	}))
+	require.NoError(t, fakeClient.Create(t.Context(), &egv1a1.HTTPRouteFilter{
+		ObjectMeta: metav1.ObjectMeta{Name: hostRewriteHTTPFilterName, Namespace: "ns"},
+	}))
+
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+
+	names := func(t *testing.T, list client.ObjectList) []string {
+		require.NoError(t, fakeClient.List(t.Context(), list, client.InNamespace("ns")))
+		var ret []string
+		switch l := list.(type) {
+		case *egv1a1.EnvoyExtensionPolicyList:
+			for _, i := range l.Items {
+				ret = append(ret, i.Name)
+			}
+		case *gwapiv1.HTTPRouteList:
+			for _, i := range l.Items {
+				ret = append(ret, i.Name)
+			}
+		case *egv1a1.HTTPRouteFilterList:
+			for _, i := range l.Items {
+				ret = append(ret, i.Name)
+			}
+		case *egv1a1.SecurityPolicyList:
+			for _, i := range l.Items {
+				ret = append(ret, i.Name)
+			}
+		case *egv1a1.BackendTrafficPolicyList:
+			for _, i := range l.Items {
+				ret = append(ret, i.Name)
+			}
+		}
+		return ret
+	}
+	require.ElementsMatch(t, []string{extProcName(live), "user-policy"}, names(t, &egv1a1.EnvoyExtensionPolicyList{}))
+	require.ElementsMatch(t, []string{live.Name}, names(t, &gwapiv1.HTTPRouteList{}))
+	require.ElementsMatch(t, []string{hostRewriteHTTPFilterName}, names(t, &egv1a1.HTTPRouteFilterList{}))
+	require.ElementsMatch(t, []string{corsSecurityPolicyName(live)}, names(t, &egv1a1.SecurityPolicyList{}))
+	require.ElementsMatch(t, []string{trafficPolicyName(live)}, names(t, &egv1a1.BackendTrafficPolicyList{}))
+
+	configMaps, err := kube.CoreV1().ConfigMaps("ns").List(t.Context(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, configMaps.Items, 1)
+	require.Equal(t, extProcName(live), configMaps.Items[0].Name)
+	deployments, err := kube.AppsV1().Deployments("ns").List(t.Context(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, deployments.Items, 1)
+	require.Equal(t, extProcName(live), deployments.Items[0].Name)
+	services, err := kube.CoreV1().Services("ns").List(t.Context(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, services.Items, 1)
+	require.Equal(t, extProcName(live), services.Items[0].Name)
+	hpas, err := kube.AutoscalingV2().HorizontalPodAutoscalers("ns").List(t.Context(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, hpas.Items, 1)
+	require.Equal(t, extProcName(live), hpas.Items[0].Name)
+
+	// Once the last route is gone, the host rewrite filter is also deleted.
+	require.NoError(t, fakeClient.Delete(t.Context(), live))
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	require.Equal(t, []string{"user-policy"}, names(t, &egv1a1.EnvoyExtensionPolicyList{}))
+	require.Empty(t, names(t, &gwapiv1.HTTPRouteList{}))
+	require.Empty(t, names(t, &egv1a1.HTTPRouteFilterList{}))
+}
+
+func TestAIGatewayRouteController_syncSharedExtProc(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	c := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "info", corev1.ResourceRequirements{})
+
+	for _, name := range []string{"apple", "orange"} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				BackendRef: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(name), Namespace: ptr.To[gwapiv1.Namespace]("ns")},
+			},
+		}))
+	}
+	newRoute := func(name, backend string) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				APISchema:    aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+				FilterConfig: &aigv1a1.AIGatewayFilterConfig{SharedDeployment: true},
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: backend}}},
+				},
+				LLMRequestCosts: []aigv1a1.LLMRequestCost{
+					{MetadataKey: "total", Type: aigv1a1.LLMRequestCostTypeTotalToken},
+					{MetadataKey: name, Type: aigv1a1.LLMRequestCostTypeInputToken},
+				},
+			},
+		}
+	}
+	b := newRoute("b", "orange")
+	a := newRoute("a", "apple")
+	// The resources left over from the dedicated extproc of the route switched to the shared one.
+	objectMeta := metav1.ObjectMeta{Name: extProcName(a), Namespace: "ns"}
+	_, err := kube.AutoscalingV2().HorizontalPodAutoscalers("ns").Create(t.Context(),
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: objectMeta}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = kube.PolicyV1().PodDisruptionBudgets("ns").Create(t.Context(),
+		&policyv1.PodDisruptionBudget{ObjectMeta: objectMeta}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	for _, route := range []*aigv1a1.AIGatewayRoute{b, a} {
+		require.NoError(t, fakeClient.Create(t.Context(), route))
+		if route == a {
+			a.Status.Conditions = []metav1.Condition{{
+				Type: aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed, Status: metav1.ConditionTrue,
+				Reason: aigv1a1.AIGatewayRouteReasonReplicated, LastTransitionTime: metav1.Now(),
+			}}
+			require.NoError(t, fakeClient.Status().Update(t.Context(), a))
+		}
+		require.NoError(t, c.syncAIGatewayRoute(t.Context(), route))
+	}
+	// The per-route resources are not created, and the left over ones are deleted.
+	_, err = kube.AppsV1().Deployments("ns").Get(t.Context(), extProcName(a), metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	_, err = kube.AutoscalingV2().HorizontalPodAutoscalers("ns").Get(t.Context(), extProcName(a), metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	_, err = kube.PolicyV1().PodDisruptionBudgets("ns").Get(t.Context(), extProcName(a), metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	var stored aigv1a1.AIGatewayRoute
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(a), &stored))
+	require.Empty(t, stored.Status.Conditions)
+
+	requireSharedConfig := func(t *testing.T) *filterapi.Config {
+		configMap, err := kube.CoreV1().ConfigMaps("ns").Get(t.Context(), sharedExtProcName, metav1.GetOptions{})
+		require.NoError(t, err)
+		var fc filterapi.Config
+		require.NoError(t, yaml.Unmarshal([]byte(configMap.Data[expProcConfigFileName]), &fc))
+		return &fc
+	}
+
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	fc := requireSharedConfig(t)
+	// Syncing again without any change keeps the configuration as-is.
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	require.Equal(t, fc, requireSharedConfig(t))
+	require.Equal(t, "ns/"+sharedExtProcName, fc.RouteName)
+	// The rules are ordered by the route names regardless of the order of the reconciliation. The default rules are
+	// not merged since the extproc cannot tell which route the request matching no rule belongs to.
+	require.Len(t, fc.Rules, 2)
+	require.Equal(t, "apple.ns", fc.Rules[0].Backends[0].Name)
+	require.Equal(t, "orange.ns", fc.Rules[1].Backends[0].Name)
+	var costKeys []string
+	for _, cost := range fc.LLMRequestCosts {
+		costKeys = append(costKeys, cost.MetadataKey)
+	}
+	require.Equal(t, []string{"total", "a", "b"}, costKeys)
+
+	deployments, err := kube.AppsV1().Deployments("ns").List(t.Context(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, deployments.Items, 1)
+	deployment := deployments.Items[0]
+	require.Equal(t, sharedExtProcName, deployment.Name)
+	require.Empty(t, deployment.OwnerReferences)
+	require.NotContains(t, deployment.Labels, aiGatewayRouteLabel)
+	_, err = kube.CoreV1().Services("ns").Get(t.Context(), sharedExtProcName, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// The route with a different metadata namespace is not merged into the shared configuration.
+	other := newRoute("c", "apple")
+	other.Spec.MetadataNamespace = "io.example.other"
+	require.NoError(t, fakeClient.Create(t.Context(), other))
+	require.ErrorContains(t, c.syncAIGatewayRoute(t.Context(), other), "metadataNamespace")
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	fc = requireSharedConfig(t)
+	require.Len(t, fc.Rules, 2)
+	require.Equal(t, aigv1a1.AIGatewayFilterMetadataNamespace, fc.MetadataNamespace)
+	require.NoError(t, fakeClient.Delete(t.Context(), other))
+
+	// Nor is the route with a different state store from the first route.
+	other = newRoute("d", "apple")
+	other.Spec.StateStore = &aigv1a1.AIGatewayRouteStateStore{Type: aigv1a1.AIGatewayRouteStateStoreTypeRedis, Address: "redis:6379"}
+	require.NoError(t, fakeClient.Create(t.Context(), other))
+	require.ErrorContains(t, c.syncAIGatewayRoute(t.Context(), other), "stateStore differs from the one of AIGatewayRoute a")
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	fc = requireSharedConfig(t)
+	require.Len(t, fc.Rules, 2)
+	require.Nil(t, fc.StateStore)
+	require.NoError(t, fakeClient.Delete(t.Context(), other))
+
+	// Nor is the route with a different Deployment configuration, which would be silently ignored otherwise.
+	other = newRoute("e", "apple")
+	other.Spec.FilterConfig.ExternalProcessor = &aigv1a1.AIGatewayFilterConfigExternalProcessor{Replicas: ptr.To[int32](3)}
+	require.NoError(t, fakeClient.Create(t.Context(), other))
+	require.ErrorContains(t, c.syncAIGatewayRoute(t.Context(), other), "filterConfig.externalProcessor differs")
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	fc = requireSharedConfig(t)
+	require.Len(t, fc.Rules, 2)
+	sharedDeployment, err := kube.AppsV1().Deployments("ns").Get(t.Context(), sharedExtProcName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotEqual(t, ptr.To[int32](3), sharedDeployment.Spec.Replicas)
+	require.NoError(t, fakeClient.Delete(t.Context(), other))
+
+	// Deleting a route only removes its rules. The default rule of the remaining route is kept.
+	require.NoError(t, fakeClient.Delete(t.Context(), a))
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	fc = requireSharedConfig(t)
+	require.Len(t, fc.Rules, 2)
+	require.Equal(t, "orange.ns", fc.Rules[0].Backends[0].Name)
+	require.True(t, fc.Rules[1].Default)
+	require.Equal(t, "orange.ns", fc.Rules[1].Backends[0].Name)
+
+	// Once the last route is gone, the shared resources are deleted.
+	require.NoError(t, fakeClient.Delete(t.Context(), b))
+	require.NoError(t, c.deleteOrphanedResources(t.Context(), "ns"))
+	_, err = kube.CoreV1().ConfigMaps("ns").Get(t.Context(), sharedExtProcName, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	_, err = kube.AppsV1().Deployments("ns").Get(t.Context(), sharedExtProcName, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	_, err = kube.CoreV1().Services("ns").Get(t.Context(), sharedExtProcName, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+}
+
+func Test_labelValue(t *testing.T) {
+	require.Equal(t, "myroute", labelValue("myroute"))
+	require.Equal(t, strings.Repeat("a", 63), labelValue(strings.Repeat("a", 63)))
+
+	long := labelValue(strings.Repeat("a", 64))
+	require.Len(t, long, 63)
+	require.Empty(t, validation.IsValidLabelValue(long))
+	require.True(t, strings.HasPrefix(long, strings.Repeat("a", 52)+"-"))
+	// The values sharing the same prefix are distinguished by the hash.
+	require.NotEqual(t, long, labelValue(strings.Repeat("a", 65)))
+	// The separators at the end of the truncated prefix are trimmed.
+	require.Empty(t, validation.IsValidLabelValue(labelValue(strings.Repeat("a", 51)+"--"+strings.Repeat("b", 20))))
 }
 
 func Test_extProcName(t *testing.T) {
@@ -118,7 +435,7 @@ func TestAIGatewayRouteController_reconcileExtProcExtensionPolicy(t *testing.T)
 		Spec: aigv1a1.AIGatewayRouteSpec{
 			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
 				{LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Name: "mytarget"}},
-				{LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Name: "mytarget2"}},
+				{LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Name: "mytarget2"}, SectionName: ptr.To[gwapiv1.SectionName]("https")},
 			},
 		},
 	}
@@ -131,6 +448,8 @@ func TestAIGatewayRouteController_reconcileExtProcExtensionPolicy(t *testing.T)
 	require.Equal(t, len(aiGatewayRoute.Spec.TargetRefs), len(extPolicy.Spec.TargetRefs))
 	for i, target := range extPolicy.Spec.TargetRefs {
 		require.Equal(t, aiGatewayRoute.Spec.TargetRefs[i].Name, target.Name)
+		// The section name is not supported by EnvoyExtensionPolicy.
+		require.Nil(t, target.SectionName)
 	}
 	require.Equal(t, ownerRef, extPolicy.OwnerReferences)
 	require.Len(t, extPolicy.Spec.ExtProc, 1)
@@ -142,6 +461,7 @@ func TestAIGatewayRouteController_reconcileExtProcExtensionPolicy(t *testing.T)
 		Response:          &egv1a1.ProcessingModeOptions{Body: ptr.To(egv1a1.BufferedExtProcBodyProcessingMode)},
 	}, extPolicy.Spec.ExtProc[0].ProcessingMode)
 	require.Equal(t, aigv1a1.AIGatewayFilterMetadataNamespace, extPolicy.Spec.ExtProc[0].Metadata.WritableNamespaces[0])
+	require.Equal(t, ptr.To(false), extPolicy.Spec.ExtProc[0].FailOpen)
 
 	// Update the policy.
 	aiGatewayRoute.Spec.TargetRefs = []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
@@ -159,6 +479,416 @@ func TestAIGatewayRouteController_reconcileExtProcExtensionPolicy(t *testing.T)
 	for i, target := range extPolicy.Spec.TargetRefs {
 		require.Equal(t, aiGatewayRoute.Spec.TargetRefs[i].Name, target.Name)
 	}
+	require.Len(t, extPolicy.Spec.ExtProc[0].BackendRefs, 1)
+	require.Equal(t, ptr.To[gwapiv1.PortNumber](1063), extPolicy.Spec.ExtProc[0].BackendRefs[0].Port)
+	require.Nil(t, extPolicy.Spec.ExtProc[0].BackendRefs[0].Kind)
+
+	// Switch to the sidecar mode.
+	aiGatewayRoute.Spec.FilterConfig = &aigv1a1.AIGatewayFilterConfig{
+		Type: aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
+		ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+			DeploymentMode: aigv1a1.AIGatewayFilterConfigExternalProcessorDeploymentModeSidecar,
+		},
+	}
+	err = c.reconcileExtProcExtensionPolicy(t.Context(), aiGatewayRoute)
+	require.NoError(t, err)
+
+	err = c.client.Get(t.Context(), client.ObjectKey{Name: extProcName(aiGatewayRoute), Namespace: "default"}, &extPolicy)
+	require.NoError(t, err)
+	require.Len(t, extPolicy.Spec.ExtProc[0].BackendRefs, 1)
+	backendRef := extPolicy.Spec.ExtProc[0].BackendRefs[0]
+	require.Equal(t, gwapiv1.ObjectName(extProcName(aiGatewayRoute)), backendRef.Name)
+	require.Equal(t, ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"), backendRef.Group)
+	require.Equal(t, ptr.To[gwapiv1.Kind]("Backend"), backendRef.Kind)
+	require.Nil(t, backendRef.Port)
+
+	// Switch to the fail open mode.
+	aiGatewayRoute.Spec.FilterConfig.FailureMode = aigv1a1.AIGatewayFilterConfigFailureModeFailOpen
+	err = c.reconcileExtProcExtensionPolicy(t.Context(), aiGatewayRoute)
+	require.NoError(t, err)
+
+	err = c.client.Get(t.Context(), client.ObjectKey{Name: extProcName(aiGatewayRoute), Namespace: "default"}, &extPolicy)
+	require.NoError(t, err)
+	require.Equal(t, ptr.To(true), extPolicy.Spec.ExtProc[0].FailOpen)
+
+	// Change the metadata namespace.
+	aiGatewayRoute.Spec.MetadataNamespace = "io.example.tenant_a"
+	err = c.reconcileExtProcExtensionPolicy(t.Context(), aiGatewayRoute)
+	require.NoError(t, err)
+
+	err = c.client.Get(t.Context(), client.ObjectKey{Name: extProcName(aiGatewayRoute), Namespace: "default"}, &extPolicy)
+	require.NoError(t, err)
+	require.Equal(t, []string{"io.example.tenant_a"}, extPolicy.Spec.ExtProc[0].Metadata.WritableNamespaces)
+}
+
+func TestAIGatewayRouteController_reconcileCORSSecurityPolicy(t *testing.T) {
+	c := &AIGatewayRouteController{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	aiGatewayRoute := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			CORS: &egv1a1.CORS{
+				AllowOrigins: []egv1a1.Origin{"https://example.com"},
+				AllowMethods: []string{"POST"},
+			},
+		},
+	}
+	require.Equal(t, "ai-eg-route-cors-myroute", corsSecurityPolicyName(aiGatewayRoute))
+	key := client.ObjectKey{Name: corsSecurityPolicyName(aiGatewayRoute), Namespace: "default"}
+
+	require.NoError(t, c.reconcileCORSSecurityPolicy(t.Context(), aiGatewayRoute))
+	var policy egv1a1.SecurityPolicy
+	require.NoError(t, c.client.Get(t.Context(), key, &policy))
+	require.Equal(t, []metav1.OwnerReference{
+		{APIVersion: "aigateway.envoyproxy.io/v1alpha1", Kind: "AIGatewayRoute", Name: "myroute", Controller: ptr.To(true), BlockOwnerDeletion: ptr.To(true)},
+	}, policy.OwnerReferences)
+	require.Equal(t, aiGatewayRouteLabels(aiGatewayRoute), policy.Labels)
+	require.Equal(t, []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+		LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "myroute"},
+	}}, policy.Spec.TargetRefs)
+	require.Equal(t, aiGatewayRoute.Spec.CORS, policy.Spec.CORS)
+
+	// Update the CORS.
+	aiGatewayRoute.Spec.CORS.AllowHeaders = []string{"authorization"}
+	require.NoError(t, c.reconcileCORSSecurityPolicy(t.Context(), aiGatewayRoute))
+	require.NoError(t, c.client.Get(t.Context(), key, &policy))
+	require.Equal(t, []string{"authorization"}, policy.Spec.CORS.AllowHeaders)
+
+	// The policy is deleted once the CORS is unset, and deleting it again is a no-op.
+	aiGatewayRoute.Spec.CORS = nil
+	require.NoError(t, c.reconcileCORSSecurityPolicy(t.Context(), aiGatewayRoute))
+	require.True(t, apierrors.IsNotFound(c.client.Get(t.Context(), key, &policy)))
+	require.NoError(t, c.reconcileCORSSecurityPolicy(t.Context(), aiGatewayRoute))
+}
+
+func TestAIGatewayRouteController_reconcileTrafficPolicy(t *testing.T) {
+	c := &AIGatewayRouteController{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	aiGatewayRoute := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			LLMRequestCosts: []aigv1a1.LLMRequestCost{{MetadataKey: "llm_total_token", Type: aigv1a1.LLMRequestCostTypeTotalToken}},
+			RateLimit: &aigv1a1.AIGatewayRouteRateLimit{
+				ClientSelectorHeader: "x-user-id",
+				Limits: []aigv1a1.AIGatewayRouteRateLimitRule{
+					{MetadataKey: "llm_total_token", Requests: 1000, Unit: egv1a1.RateLimitUnitHour},
+					{Requests: 10, Unit: egv1a1.RateLimitUnitMinute},
+				},
+			},
+		},
+	}
+	require.Equal(t, "ai-eg-route-traffic-myroute", trafficPolicyName(aiGatewayRoute))
+	key := client.ObjectKey{Name: trafficPolicyName(aiGatewayRoute), Namespace: "default"}
+
+	require.NoError(t, c.reconcileTrafficPolicy(t.Context(), aiGatewayRoute))
+	var policy egv1a1.BackendTrafficPolicy
+	require.NoError(t, c.client.Get(t.Context(), key, &policy))
+	require.Equal(t, []metav1.OwnerReference{
+		{APIVersion: "aigateway.envoyproxy.io/v1alpha1", Kind: "AIGatewayRoute", Name: "myroute", Controller: ptr.To(true), BlockOwnerDeletion: ptr.To(true)},
+	}, policy.OwnerReferences)
+	require.Equal(t, aiGatewayRouteLabels(aiGatewayRoute), policy.Labels)
+	require.Equal(t, []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+		LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "myroute"},
+	}}, policy.Spec.TargetRefs)
+	clientSelectors := []egv1a1.RateLimitSelectCondition{{
+		Headers: []egv1a1.HeaderMatch{{Name: "x-user-id", Type: ptr.To(egv1a1.HeaderMatchDistinct)}},
+	}}
+	require.Equal(t, &egv1a1.RateLimitSpec{
+		Type: egv1a1.GlobalRateLimitType,
+		Global: &egv1a1.GlobalRateLimit{Rules: []egv1a1.RateLimitRule{
+			{
+				ClientSelectors: clientSelectors,
+				Limit:           egv1a1.RateLimitValue{Requests: 1000, Unit: egv1a1.RateLimitUnitHour},
+				Cost: &egv1a1.RateLimitCost{
+					Request: &egv1a1.RateLimitCostSpecifier{From: egv1a1.RateLimitCostFromNumber, Number: ptr.To[uint64](0)},
+					Response: &egv1a1.RateLimitCostSpecifier{
+						From:     egv1a1.RateLimitCostFromMetadata,
+						Metadata: &egv1a1.RateLimitCostMetadata{Namespace: "io.envoy.ai_gateway", Key: "llm_total_token"},
+					},
+				},
+			},
+			{
+				ClientSelectors: clientSelectors,
+				Limit:           egv1a1.RateLimitValue{Requests: 10, Unit: egv1a1.RateLimitUnitMinute},
+			},
+		}},
+	}, policy.Spec.RateLimit)
+
+	// Update the limits.
+	aiGatewayRoute.Spec.RateLimit.ClientSelectorHeader = ""
+	aiGatewayRoute.Spec.RateLimit.Limits = aiGatewayRoute.Spec.RateLimit.Limits[1:]
+	require.NoError(t, c.reconcileTrafficPolicy(t.Context(), aiGatewayRoute))
+	require.NoError(t, c.client.Get(t.Context(), key, &policy))
+	require.Equal(t, []egv1a1.RateLimitRule{
+		{Limit: egv1a1.RateLimitValue{Requests: 10, Unit: egv1a1.RateLimitUnitMinute}},
+	}, policy.Spec.RateLimit.Global.Rules)
+
+	// The metadata key must refer to one of the request costs.
+	aiGatewayRoute.Spec.RateLimit.Limits = []aigv1a1.AIGatewayRouteRateLimitRule{{MetadataKey: "unknown", Requests: 1, Unit: egv1a1.RateLimitUnitDay}}
+	err := c.reconcileTrafficPolicy(t.Context(), aiGatewayRoute)
+	require.ErrorContains(t, err, `rateLimit.limits[0]: metadataKey "unknown" does not match any of llmRequestCosts`)
+
+	// The policy is deleted once the rate limit is unset, and deleting it again is a no-op.
+	aiGatewayRoute.Spec.RateLimit = nil
+	require.NoError(t, c.reconcileTrafficPolicy(t.Context(), aiGatewayRoute))
+	require.True(t, apierrors.IsNotFound(c.client.Get(t.Context(), key, &policy)))
+	require.NoError(t, c.reconcileTrafficPolicy(t.Context(), aiGatewayRoute))
+}
+
+func TestAIGatewayRouteController_reconcileExcludedPaths(t *testing.T) {
+	c := &AIGatewayRouteController{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	require.NoError(t, c.client.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default"},
+		Spec: aigv1a1.AIServiceBackendSpec{
+			BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace]("default")},
+		},
+	}))
+	aiGatewayRoute := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+				{LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Name: "mygateway"}},
+			},
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "backend", Weight: ptr.To[int32](1)}}},
+			},
+		},
+	}
+	key := client.ObjectKey{Name: excludedPathsName(aiGatewayRoute), Namespace: "default"}
+	require.Equal(t, "ai-eg-route-excluded-myroute", key.Name)
+
+	// Nothing is created when no path is excluded.
+	require.NoError(t, c.reconcileExcludedPaths(t.Context(), aiGatewayRoute))
+	var httpRoute gwapiv1.HTTPRoute
+	require.True(t, apierrors.IsNotFound(c.client.Get(t.Context(), key, &httpRoute)))
+	var policy egv1a1.EnvoyExtensionPolicy
+	require.True(t, apierrors.IsNotFound(c.client.Get(t.Context(), key, &policy)))
+
+	aiGatewayRoute.Spec.ExcludePaths = []aigv1a1.AIGatewayRouteExcludePath{
+		{Value: "/health"},
+		{Type: ptr.To(gwapiv1.PathMatchExact), Value: "/metrics"},
+	}
+	require.NoError(t, c.reconcileExcludedPaths(t.Context(), aiGatewayRoute))
+	require.NoError(t, c.client.Get(t.Context(), key, &httpRoute))
+	require.Equal(t, aiGatewayRouteLabels(aiGatewayRoute), httpRoute.Labels)
+	require.Equal(t, `[null]`, httpRoute.Annotations[extensionserver.TrafficPoliciesAnnotationKey])
+	require.Equal(t, []gwapiv1.ParentReference{
+		{Name: "mygateway", Namespace: ptr.To[gwapiv1.Namespace]("default")},
+	}, httpRoute.Spec.ParentRefs)
+	require.Len(t, httpRoute.Spec.Rules, 1)
+	require.Equal(t, []gwapiv1.HTTPRouteMatch{
+		{Path: &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchPathPrefix), Value: ptr.To("/health")}},
+		{Path: &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchExact), Value: ptr.To("/metrics")}},
+	}, httpRoute.Spec.Rules[0].Matches)
+	require.Len(t, httpRoute.Spec.Rules[0].BackendRefs, 1)
+	require.Equal(t, gwapiv1.ObjectName("some-backend"), httpRoute.Spec.Rules[0].BackendRefs[0].Name)
+
+	require.NoError(t, c.client.Get(t.Context(), key, &policy))
+	require.Equal(t, aiGatewayRouteLabels(aiGatewayRoute), policy.Labels)
+	require.Nil(t, policy.Spec.ExtProc)
+	require.Equal(t, []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+		LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+			Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: gwapiv1.ObjectName(key.Name),
+		},
+	}}, policy.Spec.TargetRefs)
+
+	// Updated in place.
+	aiGatewayRoute.Spec.ExcludePaths = aiGatewayRoute.Spec.ExcludePaths[:1]
+	require.NoError(t, c.reconcileExcludedPaths(t.Context(), aiGatewayRoute))
+	require.NoError(t, c.client.Get(t.Context(), key, &httpRoute))
+	require.Len(t, httpRoute.Spec.Rules[0].Matches, 1)
+
+	// Deleted when no path is excluded anymore.
+	aiGatewayRoute.Spec.ExcludePaths = nil
+	require.NoError(t, c.reconcileExcludedPaths(t.Context(), aiGatewayRoute))
+	require.True(t, apierrors.IsNotFound(c.client.Get(t.Context(), key, &httpRoute)))
+	require.True(t, apierrors.IsNotFound(c.client.Get(t.Context(), key, &policy)))
+
+	t.Run("missing backend", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.ExcludePaths = []aigv1a1.AIGatewayRouteExcludePath{{Value: "/health"}}
+		route.Spec.Rules[0].BackendRefs[0].Name = "unknown"
+		err := c.reconcileExcludedPaths(t.Context(), route)
+		require.EqualError(t, err, "AIServiceBackend unknown.default not found")
+		require.Equal(t, EventReasonBackendNotFound, eventReason(err))
+	})
+	t.Run("client error", func(t *testing.T) {
+		// The scheme without the AIServiceBackend fails the client with an error other than NotFound.
+		c := &AIGatewayRouteController{client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.ExcludePaths = []aigv1a1.AIGatewayRouteExcludePath{{Value: "/health"}}
+		err := c.reconcileExcludedPaths(t.Context(), route)
+		require.ErrorContains(t, err, "failed to get AIServiceBackend backend.default")
+		require.Equal(t, EventReasonSyncFailed, eventReason(err))
+	})
+}
+
+func TestAIGatewayRouteController_validateFailOpenDefaultBackend(t *testing.T) {
+	c := &AIGatewayRouteController{client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+	for name, schema := range map[string]aigv1a1.APISchema{"openai": aigv1a1.APISchemaOpenAI, "bedrock": aigv1a1.APISchemaAWSBedrock} {
+		require.NoError(t, c.client.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       aigv1a1.AIServiceBackendSpec{APISchema: aigv1a1.VersionedAPISchema{Name: schema}},
+		}))
+	}
+	newRoute := func(failureMode aigv1a1.AIGatewayFilterConfigFailureMode, defaultBackend string, disableDefaultRoute bool) *aigv1a1.AIGatewayRoute {
+		return &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "bedrock"}, {Name: "openai"}}},
+				},
+				FilterConfig:        &aigv1a1.AIGatewayFilterConfig{FailureMode: failureMode},
+				DefaultBackend:      defaultBackend,
+				DisableDefaultRoute: disableDefaultRoute,
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		route  *aigv1a1.AIGatewayRoute
+		expErr string
+	}{
+		{name: "fail closed", route: newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailClosed, "", false)},
+		{name: "unset", route: newRoute("", "", false)},
+		{name: "openai default backend", route: newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "openai", false)},
+		{name: "default route disabled", route: newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "", true)},
+		{
+			name:   "first backend",
+			route:  newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "", false),
+			expErr: "failureMode FailOpen requires the default backend bedrock to have the OpenAI schema, but got AWSBedrock",
+		},
+		{
+			// The catch-all rule routes to the first backend with a positive weight.
+			name: "zero weight first backend",
+			route: func() *aigv1a1.AIGatewayRoute {
+				r := newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "", false)
+				r.Spec.Rules[0].BackendRefs[0].Weight = ptr.To[int32](0)
+				return r
+			}(),
+		},
+		{
+			// The catch-all rule routes to the backend of the rule with the highest priority.
+			name: "higher priority rule",
+			route: func() *aigv1a1.AIGatewayRoute {
+				r := newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "", false)
+				r.Spec.Rules = []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "openai"}}},
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "bedrock"}}, Priority: ptr.To[int32](1)},
+				}
+				return r
+			}(),
+			expErr: "failureMode FailOpen requires the default backend bedrock to have the OpenAI schema, but got AWSBedrock",
+		},
+		{
+			name:   "missing backend",
+			route:  newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "unknown", false),
+			expErr: "AIServiceBackend unknown.default not found",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := c.validateFailOpenDefaultBackend(t.Context(), tc.route)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("client error", func(t *testing.T) {
+		// The scheme without the AIServiceBackend fails the client with an error other than NotFound.
+		c := &AIGatewayRouteController{client: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).Build()}
+		err := c.validateFailOpenDefaultBackend(t.Context(), newRoute(aigv1a1.AIGatewayFilterConfigFailureModeFailOpen, "openai", false))
+		require.ErrorContains(t, err, "failed to get AIServiceBackend openai.default")
+		require.Equal(t, EventReasonSyncFailed, eventReason(err))
+	})
+}
+
+func TestAIGatewayRouteController_syncExtProcSidecar(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+	})
+
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		TypeMeta:   metav1.TypeMeta{Kind: "AIGatewayRoute"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			FilterConfig: &aigv1a1.AIGatewayFilterConfig{
+				Type: aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
+				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+					DeploymentMode: aigv1a1.AIGatewayFilterConfigExternalProcessorDeploymentModeSidecar,
+					Resources: &corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+					},
+				},
+			},
+		},
+	}
+	name := extProcName(route)
+
+	// The resources of the Deployment mode should be deleted.
+	_, err := kube.AppsV1().Deployments("ns").Create(t.Context(), &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = kube.CoreV1().Services("ns").Create(t.Context(), &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for range 2 { // The second time is the update.
+		require.NoError(t, s.syncExtProcSidecar(t.Context(), route))
+	}
+
+	var envoyProxy egv1a1.EnvoyProxy
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "ns"}, &envoyProxy))
+	require.Len(t, envoyProxy.OwnerReferences, 1)
+	require.Equal(t, "myroute", envoyProxy.OwnerReferences[0].Name)
+	require.Equal(t, "myroute", envoyProxy.Labels[aiGatewayRouteLabel])
+	patch := envoyProxy.Spec.Provider.Kubernetes.EnvoyDeployment.Patch
+	require.Equal(t, egv1a1.StrategicMerge, *patch.Type)
+	var deployment struct {
+		Spec struct {
+			Template struct {
+				Spec corev1.PodSpec `json:"spec"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	require.NoError(t, json.Unmarshal(patch.Value.Raw, &deployment))
+	podSpec := deployment.Spec.Template.Spec
+	socketMount := corev1.VolumeMount{Name: extProcSocketVolumeName, MountPath: "/var/run/ai-gateway"}
+	require.Len(t, podSpec.Containers, 2)
+	require.Equal(t, corev1.Container{Name: "envoy", VolumeMounts: []corev1.VolumeMount{socketMount}}, podSpec.Containers[0])
+	extProc := podSpec.Containers[1]
+	require.Equal(t, name, extProc.Name)
+	require.Equal(t, "defaultExtProcImage", extProc.Image)
+	require.Empty(t, extProc.Ports)
+	require.Contains(t, extProc.Args, "unix:///var/run/ai-gateway/"+name+".sock")
+	require.Contains(t, extProc.VolumeMounts, socketMount)
+	// The resources of the route take precedence over the defaults.
+	require.Equal(t, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}, extProc.Resources.Limits)
+	require.Len(t, podSpec.Volumes, 2)
+	require.Equal(t, extProcConfigVolume(extProcName(route)), podSpec.Volumes[0])
+	require.Equal(t, extProcSocketVolumeName, podSpec.Volumes[1].Name)
+	require.NotNil(t, podSpec.Volumes[1].EmptyDir)
+
+	var backend egv1a1.Backend
+	require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "ns"}, &backend))
+	require.Equal(t, "myroute", backend.OwnerReferences[0].Name)
+	require.Len(t, backend.Spec.Endpoints, 1)
+	require.Equal(t, "/var/run/ai-gateway/"+name+".sock", backend.Spec.Endpoints[0].Unix.Path)
+
+	_, err = kube.AppsV1().Deployments("ns").Get(t.Context(), name, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+	_, err = kube.CoreV1().Services("ns").Get(t.Context(), name, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// Switching back to the Deployment mode deletes the EnvoyProxy and the Backend.
+	for range 2 { // Doing it again should be no-op.
+		require.NoError(t, s.deleteExtProcSidecar(t.Context(), route))
+	}
+	err = fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "ns"}, &envoyProxy)
+	require.True(t, apierrors.IsNotFound(err))
+	err = fakeClient.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "ns"}, &backend)
+	require.True(t, apierrors.IsNotFound(err))
 }
 
 func Test_applyExtProcDeploymentConfigUpdate(t *testing.T) {
@@ -170,11 +900,11 @@ func Test_applyExtProcDeploymentConfigUpdate(t *testing.T) {
 		},
 	}
 	t.Run("not panic", func(_ *testing.T) {
-		applyExtProcDeploymentConfigUpdate(dep, nil)
-		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{})
+		applyExtProcDeploymentConfigUpdate(dep, nil, nil, corev1.ResourceRequirements{})
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{}, nil, corev1.ResourceRequirements{})
 		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
 			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
-		})
+		}, nil, corev1.ResourceRequirements{})
 	})
 	t.Run("update", func(t *testing.T) {
 		req := corev1.ResourceRequirements{
@@ -188,17 +918,175 @@ func Test_applyExtProcDeploymentConfigUpdate(t *testing.T) {
 				Resources: &req,
 				Replicas:  ptr.To[int32](123),
 			},
-		},
-		)
+		}, nil, corev1.ResourceRequirements{})
 		require.Equal(t, req, dep.Template.Spec.Containers[0].Resources)
 		require.Equal(t, int32(123), *dep.Replicas)
 	})
+	t.Run("update pod scheduling", func(t *testing.T) {
+		affinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+				}}},
+			},
+		}}
+		tolerations := []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ai", Effect: corev1.TaintEffectNoSchedule}}
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+				ImagePullSecrets:   []corev1.LocalObjectReference{{Name: "route-secret"}},
+				NodeSelector:       map[string]string{"pool": "ai"},
+				Tolerations:        tolerations,
+				Affinity:           affinity,
+				PodAnnotations:     map[string]string{"foo": "bar"},
+				ServiceAccountName: "extproc",
+			},
+		}, []corev1.LocalObjectReference{{Name: "default-secret"}}, corev1.ResourceRequirements{})
+		require.Equal(t, []corev1.LocalObjectReference{{Name: "route-secret"}}, dep.Template.Spec.ImagePullSecrets)
+		require.Equal(t, map[string]string{"pool": "ai"}, dep.Template.Spec.NodeSelector)
+		require.Equal(t, tolerations, dep.Template.Spec.Tolerations)
+		require.Equal(t, affinity, dep.Template.Spec.Affinity)
+		require.Equal(t, map[string]string{"foo": "bar", extProcPodAnnotationKeysAnnotationKey: "foo"}, dep.Template.Annotations)
+		require.Equal(t, "extproc", dep.Template.Spec.ServiceAccountName)
+	})
+	t.Run("pod annotations", func(t *testing.T) {
+		annotations := map[string]string{"foo": "bar", "baz": "qux"}
+		// The annotations set by others are kept.
+		dep.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "now"}
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{PodAnnotations: annotations},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, map[string]string{
+			"kubectl.kubernetes.io/restartedAt": "now", "foo": "bar", "baz": "qux",
+			extProcPodAnnotationKeysAnnotationKey: "baz,foo",
+		}, dep.Template.Annotations)
+		// The filter config is not aliased.
+		dep.Template.Annotations["foo"] = "changed"
+		require.Equal(t, "bar", annotations["foo"])
+
+		// The annotations removed from the filter config are removed.
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{PodAnnotations: map[string]string{"foo": "bar"}},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, map[string]string{
+			"kubectl.kubernetes.io/restartedAt": "now", "foo": "bar", extProcPodAnnotationKeysAnnotationKey: "foo",
+		}, dep.Template.Annotations)
+		applyExtProcDeploymentConfigUpdate(dep, nil, nil, corev1.ResourceRequirements{})
+		require.Equal(t, map[string]string{"kubectl.kubernetes.io/restartedAt": "now"}, dep.Template.Annotations)
+	})
+	t.Run("deployment strategy", func(t *testing.T) {
+		defaultStrategy := appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxUnavailable: ptr.To(intstr.FromInt32(0)),
+				MaxSurge:       ptr.To(intstr.FromInt32(1)),
+			},
+		}
+		applyExtProcDeploymentConfigUpdate(dep, nil, nil, corev1.ResourceRequirements{})
+		require.Equal(t, defaultStrategy, dep.Strategy)
+		require.Equal(t, int32(10), *dep.RevisionHistoryLimit)
+
+		// The parameters not specified are defaulted.
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+				DeploymentStrategy: &appsv1.DeploymentStrategy{
+					Type:          appsv1.RollingUpdateDeploymentStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: ptr.To(intstr.FromString("50%"))},
+				},
+				RevisionHistoryLimit: ptr.To[int32](2),
+			},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDeployment{
+				MaxUnavailable: ptr.To(intstr.FromInt32(0)),
+				MaxSurge:       ptr.To(intstr.FromString("50%")),
+			},
+		}, dep.Strategy)
+		require.Equal(t, int32(2), *dep.RevisionHistoryLimit)
+
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+				DeploymentStrategy: &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}, dep.Strategy)
+		require.Equal(t, int32(10), *dep.RevisionHistoryLimit)
+
+		// The default is applied again when the strategy is removed.
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, defaultStrategy, dep.Strategy)
+	})
+	t.Run("horizontal pod autoscaler keeps replicas", func(t *testing.T) {
+		dep.Replicas = ptr.To[int32](7)
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+				HorizontalPodAutoscaler: &aigv1a1.AIGatewayFilterConfigExternalProcessorHPA{MaxReplicas: 10},
+			},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, int32(7), *dep.Replicas)
+	})
+	t.Run("otlp endpoint", func(t *testing.T) {
+		dep.Template.Spec.Containers[0].Args = []string{"-configPath", "/etc/config.yaml"}
+		for _, endpoint := range []string{"otel-collector:4317", "otel-collector-2:4317"} {
+			applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{OTLPEndpoint: endpoint},
+			}, nil, corev1.ResourceRequirements{})
+			require.Equal(t, []string{"-configPath", "/etc/config.yaml", "-otlpEndpoint", endpoint}, dep.Template.Spec.Containers[0].Args)
+		}
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
+		}, nil, corev1.ResourceRequirements{})
+		require.Equal(t, []string{"-configPath", "/etc/config.yaml"}, dep.Template.Spec.Containers[0].Args)
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{OTLPEndpoint: "otel-collector:4317"},
+		}, nil, corev1.ResourceRequirements{})
+		applyExtProcDeploymentConfigUpdate(dep, nil, nil, corev1.ResourceRequirements{})
+		require.Equal(t, []string{"-configPath", "/etc/config.yaml"}, dep.Template.Spec.Containers[0].Args)
+	})
+	t.Run("default image pull secrets", func(t *testing.T) {
+		defaultSecrets := []corev1.LocalObjectReference{{Name: "default-secret"}}
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
+		}, defaultSecrets, corev1.ResourceRequirements{})
+		require.Equal(t, defaultSecrets, dep.Template.Spec.ImagePullSecrets)
+		applyExtProcDeploymentConfigUpdate(dep, nil, defaultSecrets, corev1.ResourceRequirements{})
+		require.Equal(t, defaultSecrets, dep.Template.Spec.ImagePullSecrets)
+	})
+	t.Run("default resources", func(t *testing.T) {
+		defaultResources := corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+		}
+		applyExtProcDeploymentConfigUpdate(dep, nil, nil, defaultResources)
+		require.Equal(t, defaultResources, dep.Template.Spec.Containers[0].Resources)
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
+		}, nil, defaultResources)
+		require.Equal(t, defaultResources, dep.Template.Spec.Containers[0].Resources)
+
+		// The resources of the route take precedence as a whole over the defaults.
+		routeResources := corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+		}
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{Resources: &routeResources},
+		}, nil, defaultResources)
+		require.Equal(t, routeResources, dep.Template.Spec.Containers[0].Resources)
+
+		// The defaults are applied again when the resources of the route are removed.
+		applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
+			ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
+		}, nil, defaultResources)
+		require.Equal(t, defaultResources, dep.Template.Spec.Containers[0].Resources)
+	})
 	t.Run("remove partial config", func(t *testing.T) {
 		t.Run("replicas", func(t *testing.T) {
 			dep.Replicas = ptr.To[int32](123)
 			applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
 				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{},
-			})
+			}, nil, corev1.ResourceRequirements{})
 			require.Nil(t, dep.Replicas)
 		})
 		t.Run("resources", func(t *testing.T) {
@@ -211,7 +1099,7 @@ func Test_applyExtProcDeploymentConfigUpdate(t *testing.T) {
 			dep.Replicas = ptr.To[int32](123)
 			applyExtProcDeploymentConfigUpdate(dep, &aigv1a1.AIGatewayFilterConfig{
 				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{Replicas: ptr.To[int32](123)},
-			})
+			}, nil, corev1.ResourceRequirements{})
 			require.Empty(t, dep.Template.Spec.Containers[0].Resources.Limits)
 			require.Empty(t, dep.Template.Spec.Containers[0].Resources.Requests)
 			require.Equal(t, int32(123), *dep.Replicas)
@@ -230,16 +1118,22 @@ func Test_applyExtProcDeploymentConfigUpdate(t *testing.T) {
 					corev1.ResourceMemory: resource.MustParse("50Mi"),
 				},
 			}
-			applyExtProcDeploymentConfigUpdate(dep, c)
+			dep.Template.Spec.NodeSelector = map[string]string{"pool": "ai"}
+			dep.Template.Spec.ServiceAccountName = "extproc"
+			dep.Template.Annotations = map[string]string{"foo": "bar", extProcPodAnnotationKeysAnnotationKey: "foo"}
+			applyExtProcDeploymentConfigUpdate(dep, c, nil, corev1.ResourceRequirements{})
 			require.Nil(t, dep.Replicas)
 			require.Empty(t, dep.Template.Spec.Containers[0].Resources.Limits)
 			require.Empty(t, dep.Template.Spec.Containers[0].Resources.Requests)
+			require.Empty(t, dep.Template.Spec.NodeSelector)
+			require.Empty(t, dep.Template.Spec.ServiceAccountName)
+			require.Empty(t, dep.Template.Annotations)
 		}
 	})
 }
 
 func requireNewFakeClientWithIndexes(t *testing.T) client.Client {
-	builder := fake.NewClientBuilder().WithScheme(scheme)
+	builder := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&aigv1a1.AIGatewayRoute{}, &aigv1a1.AIServiceBackend{}, &aigv1a1.BackendSecurityPolicy{})
 	err := ApplyIndexing(t.Context(), func(_ context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
 		builder = builder.WithIndex(obj, field, extractValue)
 		return nil
@@ -252,7 +1146,7 @@ func TestAIGatewayRouterController_syncAIGatewayRoute(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
 
-	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), "defaultExtProcImage", "debug")
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
 	require.NotNil(t, s)
 
 	for _, backend := range []*aigv1a1.AIServiceBackend{
@@ -273,7 +1167,7 @@ func TestAIGatewayRouterController_syncAIGatewayRoute(t *testing.T) {
 			Spec: aigv1a1.AIGatewayRouteSpec{
 				Rules: []aigv1a1.AIGatewayRouteRule{
 					{
-						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple", Weight: 1}, {Name: "orange", Weight: 1}},
+						BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple", Weight: ptr.To[int32](1)}, {Name: "orange", Weight: ptr.To[int32](1)}},
 					},
 				},
 				APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: "v123"},
@@ -319,7 +1213,7 @@ func TestAIGatewayRouterController_syncAIGatewayRoute(t *testing.T) {
 
 func Test_newHTTPRoute(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
-	s := NewAIGatewayRouteController(fakeClient, nil, logr.Discard(), "defaultExtProcImage", "debug")
+	s := NewAIGatewayRouteController(fakeClient, nil, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
 	httpRoute := &gwapiv1.HTTPRoute{
 		ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: "ns1"},
 		Spec:       gwapiv1.HTTPRouteSpec{},
@@ -336,17 +1230,17 @@ func Test_newHTTPRoute(t *testing.T) {
 			},
 			Rules: []aigv1a1.AIGatewayRouteRule{
 				{
-					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple", Weight: 100}},
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple", Weight: ptr.To[int32](100)}},
 				},
 				{
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "orange", Weight: 100},
-						{Name: "apple", Weight: 100},
-						{Name: "pineapple", Weight: 100},
+						{Name: "orange", Weight: ptr.To[int32](100)},
+						{Name: "apple", Weight: ptr.To[int32](100)},
+						{Name: "pineapple", Weight: ptr.To[int32](100)},
 					},
 				},
 				{
-					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "foo", Weight: 1}},
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "foo", Weight: ptr.To[int32](1)}},
 				},
 			},
 		},
@@ -361,7 +1255,8 @@ func Test_newHTTPRoute(t *testing.T) {
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "orange", Namespace: "ns1"},
 			Spec: aigv1a1.AIServiceBackendSpec{
-				BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend2", Namespace: ptr.To[gwapiv1.Namespace]("ns1")},
+				BackendRef:    gwapiv1.BackendObjectReference{Name: "some-backend2", Namespace: ptr.To[gwapiv1.Namespace]("ns1")},
+				TrafficPolicy: &aigv1a1.AIServiceBackendTrafficPolicy{CircuitBreaker: &egv1a1.CircuitBreaker{MaxConnections: ptr.To[int64](10)}},
 			},
 		},
 		{
@@ -388,28 +1283,39 @@ func Test_newHTTPRoute(t *testing.T) {
 			Matches: []gwapiv1.HTTPRouteMatch{
 				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: selectedBackendHeaderKey, Value: "apple.ns1"}}},
 			},
-			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend1", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}}}},
+			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{
+				BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend1", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}, Weight: ptr.To[int32](100),
+			}}},
 		},
 		{
 			Matches: []gwapiv1.HTTPRouteMatch{
 				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: selectedBackendHeaderKey, Value: "orange.ns1"}}},
 			},
-			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend2", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}}}},
+			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{
+				BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend2", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}, Weight: ptr.To[int32](100),
+			}}},
 		},
 		{
 			Matches: []gwapiv1.HTTPRouteMatch{
 				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: selectedBackendHeaderKey, Value: "pineapple.ns1"}}},
 			},
-			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend3", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}}}},
+			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{
+				BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend3", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}, Weight: ptr.To[int32](100),
+			}}},
 		},
 		{
 			Matches: []gwapiv1.HTTPRouteMatch{
 				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: selectedBackendHeaderKey, Value: "foo.ns1"}}},
 			},
-			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend4", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}}}},
+			BackendRefs: []gwapiv1.HTTPBackendRef{{BackendRef: gwapiv1.BackendRef{
+				BackendObjectReference: gwapiv1.BackendObjectReference{Name: "some-backend4", Namespace: ptr.To[gwapiv1.Namespace]("ns1")}, Weight: ptr.To[int32](1),
+			}}},
 		},
 	}
 	require.Len(t, httpRoute.Spec.Rules, 5) // 4 backends + 1 for the default rule.
+	// The traffic policies are indexed by the rule, including the default rule.
+	require.JSONEq(t, `[null,{"circuitBreaker":{"maxConnections":10}},null,null,null]`,
+		httpRoute.Annotations[extensionserver.TrafficPoliciesAnnotationKey])
 	for i, r := range httpRoute.Spec.Rules {
 		t.Run(fmt.Sprintf("rule-%d", i), func(t *testing.T) {
 			if i == 4 {
@@ -428,13 +1334,462 @@ func Test_newHTTPRoute(t *testing.T) {
 			require.Equal(t, hostRewriteHTTPFilterName, string(r.Filters[0].ExtensionRef.Name))
 		})
 	}
+
+	t.Run("override error responses", func(t *testing.T) {
+		require.NotContains(t, httpRoute.Labels, extensionserver.OverrideErrorResponsesLabelKey)
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.OverrideErrorResponses = true
+		route.Spec.APISchema.Name = aigv1a1.APISchemaOpenAI
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Equal(t, "true", httpRoute.Labels[extensionserver.OverrideErrorResponsesLabelKey])
+
+		// The label is removed once the override is disabled, and the errors of the other schemas are not overridden.
+		route.Spec.APISchema.Name = aigv1a1.APISchemaAWSBedrock
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.NotContains(t, httpRoute.Labels, extensionserver.OverrideErrorResponsesLabelKey)
+	})
+	t.Run("default backend", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.DefaultBackend = "pineapple"
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 5)
+		require.Equal(t, expRules[2].BackendRefs, httpRoute.Spec.Rules[4].BackendRefs)
+
+		route.Spec.DefaultBackend = "unknown"
+		require.EqualError(t, s.newHTTPRoute(t.Context(), httpRoute, route), "default backend unknown is not referenced by any rule")
+	})
+	t.Run("zero weight", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		// apple has zero weight in the first rule but not in the second one.
+		route.Spec.Rules[0].BackendRefs[0].Weight = ptr.To[int32](0)
+		route.Spec.Rules[1].BackendRefs[0].Weight = ptr.To[int32](0)
+		route.Spec.Rules[2].BackendRefs[0].Weight = ptr.To[int32](0)
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 5)
+		for i, exp := range []int32{100, 0, 100, 0} {
+			require.Equal(t, ptr.To(exp), httpRoute.Spec.Rules[i].BackendRefs[0].Weight, i)
+		}
+		// The catch-all rule routes to the first backend with a positive weight.
+		require.Equal(t, "some-backend1", string(httpRoute.Spec.Rules[4].BackendRefs[0].Name))
+		require.Equal(t, ptr.To[int32](100), httpRoute.Spec.Rules[4].BackendRefs[0].Weight)
+
+		route.Spec.Rules[1].BackendRefs[1].Weight = ptr.To[int32](0)
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Equal(t, "some-backend3", string(httpRoute.Spec.Rules[4].BackendRefs[0].Name))
+
+		// The catch-all rule fails closed when all the backends have zero weight.
+		route.Spec.Rules[1].BackendRefs[2].Weight = ptr.To[int32](0)
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Equal(t, "some-backend1", string(httpRoute.Spec.Rules[4].BackendRefs[0].Name))
+		require.Equal(t, ptr.To[int32](0), httpRoute.Spec.Rules[4].BackendRefs[0].Weight)
+	})
+	t.Run("default weight", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.Rules[2].BackendRefs[0].Weight = nil
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Equal(t, ptr.To[int32](1), httpRoute.Spec.Rules[3].BackendRefs[0].Weight)
+
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Equal(t, 1, ec.Rules[2].Backends[0].Weight)
+	})
+	t.Run("priority", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.Rules[0].Priority = ptr.To[int32](1)
+		route.Spec.Rules[1].Priority = ptr.To[int32](-1)
+		route.Spec.Rules[2].Priority = ptr.To[int32](10)
+		// The orderings are the same regardless of the order of the list.
+		for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 0, 2}, {1, 2, 0}} {
+			shuffled := route.DeepCopy()
+			for k, i := range order {
+				shuffled.Spec.Rules[k] = route.Spec.Rules[i]
+			}
+			require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, shuffled))
+			require.Len(t, httpRoute.Spec.Rules, 5)
+			var names []string
+			for _, rule := range httpRoute.Spec.Rules {
+				names = append(names, string(rule.BackendRefs[0].Name))
+			}
+			// The catch-all rule routes to the first backend of the rule with the greatest priority.
+			require.Equal(t, []string{"some-backend4", "some-backend1", "some-backend2", "some-backend3", "some-backend4"}, names, order)
+
+			ec, err := NewFilterConfig(t.Context(), s.client, shuffled, "uuid")
+			require.NoError(t, err)
+			var backends []string
+			var priorities []int
+			for _, rule := range ec.Rules {
+				backends = append(backends, rule.Backends[0].Name)
+				priorities = append(priorities, rule.Priority)
+			}
+			// The default rule selects the same backend as the catch-all rule.
+			require.Equal(t, []string{"foo.ns1", "apple.ns1", "orange.ns1", "foo.ns1"}, backends, order)
+			require.Equal(t, []int{10, 1, -1, 0}, priorities, order)
+			require.True(t, ec.Rules[3].Default)
+		}
+
+		// The rules with the same priority keep the order of the list.
+		route.Spec.Rules[2].Priority = ptr.To[int32](1)
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Equal(t, "apple.ns1", ec.Rules[0].Backends[0].Name)
+		require.Equal(t, "foo.ns1", ec.Rules[1].Backends[0].Name)
+		require.Equal(t, "orange.ns1", ec.Rules[2].Backends[0].Name)
+	})
+	t.Run("default route disabled", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.DisableDefaultRoute = true
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 5)
+		defaultRule := httpRoute.Spec.Rules[4]
+		require.Equal(t, "/", *defaultRule.Matches[0].Path.Value)
+		require.Empty(t, defaultRule.BackendRefs)
+		require.Len(t, defaultRule.Filters, 1)
+	})
+	t.Run("hostname rewrite disabled", func(t *testing.T) {
+		require.NoError(t, s.client.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "grape", Namespace: "ns1"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				BackendRef:      gwapiv1.BackendObjectReference{Name: "some-backend6", Namespace: ptr.To[gwapiv1.Namespace]("ns1")},
+				HostnameRewrite: aigv1a1.HostnameRewriteDisabled,
+			},
+		}))
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.Rules = append(route.Spec.Rules, aigv1a1.AIGatewayRouteRule{
+			BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "grape", Weight: ptr.To[int32](1)}},
+		})
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 6)
+		for _, r := range httpRoute.Spec.Rules[:4] {
+			require.Len(t, r.Filters, 1)
+		}
+		require.Empty(t, httpRoute.Spec.Rules[4].Filters)
+		// The default rule routing to the backend with the rewrite enabled keeps the filter.
+		require.Len(t, httpRoute.Spec.Rules[5].Filters, 1)
+
+		route.Spec.DefaultBackend = "grape"
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Equal(t, "some-backend6", string(httpRoute.Spec.Rules[5].BackendRefs[0].Name))
+		require.Empty(t, httpRoute.Spec.Rules[5].Filters)
+	})
+	t.Run("section name", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.TargetRefs[0].SectionName = ptr.To[gwapiv1.SectionName]("https")
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Equal(t, []gwapiv1.ParentReference{
+			{Name: "gtw", Namespace: ptr.To[gwapiv1.Namespace]("ns1"), SectionName: ptr.To[gwapiv1.SectionName]("https")},
+		}, httpRoute.Spec.ParentRefs)
+	})
+	t.Run("path prefix", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.PathPrefix = "/llm"
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 5)
+		require.Equal(t, "/llm", *httpRoute.Spec.Rules[4].Matches[0].Path.Value)
+
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Equal(t, "/llm", ec.PathPrefix)
+	})
+	t.Run("request id propagation", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.False(t, ec.RequestIDPropagationDisabled)
+
+		route.Spec.DisableRequestIDPropagation = true
+		ec, err = NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.True(t, ec.RequestIDPropagationDisabled)
+	})
+	t.Run("config version header", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.EmitConfigVersionHeader = true
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.True(t, ec.EmitConfigVersionHeader)
+		require.Equal(t, "uuid", ec.UUID)
+	})
+	t.Run("allowed endpoints", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Empty(t, ec.AllowedPaths)
+
+		route.Spec.AllowedEndpoints = []aigv1a1.AIGatewayRouteEndpoint{
+			aigv1a1.AIGatewayRouteEndpointChatCompletions, aigv1a1.AIGatewayRouteEndpointConverse,
+		}
+		ec, err = NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Equal(t, []string{"/v1/chat/completions", "/model/{modelId}/converse"}, ec.AllowedPaths)
+
+		route.Spec.AllowedEndpoints = []aigv1a1.AIGatewayRouteEndpoint{"Images"}
+		_, err = NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.EqualError(t, err, "unknown endpoint: Images")
+	})
+	t.Run("model policy", func(t *testing.T) {
+		route := aiGatewayRoute.DeepCopy()
+		ec, err := NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Nil(t, ec.ModelPolicy)
+
+		route.Spec.ModelPolicy = &aigv1a1.AIGatewayRouteModelPolicy{Allow: []string{"gpt-4o*"}, Deny: []string{"gpt-4o-mini"}}
+		ec, err = NewFilterConfig(t.Context(), s.client, route, "uuid")
+		require.NoError(t, err)
+		require.Equal(t, &filterapi.ModelPolicy{Allow: []string{"gpt-4o*"}, Deny: []string{"gpt-4o-mini"}}, ec.ModelPolicy)
+	})
+	t.Run("mirror", func(t *testing.T) {
+		require.NoError(t, s.client.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "kiwi", Namespace: "ns1"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema:  aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaAWSBedrock},
+				BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend5", Namespace: ptr.To[gwapiv1.Namespace]("ns1")},
+			},
+		}))
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.Rules[0].Mirror = &aigv1a1.AIGatewayRouteRuleMirror{Name: "foo", Percent: ptr.To[int32](10)}
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 5)
+		appleRule := httpRoute.Spec.Rules[0]
+		require.Len(t, appleRule.Filters, 2)
+		require.Equal(t, gwapiv1.HTTPRouteFilterRequestMirror, appleRule.Filters[1].Type)
+		require.Equal(t, &gwapiv1.HTTPRequestMirrorFilter{
+			BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend4", Namespace: ptr.To[gwapiv1.Namespace]("ns1")},
+			Percent:    ptr.To[int32](10),
+		}, appleRule.Filters[1].RequestMirror)
+		fooRule := httpRoute.Spec.Rules[3]
+		require.Len(t, fooRule.Filters, 1)
+
+		// The request translated for the selected backend cannot be mirrored to the backend of a different schema.
+		route.Spec.Rules[2].Mirror = &aigv1a1.AIGatewayRouteRuleMirror{Name: "kiwi"}
+		require.ErrorContains(t, s.newHTTPRoute(t.Context(), httpRoute, route), "mirror AIServiceBackend kiwi.ns1 has the schema AWSBedrock different from")
+
+		route.Spec.Rules[2].Mirror.Name = "unknown"
+		require.EqualError(t, s.newHTTPRoute(t.Context(), httpRoute, route), "mirror AIServiceBackend unknown.ns1 not found")
+	})
+	t.Run("fqdn backend port", func(t *testing.T) {
+		require.NoError(t, s.client.Create(t.Context(), &egv1a1.Backend{
+			ObjectMeta: metav1.ObjectMeta{Name: "fqdn-backend", Namespace: "ns1"},
+			Spec: egv1a1.BackendSpec{
+				Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}},
+			},
+		}))
+		backendRef := gwapiv1.BackendObjectReference{
+			Name: "fqdn-backend", Group: ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"), Kind: ptr.To[gwapiv1.Kind]("Backend"),
+		}
+		require.NoError(t, s.client.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "melon", Namespace: "ns1"},
+			Spec:       aigv1a1.AIServiceBackendSpec{BackendRef: backendRef},
+		}))
+		route := aiGatewayRoute.DeepCopy()
+		route.Spec.Rules = append(route.Spec.Rules, aigv1a1.AIGatewayRouteRule{
+			BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "melon", Weight: ptr.To[int32](1)}},
+		})
+		route.Spec.DefaultBackend = "melon"
+		require.NoError(t, s.newHTTPRoute(t.Context(), httpRoute, route))
+		require.Len(t, httpRoute.Spec.Rules, 6)
+		backendRef.Port = ptr.To[gwapiv1.PortNumber](443)
+		require.Equal(t, backendRef, httpRoute.Spec.Rules[4].BackendRefs[0].BackendObjectReference)
+		require.Equal(t, backendRef, httpRoute.Spec.Rules[5].BackendRefs[0].BackendObjectReference)
+	})
+}
+
+func TestAIGatewayRouteController_updateTargetRefConditions(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewAIGatewayRouteController(fakeClient, nil, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+	require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Spec:       gwapiv1.GatewayClassSpec{ControllerName: egv1a1.GatewayControllerName},
+	}))
+	require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec:       gwapiv1.GatewayClassSpec{ControllerName: "example.com/other-controller"},
+	}))
+	for name, className := range map[string]gwapiv1.ObjectName{"gtw": "eg", "other-gtw": "other"} {
+		require.NoError(t, fakeClient.Create(t.Context(), &gwapiv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns1"},
+			Spec: gwapiv1.GatewaySpec{
+				GatewayClassName: className,
+				Listeners:        []gwapiv1.Listener{{Name: "http", Port: 80, Protocol: gwapiv1.HTTPProtocolType}},
+			},
+		}))
+	}
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: "ns1"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+				{LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Name: "gtw", Kind: "Gateway"}},
+			},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+
+	type expCondition struct {
+		status          metav1.ConditionStatus
+		reason, message string
+	}
+	for _, tc := range []struct {
+		name                         string
+		targetName                   gwapiv1.ObjectName
+		sectionName                  *gwapiv1.SectionName
+		expAccepted, expResolvedRefs expCondition
+	}{
+		{
+			name: "no section name", targetName: "gtw",
+			expAccepted:     expCondition{metav1.ConditionTrue, "Accepted", "All target Gateways are managed by Envoy Gateway"},
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "All target references are resolved"},
+		},
+		{
+			name: "existing section", targetName: "gtw", sectionName: ptr.To[gwapiv1.SectionName]("http"),
+			expAccepted:     expCondition{metav1.ConditionTrue, "Accepted", "All target Gateways are managed by Envoy Gateway"},
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "All target references are resolved"},
+		},
+		{
+			name: "missing section", targetName: "gtw", sectionName: ptr.To[gwapiv1.SectionName]("https"),
+			expAccepted:     expCondition{metav1.ConditionTrue, "Accepted", "All target Gateways are managed by Envoy Gateway"},
+			expResolvedRefs: expCondition{metav1.ConditionFalse, "NoMatchingParent", "listener https is not found in Gateway gtw"},
+		},
+		{
+			name: "missing gateway", targetName: "unknown", sectionName: ptr.To[gwapiv1.SectionName]("http"),
+			expAccepted:     expCondition{metav1.ConditionFalse, "NoMatchingParent", "Gateway unknown is not found"},
+			expResolvedRefs: expCondition{metav1.ConditionFalse, "NoMatchingParent", "Gateway unknown is not found"},
+		},
+		{
+			name: "missing gateway without section name", targetName: "unknown",
+			expAccepted:     expCondition{metav1.ConditionFalse, "NoMatchingParent", "Gateway unknown is not found"},
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "All target references are resolved"},
+		},
+		{
+			name: "gateway not managed by envoy gateway", targetName: "other-gtw",
+			expAccepted: expCondition{
+				metav1.ConditionFalse, "NoMatchingParent",
+				"Gateway other-gtw belongs to GatewayClass other that is not managed by Envoy Gateway",
+			},
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "All target references are resolved"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			route.Spec.TargetRefs[0].Name = tc.targetName
+			route.Spec.TargetRefs[0].SectionName = tc.sectionName
+			require.NoError(t, c.updateTargetRefConditions(t.Context(), route))
+
+			var updated aigv1a1.AIGatewayRoute
+			require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(route), &updated))
+			require.Len(t, updated.Status.Conditions, 2)
+			for condType, exp := range map[string]expCondition{"Accepted": tc.expAccepted, "ResolvedRefs": tc.expResolvedRefs} {
+				cond := meta.FindStatusCondition(updated.Status.Conditions, condType)
+				require.NotNil(t, cond, condType)
+				require.Equal(t, exp, expCondition{cond.Status, cond.Reason, cond.Message}, condType)
+			}
+		})
+	}
+}
+
+func TestAIGatewayRouteController_updateProgrammedCondition(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	recorder := record.NewFakeRecorder(10)
+	c := NewAIGatewayRouteController(fakeClient, nil, logr.Discard(), recorder, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+	route := &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: "ns1"}}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+	httpRoute := &gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "route1", Namespace: "ns1", Generation: 2}}
+	require.NoError(t, fakeClient.Create(t.Context(), httpRoute))
+
+	parentStatus := func(status metav1.ConditionStatus, generation int64, message string) gwapiv1.RouteParentStatus {
+		return gwapiv1.RouteParentStatus{
+			ParentRef: gwapiv1.ParentReference{Name: "gtw"},
+			Conditions: []metav1.Condition{{
+				Type: "Accepted", Status: status, ObservedGeneration: generation, Reason: "Test", Message: message,
+				LastTransitionTime: metav1.Now(),
+			}},
+		}
+	}
+	for _, tc := range []struct {
+		name       string
+		parents    []gwapiv1.RouteParentStatus
+		expStatus  metav1.ConditionStatus
+		expReason  string
+		expMessage string
+		expEvent   string
+	}{
+		{
+			name:      "no parents",
+			expStatus: metav1.ConditionFalse, expReason: "Pending",
+			expMessage: "HTTPRoute route1 has not been accepted by any parent Gateway yet",
+		},
+		{
+			name:      "stale parent status",
+			parents:   []gwapiv1.RouteParentStatus{parentStatus(metav1.ConditionTrue, 1, "")},
+			expStatus: metav1.ConditionFalse, expReason: "Pending",
+			expMessage: "HTTPRoute route1 has not been accepted by Gateway gtw yet",
+		},
+		{
+			name:      "rejected",
+			parents:   []gwapiv1.RouteParentStatus{parentStatus(metav1.ConditionFalse, 2, "no listener")},
+			expStatus: metav1.ConditionFalse, expReason: "Pending",
+			expMessage: "HTTPRoute route1 is not accepted by Gateway gtw: no listener",
+		},
+		{
+			name:      "accepted",
+			parents:   []gwapiv1.RouteParentStatus{parentStatus(metav1.ConditionTrue, 2, "")},
+			expStatus: metav1.ConditionTrue, expReason: "Accepted",
+			expMessage: "The HTTPRoute is accepted by all the parent Gateways",
+			expEvent:   "Normal Programmed The HTTPRoute is accepted by all the parent Gateways",
+		},
+		{
+			// The event is emitted only when the AIGatewayRoute becomes programmed.
+			name:      "accepted again",
+			parents:   []gwapiv1.RouteParentStatus{parentStatus(metav1.ConditionTrue, 2, "")},
+			expStatus: metav1.ConditionTrue, expReason: "Accepted",
+			expMessage: "The HTTPRoute is accepted by all the parent Gateways",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(httpRoute), httpRoute))
+			httpRoute.Status.Parents = tc.parents
+			require.NoError(t, fakeClient.Update(t.Context(), httpRoute))
+
+			require.NoError(t, c.updateProgrammedCondition(t.Context(), route))
+			var updated aigv1a1.AIGatewayRoute
+			require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(route), &updated))
+			cond := meta.FindStatusCondition(updated.Status.Conditions, "Programmed")
+			require.NotNil(t, cond)
+			require.Equal(t, tc.expStatus, cond.Status)
+			require.Equal(t, tc.expReason, cond.Reason)
+			require.Equal(t, tc.expMessage, cond.Message)
+			if tc.expEvent != "" {
+				require.Equal(t, tc.expEvent, <-recorder.Events)
+			}
+			require.Empty(t, recorder.Events)
+		})
+	}
+}
+
+func TestAIGatewayRouteController_gatewayToAIGatewayRoutes(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewAIGatewayRouteController(fakeClient, nil, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+	for _, r := range []struct{ name, namespace, gateway string }{
+		{"route1", "ns1", "gtw"}, {"route2", "ns1", "gtw"}, {"route3", "ns1", "other"}, {"route4", "ns2", "gtw"},
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: r.name, Namespace: r.namespace},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+					{LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Name: gwapiv1.ObjectName(r.gateway), Kind: "Gateway"}},
+				},
+			},
+		}))
+	}
+	requests := c.gatewayToAIGatewayRoutes(t.Context(), &gwapiv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gtw", Namespace: "ns1"}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "route1", Namespace: "ns1"}},
+		{NamespacedName: types.NamespacedName{Name: "route2", Namespace: "ns1"}},
+	}, requests)
 }
 
 func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
 
-	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), "defaultExtProcImage", "debug")
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
 	require.NoError(t, fakeClient.Create(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret-policy"}}))
 	require.NoError(t, fakeClient.Create(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret-policy-2"}}))
 
@@ -444,7 +1799,9 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 			Spec: aigv1a1.BackendSecurityPolicySpec{
 				Type: aigv1a1.BackendSecurityPolicyTypeAPIKey,
 				APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{
-					SecretRef: &gwapiv1.SecretObjectReference{Name: "some-secret-policy", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
+					SecretRef:     &gwapiv1.SecretObjectReference{Name: "some-secret-policy", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
+					HeaderName:    ptr.To("x-api-key"),
+					ValueTemplate: ptr.To("{key}"),
 				},
 			},
 		},
@@ -485,6 +1842,16 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 				},
 				BackendRef:               gwapiv1.BackendObjectReference{Name: "some-backend1", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
 				BackendSecurityPolicyRef: &gwapiv1.LocalObjectReference{Name: "some-backend-security-policy-1"},
+				GuardrailConfig:          &aigv1a1.AWSBedrockGuardrailConfig{Identifier: "some-guardrail", Version: "1", Trace: ptr.To("enabled")},
+				UnsupportedFieldPolicy:   aigv1a1.UnsupportedFieldPolicyWarn,
+				AWSBedrock: &aigv1a1.AIServiceBackendAWSBedrockConfig{
+					BedrockAPI: aigv1a1.AWSBedrockAPIInvokeModel, ModelPrefix: "us.",
+					ModelFamilies: []aigv1a1.AWSBedrockModelFamily{{ModelPrefix: "meta.llama2", NoSystemMessages: true}},
+				},
+				ParameterNormalization: &aigv1a1.ParameterNormalization{
+					Mode: aigv1a1.ParameterNormalizationModeExplicit, TemperatureScale: ptr.To("0.5"), Debug: true,
+				},
+				PathOverride: "/bedrock/{model}/converse",
 			},
 		},
 		{
@@ -492,6 +1859,7 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 			Spec: aigv1a1.AIServiceBackendSpec{
 				BackendRef:               gwapiv1.BackendObjectReference{Name: "some-backend2", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
 				BackendSecurityPolicyRef: &gwapiv1.LocalObjectReference{Name: "some-backend-security-policy-1"},
+				OpenAI:                   &aigv1a1.AIServiceBackendOpenAIConfig{Organization: "org-foo", Project: "proj_bar"},
 			},
 		},
 		{
@@ -534,33 +1902,34 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 					Rules: []aigv1a1.AIGatewayRouteRule{
 						{
 							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-								{Name: "apple", Weight: 1},
-								{Name: "pineapple", Weight: 2},
+								{Name: "apple", Weight: ptr.To[int32](1)},
+								{Name: "pineapple", Weight: ptr.To[int32](2)},
 							},
 							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
 							},
+							SessionAffinity: &aigv1a1.AIGatewayRouteRuleSessionAffinity{Header: "x-user-id"},
 						},
 						{
-							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "cat", Weight: 1}},
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "cat", Weight: ptr.To[int32](1)}},
 							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}}},
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}}},
 							},
 						},
 						{
 							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-								{Name: "pen", Weight: 2},
+								{Name: "pen", Weight: ptr.To[int32](2)},
 							},
 							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-2"}}},
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-2"}}},
 							},
 						},
 						{
 							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-								{Name: "dog", Weight: 1},
+								{Name: "dog", Weight: ptr.To[int32](1)},
 							},
 							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-								{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-3"}}},
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai-3"}}},
 							},
 						},
 					},
@@ -582,11 +1951,25 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 							MetadataKey: "cel-token",
 							CEL:         ptr.To("model == 'cool_model' ?  input_tokens * output_tokens : total_tokens"),
 						},
+						{
+							Type:        aigv1a1.LLMRequestCostTypeModelPriceTable,
+							MetadataKey: "price",
+							ModelPriceTable: &aigv1a1.LLMRequestCostModelPriceTable{
+								Prices: map[string]aigv1a1.LLMRequestCostModelPrice{
+									"gpt-4o*": {InputTokenPrice: "2.5", OutputTokenPrice: "10"},
+								},
+								Default: &aigv1a1.LLMRequestCostModelPrice{InputTokenPrice: "0.1", OutputTokenPrice: "0.2"},
+							},
+						},
 					},
+					EmitCostHeaders:     true,
+					EmitLatencyHeaders:  true,
+					EstimateInputTokens: true,
+					DisableDefaultRoute: true,
 				},
 			},
 			exp: &filterapi.Config{
-				UUID:                     string(uuid2.NewUUID()),
+				RouteName:                "ns/myroute",
 				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v123"},
 				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
 				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
@@ -596,18 +1979,32 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 						Backends: []filterapi.Backend{
 							{Name: "apple.ns", Weight: 1, Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Auth: &filterapi.BackendAuth{
 								APIKey: &filterapi.APIKeyAuth{
-									Filename: "/etc/backend_security_policy/rule0-backref0-some-backend-security-policy-1/apiKey",
+									Filename:      "/etc/backend_security_policy/rule0-backref0-some-backend-security-policy-1/apiKey",
+									HeaderName:    "x-api-key",
+									ValueTemplate: "{key}",
+								},
+							}, GuardrailConfig: &filterapi.GuardrailConfig{Identifier: "some-guardrail", Version: "1", Trace: "enabled"},
+								UnsupportedFieldPolicy: filterapi.UnsupportedFieldPolicyWarn,
+								AWSBedrock: &filterapi.AWSBedrockConfig{
+									BedrockAPI: filterapi.AWSBedrockAPIInvokeModel, ModelPrefix: "us.",
+									ModelFamilies: []filterapi.AWSBedrockModelFamily{{ModelPrefix: "meta.llama2", NoSystemMessages: true}},
 								},
-							}}, {Name: "pineapple.ns", Weight: 2},
+								ParameterNormalization: &filterapi.ParameterNormalization{
+									Mode: filterapi.ParameterNormalizationModeExplicit, TemperatureScale: ptr.To(0.5), Debug: true,
+								},
+								PathOverride: "/bedrock/{model}/converse"}, {Name: "pineapple.ns", Weight: 2},
 						},
-						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}},
+						Headers:         []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}},
+						SessionAffinity: &filterapi.SessionAffinity{HeaderName: "x-user-id"},
 					},
 					{
 						Backends: []filterapi.Backend{{Name: "cat.ns", Weight: 1, Auth: &filterapi.BackendAuth{
 							APIKey: &filterapi.APIKeyAuth{
-								Filename: "/etc/backend_security_policy/rule1-backref0-some-backend-security-policy-1/apiKey",
+								Filename:      "/etc/backend_security_policy/rule1-backref0-some-backend-security-policy-1/apiKey",
+								HeaderName:    "x-api-key",
+								ValueTemplate: "{key}",
 							},
-						}}},
+						}, OpenAI: &filterapi.OpenAIConfig{Organization: "org-foo", Project: "proj_bar"}}},
 						Headers: []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}},
 					},
 					{
@@ -634,6 +2031,182 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 					{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input-token"},
 					{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total-token"},
 					{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel-token", CEL: "model == 'cool_model' ?  input_tokens * output_tokens : total_tokens"},
+					{Type: filterapi.LLMRequestCostTypeModelPriceTable, MetadataKey: "price", ModelPriceTable: &filterapi.ModelPriceTable{
+						Prices:     map[string]filterapi.ModelPrice{"gpt-4o*": {InputTokenPrice: 2.5, OutputTokenPrice: 10}},
+						Default:    &filterapi.ModelPrice{InputTokenPrice: 0.1, OutputTokenPrice: 0.2},
+						Multiplier: 1,
+					}},
+				},
+				EmitCostHeaders:      true,
+				EmitLatencyHeaders:   true,
+				EstimateInputTokens:  true,
+				DefaultRouteDisabled: true,
+			},
+		},
+		{
+			name: "session affinity header is lower cased",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-affinity", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: "v123"},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "cat", Weight: ptr.To[int32](1)}},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}}},
+							},
+							SessionAffinity: &aigv1a1.AIGatewayRouteRuleSessionAffinity{Header: "X-User-Id"},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				RouteName:                "ns/myroute-affinity",
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v123"},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "cat.ns", Weight: 1, Auth: &filterapi.BackendAuth{
+							APIKey: &filterapi.APIKeyAuth{
+								Filename:      "/etc/backend_security_policy/rule0-backref0-some-backend-security-policy-1/apiKey",
+								HeaderName:    "x-api-key",
+								ValueTemplate: "{key}",
+							},
+						}, OpenAI: &filterapi.OpenAIConfig{Organization: "org-foo", Project: "proj_bar"}}},
+						Headers:         []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}},
+						SessionAffinity: &filterapi.SessionAffinity{HeaderName: "x-user-id"},
+					},
+					{
+						// The default rule has the same auth as the first reference to the backend.
+						Headers: []filterapi.HeaderMatch{},
+						Backends: []filterapi.Backend{{Name: "cat.ns", Weight: 1, Auth: &filterapi.BackendAuth{
+							APIKey: &filterapi.APIKeyAuth{
+								Filename:      "/etc/backend_security_policy/rule0-backref0-some-backend-security-policy-1/apiKey",
+								HeaderName:    "x-api-key",
+								ValueTemplate: "{key}",
+							},
+						}, OpenAI: &filterapi.OpenAIConfig{Organization: "org-foo", Project: "proj_bar"}}},
+						Default: true,
+					},
+				},
+			},
+		},
+		{
+			name: "prefix match",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-prefix", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: "v123"},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "pineapple", Weight: ptr.To[int32](1)}},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{
+									Name: aigv1a1.AIModelHeaderKey, Value: "claude-", Type: ptr.To(aigv1a1.AIGatewayRouteRuleHeaderMatchTypePrefix),
+								}}},
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				RouteName:                "ns/myroute-prefix",
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v123"},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "pineapple.ns", Weight: 1}},
+						Headers: []filterapi.HeaderMatch{{
+							Name: aigv1a1.AIModelHeaderKey, Value: "claude-", Type: ptr.To(filterapi.HeaderMatchPrefix),
+						}},
+					},
+					{Headers: []filterapi.HeaderMatch{}, Backends: []filterapi.Backend{{Name: "pineapple.ns", Weight: 1}}, Default: true},
+				},
+			},
+		},
+		{
+			name: "custom model header and metadata namespace",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-custom-header", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema:         aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: "v123"},
+					ModelHeaderName:   "X-Tenant-Model",
+					MetadataNamespace: "io.example.tenant_a",
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "pineapple", Weight: ptr.To[int32](1)}},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: "x-tenant-id", Value: "a"}}},
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				RouteName:                "ns/myroute-custom-header",
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v123"},
+				ModelNameHeaderKey:       "x-tenant-model",
+				MetadataNamespace:        "io.example.tenant_a",
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "pineapple.ns", Weight: 1}},
+						Headers: []filterapi.HeaderMatch{
+							{Name: "x-tenant-model", Value: "some-ai"},
+							{Name: "x-tenant-id", Value: "a"},
+						},
+					},
+					{Headers: []filterapi.HeaderMatch{}, Backends: []filterapi.Backend{{Name: "pineapple.ns", Weight: 1}}, Default: true},
+				},
+			},
+		},
+		{
+			name: "model defaults and limits",
+			route: &aigv1a1.AIGatewayRoute{
+				ObjectMeta: metav1.ObjectMeta{Name: "myroute-model-params", Namespace: "ns"},
+				Spec: aigv1a1.AIGatewayRouteSpec{
+					APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI, Version: "v123"},
+					Rules: []aigv1a1.AIGatewayRouteRule{
+						{
+							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "pineapple", Weight: ptr.To[int32](1)}},
+							Matches: []aigv1a1.AIGatewayRouteRuleMatch{
+								{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "llama"}}},
+							},
+							ModelDefaults: &aigv1a1.AIGatewayRouteRuleModelDefaults{
+								Temperature: ptr.To("0.2"), TopP: ptr.To("0.9"), MaxTokens: ptr.To[int64](1024),
+							},
+							ModelLimits: &aigv1a1.AIGatewayRouteRuleModelLimits{
+								MaxTemperature: ptr.To("1"), MaxTopP: ptr.To("0.95"), MaxTokens: ptr.To[int64](4096),
+								MaxStopSequences: ptr.To[int32](4), Strict: true,
+							},
+						},
+					},
+				},
+			},
+			exp: &filterapi.Config{
+				RouteName:                "ns/myroute-model-params",
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v123"},
+				ModelNameHeaderKey:       aigv1a1.AIModelHeaderKey,
+				MetadataNamespace:        aigv1a1.AIGatewayFilterMetadataNamespace,
+				SelectedBackendHeaderKey: selectedBackendHeaderKey,
+				Rules: []filterapi.RouteRule{
+					{
+						Backends: []filterapi.Backend{{Name: "pineapple.ns", Weight: 1}},
+						Headers:  []filterapi.HeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "llama"}},
+						ModelDefaults: &filterapi.ModelDefaults{
+							Temperature: ptr.To(0.2), TopP: ptr.To(0.9), MaxTokens: ptr.To[int64](1024),
+						},
+						ModelLimits: &filterapi.ModelLimits{
+							MaxTemperature: ptr.To(1.0), MaxTopP: ptr.To(0.95), MaxTokens: ptr.To[int64](4096),
+							MaxStopSequences: ptr.To(4), Strict: true,
+						},
+					},
+					{Headers: []filterapi.HeaderMatch{}, Backends: []filterapi.Backend{{Name: "pineapple.ns", Weight: 1}}, Default: true},
 				},
 			},
 		},
@@ -644,8 +2217,10 @@ func TestAIGatewayRouteController_updateExtProcConfigMap(t *testing.T) {
 			}, metav1.CreateOptions{})
 			require.NoError(t, err)
 
-			err = s.updateExtProcConfigMap(t.Context(), tc.route, tc.exp.UUID)
+			uuid, err := s.updateExtProcConfigMap(t.Context(), tc.route)
 			require.NoError(t, err)
+			require.NotEmpty(t, uuid)
+			tc.exp.UUID = uuid
 
 			cm, err := s.kube.CoreV1().ConfigMaps(tc.route.Namespace).Get(t.Context(), extProcName(tc.route), metav1.GetOptions{})
 			require.NoError(t, err)
@@ -664,7 +2239,7 @@ func TestAIGatewayRouteController_syncExtProcDeployment(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
 
-	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), "envoyproxy/ai-gateway-extproc:foo", "debug")
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "envoyproxy/ai-gateway-extproc:foo", "", "debug", corev1.ResourceRequirements{})
 	err := fakeClient.Create(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret-policy"}})
 	require.NoError(t, err)
 
@@ -733,17 +2308,17 @@ func TestAIGatewayRouteController_syncExtProcDeployment(t *testing.T) {
 			Rules: []aigv1a1.AIGatewayRouteRule{
 				{
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "apple", Weight: 1},
-						{Name: "pineapple", Weight: 2},
+						{Name: "apple", Weight: ptr.To[int32](1)},
+						{Name: "pineapple", Weight: ptr.To[int32](2)},
 					},
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
+						{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
 					},
 				},
 				{
-					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "cat", Weight: 1}},
+					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "cat", Weight: ptr.To[int32](1)}},
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}}},
+						{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "another-ai"}}},
 					},
 				},
 			},
@@ -824,12 +2399,212 @@ func TestAIGatewayRouteController_syncExtProcDeployment(t *testing.T) {
 	})
 }
 
+func TestAIGatewayRouteController_syncExtProcDeployment_containerUpdate(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		TypeMeta:   metav1.TypeMeta{Kind: "AIGatewayRoute"},
+	}
+	getContainer := func(t *testing.T) corev1.Container {
+		deployment, err := kube.AppsV1().Deployments("ns").Get(t.Context(), extProcName(route), metav1.GetOptions{})
+		require.NoError(t, err)
+		return deployment.Spec.Template.Spec.Containers[0]
+	}
+	countUpdates := func() (n int) {
+		for _, a := range kube.Actions() {
+			if a.GetVerb() == "update" && a.GetResource().Resource == "deployments" {
+				n++
+			}
+		}
+		return
+	}
+
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "extproc:a", "", "info", corev1.ResourceRequirements{})
+	require.NoError(t, s.syncExtProcDeployment(t.Context(), route))
+	require.Equal(t, "extproc:a", getContainer(t).Image)
+
+	// Reconciling the same state must not update the deployment.
+	require.NoError(t, s.syncExtProcDeployment(t.Context(), route))
+	require.Zero(t, countUpdates())
+
+	s = NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "extproc:b", "", "debug", corev1.ResourceRequirements{})
+	require.NoError(t, s.syncExtProcDeployment(t.Context(), route))
+	require.Equal(t, 1, countUpdates())
+	container := getContainer(t)
+	require.Equal(t, "extproc:b", container.Image)
+	require.Equal(t, []string{
+		"-configPath", "/etc/ai-gateway/extproc/extproc-config.yaml", "-logLevel", "debug", "-debugAddr", "localhost:1064",
+		"-metricsAddr", ":1065",
+	}, container.Args)
+}
+
+func TestAIGatewayRouteController_syncExtProcHPA(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		TypeMeta:   metav1.TypeMeta{Kind: "AIGatewayRoute"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			FilterConfig: &aigv1a1.AIGatewayFilterConfig{
+				Type: aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
+				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+					HorizontalPodAutoscaler: &aigv1a1.AIGatewayFilterConfigExternalProcessorHPA{
+						MinReplicas: ptr.To[int32](2),
+						MaxReplicas: 10,
+					},
+				},
+			},
+		},
+	}
+	getHPA := func(t *testing.T) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+		return kube.AutoscalingV2().HorizontalPodAutoscalers("ns").Get(t.Context(), extProcName(route), metav1.GetOptions{})
+	}
+
+	t.Run("create", func(t *testing.T) {
+		require.NoError(t, s.syncExtProcHPA(t.Context(), route))
+		hpa, err := getHPA(t)
+		require.NoError(t, err)
+		require.Equal(t, autoscalingv2.CrossVersionObjectReference{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: extProcName(route),
+		}, hpa.Spec.ScaleTargetRef)
+		require.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+		require.Equal(t, int32(10), hpa.Spec.MaxReplicas)
+		require.Empty(t, hpa.Spec.Metrics)
+		require.Len(t, hpa.OwnerReferences, 1)
+		require.Equal(t, "myroute", hpa.OwnerReferences[0].Name)
+	})
+	t.Run("update", func(t *testing.T) {
+		metrics := []autoscalingv2.MetricSpec{{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name:   corev1.ResourceCPU,
+				Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: ptr.To[int32](60)},
+			},
+		}}
+		route.Spec.FilterConfig.ExternalProcessor.HorizontalPodAutoscaler = &aigv1a1.AIGatewayFilterConfigExternalProcessorHPA{
+			MaxReplicas: 20,
+			Metrics:     metrics,
+		}
+		require.NoError(t, s.syncExtProcHPA(t.Context(), route))
+		hpa, err := getHPA(t)
+		require.NoError(t, err)
+		require.Nil(t, hpa.Spec.MinReplicas)
+		require.Equal(t, int32(20), hpa.Spec.MaxReplicas)
+		require.Equal(t, metrics, hpa.Spec.Metrics)
+	})
+	t.Run("delete", func(t *testing.T) {
+		route.Spec.FilterConfig.ExternalProcessor.HorizontalPodAutoscaler = nil
+		require.NoError(t, s.syncExtProcHPA(t.Context(), route))
+		_, err := getHPA(t)
+		require.True(t, apierrors.IsNotFound(err))
+
+		// Doing it again should be no-op.
+		require.NoError(t, s.syncExtProcHPA(t.Context(), route))
+		route.Spec.FilterConfig = nil
+		require.NoError(t, s.syncExtProcHPA(t.Context(), route))
+	})
+}
+
+func TestAIGatewayRouteController_syncExtProcPDB(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		TypeMeta:   metav1.TypeMeta{Kind: "AIGatewayRoute"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			FilterConfig: &aigv1a1.AIGatewayFilterConfig{
+				Type: aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
+				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+					Replicas: ptr.To[int32](3),
+					PodDisruptionBudget: &aigv1a1.AIGatewayFilterConfigExternalProcessorPDB{
+						MinAvailable: ptr.To(intstr.FromInt32(2)),
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+	getPDB := func(t *testing.T) (*policyv1.PodDisruptionBudget, error) {
+		return kube.PolicyV1().PodDisruptionBudgets("ns").Get(t.Context(), extProcName(route), metav1.GetOptions{})
+	}
+	requireCondition := func(t *testing.T, status metav1.ConditionStatus, reason string) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(route), &r))
+		cond := meta.FindStatusCondition(r.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed)
+		require.NotNil(t, cond)
+		require.Equal(t, status, cond.Status)
+		require.Equal(t, reason, cond.Reason)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		require.NoError(t, s.syncExtProcPDB(t.Context(), route))
+		pdb, err := getPDB(t)
+		require.NoError(t, err)
+		require.Equal(t, ptr.To(intstr.FromInt32(2)), pdb.Spec.MinAvailable)
+		require.Nil(t, pdb.Spec.MaxUnavailable)
+		require.Equal(t, map[string]string{"app": extProcName(route), managedByLabel: managedByLabelValue}, pdb.Spec.Selector.MatchLabels)
+		require.Len(t, pdb.OwnerReferences, 1)
+		require.Equal(t, "myroute", pdb.OwnerReferences[0].Name)
+		requireCondition(t, metav1.ConditionTrue, aigv1a1.AIGatewayRouteReasonReplicated)
+	})
+	t.Run("update to single replica", func(t *testing.T) {
+		route.Spec.FilterConfig.ExternalProcessor.Replicas = nil
+		route.Spec.FilterConfig.ExternalProcessor.PodDisruptionBudget = &aigv1a1.AIGatewayFilterConfigExternalProcessorPDB{
+			MaxUnavailable: ptr.To(intstr.FromString("50%")),
+		}
+		require.NoError(t, s.syncExtProcPDB(t.Context(), route))
+		pdb, err := getPDB(t)
+		require.NoError(t, err)
+		require.Nil(t, pdb.Spec.MinAvailable)
+		require.Equal(t, ptr.To(intstr.FromString("50%")), pdb.Spec.MaxUnavailable)
+		requireCondition(t, metav1.ConditionFalse, aigv1a1.AIGatewayRouteReasonSingleReplica)
+	})
+	t.Run("delete", func(t *testing.T) {
+		route.Spec.FilterConfig.ExternalProcessor.PodDisruptionBudget = nil
+		require.NoError(t, s.syncExtProcPDB(t.Context(), route))
+		_, err := getPDB(t)
+		require.True(t, apierrors.IsNotFound(err))
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(route), &r))
+		require.Nil(t, meta.FindStatusCondition(r.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed))
+
+		// Doing it again should be no-op.
+		require.NoError(t, s.syncExtProcPDB(t.Context(), route))
+		route.Spec.FilterConfig = nil
+		require.NoError(t, s.syncExtProcPDB(t.Context(), route))
+	})
+}
+
+func Test_extProcSingleReplica(t *testing.T) {
+	for _, tc := range []struct {
+		extProc *aigv1a1.AIGatewayFilterConfigExternalProcessor
+		exp     bool
+	}{
+		{extProc: &aigv1a1.AIGatewayFilterConfigExternalProcessor{}, exp: true},
+		{extProc: &aigv1a1.AIGatewayFilterConfigExternalProcessor{Replicas: ptr.To[int32](1)}, exp: true},
+		{extProc: &aigv1a1.AIGatewayFilterConfigExternalProcessor{Replicas: ptr.To[int32](2)}, exp: false},
+		{extProc: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+			HorizontalPodAutoscaler: &aigv1a1.AIGatewayFilterConfigExternalProcessorHPA{MaxReplicas: 1},
+		}, exp: true},
+		{extProc: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
+			HorizontalPodAutoscaler: &aigv1a1.AIGatewayFilterConfigExternalProcessorHPA{MaxReplicas: 3},
+		}, exp: false},
+	} {
+		require.Equal(t, tc.exp, extProcSingleReplica(tc.extProc))
+	}
+}
+
 func TestAIGatewayRouteController_MountBackendSecurityPolicySecrets(t *testing.T) {
 	// Create simple case
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
 
-	c := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), "defaultExtProcImage", "debug")
+	c := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
 	require.NoError(t, fakeClient.Create(t.Context(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "some-secret-policy"}}))
 
 	for _, secret := range []*corev1.Secret{
@@ -929,26 +2704,26 @@ func TestAIGatewayRouteController_MountBackendSecurityPolicySecrets(t *testing.T
 			Rules: []aigv1a1.AIGatewayRouteRule{
 				{
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "apple", Weight: 1},
+						{Name: "apple", Weight: ptr.To[int32](1)},
 					},
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
+						{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai"}}},
 					},
 				},
 				{
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "pineapple", Weight: 1},
+						{Name: "pineapple", Weight: ptr.To[int32](1)},
 					},
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai-2"}}},
+						{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai-2"}}},
 					},
 				},
 				{
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "dog", Weight: 1},
+						{Name: "dog", Weight: ptr.To[int32](1)},
 					},
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{
-						{Headers: []gwapiv1.HTTPHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai-3"}}},
+						{Headers: []aigv1a1.AIGatewayRouteRuleHeaderMatch{{Name: aigv1a1.AIModelHeaderKey, Value: "some-ai-3"}}},
 					},
 				},
 			},
@@ -1026,6 +2801,79 @@ func TestAIGatewayRouteController_MountBackendSecurityPolicySecrets(t *testing.T
 	for _, v := range updatedSpec.Containers[0].VolumeMounts {
 		require.True(t, v.ReadOnly, v.Name)
 	}
+
+	// The state store password is mounted after the backend security policy secrets.
+	aiGateway.Spec.StateStore = &aigv1a1.AIGatewayRouteStateStore{
+		Type: aigv1a1.AIGatewayRouteStateStoreTypeRedis, Address: "redis:6379",
+		PasswordSecretRef: &corev1.LocalObjectReference{Name: "redis-password"},
+	}
+	updatedSpec, err = c.mountBackendSecurityPolicySecrets(t.Context(), &spec, &aiGateway)
+	require.NoError(t, err)
+	require.Len(t, updatedSpec.Volumes, 5)
+	require.Len(t, updatedSpec.Containers[0].VolumeMounts, 5)
+	require.Equal(t, "redis-password", updatedSpec.Volumes[4].VolumeSource.Secret.SecretName)
+	require.Equal(t, corev1.VolumeMount{Name: "state-store", MountPath: "/etc/state_store", ReadOnly: true}, updatedSpec.Containers[0].VolumeMounts[4])
+	ec, err := NewFilterConfig(t.Context(), fakeClient, &aiGateway, "uuid")
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.StateStore{
+		Type: filterapi.StateStoreTypeRedis, Address: "redis:6379", KeyPrefix: "ns/myroute:", PasswordFile: "/etc/state_store/password",
+	}, ec.StateStore)
+
+	// The secret is unmounted once the password is unset.
+	aiGateway.Spec.StateStore.PasswordSecretRef = nil
+	aiGateway.Spec.StateStore.KeyPrefix = "shared:"
+	aiGateway.Spec.StateStore.TLS = true
+	updatedSpec, err = c.mountBackendSecurityPolicySecrets(t.Context(), &spec, &aiGateway)
+	require.NoError(t, err)
+	require.Len(t, updatedSpec.Volumes, 4)
+	ec, err = NewFilterConfig(t.Context(), fakeClient, &aiGateway, "uuid")
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.StateStore{
+		Type: filterapi.StateStoreTypeRedis, Address: "redis:6379", TLS: true, KeyPrefix: "shared:",
+	}, ec.StateStore)
+}
+
+func Test_newHeaderModifications(t *testing.T) {
+	require.Nil(t, newHeaderModifications(nil))
+	require.Equal(t, &filterapi.HeaderModifications{
+		Add:    []filterapi.Header{{Name: "anthropic-beta", Value: "prompt-caching-2024-07-31"}},
+		Set:    []filterapi.Header{{Name: "x-model", Value: "${model}"}},
+		Remove: []string{"x-internal"},
+	}, newHeaderModifications(&gwapiv1.HTTPHeaderFilter{
+		Add:    []gwapiv1.HTTPHeader{{Name: "Anthropic-Beta", Value: "prompt-caching-2024-07-31"}},
+		Set:    []gwapiv1.HTTPHeader{{Name: "X-Model", Value: "${model}"}},
+		Remove: []string{"X-Internal"},
+	}))
+}
+
+func Test_newModelPriceTable(t *testing.T) {
+	table, err := newModelPriceTable(&aigv1a1.LLMRequestCostModelPriceTable{
+		Prices: map[string]aigv1a1.LLMRequestCostModelPrice{
+			"gpt-4o":  {InputTokenPrice: "2.5", OutputTokenPrice: "10"},
+			"claude*": {InputTokenPrice: "3", OutputTokenPrice: "15.0"},
+		},
+		Strict:     true,
+		Multiplier: ptr.To[int64](1000),
+	})
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.ModelPriceTable{
+		Prices: map[string]filterapi.ModelPrice{
+			"gpt-4o":  {InputTokenPrice: 2.5, OutputTokenPrice: 10},
+			"claude*": {InputTokenPrice: 3, OutputTokenPrice: 15},
+		},
+		Strict:     true,
+		Multiplier: 1000,
+	}, table)
+
+	_, err = newModelPriceTable(&aigv1a1.LLMRequestCostModelPriceTable{
+		Prices: map[string]aigv1a1.LLMRequestCostModelPrice{"gpt-4o": {InputTokenPrice: "foo", OutputTokenPrice: "10"}},
+	})
+	require.ErrorContains(t, err, "invalid price of model gpt-4o: invalid inputTokenPrice")
+	_, err = newModelPriceTable(&aigv1a1.LLMRequestCostModelPriceTable{
+		Prices:  map[string]aigv1a1.LLMRequestCostModelPrice{},
+		Default: &aigv1a1.LLMRequestCostModelPrice{InputTokenPrice: "1", OutputTokenPrice: "bar"},
+	})
+	require.ErrorContains(t, err, "invalid default price: invalid outputTokenPrice")
 }
 
 func Test_backendSecurityPolicyVolumeName(t *testing.T) {
@@ -1037,7 +2885,7 @@ func TestAIGatewayRouteController_AnnotateExtProcPods(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	kube := fake2.NewClientset()
 
-	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), "defaultExtProcImage", "debug")
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
 
 	aiGatewayRoute := &aigv1a1.AIGatewayRoute{
 		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "foons"},
@@ -1057,7 +2905,7 @@ func TestAIGatewayRouteController_AnnotateExtProcPods(t *testing.T) {
 	}
 
 	uuid := string(uuid2.NewUUID())
-	err := s.annotateExtProcPods(t.Context(), aiGatewayRoute, uuid)
+	err := s.annotateExtProcPods(t.Context(), aiGatewayRoute.Namespace, extProcName(aiGatewayRoute), uuid)
 	require.NoError(t, err)
 
 	// Check that all pods have been annotated.
@@ -1066,4 +2914,198 @@ func TestAIGatewayRouteController_AnnotateExtProcPods(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, uuid, pod.Annotations[extProcConfigAnnotationKey])
 	}
+
+	// The pods already annotated with the uuid are not patched again.
+	kube.ClearActions()
+	require.NoError(t, s.annotateExtProcPods(t.Context(), aiGatewayRoute.Namespace, extProcName(aiGatewayRoute), uuid))
+	for _, action := range kube.Actions() {
+		require.NotEqual(t, "patch", action.GetVerb())
+	}
+}
+
+func TestAIGatewayRouteController_syncAIGatewayRoute_noOpUpdate(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "apple", Namespace: "ns"},
+		Spec: aigv1a1.AIServiceBackendSpec{
+			BackendRef: gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
+		},
+	}))
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+			Rules:     []aigv1a1.AIGatewayRouteRule{{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}}}},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+	_, err := kube.CoreV1().ConfigMaps("ns").Create(t.Context(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = kube.CoreV1().Pods("ns").Create(t.Context(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "somepod", Namespace: "ns", Labels: map[string]string{"app": extProcName(route)}},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// requireSynced syncs the route and returns the stored configuration and the annotation of the pod.
+	var podPatches int
+	requireSynced := func(t *testing.T) (data, annotation string) {
+		kube.ClearActions()
+		require.NoError(t, s.syncAIGatewayRoute(t.Context(), route))
+		for _, action := range kube.Actions() {
+			if action.GetVerb() == "patch" && action.GetResource().Resource == "pods" {
+				podPatches++
+			}
+		}
+		configMap, err := kube.CoreV1().ConfigMaps("ns").Get(t.Context(), extProcName(route), metav1.GetOptions{})
+		require.NoError(t, err)
+		pod, err := kube.CoreV1().Pods("ns").Get(t.Context(), "somepod", metav1.GetOptions{})
+		require.NoError(t, err)
+		return configMap.Data[expProcConfigFileName], pod.Annotations[extProcConfigAnnotationKey]
+	}
+
+	data, annotation := requireSynced(t)
+	var fc filterapi.Config
+	require.NoError(t, yaml.Unmarshal([]byte(data), &fc))
+	require.NotEmpty(t, fc.UUID)
+	require.Equal(t, fc.UUID, annotation)
+	require.Equal(t, 1, podPatches)
+
+	// Nothing changed, so neither the configuration nor the annotation is updated.
+	data2, annotation2 := requireSynced(t)
+	require.Equal(t, data, data2)
+	require.Equal(t, annotation, annotation2)
+	require.Equal(t, 1, podPatches)
+
+	// The change of the route is reflected with a new UUID.
+	route.Spec.LLMRequestCosts = []aigv1a1.LLMRequestCost{{MetadataKey: "total", Type: aigv1a1.LLMRequestCostTypeTotalToken}}
+	data3, annotation3 := requireSynced(t)
+	require.NotEqual(t, data, data3)
+	require.NoError(t, yaml.Unmarshal([]byte(data3), &fc))
+	require.NotEqual(t, annotation, annotation3)
+	require.Equal(t, fc.UUID, annotation3)
+	require.Equal(t, 2, podPatches)
+
+	// The pod left with the stale annotation, e.g., when the previous reconciliation failed after updating the
+	// configmap, is annotated even though the configuration is not changed.
+	_, err = kube.CoreV1().Pods("ns").Patch(t.Context(), "somepod", types.MergePatchType,
+		[]byte(`{"metadata":{"annotations":{"`+extProcConfigAnnotationKey+`":"stale"}}}`), metav1.PatchOptions{})
+	require.NoError(t, err)
+	data4, annotation4 := requireSynced(t)
+	require.Equal(t, data3, data4)
+	require.Equal(t, annotation3, annotation4)
+	require.Equal(t, 3, podPatches)
+}
+
+func TestAIGatewayRouteController_syncAIGatewayRoute_secretRotation(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	kube := fake2.NewClientset()
+	s := NewAIGatewayRouteController(fakeClient, kube, logr.Discard(), &record.FakeRecorder{}, "defaultExtProcImage", "", "debug", corev1.ResourceRequirements{})
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-key", Namespace: "ns"},
+		Data:       map[string][]byte{"apiKey": []byte("old")},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), secret))
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "bsp", Namespace: "ns"},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type:   aigv1a1.BackendSecurityPolicyTypeAPIKey,
+			APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{Name: "api-key"}},
+		},
+	}))
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.AIServiceBackend{
+		ObjectMeta: metav1.ObjectMeta{Name: "apple", Namespace: "ns"},
+		Spec: aigv1a1.AIServiceBackendSpec{
+			BackendRef:               gwapiv1.BackendObjectReference{Name: "some-backend", Namespace: ptr.To[gwapiv1.Namespace]("ns")},
+			BackendSecurityPolicyRef: &gwapiv1.LocalObjectReference{Name: "bsp"},
+		},
+	}))
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "ns"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: aigv1a1.VersionedAPISchema{Name: aigv1a1.APISchemaOpenAI},
+			Rules:     []aigv1a1.AIGatewayRouteRule{{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "apple"}}}},
+		},
+	}
+	require.NoError(t, fakeClient.Create(t.Context(), route))
+	_, err := kube.CoreV1().ConfigMaps("ns").Create(t.Context(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: extProcName(route), Namespace: "ns"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	requireUUID := func(t *testing.T) string {
+		require.NoError(t, s.syncAIGatewayRoute(t.Context(), route))
+		configMap, err := kube.CoreV1().ConfigMaps("ns").Get(t.Context(), extProcName(route), metav1.GetOptions{})
+		require.NoError(t, err)
+		var fc filterapi.Config
+		require.NoError(t, yaml.Unmarshal([]byte(configMap.Data[expProcConfigFileName]), &fc))
+		require.NotEmpty(t, fc.UUID)
+		return fc.UUID
+	}
+
+	uuid := requireUUID(t)
+	require.Equal(t, uuid, requireUUID(t))
+
+	// The rotation of the secret reloads the same configuration with a new UUID.
+	secret.Data["apiKey"] = []byte("new")
+	require.NoError(t, fakeClient.Update(t.Context(), secret))
+	uuid2 := requireUUID(t)
+	require.NotEqual(t, uuid, uuid2)
+	require.Equal(t, uuid2, requireUUID(t))
+}
+
+func Test_extProcConfigData(t *testing.T) {
+	ec := &filterapi.Config{RouteName: "ns/myroute", MetadataNamespace: "io.envoy.ai_gateway"}
+	data, changed, err := extProcConfigData(ec, "", false)
+	require.NoError(t, err)
+	require.True(t, changed)
+	uuid := ec.UUID
+	require.NotEmpty(t, uuid)
+
+	// The UUID of the stored configuration is reused when nothing else changed.
+	ec = &filterapi.Config{RouteName: "ns/myroute", MetadataNamespace: "io.envoy.ai_gateway"}
+	data2, changed, err := extProcConfigData(ec, data, false)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Equal(t, data, data2)
+	require.Equal(t, uuid, ec.UUID)
+
+	ec = &filterapi.Config{RouteName: "ns/myroute", MetadataNamespace: "io.example"}
+	data3, changed, err := extProcConfigData(ec, data, false)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NotEqual(t, data, data3)
+	require.NotEqual(t, uuid, ec.UUID)
+
+	// The unparsable configuration is replaced.
+	ec = &filterapi.Config{RouteName: "ns/myroute"}
+	_, changed, err = extProcConfigData(ec, "{", false)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NotEmpty(t, ec.UUID)
+
+	// The reload forces a new UUID even though nothing else changed.
+	ec = &filterapi.Config{RouteName: "ns/myroute", MetadataNamespace: "io.envoy.ai_gateway"}
+	data4, changed, err := extProcConfigData(ec, data, true)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NotEqual(t, data, data4)
+	require.NotEqual(t, uuid, ec.UUID)
+}
+
+func TestNewAPIKeyAuth(t *testing.T) {
+	require.Equal(t, &filterapi.APIKeyAuth{Filename: "/mnt/apiKey"}, newAPIKeyAuth(nil, "/mnt"))
+	require.Equal(t, &filterapi.APIKeyAuth{Filename: "/mnt/apiKey", HeaderName: "x-api-key"}, newAPIKeyAuth(
+		&aigv1a1.BackendSecurityPolicyAPIKey{HeaderName: ptr.To("x-api-key")}, "/mnt"))
+	// The rotated API keys are not listed as the secret is not read by the controller.
+	require.Equal(t, &filterapi.APIKeyAuth{Directory: "/mnt", Weights: []int{3, 1}, ValueTemplate: "{key}"}, newAPIKeyAuth(
+		&aigv1a1.BackendSecurityPolicyAPIKey{
+			Keys:          &aigv1a1.BackendSecurityPolicyAPIKeys{Weights: []int32{3, 1}},
+			ValueTemplate: ptr.To("{key}"),
+		}, "/mnt"))
 }
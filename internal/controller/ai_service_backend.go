@@ -10,11 +10,19 @@ import (
 	"errors"
 	"fmt"
 
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 )
@@ -26,15 +34,21 @@ type AIBackendController struct {
 	client    client.Client
 	kube      kubernetes.Interface
 	logger    logr.Logger
+	recorder  record.EventRecorder
 	syncRoute syncAIGatewayRouteFn
 }
 
 // NewAIServiceBackendController creates a new [reconcile.TypedReconciler] for [aigv1a1.AIServiceBackend].
-func NewAIServiceBackendController(client client.Client, kube kubernetes.Interface, logger logr.Logger, syncRoute syncAIGatewayRouteFn) *AIBackendController {
+//
+// The recorder emits the Kubernetes Events on the failures to sync the AIGatewayRoutes referencing the backend.
+func NewAIServiceBackendController(client client.Client, kube kubernetes.Interface, logger logr.Logger, recorder record.EventRecorder,
+	syncRoute syncAIGatewayRouteFn,
+) *AIBackendController {
 	return &AIBackendController{
 		client:    client,
 		kube:      kube,
 		logger:    logger,
+		recorder:  recorder,
 		syncRoute: syncRoute,
 	}
 }
@@ -51,7 +65,14 @@ func (c *AIBackendController) Reconcile(ctx context.Context, req reconcile.Reque
 		return ctrl.Result{}, err
 	}
 	c.logger.Info("Reconciling AIServiceBackend", "namespace", req.Namespace, "name", req.Name)
-	return ctrl.Result{}, c.syncAIServiceBackend(ctx, &aiBackend)
+	if err := c.updateBackendRefConditions(ctx, &aiBackend); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := c.syncAIServiceBackend(ctx, &aiBackend); err != nil {
+		c.recorder.Event(&aiBackend, corev1.EventTypeWarning, eventReason(err), err.Error())
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
 }
 
 // syncAIServiceBackend implements syncAIServiceBackendFn.
@@ -77,3 +98,143 @@ func (c *AIBackendController) syncAIServiceBackend(ctx context.Context, aiBacken
 	}
 	return nil
 }
+
+// defaultFQDNBackendPort is the port of the BackendRef referencing a Backend of Envoy Gateway with the FQDN endpoints
+// when the port is not set.
+const defaultFQDNBackendPort gwapiv1.PortNumber = 443
+
+// updateBackendRefConditions sets the ResolvedRefs and PortDefaulted conditions of the AIServiceBackend status based
+// on whether the object referenced by the BackendRef is of the supported kind and exists, and whether its port is
+// defaulted.
+func (c *AIBackendController) updateBackendRefConditions(ctx context.Context, aiBackend *aigv1a1.AIServiceBackend) error {
+	resolvedRefs := metav1.Condition{
+		Type:               string(gwapiv1.RouteConditionResolvedRefs),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gwapiv1.RouteReasonResolvedRefs),
+		Message:            "The backend reference is resolved",
+		ObservedGeneration: aiBackend.Generation,
+	}
+	ref := &aiBackend.Spec.BackendRef
+	key := backendRefKey(aiBackend)
+	var err error
+	switch {
+	case isServiceBackendRef(ref):
+		err = c.client.Get(ctx, key, &corev1.Service{})
+	case isEnvoyGatewayBackendRef(ref):
+		err = c.client.Get(ctx, key, &egv1a1.Backend{})
+	default:
+		resolvedRefs.Status = metav1.ConditionFalse
+		resolvedRefs.Reason = string(gwapiv1.RouteReasonInvalidKind)
+		resolvedRefs.Message = fmt.Sprintf("unsupported backend kind %s/%s: must be Service or %s/%s",
+			ptr.Deref(ref.Group, ""), ptr.Deref(ref.Kind, ""), egv1a1.GroupName, egv1a1.KindBackend)
+	}
+	if apierrors.IsNotFound(err) {
+		resolvedRefs.Status = metav1.ConditionFalse
+		resolvedRefs.Reason = string(gwapiv1.RouteReasonBackendNotFound)
+		resolvedRefs.Message = fmt.Sprintf("%s %s.%s not found", ptr.Deref(ref.Kind, "Service"), key.Name, key.Namespace)
+	} else if err != nil {
+		return fmt.Errorf("failed to get the backend reference: %w", err)
+	}
+	if resolvedRefs.Status == metav1.ConditionFalse {
+		c.logger.Info("Unresolved backend reference", "namespace", aiBackend.Namespace,
+			"name", aiBackend.Name, "message", resolvedRefs.Message)
+	}
+	changed := meta.SetStatusCondition(&aiBackend.Status.Conditions, resolvedRefs)
+
+	defaulted, err := fqdnBackendPortDefaulted(ctx, c.client, aiBackend)
+	if err != nil {
+		return err
+	}
+	if defaulted {
+		changed = meta.SetStatusCondition(&aiBackend.Status.Conditions, metav1.Condition{
+			Type:               aigv1a1.AIServiceBackendConditionPortDefaulted,
+			Status:             metav1.ConditionTrue,
+			Reason:             aigv1a1.AIServiceBackendReasonFQDNBackend,
+			Message:            fmt.Sprintf("The port is not set and defaulted to %d for the FQDN Backend", defaultFQDNBackendPort),
+			ObservedGeneration: aiBackend.Generation,
+		}) || changed
+	} else {
+		changed = meta.RemoveStatusCondition(&aiBackend.Status.Conditions, aigv1a1.AIServiceBackendConditionPortDefaulted) || changed
+	}
+	if !changed {
+		return nil
+	}
+	if err := c.client.Status().Update(ctx, aiBackend); err != nil {
+		return fmt.Errorf("failed to update AIServiceBackend status: %w", err)
+	}
+	return nil
+}
+
+// backendRefKey returns the key of the object referenced by the BackendRef of the AIServiceBackend, which defaults
+// to the namespace of the AIServiceBackend.
+func backendRefKey(aiBackend *aigv1a1.AIServiceBackend) client.ObjectKey {
+	ref := &aiBackend.Spec.BackendRef
+	return client.ObjectKey{
+		Name:      string(ref.Name),
+		Namespace: string(ptr.Deref(ref.Namespace, gwapiv1.Namespace(aiBackend.Namespace))),
+	}
+}
+
+// isServiceBackendRef returns true if the ref references a Service, which is the default of the BackendRef.
+func isServiceBackendRef(ref *gwapiv1.BackendObjectReference) bool {
+	return ptr.Deref(ref.Group, "") == "" && ptr.Deref(ref.Kind, "Service") == "Service"
+}
+
+// isEnvoyGatewayBackendRef returns true if the ref references a Backend of Envoy Gateway.
+func isEnvoyGatewayBackendRef(ref *gwapiv1.BackendObjectReference) bool {
+	return ptr.Deref(ref.Group, "") == egv1a1.GroupName && ptr.Deref(ref.Kind, "") == egv1a1.KindBackend
+}
+
+// fqdnBackendPortDefaulted returns true if the BackendRef of the AIServiceBackend references an existing Backend of
+// Envoy Gateway with the FQDN endpoints without the port, in which case the port defaults to defaultFQDNBackendPort.
+func fqdnBackendPortDefaulted(ctx context.Context, c client.Client, aiBackend *aigv1a1.AIServiceBackend) (bool, error) {
+	ref := &aiBackend.Spec.BackendRef
+	if ref.Port != nil || !isEnvoyGatewayBackendRef(ref) {
+		return false, nil
+	}
+	var backend egv1a1.Backend
+	if err := c.Get(ctx, backendRefKey(aiBackend), &backend); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get Backend: %w", err)
+	}
+	for _, endpoint := range backend.Spec.Endpoints {
+		if endpoint.FQDN != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// defaultBackendRefPort sets the port of the BackendRef of the AIServiceBackend to defaultFQDNBackendPort if it
+// references a Backend of Envoy Gateway with the FQDN endpoints without the port, since Envoy Gateway rejects the
+// backendRef of the HTTPRoute without the port.
+func defaultBackendRefPort(ctx context.Context, c client.Client, aiBackend *aigv1a1.AIServiceBackend) error {
+	defaulted, err := fqdnBackendPortDefaulted(ctx, c, aiBackend)
+	if err != nil {
+		return err
+	}
+	if defaulted {
+		aiBackend.Spec.BackendRef.Port = ptr.To(defaultFQDNBackendPort)
+	}
+	return nil
+}
+
+// EnvoyGatewayBackendToAIServiceBackends returns the requests of the AIServiceBackends referencing the Backend of
+// Envoy Gateway, so that their conditions are updated when the Backend is created, updated or deleted.
+//
+// Exported for testing purposes.
+func (c *AIBackendController) EnvoyGatewayBackendToAIServiceBackends(ctx context.Context, obj client.Object) []reconcile.Request {
+	var aiBackends aigv1a1.AIServiceBackendList
+	key := fmt.Sprintf("%s.%s", obj.GetName(), obj.GetNamespace())
+	if err := c.client.List(ctx, &aiBackends, client.MatchingFields{k8sClientIndexEnvoyGatewayBackendToReferencingAIServiceBackend: key}); err != nil {
+		c.logger.Error(err, "failed to list AIServiceBackends referencing the Backend", "backend", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(aiBackends.Items))
+	for i := range aiBackends.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&aiBackends.Items[i])})
+	}
+	return requests
+}
@@ -6,12 +6,17 @@
 package controller
 
 import (
+	"sort"
 	"testing"
 
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	fake2 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -27,7 +32,7 @@ import (
 func TestAIServiceBackendController_Reconcile(t *testing.T) {
 	fakeClient := requireNewFakeClientWithIndexes(t)
 	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIGatewayRoute]()
-	c := NewAIServiceBackendController(fakeClient, fake2.NewClientset(), ctrl.Log, syncFn.Sync)
+	c := NewAIServiceBackendController(fakeClient, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, syncFn.Sync)
 	originals := []*aigv1a1.AIGatewayRoute{
 		{
 			ObjectMeta: metav1.ObjectMeta{Name: "myroute", Namespace: "default"},
@@ -83,6 +88,139 @@ func TestAIServiceBackendController_Reconcile(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAIServiceBackendController_updateBackendRefConditions(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewAIServiceBackendController(fakeClient, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, nil)
+	for _, obj := range []client.Object{
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "ns"}},
+		&egv1a1.Backend{ObjectMeta: metav1.ObjectMeta{Name: "fqdn", Namespace: "ns"}, Spec: egv1a1.BackendSpec{
+			Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}},
+		}},
+		&egv1a1.Backend{ObjectMeta: metav1.ObjectMeta{Name: "ip", Namespace: "ns"}, Spec: egv1a1.BackendSpec{
+			Endpoints: []egv1a1.BackendEndpoint{{IP: &egv1a1.IPEndpoint{Address: "1.2.3.4", Port: 8080}}},
+		}},
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), obj))
+	}
+	type expCondition struct {
+		status          metav1.ConditionStatus
+		reason, message string
+	}
+	egBackendRef := func(name string, port *gwapiv1.PortNumber) gwapiv1.BackendObjectReference {
+		return gwapiv1.BackendObjectReference{
+			Name: gwapiv1.ObjectName(name), Group: ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"),
+			Kind: ptr.To[gwapiv1.Kind]("Backend"), Port: port,
+		}
+	}
+
+	for _, tc := range []struct {
+		name            string
+		backendRef      gwapiv1.BackendObjectReference
+		expResolvedRefs expCondition
+		expDefaulted    bool
+	}{
+		{
+			name:            "service",
+			backendRef:      gwapiv1.BackendObjectReference{Name: "svc", Port: ptr.To[gwapiv1.PortNumber](8080)},
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "The backend reference is resolved"},
+		},
+		{
+			name:            "service not found",
+			backendRef:      gwapiv1.BackendObjectReference{Name: "unknown", Kind: ptr.To[gwapiv1.Kind]("Service")},
+			expResolvedRefs: expCondition{metav1.ConditionFalse, "BackendNotFound", "Service unknown.ns not found"},
+		},
+		{
+			name:            "fqdn backend without port",
+			backendRef:      egBackendRef("fqdn", nil),
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "The backend reference is resolved"},
+			expDefaulted:    true,
+		},
+		{
+			name:            "fqdn backend with port",
+			backendRef:      egBackendRef("fqdn", ptr.To[gwapiv1.PortNumber](8443)),
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "The backend reference is resolved"},
+		},
+		{
+			name:            "ip backend without port",
+			backendRef:      egBackendRef("ip", nil),
+			expResolvedRefs: expCondition{metav1.ConditionTrue, "ResolvedRefs", "The backend reference is resolved"},
+		},
+		{
+			name:            "backend not found",
+			backendRef:      egBackendRef("unknown", nil),
+			expResolvedRefs: expCondition{metav1.ConditionFalse, "BackendNotFound", "Backend unknown.ns not found"},
+		},
+		{
+			name:       "invalid kind",
+			backendRef: gwapiv1.BackendObjectReference{Name: "svc", Group: ptr.To[gwapiv1.Group]("example.com"), Kind: ptr.To[gwapiv1.Kind]("Foo")},
+			expResolvedRefs: expCondition{
+				metav1.ConditionFalse, "InvalidKind",
+				"unsupported backend kind example.com/Foo: must be Service or gateway.envoyproxy.io/Backend",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			aiBackend := &aigv1a1.AIServiceBackend{
+				ObjectMeta: metav1.ObjectMeta{Name: "mybackend", Namespace: "ns"},
+				Spec:       aigv1a1.AIServiceBackendSpec{BackendRef: tc.backendRef},
+			}
+			require.NoError(t, fakeClient.Create(t.Context(), aiBackend))
+			defer func() { require.NoError(t, fakeClient.Delete(t.Context(), aiBackend)) }()
+
+			require.NoError(t, c.updateBackendRefConditions(t.Context(), aiBackend))
+			var updated aigv1a1.AIServiceBackend
+			require.NoError(t, fakeClient.Get(t.Context(), client.ObjectKeyFromObject(aiBackend), &updated))
+			resolvedRefs := meta.FindStatusCondition(updated.Status.Conditions, "ResolvedRefs")
+			require.NotNil(t, resolvedRefs)
+			require.Equal(t, tc.expResolvedRefs, expCondition{resolvedRefs.Status, resolvedRefs.Reason, resolvedRefs.Message})
+			defaulted := meta.FindStatusCondition(updated.Status.Conditions, aigv1a1.AIServiceBackendConditionPortDefaulted)
+			if tc.expDefaulted {
+				require.NotNil(t, defaulted)
+				require.Equal(t, metav1.ConditionTrue, defaulted.Status)
+				require.Equal(t, aigv1a1.AIServiceBackendReasonFQDNBackend, defaulted.Reason)
+				require.Equal(t, "The port is not set and defaulted to 443 for the FQDN Backend", defaulted.Message)
+			} else {
+				require.Nil(t, defaulted)
+			}
+		})
+	}
+}
+
+func TestAIServiceBackendController_EnvoyGatewayBackendToAIServiceBackends(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewAIServiceBackendController(fakeClient, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, nil)
+	for _, aiBackend := range []*aigv1a1.AIServiceBackend{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "one", Namespace: "ns"},
+			Spec: aigv1a1.AIServiceBackendSpec{BackendRef: gwapiv1.BackendObjectReference{
+				Name: "eg", Group: ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"), Kind: ptr.To[gwapiv1.Kind]("Backend"),
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "two", Namespace: "other"},
+			Spec: aigv1a1.AIServiceBackendSpec{BackendRef: gwapiv1.BackendObjectReference{
+				Name: "eg", Namespace: ptr.To[gwapiv1.Namespace]("ns"),
+				Group: ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"), Kind: ptr.To[gwapiv1.Kind]("Backend"),
+			}},
+		},
+		{
+			// The Service with the same name is not indexed.
+			ObjectMeta: metav1.ObjectMeta{Name: "three", Namespace: "ns"},
+			Spec:       aigv1a1.AIServiceBackendSpec{BackendRef: gwapiv1.BackendObjectReference{Name: "eg"}},
+		},
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), aiBackend))
+	}
+
+	requests := c.EnvoyGatewayBackendToAIServiceBackends(t.Context(), &egv1a1.Backend{ObjectMeta: metav1.ObjectMeta{Name: "eg", Namespace: "ns"}})
+	sort.Slice(requests, func(i, j int) bool { return requests[i].Name < requests[j].Name })
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "one", Namespace: "ns"}},
+		{NamespacedName: types.NamespacedName{Name: "two", Namespace: "other"}},
+	}, requests)
+	require.Empty(t, c.EnvoyGatewayBackendToAIServiceBackends(t.Context(), &egv1a1.Backend{ObjectMeta: metav1.ObjectMeta{Name: "eg", Namespace: "other"}}))
+}
+
 func Test_AiServiceBackendIndexFunc(t *testing.T) {
 	c := fake.NewClientBuilder().
 		WithScheme(scheme).
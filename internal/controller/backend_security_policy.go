@@ -6,19 +6,28 @@
 package controller
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
 	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/internal/controller/oauth"
@@ -29,6 +38,9 @@ import (
 // Temporarily a fixed duration.
 const preRotationWindow = 5 * time.Minute
 
+// defaultAzureScope is the scope of the Azure access token when not specified, which is the one of Azure OpenAI.
+const defaultAzureScope = "https://cognitiveservices.azure.com/.default"
+
 // BackendSecurityPolicyController implements [reconcile.TypedReconciler] for [aigv1a1.BackendSecurityPolicy].
 //
 // Exported for testing purposes.
@@ -36,16 +48,20 @@ type BackendSecurityPolicyController struct {
 	client               client.Client
 	kube                 kubernetes.Interface
 	logger               logr.Logger
+	recorder             record.EventRecorder
 	oidcTokenCache       map[string]*oauth2.Token
 	oidcTokenCacheMutex  sync.RWMutex
 	syncAIServiceBackend syncAIServiceBackendFn
 }
 
-func NewBackendSecurityPolicyController(client client.Client, kube kubernetes.Interface, logger logr.Logger, syncAIServiceBackend syncAIServiceBackendFn) *BackendSecurityPolicyController {
+func NewBackendSecurityPolicyController(client client.Client, kube kubernetes.Interface, logger logr.Logger, recorder record.EventRecorder,
+	syncAIServiceBackend syncAIServiceBackendFn,
+) *BackendSecurityPolicyController {
 	return &BackendSecurityPolicyController{
 		client:               client,
 		kube:                 kube,
 		logger:               logger,
+		recorder:             recorder,
 		oidcTokenCache:       make(map[string]*oauth2.Token),
 		syncAIServiceBackend: syncAIServiceBackend,
 	}
@@ -62,22 +78,17 @@ func (c *BackendSecurityPolicyController) Reconcile(ctx context.Context, req ctr
 		}
 		return ctrl.Result{}, err
 	}
-	if oidc := getBackendSecurityPolicyAuthOIDC(backendSecurityPolicy.Spec); oidc != nil {
-		var rotator rotators.Rotator
-		switch backendSecurityPolicy.Spec.Type {
-		case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
-			region := backendSecurityPolicy.Spec.AWSCredentials.Region
-			roleArn := backendSecurityPolicy.Spec.AWSCredentials.OIDCExchangeToken.AwsRoleArn
-			rotator, err = rotators.NewAWSOIDCRotator(ctx, c.client, nil, c.kube, c.logger, backendSecurityPolicy.Namespace, backendSecurityPolicy.Name, preRotationWindow, roleArn, region)
-			if err != nil {
-				return ctrl.Result{}, err
-			}
-		default:
-			err = fmt.Errorf("backend security type %s does not support OIDC token exchange", backendSecurityPolicy.Spec.Type)
-			c.logger.Error(err, "namespace", backendSecurityPolicy.Namespace, "name", backendSecurityPolicy.Name)
-			return ctrl.Result{}, err
+	defer func() {
+		if err != nil {
+			c.recorder.Event(&backendSecurityPolicy, corev1.EventTypeWarning, eventReason(err), err.Error())
 		}
-
+	}()
+	rotator, err := c.newRotator(ctx, &backendSecurityPolicy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if rotator != nil {
+		oidc := getBackendSecurityPolicyAuthOIDC(backendSecurityPolicy.Spec)
 		requeue := time.Minute
 		var rotationTime time.Time
 		rotationTime, err = rotator.GetPreRotationTime(ctx)
@@ -85,14 +96,17 @@ func (c *BackendSecurityPolicyController) Reconcile(ctx context.Context, req ctr
 			c.logger.Error(err, "failed to get rotation time, retry in one minute")
 		} else {
 			if rotator.IsExpired(rotationTime) {
-				requeue, err = c.rotateCredential(ctx, &backendSecurityPolicy, *oidc, rotator)
+				requeue, err = c.rotateCredential(ctx, &backendSecurityPolicy, oidc, rotator)
 				if err != nil {
-					c.logger.Error(err, "failed to rotate OIDC exchange token, retry in one minute")
+					c.logger.Error(err, "failed to rotate credentials, retry in one minute")
 				} else {
 					c.logger.Info(
 						fmt.Sprintf("successfully rotated credentials for %s in namespace %s of auth type %s, renewing in %f minutes",
 							req.Name, req.Namespace, backendSecurityPolicy.Spec.Type, requeue.Minutes()))
 				}
+				if statusErr := c.updateCredentialsRotatedCondition(ctx, &backendSecurityPolicy, err); statusErr != nil {
+					c.logger.Error(statusErr, "failed to update the status", "namespace", req.Namespace, "name", req.Name)
+				}
 			} else {
 				requeue = time.Until(rotationTime)
 			}
@@ -102,26 +116,61 @@ func (c *BackendSecurityPolicyController) Reconcile(ctx context.Context, req ctr
 	return res, c.syncBackendSecurityPolicy(ctx, &backendSecurityPolicy)
 }
 
-// rotateCredential rotates the credentials using the access token from OIDC provider and return the requeue time for next rotation.
-func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context, policy *aigv1a1.BackendSecurityPolicy, oidcCreds egv1a1.OIDC, rotator rotators.Rotator) (time.Duration, error) {
+// newRotator returns the rotator of the credentials of the policy, or nil if the credentials are not rotated by the controller.
+func (c *BackendSecurityPolicyController) newRotator(ctx context.Context, policy *aigv1a1.BackendSecurityPolicy) (rotators.Rotator, error) {
+	switch policy.Spec.Type {
+	case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
+		awsCreds := policy.Spec.AWSCredentials
+		if awsCreds == nil || awsCreds.OIDCExchangeToken == nil {
+			return nil, nil
+		}
+		return rotators.NewAWSOIDCRotator(ctx, c.client, nil, c.kube, c.logger, policy.Namespace, policy.Name,
+			preRotationWindow, awsCreds.OIDCExchangeToken.AwsRoleArn, awsCreds.Region)
+	case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
+		azureCreds := policy.Spec.AzureCredentials
+		if azureCreds == nil {
+			return nil, fmt.Errorf("AzureCredentials type selected but not defined %s", policy.Name)
+		}
+		var clientSecretRef *corev1.SecretReference
+		if ref := azureCreds.ClientSecretRef; ref != nil {
+			clientSecretRef = &corev1.SecretReference{
+				Name:      string(ref.Name),
+				Namespace: string(ptr.Deref(ref.Namespace, gwapiv1.Namespace(policy.Namespace))),
+			}
+		}
+		return rotators.NewAzureTokenRotator(c.client, c.logger, policy.Namespace, policy.Name, preRotationWindow,
+			azureCreds.TenantID, azureCreds.ClientID, cmp.Or(azureCreds.Scope, defaultAzureScope), clientSecretRef), nil
+	default:
+		return nil, nil
+	}
+}
+
+// rotateCredential rotates the credentials and return the requeue time for next rotation.
+// The access token from OIDC provider is passed to the rotator when oidcCreds is non-nil.
+func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context, policy *aigv1a1.BackendSecurityPolicy, oidcCreds *egv1a1.OIDC, rotator rotators.Rotator) (time.Duration, error) {
 	bspKey := backendSecurityPolicyKey(policy.Namespace, policy.Name)
 
-	var err error
-	c.oidcTokenCacheMutex.RLock()
-	validToken, ok := c.oidcTokenCache[bspKey]
-	c.oidcTokenCacheMutex.RUnlock()
-	if !ok || validToken == nil || rotators.IsBufferedTimeExpired(preRotationWindow, validToken.Expiry) {
-		oidcProvider := oauth.NewOIDCProvider(c.client, oidcCreds)
-		validToken, err = oidcProvider.FetchToken(ctx)
-		if err != nil {
-			return time.Minute, err
+	var (
+		err   error
+		token string
+	)
+	if oidcCreds != nil {
+		c.oidcTokenCacheMutex.RLock()
+		validToken, ok := c.oidcTokenCache[bspKey]
+		c.oidcTokenCacheMutex.RUnlock()
+		if !ok || validToken == nil || rotators.IsBufferedTimeExpired(preRotationWindow, validToken.Expiry) {
+			oidcProvider := oauth.NewOIDCProvider(c.client, *oidcCreds)
+			validToken, err = oidcProvider.FetchToken(ctx)
+			if err != nil {
+				return time.Minute, err
+			}
+			c.oidcTokenCacheMutex.Lock()
+			c.oidcTokenCache[bspKey] = validToken
+			c.oidcTokenCacheMutex.Unlock()
 		}
-		c.oidcTokenCacheMutex.Lock()
-		c.oidcTokenCache[bspKey] = validToken
-		c.oidcTokenCacheMutex.Unlock()
+		token = validToken.AccessToken
 	}
 
-	token := validToken.AccessToken
 	err = rotator.Rotate(ctx, token)
 	if err != nil {
 		return time.Minute, err
@@ -133,14 +182,37 @@ func (c *BackendSecurityPolicyController) rotateCredential(ctx context.Context,
 	return time.Until(rotationTime), nil
 }
 
+// updateCredentialsRotatedCondition sets the CredentialsRotated condition of the policy by the rotation error, if any.
+func (c *BackendSecurityPolicyController) updateCredentialsRotatedCondition(ctx context.Context, policy *aigv1a1.BackendSecurityPolicy, rotationErr error) error {
+	condition := metav1.Condition{
+		Type:               aigv1a1.BackendSecurityPolicyConditionCredentialsRotated,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Rotated",
+		Message:            "The credentials have been rotated",
+		ObservedGeneration: policy.Generation,
+	}
+	if rotationErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "RotationFailed"
+		condition.Message = rotationErr.Error()
+	}
+	if !meta.SetStatusCondition(&policy.Status.Conditions, condition) {
+		return nil
+	}
+	return c.client.Status().Update(ctx, policy)
+}
+
 // getBackendSecurityPolicyAuthOIDC returns the backendSecurityPolicy's OIDC pointer or nil.
 func getBackendSecurityPolicyAuthOIDC(spec aigv1a1.BackendSecurityPolicySpec) *egv1a1.OIDC {
-	// Currently only supports AWS.
 	switch spec.Type {
 	case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
 		if spec.AWSCredentials != nil && spec.AWSCredentials.OIDCExchangeToken != nil {
 			return &spec.AWSCredentials.OIDCExchangeToken.OIDC
 		}
+	case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
+		if spec.AzureCredentials != nil && spec.AzureCredentials.OIDCExchangeToken != nil {
+			return &spec.AzureCredentials.OIDCExchangeToken.OIDC
+		}
 	default:
 		return nil
 	}
@@ -153,6 +225,12 @@ func backendSecurityPolicyKey(namespace, name string) string {
 }
 
 func (c *BackendSecurityPolicyController) syncBackendSecurityPolicy(ctx context.Context, bsp *aigv1a1.BackendSecurityPolicy) error {
+	// The AIServiceBackends are synced regardless of the ResolvedRefs condition so that the secrets no longer
+	// permitted are unmounted from the external processor.
+	if err := c.updateResolvedRefsCondition(ctx, bsp); err != nil {
+		return fmt.Errorf("failed to update the ResolvedRefs condition: %w", err)
+	}
+
 	key := backendSecurityPolicyKey(bsp.Namespace, bsp.Name)
 	var aiServiceBackends aigv1a1.AIServiceBackendList
 	err := c.client.List(ctx, &aiServiceBackends, client.MatchingFields{k8sClientIndexBackendSecurityPolicyToReferencingAIServiceBackend: key})
@@ -173,3 +251,100 @@ func (c *BackendSecurityPolicyController) syncBackendSecurityPolicy(ctx context.
 	}
 	return nil
 }
+
+// updateResolvedRefsCondition sets the ResolvedRefs condition of the policy to False when it references a secret in
+// another namespace without the ReferenceGrant permitting it, and removes the condition otherwise.
+func (c *BackendSecurityPolicyController) updateResolvedRefsCondition(ctx context.Context, policy *aigv1a1.BackendSecurityPolicy) error {
+	message, err := unpermittedSecretRef(ctx, c.client, policy)
+	if err != nil {
+		return err
+	}
+	var changed bool
+	if message == "" {
+		changed = meta.RemoveStatusCondition(&policy.Status.Conditions, aigv1a1.BackendSecurityPolicyConditionResolvedRefs)
+	} else {
+		c.logger.Info("secret reference not permitted", "namespace", policy.Namespace, "name", policy.Name, "message", message)
+		changed = meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+			Type:               aigv1a1.BackendSecurityPolicyConditionResolvedRefs,
+			Status:             metav1.ConditionFalse,
+			Reason:             string(gwapiv1.RouteReasonRefNotPermitted),
+			Message:            message,
+			ObservedGeneration: policy.Generation,
+		})
+	}
+	if !changed {
+		return nil
+	}
+	return c.client.Status().Update(ctx, policy)
+}
+
+// backendSecurityPolicySecretRefs returns the references to the user-provided secrets mounted on the external
+// processor for the policy.
+func backendSecurityPolicySecretRefs(policy *aigv1a1.BackendSecurityPolicy) []*gwapiv1.SecretObjectReference {
+	switch policy.Spec.Type {
+	case aigv1a1.BackendSecurityPolicyTypeAPIKey:
+		if apiKey := policy.Spec.APIKey; apiKey != nil && apiKey.SecretRef != nil {
+			return []*gwapiv1.SecretObjectReference{apiKey.SecretRef}
+		}
+	case aigv1a1.BackendSecurityPolicyTypeAWSCredentials:
+		if awsCreds := policy.Spec.AWSCredentials; awsCreds != nil && awsCreds.CredentialsFile != nil &&
+			awsCreds.CredentialsFile.SecretRef != nil {
+			return []*gwapiv1.SecretObjectReference{awsCreds.CredentialsFile.SecretRef}
+		}
+	}
+	return nil
+}
+
+// unpermittedSecretRef returns the message describing the first secret referenced by the policy without being
+// permitted, or the empty string if all the references are permitted.
+func unpermittedSecretRef(ctx context.Context, r client.Reader, policy *aigv1a1.BackendSecurityPolicy) (string, error) {
+	for _, ref := range backendSecurityPolicySecretRefs(policy) {
+		granted, err := secretReferenceGranted(ctx, r, policy.Namespace, ref)
+		if err != nil {
+			return "", err
+		}
+		if !granted {
+			return fmt.Sprintf("Secret %s/%s is not permitted by any ReferenceGrant", *ref.Namespace, ref.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// backendSecurityPolicySecretsGranted returns true if all the secrets referenced by the policy are permitted.
+func backendSecurityPolicySecretsGranted(ctx context.Context, r client.Reader, policy *aigv1a1.BackendSecurityPolicy) (bool, error) {
+	message, err := unpermittedSecretRef(ctx, r, policy)
+	if err != nil {
+		return false, err
+	}
+	return message == "", nil
+}
+
+// secretReferenceGranted returns true if the BackendSecurityPolicy in the policyNamespace is permitted to reference
+// the secret. The secret in the same namespace is always permitted, while the one in another namespace is permitted
+// only when a ReferenceGrant in the namespace of the secret allows it.
+func secretReferenceGranted(ctx context.Context, r client.Reader, policyNamespace string, ref *gwapiv1.SecretObjectReference) (bool, error) {
+	if ref.Namespace == nil || string(*ref.Namespace) == policyNamespace {
+		return true, nil
+	}
+	var grants gwapiv1b1.ReferenceGrantList
+	if err := r.List(ctx, &grants, client.InNamespace(string(*ref.Namespace))); err != nil {
+		return false, fmt.Errorf("failed to list ReferenceGrants in namespace %s: %w", *ref.Namespace, err)
+	}
+	for i := range grants.Items {
+		if referenceGrantPermits(&grants.Items[i].Spec, policyNamespace, ref.Name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// referenceGrantPermits returns true if the ReferenceGrant permits the BackendSecurityPolicy in the policyNamespace
+// to reference the secret of the name.
+func referenceGrantPermits(spec *gwapiv1b1.ReferenceGrantSpec, policyNamespace string, name gwapiv1.ObjectName) bool {
+	return slices.ContainsFunc(spec.From, func(from gwapiv1b1.ReferenceGrantFrom) bool {
+		return from.Group == aigv1a1.GroupName && from.Kind == "BackendSecurityPolicy" &&
+			string(from.Namespace) == policyNamespace
+	}) && slices.ContainsFunc(spec.To, func(to gwapiv1b1.ReferenceGrantTo) bool {
+		return to.Group == "" && to.Kind == "Secret" && (to.Name == nil || *to.Name == name)
+	})
+}
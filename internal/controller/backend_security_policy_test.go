@@ -25,12 +25,14 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	fake2 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
@@ -40,7 +42,7 @@ import (
 func TestBackendSecurityController_Reconcile(t *testing.T) {
 	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
 	fakeClient := requireNewFakeClientWithIndexes(t)
-	c := NewBackendSecurityPolicyController(fakeClient, fake2.NewClientset(), ctrl.Log, syncFn.Sync)
+	c := NewBackendSecurityPolicyController(fakeClient, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, syncFn.Sync)
 	backendSecurityPolicyName := "mybackendSecurityPolicy"
 	namespace := "default"
 
@@ -83,6 +85,67 @@ func TestBackendSecurityController_Reconcile(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBackendSecurityPolicyController_ReconcileReferenceGrant(t *testing.T) {
+	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewBackendSecurityPolicyController(fakeClient, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, syncFn.Sync)
+	const name, namespace, secretNamespace = "mybackendSecurityPolicy", "team-a", "team-b"
+
+	err := fakeClient.Create(t.Context(), &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type: aigv1a1.BackendSecurityPolicyTypeAPIKey,
+			APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{
+				SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret", Namespace: ptr.To[gwapiv1.Namespace](secretNamespace)},
+			},
+		},
+	})
+	require.NoError(t, err)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+
+	// Without the ReferenceGrant, the ResolvedRefs condition is set to False.
+	_, err = c.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	var policy aigv1a1.BackendSecurityPolicy
+	require.NoError(t, fakeClient.Get(t.Context(), req.NamespacedName, &policy))
+	require.Len(t, policy.Status.Conditions, 1)
+	require.Equal(t, aigv1a1.BackendSecurityPolicyConditionResolvedRefs, policy.Status.Conditions[0].Type)
+	require.Equal(t, metav1.ConditionFalse, policy.Status.Conditions[0].Status)
+	require.Equal(t, "RefNotPermitted", policy.Status.Conditions[0].Reason)
+	require.Equal(t, "Secret team-b/mysecret is not permitted by any ReferenceGrant", policy.Status.Conditions[0].Message)
+
+	// The ReferenceGrant permitting another secret or another kind does not resolve the reference.
+	err = fakeClient.Create(t.Context(), &gwapiv1b1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: secretNamespace},
+		Spec: gwapiv1b1.ReferenceGrantSpec{
+			From: []gwapiv1b1.ReferenceGrantFrom{
+				{Group: aigv1a1.GroupName, Kind: "BackendSecurityPolicy", Namespace: namespace},
+				{Group: gwapiv1.GroupName, Kind: "HTTPRoute", Namespace: namespace},
+			},
+			To: []gwapiv1b1.ReferenceGrantTo{{Kind: "Secret", Name: ptr.To[gwapiv1.ObjectName]("othersecret")}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = c.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(t.Context(), req.NamespacedName, &policy))
+	require.Len(t, policy.Status.Conditions, 1)
+
+	// With the ReferenceGrant, the condition is removed.
+	err = fakeClient.Create(t.Context(), &gwapiv1b1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: secretNamespace},
+		Spec: gwapiv1b1.ReferenceGrantSpec{
+			From: []gwapiv1b1.ReferenceGrantFrom{{Group: aigv1a1.GroupName, Kind: "BackendSecurityPolicy", Namespace: namespace}},
+			To:   []gwapiv1b1.ReferenceGrantTo{{Kind: "Secret"}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = c.Reconcile(t.Context(), req)
+	require.NoError(t, err)
+	require.NoError(t, fakeClient.Get(t.Context(), req.NamespacedName, &policy))
+	require.Empty(t, policy.Status.Conditions)
+}
+
 // mockSTSClient implements the STSOperations interface for testing
 type mockSTSClient struct{}
 
@@ -103,7 +166,7 @@ func (m *mockSTSClient) AssumeRoleWithWebIdentity(_ context.Context, _ *sts.Assu
 func TestBackendSecurityPolicyController_ReconcileOIDC(t *testing.T) {
 	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
 	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
-	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, syncFn.Sync)
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, syncFn.Sync)
 	backendSecurityPolicyName := "mybackendSecurityPolicy"
 	namespace := "default"
 
@@ -129,7 +192,7 @@ func TestBackendSecurityPolicyController_ReconcileOIDC(t *testing.T) {
 
 func TestBackendSecurityController_RotateCredentials(t *testing.T) {
 	cl := fake.NewClientBuilder().WithScheme(scheme).Build()
-	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]().Sync)
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]().Sync)
 	backendSecurityPolicyName := "mybackendSecurityPolicy"
 	namespace := "default"
 
@@ -202,7 +265,7 @@ func TestBackendSecurityController_RotateCredentials(t *testing.T) {
 	rotator, err := rotators.NewAWSOIDCRotator(ctx, cl, &mockSTSClient{}, fake2.NewClientset(), ctrl.Log, namespace, bsp.Name, preRotationWindow, "placeholder", "us-east-1")
 	require.NoError(t, err)
 
-	res, err := c.rotateCredential(ctx, bsp, oidc, rotator)
+	res, err := c.rotateCredential(ctx, bsp, &oidc, rotator)
 	require.NoError(t, err)
 	require.WithinRange(t, time.Now().Add(res), time.Now().Add(50*time.Minute), time.Now().Add(time.Hour))
 
@@ -241,4 +304,87 @@ func TestBackendSecurityController_GetBackendSecurityPolicyAuthOIDC(t *testing.T
 	})
 	require.NotNil(t, oidc)
 	require.Equal(t, "some-client-id", oidc.ClientID)
+
+	// Azure type with OIDC defined.
+	oidc = getBackendSecurityPolicyAuthOIDC(aigv1a1.BackendSecurityPolicySpec{
+		Type: aigv1a1.BackendSecurityPolicyTypeAzureCredentials,
+		AzureCredentials: &aigv1a1.BackendSecurityPolicyAzureCredentials{
+			OIDCExchangeToken: &aigv1a1.AzureOIDCExchangeToken{
+				OIDC: egv1a1.OIDC{
+					ClientID: "some-azure-client-id",
+				},
+			},
+		},
+	})
+	require.NotNil(t, oidc)
+	require.Equal(t, "some-azure-client-id", oidc.ClientID)
+}
+
+func TestBackendSecurityPolicyController_ReconcileAzure(t *testing.T) {
+	tokenStatus := http.StatusOK
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "some-client-secret", r.PostForm.Get("client_secret"))
+		require.Equal(t, "https://cognitiveservices.azure.com/.default", r.PostForm.Get("scope"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(tokenStatus)
+		if tokenStatus == http.StatusOK {
+			_, _ = w.Write([]byte(`{"access_token":"some-access-token","token_type":"Bearer","expires_in":3600}`))
+		} else {
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+		}
+	}))
+	defer tokenServer.Close()
+	t.Setenv("AI_GATEWAY_AZURE_AUTHORITY_HOST", tokenServer.URL)
+
+	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
+	cl := requireNewFakeClientWithIndexes(t)
+	c := NewBackendSecurityPolicyController(cl, fake2.NewClientset(), ctrl.Log, &record.FakeRecorder{}, syncFn.Sync)
+	const namespace = "default"
+	require.NoError(t, cl.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "azure-client-secret", Namespace: namespace},
+		Data:       map[string][]byte{"clientSecret": []byte("some-client-secret")},
+	}))
+
+	requireCondition := func(t *testing.T, name string, status metav1.ConditionStatus, reason string) {
+		var bsp aigv1a1.BackendSecurityPolicy
+		require.NoError(t, cl.Get(t.Context(), client.ObjectKey{Name: name, Namespace: namespace}, &bsp))
+		require.Len(t, bsp.Status.Conditions, 1)
+		require.Equal(t, aigv1a1.BackendSecurityPolicyConditionCredentialsRotated, bsp.Status.Conditions[0].Type)
+		require.Equal(t, status, bsp.Status.Conditions[0].Status)
+		require.Equal(t, reason, bsp.Status.Conditions[0].Reason)
+	}
+	newBSP := func(name string) *aigv1a1.BackendSecurityPolicy {
+		return &aigv1a1.BackendSecurityPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: aigv1a1.BackendSecurityPolicySpec{
+				Type: aigv1a1.BackendSecurityPolicyTypeAzureCredentials,
+				AzureCredentials: &aigv1a1.BackendSecurityPolicyAzureCredentials{
+					TenantID:        "some-tenant",
+					ClientID:        "some-client-id",
+					ClientSecretRef: &gwapiv1.SecretObjectReference{Name: "azure-client-secret"},
+				},
+			},
+		}
+	}
+
+	t.Run("rotated", func(t *testing.T) {
+		require.NoError(t, cl.Create(t.Context(), newBSP("azure")))
+		res, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "azure"}})
+		require.NoError(t, err)
+		require.WithinRange(t, time.Now().Add(res.RequeueAfter), time.Now().Add(50*time.Minute), time.Now().Add(time.Hour))
+
+		secret, err := rotators.LookupSecret(t.Context(), cl, namespace, rotators.GetBSPSecretName("azure"))
+		require.NoError(t, err)
+		require.Equal(t, "some-access-token", string(secret.Data[rotators.AzureAccessTokenKey]))
+		requireCondition(t, "azure", metav1.ConditionTrue, "Rotated")
+	})
+	t.Run("rotation failed", func(t *testing.T) {
+		tokenStatus = http.StatusUnauthorized
+		require.NoError(t, cl.Create(t.Context(), newBSP("azure-failed")))
+		res, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "azure-failed"}})
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, res.RequeueAfter)
+		requireCondition(t, "azure-failed", metav1.ConditionFalse, "RotationFailed")
+	})
 }
@@ -8,23 +8,38 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+	"github.com/envoyproxy/ai-gateway/internal/controller/rotators"
 )
 
 func init() { MustInitializeScheme(scheme) }
@@ -45,9 +60,26 @@ func MustInitializeScheme(scheme *runtime.Scheme) {
 
 // Options defines the program configurable options that may be passed on the command line.
 type Options struct {
-	ExtProcLogLevel      string
-	ExtProcImage         string
-	EnableLeaderElection bool
+	ExtProcLogLevel         string
+	ExtProcImage            string
+	ExtProcImagePullSecret  string
+	EnableLeaderElection    bool
+	ExtProcDefaultResources corev1.ResourceRequirements
+	// WatchNamespaces is the list of the namespaces that the controllers watch. Empty means all namespaces.
+	WatchNamespaces []string
+	// NamespaceLabelSelector selects the namespaces that the controllers reconcile the objects in by their labels.
+	// Nil means all namespaces.
+	NamespaceLabelSelector labels.Selector
+	// LeaseDuration, RenewDeadline and RetryPeriod tune the leader election. Zero uses the default of
+	// controller-runtime.
+	LeaseDuration, RenewDeadline, RetryPeriod time.Duration
+	// HealthProbeBindAddress is the address of the /healthz and /readyz endpoints. Empty disables them.
+	HealthProbeBindAddress string
+	// MetricsBindAddress is the address of the metrics endpoint. Empty means ":8080", and "0" disables it.
+	MetricsBindAddress string
+	// HealthChecks is the checks added to both of /healthz and /readyz keyed by the name, in addition to the ping of
+	// the manager, such as the one of the extension server.
+	HealthChecks map[string]healthz.Checker
 }
 
 type (
@@ -68,9 +100,36 @@ type (
 // Note: this is tested with envtest, hence the test exists outside of this package. See /tests/controller_test.go.
 func StartControllers(ctx context.Context, config *rest.Config, logger logr.Logger, options Options) error {
 	opt := ctrl.Options{
-		Scheme:           scheme,
-		LeaderElection:   options.EnableLeaderElection,
-		LeaderElectionID: "envoy-ai-gateway-controller",
+		Scheme:                 scheme,
+		LeaderElection:         options.EnableLeaderElection,
+		LeaderElectionID:       "envoy-ai-gateway-controller",
+		HealthProbeBindAddress: options.HealthProbeBindAddress,
+		Metrics:                metricsserver.Options{BindAddress: options.MetricsBindAddress},
+	}
+	for _, d := range []struct {
+		value time.Duration
+		dst   **time.Duration
+	}{
+		{options.LeaseDuration, &opt.LeaseDuration},
+		{options.RenewDeadline, &opt.RenewDeadline},
+		{options.RetryPeriod, &opt.RetryPeriod},
+	} {
+		if d.value > 0 {
+			*d.dst = ptr.To(d.value)
+		}
+	}
+	// Restricting the cache to the namespaces also restricts the watches and the indexes built on top of it.
+	if len(options.WatchNamespaces) > 0 {
+		opt.Cache.DefaultNamespaces = make(map[string]cache.Config, len(options.WatchNamespaces))
+		for _, ns := range options.WatchNamespaces {
+			opt.Cache.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+
+	// Only the data of the secrets referenced by the BackendSecurityPolicies is kept in the cache.
+	var c client.Client
+	opt.Cache.ByObject = map[client.Object]cache.ByObject{
+		&corev1.Secret{}: {Transform: newSecretCacheTransform(func() client.Reader { return c }, logger.WithName("secret-cache"))},
 	}
 
 	mgr, err := ctrl.NewManager(config, opt)
@@ -78,36 +137,52 @@ func StartControllers(ctx context.Context, config *rest.Config, logger logr.Logg
 		return fmt.Errorf("failed to create new controller manager: %w", err)
 	}
 
-	c := mgr.GetClient()
+	if err = addHealthChecks(mgr, options.HealthChecks); err != nil {
+		return err
+	}
+
+	c = secretDataClient{Client: mgr.GetClient(), apiReader: mgr.GetAPIReader()}
+	namespaces := newNamespaceFilter(c, options.NamespaceLabelSelector, logger.WithName("namespace-filter"))
 	indexer := mgr.GetFieldIndexer()
 	if err = ApplyIndexing(ctx, indexer.IndexField); err != nil {
 		return fmt.Errorf("failed to apply indexing: %w", err)
 	}
 
 	routeC := NewAIGatewayRouteController(c, kubernetes.NewForConfigOrDie(config), logger.WithName("ai-gateway-route"),
-		options.ExtProcImage, options.ExtProcLogLevel)
+		mgr.GetEventRecorderFor("ai-gateway-route"), options.ExtProcImage, options.ExtProcImagePullSecret, options.ExtProcLogLevel, options.ExtProcDefaultResources)
 	if err = ctrl.NewControllerManagedBy(mgr).
 		For(&aigv1a1.AIGatewayRoute{}).
 		Owns(&egv1a1.EnvoyExtensionPolicy{}).
+		Owns(&egv1a1.SecurityPolicy{}).
+		Owns(&egv1a1.BackendTrafficPolicy{}).
+		Owns(&egv1a1.EnvoyProxy{}).
+		Owns(&egv1a1.Backend{}).
 		Owns(&gwapiv1.HTTPRoute{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
 		Owns(&corev1.Service{}).
+		Watches(&gwapiv1.Gateway{}, handler.EnqueueRequestsFromMapFunc(routeC.gatewayToAIGatewayRoutes)).
+		WithEventFilter(namespaces.predicate()).
 		Complete(routeC); err != nil {
 		return fmt.Errorf("failed to create controller for AIGatewayRoute: %w", err)
 	}
 
 	backendC := NewAIServiceBackendController(c, kubernetes.NewForConfigOrDie(config), logger.
-		WithName("ai-service-backend"), routeC.syncAIGatewayRoute)
+		WithName("ai-service-backend"), mgr.GetEventRecorderFor("ai-service-backend"), routeC.syncAIGatewayRoute)
 	if err = ctrl.NewControllerManagedBy(mgr).
 		For(&aigv1a1.AIServiceBackend{}).
+		Watches(&egv1a1.Backend{}, handler.EnqueueRequestsFromMapFunc(backendC.EnvoyGatewayBackendToAIServiceBackends)).
+		WithEventFilter(namespaces.predicate()).
 		Complete(backendC); err != nil {
 		return fmt.Errorf("failed to create controller for AIServiceBackend: %w", err)
 	}
 
 	backendSecurityPolicyC := NewBackendSecurityPolicyController(c, kubernetes.NewForConfigOrDie(config), logger.
-		WithName("backend-security-policy"), backendC.syncAIServiceBackend)
+		WithName("backend-security-policy"), mgr.GetEventRecorderFor("backend-security-policy"), backendC.syncAIServiceBackend)
 	if err = ctrl.NewControllerManagedBy(mgr).
 		For(&aigv1a1.BackendSecurityPolicy{}).
+		WithEventFilter(namespaces.predicate()).
 		Complete(backendSecurityPolicyC); err != nil {
 		return fmt.Errorf("failed to create controller for BackendSecurityPolicy: %w", err)
 	}
@@ -115,7 +190,9 @@ func StartControllers(ctx context.Context, config *rest.Config, logger logr.Logg
 	secretC := NewSecretController(c, kubernetes.NewForConfigOrDie(config), logger.
 		WithName("secret"), backendSecurityPolicyC.syncBackendSecurityPolicy)
 	if err = ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Secret{}).
+		For(&corev1.Secret{}, builder.WithPredicates(secretC.referencedSecretPredicate())).
+		Watches(&gwapiv1b1.ReferenceGrant{}, handler.EnqueueRequestsFromMapFunc(secretC.ReferenceGrantToSecrets)).
+		WithEventFilter(namespaces.predicate()).
 		Complete(secretC); err != nil {
 		return fmt.Errorf("failed to create controller for Secret: %w", err)
 	}
@@ -126,6 +203,58 @@ func StartControllers(ctx context.Context, config *rest.Config, logger logr.Logg
 	return nil
 }
 
+// addHealthChecks adds the ping of the manager and the checks to both of /healthz and /readyz of the manager.
+func addHealthChecks(mgr ctrl.Manager, checks map[string]healthz.Checker) error {
+	all := map[string]healthz.Checker{"ping": healthz.Ping}
+	maps.Copy(all, checks)
+	for _, name := range slices.Sorted(maps.Keys(all)) {
+		if err := mgr.AddHealthzCheck(name, all[name]); err != nil {
+			return fmt.Errorf("failed to add health check %s: %w", name, err)
+		}
+		if err := mgr.AddReadyzCheck(name, all[name]); err != nil {
+			return fmt.Errorf("failed to add ready check %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// namespaceFilter filters the objects by the labels of their namespaces.
+//
+// The namespaces are looked up through the cached client, so the change of the namespace labels takes effect on
+// the next event of the objects in the namespace.
+type namespaceFilter struct {
+	client   client.Client
+	selector labels.Selector
+	logger   logr.Logger
+}
+
+// newNamespaceFilter creates a new namespaceFilter. The nil selector selects all namespaces.
+func newNamespaceFilter(client client.Client, selector labels.Selector, logger logr.Logger) *namespaceFilter {
+	return &namespaceFilter{client: client, selector: selector, logger: logger}
+}
+
+// predicate returns the predicate filtering the events of the objects in the namespaces not selected.
+func (f *namespaceFilter) predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return f.selected(context.Background(), o.GetNamespace())
+	})
+}
+
+// selected returns true if the namespace is selected. The cluster-scoped objects are always selected.
+func (f *namespaceFilter) selected(ctx context.Context, namespace string) bool {
+	if f.selector == nil || f.selector.Empty() || namespace == "" {
+		return true
+	}
+	var ns corev1.Namespace
+	if err := f.client.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+		if !apierrors.IsNotFound(err) {
+			f.logger.Error(err, "failed to get namespace", "namespace", namespace)
+		}
+		return false
+	}
+	return f.selector.Matches(labels.Set(ns.Labels))
+}
+
 const (
 	// k8sClientIndexSecretToReferencingBackendSecurityPolicy is the index name that maps
 	// from a Secret to the BackendSecurityPolicy that references it.
@@ -136,6 +265,12 @@ const (
 	// k8sClientIndexBackendSecurityPolicyToReferencingAIServiceBackend is the index name that maps from a BackendSecurityPolicy
 	// to the AIServiceBackend that references it.
 	k8sClientIndexBackendSecurityPolicyToReferencingAIServiceBackend = "BackendSecurityPolicyToReferencingAIServiceBackend"
+	// k8sClientIndexGatewayToReferencingAIGatewayRoute is the index name that maps from a Gateway to the
+	// AIGatewayRoute that targets it.
+	k8sClientIndexGatewayToReferencingAIGatewayRoute = "GatewayToReferencingAIGatewayRoute"
+	// k8sClientIndexEnvoyGatewayBackendToReferencingAIServiceBackend is the index name that maps from a Backend of
+	// Envoy Gateway to the AIServiceBackend that references it.
+	k8sClientIndexEnvoyGatewayBackendToReferencingAIServiceBackend = "EnvoyGatewayBackendToReferencingAIServiceBackend"
 )
 
 // ApplyIndexing applies indexing to the given indexer. This is exported for testing purposes.
@@ -145,11 +280,21 @@ func ApplyIndexing(ctx context.Context, indexer func(ctx context.Context, obj cl
 	if err != nil {
 		return fmt.Errorf("failed to index field for AIGatewayRoute: %w", err)
 	}
+	err = indexer(ctx, &aigv1a1.AIGatewayRoute{},
+		k8sClientIndexGatewayToReferencingAIGatewayRoute, aiGatewayRouteTargetGatewayIndexFunc)
+	if err != nil {
+		return fmt.Errorf("failed to index field for AIGatewayRoute: %w", err)
+	}
 	err = indexer(ctx, &aigv1a1.AIServiceBackend{},
 		k8sClientIndexBackendSecurityPolicyToReferencingAIServiceBackend, aiServiceBackendIndexFunc)
 	if err != nil {
 		return fmt.Errorf("failed to index field for AIServiceBackend: %w", err)
 	}
+	err = indexer(ctx, &aigv1a1.AIServiceBackend{},
+		k8sClientIndexEnvoyGatewayBackendToReferencingAIServiceBackend, aiServiceBackendEnvoyGatewayBackendIndexFunc)
+	if err != nil {
+		return fmt.Errorf("failed to index field for AIServiceBackend: %w", err)
+	}
 	err = indexer(ctx, &aigv1a1.BackendSecurityPolicy{},
 		k8sClientIndexSecretToReferencingBackendSecurityPolicy, backendSecurityPolicyIndexFunc)
 	if err != nil {
@@ -161,15 +306,26 @@ func ApplyIndexing(ctx context.Context, indexer func(ctx context.Context, obj cl
 func aiGatewayRouteIndexFunc(o client.Object) []string {
 	aiGatewayRoute := o.(*aigv1a1.AIGatewayRoute)
 	var ret []string
-	for _, rule := range aiGatewayRoute.Spec.Rules {
-		for _, backend := range rule.BackendRefs {
-			key := fmt.Sprintf("%s.%s", backend.Name, aiGatewayRoute.Namespace)
+	for i := range aiGatewayRoute.Spec.Rules {
+		for _, name := range ruleBackendNames(&aiGatewayRoute.Spec.Rules[i]) {
+			key := fmt.Sprintf("%s.%s", name, aiGatewayRoute.Namespace)
 			ret = append(ret, key)
 		}
 	}
 	return ret
 }
 
+func aiGatewayRouteTargetGatewayIndexFunc(o client.Object) []string {
+	aiGatewayRoute := o.(*aigv1a1.AIGatewayRoute)
+	var ret []string
+	for _, ref := range aiGatewayRoute.Spec.TargetRefs {
+		if ref.Kind == "Gateway" {
+			ret = append(ret, fmt.Sprintf("%s.%s", ref.Name, aiGatewayRoute.Namespace))
+		}
+	}
+	return ret
+}
+
 func aiServiceBackendIndexFunc(o client.Object) []string {
 	aiServiceBackend := o.(*aigv1a1.AIServiceBackend)
 	var ret []string
@@ -179,6 +335,15 @@ func aiServiceBackendIndexFunc(o client.Object) []string {
 	return ret
 }
 
+func aiServiceBackendEnvoyGatewayBackendIndexFunc(o client.Object) []string {
+	aiServiceBackend := o.(*aigv1a1.AIServiceBackend)
+	if !isEnvoyGatewayBackendRef(&aiServiceBackend.Spec.BackendRef) {
+		return nil
+	}
+	key := backendRefKey(aiServiceBackend)
+	return []string{fmt.Sprintf("%s.%s", key.Name, key.Namespace)}
+}
+
 func backendSecurityPolicyIndexFunc(o client.Object) []string {
 	backendSecurityPolicy := o.(*aigv1a1.BackendSecurityPolicy)
 	var key string
@@ -193,6 +358,14 @@ func backendSecurityPolicyIndexFunc(o client.Object) []string {
 		} else if awsCreds.OIDCExchangeToken != nil {
 			key = backendSecurityPolicyKey(backendSecurityPolicy.Namespace, backendSecurityPolicy.Name)
 		}
+	case aigv1a1.BackendSecurityPolicyTypeAzureCredentials:
+		// The access token is stored in the secret managed by the rotator, so the policy is
+		// re-synced both when the client secret changes and when the access token is rotated.
+		keys := []string{backendSecurityPolicyKey(backendSecurityPolicy.Namespace, rotators.GetBSPSecretName(backendSecurityPolicy.Name))}
+		if azureCreds := backendSecurityPolicy.Spec.AzureCredentials; azureCreds != nil && azureCreds.ClientSecretRef != nil {
+			keys = append(keys, getSecretNameAndNamespace(azureCreds.ClientSecretRef, backendSecurityPolicy.Namespace))
+		}
+		return keys
 	}
 	return []string{key}
 }
@@ -8,12 +8,16 @@ package controller
 import (
 	"testing"
 
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
@@ -45,8 +49,8 @@ func Test_aiGatewayRouteIndexFunc(t *testing.T) {
 				{
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{},
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "backend1", Weight: 1},
-						{Name: "backend2", Weight: 1},
+						{Name: "backend1", Weight: ptr.To[int32](1)},
+						{Name: "backend2", Weight: ptr.To[int32](1)},
 					},
 				},
 			},
@@ -135,6 +139,30 @@ func Test_backendSecurityPolicyIndexFunc(t *testing.T) {
 			},
 			expKey: "some-secret4.ns",
 		},
+		{
+			name: "azure credentials client secret",
+			backendSecurityPolicy: &aigv1a1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-5", Namespace: "ns"},
+				Spec: aigv1a1.BackendSecurityPolicySpec{
+					Type: aigv1a1.BackendSecurityPolicyTypeAzureCredentials,
+					AzureCredentials: &aigv1a1.BackendSecurityPolicyAzureCredentials{
+						ClientSecretRef: &gwapiv1.SecretObjectReference{Name: "some-secret5"},
+					},
+				},
+			},
+			expKey: "some-secret5.ns",
+		},
+		{
+			name: "azure credentials access token",
+			backendSecurityPolicy: &aigv1a1.BackendSecurityPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-backend-security-policy-6", Namespace: "ns"},
+				Spec: aigv1a1.BackendSecurityPolicySpec{
+					Type:             aigv1a1.BackendSecurityPolicyTypeAzureCredentials,
+					AzureCredentials: &aigv1a1.BackendSecurityPolicyAzureCredentials{OIDCExchangeToken: &aigv1a1.AzureOIDCExchangeToken{}},
+				},
+			},
+			expKey: "ai-eg-bsp-some-backend-security-policy-6.ns",
+		},
 	} {
 		t.Run(bsp.name, func(t *testing.T) {
 			c := fake.NewClientBuilder().
@@ -165,3 +193,34 @@ func Test_getSecretNameAndNamespace(t *testing.T) {
 	secretRef.Namespace = nil
 	require.Equal(t, "mysecret.foo", getSecretNameAndNamespace(secretRef, "foo"))
 }
+
+func Test_namespaceFilter(t *testing.T) {
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ai", Labels: map[string]string{"team": "ai"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"team": "web"}}},
+	).Build()
+	route := func(namespace string) client.Object {
+		return &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: namespace}}
+	}
+
+	t.Run("no selector", func(t *testing.T) {
+		f := newNamespaceFilter(c, nil, logr.Discard())
+		for _, ns := range []string{"ai", "web", "missing"} {
+			require.True(t, f.selected(t.Context(), ns))
+		}
+	})
+	t.Run("selector", func(t *testing.T) {
+		f := newNamespaceFilter(c, labels.SelectorFromSet(labels.Set{"team": "ai"}), logr.Discard())
+		require.True(t, f.selected(t.Context(), "ai"))
+		require.False(t, f.selected(t.Context(), "web"))
+		require.False(t, f.selected(t.Context(), "missing"))
+		// The cluster-scoped objects are always selected.
+		require.True(t, f.selected(t.Context(), ""))
+
+		p := f.predicate()
+		require.True(t, p.Create(event.CreateEvent{Object: route("ai")}))
+		require.False(t, p.Create(event.CreateEvent{Object: route("web")}))
+		require.False(t, p.Update(event.UpdateEvent{ObjectOld: route("web"), ObjectNew: route("web")}))
+		require.False(t, p.Delete(event.DeleteEvent{Object: route("web")}))
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import "errors"
+
+// The reasons of the Kubernetes Events emitted by the controllers. These are stable so that users can filter on them.
+const (
+	// EventReasonBackendNotFound is the reason of the Warning event emitted when an AIServiceBackend referenced by
+	// the AIGatewayRoute does not exist.
+	EventReasonBackendNotFound = "BackendNotFound"
+	// EventReasonInvalidCELExpression is the reason of the Warning event emitted when a CEL expression of the
+	// LLMRequestCosts of the AIGatewayRoute is invalid.
+	EventReasonInvalidCELExpression = "InvalidCELExpression"
+	// EventReasonExtProcDeploymentFailed is the reason of the Warning event emitted when the external processor
+	// of the AIGatewayRoute cannot be deployed.
+	EventReasonExtProcDeploymentFailed = "ExtProcDeploymentFailed"
+	// EventReasonSyncFailed is the reason of the Warning event emitted on the other sync failures.
+	EventReasonSyncFailed = "SyncFailed"
+	// EventReasonProgrammed is the reason of the Normal event emitted when the AIGatewayRoute is programmed.
+	EventReasonProgrammed = "Programmed"
+)
+
+// eventReasonError is an error annotated with the reason of the Warning event emitted for it.
+type eventReasonError struct {
+	reason string
+	err    error
+}
+
+// withEventReason annotates the err with the reason of the Warning event emitted for it.
+func withEventReason(reason string, err error) error {
+	return &eventReasonError{reason: reason, err: err}
+}
+
+// Error implements [error].
+func (e *eventReasonError) Error() string { return e.err.Error() }
+
+// Unwrap returns the annotated error.
+func (e *eventReasonError) Unwrap() error { return e.err }
+
+// eventReason returns the reason of the Warning event emitted for the err, or EventReasonSyncFailed if the err
+// is not annotated.
+func eventReason(err error) string {
+	var e *eventReasonError
+	if errors.As(err, &e) {
+		return e.reason
+	}
+	return EventReasonSyncFailed
+}
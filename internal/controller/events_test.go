@@ -0,0 +1,23 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_eventReason(t *testing.T) {
+	err := withEventReason(EventReasonBackendNotFound, errors.New("AIServiceBackend foo.ns not found"))
+	require.EqualError(t, err, "AIServiceBackend foo.ns not found")
+	require.Equal(t, EventReasonBackendNotFound, eventReason(err))
+	require.Equal(t, EventReasonBackendNotFound, eventReason(fmt.Errorf("failed to sync: %w", err)))
+	require.Equal(t, EventReasonBackendNotFound, eventReason(errors.Join(errors.New("foo"), err)))
+	require.Equal(t, EventReasonSyncFailed, eventReason(errors.New("foo")))
+}
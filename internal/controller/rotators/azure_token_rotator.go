@@ -0,0 +1,198 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package rotators
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// AzureAccessTokenKey is the key of the access token in the secret managed by [AzureTokenRotator].
+	AzureAccessTokenKey = "azureAccessToken" // #nosec G101
+	// azureClientSecretKey is the key of the client secret in the secret referenced by the policy.
+	azureClientSecretKey = "clientSecret" // #nosec G101
+	// azureDefaultAuthorityHost is the Microsoft Entra ID endpoint of the Azure public cloud.
+	azureDefaultAuthorityHost = "https://login.microsoftonline.com"
+	// azureClientAssertionType is the client assertion type of the federated identity credential.
+	azureClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	// azureTokenTimeout specifies the timeout of the token request.
+	azureTokenTimeout = time.Minute
+)
+
+// AzureTokenRotator implements the Rotator interface for the Microsoft Entra ID access token.
+// It obtains the access token with the client credentials flow, authenticating the application either
+// with the client secret or with the oidc token as the client assertion of the federated identity credential.
+type AzureTokenRotator struct {
+	// client is used for Kubernetes API operations.
+	client client.Client
+	// logger is used for structured logging.
+	logger logr.Logger
+	// backendSecurityPolicyName provides name of backend security policy.
+	backendSecurityPolicyName string
+	// backendSecurityPolicyNamespace provides namespace of backend security policy.
+	backendSecurityPolicyNamespace string
+	// preRotationWindow specifies how long before expiry to rotate.
+	preRotationWindow time.Duration
+	// tokenURL is the token endpoint of the tenant.
+	tokenURL string
+	// clientID is the application (client) ID.
+	clientID string
+	// scope is the scope of the access token.
+	scope string
+	// clientSecretRef is the reference to the secret containing the client secret. When nil, the oidc token
+	// passed to Rotate is used as the client assertion instead.
+	clientSecretRef *corev1.SecretReference
+}
+
+// NewAzureTokenRotator creates a new Azure token rotator with the specified configuration.
+//
+// The Microsoft Entra ID endpoint can be overridden with the AI_GATEWAY_AZURE_AUTHORITY_HOST environment variable,
+// for example, for the sovereign clouds.
+func NewAzureTokenRotator(
+	client client.Client,
+	logger logr.Logger,
+	backendSecurityPolicyNamespace string,
+	backendSecurityPolicyName string,
+	preRotationWindow time.Duration,
+	tenantID string,
+	clientID string,
+	scope string,
+	clientSecretRef *corev1.SecretReference,
+) *AzureTokenRotator {
+	authorityHost := cmp.Or(os.Getenv("AI_GATEWAY_AZURE_AUTHORITY_HOST"), azureDefaultAuthorityHost)
+	return &AzureTokenRotator{
+		client:                         client,
+		logger:                         logger.WithName("azure-token-rotator"),
+		backendSecurityPolicyNamespace: backendSecurityPolicyNamespace,
+		backendSecurityPolicyName:      backendSecurityPolicyName,
+		preRotationWindow:              preRotationWindow,
+		tokenURL:                       fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityHost, "/"), tenantID),
+		clientID:                       clientID,
+		scope:                          scope,
+		clientSecretRef:                clientSecretRef,
+	}
+}
+
+// IsExpired checks if the preRotation time is before the current time.
+func (r *AzureTokenRotator) IsExpired(preRotationExpirationTime time.Time) bool {
+	return IsBufferedTimeExpired(0, preRotationExpirationTime)
+}
+
+// GetPreRotationTime gets the expiration time minus the preRotation interval or return zero value for time.
+func (r *AzureTokenRotator) GetPreRotationTime(ctx context.Context) (time.Time, error) {
+	secret, err := LookupSecret(ctx, r.client, r.backendSecurityPolicyNamespace, GetBSPSecretName(r.backendSecurityPolicyName))
+	if err != nil {
+		// return zero value for time if secret has not been created.
+		if apierrors.IsNotFound(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	expirationTime, err := GetExpirationSecretAnnotation(secret)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return expirationTime.Add(-r.preRotationWindow), nil
+}
+
+// Rotate implements the retrieval and storage of the Microsoft Entra ID access token.
+// The token is the oidc token used as the client assertion, and is ignored when the client secret is used.
+//
+// This implements [Rotator.Rotate].
+func (r *AzureTokenRotator) Rotate(ctx context.Context, token string) error {
+	r.logger.Info("rotating Azure access token",
+		"namespace", r.backendSecurityPolicyNamespace,
+		"name", r.backendSecurityPolicyName)
+
+	accessToken, err := r.fetchAccessToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	secret, err := LookupSecret(ctx, r.client, r.backendSecurityPolicyNamespace, GetBSPSecretName(r.backendSecurityPolicyName))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if !exists {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      GetBSPSecretName(r.backendSecurityPolicyName),
+				Namespace: r.backendSecurityPolicyNamespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[AzureAccessTokenKey] = []byte(accessToken.AccessToken)
+	updateExpirationSecretAnnotation(secret, accessToken.Expiry)
+
+	if exists {
+		if err = r.client.Update(ctx, secret); err != nil {
+			return fmt.Errorf("failed to update secret: %w", err)
+		}
+		return nil
+	}
+	if err = r.client.Create(ctx, secret); err != nil {
+		return fmt.Errorf("failed to create secret: %w", err)
+	}
+	return nil
+}
+
+// fetchAccessToken obtains the access token from the token endpoint of the tenant.
+func (r *AzureTokenRotator) fetchAccessToken(ctx context.Context, oidcToken string) (*oauth2.Token, error) {
+	config := clientcredentials.Config{
+		ClientID:  r.clientID,
+		TokenURL:  r.tokenURL,
+		Scopes:    []string{r.scope},
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+	if r.clientSecretRef != nil {
+		secret, err := LookupSecret(ctx, r.client, r.clientSecretRef.Namespace, r.clientSecretRef.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client secret: %w", err)
+		}
+		clientSecret, ok := secret.Data[azureClientSecretKey]
+		if !ok {
+			return nil, fmt.Errorf("missing %s in secret %s/%s", azureClientSecretKey, secret.Namespace, secret.Name)
+		}
+		config.ClientSecret = strings.TrimSpace(string(clientSecret))
+	} else {
+		config.EndpointParams = url.Values{
+			"client_assertion_type": {azureClientAssertionType},
+			"client_assertion":      {oidcToken},
+		}
+	}
+
+	// Underlying token call will apply http client timeout.
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Timeout: azureTokenTimeout})
+	token, err := config.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Azure access token: %w", err)
+	}
+	if token.Expiry.IsZero() {
+		return nil, errors.New("access token has no expiry")
+	}
+	return token, nil
+}
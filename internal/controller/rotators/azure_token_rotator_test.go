@@ -0,0 +1,84 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package rotators
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAzureTokenRotator(t *testing.T) {
+	var form url.Values
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/tenant/oauth2/v2.0/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token":"some-access-token","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+	t.Setenv("AI_GATEWAY_AZURE_AUTHORITY_HOST", tokenServer.URL+"/")
+
+	t.Run("client secret", func(t *testing.T) {
+		cl := fake.NewClientBuilder().Build()
+		require.NoError(t, cl.Create(t.Context(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "azure-client-secret", Namespace: "default"},
+			Data:       map[string][]byte{azureClientSecretKey: []byte("some-client-secret\n")},
+		}))
+		r := NewAzureTokenRotator(cl, logr.Discard(), "default", "bsp", 5*time.Minute, "tenant", "client-id", "scope/.default",
+			&corev1.SecretReference{Name: "azure-client-secret", Namespace: "default"})
+
+		preRotationTime, err := r.GetPreRotationTime(t.Context())
+		require.NoError(t, err)
+		require.True(t, preRotationTime.IsZero())
+		require.True(t, r.IsExpired(preRotationTime))
+
+		require.NoError(t, r.Rotate(t.Context(), ""))
+		require.Equal(t, "client_credentials", form.Get("grant_type"))
+		require.Equal(t, "client-id", form.Get("client_id"))
+		require.Equal(t, "some-client-secret", form.Get("client_secret"))
+		require.Equal(t, "scope/.default", form.Get("scope"))
+		require.Empty(t, form.Get("client_assertion"))
+
+		secret, err := LookupSecret(t.Context(), cl, "default", GetBSPSecretName("bsp"))
+		require.NoError(t, err)
+		require.Equal(t, "some-access-token", string(secret.Data[AzureAccessTokenKey]))
+		preRotationTime, err = r.GetPreRotationTime(t.Context())
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(55*time.Minute), preRotationTime, time.Minute)
+		require.False(t, r.IsExpired(preRotationTime))
+
+		// Rotating again updates the existing secret.
+		require.NoError(t, r.Rotate(t.Context(), ""))
+	})
+	t.Run("client assertion", func(t *testing.T) {
+		cl := fake.NewClientBuilder().Build()
+		r := NewAzureTokenRotator(cl, logr.Discard(), "default", "bsp", 5*time.Minute, "tenant", "client-id", "scope/.default", nil)
+		require.NoError(t, r.Rotate(t.Context(), "some-oidc-token"))
+		require.Equal(t, azureClientAssertionType, form.Get("client_assertion_type"))
+		require.Equal(t, "some-oidc-token", form.Get("client_assertion"))
+		require.Empty(t, form.Get("client_secret"))
+
+		secret, err := LookupSecret(t.Context(), cl, "default", GetBSPSecretName("bsp"))
+		require.NoError(t, err)
+		require.Equal(t, "some-access-token", string(secret.Data[AzureAccessTokenKey]))
+	})
+	t.Run("missing client secret", func(t *testing.T) {
+		cl := fake.NewClientBuilder().Build()
+		r := NewAzureTokenRotator(cl, logr.Discard(), "default", "bsp", 5*time.Minute, "tenant", "client-id", "scope/.default",
+			&corev1.SecretReference{Name: "azure-client-secret", Namespace: "default"})
+		require.ErrorContains(t, r.Rotate(t.Context(), ""), "failed to get client secret")
+	})
+}
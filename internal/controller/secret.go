@@ -14,26 +14,33 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/kubernetes"
+	toolscache "k8s.io/client-go/tools/cache"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 )
 
-// secretController implements reconcile.TypedReconciler for corev1.Secret.
-type secretController struct {
+// SecretController implements reconcile.TypedReconciler for corev1.Secret.
+//
+// Exported for testing purposes.
+type SecretController struct {
 	client                    client.Client
 	kubeClient                kubernetes.Interface
 	logger                    logr.Logger
 	syncBackendSecurityPolicy syncBackendSecurityPolicyFn
 }
 
-// NewSecretController creates a new reconcile.TypedReconciler[reconcile.Request] for corev1.Secret.
+// NewSecretController creates a new SecretController.
 func NewSecretController(client client.Client, kubeClient kubernetes.Interface,
 	logger logr.Logger, syncBackendSecurityPolicy syncBackendSecurityPolicyFn,
-) reconcile.TypedReconciler[reconcile.Request] {
-	return &secretController{
+) *SecretController {
+	return &SecretController{
 		client:                    client,
 		kubeClient:                kubeClient,
 		logger:                    logger,
@@ -42,7 +49,7 @@ func NewSecretController(client client.Client, kubeClient kubernetes.Interface,
 }
 
 // Reconcile implements the reconcile.Reconciler for corev1.Secret.
-func (c *secretController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (c *SecretController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var secret corev1.Secret
 	if err := c.client.Get(ctx, req.NamespacedName, &secret); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -57,8 +64,84 @@ func (c *secretController) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	return ctrl.Result{}, nil
 }
 
+// referencedSecretPredicate returns the predicate filtering the events of the secrets not referenced by any
+// BackendSecurityPolicy, so that the churn of the unrelated secrets in the cluster does not trigger reconciliation.
+//
+// The lookup goes through the index of the cached client, hence it does not hit the API server. The secret
+// referenced later is reconciled via the BackendSecurityPolicy controller, which syncs the policy on creation
+// and update.
+func (c *SecretController) referencedSecretPredicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return c.referenced(context.Background(), o.GetNamespace(), o.GetName())
+	})
+}
+
+// referenced returns true if the secret is referenced by at least one BackendSecurityPolicy. When the lookup fails,
+// this returns true so that the event is not lost.
+func (c *SecretController) referenced(ctx context.Context, namespace, name string) bool {
+	return secretReferenced(ctx, c.client, c.logger, namespace, name)
+}
+
+// secretReferenced returns true if the secret is referenced by at least one BackendSecurityPolicy, or if the lookup
+// fails.
+func secretReferenced(ctx context.Context, r client.Reader, logger logr.Logger, namespace, name string) bool {
+	var backendSecurityPolicies aigv1a1.BackendSecurityPolicyList
+	err := r.List(ctx, &backendSecurityPolicies,
+		client.MatchingFields{
+			k8sClientIndexSecretToReferencingBackendSecurityPolicy: backendSecurityPolicyKey(namespace, name),
+		},
+	)
+	if err != nil {
+		logger.Error(err, "failed to list BackendSecurityPolicyList", "namespace", namespace, "name", name)
+		return true
+	}
+	return len(backendSecurityPolicies.Items) > 0
+}
+
+// newSecretCacheTransform returns the cache transform stripping the managed fields of the secrets, as well as the data
+// of the secrets not referenced by any BackendSecurityPolicy, since the cluster may have a large number of secrets
+// unrelated to the AI Gateway. The reader is resolved lazily as the transform is set up before the manager creating
+// the cached client.
+//
+// The secret referenced after it is cached stays stripped until it is updated, hence its data must be read via
+// [secretDataClient].
+func newSecretCacheTransform(reader func() client.Reader, logger logr.Logger) toolscache.TransformFunc {
+	stripManagedFields := cache.TransformStripManagedFields()
+	return func(o any) (any, error) {
+		o, err := stripManagedFields(o)
+		if err != nil {
+			return o, err
+		}
+		secret, ok := o.(*corev1.Secret)
+		if !ok || secretReferenced(context.Background(), reader(), logger, secret.Namespace, secret.Name) {
+			return o, nil
+		}
+		secret.Data = nil
+		secret.StringData = nil
+		return secret, nil
+	}
+}
+
+// secretDataClient is the [client.Client] reading the secrets whose data is stripped from the cache by
+// [newSecretCacheTransform] from the API server.
+type secretDataClient struct {
+	client.Client
+	apiReader client.Reader
+}
+
+// Get implements [client.Reader.Get].
+func (c secretDataClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Client.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	if secret, ok := obj.(*corev1.Secret); ok && secret.Data == nil {
+		return c.apiReader.Get(ctx, key, obj, opts...)
+	}
+	return nil
+}
+
 // syncSecret syncs the state of all resource referencing the given secret.
-func (c *secretController) syncSecret(ctx context.Context, namespace, name string) error {
+func (c *SecretController) syncSecret(ctx context.Context, namespace, name string) error {
 	var backendSecurityPolicies aigv1a1.BackendSecurityPolicyList
 	err := c.client.List(ctx, &backendSecurityPolicies,
 		client.MatchingFields{
@@ -82,3 +165,36 @@ func (c *secretController) syncSecret(ctx context.Context, namespace, name strin
 	}
 	return nil
 }
+
+// ReferenceGrantToSecrets maps the ReferenceGrant to the secrets in its namespace referenced by the
+// BackendSecurityPolicies in the namespaces it permits, so that the policies are re-synced when the grant changes.
+//
+// This is exported for testing purposes.
+func (c *SecretController) ReferenceGrantToSecrets(ctx context.Context, obj client.Object) []reconcile.Request {
+	grant, ok := obj.(*gwapiv1b1.ReferenceGrant)
+	if !ok {
+		panic(fmt.Sprintf("unexpected object type: %T", obj))
+	}
+	var requests []reconcile.Request
+	for _, from := range grant.Spec.From {
+		if from.Group != aigv1a1.GroupName || from.Kind != "BackendSecurityPolicy" {
+			continue
+		}
+		var backendSecurityPolicies aigv1a1.BackendSecurityPolicyList
+		if err := c.client.List(ctx, &backendSecurityPolicies, client.InNamespace(string(from.Namespace))); err != nil {
+			c.logger.Error(err, "failed to list BackendSecurityPolicyList", "namespace", from.Namespace)
+			continue
+		}
+		for i := range backendSecurityPolicies.Items {
+			for _, ref := range backendSecurityPolicySecretRefs(&backendSecurityPolicies.Items[i]) {
+				if ref.Namespace == nil || *ref.Namespace != gwapiv1.Namespace(grant.Namespace) {
+					continue
+				}
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{
+					Namespace: grant.Namespace, Name: string(ref.Name),
+				}})
+			}
+		}
+	}
+	return requests
+}
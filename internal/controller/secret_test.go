@@ -6,17 +6,23 @@
 package controller
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	fake2 "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
@@ -80,3 +86,139 @@ func TestSecretController_Reconcile(t *testing.T) {
 	}})
 	require.NoError(t, err)
 }
+
+func TestSecretController_ReferenceGrantToSecrets(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewSecretController(fakeClient, fake2.NewClientset(), ctrl.Log, internaltesting.NewSyncFnImpl[aigv1a1.BackendSecurityPolicy]().Sync)
+
+	for _, bsp := range []*aigv1a1.BackendSecurityPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "team-a"},
+			Spec: aigv1a1.BackendSecurityPolicySpec{
+				Type: aigv1a1.BackendSecurityPolicyTypeAPIKey,
+				APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{
+					Name: "mysecret", Namespace: ptr.To[gwapiv1.Namespace]("team-b"),
+				}},
+			},
+		},
+		{
+			// The secret in the same namespace is not affected by the ReferenceGrant.
+			ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "team-a"},
+			Spec: aigv1a1.BackendSecurityPolicySpec{
+				Type:   aigv1a1.BackendSecurityPolicyTypeAPIKey,
+				APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"}},
+			},
+		},
+	} {
+		require.NoError(t, fakeClient.Create(t.Context(), bsp))
+	}
+
+	requests := c.ReferenceGrantToSecrets(t.Context(), &gwapiv1b1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "team-b"},
+		Spec: gwapiv1b1.ReferenceGrantSpec{
+			From: []gwapiv1b1.ReferenceGrantFrom{
+				{Group: aigv1a1.GroupName, Kind: "BackendSecurityPolicy", Namespace: "team-a"},
+				{Group: gwapiv1.GroupName, Kind: "HTTPRoute", Namespace: "team-a"},
+			},
+			To: []gwapiv1b1.ReferenceGrantTo{{Kind: "Secret"}},
+		},
+	})
+	require.Equal(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "team-b", Name: "mysecret"}},
+	}, requests)
+}
+
+func TestSecretController_referencedSecretPredicate(t *testing.T) {
+	syncFn := internaltesting.NewSyncFnImpl[aigv1a1.BackendSecurityPolicy]()
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	c := NewSecretController(fakeClient, fake2.NewClientset(), ctrl.Log, syncFn.Sync)
+
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type:   aigv1a1.BackendSecurityPolicyTypeAPIKey,
+			APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"}},
+		},
+	}))
+
+	secret := func(namespace, name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	p := c.referencedSecretPredicate()
+	require.True(t, p.Create(event.CreateEvent{Object: secret("default", "mysecret")}))
+	require.True(t, p.Update(event.UpdateEvent{ObjectOld: secret("default", "mysecret"), ObjectNew: secret("default", "mysecret")}))
+	require.True(t, p.Delete(event.DeleteEvent{Object: secret("default", "mysecret")}))
+	// The secret with the same name in another namespace is not referenced.
+	require.False(t, p.Update(event.UpdateEvent{ObjectOld: secret("other", "mysecret"), ObjectNew: secret("other", "mysecret")}))
+
+	// Simulate the churn of the unrelated secrets, e.g. the certificates renewed by cert-manager, none of which
+	// should reach the reconciler.
+	var reconciled int
+	for i := range 1000 {
+		s := secret("default", fmt.Sprintf("cert-%d", i))
+		require.NoError(t, fakeClient.Create(t.Context(), s))
+		if !p.Update(event.UpdateEvent{ObjectOld: s, ObjectNew: s}) {
+			continue
+		}
+		reconciled++
+		_, err := c.Reconcile(t.Context(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(s)})
+		require.NoError(t, err)
+	}
+	require.Zero(t, reconciled)
+	require.Empty(t, syncFn.GetItems())
+}
+
+func TestNewSecretCacheTransform(t *testing.T) {
+	fakeClient := requireNewFakeClientWithIndexes(t)
+	require.NoError(t, fakeClient.Create(t.Context(), &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type:   aigv1a1.BackendSecurityPolicyTypeAPIKey,
+			APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret"}},
+		},
+	}))
+	transform := newSecretCacheTransform(func() client.Reader { return fakeClient }, ctrl.Log)
+	secret := func(namespace, name string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: namespace, ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			},
+			Data: map[string][]byte{"apiKey": []byte("secret")},
+		}
+	}
+
+	o, err := transform(secret("default", "mysecret"))
+	require.NoError(t, err)
+	require.Empty(t, o.(*corev1.Secret).ManagedFields)
+	require.Equal(t, map[string][]byte{"apiKey": []byte("secret")}, o.(*corev1.Secret).Data)
+
+	// The secret with the same name in another namespace is not referenced.
+	o, err = transform(secret("other", "mysecret"))
+	require.NoError(t, err)
+	require.Empty(t, o.(*corev1.Secret).ManagedFields)
+	require.Nil(t, o.(*corev1.Secret).Data)
+}
+
+func TestSecretDataClient_Get(t *testing.T) {
+	cached := requireNewFakeClientWithIndexes(t)
+	apiReader := requireNewFakeClientWithIndexes(t)
+	for _, s := range []*corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Name: "stripped", Namespace: "ns"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cached", Namespace: "ns"}, Data: map[string][]byte{"key": []byte("cached")}},
+	} {
+		require.NoError(t, cached.Create(t.Context(), s))
+	}
+	require.NoError(t, apiReader.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "stripped", Namespace: "ns"}, Data: map[string][]byte{"key": []byte("api")},
+	}))
+	c := secretDataClient{Client: cached, apiReader: apiReader}
+
+	var secret corev1.Secret
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "stripped", Namespace: "ns"}, &secret))
+	require.Equal(t, []byte("api"), secret.Data["key"])
+	// The secret with the data in the cache is not read from the API server.
+	require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "cached", Namespace: "ns"}, &secret))
+	require.Equal(t, []byte("cached"), secret.Data["key"])
+	err := c.Get(t.Context(), client.ObjectKey{Name: "missing", Namespace: "ns"}, &secret)
+	require.True(t, apierrors.IsNotFound(err))
+}
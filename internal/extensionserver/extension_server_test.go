@@ -6,29 +6,48 @@
 package extensionserver
 
 import (
+	"net"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func TestNew(t *testing.T) {
 	logger := logr.Discard()
-	s := New(logger)
+	s := New(nil, logger)
 	require.NotNil(t, s)
 }
 
 func TestCheck(t *testing.T) {
 	logger := logr.Discard()
-	s := New(logger)
+	s := New(nil, logger)
 	_, err := s.Check(t.Context(), nil)
 	require.NoError(t, err)
 }
 
 func TestWatch(t *testing.T) {
 	logger := logr.Discard()
-	s := New(logger)
+	s := New(nil, logger)
 	err := s.Watch(nil, nil)
 	require.Error(t, err)
 	require.Equal(t, "rpc error: code = Unimplemented desc = Watch is not implemented", err.Error())
 }
+
+func TestHealthChecker(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, New(nil, logr.Discard()))
+	go func() { _ = s.Serve(lis) }()
+
+	check := HealthChecker(lis.Addr().String())
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	require.NoError(t, check(req))
+
+	s.Stop()
+	require.ErrorContains(t, check(req), "extension server is not serving")
+}
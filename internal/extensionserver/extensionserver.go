@@ -7,24 +7,32 @@ package extensionserver
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"time"
 
 	pb "github.com/envoyproxy/gateway/proto/extension"
 	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Server is the implementation of the EnvoyGatewayExtensionServer interface.
 type Server struct {
 	pb.UnimplementedEnvoyGatewayExtensionServer
 	log logr.Logger
+	// k8sClient is used to read the HTTPRoutes the clusters are generated for.
+	k8sClient client.Reader
 }
 
 // New creates a new instance of the extension server that implements the EnvoyGatewayExtensionServer interface.
-func New(logger logr.Logger) *Server {
+func New(k8sClient client.Reader, logger logr.Logger) *Server {
 	logger = logger.WithName("envoy-gateway-extension-server")
-	return &Server{log: logger}
+	return &Server{log: logger, k8sClient: k8sClient}
 }
 
 // Check implements [grpc_health_v1.HealthServer].
@@ -36,3 +44,29 @@ func (s *Server) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*gr
 func (s *Server) Watch(*grpc_health_v1.HealthCheckRequest, grpc_health_v1.Health_WatchServer) error {
 	return status.Error(codes.Unimplemented, "Watch is not implemented")
 }
+
+// healthCheckTimeout is the timeout of the gRPC health check by [HealthChecker].
+const healthCheckTimeout = time.Second
+
+// HealthChecker returns the health check of the extension server served at the address for the health probes of the
+// controller manager. This calls the gRPC health check through the address, so it fails unless the gRPC server is
+// serving on the listener.
+func HealthChecker(addr string) func(*http.Request) error {
+	return func(r *http.Request) error {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return fmt.Errorf("failed to create the client of the extension server: %w", err)
+		}
+		defer func() { _ = conn.Close() }()
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		defer cancel()
+		res, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			return fmt.Errorf("extension server is not serving: %w", err)
+		}
+		if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			return fmt.Errorf("extension server is %s", res.Status)
+		}
+		return nil
+	}
+}
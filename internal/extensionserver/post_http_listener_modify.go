@@ -0,0 +1,207 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	accesslogv3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	hcmv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// OverrideErrorResponsesLabelKey is the label of the HTTPRoute generated for an AIGatewayRoute with
+// OverrideErrorResponses enabled. The local replies of Envoy to the requests routed by the HTTPRoute when the backend
+// is not available are overridden with the OpenAI errors by the local reply config of the listener.
+const OverrideErrorResponsesLabelKey = "aigateway.envoyproxy.io/override-error-responses"
+
+// errorResponseOverrides is the status codes of the local replies of Envoy when the backend is not available,
+// and the messages of the OpenAI errors they are overridden with.
+var errorResponseOverrides = []struct {
+	status  int
+	message string
+}{
+	{status: http.StatusBadGateway, message: "the backend returned an invalid response or the connection failed"},
+	{status: http.StatusServiceUnavailable, message: "the backend is unavailable"},
+	{status: http.StatusGatewayTimeout, message: "the backend did not respond in time"},
+}
+
+// upstreamFailureResponseFlags is the response flags of the local replies of Envoy when the backend is not available,
+// e.g., "UF" for the upstream connection failure and "UT" for the upstream request timeout.
+var upstreamFailureResponseFlags = []string{"UH", "UF", "UO", "UT", "UC", "URX", "UPE"}
+
+// PostHTTPListenerModify implements [pb.EnvoyGatewayExtensionServer].
+//
+// This overrides the local replies of Envoy with the OpenAI errors for the requests routed by the HTTPRoutes attached
+// to the listener with OverrideErrorResponsesLabelKey, so that the clients do not receive the plain text body of Envoy
+// when the backend cannot be reached and the AI Gateway filter never sees the response. Only the local replies caused
+// by the upstream failures are overridden, and the error responses from the backends are passed through as-is.
+func (s *Server) PostHTTPListenerModify(ctx context.Context, req *pb.PostHTTPListenerModifyRequest) (*pb.PostHTTPListenerModifyResponse, error) {
+	listener := req.Listener
+	headers, err := s.errorResponseOverrideHeaders(ctx, listener.GetName())
+	if err != nil {
+		// The failure must not block the translation of the rest of the configuration.
+		s.log.Error(err, "failed to list the HTTPRoutes overriding the error responses", "listener", listener.GetName())
+		return &pb.PostHTTPListenerModifyResponse{Listener: listener}, nil
+	}
+	if len(headers) == 0 {
+		return &pb.PostHTTPListenerModifyResponse{Listener: listener}, nil
+	}
+	mappers := newErrorResponseMappers(headers)
+	for _, chain := range append(slices.Clone(listener.GetFilterChains()), listener.GetDefaultFilterChain()) {
+		for _, filter := range chain.GetFilters() {
+			if err = addLocalReplyMappers(filter, mappers); err != nil {
+				s.log.Error(err, "failed to override the error responses", "listener", listener.GetName())
+			}
+		}
+	}
+	return &pb.PostHTTPListenerModifyResponse{Listener: listener}, nil
+}
+
+// errorResponseOverrideHeaders returns the header matches selecting the backends of the HTTPRoutes with
+// OverrideErrorResponsesLabelKey attached to the Gateway of the listener named "<namespace>/<gateway>/<listener>"
+// by Envoy Gateway. The values of the same header are merged into a single regular expression.
+func (s *Server) errorResponseOverrideHeaders(ctx context.Context, listenerName string) (map[string][]string, error) {
+	var routes gwapiv1.HTTPRouteList
+	if err := s.k8sClient.List(ctx, &routes, client.MatchingLabels{OverrideErrorResponsesLabelKey: "true"}); err != nil {
+		return nil, err
+	}
+	gatewayNamespace, gatewayName, ok := parseListenerName(listenerName)
+	headers := make(map[string][]string)
+	for i := range routes.Items {
+		route := &routes.Items[i]
+		if ok && !slices.ContainsFunc(route.Spec.ParentRefs, func(ref gwapiv1.ParentReference) bool {
+			namespace := route.Namespace
+			if ref.Namespace != nil {
+				namespace = string(*ref.Namespace)
+			}
+			return string(ref.Name) == gatewayName && namespace == gatewayNamespace
+		}) {
+			continue
+		}
+		for _, rule := range route.Spec.Rules {
+			for _, match := range rule.Matches {
+				for _, h := range match.Headers {
+					name := strings.ToLower(string(h.Name))
+					if !slices.Contains(headers[name], h.Value) {
+						headers[name] = append(headers[name], h.Value)
+					}
+				}
+			}
+		}
+	}
+	return headers, nil
+}
+
+// parseListenerName parses the listener name "<namespace>/<gateway>/<listener>" generated by Envoy Gateway.
+func parseListenerName(name string) (namespace, gateway string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// newErrorResponseMappers returns the local reply mappers overriding the local replies to the requests matching any
+// of the headers with the OpenAI errors.
+func newErrorResponseMappers(headers map[string][]string) []*hcmv3.ResponseMapper {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	headerFilters := make([]*accesslogv3.AccessLogFilter, len(names))
+	for i, name := range names {
+		quoted := make([]string, len(headers[name]))
+		for j, v := range headers[name] {
+			quoted[j] = regexp.QuoteMeta(v)
+		}
+		headerFilters[i] = &accesslogv3.AccessLogFilter{FilterSpecifier: &accesslogv3.AccessLogFilter_HeaderFilter{
+			HeaderFilter: &accesslogv3.HeaderFilter{Header: &routev3.HeaderMatcher{
+				Name: name,
+				HeaderMatchSpecifier: &routev3.HeaderMatcher_StringMatch{StringMatch: &matcherv3.StringMatcher{
+					MatchPattern: &matcherv3.StringMatcher_SafeRegex{SafeRegex: &matcherv3.RegexMatcher{
+						Regex: "^(" + strings.Join(quoted, "|") + ")$",
+					}},
+				}},
+			}},
+		}}
+	}
+	var headerFilter *accesslogv3.AccessLogFilter
+	if len(headerFilters) == 1 {
+		headerFilter = headerFilters[0]
+	} else {
+		headerFilter = &accesslogv3.AccessLogFilter{FilterSpecifier: &accesslogv3.AccessLogFilter_OrFilter{
+			OrFilter: &accesslogv3.OrFilter{Filters: headerFilters},
+		}}
+	}
+
+	mappers := make([]*hcmv3.ResponseMapper, len(errorResponseOverrides))
+	for i, o := range errorResponseOverrides {
+		body := fmt.Sprintf(`{"type":"error","error":{"type":"upstream_unavailable","code":"%d","message":%q}}`, o.status, o.message)
+		mappers[i] = &hcmv3.ResponseMapper{
+			Filter: &accesslogv3.AccessLogFilter{FilterSpecifier: &accesslogv3.AccessLogFilter_AndFilter{
+				AndFilter: &accesslogv3.AndFilter{Filters: []*accesslogv3.AccessLogFilter{
+					{FilterSpecifier: &accesslogv3.AccessLogFilter_StatusCodeFilter{StatusCodeFilter: &accesslogv3.StatusCodeFilter{
+						Comparison: &accesslogv3.ComparisonFilter{
+							Op:    accesslogv3.ComparisonFilter_EQ,
+							Value: &corev3.RuntimeUInt32{DefaultValue: uint32(o.status), RuntimeKey: fmt.Sprintf("ai_gateway.error_response_override.%d", o.status)},
+						},
+					}}},
+					{FilterSpecifier: &accesslogv3.AccessLogFilter_ResponseFlagFilter{ResponseFlagFilter: &accesslogv3.ResponseFlagFilter{
+						Flags: upstreamFailureResponseFlags,
+					}}},
+					headerFilter,
+				}},
+			}},
+			BodyFormatOverride: &corev3.SubstitutionFormatString{
+				Format: &corev3.SubstitutionFormatString_TextFormatSource{
+					TextFormatSource: &corev3.DataSource{Specifier: &corev3.DataSource_InlineString{InlineString: body}},
+				},
+				ContentType: "application/json",
+			},
+		}
+	}
+	return mappers
+}
+
+// addLocalReplyMappers appends the mappers to the local reply config of the filter if it is the HTTP connection
+// manager. The existing mappers are kept first so that they take precedence.
+func addLocalReplyMappers(filter *listenerv3.Filter, mappers []*hcmv3.ResponseMapper) error {
+	typed := filter.GetTypedConfig()
+	if typed == nil {
+		return nil
+	}
+	var hcm hcmv3.HttpConnectionManager
+	if !typed.MessageIs(&hcm) {
+		return nil
+	}
+	if err := typed.UnmarshalTo(&hcm); err != nil {
+		return fmt.Errorf("failed to unmarshal the HTTP connection manager: %w", err)
+	}
+	if hcm.LocalReplyConfig == nil {
+		hcm.LocalReplyConfig = &hcmv3.LocalReplyConfig{}
+	}
+	hcm.LocalReplyConfig.Mappers = append(hcm.LocalReplyConfig.Mappers, mappers...)
+	updated, err := anypb.New(&hcm)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the HTTP connection manager: %w", err)
+	}
+	filter.ConfigType = &listenerv3.Filter_TypedConfig{TypedConfig: updated}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"encoding/json"
+	"testing"
+
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	accesslogv3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	hcmv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestServer_PostHTTPListenerModify(t *testing.T) {
+	newRoute := func(name, gateway string, labeled bool, values ...string) *gwapiv1.HTTPRoute {
+		route := &gwapiv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+			Spec: gwapiv1.HTTPRouteSpec{CommonRouteSpec: gwapiv1.CommonRouteSpec{
+				ParentRefs: []gwapiv1.ParentReference{{Name: gwapiv1.ObjectName(gateway), Namespace: ptr.To[gwapiv1.Namespace]("ns")}},
+			}},
+		}
+		if labeled {
+			route.Labels = map[string]string{OverrideErrorResponsesLabelKey: "true"}
+		}
+		for _, v := range values {
+			route.Spec.Rules = append(route.Spec.Rules, gwapiv1.HTTPRouteRule{Matches: []gwapiv1.HTTPRouteMatch{
+				{Headers: []gwapiv1.HTTPHeaderMatch{{Name: "x-ai-eg-selected-backend", Value: v}}},
+			}})
+		}
+		// The default rule without the header match.
+		route.Spec.Rules = append(route.Spec.Rules, gwapiv1.HTTPRouteRule{})
+		return route
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, gwapiv1.Install(scheme))
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newRoute("route1", "gtw", true, "apple.ns", "orange.ns"),
+		newRoute("route2", "gtw", true, "apple.ns", "pine.apple.ns"),
+		newRoute("not-labeled", "gtw", false, "foo.ns"),
+		newRoute("other-gateway", "other", true, "bar.ns"),
+	).Build()
+	s := New(k8sClient, logr.Discard())
+
+	newListener := func(name string) *listenerv3.Listener {
+		hcm, err := anypb.New(&hcmv3.HttpConnectionManager{
+			StatPrefix:       "http",
+			LocalReplyConfig: &hcmv3.LocalReplyConfig{Mappers: []*hcmv3.ResponseMapper{{}}},
+		})
+		require.NoError(t, err)
+		return &listenerv3.Listener{
+			Name: name,
+			FilterChains: []*listenerv3.FilterChain{{Filters: []*listenerv3.Filter{
+				{Name: "envoy.filters.network.http_connection_manager", ConfigType: &listenerv3.Filter_TypedConfig{TypedConfig: hcm}},
+			}}},
+			DefaultFilterChain: &listenerv3.FilterChain{Filters: []*listenerv3.Filter{
+				{Name: "envoy.filters.network.http_connection_manager", ConfigType: &listenerv3.Filter_TypedConfig{TypedConfig: hcm}},
+				{Name: "envoy.filters.network.tcp_proxy"},
+			}},
+		}
+	}
+	requireMappers := func(t *testing.T, filter *listenerv3.Filter) []*hcmv3.ResponseMapper {
+		var hcm hcmv3.HttpConnectionManager
+		require.NoError(t, filter.GetTypedConfig().UnmarshalTo(&hcm))
+		require.Equal(t, "http", hcm.StatPrefix)
+		// The existing mappers are kept first.
+		require.Empty(t, hcm.LocalReplyConfig.Mappers[0].Filter)
+		return hcm.LocalReplyConfig.Mappers[1:]
+	}
+
+	t.Run("overridden", func(t *testing.T) {
+		res, err := s.PostHTTPListenerModify(t.Context(), &pb.PostHTTPListenerModifyRequest{Listener: newListener("ns/gtw/http")})
+		require.NoError(t, err)
+		for _, filter := range []*listenerv3.Filter{res.Listener.FilterChains[0].Filters[0], res.Listener.DefaultFilterChain.Filters[0]} {
+			mappers := requireMappers(t, filter)
+			require.Len(t, mappers, 3)
+			for i, status := range []uint32{502, 503, 504} {
+				m := mappers[i]
+				filters := m.Filter.GetAndFilter().Filters
+				require.Len(t, filters, 3)
+				require.Equal(t, status, filters[0].GetStatusCodeFilter().Comparison.Value.DefaultValue)
+				require.Equal(t, accesslogv3.ComparisonFilter_EQ, filters[0].GetStatusCodeFilter().Comparison.Op)
+				require.Equal(t, upstreamFailureResponseFlags, filters[1].GetResponseFlagFilter().Flags)
+				header := filters[2].GetHeaderFilter().Header
+				require.Equal(t, "x-ai-eg-selected-backend", header.Name)
+				require.Equal(t, `^(apple\.ns|orange\.ns|pine\.apple\.ns)$`, header.GetStringMatch().GetSafeRegex().Regex)
+
+				require.Equal(t, "application/json", m.BodyFormatOverride.ContentType)
+				var body openai.Error
+				require.NoError(t, json.Unmarshal([]byte(m.BodyFormatOverride.GetTextFormatSource().GetInlineString()), &body))
+				require.Equal(t, "upstream_unavailable", body.Error.Type)
+				require.NotEmpty(t, body.Error.Message)
+			}
+		}
+		require.Nil(t, res.Listener.DefaultFilterChain.Filters[1].ConfigType)
+	})
+	t.Run("no route", func(t *testing.T) {
+		listener := newListener("ns/another/http")
+		res, err := s.PostHTTPListenerModify(t.Context(), &pb.PostHTTPListenerModifyRequest{Listener: listener})
+		require.NoError(t, err)
+		require.Empty(t, requireMappers(t, res.Listener.FilterChains[0].Filters[0]))
+	})
+}
+
+func Test_parseListenerName(t *testing.T) {
+	namespace, gateway, ok := parseListenerName("ns/gtw/http")
+	require.True(t, ok)
+	require.Equal(t, "ns", namespace)
+	require.Equal(t, "gtw", gateway)
+	_, _, ok = parseListenerName("http")
+	require.False(t, ok)
+}
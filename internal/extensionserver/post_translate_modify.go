@@ -0,0 +1,241 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+)
+
+// TrafficPoliciesAnnotationKey is the annotation of the HTTPRoute generated for an AIGatewayRoute, holding the JSON
+// array of the *aigv1a1.AIServiceBackendTrafficPolicy of the backends indexed by the rule of the HTTPRoute.
+//
+// Envoy Gateway generates a cluster per HTTPRoute rule named "httproute/<namespace>/<name>/rule/<index>", so this
+// allows the extension server to correlate the clusters with the AIServiceBackends. The HTTPRoutes without
+// this annotation are not managed by the AI Gateway, and their clusters are left untouched.
+const TrafficPoliciesAnnotationKey = "aigateway.envoyproxy.io/backend-traffic-policies"
+
+const (
+	// defaultCircuitBreakerThreshold is the default maximum number of connections, pending requests, and parallel
+	// requests of the AI backend clusters.
+	defaultCircuitBreakerThreshold = 4096
+	// defaultConsecutive5xxErrors is the default number of consecutive 5xx errors ejecting the endpoint.
+	defaultConsecutive5xxErrors = 5
+	// defaultOutlierDetectionInterval is the default interval of the outlier detection sweeps.
+	defaultOutlierDetectionInterval = 10 * time.Second
+	// defaultBaseEjectionTime is the default base duration the endpoint is ejected for.
+	defaultBaseEjectionTime = 30 * time.Second
+	// defaultMaxEjectionPercent is the default maximum percentage of the endpoints ejected at the same time.
+	defaultMaxEjectionPercent = 50
+	// defaultHealthCheckInterval is the default interval of the active health checks.
+	defaultHealthCheckInterval = 10 * time.Second
+	// defaultHealthCheckTimeout is the default timeout of the active health check request.
+	defaultHealthCheckTimeout = time.Second
+	// defaultHealthyThreshold is the default number of the consecutive successful health checks marking the endpoint healthy.
+	defaultHealthyThreshold = 1
+	// defaultUnhealthyThreshold is the default number of the consecutive failed health checks marking the endpoint unhealthy.
+	defaultUnhealthyThreshold = 3
+)
+
+// PostTranslateModify implements [pb.EnvoyGatewayExtensionServer].
+//
+// This configures the circuit breakers, the outlier detection, and the active health checking of the clusters generated
+// for the AIServiceBackends based on their traffic policies, or the defaults tuned for the LLM requests. The explicitly configured traffic
+// policy always takes precedence, while the defaults are only applied to the cluster that Envoy Gateway has not
+// configured, for example, via the BackendTrafficPolicy.
+func (s *Server) PostTranslateModify(ctx context.Context, req *pb.PostTranslateModifyRequest) (*pb.PostTranslateModifyResponse, error) {
+	policiesByRoute := make(map[string][]*aigv1a1.AIServiceBackendTrafficPolicy)
+	for _, cluster := range req.Clusters {
+		namespace, name, ruleIndex, ok := parseHTTPRouteClusterName(cluster.Name)
+		if !ok {
+			continue
+		}
+		key := namespace + "/" + name
+		policies, ok := policiesByRoute[key]
+		if !ok {
+			var err error
+			if policies, err = s.trafficPolicies(ctx, namespace, name); err != nil {
+				// The failure must not block the translation of the rest of the configuration.
+				s.log.Error(err, "failed to get the traffic policies", "httproute", key)
+			}
+			policiesByRoute[key] = policies
+		}
+		if ruleIndex >= len(policies) {
+			continue
+		}
+		applyTrafficPolicy(cluster, policies[ruleIndex])
+	}
+	return &pb.PostTranslateModifyResponse{Clusters: req.Clusters, Secrets: req.Secrets}, nil
+}
+
+// trafficPolicies returns the traffic policies indexed by the rule of the HTTPRoute, or nil if the HTTPRoute is not
+// managed by the AI Gateway.
+func (s *Server) trafficPolicies(ctx context.Context, namespace, name string) ([]*aigv1a1.AIServiceBackendTrafficPolicy, error) {
+	var route gwapiv1.HTTPRoute
+	if err := s.k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &route); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	encoded, ok := route.Annotations[TrafficPoliciesAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	var policies []*aigv1a1.AIServiceBackendTrafficPolicy
+	if err := json.Unmarshal([]byte(encoded), &policies); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the annotation %s: %w", TrafficPoliciesAnnotationKey, err)
+	}
+	return policies, nil
+}
+
+// parseHTTPRouteClusterName parses the cluster name "httproute/<namespace>/<name>/rule/<index>" generated by
+// Envoy Gateway for the HTTPRoute rule.
+func parseHTTPRouteClusterName(clusterName string) (namespace, name string, ruleIndex int, ok bool) {
+	parts := strings.Split(clusterName, "/")
+	if len(parts) != 5 || parts[0] != "httproute" || parts[3] != "rule" {
+		return "", "", 0, false
+	}
+	ruleIndex, err := strconv.Atoi(parts[4])
+	if err != nil || ruleIndex < 0 {
+		return "", "", 0, false
+	}
+	return parts[1], parts[2], ruleIndex, true
+}
+
+// applyTrafficPolicy configures the circuit breakers, the outlier detection, and the active health checking of the cluster.
+func applyTrafficPolicy(cluster *clusterv3.Cluster, policy *aigv1a1.AIServiceBackendTrafficPolicy) {
+	var (
+		circuitBreaker   *egv1a1.CircuitBreaker
+		outlierDetection *egv1a1.PassiveHealthCheck
+	)
+	if policy != nil {
+		circuitBreaker, outlierDetection = policy.CircuitBreaker, policy.OutlierDetection
+		if policy.HealthCheck != nil {
+			cluster.HealthChecks = []*corev3.HealthCheck{buildHealthCheck(policy.HealthCheck)}
+		}
+	}
+	if circuitBreaker != nil || cluster.CircuitBreakers == nil {
+		cluster.CircuitBreakers = buildCircuitBreakers(circuitBreaker)
+	}
+	if outlierDetection != nil || cluster.OutlierDetection == nil {
+		cluster.OutlierDetection = buildOutlierDetection(outlierDetection)
+	}
+}
+
+// buildCircuitBreakers builds the circuit breakers of the cluster. The unset fields are set to the defaults.
+func buildCircuitBreakers(cb *egv1a1.CircuitBreaker) *clusterv3.CircuitBreakers {
+	if cb == nil {
+		cb = &egv1a1.CircuitBreaker{}
+	}
+	threshold := func(v *int64) *wrapperspb.UInt32Value {
+		if v == nil {
+			return wrapperspb.UInt32(defaultCircuitBreakerThreshold)
+		}
+		return wrapperspb.UInt32(uint32(*v)) //nolint:gosec // The CRD validation limits the value to uint32.
+	}
+	thresholds := &clusterv3.CircuitBreakers_Thresholds{
+		Priority:           corev3.RoutingPriority_DEFAULT,
+		MaxConnections:     threshold(cb.MaxConnections),
+		MaxPendingRequests: threshold(cb.MaxPendingRequests),
+		MaxRequests:        threshold(cb.MaxParallelRequests),
+	}
+	if cb.MaxParallelRetries != nil {
+		thresholds.MaxRetries = wrapperspb.UInt32(uint32(*cb.MaxParallelRetries)) //nolint:gosec // Same as above.
+	}
+	return &clusterv3.CircuitBreakers{Thresholds: []*clusterv3.CircuitBreakers_Thresholds{thresholds}}
+}
+
+// buildOutlierDetection builds the outlier detection of the cluster. The unset fields are set to the defaults.
+func buildOutlierDetection(hc *egv1a1.PassiveHealthCheck) *clusterv3.OutlierDetection {
+	if hc == nil {
+		hc = &egv1a1.PassiveHealthCheck{}
+	}
+	duration := func(v *metav1.Duration, def time.Duration) *durationpb.Duration {
+		if v == nil {
+			return durationpb.New(def)
+		}
+		return durationpb.New(v.Duration)
+	}
+	od := &clusterv3.OutlierDetection{
+		Interval:           duration(hc.Interval, defaultOutlierDetectionInterval),
+		BaseEjectionTime:   duration(hc.BaseEjectionTime, defaultBaseEjectionTime),
+		Consecutive_5Xx:    wrapperspb.UInt32(defaultConsecutive5xxErrors),
+		MaxEjectionPercent: wrapperspb.UInt32(defaultMaxEjectionPercent),
+	}
+	if hc.Consecutive5xxErrors != nil {
+		od.Consecutive_5Xx = wrapperspb.UInt32(*hc.Consecutive5xxErrors)
+	}
+	if v := hc.MaxEjectionPercent; v != nil && *v >= 0 && *v <= 100 {
+		od.MaxEjectionPercent = wrapperspb.UInt32(uint32(*v))
+	}
+	if hc.SplitExternalLocalOriginErrors != nil {
+		od.SplitExternalLocalOriginErrors = *hc.SplitExternalLocalOriginErrors
+	}
+	if hc.ConsecutiveLocalOriginFailures != nil {
+		od.ConsecutiveLocalOriginFailure = wrapperspb.UInt32(*hc.ConsecutiveLocalOriginFailures)
+	}
+	if hc.ConsecutiveGatewayErrors != nil {
+		od.ConsecutiveGatewayFailure = wrapperspb.UInt32(*hc.ConsecutiveGatewayErrors)
+	}
+	return od
+}
+
+// buildHealthCheck builds the HTTP active health check of the cluster. The unset fields are set to the defaults.
+func buildHealthCheck(hc *aigv1a1.AIServiceBackendHealthCheck) *corev3.HealthCheck {
+	duration := func(v *metav1.Duration, def time.Duration) *durationpb.Duration {
+		if v == nil {
+			return durationpb.New(def)
+		}
+		return durationpb.New(v.Duration)
+	}
+	threshold := func(v *uint32, def uint32) *wrapperspb.UInt32Value {
+		if v == nil {
+			return wrapperspb.UInt32(def)
+		}
+		return wrapperspb.UInt32(*v)
+	}
+	statuses := hc.ExpectedStatuses
+	if len(statuses) == 0 {
+		statuses = []int32{http.StatusOK}
+	}
+	expectedStatuses := make([]*typev3.Int64Range, len(statuses))
+	for i, s := range statuses {
+		// The range is [start, end).
+		expectedStatuses[i] = &typev3.Int64Range{Start: int64(s), End: int64(s) + 1}
+	}
+	return &corev3.HealthCheck{
+		Timeout:            duration(hc.Timeout, defaultHealthCheckTimeout),
+		Interval:           duration(hc.Interval, defaultHealthCheckInterval),
+		HealthyThreshold:   threshold(hc.HealthyThreshold, defaultHealthyThreshold),
+		UnhealthyThreshold: threshold(hc.UnhealthyThreshold, defaultUnhealthyThreshold),
+		HealthChecker: &corev3.HealthCheck_HttpHealthCheck_{HttpHealthCheck: &corev3.HealthCheck_HttpHealthCheck{
+			Host:             hc.Hostname,
+			Path:             cmp.Or(hc.Path, "/"),
+			ExpectedStatuses: expectedStatuses,
+		}},
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extensionserver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	egv1a1 "github.com/envoyproxy/gateway/api/v1alpha1"
+	pb "github.com/envoyproxy/gateway/proto/extension"
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
+)
+
+func TestServer_PostTranslateModify(t *testing.T) {
+	policies, err := json.Marshal([]*aigv1a1.AIServiceBackendTrafficPolicy{
+		nil,
+		{
+			CircuitBreaker: &egv1a1.CircuitBreaker{MaxConnections: ptr.To[int64](10), MaxParallelRetries: ptr.To[int64](3)},
+			OutlierDetection: &egv1a1.PassiveHealthCheck{
+				Consecutive5xxErrors: ptr.To[uint32](2),
+				BaseEjectionTime:     &metav1.Duration{Duration: time.Minute},
+			},
+			HealthCheck: &aigv1a1.AIServiceBackendHealthCheck{
+				Path:               "/health",
+				Hostname:           "api.example.com",
+				Interval:           &metav1.Duration{Duration: 5 * time.Second},
+				UnhealthyThreshold: ptr.To[uint32](2),
+				ExpectedStatuses:   []int32{200, 401},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, gwapiv1.Install(scheme))
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{
+			Name: "ai-route", Namespace: "ns",
+			Annotations: map[string]string{TrafficPoliciesAnnotationKey: string(policies)},
+		}},
+		&gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "non-ai-route", Namespace: "ns"}},
+		&gwapiv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{
+			Name: "broken-route", Namespace: "ns",
+			Annotations: map[string]string{TrafficPoliciesAnnotationKey: "{"},
+		}},
+	).Build()
+	s := New(k8sClient, logr.Discard())
+
+	egCircuitBreakers := &clusterv3.CircuitBreakers{
+		Thresholds: []*clusterv3.CircuitBreakers_Thresholds{{MaxConnections: wrapperspb.UInt32(1)}},
+	}
+	clusters := []*clusterv3.Cluster{
+		{Name: "httproute/ns/ai-route/rule/0"},
+		{Name: "httproute/ns/ai-route/rule/1"},
+		// The circuit breakers configured by Envoy Gateway are kept when the policy is not set.
+		{Name: "httproute/ns/ai-route/rule/0", CircuitBreakers: egCircuitBreakers},
+		// The out of range rule, e.g., the default rule without the backend.
+		{Name: "httproute/ns/ai-route/rule/2"},
+		{Name: "httproute/ns/non-ai-route/rule/0"},
+		{Name: "httproute/ns/not-found/rule/0"},
+		{Name: "httproute/ns/broken-route/rule/0"},
+		{Name: "grpcroute/ns/ai-route/rule/0"},
+	}
+	resp, err := s.PostTranslateModify(t.Context(), &pb.PostTranslateModifyRequest{Clusters: clusters})
+	require.NoError(t, err)
+	require.Len(t, resp.Clusters, len(clusters))
+
+	defaultCircuitBreakers := &clusterv3.CircuitBreakers{Thresholds: []*clusterv3.CircuitBreakers_Thresholds{{
+		MaxConnections:     wrapperspb.UInt32(4096),
+		MaxPendingRequests: wrapperspb.UInt32(4096),
+		MaxRequests:        wrapperspb.UInt32(4096),
+	}}}
+	defaultOutlierDetection := &clusterv3.OutlierDetection{
+		Interval:           durationpb.New(10 * time.Second),
+		BaseEjectionTime:   durationpb.New(30 * time.Second),
+		Consecutive_5Xx:    wrapperspb.UInt32(5),
+		MaxEjectionPercent: wrapperspb.UInt32(50),
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		require.Equal(t, defaultCircuitBreakers.String(), resp.Clusters[0].CircuitBreakers.String())
+		require.Equal(t, defaultOutlierDetection.String(), resp.Clusters[0].OutlierDetection.String())
+		require.Empty(t, resp.Clusters[0].HealthChecks)
+	})
+	t.Run("explicit policy", func(t *testing.T) {
+		require.Equal(t, (&clusterv3.CircuitBreakers{Thresholds: []*clusterv3.CircuitBreakers_Thresholds{{
+			MaxConnections:     wrapperspb.UInt32(10),
+			MaxPendingRequests: wrapperspb.UInt32(4096),
+			MaxRequests:        wrapperspb.UInt32(4096),
+			MaxRetries:         wrapperspb.UInt32(3),
+		}}}).String(), resp.Clusters[1].CircuitBreakers.String())
+		require.Equal(t, (&clusterv3.OutlierDetection{
+			Interval:           durationpb.New(10 * time.Second),
+			BaseEjectionTime:   durationpb.New(time.Minute),
+			Consecutive_5Xx:    wrapperspb.UInt32(2),
+			MaxEjectionPercent: wrapperspb.UInt32(50),
+		}).String(), resp.Clusters[1].OutlierDetection.String())
+		require.Len(t, resp.Clusters[1].HealthChecks, 1)
+		require.Equal(t, (&corev3.HealthCheck{
+			Timeout:            durationpb.New(time.Second),
+			Interval:           durationpb.New(5 * time.Second),
+			HealthyThreshold:   wrapperspb.UInt32(1),
+			UnhealthyThreshold: wrapperspb.UInt32(2),
+			HealthChecker: &corev3.HealthCheck_HttpHealthCheck_{HttpHealthCheck: &corev3.HealthCheck_HttpHealthCheck{
+				Host:             "api.example.com",
+				Path:             "/health",
+				ExpectedStatuses: []*typev3.Int64Range{{Start: 200, End: 201}, {Start: 401, End: 402}},
+			}},
+		}).String(), resp.Clusters[1].HealthChecks[0].String())
+	})
+	t.Run("preexisting circuit breakers", func(t *testing.T) {
+		require.Equal(t, egCircuitBreakers.String(), resp.Clusters[2].CircuitBreakers.String())
+		require.Equal(t, defaultOutlierDetection.String(), resp.Clusters[2].OutlierDetection.String())
+	})
+	t.Run("untouched", func(t *testing.T) {
+		for _, c := range resp.Clusters[3:] {
+			require.Nil(t, c.CircuitBreakers, c.Name)
+			require.Nil(t, c.OutlierDetection, c.Name)
+		}
+	})
+}
+
+func Test_parseHTTPRouteClusterName(t *testing.T) {
+	namespace, name, ruleIndex, ok := parseHTTPRouteClusterName("httproute/ns/route/rule/3")
+	require.True(t, ok)
+	require.Equal(t, "ns", namespace)
+	require.Equal(t, "route", name)
+	require.Equal(t, 3, ruleIndex)
+
+	for _, clusterName := range []string{
+		"httproute/ns/route/rule",
+		"httproute/ns/route/rule/foo",
+		"httproute/ns/route/rule/-1",
+		"httproute/ns/route/match/0",
+		"grpcroute/ns/route/rule/0",
+		"httproute/ns/route/rule/0/extra",
+	} {
+		_, _, _, ok = parseHTTPRouteClusterName(clusterName)
+		require.False(t, ok, clusterName)
+	}
+}
+
+func Test_buildHealthCheck(t *testing.T) {
+	require.Equal(t, (&corev3.HealthCheck{
+		Timeout:            durationpb.New(time.Second),
+		Interval:           durationpb.New(10 * time.Second),
+		HealthyThreshold:   wrapperspb.UInt32(1),
+		UnhealthyThreshold: wrapperspb.UInt32(3),
+		HealthChecker: &corev3.HealthCheck_HttpHealthCheck_{HttpHealthCheck: &corev3.HealthCheck_HttpHealthCheck{
+			Path:             "/",
+			ExpectedStatuses: []*typev3.Int64Range{{Start: 200, End: 201}},
+		}},
+	}).String(), buildHealthCheck(&aigv1a1.AIServiceBackendHealthCheck{}).String())
+}
@@ -0,0 +1,98 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+// requestIDHeaderKey is the header key of the request ID generated by Envoy.
+const requestIDHeaderKey = "x-request-id"
+
+// accessLogRecorder is optionally implemented by a [Processor] to fill the fields of the access log record that
+// only the processor knows, such as the selected backend and the token usage.
+type accessLogRecorder interface {
+	fillAccessLogRecord(record *x.AccessLogRecord)
+}
+
+// jsonAccessLogSink implements [x.AccessLogSink] writing the records as JSON lines. This is the default sink.
+type jsonAccessLogSink struct {
+	logger *slog.Logger
+	mu     sync.Mutex
+	w      io.Writer
+}
+
+// Write implements [x.AccessLogSink.Write].
+func (s *jsonAccessLogSink) Write(_ context.Context, record *x.AccessLogRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		s.logger.Error("failed to marshal access log record", slog.String("error", err.Error()))
+		return
+	}
+	line = append(line, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err = s.w.Write(line); err != nil {
+		s.logger.Error("failed to write access log record", slog.String("error", err.Error()))
+	}
+}
+
+// streamAccessLog accumulates the access log record of a stream from the messages exchanged with Envoy.
+type streamAccessLog struct {
+	record x.AccessLogRecord
+	start  time.Time
+}
+
+// observeRequest records the fields of the record carried by the message from Envoy.
+func (a *streamAccessLog) observeRequest(req *extprocv3.ProcessingRequest) {
+	if headers := req.GetRequestHeaders().GetHeaders(); headers != nil && a.start.IsZero() {
+		requestHeaders := headersToMap(headers)
+		a.start = time.Now()
+		a.record.Timestamp = a.start
+		a.record.RequestID = requestHeaders[requestIDHeaderKey]
+		a.record.Path = requestHeaders[":path"]
+	} else if headers := req.GetResponseHeaders().GetHeaders(); headers != nil {
+		a.record.Status, _ = strconv.Atoi(headersToMap(headers)[":status"])
+	}
+}
+
+// observeResponse records the status of the immediate response sent by the filter, if any.
+func (a *streamAccessLog) observeResponse(resp *extprocv3.ProcessingResponse) {
+	if ir := resp.GetImmediateResponse(); ir != nil {
+		a.record.Status = int(ir.GetStatus().GetCode())
+	}
+}
+
+// maybeEmit emits the access log record of the stream processed by the processor when the stream ends, if the
+// access log is enabled and the request is sampled. The errMsg is the error the stream ended with, if any.
+func (a *streamAccessLog) maybeEmit(ctx context.Context, config *processorConfig, p Processor, errMsg string) {
+	sink := config.accessLogSink
+	if sink == nil || a.start.IsZero() {
+		// The access log is disabled, or the stream ended before the request headers.
+		return
+	}
+	if config.accessLogSampleRate < 1 && rand.Float64() >= config.accessLogSampleRate { //nolint:gosec // Sampling does not need the cryptographic randomness.
+		return
+	}
+	a.record.Route = config.routeName
+	a.record.DurationMillis = time.Since(a.start).Milliseconds()
+	a.record.Error = errMsg
+	if r, ok := p.(accessLogRecorder); ok {
+		r.fillAccessLogRecord(&a.record)
+	}
+	sink.Write(ctx, &a.record)
+}
@@ -0,0 +1,196 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+// recordingAccessLogSink implements [x.AccessLogSink] recording the written records for testing.
+type recordingAccessLogSink struct {
+	mu      sync.Mutex
+	records []x.AccessLogRecord
+}
+
+// Write implements [x.AccessLogSink.Write].
+func (r *recordingAccessLogSink) Write(_ context.Context, record *x.AccessLogRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, *record)
+}
+
+func TestJSONAccessLogSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &jsonAccessLogSink{logger: slog.Default(), w: &buf}
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	sink.Write(t.Context(), &x.AccessLogRecord{
+		Timestamp: ts, RequestID: "req-1", Route: "ns/route", Path: "/v1/chat/completions", Backend: "openai",
+		Model: "gpt-4o", Status: 200, Stream: true, InputTokens: 1, OutputTokens: 2, TotalTokens: 3, DurationMillis: 42,
+	})
+	sink.Write(t.Context(), &x.AccessLogRecord{Timestamp: ts, Error: "boom"})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	require.JSONEq(t, `{
+"timestamp": "2025-01-02T03:04:05Z", "request_id": "req-1", "route": "ns/route", "path": "/v1/chat/completions",
+"backend": "openai", "model": "gpt-4o", "status": 200, "stream": true,
+"input_tokens": 1, "output_tokens": 2, "total_tokens": 3, "duration_ms": 42
+}`, lines[0])
+	require.JSONEq(t, `{
+"timestamp": "2025-01-02T03:04:05Z", "stream": false, "input_tokens": 0, "output_tokens": 0, "total_tokens": 0,
+"duration_ms": 0, "error": "boom"
+}`, lines[1])
+}
+
+func TestServer_Process_accessLog(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	sink := &recordingAccessLogSink{}
+	s.accessLogSink = sink
+	loadConfig := func(accessLog *filterapi.AccessLogConfig) {
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			RouteName:                "ns/route",
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+			}},
+			AccessLog: accessLog,
+		}))
+	}
+	process := func(retErr error, reqs ...*extprocv3.ProcessingRequest) []x.AccessLogRecord {
+		sink.mu.Lock()
+		sink.records = nil
+		sink.mu.Unlock()
+		reqs = append([]*extprocv3.ProcessingRequest{
+			{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+					{Key: ":path", Value: "/v1/chat/completions"},
+					{Key: "x-request-id", Value: "req-1"},
+				}},
+			}}},
+		}, reqs...)
+		_ = s.Process(&mockScriptedProcessingStream{ctx: t.Context(), reqs: reqs, retErr: retErr})
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		return sink.records
+	}
+	requestBody := func(body string) *extprocv3.ProcessingRequest {
+		return &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestBody{
+			RequestBody: &extprocv3.HttpBody{Body: []byte(body), EndOfStream: true},
+		}}
+	}
+	responseHeaders := &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{
+		ResponseHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}}},
+	}}
+	responseBody := func(body string) *extprocv3.ProcessingRequest {
+		return &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{
+			ResponseBody: &extprocv3.HttpBody{Body: []byte(body), EndOfStream: true},
+		}}
+	}
+	requireRecord := func(t *testing.T, exp x.AccessLogRecord, records []x.AccessLogRecord) {
+		require.Len(t, records, 1)
+		actual := records[0]
+		require.False(t, actual.Timestamp.IsZero())
+		require.GreaterOrEqual(t, actual.DurationMillis, int64(0))
+		actual.Timestamp, actual.DurationMillis = time.Time{}, 0
+		require.Equal(t, exp, actual)
+	}
+
+	loadConfig(&filterapi.AccessLogConfig{})
+	t.Run("non-streaming", func(t *testing.T) {
+		records := process(io.EOF,
+			requestBody(`{"model":"some-model","messages":[]}`),
+			responseHeaders,
+			responseBody(`{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`),
+		)
+		requireRecord(t, x.AccessLogRecord{
+			RequestID: "req-1", Route: "ns/route", Path: "/v1/chat/completions", Backend: "openai", Model: "some-model",
+			Status: 200, InputTokens: 3, OutputTokens: 2, TotalTokens: 5,
+		}, records)
+	})
+	t.Run("streaming", func(t *testing.T) {
+		records := process(io.EOF,
+			requestBody(`{"model":"some-model","messages":[],"stream":true}`),
+			responseHeaders,
+			responseBody("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":2,\"total_tokens\":5}}\n\ndata: [DONE]\n\n"),
+		)
+		requireRecord(t, x.AccessLogRecord{
+			RequestID: "req-1", Route: "ns/route", Path: "/v1/chat/completions", Backend: "openai", Model: "some-model",
+			Status: 200, Stream: true, InputTokens: 3, OutputTokens: 2, TotalTokens: 5,
+		}, records)
+	})
+	t.Run("immediate response", func(t *testing.T) {
+		records := process(io.EOF, requestBody(`{"model":"unknown-model","messages":[]}`))
+		requireRecord(t, x.AccessLogRecord{
+			RequestID: "req-1", Route: "ns/route", Path: "/v1/chat/completions", Model: "unknown-model", Status: 404,
+		}, records)
+	})
+	t.Run("invalid request", func(t *testing.T) {
+		records := process(io.EOF, requestBody(`{`))
+		requireRecord(t, x.AccessLogRecord{
+			RequestID: "req-1", Route: "ns/route", Path: "/v1/chat/completions", Status: 400,
+		}, records)
+	})
+	t.Run("processing error", func(t *testing.T) {
+		records := process(io.EOF,
+			requestBody(`{"model":"some-model","messages":[]}`),
+			&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{
+				ResponseHeaders: &extprocv3.HttpHeaders{Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+					{Key: ":status", Value: "200"}, {Key: "content-encoding", Value: "gzip"},
+				}}},
+			}},
+			responseBody(`not gzip`),
+		)
+		require.Len(t, records, 1)
+		require.Contains(t, records[0].Error, "failed to decode gzip")
+	})
+	t.Run("client cancellation", func(t *testing.T) {
+		// The client goes away before the response, so the status is unknown.
+		records := process(status.Error(codes.Canceled, "client went away"), requestBody(`{"model":"some-model","messages":[]}`))
+		requireRecord(t, x.AccessLogRecord{
+			RequestID: "req-1", Route: "ns/route", Path: "/v1/chat/completions", Backend: "openai", Model: "some-model",
+		}, records)
+	})
+
+	loadConfig(&filterapi.AccessLogConfig{SampleRate: ptr.To(0.0)})
+	t.Run("not sampled", func(t *testing.T) {
+		require.Empty(t, process(io.EOF, requestBody(`{"model":"some-model","messages":[]}`)))
+	})
+	loadConfig(nil)
+	t.Run("disabled", func(t *testing.T) {
+		require.Empty(t, process(io.EOF, requestBody(`{"model":"some-model","messages":[]}`)))
+	})
+	t.Run("custom sink", func(t *testing.T) {
+		custom := &recordingAccessLogSink{}
+		x.CustomAccessLogSink = custom
+		t.Cleanup(func() { x.CustomAccessLogSink = nil })
+		loadConfig(&filterapi.AccessLogConfig{})
+		require.Empty(t, process(io.EOF, requestBody(`{"model":"some-model","messages":[]}`)))
+		require.Len(t, custom.records, 1)
+		require.Equal(t, "req-1", custom.records[0].RequestID)
+	})
+}
@@ -7,9 +7,14 @@ package backendauth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
@@ -20,24 +25,207 @@ import (
 // apiKeyHandler implements [Handler] for api key authz.
 type apiKeyHandler struct {
 	apiKey string
+	// header is the header carrying the API key.
+	header apiKeyHeader
 }
 
 func newAPIKeyHandler(auth *filterapi.APIKeyAuth) (Handler, error) {
+	header := newAPIKeyHeader(auth)
+	if auth.Directory != "" {
+		return newRotatingAPIKeyHandler(auth.Directory, auth.Weights, header)
+	}
 	secret, err := os.ReadFile(auth.Filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read api key file: %w", err)
 	}
-	return &apiKeyHandler{apiKey: strings.TrimSpace(string(secret))}, nil
+	return &apiKeyHandler{apiKey: strings.TrimSpace(string(secret)), header: header}, nil
 }
 
 // Do implements [Handler.Do].
 //
-// Extracts the api key from the local file and set it as an authorization header.
+// Extracts the api key from the local file and sets it to the header, the Authorization header by default.
 func (a *apiKeyHandler) Do(_ context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, _ *extprocv3.BodyMutation) error {
-	requestHeaders["Authorization"] = fmt.Sprintf("Bearer %s", a.apiKey)
+	a.header.set(requestHeaders, headerMut, a.header.value(a.apiKey))
+	return nil
+}
+
+// apiKeyHeader is the header carrying the API key configured by [filterapi.APIKeyAuth.HeaderName] and
+// [filterapi.APIKeyAuth.ValueTemplate].
+type apiKeyHeader struct {
+	// name is the name of the header. Defaults to "Authorization".
+	name string
+	// valueTemplate is the value of the header where "{key}" is replaced with the API key. Defaults to "Bearer {key}".
+	valueTemplate string
+}
+
+func newAPIKeyHeader(auth *filterapi.APIKeyAuth) apiKeyHeader {
+	h := apiKeyHeader{name: strings.ToLower(auth.HeaderName), valueTemplate: auth.ValueTemplate}
+	// The custom header is lowercased as the request headers received from Envoy are.
+	if h.name == "" || h.name == "authorization" {
+		h.name = "Authorization"
+	}
+	if h.valueTemplate == "" {
+		h.valueTemplate = "Bearer {key}"
+	}
+	return h
+}
+
+// value returns the value of the header for the API key.
+func (h apiKeyHeader) value(apiKey string) string {
+	return strings.ReplaceAll(h.valueTemplate, "{key}", apiKey)
+}
+
+// set sets the header to the value. When the API key is carried by another header, the Authorization header sent by
+// the client is removed so that the backend does not receive a credential conflicting with the API key.
+func (h apiKeyHeader) set(requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, value string) {
+	requestHeaders[h.name] = value
 	headerMut.SetHeaders = append(headerMut.SetHeaders, &corev3.HeaderValueOption{
-		Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte(requestHeaders["Authorization"])},
+		Header: &corev3.HeaderValue{Key: h.name, RawValue: []byte(value)},
 	})
+	if h.name != "Authorization" {
+		delete(requestHeaders, "Authorization")
+		delete(requestHeaders, "authorization")
+		headerMut.RemoveHeaders = append(headerMut.RemoveHeaders, "authorization")
+	}
+}
+
+// apiKeyFailureCooldown is the duration for which an API key rejected by the backend is skipped.
+var apiKeyFailureCooldown = 30 * time.Second
+
+// APIKeyStats is the statistics of one of the API keys rotated by a [Handler], identified by its index
+// so that the API key itself is never exposed.
+type APIKeyStats struct {
+	// Index is the index of the API key in [filterapi.APIKeyAuth.Directory].
+	Index int `json:"index"`
+	// Weight is the weight of the API key in the rotation.
+	Weight int `json:"weight"`
+	// Requests is the number of the requests that used the API key.
+	Requests uint64 `json:"requests"`
+	// Failures is the number of the responses with the status code 401 or 429 to the requests using the API key.
+	Failures uint64 `json:"failures"`
+	// CoolingDown is true while the API key is skipped because of a recent failure.
+	CoolingDown bool `json:"coolingDown"`
+}
+
+// APIKeyStatsReporter is optionally implemented by a [Handler] rotating multiple API keys.
+type APIKeyStatsReporter interface {
+	// APIKeyStats returns the statistics of the API keys in the order of their indexes.
+	APIKeyStats() []APIKeyStats
+}
+
+// rotatingAPIKeyHandler implements [Handler] for the api key authz rotating multiple API keys
+// with the smooth weighted round-robin.
+type rotatingAPIKeyHandler struct {
+	mu sync.Mutex
+	// header is the header carrying the API keys.
+	header apiKeyHeader
+	keys   []rotatingAPIKey
+	now    func() time.Time
+}
+
+// rotatingAPIKey is the state of an API key rotated by [rotatingAPIKeyHandler].
+type rotatingAPIKey struct {
+	// headerValue is the value of the header carrying the API key.
+	headerValue      string
+	weight           int
+	currentWeight    int
+	coolingDownUntil time.Time
+	requests         uint64
+	failures         uint64
+}
+
+// newRotatingAPIKeyHandler reads the API keys "apiKey-0", "apiKey-1", ... in the directory where the secret is mounted
+// until one is missing, so that the controller does not need to read the secret to know the number of the API keys.
+func newRotatingAPIKeyHandler(dir string, weights []int, header apiKeyHeader) (Handler, error) {
+	h := &rotatingAPIKeyHandler{header: header, now: time.Now}
+	for i := 0; ; i++ {
+		secret, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("apiKey-%d", i)))
+		if errors.Is(err, os.ErrNotExist) && i > 0 {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read api key file: %w", err)
+		}
+		k := rotatingAPIKey{headerValue: header.value(strings.TrimSpace(string(secret))), weight: 1}
+		if i < len(weights) {
+			k.weight = max(weights[i], 1)
+		}
+		h.keys = append(h.keys, k)
+	}
+	return h, nil
+}
 
+// Do implements [Handler.Do].
+//
+// Picks one of the API keys and sets it as an authorization header.
+func (h *rotatingAPIKeyHandler) Do(_ context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, _ *extprocv3.BodyMutation) error {
+	h.header.set(requestHeaders, headerMut, h.pick())
 	return nil
 }
+
+// pick returns the header value of the next API key. The API keys cooling down are skipped
+// unless all of them are cooling down.
+func (h *rotatingAPIKeyHandler) pick() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	allCoolingDown := true
+	for i := range h.keys {
+		if !now.Before(h.keys[i].coolingDownUntil) {
+			allCoolingDown = false
+			break
+		}
+	}
+	var picked *rotatingAPIKey
+	total := 0
+	for i := range h.keys {
+		k := &h.keys[i]
+		if !allCoolingDown && now.Before(k.coolingDownUntil) {
+			continue
+		}
+		k.currentWeight += k.weight
+		total += k.weight
+		if picked == nil || k.currentWeight > picked.currentWeight {
+			picked = k
+		}
+	}
+	picked.currentWeight -= total
+	picked.requests++
+	return picked.headerValue
+}
+
+// ObserveResponse implements [ResponseObserver.ObserveResponse].
+//
+// The API key used for the request is skipped for a while when the backend rejects it with 401 or 429.
+func (h *rotatingAPIKeyHandler) ObserveResponse(requestHeaders map[string]string, status int) {
+	if status != http.StatusUnauthorized && status != http.StatusTooManyRequests {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.keys {
+		if k := &h.keys[i]; k.headerValue == requestHeaders[h.header.name] {
+			k.failures++
+			k.coolingDownUntil = h.now().Add(apiKeyFailureCooldown)
+			return
+		}
+	}
+}
+
+// APIKeyStats implements [APIKeyStatsReporter.APIKeyStats].
+func (h *rotatingAPIKeyHandler) APIKeyStats() []APIKeyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	stats := make([]APIKeyStats, len(h.keys))
+	for i := range h.keys {
+		k := &h.keys[i]
+		stats[i] = APIKeyStats{
+			Index:       i,
+			Weight:      k.weight,
+			Requests:    k.requests,
+			Failures:    k.failures,
+			CoolingDown: now.Before(k.coolingDownUntil),
+		}
+	}
+	return stats
+}
@@ -6,8 +6,11 @@
 package backendauth
 
 import (
+	"fmt"
 	"os"
+	"slices"
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
@@ -78,3 +81,157 @@ func TestApiKeyHandler_Do(t *testing.T) {
 	require.Equal(t, "Authorization", headerMut.SetHeaders[1].Header.Key)
 	require.Equal(t, []byte("Bearer test"), headerMut.SetHeaders[1].Header.GetRawValue())
 }
+
+func TestApiKeyHandler_Do_customHeader(t *testing.T) {
+	apiKeyFile := t.TempDir() + "/test"
+	require.NoError(t, os.WriteFile(apiKeyFile, []byte("test\n"), 0o600))
+
+	for _, tc := range []struct {
+		name           string
+		auth           filterapi.APIKeyAuth
+		expHeaders     map[string]string
+		expSetHeader   string
+		expSetValue    string
+		expRemovedAuth bool
+	}{
+		{
+			name:         "default",
+			auth:         filterapi.APIKeyAuth{Filename: apiKeyFile},
+			expHeaders:   map[string]string{"Authorization": "Bearer test", "x-api-key": "client-key"},
+			expSetHeader: "Authorization",
+			expSetValue:  "Bearer test",
+		},
+		{
+			name:         "authorization with a custom scheme",
+			auth:         filterapi.APIKeyAuth{Filename: apiKeyFile, HeaderName: "authorization", ValueTemplate: "Token {key}"},
+			expHeaders:   map[string]string{"Authorization": "Token test", "x-api-key": "client-key"},
+			expSetHeader: "Authorization",
+			expSetValue:  "Token test",
+		},
+		{
+			name:           "custom header with the raw key",
+			auth:           filterapi.APIKeyAuth{Filename: apiKeyFile, HeaderName: "X-API-Key", ValueTemplate: "{key}"},
+			expHeaders:     map[string]string{"x-api-key": "test"},
+			expSetHeader:   "x-api-key",
+			expSetValue:    "test",
+			expRemovedAuth: true,
+		},
+		{
+			name:           "custom header with the default template",
+			auth:           filterapi.APIKeyAuth{Filename: apiKeyFile, HeaderName: "x-api-key"},
+			expHeaders:     map[string]string{"x-api-key": "Bearer test"},
+			expSetHeader:   "x-api-key",
+			expSetValue:    "Bearer test",
+			expRemovedAuth: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, err := newAPIKeyHandler(&tc.auth)
+			require.NoError(t, err)
+			// The client supplies its own credentials, which must not reach the backend.
+			requestHeaders := map[string]string{"Authorization": "Bearer client-token", "x-api-key": "client-key"}
+			headerMut := &extprocv3.HeaderMutation{}
+			require.NoError(t, handler.Do(t.Context(), requestHeaders, headerMut, nil))
+			require.Equal(t, tc.expHeaders, requestHeaders)
+			require.Len(t, headerMut.SetHeaders, 1)
+			require.Equal(t, tc.expSetHeader, headerMut.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expSetValue, string(headerMut.SetHeaders[0].Header.RawValue))
+			if tc.expRemovedAuth {
+				require.Equal(t, []string{"authorization"}, headerMut.RemoveHeaders)
+			} else {
+				require.Empty(t, headerMut.RemoveHeaders)
+			}
+		})
+	}
+}
+
+func TestRotatingAPIKeyHandler(t *testing.T) {
+	dir := t.TempDir()
+	// apiKey-4 is ignored as apiKey-3 is missing.
+	for _, i := range []int{0, 1, 2, 4} {
+		filename := fmt.Sprintf("%s/apiKey-%d", dir, i)
+		require.NoError(t, os.WriteFile(filename, []byte(fmt.Sprintf("key-%d\n", i)), 0o600))
+	}
+	newHandler := func(t *testing.T) (*rotatingAPIKeyHandler, *time.Time) {
+		handler, err := newAPIKeyHandler(&filterapi.APIKeyAuth{Directory: dir, Weights: []int{3, 1, 0}})
+		require.NoError(t, err)
+		h := handler.(*rotatingAPIKeyHandler)
+		now := time.Unix(0, 0)
+		h.now = func() time.Time { return now }
+		return h, &now
+	}
+	do := func(t *testing.T, h Handler) map[string]string {
+		requestHeaders := map[string]string{}
+		headerMut := &extprocv3.HeaderMutation{}
+		require.NoError(t, h.Do(t.Context(), requestHeaders, headerMut, nil))
+		require.Equal(t, requestHeaders["Authorization"], string(headerMut.SetHeaders[0].Header.RawValue))
+		return requestHeaders
+	}
+
+	t.Run("weighted distribution", func(t *testing.T) {
+		h, _ := newHandler(t)
+		counts := map[string]int{}
+		var order []string
+		for range 50 {
+			auth := do(t, h)["Authorization"]
+			counts[auth]++
+			if len(order) < 5 {
+				order = append(order, auth)
+			}
+		}
+		require.Equal(t, map[string]int{"Bearer key-0": 30, "Bearer key-1": 10, "Bearer key-2": 10}, counts)
+		// The smooth weighted round-robin interleaves the keys instead of sending bursts to the heaviest one.
+		require.Equal(t, []string{"Bearer key-0", "Bearer key-1", "Bearer key-0", "Bearer key-2", "Bearer key-0"}, order)
+		require.Equal(t, []APIKeyStats{
+			{Index: 0, Weight: 3, Requests: 30},
+			{Index: 1, Weight: 1, Requests: 10},
+			{Index: 2, Weight: 1, Requests: 10},
+		}, h.APIKeyStats())
+	})
+	t.Run("failure skipping", func(t *testing.T) {
+		h, now := newHandler(t)
+		h.ObserveResponse(map[string]string{"Authorization": "Bearer key-0"}, 200)
+		h.ObserveResponse(map[string]string{"Authorization": "Bearer key-0"}, 429)
+		h.ObserveResponse(map[string]string{"Authorization": "Bearer key-1"}, 401)
+		h.ObserveResponse(map[string]string{"Authorization": "Bearer unknown"}, 401)
+		for range 5 {
+			require.Equal(t, "Bearer key-2", do(t, h)["Authorization"])
+		}
+		stats := h.APIKeyStats()
+		require.True(t, stats[0].CoolingDown)
+		require.Equal(t, uint64(1), stats[0].Failures)
+		require.True(t, stats[1].CoolingDown)
+		require.False(t, stats[2].CoolingDown)
+
+		// All the keys are used when all of them are cooling down.
+		h.ObserveResponse(map[string]string{"Authorization": "Bearer key-2"}, 429)
+		counts := map[string]int{}
+		for range 5 {
+			counts[do(t, h)["Authorization"]]++
+		}
+		require.Len(t, counts, 3)
+
+		// The keys are back in the rotation after the cooldown.
+		*now = now.Add(apiKeyFailureCooldown)
+		require.False(t, slices.ContainsFunc(h.APIKeyStats(), func(s APIKeyStats) bool { return s.CoolingDown }))
+	})
+	t.Run("custom header", func(t *testing.T) {
+		handler, err := newAPIKeyHandler(&filterapi.APIKeyAuth{Directory: dir, Weights: []int{3, 1, 0}, HeaderName: "x-api-key", ValueTemplate: "{key}"})
+		require.NoError(t, err)
+		h := handler.(*rotatingAPIKeyHandler)
+		requestHeaders := map[string]string{"authorization": "Bearer client-token"}
+		headerMut := &extprocv3.HeaderMutation{}
+		require.NoError(t, h.Do(t.Context(), requestHeaders, headerMut, nil))
+		require.Equal(t, map[string]string{"x-api-key": "key-0"}, requestHeaders)
+		require.Equal(t, "x-api-key", headerMut.SetHeaders[0].Header.Key)
+		require.Equal(t, []string{"authorization"}, headerMut.RemoveHeaders)
+
+		// The failures are attributed to the API key in the custom header.
+		h.ObserveResponse(requestHeaders, 429)
+		require.True(t, h.APIKeyStats()[0].CoolingDown)
+	})
+	t.Run("missing file", func(t *testing.T) {
+		_, err := newAPIKeyHandler(&filterapi.APIKeyAuth{Directory: t.TempDir()})
+		require.ErrorContains(t, err, "failed to read api key file")
+	})
+}
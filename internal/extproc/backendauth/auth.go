@@ -22,12 +22,20 @@ type Handler interface {
 	Do(ctx context.Context, requestHeaders map[string]string, headerMut *extprocv3.HeaderMutation, bodyMut *extprocv3.BodyMutation) error
 }
 
+// ResponseObserver is optionally implemented by a [Handler] that reacts to the response of the backend.
+type ResponseObserver interface {
+	// ObserveResponse is called with the request headers modified by [Handler.Do] and the status code of the response.
+	ObserveResponse(requestHeaders map[string]string, status int)
+}
+
 // NewHandler returns a new implementation of [Handler] based on the configuration.
 func NewHandler(ctx context.Context, config *filterapi.BackendAuth) (Handler, error) {
 	if config.AWSAuth != nil {
 		return newAWSHandler(ctx, config.AWSAuth)
 	} else if config.APIKey != nil {
 		return newAPIKeyHandler(config.APIKey)
+	} else if config.AzureAuth != nil {
+		return newAzureHandler(config.AzureAuth)
 	}
 	return nil, errors.New("no backend auth handler found")
 }
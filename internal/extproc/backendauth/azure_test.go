@@ -0,0 +1,41 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package backendauth
+
+import (
+	"os"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestNewAzureHandler(t *testing.T) {
+	tokenFile := t.TempDir() + "/azureAccessToken"
+	require.NoError(t, os.WriteFile(tokenFile, []byte(" some-access-token \n"), 0o600))
+
+	handler, err := newAzureHandler(&filterapi.AzureAuth{Filename: tokenFile})
+	require.NoError(t, err)
+	// accessToken should be trimmed.
+	require.Equal(t, "some-access-token", handler.(*azureHandler).accessToken)
+
+	_, err = newAzureHandler(&filterapi.AzureAuth{Filename: t.TempDir() + "/missing"})
+	require.ErrorContains(t, err, "failed to read azure access token file")
+}
+
+func TestAzureHandler_Do(t *testing.T) {
+	handler := &azureHandler{accessToken: "some-access-token"}
+	requestHeaders := map[string]string{":method": "POST"}
+	headerMut := &extprocv3.HeaderMutation{}
+	require.NoError(t, handler.Do(t.Context(), requestHeaders, headerMut, nil))
+
+	require.Equal(t, "Bearer some-access-token", requestHeaders["Authorization"])
+	require.Len(t, headerMut.SetHeaders, 1)
+	require.Equal(t, "Authorization", headerMut.SetHeaders[0].Header.Key)
+	require.Equal(t, []byte("Bearer some-access-token"), headerMut.SetHeaders[0].Header.GetRawValue())
+}
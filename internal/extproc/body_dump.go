@@ -0,0 +1,173 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+)
+
+const (
+	// defaultBodyDumpTTL is the duration the body dump is enabled for when the ttl parameter is not given.
+	defaultBodyDumpTTL = 5 * time.Minute
+	// maxBodyDumpTTL bounds the duration the body dump is enabled for, so that it is never left enabled for long.
+	maxBodyDumpTTL = time.Hour
+)
+
+// bodyDump is the runtime toggle of the dump of the request and response bodies before and after the translation
+// to the logger, which is enabled through the POST /debug/dump endpoint for a sample of the requests for a bounded
+// time. This is meant to reproduce the translation bugs in production without restarting with the debug log level.
+//
+// Only the bodies are dumped. The headers are not, so the credentials set by the backend auth never appear in the
+// dumps, the same as the headers redacted in the debug logs.
+type bodyDump struct {
+	settings atomic.Pointer[bodyDumpSettings]
+	// now and random are replaced in the tests.
+	now    func() time.Time
+	random func() float64
+}
+
+// bodyDumpSettings is the settings of the enabled body dump, which is also the response body of the endpoint.
+type bodyDumpSettings struct {
+	// SampleRate is the fraction of the requests whose bodies are dumped, greater than 0 and at most 1.
+	SampleRate float64 `json:"sampleRate"`
+	// Until is the time when the body dump is disabled automatically.
+	Until time.Time `json:"until"`
+}
+
+// newBodyDump creates the disabled body dump.
+func newBodyDump() *bodyDump {
+	return &bodyDump{now: time.Now, random: rand.Float64}
+}
+
+// enable enables the body dump with the sample rate for the ttl, overriding the current settings.
+func (d *bodyDump) enable(sampleRate float64, ttl time.Duration) *bodyDumpSettings {
+	settings := &bodyDumpSettings{SampleRate: sampleRate, Until: d.now().Add(ttl)}
+	d.settings.Store(settings)
+	return settings
+}
+
+// disable disables the body dump.
+func (d *bodyDump) disable() {
+	d.settings.Store(nil)
+}
+
+// current returns the current settings, or nil when the body dump is disabled or has expired.
+func (d *bodyDump) current() *bodyDumpSettings {
+	settings := d.settings.Load()
+	if settings == nil {
+		return nil
+	}
+	if !d.now().Before(settings.Until) {
+		// Reverts to disabled unless enabled again in the meantime.
+		d.settings.CompareAndSwap(settings, nil)
+		return nil
+	}
+	return settings
+}
+
+// newStream decides whether the bodies of the request are dumped, and returns the dump of the stream tagged with the
+// request ID, or nil if not dumped. This is called once per request so that both of the request and the response
+// of a sampled request are dumped even if the body dump expires in between.
+func (d *bodyDump) newStream(logger *slog.Logger, requestHeaders map[string]string) *streamBodyDump {
+	settings := d.current()
+	if settings == nil {
+		return nil
+	}
+	if settings.SampleRate < 1 && d.random() >= settings.SampleRate {
+		return nil
+	}
+	return &streamBodyDump{logger: logger.With(
+		slog.String("request_id", requestHeaders[requestIDHeaderKey]),
+		slog.String("path", requestHeaders[":path"]),
+	)}
+}
+
+// streamBodyDump dumps the bodies of a sampled request. The nil value dumps nothing.
+type streamBodyDump struct {
+	logger *slog.Logger
+}
+
+// observe dumps the body of the message from Envoy, and the body sent back in the response to it, if any. The
+// body of the response is omitted when the body is not mutated.
+func (s *streamBodyDump) observe(req *extprocv3.ProcessingRequest, resp *extprocv3.ProcessingResponse) {
+	if s == nil {
+		return
+	}
+	var (
+		msg      string
+		body     *extprocv3.HttpBody
+		response *extprocv3.BodyResponse
+	)
+	switch {
+	case req.GetRequestBody() != nil:
+		msg, body, response = "request body dump", req.GetRequestBody(), resp.GetRequestBody()
+	case req.GetResponseBody() != nil:
+		msg, body, response = "response body dump", req.GetResponseBody(), resp.GetResponseBody()
+	default:
+		return
+	}
+	attrs := []any{slog.String("original", string(body.Body)), slog.Bool("end_of_stream", body.EndOfStream)}
+	if mutation := response.GetResponse().GetBodyMutation(); mutation != nil {
+		attrs = append(attrs, slog.String("translated", string(mutation.GetBody())))
+	} else if ir := resp.GetImmediateResponse(); ir != nil {
+		attrs = append(attrs, slog.String("immediate_response", string(ir.Body)), slog.Int("status", int(ir.GetStatus().GetCode())))
+	}
+	s.logger.Info(msg, attrs...)
+}
+
+// handleDebugDump enables the body dump with the query parameters "sample", the sample rate defaulting to 1, and
+// "ttl", the duration defaulting to 5m, when "enable" is true, or disables it when "enable" is false. This serves
+// the settings of the enabled body dump, or null when disabled.
+func (s *Server) handleDebugDump(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	enable, err := strconv.ParseBool(query.Get("enable"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid enable %q: must be true or false", query.Get("enable")), http.StatusBadRequest)
+		return
+	}
+	var settings *bodyDumpSettings
+	if enable {
+		sampleRate, ttl := 1.0, defaultBodyDumpTTL
+		if v := query.Get("sample"); v != "" {
+			if sampleRate, err = strconv.ParseFloat(v, 64); err != nil || !(sampleRate > 0 && sampleRate <= 1) {
+				http.Error(w, fmt.Sprintf("invalid sample %q: must be greater than 0 and at most 1", v), http.StatusBadRequest)
+				return
+			}
+		}
+		if v := query.Get("ttl"); v != "" {
+			if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 || ttl > maxBodyDumpTTL {
+				http.Error(w, fmt.Sprintf("invalid ttl %q: must be positive and at most %s", v, maxBodyDumpTTL), http.StatusBadRequest)
+				return
+			}
+		}
+		settings = s.bodyDump.enable(sampleRate, ttl)
+		s.logger.Info("enabled the body dump", slog.Float64("sample_rate", sampleRate), slog.Time("until", settings.Until))
+	} else {
+		s.bodyDump.disable()
+		s.logger.Info("disabled the body dump")
+	}
+	w.Header().Set("content-type", "application/json")
+	if err = json.NewEncoder(w).Encode(settings); err != nil {
+		s.logger.Error("cannot encode the body dump settings", "error", err)
+	}
+}
+
+// handleDebugDumpStatus serves the settings of the body dump, or null when disabled or expired.
+func (s *Server) handleDebugDumpStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.bodyDump.current()); err != nil {
+		s.logger.Error("cannot encode the body dump settings", "error", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestBodyDump(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	var random float64
+	d := &bodyDump{now: func() time.Time { return now }, random: func() float64 { return random }}
+	logger := slog.New(slog.DiscardHandler)
+	headers := map[string]string{requestIDHeaderKey: "req-1"}
+
+	// Disabled by default.
+	require.Nil(t, d.current())
+	require.Nil(t, d.newStream(logger, headers))
+
+	t.Run("expiry", func(t *testing.T) {
+		settings := d.enable(1, time.Minute)
+		require.Equal(t, &bodyDumpSettings{SampleRate: 1, Until: now.Add(time.Minute)}, settings)
+		require.Equal(t, settings, d.current())
+		require.NotNil(t, d.newStream(logger, headers))
+
+		now = now.Add(time.Minute)
+		require.Nil(t, d.current())
+		require.Nil(t, d.newStream(logger, headers))
+		require.Nil(t, d.settings.Load())
+
+		// Enabling again restarts the TTL.
+		d.enable(1, time.Minute)
+		now = now.Add(59 * time.Second)
+		require.NotNil(t, d.current())
+		d.disable()
+		require.Nil(t, d.current())
+	})
+	t.Run("sampling", func(t *testing.T) {
+		d.enable(0.25, time.Minute)
+		for _, tc := range []struct {
+			random  float64
+			sampled bool
+		}{
+			{random: 0, sampled: true},
+			{random: 0.24, sampled: true},
+			{random: 0.25},
+			{random: 0.99},
+		} {
+			random = tc.random
+			require.Equal(t, tc.sampled, d.newStream(logger, headers) != nil, tc.random)
+		}
+		// The sample rate of 1 dumps all the requests.
+		d.enable(1, time.Minute)
+		random = 0.99
+		require.NotNil(t, d.newStream(logger, headers))
+	})
+}
+
+func TestServer_handleDebugDump(t *testing.T) {
+	s, err := NewServer(slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	s.bodyDump.now = func() time.Time { return now }
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/debug/dump")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `null`, rec.Body.String())
+
+	rec = do(http.MethodPost, "/debug/dump?enable=true&sample=0.01&ttl=10m")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("content-type"))
+	require.JSONEq(t, `{"sampleRate":0.01,"until":"2025-01-02T03:14:05Z"}`, rec.Body.String())
+	rec = do(http.MethodGet, "/debug/dump")
+	require.JSONEq(t, `{"sampleRate":0.01,"until":"2025-01-02T03:14:05Z"}`, rec.Body.String())
+
+	// The parameters default to all the requests for 5 minutes.
+	rec = do(http.MethodPost, "/debug/dump?enable=true")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"sampleRate":1,"until":"2025-01-02T03:09:05Z"}`, rec.Body.String())
+
+	// Reverts after the TTL.
+	now = now.Add(defaultBodyDumpTTL)
+	rec = do(http.MethodGet, "/debug/dump")
+	require.JSONEq(t, `null`, rec.Body.String())
+
+	do(http.MethodPost, "/debug/dump?enable=true")
+	rec = do(http.MethodPost, "/debug/dump?enable=false")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `null`, rec.Body.String())
+	require.Nil(t, s.bodyDump.current())
+
+	for _, tc := range []struct {
+		query  string
+		expErr string
+	}{
+		{query: "", expErr: `invalid enable "": must be true or false`},
+		{query: "enable=yes", expErr: `invalid enable "yes": must be true or false`},
+		{query: "enable=true&sample=0", expErr: `invalid sample "0": must be greater than 0 and at most 1`},
+		{query: "enable=true&sample=1.5", expErr: `invalid sample "1.5": must be greater than 0 and at most 1`},
+		{query: "enable=true&sample=NaN", expErr: `invalid sample "NaN": must be greater than 0 and at most 1`},
+		{query: "enable=true&ttl=0s", expErr: `invalid ttl "0s": must be positive and at most 1h0m0s`},
+		{query: "enable=true&ttl=2h", expErr: `invalid ttl "2h": must be positive and at most 1h0m0s`},
+		{query: "enable=true&ttl=5", expErr: `invalid ttl "5": must be positive and at most 1h0m0s`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			rec := do(http.MethodPost, "/debug/dump?"+tc.query)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			require.Equal(t, tc.expErr+"\n", rec.Body.String())
+			require.Nil(t, s.bodyDump.current())
+		})
+	}
+}
+
+func TestServer_Process_bodyDump(t *testing.T) {
+	var buf bytes.Buffer
+	s, err := NewServer(slog.New(slog.NewJSONHandler(&buf, nil)))
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+		}},
+	}))
+	process := func() []map[string]any {
+		buf.Reset()
+		_ = s.Process(&mockScriptedProcessingStream{ctx: t.Context(), retErr: io.EOF, reqs: []*extprocv3.ProcessingRequest{
+			{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+					{Key: ":path", Value: "/v1/chat/completions"},
+					{Key: "x-request-id", Value: "req-1"},
+				}},
+			}}},
+			{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+				Body: []byte(`{"model":"some-model","messages":[{"role":"user","content":"hi"}]}`), EndOfStream: true,
+			}}},
+			{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}},
+			}}},
+			{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{
+				Body:        []byte(`{"output":{"message":{"content":[{"text":"hello"}],"role":"assistant"}},"stopReason":"end_turn"}`),
+				EndOfStream: true,
+			}}},
+		}})
+		var dumps []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			if msg := entry["msg"].(string); strings.HasSuffix(msg, "body dump") {
+				dumps = append(dumps, entry)
+			}
+		}
+		return dumps
+	}
+
+	require.Empty(t, process())
+
+	s.bodyDump.enable(1, time.Minute)
+	dumps := process()
+	require.Len(t, dumps, 2)
+	require.Equal(t, "request body dump", dumps[0]["msg"])
+	require.Equal(t, "req-1", dumps[0]["request_id"])
+	require.Equal(t, "/v1/chat/completions", dumps[0]["path"])
+	require.JSONEq(t, `{"model":"some-model","messages":[{"role":"user","content":"hi"}]}`, dumps[0]["original"].(string))
+	require.JSONEq(t, `{"inferenceConfig":{},"messages":[{"content":[{"text":"hi"}],"role":"user"}],"modelId":null}`, dumps[0]["translated"].(string))
+	require.Equal(t, "response body dump", dumps[1]["msg"])
+	require.Equal(t, "req-1", dumps[1]["request_id"])
+	require.Equal(t, true, dumps[1]["end_of_stream"])
+	require.Contains(t, dumps[1]["translated"], `"content":"hello"`)
+
+	s.bodyDump.disable()
+	require.Empty(t, process())
+}
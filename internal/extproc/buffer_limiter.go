@@ -0,0 +1,101 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// bufferLimiter tracks the number of bytes of the request bodies buffered by the in-flight streams.
+//
+// This is owned by the [Server] and shared across configuration reloads so that the accounting
+// of the in-flight streams survives them.
+type bufferLimiter struct {
+	// buffered is the current number of the buffered bytes.
+	buffered atomic.Int64
+	// rejections is the number of the requests rejected because of exceeding the limit.
+	rejections atomic.Uint64
+}
+
+// reserve adds n bytes to the buffered bytes unless it would exceed the limit. A non-positive limit means unlimited.
+//
+// When this returns false, the rejection is counted and nothing is reserved.
+func (l *bufferLimiter) reserve(n, limit int64) bool {
+	for {
+		current := l.buffered.Load()
+		if limit > 0 && current+n > limit {
+			l.rejections.Add(1)
+			return false
+		}
+		if l.buffered.CompareAndSwap(current, current+n) {
+			return true
+		}
+	}
+}
+
+// release subtracts n bytes previously reserved from the buffered bytes.
+func (l *bufferLimiter) release(n int64) {
+	l.buffered.Add(-n)
+}
+
+// streamBufferReservation is the per-stream handle of the bytes reserved in the [bufferLimiter].
+type streamBufferReservation struct {
+	limiter *bufferLimiter
+	// reserved is the number of bytes reserved by the stream so far.
+	reserved int64
+}
+
+// reserveUpTo grows the reservation of the stream to n bytes unless it would exceed the limit.
+// This is a no-op when the stream has already reserved n bytes or more.
+func (r *streamBufferReservation) reserveUpTo(n, limit int64) bool {
+	if n <= r.reserved {
+		return true
+	}
+	if !r.limiter.reserve(n-r.reserved, limit) {
+		return false
+	}
+	r.reserved = n
+	return true
+}
+
+// release releases all the bytes reserved by the stream. This must be called when the stream ends.
+func (r *streamBufferReservation) release() {
+	r.limiter.release(r.reserved)
+	r.reserved = 0
+}
+
+// serverOverloadedResponse returns the immediate response to reject the request with 503 Service Unavailable
+// because the buffered bytes would exceed the limit.
+func serverOverloadedResponse(limit int64) *extprocv3.ProcessingResponse {
+	code := "server_overloaded"
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "server_error",
+			Code:    &code,
+			Message: fmt.Sprintf("the server is overloaded: buffering the request body would exceed the limit of %d bytes", limit),
+		},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_ServiceUnavailable},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestBufferLimiter(t *testing.T) {
+	var l bufferLimiter
+	require.True(t, l.reserve(60, 100))
+	require.False(t, l.reserve(50, 100))
+	require.True(t, l.reserve(40, 100))
+	require.Equal(t, int64(100), l.buffered.Load())
+	require.Equal(t, uint64(1), l.rejections.Load())
+	l.release(100)
+	require.Zero(t, l.buffered.Load())
+	// Non-positive limit means unlimited.
+	require.True(t, l.reserve(1000, 0))
+	l.release(1000)
+
+	r := &streamBufferReservation{limiter: &l}
+	require.True(t, r.reserveUpTo(30, 100))
+	require.True(t, r.reserveUpTo(20, 100))
+	require.True(t, r.reserveUpTo(80, 100))
+	require.Equal(t, int64(80), l.buffered.Load())
+	require.False(t, r.reserveUpTo(120, 100))
+	require.Equal(t, int64(80), r.reserved)
+	r.release()
+	require.Zero(t, l.buffered.Load())
+}
+
+func TestServer_Process_bufferLimit(t *testing.T) {
+	const limit = 1000
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.SetMaxBufferedBytes(limit)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+		}},
+	}))
+
+	body := []byte(`{"model":"some-model","messages":[{"role":"user","content":"` + strings.Repeat("hello ", 40) + `"}]}`)
+	require.Less(t, len(body)*3, limit)
+	require.Greater(t, len(body)*4, limit)
+	newStream := func(withContentLength bool, unblock <-chan struct{}, retErr error) *mockScriptedProcessingStream {
+		headers := []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}
+		if withContentLength {
+			headers = append(headers, &corev3.HeaderValue{Key: "content-length", Value: strconv.Itoa(len(body))})
+		}
+		return &mockScriptedProcessingStream{
+			ctx: t.Context(),
+			reqs: []*extprocv3.ProcessingRequest{
+				{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+					Headers: &corev3.HeaderMap{Headers: headers},
+				}}},
+				{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{Body: body}}},
+			},
+			unblock: unblock,
+			retErr:  retErr,
+		}
+	}
+	// rejectedAt returns the index of the sent response rejecting the request, or -1 if not rejected.
+	rejectedAt := func(ms *mockScriptedProcessingStream) int {
+		for i, resp := range ms.sentResponses() {
+			if resp.GetImmediateResponse().GetStatus().GetCode() == typev3.StatusCode_ServiceUnavailable {
+				return i
+			}
+		}
+		return -1
+	}
+
+	t.Run("reject over the limit", func(t *testing.T) {
+		unblock := make(chan struct{})
+		var wg sync.WaitGroup
+		held := []*mockScriptedProcessingStream{
+			newStream(true, unblock, io.EOF),
+			newStream(false, unblock, io.EOF),
+			newStream(true, unblock, status.Error(codes.Canceled, "client went away")),
+		}
+		for _, ms := range held {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, s.Process(ms))
+			}()
+		}
+		require.Eventually(t, func() bool {
+			return s.bufferLimiter.buffered.Load() == int64(3*len(body))
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// The request with the content-length is rejected before the body is buffered.
+		rejected := newStream(true, nil, io.EOF)
+		require.NoError(t, s.Process(rejected))
+		require.Equal(t, 0, rejectedAt(rejected))
+		ir := rejected.sentResponses()[0].GetImmediateResponse()
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+		require.Equal(t, "server_overloaded", *openAIErr.Error.Code)
+		require.Equal(t, "server_error", openAIErr.Error.Type)
+
+		// The request without the content-length is rejected on the body.
+		rejected = newStream(false, nil, io.EOF)
+		require.NoError(t, s.Process(rejected))
+		require.Equal(t, 1, rejectedAt(rejected))
+		require.Equal(t, uint64(2), s.bufferLimiter.rejections.Load())
+
+		close(unblock)
+		wg.Wait()
+		require.Zero(t, s.bufferLimiter.buffered.Load())
+		for _, ms := range held {
+			require.Equal(t, -1, rejectedAt(ms))
+		}
+	})
+	t.Run("parallel streams never leak", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 200 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var retErr error = io.EOF
+				if i%2 == 0 {
+					retErr = status.Error(codes.Canceled, "client went away")
+				}
+				_ = s.Process(newStream(i%3 == 0, nil, retErr))
+			}()
+		}
+		wg.Wait()
+		require.Zero(t, s.bufferLimiter.buffered.Load())
+	})
+}
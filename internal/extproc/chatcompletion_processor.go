@@ -7,6 +7,7 @@ package extproc
 
 import (
 	"bytes"
+	"cmp"
 	"compress/gzip"
 	"context"
 	"encoding/json"
@@ -14,28 +15,55 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/utils/ptr"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
 
+const (
+	// inputTokensHeaderKey is the header or trailer key of the input token count emitted when EmitCostHeaders is enabled.
+	inputTokensHeaderKey = "x-ai-eg-input-tokens"
+	// outputTokensHeaderKey is the header or trailer key of the output token count emitted when EmitCostHeaders is enabled.
+	outputTokensHeaderKey = "x-ai-eg-output-tokens"
+	// totalTokensHeaderKey is the header or trailer key of the total token count emitted when EmitCostHeaders is enabled.
+	totalTokensHeaderKey = "x-ai-eg-total-tokens"
+)
+
 // NewChatCompletionProcessor implements [Processor] for the /chat/completions endpoint.
 func NewChatCompletionProcessor(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger) (Processor, error) {
 	if config.schema.Name != filterapi.APISchemaOpenAI {
 		return nil, fmt.Errorf("unsupported API schema: %s", config.schema.Name)
 	}
+	var requestID string
+	if !config.requestIDPropagationDisabled {
+		// Envoy generates the request ID unless configured otherwise, so this normally reuses it.
+		if requestID = requestHeaders[requestIDHeaderKey]; requestID == "" {
+			requestID = newRequestID()
+		}
+		logger = logger.With(slog.String("request_id", requestID))
+	}
 	return &chatCompletionProcessor{
 		config:         config,
 		requestHeaders: requestHeaders,
 		logger:         logger,
+		requestID:      requestID,
 	}, nil
 }
 
@@ -47,25 +75,121 @@ type chatCompletionProcessor struct {
 	responseHeaders  map[string]string
 	responseEncoding string
 	translator       translator.Translator
+	// stream is true if the request is a streaming request.
+	stream bool
 	// cost is the cost of the request that is accumulated during the processing of the response.
 	costs translator.LLMTokenUsage
+	// releaseConcurrency releases the concurrency limit counter acquired for this request, if any.
+	releaseConcurrency func()
+	// choicesFanOut is the additional requests issued for the `n` parameter, if any.
+	choicesFanOut *choicesFanOut
+	// backendName is the name of the selected backend. Empty until the backend is selected.
+	backendName string
+	// responseBodyBytes and responseBodyChunks are the size and the number of the response body chunks processed so far.
+	responseBodyBytes  int
+	responseBodyChunks int
+	// responseCompleted is true once the end of the response body has been processed.
+	responseCompleted bool
+	// fullDuplex is true when the streaming response body is processed in the full duplex mode to send the heartbeats.
+	fullDuplex bool
+	// lastForwarded is the time when the last response body chunk was forwarded to the client, and atEventBoundary
+	// is true when the forwarded body so far ends at the boundary of the SSE events. These are only tracked in the
+	// full duplex mode. lastForwarded stays zero until the first body chunk is received since Envoy does not accept
+	// the streamed body response before that.
+	lastForwarded   time.Time
+	atEventBoundary bool
+	// requestID is the request ID sent to the backend, and upstreamRequestID is the one assigned by the backend.
+	// Both are empty when the request ID propagation is disabled.
+	requestID, upstreamRequestID string
+	// latency tracks the timings of the request to the backend.
+	latency upstreamLatency
+	// user is the "user" field of the request, if any.
+	user string
+	// estimatedInputTokens is the input tokens estimated before the request is forwarded. Nil unless estimated.
+	estimatedInputTokens *uint32
 }
 
-// selectTranslator selects the translator based on the output schema.
-func (c *chatCompletionProcessor) selectTranslator(out filterapi.VersionedAPISchema) error {
+// selectTranslator selects the translator based on the output schema of the backend.
+func (c *chatCompletionProcessor) selectTranslator(b *filterapi.Backend) error {
 	if c.translator != nil { // Prevents re-selection and allows translator injection in tests.
 		return nil
 	}
+	var err error
+	c.translator, err = newChatCompletionTranslator(c.config.schema, c.requestHeaders[":path"], b, c.config.maxStreamBufferSize)
+	return err
+}
+
+// newChatCompletionTranslator creates the translator based on the output schema of the backend, unless
+// [x.NewCustomTranslatorFactory] provides the one for the input schema, the output schema and the path.
+// The maxStreamBufferSize limits the unparsed bytes buffered while translating the streaming responses.
+func newChatCompletionTranslator(input filterapi.VersionedAPISchema, path string, b *filterapi.Backend, maxStreamBufferSize int) (translator.Translator, error) {
+	if x.NewCustomTranslatorFactory != nil {
+		if t := x.NewCustomTranslatorFactory(input, b.Schema, path); t != nil {
+			return &customTranslator{custom: t}, nil
+		}
+	}
 	// TODO: currently, we ignore the LLMAPISchema."Version" field.
-	switch out.Name {
+	switch out := b.Schema; out.Name {
 	case filterapi.APISchemaOpenAI:
-		c.translator = translator.NewChatCompletionOpenAIToOpenAITranslator()
+		return translator.NewChatCompletionOpenAIToOpenAITranslator(), nil
 	case filterapi.APISchemaAWSBedrock:
-		c.translator = translator.NewChatCompletionOpenAIToAWSBedrockTranslator()
+		var modelPrefix string
+		var modelFamilies []translator.BedrockModelFamily
+		if bc := b.AWSBedrock; bc != nil {
+			modelPrefix = bc.ModelPrefix
+			for _, f := range bc.ModelFamilies {
+				modelFamilies = append(modelFamilies, translator.BedrockModelFamily{ModelPrefix: f.ModelPrefix, NoSystemMessages: f.NoSystemMessages})
+			}
+			if bc.BedrockAPI == filterapi.AWSBedrockAPIInvokeModel {
+				return translator.NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(maxStreamBufferSize,
+					unsupportedFieldPolicy(b.UnsupportedFieldPolicy), modelPrefix, parameterNormalization(b.ParameterNormalization)), nil
+			}
+		}
+		var guardrail *awsbedrock.GuardrailConfiguration
+		if gc := b.GuardrailConfig; gc != nil {
+			guardrail = &awsbedrock.GuardrailConfiguration{
+				GuardrailIdentifier: ptr.To(gc.Identifier),
+				GuardrailVersion:    ptr.To(gc.Version),
+			}
+			if gc.Trace != "" {
+				guardrail.Trace = ptr.To(gc.Trace)
+			}
+		}
+		return translator.NewChatCompletionOpenAIToAWSBedrockTranslator(guardrail, maxStreamBufferSize,
+			unsupportedFieldPolicy(b.UnsupportedFieldPolicy), modelPrefix, parameterNormalization(b.ParameterNormalization),
+			modelFamilies), nil
+	case filterapi.APISchemaCohere:
+		return translator.NewChatCompletionOpenAIToCohereTranslator(), nil
+	case filterapi.APISchemaMistral:
+		return translator.NewChatCompletionOpenAIToMistralTranslator(unsupportedFieldPolicy(b.UnsupportedFieldPolicy)), nil
+	default:
+		return nil, fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+}
+
+// unsupportedFieldPolicy converts the [filterapi.UnsupportedFieldPolicy] to the one of the translator.
+func unsupportedFieldPolicy(policy filterapi.UnsupportedFieldPolicy) translator.UnsupportedFieldPolicy {
+	switch policy {
+	case filterapi.UnsupportedFieldPolicyWarn:
+		return translator.UnsupportedFieldPolicyWarn
+	case filterapi.UnsupportedFieldPolicyReject:
+		return translator.UnsupportedFieldPolicyReject
 	default:
-		return fmt.Errorf("unsupported API schema: backend=%s", out)
+		return translator.UnsupportedFieldPolicyIgnore
+	}
+}
+
+// parameterNormalization converts the [filterapi.ParameterNormalization] to the one of the translator.
+func parameterNormalization(pn *filterapi.ParameterNormalization) *translator.ParameterNormalization {
+	if pn == nil {
+		return nil
+	}
+	return &translator.ParameterNormalization{
+		Auto:             pn.Mode == filterapi.ParameterNormalizationModeAuto,
+		TemperatureScale: pn.TemperatureScale,
+		TopPScale:        pn.TopPScale,
+		Debug:            pn.Debug,
 	}
-	return nil
 }
 
 // ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
@@ -78,53 +202,199 @@ func (c *chatCompletionProcessor) ProcessRequestHeaders(_ context.Context, _ *co
 
 // ProcessRequestBody implements [Processor.ProcessRequestBody].
 func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	defer func() {
+		// The request rejected early never reaches the response processing, so the metadata is set here for the
+		// consumers such as the rate limit selectors to see the model and the backend of the rejected request.
+		if res.GetImmediateResponse() != nil && len(c.config.requestCosts) > 0 {
+			var mdErr error
+			if res.DynamicMetadata, mdErr = c.maybeBuildDynamicMetadata(); mdErr != nil {
+				c.logger.Error("failed to build dynamic metadata of the rejected request", "error", mdErr)
+			}
+		}
+	}()
 	model, body, err := parseOpenAIChatCompletionBody(rawBody)
-	if err != nil {
+	var validationErr *requestValidationError
+	if errors.As(err, &validationErr) {
+		c.logger.Info("Rejecting invalid request", "param", validationErr.pointer, "reason", validationErr.message)
+		return invalidRequestResponse(validationErr.code, validationErr.pointer, validationErr.message), nil
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to parse request body: %w", err)
 	}
 	c.logger.Info("Processing request", "path", c.requestHeaders[":path"], "model", model)
+	if !modelAllowed(c.config.modelPolicy, model) {
+		c.logger.Info("Rejecting request for the model not allowed by the model policy", "model", model)
+		return modelNotAllowedResponse(model), nil
+	}
+
+	openAIReq := body.(*openai.ChatCompletionRequest)
+	c.user = openAIReq.User
+	stream := openAIReq.Stream
+	c.stream = stream
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(spanAttrModel.String(model), spanAttrStream.Bool(stream))
+
+	if resp := c.maybeAcquireConcurrency(stream); resp != nil {
+		return resp, nil
+	}
 
 	c.requestHeaders[c.config.modelNameHeaderKey] = model
 	b, err := c.config.router.Calculate(c.requestHeaders)
 	if err != nil {
 		if errors.Is(err, x.ErrNoMatchingRule) {
-			return &extprocv3.ProcessingResponse{
-				Response: &extprocv3.ProcessingResponse_ImmediateResponse{
-					ImmediateResponse: &extprocv3.ImmediateResponse{
-						Status: &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
-						Body:   []byte(err.Error()),
-					},
-				},
-			}, nil
+			return c.noMatchingRule(model), nil
+		} else if errors.Is(err, x.ErrNoAvailableBackend) {
+			c.logger.Info("Rejecting request with no available backend", "model", model, "reason", err)
+			return noAvailableBackendResponse(fmt.Sprintf("no backend is available for the model %s", model)), nil
 		}
 
 		return nil, fmt.Errorf("failed to calculate route: %w", err)
 	}
 	c.logger.Info("Selected backend", "backend", b.Name)
+	c.backendName = b.Name
+	span.SetAttributes(
+		spanAttrBackend.String(b.Name),
+		spanAttrSchemaInput.String(string(c.config.schema.Name)),
+		spanAttrSchemaOutput.String(string(b.Schema.Name)),
+	)
+	var (
+		modifiedParams      map[string]any
+		headerModifications = []*filterapi.HeaderModifications{
+			nil, b.HeaderModifications, openAIHeaderModifications(b.OpenAI),
+		}
+	)
+	if ruleIndex, ok := c.config.backendRuleIndexes[b]; ok {
+		span.SetAttributes(spanAttrRouteRuleIndex.Int(ruleIndex))
+		rule := &c.config.rules[ruleIndex]
+		headerModifications[0] = rule.HeaderModifications
+		if rule.ModelDefaults != nil || rule.ModelLimits != nil {
+			modifiedParams, err = applyModelParams(openAIReq, rule.ModelDefaults, rule.ModelLimits)
+			var limitErr *modelLimitError
+			if errors.As(err, &limitErr) {
+				c.logger.Info("Rejecting request over the model limits", "param", limitErr.param, "reason", limitErr.message)
+				return modelLimitExceededResponse(limitErr), nil
+			}
+		}
+	}
+
+	var fanOutChoices int
+	if n := ptr.Deref(openAIReq.N, 1); n > 1 && b.Schema.Name == filterapi.APISchemaAWSBedrock {
+		if resp := c.checkChoicesFanOut(b, n, stream); resp != nil {
+			return resp, nil
+		}
+		fanOutChoices = n - 1
+	}
 
-	if err = c.selectTranslator(b.Schema); err != nil {
+	if err = c.selectTranslator(b); err != nil {
 		return nil, fmt.Errorf("failed to select translator: %w", err)
 	}
 
+	if c.config.allowRemoteImages && b.Schema.Name == filterapi.APISchemaAWSBedrock {
+		var imageErr *remoteImageError
+		if err = inlineRemoteImages(ctx, c.config.remoteImageClient, openAIReq); errors.As(err, &imageErr) {
+			c.logger.Info("Rejecting request with the image that cannot be fetched", "param", imageErr.param, "reason", imageErr.message)
+			return invalidRequestResponse("invalid_image_url", imageErr.param, imageErr.message), nil
+		}
+	}
+
+	if t, ok := c.translator.(translator.RawRequestBodyTranslator); ok {
+		raw := rawBody.Body
+		if len(modifiedParams) > 0 {
+			if raw, err = patchJSONFields(raw, modifiedParams); err != nil {
+				return nil, fmt.Errorf("failed to apply the model parameters: %w", err)
+			}
+		}
+		t.SetRawRequestBody(raw)
+	}
 	headerMutation, bodyMutation, override, err := c.translator.RequestBody(body)
-	if err != nil {
+	var (
+		unsupportedErr *translator.UnsupportedFieldsError
+		audioErr       *translator.UnsupportedAudioInputError
+	)
+	if errors.Is(err, translator.ErrUnsupportedImageURL) {
+		c.logger.Info("Rejecting request with the remote image URL", "backend", b.Name, "reason", err)
+		return invalidRequestResponse("unsupported_image_url", "messages", err.Error()), nil
+	} else if errors.Is(err, translator.ErrRequestMetadataTooManyKeys) {
+		c.logger.Info("Rejecting request with too many metadata keys", "backend", b.Name)
+		return invalidRequestResponse("invalid_value", "metadata", err.Error()), nil
+	} else if errors.Is(err, translator.ErrAssistantPrefillWithToolChoice) {
+		c.logger.Info("Rejecting request with the assistant prefill and the forced tool use", "backend", b.Name)
+		return invalidRequestResponse("invalid_value", "tool_choice", err.Error()), nil
+	} else if errors.As(err, &unsupportedErr) {
+		c.logger.Info("Rejecting request with the unsupported fields", "backend", b.Name, "fields", unsupportedErr.Fields)
+		return invalidRequestResponse("unsupported_parameter", strings.Join(unsupportedErr.Fields, ","),
+			fmt.Sprintf("the fields are not supported by the backend %s: %s", b.Name, strings.Join(unsupportedErr.Fields, ", "))), nil
+	} else if errors.As(err, &audioErr) {
+		c.logger.Info("Rejecting request with the audio input", "backend", b.Name, "param", audioErr.Param())
+		return invalidRequestResponse("unsupported_content_type", audioErr.Param(), audioErr.Error()), nil
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to transform request: %w", err)
 	}
 
 	if headerMutation == nil {
 		headerMutation = &extprocv3.HeaderMutation{}
 	}
+	if stream && (c.config.emitCostHeaders || c.config.emitLatencyHeaders) {
+		// The headers have already been sent when the token usage is known, so it is sent in the trailers.
+		if override == nil {
+			override = &extprocv3http.ProcessingMode{}
+		}
+		override.ResponseTrailerMode = extprocv3http.ProcessingMode_SEND
+	}
+	if stream && c.config.heartbeatInterval > 0 {
+		// The heartbeats can only be sent independently of the response body chunks in the full duplex mode,
+		// which requires the trailers to be sent as well.
+		if override == nil {
+			override = &extprocv3http.ProcessingMode{}
+		}
+		override.ResponseBodyMode = extprocv3http.ProcessingMode_FULL_DUPLEX_STREAMED
+		override.ResponseTrailerMode = extprocv3http.ProcessingMode_SEND
+		c.fullDuplex = true
+	}
+	// The translator passing through the original body does not reflect the modified parameters, so patch them here.
+	if bodyMutation == nil && len(modifiedParams) > 0 {
+		var patched []byte
+		if patched, err = patchJSONFields(rawBody.Body, modifiedParams); err != nil {
+			return nil, fmt.Errorf("failed to apply the model parameters: %w", err)
+		}
+		bodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: patched}}
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: "content-length", RawValue: []byte(strconv.Itoa(len(patched)))},
+		})
+	}
 	// Set the model name to the request header with the key `x-ai-gateway-llm-model-name`.
 	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{Key: c.config.modelNameHeaderKey, RawValue: []byte(model)},
 	}, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{Key: c.config.selectedBackendHeaderKey, RawValue: []byte(b.Name)},
 	})
+	if c.requestID != "" {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: requestIDHeaderKey, RawValue: []byte(c.requestID)},
+		})
+	}
+
+	// The path prefix is only exposed to the clients, so the backend receives the path without it unless the
+	// translator has already rewritten the path.
+	if c.config.pathPrefix != "" && headerMutationValue(headerMutation, ":path") == "" {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(c.requestHeaders[":path"])},
+		})
+	}
+	applyPathOverride(b, model, headerMutation)
+
+	applyHeaderModifications(headerModifications, model, b.Name, c.requestHeaders, headerMutation)
 
 	if authHandler, ok := c.config.backendAuthHandlers[b.Name]; ok {
+		authStart := len(headerMutation.SetHeaders)
 		if err := authHandler.Do(ctx, c.requestHeaders, headerMutation, bodyMutation); err != nil {
 			return nil, fmt.Errorf("failed to do auth request: %w", err)
 		}
+		resolveAuthHeaderConflicts(headerMutation, authStart)
+	}
+
+	if fanOutChoices > 0 {
+		url, _ := bedrockEndpoint(b, headerMutationValue(headerMutation, ":path"))
+		c.choicesFanOut = startChoicesFanOut(ctx, c.config.httpClient, b, url, headerMutation, bodyMutation.GetBody(), fanOutChoices)
 	}
 
 	resp := &extprocv3.ProcessingResponse{
@@ -139,15 +409,51 @@ func (c *chatCompletionProcessor) ProcessRequestBody(ctx context.Context, rawBod
 		},
 		ModeOverride: override,
 	}
+	if estimator := c.config.inputTokenEstimator; estimator != nil {
+		if estimate, err := estimator.EstimateInputTokens(model, rawBody.Body); err != nil {
+			c.logger.Error("failed to estimate the input tokens", "error", err)
+		} else {
+			c.estimatedInputTokens = &estimate
+			resp.DynamicMetadata = c.estimatedInputTokensMetadata()
+		}
+	}
+	c.latency.requestSent = time.Now()
 	return resp, nil
 }
 
 // ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
-func (c *chatCompletionProcessor) ProcessResponseHeaders(_ context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
+func (c *chatCompletionProcessor) ProcessResponseHeaders(ctx context.Context, headers *corev3.HeaderMap) (res *extprocv3.ProcessingResponse, err error) {
 	c.responseHeaders = headersToMap(headers)
+	var override *extprocv3http.ProcessingMode
+	st, statusErr := strconv.Atoi(c.responseHeaders[":status"])
+	if observer, ok := c.config.backendAuthHandlers[c.backendName].(backendauth.ResponseObserver); ok && statusErr == nil {
+		observer.ObserveResponse(c.requestHeaders, st)
+	}
+	if observer, ok := c.config.router.(router.ResponseObserver); ok && statusErr == nil && c.backendName != "" {
+		observer.ObserveResponse(c.backendName, st)
+	}
+	if statusErr == nil && (st < 200 || st >= 300) {
+		// The error response is translated by the translator, but it is still an error from the tracing perspective.
+		recordSpanError(trace.SpanFromContext(ctx), fmt.Errorf("upstream error: status %d", st))
+		if c.stream {
+			// The error response of the streaming request is not an event stream, so the streaming mode is
+			// terminated to receive the entire error body at once. This allows the translator to handle
+			// the error body as a whole and to fix the content-length before the headers are sent.
+			override = &extprocv3http.ProcessingMode{
+				ResponseHeaderMode: extprocv3http.ProcessingMode_SEND,
+				ResponseBodyMode:   extprocv3http.ProcessingMode_BUFFERED,
+			}
+			c.fullDuplex = false
+		}
+	}
 	if enc := c.responseHeaders["content-encoding"]; enc != "" {
 		c.responseEncoding = enc
 	}
+	if c.requestID != "" {
+		if c.upstreamRequestID = upstreamRequestID(c.responseHeaders); c.upstreamRequestID != "" {
+			c.logger.Info("Received response", "upstream_request_id", c.upstreamRequestID, "status", c.responseHeaders[":status"])
+		}
+	}
 	// The translator can be nil as there could be response event generated by previous ext proc without
 	// getting the request event.
 	if c.translator == nil {
@@ -159,15 +465,29 @@ func (c *chatCompletionProcessor) ProcessResponseHeaders(_ context.Context, head
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform response headers: %w", err)
 	}
+	var metadata *structpb.Struct
+	if c.requestID != "" {
+		if c.upstreamRequestID != "" {
+			if headerMutation == nil {
+				headerMutation = &extprocv3.HeaderMutation{}
+			}
+			headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+				Header: &corev3.HeaderValue{Key: upstreamRequestIDHeaderKey, RawValue: []byte(c.upstreamRequestID)},
+			})
+		}
+		// The request IDs are set here as well since the response body may never be processed, for example, when
+		// the stream is reset. Envoy merges the metadata of the same namespace.
+		metadata = c.requestIDMetadata()
+	}
 	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseHeaders{
 		ResponseHeaders: &extprocv3.HeadersResponse{
 			Response: &extprocv3.CommonResponse{HeaderMutation: headerMutation},
 		},
-	}}, nil
+	}, ModeOverride: override, DynamicMetadata: metadata}, nil
 }
 
 // ProcessResponseBody implements [Processor.ProcessResponseBody].
-func (c *chatCompletionProcessor) ProcessResponseBody(_ context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+func (c *chatCompletionProcessor) ProcessResponseBody(ctx context.Context, body *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
 	var br io.Reader
 	switch c.responseEncoding {
 	case "gzip":
@@ -183,11 +503,37 @@ func (c *chatCompletionProcessor) ProcessResponseBody(_ context.Context, body *e
 	if c.translator == nil {
 		return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
 	}
+	c.responseBodyBytes += len(body.Body)
+	c.responseBodyChunks++
+	c.responseCompleted = body.EndOfStream
+	c.latency.observeResponseBody(time.Now(), len(body.Body), body.EndOfStream)
 
 	headerMutation, bodyMutation, tokenUsage, err := c.translator.ResponseBody(c.responseHeaders, br, body.EndOfStream)
+	if errors.Is(err, translator.ErrStreamBufferLimitExceeded) {
+		if c.config.streamBufferOverflows != nil {
+			c.config.streamBufferOverflows.Add(1)
+		}
+		c.logger.Error("aborting the streaming response", "error", err)
+		return streamAbortedResponse(err.Error()), nil
+	}
+	var streamException *translator.StreamExceptionError
+	if errors.As(err, &streamException) {
+		// The body mutation carries the exception as the final error chunk, so it is forwarded as usual.
+		if c.config.streamExceptions != nil {
+			c.config.streamExceptions.Add(1)
+		}
+		c.logger.Warn("streaming response terminated by the upstream", "error", err)
+		err = nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to transform response: %w", err)
 	}
+	if c.choicesFanOut != nil && body.EndOfStream {
+		headerMutation, bodyMutation, tokenUsage, err = c.mergeFanOutChoices(headerMutation, bodyMutation, tokenUsage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge the fanned out choices: %w", err)
+		}
+	}
 
 	resp := &extprocv3.ProcessingResponse{
 		Response: &extprocv3.ProcessingResponse_ResponseBody{
@@ -200,29 +546,358 @@ func (c *chatCompletionProcessor) ProcessResponseBody(_ context.Context, body *e
 		},
 	}
 
+	if c.fullDuplex {
+		// The header mutations cannot be applied once the body is streamed in the full duplex mode.
+		resp.GetResponseBody().Response = &extprocv3.CommonResponse{BodyMutation: c.forwardStreamedBody(body, bodyMutation)}
+	}
+
 	// TODO: this is coupled with "LLM" specific logic. Once we have another use case, we need to refactor this.
 	c.costs.InputTokens += tokenUsage.InputTokens
 	c.costs.OutputTokens += tokenUsage.OutputTokens
 	c.costs.TotalTokens += tokenUsage.TotalTokens
-	if body.EndOfStream && len(c.config.requestCosts) > 0 {
+	c.costs.CacheReadInputTokens += tokenUsage.CacheReadInputTokens
+	c.costs.CacheWriteInputTokens += tokenUsage.CacheWriteInputTokens
+	if body.EndOfStream {
+		trace.SpanFromContext(ctx).SetAttributes(
+			spanAttrInputTokens.Int64(int64(c.costs.InputTokens)),
+			spanAttrOutputTokens.Int64(int64(c.costs.OutputTokens)),
+			spanAttrTotalTokens.Int64(int64(c.costs.TotalTokens)),
+		)
+	}
+	if body.EndOfStream && !c.stream && (c.config.emitCostHeaders || c.config.emitLatencyHeaders) {
+		if headerMutation == nil {
+			headerMutation = &extprocv3.HeaderMutation{}
+			resp.GetResponseBody().Response.HeaderMutation = headerMutation
+		}
+		if c.config.emitCostHeaders {
+			headerMutation.SetHeaders = append(headerMutation.SetHeaders, c.costHeaders()...)
+		}
+		if c.config.emitLatencyHeaders {
+			headerMutation.SetHeaders = append(headerMutation.SetHeaders, c.latency.headers()...)
+		}
+	}
+	// The usage of the streaming response is also set to the dynamic metadata in the middle of the response so that
+	// Envoy has the partial usage when the client disconnects before the end of the stream, since nothing can be sent
+	// once the stream is closed.
+	if (body.EndOfStream || (c.stream && tokenUsage != (translator.LLMTokenUsage{}))) && len(c.config.requestCosts) > 0 {
 		resp.DynamicMetadata, err = c.maybeBuildDynamicMetadata()
 		if err != nil {
 			return nil, fmt.Errorf("failed to build dynamic metadata: %w", err)
 		}
+	} else if body.EndOfStream {
+		resp.DynamicMetadata = c.latencyMetadata()
+	}
+	if body.EndOfStream {
+		c.setEstimatedInputTokensDeltaMetadata(resp.DynamicMetadata.Fields[c.config.metadataNamespace].GetStructValue().Fields)
 	}
 	return resp, nil
 }
 
+// ProcessResponseTrailers implements [Processor.ProcessResponseTrailers].
+func (c *chatCompletionProcessor) ProcessResponseTrailers(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	trailersResponse := &extprocv3.TrailersResponse{}
+	// The trailers are only requested for the streaming responses when EmitCostHeaders or EmitLatencyHeaders is enabled.
+	if c.translator != nil && c.stream && (c.config.emitCostHeaders || c.config.emitLatencyHeaders) {
+		trailersResponse.HeaderMutation = &extprocv3.HeaderMutation{}
+		if c.config.emitCostHeaders {
+			trailersResponse.HeaderMutation.SetHeaders = append(trailersResponse.HeaderMutation.SetHeaders, c.costHeaders()...)
+		}
+		if c.config.emitLatencyHeaders {
+			trailersResponse.HeaderMutation.SetHeaders = append(trailersResponse.HeaderMutation.SetHeaders, c.latency.headers()...)
+		}
+	}
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseTrailers{
+		ResponseTrailers: trailersResponse,
+	}}, nil
+}
+
+// heartbeatEvent is the SSE comment line injected into the idle streaming response. The clients ignore it.
+var heartbeatEvent = []byte(": heartbeat\n\n")
+
+// heartbeat implements [processorHeartbeater.heartbeat].
+//
+// The heartbeat is sent when nothing has been forwarded to the client within the heartbeat interval since the first
+// response body chunk. It is not sent in the middle of an SSE event, nor into the compressed response body where it
+// would corrupt the stream.
+func (c *chatCompletionProcessor) heartbeat(now time.Time) *extprocv3.ProcessingResponse {
+	if !c.fullDuplex || c.lastForwarded.IsZero() || c.responseCompleted || !c.atEventBoundary || c.responseEncoding != "" ||
+		now.Sub(c.lastForwarded) < c.config.heartbeatInterval {
+		return nil
+	}
+	c.lastForwarded = now
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ResponseBody{
+			ResponseBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{BodyMutation: streamedBodyMutation(heartbeatEvent, false)},
+			},
+		},
+	}
+}
+
+// forwardStreamedBody returns the body mutation in the full duplex mode, where the processor sends back every chunk
+// to be forwarded to the client, including the original one when the translator does not mutate it.
+func (c *chatCompletionProcessor) forwardStreamedBody(body *extprocv3.HttpBody, mutation *extprocv3.BodyMutation) *extprocv3.BodyMutation {
+	chunk := body.Body
+	if mutation != nil {
+		chunk = mutation.GetBody()
+	}
+	if len(chunk) > 0 {
+		c.lastForwarded = time.Now()
+		c.atEventBoundary = bytes.HasSuffix(chunk, []byte("\n\n"))
+	}
+	return streamedBodyMutation(chunk, body.EndOfStream)
+}
+
+// streamedBodyMutation returns the body mutation that sends the chunk in the full duplex mode.
+func streamedBodyMutation(chunk []byte, endOfStream bool) *extprocv3.BodyMutation {
+	return &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_StreamedResponse{
+		StreamedResponse: &extprocv3.StreamedBodyResponse{Body: chunk, EndOfStream: endOfStream},
+	}}
+}
+
+// costHeaders returns the headers of the token usage accumulated during the processing of the response.
+func (c *chatCompletionProcessor) costHeaders() []*corev3.HeaderValueOption {
+	return []*corev3.HeaderValueOption{
+		{Header: &corev3.HeaderValue{Key: inputTokensHeaderKey, RawValue: []byte(strconv.FormatUint(uint64(c.costs.InputTokens), 10))}},
+		{Header: &corev3.HeaderValue{Key: outputTokensHeaderKey, RawValue: []byte(strconv.FormatUint(uint64(c.costs.OutputTokens), 10))}},
+		{Header: &corev3.HeaderValue{Key: totalTokensHeaderKey, RawValue: []byte(strconv.FormatUint(uint64(c.costs.TotalTokens), 10))}},
+	}
+}
+
+// maybeAcquireConcurrency acquires the concurrency limit counter of the client identity if the limit is configured.
+// This returns the immediate response to reject the request when the limit is exceeded, otherwise nil.
+func (c *chatCompletionProcessor) maybeAcquireConcurrency(stream bool) *extprocv3.ProcessingResponse {
+	limit := c.config.concurrencyLimit
+	if limit == nil || c.releaseConcurrency != nil {
+		return nil
+	}
+	identity, ok := c.requestHeaders[limit.IdentityHeaderKey]
+	if !ok || identity == "" {
+		return nil
+	}
+	maxRequests, kind := limit.MaxNonStreamingRequests, "non-streaming"
+	if stream {
+		maxRequests, kind = limit.MaxStreamingRequests, "streaming"
+	}
+	var release func()
+	if store := c.config.stateStore; store != nil {
+		var err error
+		release, ok, err = c.config.concurrencyLimiter.acquireShared(context.Background(), store, identity, stream, maxRequests)
+		if errors.Is(err, errStateStoreBreakerOpen) {
+			// The failure has been logged when the breaker opened.
+			c.logger.Debug("Falling back to the local concurrency limit as the state store is skipped", "error", err)
+		} else if err != nil {
+			c.logger.Warn("Falling back to the local concurrency limit as the state store is unavailable", "error", err)
+		}
+	} else {
+		release, ok = c.config.concurrencyLimiter.acquire(identity, stream, maxRequests)
+	}
+	if ok {
+		c.releaseConcurrency = release
+		return nil
+	}
+	c.logger.Info("Rejecting request over the concurrency limit", "identity", identity, "kind", kind, "limit", maxRequests)
+	code := "concurrency_limit_exceeded"
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "rate_limit_error",
+			Code:    &code,
+			Message: fmt.Sprintf("too many concurrent %s requests: the limit is %d", kind, maxRequests),
+		},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_TooManyRequests},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
+
+// noMatchingRule counts and logs the request for the model that matches no rule, and returns the immediate response
+// rejecting it with 404 Not Found.
+func (c *chatCompletionProcessor) noMatchingRule(model string) *extprocv3.ProcessingResponse {
+	if c.config.noMatchingRules != nil {
+		c.config.noMatchingRules.Add(1)
+	}
+	c.logger.Info("Rejecting request with no matching rule", "model", model)
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "model_not_found",
+			Message: fmt.Sprintf("no route for model %s", model),
+		},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
+
+// modelLimitExceededResponse returns the immediate response to reject the request exceeding the model limits.
+func modelLimitExceededResponse(limitErr *modelLimitError) *extprocv3.ProcessingResponse {
+	return invalidRequestResponse("model_limit_exceeded", limitErr.param, limitErr.message)
+}
+
+// invalidRequestResponse returns the immediate response to reject the invalid request with 400 Bad Request.
+func invalidRequestResponse(code, param, message string) *extprocv3.ProcessingResponse {
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "invalid_request_error",
+			Code:    &code,
+			Message: message,
+			Param:   &param,
+		},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_BadRequest},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
+
+// noAvailableBackendResponse returns the immediate response to reject the request with 503 Service Unavailable when the
+// matching rule has no backend to receive the traffic.
+func noAvailableBackendResponse(message string) *extprocv3.ProcessingResponse {
+	code := "no_available_backend"
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "server_error",
+			Code:    &code,
+			Message: message,
+		},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_ServiceUnavailable},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
+
+// streamAbortedResponse returns the immediate response that aborts the streaming response with the OpenAI error chunk
+// followed by the end of the stream.
+func streamAbortedResponse(message string) *extprocv3.ProcessingResponse {
+	code := strconv.Itoa(int(typev3.StatusCode_BadGateway))
+	errBody, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "server_error",
+			Code:    &code,
+			Message: message,
+		},
+	})
+	var body []byte
+	body = append(body, "data: "...)
+	body = append(body, errBody...)
+	body = append(body, "\n\ndata: [DONE]\n"...)
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_BadGateway},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("text/event-stream")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
+
+// close implements [processorCloser].
+func (c *chatCompletionProcessor) close() {
+	if c.translator != nil && c.responseHeaders != nil && !c.responseCompleted {
+		c.logger.Info("stream aborted before the response completed",
+			"bytes", c.responseBodyBytes, "chunks", c.responseBodyChunks,
+			"input_tokens", c.costs.InputTokens, "output_tokens", c.costs.OutputTokens)
+	}
+	if c.releaseConcurrency != nil {
+		c.releaseConcurrency()
+	}
+	if c.choicesFanOut != nil {
+		c.choicesFanOut.close()
+	}
+}
+
+// fillAccessLogRecord implements [accessLogRecorder].
+func (c *chatCompletionProcessor) fillAccessLogRecord(record *x.AccessLogRecord) {
+	if c.requestID != "" {
+		record.RequestID = c.requestID
+	}
+	record.UpstreamRequestID = c.upstreamRequestID
+	record.Backend = c.backendName
+	record.Model = c.requestHeaders[c.config.modelNameHeaderKey]
+	record.Stream = c.stream
+	record.InputTokens = c.costs.InputTokens
+	record.OutputTokens = c.costs.OutputTokens
+	record.TotalTokens = c.costs.TotalTokens
+	if ttft, ok := c.latency.timeToFirstToken(); ok {
+		record.TimeToFirstTokenMillis = ttft.Milliseconds()
+	}
+}
+
 func parseOpenAIChatCompletionBody(body *extprocv3.HttpBody) (modelName string, rb translator.RequestBody, err error) {
 	var openAIReq openai.ChatCompletionRequest
 	if err := json.Unmarshal(body.Body, &openAIReq); err != nil {
-		return "", nil, fmt.Errorf("failed to unmarshal body: %w", err)
+		if validationErr := validateChatCompletionRequest(body.Body); validationErr != nil {
+			return "", nil, validationErr
+		}
+		return "", nil, unmarshalErrorToValidationError(err)
+	}
+	if validationErr := validateChatCompletionRequest(body.Body); validationErr != nil {
+		return "", nil, validationErr
 	}
 	return openAIReq.Model, &openAIReq, nil
 }
 
+// unknownMetadataValue is the value of the model and backend in the dynamic metadata and the CEL expressions
+// when they are not known, for example, when the request is rejected before the backend is selected.
+const unknownMetadataValue = "unknown"
+
+// metadataModelKey and metadataBackendKey are the dynamic metadata keys of the model and the backend names, and
+// metadataUserKey is the one of the "user" field of the request.
+const (
+	metadataModelKey   = "model"
+	metadataBackendKey = "backend"
+	metadataUserKey    = "user"
+)
+
+// maybeBuildDynamicMetadata builds the dynamic metadata of the request costs accumulated so far, along with the model
+// and the backend names.
 func (c *chatCompletionProcessor) maybeBuildDynamicMetadata() (*structpb.Struct, error) {
-	metadata := make(map[string]*structpb.Value, len(c.config.requestCosts))
+	model := cmp.Or(c.requestHeaders[c.config.modelNameHeaderKey], unknownMetadataValue)
+	backend := cmp.Or(c.backendName, unknownMetadataValue)
+	metadata := make(map[string]*structpb.Value, len(c.config.requestCosts)+2)
+	metadata[metadataModelKey] = structpb.NewStringValue(model)
+	metadata[metadataBackendKey] = structpb.NewStringValue(backend)
+	c.setUserMetadata(metadata)
+	c.setRequestIDMetadata(metadata)
+	c.latency.setMetadata(metadata)
 	for i := range c.config.requestCosts {
 		rc := &c.config.requestCosts[i]
 		var cost uint32
@@ -236,8 +911,9 @@ func (c *chatCompletionProcessor) maybeBuildDynamicMetadata() (*structpb.Struct,
 		case filterapi.LLMRequestCostTypeCEL:
 			costU64, err := llmcostcel.EvaluateProgram(
 				rc.celProg,
-				c.requestHeaders[c.config.modelNameHeaderKey],
-				c.requestHeaders[c.config.selectedBackendHeaderKey],
+				model,
+				backend,
+				c.user,
 				c.costs.InputTokens,
 				c.costs.OutputTokens,
 				c.costs.TotalTokens,
@@ -246,15 +922,19 @@ func (c *chatCompletionProcessor) maybeBuildDynamicMetadata() (*structpb.Struct,
 				return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
 			}
 			cost = uint32(costU64) //nolint:gosec
+		case filterapi.LLMRequestCostTypeModelPriceTable:
+			var err error
+			cost, err = modelPriceTableCost(rc.ModelPriceTable, c.requestHeaders[c.config.modelNameHeaderKey],
+				c.costs.InputTokens, c.costs.OutputTokens)
+			if err != nil {
+				return nil, fmt.Errorf("failed to calculate cost with model price table: %w", err)
+			}
 		default:
 			return nil, fmt.Errorf("unknown request cost kind: %s", rc.Type)
 		}
 		c.logger.Info("Setting request cost metadata", "type", rc.Type, "cost", cost, "metadataKey", rc.MetadataKey)
 		metadata[rc.MetadataKey] = &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: float64(cost)}}
 	}
-	if len(metadata) == 0 {
-		return nil, nil
-	}
 	return &structpb.Struct{
 		Fields: map[string]*structpb.Value{
 			c.config.metadataNamespace: {
@@ -265,3 +945,46 @@ func (c *chatCompletionProcessor) maybeBuildDynamicMetadata() (*structpb.Struct,
 		},
 	}, nil
 }
+
+// requestIDMetadata builds the dynamic metadata of the request IDs.
+func (c *chatCompletionProcessor) requestIDMetadata() *structpb.Struct {
+	metadata := make(map[string]*structpb.Value, 2)
+	c.setRequestIDMetadata(metadata)
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			c.config.metadataNamespace: structpb.NewStructValue(&structpb.Struct{Fields: metadata}),
+		},
+	}
+}
+
+// latencyMetadata builds the dynamic metadata of the latencies of the completed response along with the request IDs
+// and the user.
+func (c *chatCompletionProcessor) latencyMetadata() *structpb.Struct {
+	metadata := make(map[string]*structpb.Value, 6)
+	c.setUserMetadata(metadata)
+	c.setRequestIDMetadata(metadata)
+	c.latency.setMetadata(metadata)
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			c.config.metadataNamespace: structpb.NewStructValue(&structpb.Struct{Fields: metadata}),
+		},
+	}
+}
+
+// setUserMetadata sets the user of the request to the metadata, if any, for example, to be used for the per-user
+// rate limiting.
+func (c *chatCompletionProcessor) setUserMetadata(metadata map[string]*structpb.Value) {
+	if c.user != "" {
+		metadata[metadataUserKey] = structpb.NewStringValue(c.user)
+	}
+}
+
+// setRequestIDMetadata sets the request IDs known so far to the metadata.
+func (c *chatCompletionProcessor) setRequestIDMetadata(metadata map[string]*structpb.Value) {
+	if c.requestID != "" {
+		metadata[metadataRequestIDKey] = structpb.NewStringValue(c.requestID)
+	}
+	if c.upstreamRequestID != "" {
+		metadata[metadataUpstreamRequestIDKey] = structpb.NewStringValue(c.upstreamRequestID)
+	}
+}
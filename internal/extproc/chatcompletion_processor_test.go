@@ -8,18 +8,27 @@ package extproc
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
@@ -32,27 +41,108 @@ func TestChatCompletion_Scheema(t *testing.T) {
 	})
 	t.Run("supported openai", func(t *testing.T) {
 		cfg := &processorConfig{schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI, Version: "v123"}}
-		_, err := NewChatCompletionProcessor(cfg, nil, nil)
+		_, err := NewChatCompletionProcessor(cfg, nil, slog.Default())
 		require.NoError(t, err)
 	})
 }
 
 func TestChatCompletion_SelectTranslator(t *testing.T) {
-	c := &chatCompletionProcessor{}
 	t.Run("unsupported", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"})
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: "Bar", Version: "v123"}})
 		require.ErrorContains(t, err, "unsupported API schema: backend={Bar v123}")
 	})
 	t.Run("supported openai", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI})
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}})
 		require.NoError(t, err)
 		require.NotNil(t, c.translator)
 	})
 	t.Run("supported aws bedrock", func(t *testing.T) {
-		err := c.selectTranslator(filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock})
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}})
+		require.NoError(t, err)
+		require.NotNil(t, c.translator)
+	})
+	t.Run("supported cohere", func(t *testing.T) {
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaCohere}})
+		require.NoError(t, err)
+		require.NotNil(t, c.translator)
+	})
+	t.Run("supported mistral", func(t *testing.T) {
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaMistral}})
 		require.NoError(t, err)
 		require.NotNil(t, c.translator)
 	})
+	t.Run("aws bedrock with guardrail", func(t *testing.T) {
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{
+			Schema:          filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+			GuardrailConfig: &filterapi.GuardrailConfig{Identifier: "gr-1", Version: "DRAFT", Trace: "enabled"},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, c.translator)
+
+		_, bm, _, err := c.translator.RequestBody(&openai.ChatCompletionRequest{Model: "m"})
+		require.NoError(t, err)
+		var converse awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &converse))
+		require.Equal(t, &awsbedrock.GuardrailConfiguration{
+			GuardrailIdentifier: ptr.To("gr-1"),
+			GuardrailVersion:    ptr.To("DRAFT"),
+			Trace:               ptr.To("enabled"),
+		}, converse.GuardrailConfig)
+	})
+	t.Run("aws bedrock invoke model", func(t *testing.T) {
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{
+			Schema:     filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+			AWSBedrock: &filterapi.AWSBedrockConfig{BedrockAPI: filterapi.AWSBedrockAPIInvokeModel},
+		})
+		require.NoError(t, err)
+		hm, _, _, err := c.translator.RequestBody(&openai.ChatCompletionRequest{Model: "amazon.titan-text-express-v1"})
+		require.NoError(t, err)
+		require.Equal(t, "/model/amazon.titan-text-express-v1/invoke", string(hm.SetHeaders[0].Header.RawValue))
+	})
+	t.Run("aws bedrock model prefix", func(t *testing.T) {
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{
+			Schema:     filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+			AWSBedrock: &filterapi.AWSBedrockConfig{ModelPrefix: "us."},
+		})
+		require.NoError(t, err)
+		hm, _, _, err := c.translator.RequestBody(&openai.ChatCompletionRequest{Model: "anthropic.claude-3-5-sonnet-20240620-v1:0"})
+		require.NoError(t, err)
+		require.Equal(t, "/model/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse", string(hm.SetHeaders[0].Header.RawValue))
+	})
+	t.Run("aws bedrock model families", func(t *testing.T) {
+		c := &chatCompletionProcessor{config: &processorConfig{}}
+		err := c.selectTranslator(&filterapi.Backend{
+			Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+			AWSBedrock: &filterapi.AWSBedrockConfig{
+				ModelFamilies: []filterapi.AWSBedrockModelFamily{{ModelPrefix: "ai21.", NoSystemMessages: true}},
+			},
+		})
+		require.NoError(t, err)
+		_, bm, _, err := c.translator.RequestBody(&openai.ChatCompletionRequest{
+			Model: "ai21.jamba-1-5-large-v1:0",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{Type: openai.ChatMessageRoleSystem, Value: openai.ChatCompletionSystemMessageParam{
+					Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: "be brief"},
+				}},
+				{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+					Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "hi"},
+				}},
+			},
+		})
+		require.NoError(t, err)
+		var converse awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &converse))
+		require.Empty(t, converse.System)
+		require.Equal(t, "be brief\n\n---\n\nhi", *converse.Messages[0].Content[0].Text)
+	})
 }
 
 func TestChatCompletion_ProcessRequestHeaders(t *testing.T) {
@@ -68,7 +158,7 @@ func TestChatCompletion_ProcessRequestHeaders(t *testing.T) {
 func TestChatCompletion_ProcessResponseHeaders(t *testing.T) {
 	t.Run("error translation", func(t *testing.T) {
 		mt := &mockTranslator{t: t, expHeaders: make(map[string]string)}
-		p := &chatCompletionProcessor{translator: mt}
+		p := &chatCompletionProcessor{translator: mt, config: &processorConfig{}}
 		mt.retErr = errors.New("test error")
 		_, err := p.ProcessResponseHeaders(t.Context(), nil)
 		require.ErrorContains(t, err, "test error")
@@ -79,11 +169,71 @@ func TestChatCompletion_ProcessResponseHeaders(t *testing.T) {
 		}
 		expHeaders := map[string]string{"foo": "bar", "dog": "cat"}
 		mt := &mockTranslator{t: t, expHeaders: expHeaders}
-		p := &chatCompletionProcessor{translator: mt}
+		p := &chatCompletionProcessor{translator: mt, config: &processorConfig{}}
 		res, err := p.ProcessResponseHeaders(t.Context(), inHeaders)
 		require.NoError(t, err)
 		commonRes := res.Response.(*extprocv3.ProcessingResponse_ResponseHeaders).ResponseHeaders.Response
 		require.Equal(t, mt.retHeaderMutation, commonRes.HeaderMutation)
+		require.Nil(t, res.ModeOverride)
+	})
+	t.Run("streaming error", func(t *testing.T) {
+		for _, tc := range []struct {
+			status      string
+			stream      bool
+			expOverride *extprocv3http.ProcessingMode
+		}{
+			{status: "200", stream: true},
+			{status: "429", stream: false},
+			{
+				status: "429", stream: true,
+				expOverride: &extprocv3http.ProcessingMode{
+					ResponseHeaderMode: extprocv3http.ProcessingMode_SEND,
+					ResponseBodyMode:   extprocv3http.ProcessingMode_BUFFERED,
+				},
+			},
+		} {
+			t.Run(fmt.Sprintf("status=%s,stream=%v", tc.status, tc.stream), func(t *testing.T) {
+				mt := &mockTranslator{t: t, expHeaders: map[string]string{":status": tc.status}}
+				p := &chatCompletionProcessor{translator: mt, stream: tc.stream, config: &processorConfig{}}
+				res, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{
+					Headers: []*corev3.HeaderValue{{Key: ":status", Value: tc.status}},
+				})
+				require.NoError(t, err)
+				require.Equal(t, tc.expOverride, res.ModeOverride)
+			})
+		}
+	})
+	t.Run("auth response observer", func(t *testing.T) {
+		observer := &mockResponseObserver{}
+		headers := map[string]string{":status": "429"}
+		mt := &mockTranslator{t: t, expHeaders: headers}
+		p := &chatCompletionProcessor{
+			translator: mt, backendName: "openai", requestHeaders: map[string]string{"Authorization": "Bearer key-0"},
+			config: &processorConfig{backendAuthHandlers: map[string]backendauth.Handler{"openai": observer}},
+		}
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "429"}}})
+		require.NoError(t, err)
+		require.Equal(t, "Bearer key-0", observer.authorization)
+		require.Equal(t, 429, observer.status)
+	})
+	t.Run("router response observer", func(t *testing.T) {
+		rt, err := router.New(&filterapi.Config{
+			PassiveHealthCheck: &filterapi.PassiveHealthCheck{ConsecutiveFailures: 1},
+			Rules: []filterapi.RouteRule{{
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+				Backends: []filterapi.Backend{{Name: "foo", Weight: 1}, {Name: "bar", Weight: 1}},
+			}},
+		}, nil)
+		require.NoError(t, err)
+		mt := &mockTranslator{t: t, expHeaders: map[string]string{":status": "503"}}
+		p := &chatCompletionProcessor{translator: mt, backendName: "foo", config: &processorConfig{router: rt}}
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "503"}}})
+		require.NoError(t, err)
+		for range 100 {
+			b, err := rt.Calculate(map[string]string{"x-model-name": "some-model"})
+			require.NoError(t, err)
+			require.Equal(t, "bar", b.Name)
+		}
 	})
 }
 
@@ -95,6 +245,41 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 		_, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
 		require.ErrorContains(t, err, "test error")
 	})
+	t.Run("stream buffer limit exceeded", func(t *testing.T) {
+		mt := &mockTranslator{t: t}
+		var overflows atomic.Uint64
+		p := &chatCompletionProcessor{
+			translator: mt, stream: true, logger: slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			config: &processorConfig{streamBufferOverflows: &overflows},
+		}
+		mt.retErr = fmt.Errorf("%w: more than 10 bytes are not parsed", translator.ErrStreamBufferLimitExceeded)
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), overflows.Load())
+		ir := res.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, typev3.StatusCode_BadGateway, ir.Status.Code)
+		require.Equal(t, "text/event-stream", string(ir.Headers.SetHeaders[0].Header.RawValue))
+		require.Equal(t, `data: {"type":"error","error":{"type":"server_error","code":"502","message":"streaming response buffer limit exceeded: more than 10 bytes are not parsed"}}
+
+data: [DONE]
+`, string(ir.Body))
+	})
+	t.Run("stream exception", func(t *testing.T) {
+		mt := &mockTranslator{t: t, retBodyMutation: &extprocv3.BodyMutation{
+			Mutation: &extprocv3.BodyMutation_Body{Body: []byte("data: [DONE]\n")},
+		}}
+		var exceptions atomic.Uint64
+		p := &chatCompletionProcessor{
+			translator: mt, stream: true, logger: slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{})),
+			config: &processorConfig{streamExceptions: &exceptions},
+		}
+		mt.retErr = &translator.StreamExceptionError{Type: "throttlingException", Message: "slow down"}
+		res, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), exceptions.Load())
+		require.Equal(t, "data: [DONE]\n", string(res.GetResponseBody().Response.BodyMutation.GetBody()))
+	})
 	t.Run("ok", func(t *testing.T) {
 		inBody := &extprocv3.HttpBody{Body: []byte("some-body"), EndOfStream: true}
 		expBodyMut := &extprocv3.BodyMutation{}
@@ -122,6 +307,11 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 					celProg:        celProgUint,
 					LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel_uint"},
 				},
+				{
+					LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeModelPriceTable, MetadataKey: "price", ModelPriceTable: &filterapi.ModelPriceTable{
+						Default: &filterapi.ModelPrice{InputTokenPrice: 1, OutputTokenPrice: 2},
+					}},
+				},
 			},
 		}}
 		res, err := p.ProcessResponseBody(t.Context(), inBody)
@@ -140,21 +330,483 @@ func TestChatCompletion_ProcessResponseBody(t *testing.T) {
 			GetStructValue().Fields["cel_int"].GetNumberValue())
 		require.Equal(t, float64(9999), md.Fields["ai_gateway_llm_ns"].
 			GetStructValue().Fields["cel_uint"].GetNumberValue())
+		require.Equal(t, float64(247), md.Fields["ai_gateway_llm_ns"].
+			GetStructValue().Fields["price"].GetNumberValue())
+	})
+}
+
+func TestChatCompletion_metadataModelAndBackend(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai.default", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+		}},
+		LLMRequestCosts: []filterapi.LLMRequestCost{
+			{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total"},
+			{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel", CEL: `backend == 'openai.default' && model == 'gpt' ? 1 : 0`},
+			{Type: filterapi.LLMRequestCostTypeCEL, MetadataKey: "cel_user", CEL: `user == 'alice' ? 1 : 0`},
+		},
+		MetadataNamespace: "ai_gateway_llm_ns",
+	}))
+	newProcessor := func(t *testing.T, body string) (*chatCompletionProcessor, *extprocv3.ProcessingResponse) {
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		return p.(*chatCompletionProcessor), resp
+	}
+	requireMetadata := func(t *testing.T, resp *extprocv3.ProcessingResponse, model, backend string, cel float64) {
+		md := resp.GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+		require.Equal(t, model, md["model"].GetStringValue())
+		require.Equal(t, backend, md["backend"].GetStringValue())
+		require.Equal(t, cel, md["cel"].GetNumberValue())
+	}
+	processResponse := func(t *testing.T, p *chatCompletionProcessor, status string, body string, endOfStream bool) *extprocv3.ProcessingResponse {
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":status", Value: status}, {Key: "content-type", Value: "application/json"},
+		}})
+		require.NoError(t, err)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body), EndOfStream: endOfStream})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		p, _ := newProcessor(t, `{"model":"gpt","messages":[]}`)
+		resp := processResponse(t, p, "200", `{"usage":{"total_tokens":10}}`, true)
+		requireMetadata(t, resp, "gpt", "openai.default", 1)
+	})
+	t.Run("error response", func(t *testing.T) {
+		p, _ := newProcessor(t, `{"model":"gpt","messages":[]}`)
+		resp := processResponse(t, p, "500", `{"error":{"message":"boom"}}`, true)
+		requireMetadata(t, resp, "gpt", "openai.default", 1)
+	})
+	t.Run("no matching rule", func(t *testing.T) {
+		_, resp := newProcessor(t, `{"model":"unknown-model","messages":[]}`)
+		require.Equal(t, typev3.StatusCode_NotFound, resp.GetImmediateResponse().GetStatus().GetCode())
+		requireMetadata(t, resp, "unknown-model", "unknown", 0)
+	})
+	t.Run("user", func(t *testing.T) {
+		p, _ := newProcessor(t, `{"model":"gpt","messages":[],"user":"alice"}`)
+		resp := processResponse(t, p, "200", `{"usage":{"total_tokens":10}}`, true)
+		md := resp.GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+		require.Equal(t, "alice", md["user"].GetStringValue())
+		require.Equal(t, float64(1), md["cel_user"].GetNumberValue())
+
+		p, _ = newProcessor(t, `{"model":"gpt","messages":[]}`)
+		resp = processResponse(t, p, "200", `{"usage":{"total_tokens":10}}`, true)
+		md = resp.GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+		require.NotContains(t, md, "user")
+		require.Equal(t, float64(0), md["cel_user"].GetNumberValue())
+	})
+}
+
+func TestChatCompletion_requestID(t *testing.T) {
+	newServer := func(t *testing.T, disabled bool) *Server {
+		s, err := NewServer(slog.Default())
+		require.NoError(t, err)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+			}},
+			MetadataNamespace:            "ai_gateway_llm_ns",
+			RequestIDPropagationDisabled: disabled,
+		}))
+		return s
+	}
+	process := func(t *testing.T, s *Server, requestHeaders map[string]string, responseHeaders []*corev3.HeaderValue) (
+		p *chatCompletionProcessor, reqResp, resResp *extprocv3.ProcessingResponse,
+	) {
+		requestHeaders[":path"] = "/v1/chat/completions"
+		processor, err := NewChatCompletionProcessor(s.config.Load(), requestHeaders, slog.Default())
+		require.NoError(t, err)
+		p = processor.(*chatCompletionProcessor)
+		reqResp, err = p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"gpt","messages":[]}`)})
+		require.NoError(t, err)
+		resResp, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: responseHeaders})
+		require.NoError(t, err)
+		return
+	}
+	requestIDMetadata := func(resp *extprocv3.ProcessingResponse) (requestID, upstreamRequestID string) {
+		md := resp.GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+		return md["request_id"].GetStringValue(), md["upstream_request_id"].GetStringValue()
+	}
+
+	t.Run("reuse incoming", func(t *testing.T) {
+		p, reqResp, resResp := process(t, newServer(t, false), map[string]string{"x-request-id": "req-1"}, []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"}, {Key: "x-request-id", Value: "upstream-1"},
+		})
+		require.Equal(t, "req-1", headerMutationValue(reqResp.GetRequestBody().GetResponse().GetHeaderMutation(), "x-request-id"))
+		require.Equal(t, "upstream-1",
+			headerMutationValue(resResp.GetResponseHeaders().GetResponse().GetHeaderMutation(), "x-ai-eg-upstream-request-id"))
+		requestID, upstreamRequestID := requestIDMetadata(resResp)
+		require.Equal(t, "req-1", requestID)
+		require.Equal(t, "upstream-1", upstreamRequestID)
+
+		var record x.AccessLogRecord
+		p.fillAccessLogRecord(&record)
+		require.Equal(t, "req-1", record.RequestID)
+		require.Equal(t, "upstream-1", record.UpstreamRequestID)
+	})
+	t.Run("generated", func(t *testing.T) {
+		_, reqResp, resResp := process(t, newServer(t, false), map[string]string{}, []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"}, {Key: "x-amzn-requestid", Value: "upstream-2"},
+		})
+		generated := headerMutationValue(reqResp.GetRequestBody().GetResponse().GetHeaderMutation(), "x-request-id")
+		require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, generated)
+		require.Equal(t, "upstream-2",
+			headerMutationValue(resResp.GetResponseHeaders().GetResponse().GetHeaderMutation(), "x-ai-eg-upstream-request-id"))
+		requestID, upstreamRequestID := requestIDMetadata(resResp)
+		require.Equal(t, generated, requestID)
+		require.Equal(t, "upstream-2", upstreamRequestID)
+	})
+	t.Run("no upstream request id", func(t *testing.T) {
+		_, _, resResp := process(t, newServer(t, false), map[string]string{"x-request-id": "req-3"}, []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"},
+		})
+		require.Empty(t, headerMutationValue(resResp.GetResponseHeaders().GetResponse().GetHeaderMutation(), "x-ai-eg-upstream-request-id"))
+		requestID, upstreamRequestID := requestIDMetadata(resResp)
+		require.Equal(t, "req-3", requestID)
+		require.Empty(t, upstreamRequestID)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		_, reqResp, resResp := process(t, newServer(t, true), map[string]string{}, []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"}, {Key: "x-request-id", Value: "upstream-4"},
+		})
+		require.Empty(t, headerMutationValue(reqResp.GetRequestBody().GetResponse().GetHeaderMutation(), "x-request-id"))
+		require.Empty(t, headerMutationValue(resResp.GetResponseHeaders().GetResponse().GetHeaderMutation(), "x-ai-eg-upstream-request-id"))
+		require.Nil(t, resResp.GetDynamicMetadata())
+	})
+}
+
+func TestChatCompletion_noAvailableBackend(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{
+				{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 0},
+				{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 0},
+			},
+			Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+		}},
+	}))
+	p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+	require.NoError(t, err)
+	resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"gpt","messages":[]}`)})
+	require.NoError(t, err)
+	ir := resp.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, typev3.StatusCode_ServiceUnavailable, ir.GetStatus().GetCode())
+	require.JSONEq(t, `{"type":"error","error":{"type":"server_error","code":"no_available_backend",
+"message":"no backend is available for the model gpt"}}`, string(ir.GetBody()))
+}
+
+func TestChatCompletion_noMatchingRule(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		defaultGPT bool
+	}{
+		{name: "rejected"},
+		{name: "default rule", defaultGPT: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewServer(slog.Default())
+			require.NoError(t, err)
+			require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       "x-model-name",
+				SelectedBackendHeaderKey: "x-selected-backend",
+				Rules: []filterapi.RouteRule{{
+					Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+					Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+					Default:  tc.defaultGPT,
+				}},
+			}))
+			p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+			require.NoError(t, err)
+			resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"unknown","messages":[]}`)})
+			require.NoError(t, err)
+			if tc.defaultGPT {
+				require.Nil(t, resp.GetImmediateResponse())
+				require.Equal(t, "openai", headerMutationValue(resp.GetRequestBody().GetResponse().GetHeaderMutation(), "x-selected-backend"))
+				require.Zero(t, s.noMatchingRules.Load())
+				return
+			}
+			ir := resp.GetImmediateResponse()
+			require.NotNil(t, ir)
+			require.Equal(t, typev3.StatusCode_NotFound, ir.GetStatus().GetCode())
+			require.JSONEq(t, `{"type":"error","error":{"type":"model_not_found","message":"no route for model unknown"}}`, string(ir.GetBody()))
+			require.Equal(t, uint64(1), s.noMatchingRules.Load())
+		})
+	}
+}
+
+func TestChatCompletion_emitCostHeaders(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+		}},
+		LLMRequestCosts: []filterapi.LLMRequestCost{
+			{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input"},
+			{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output"},
+			{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total"},
+		},
+		MetadataNamespace: "ai_gateway_llm_ns",
+		EmitCostHeaders:   true,
+	}))
+	newProcessor := func(t *testing.T, body string) (*chatCompletionProcessor, *extprocv3.ProcessingResponse) {
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		return p.(*chatCompletionProcessor), resp
+	}
+	requireCostHeaders := func(t *testing.T, resp *extprocv3.ProcessingResponse, setHeaders []*corev3.HeaderValueOption) {
+		md := resp.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue().Fields
+		require.Equal(t, map[string]string{
+			"x-ai-eg-input-tokens":  "10",
+			"x-ai-eg-output-tokens": "20",
+			"x-ai-eg-total-tokens":  "30",
+		}, headers(setHeaders))
+		// The values must match the ones written into the dynamic metadata.
+		require.Equal(t, float64(10), md["input"].GetNumberValue())
+		require.Equal(t, float64(20), md["output"].GetNumberValue())
+		require.Equal(t, float64(30), md["total"].GetNumberValue())
+	}
+
+	t.Run("non-streaming", func(t *testing.T) {
+		p, resp := newProcessor(t, `{"model":"gpt","messages":[]}`)
+		require.Nil(t, resp.ModeOverride)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+			Body:        []byte(`{"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`),
+			EndOfStream: true,
+		})
+		require.NoError(t, err)
+		requireCostHeaders(t, resp, resp.GetResponseBody().GetResponse().GetHeaderMutation().GetSetHeaders())
+
+		resp, err = p.ProcessResponseTrailers(t.Context(), &corev3.HeaderMap{})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetResponseTrailers().GetHeaderMutation())
+	})
+	t.Run("streaming", func(t *testing.T) {
+		p, resp := newProcessor(t, `{"model":"gpt","messages":[],"stream":true}`)
+		require.Equal(t, extprocv3http.ProcessingMode_SEND, resp.ModeOverride.ResponseTrailerMode)
+		require.Equal(t, extprocv3http.ProcessingMode_STREAMED, resp.ModeOverride.ResponseBodyMode)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: {\"choices\":[]}\n\n")})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetResponseBody().GetResponse().GetHeaderMutation())
+		bodyResp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+			Body:        []byte("data: {\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":20,\"total_tokens\":30}}\n\ndata: [DONE]\n\n"),
+			EndOfStream: true,
+		})
+		require.NoError(t, err)
+		// The headers have already been sent, so the token usage is only set in the trailers.
+		require.Nil(t, bodyResp.GetResponseBody().GetResponse().GetHeaderMutation())
+
+		resp, err = p.ProcessResponseTrailers(t.Context(), &corev3.HeaderMap{})
+		require.NoError(t, err)
+		require.NotNil(t, resp.GetResponseTrailers())
+		resp.DynamicMetadata = bodyResp.DynamicMetadata
+		requireCostHeaders(t, resp, resp.GetResponseTrailers().GetHeaderMutation().GetSetHeaders())
+	})
+}
+
+func TestChatCompletion_latency(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	newProcessor := func(t *testing.T, body string, emitLatencyHeaders bool) (*chatCompletionProcessor, *extprocv3.ProcessingResponse) {
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+			}},
+			MetadataNamespace:  "ai_gateway_llm_ns",
+			EmitLatencyHeaders: emitLatencyHeaders,
+		}))
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		c := p.(*chatCompletionProcessor)
+		require.False(t, c.latency.requestSent.IsZero())
+		// Pretend that the request was forwarded a while ago.
+		c.latency.requestSent = c.latency.requestSent.Add(-time.Second)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		return c, resp
+	}
+	// requireLatency requires the latencies in milliseconds to be plausible given the request was sent 1s ago.
+	requireLatency := func(t *testing.T, ttft, duration string) {
+		ttftMs, err := strconv.Atoi(ttft)
+		require.NoError(t, err)
+		durationMs, err := strconv.Atoi(duration)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, ttftMs, 1000)
+		require.LessOrEqual(t, ttftMs, durationMs)
+		require.Less(t, durationMs, 10000)
+	}
+
+	t.Run("metadata", func(t *testing.T) {
+		p, _ := newProcessor(t, `{"model":"gpt","messages":[],"stream":true}`, false)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: {\"choices\":[]}\n\n")})
+		require.NoError(t, err)
+		require.Nil(t, resp.DynamicMetadata)
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: [DONE]\n\n"), EndOfStream: true})
+		require.NoError(t, err)
+		md := resp.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue().Fields
+		requireLatency(t,
+			strconv.Itoa(int(md["time_to_first_token_ms"].GetNumberValue())),
+			strconv.Itoa(int(md["upstream_duration_ms"].GetNumberValue())))
+
+		resp, err = p.ProcessResponseTrailers(t.Context(), &corev3.HeaderMap{})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetResponseTrailers().GetHeaderMutation())
+
+		record := &x.AccessLogRecord{}
+		p.fillAccessLogRecord(record)
+		require.GreaterOrEqual(t, record.TimeToFirstTokenMillis, int64(1000))
+	})
+	t.Run("non-streaming headers", func(t *testing.T) {
+		p, resp := newProcessor(t, `{"model":"gpt","messages":[]}`, true)
+		require.Nil(t, resp.ModeOverride)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{}`), EndOfStream: true})
+		require.NoError(t, err)
+		h := headers(resp.GetResponseBody().GetResponse().GetHeaderMutation().GetSetHeaders())
+		requireLatency(t, h["x-ai-eg-time-to-first-token-ms"], h["x-ai-eg-upstream-duration-ms"])
+	})
+	t.Run("streaming trailers", func(t *testing.T) {
+		p, resp := newProcessor(t, `{"model":"gpt","messages":[],"stream":true}`, true)
+		require.Equal(t, extprocv3http.ProcessingMode_SEND, resp.ModeOverride.ResponseTrailerMode)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: [DONE]\n\n"), EndOfStream: true})
+		require.NoError(t, err)
+		// The headers have already been sent, so the latencies are only set in the trailers.
+		require.Nil(t, resp.GetResponseBody().GetResponse().GetHeaderMutation())
+		resp, err = p.ProcessResponseTrailers(t.Context(), &corev3.HeaderMap{})
+		require.NoError(t, err)
+		h := headers(resp.GetResponseTrailers().GetHeaderMutation().GetSetHeaders())
+		requireLatency(t, h["x-ai-eg-time-to-first-token-ms"], h["x-ai-eg-upstream-duration-ms"])
+	})
+}
+
+func TestChatCompletion_heartbeat(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+		}},
+		Streaming: &filterapi.StreamingConfig{HeartbeatInterval: "10s"},
+	}))
+	newProcessor := func(t *testing.T, body, status string) (*chatCompletionProcessor, *extprocv3.ProcessingResponse) {
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: status}}})
+		require.NoError(t, err)
+		return p.(*chatCompletionProcessor), resp
+	}
+	requireHeartbeat := func(t *testing.T, resp *extprocv3.ProcessingResponse) {
+		streamed := resp.GetResponseBody().GetResponse().GetBodyMutation().GetStreamedResponse()
+		require.Equal(t, ": heartbeat\n\n", string(streamed.GetBody()))
+		require.False(t, streamed.GetEndOfStream())
+	}
+
+	t.Run("streaming", func(t *testing.T) {
+		p, resp := newProcessor(t, `{"model":"gpt","messages":[],"stream":true}`, "200")
+		require.Equal(t, extprocv3http.ProcessingMode_FULL_DUPLEX_STREAMED, resp.ModeOverride.ResponseBodyMode)
+		require.Equal(t, extprocv3http.ProcessingMode_SEND, resp.ModeOverride.ResponseTrailerMode)
+
+		// No heartbeat is sent before the first body chunk.
+		require.Nil(t, p.heartbeat(time.Now().Add(time.Minute)))
+
+		// The chunks are sent back as is in the full duplex mode.
+		chunk := []byte("data: {\"choices\":[]}\n\n")
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: chunk})
+		require.NoError(t, err)
+		require.Equal(t, chunk, resp.GetResponseBody().GetResponse().GetBodyMutation().GetStreamedResponse().GetBody())
+		start := p.lastForwarded
+		require.Nil(t, p.heartbeat(start.Add(9*time.Second)))
+		requireHeartbeat(t, p.heartbeat(start.Add(10*time.Second)))
+		// The interval restarts from the last heartbeat.
+		require.Nil(t, p.heartbeat(start.Add(15*time.Second)))
+		requireHeartbeat(t, p.heartbeat(start.Add(20*time.Second)))
+
+		// The heartbeat must not be injected in the middle of an event.
+		_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: {\"choices\":")})
+		require.NoError(t, err)
+		require.Nil(t, p.heartbeat(p.lastForwarded.Add(time.Minute)))
+		_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("[]}\n\n")})
+		require.NoError(t, err)
+		requireHeartbeat(t, p.heartbeat(p.lastForwarded.Add(time.Minute)))
+
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("data: [DONE]\n\n"), EndOfStream: true})
+		require.NoError(t, err)
+		require.True(t, resp.GetResponseBody().GetResponse().GetBodyMutation().GetStreamedResponse().GetEndOfStream())
+		require.Nil(t, p.heartbeat(p.lastForwarded.Add(time.Minute)))
+	})
+	t.Run("streaming error", func(t *testing.T) {
+		p, _ := newProcessor(t, `{"model":"gpt","messages":[],"stream":true}`, "500")
+		require.False(t, p.fullDuplex)
+		require.Nil(t, p.heartbeat(time.Now().Add(time.Minute)))
+	})
+	t.Run("non-streaming", func(t *testing.T) {
+		p, resp := newProcessor(t, `{"model":"gpt","messages":[]}`, "200")
+		require.Nil(t, resp.ModeOverride)
+		require.Nil(t, p.heartbeat(time.Now().Add(time.Minute)))
 	})
 }
 
 func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 	bodyFromModel := func(t *testing.T, model string) []byte {
-		var openAIReq openai.ChatCompletionRequest
-		openAIReq.Model = model
+		openAIReq := openai.ChatCompletionRequest{Model: model, Messages: []openai.ChatCompletionMessageParamUnion{}}
 		bytes, err := json.Marshal(openAIReq)
 		require.NoError(t, err)
 		return bytes
 	}
 	t.Run("body parser error", func(t *testing.T) {
-		p := &chatCompletionProcessor{}
-		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
-		require.ErrorContains(t, err, "invalid character 'o' in literal null")
+		p := &chatCompletionProcessor{config: &processorConfig{}, logger: slog.Default()}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte("nonjson")})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_BadRequest, resp.GetImmediateResponse().GetStatus().GetCode())
+		require.Contains(t, string(resp.GetImmediateResponse().GetBody()), "invalid character 'o' in literal null")
+
+		resp, err = p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"m","messages":[{"role":"bot"}]}`)})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_BadRequest, resp.GetImmediateResponse().GetStatus().GetCode())
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(resp.GetImmediateResponse().GetBody(), &openAIErr))
+		require.Equal(t, "invalid_request_error", openAIErr.Error.Type)
+		require.Equal(t, "invalid_value", *openAIErr.Error.Code)
+		require.Equal(t, "/messages/0/role", *openAIErr.Error.Param)
 	})
 	t.Run("router error", func(t *testing.T) {
 		headers := map[string]string{":path": "/foo"}
@@ -166,14 +818,20 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 	t.Run("router error 404", func(t *testing.T) {
 		headers := map[string]string{":path": "/foo"}
 		rt := mockRouter{t: t, expHeaders: headers, retErr: x.ErrNoMatchingRule}
-		p := &chatCompletionProcessor{config: &processorConfig{router: rt}, requestHeaders: headers, logger: slog.Default()}
+		var noMatchingRules atomic.Uint64
+		p := &chatCompletionProcessor{
+			config:         &processorConfig{router: rt, noMatchingRules: &noMatchingRules},
+			requestHeaders: headers, logger: slog.Default(),
+		}
 		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: bodyFromModel(t, "some-model")})
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		ir := resp.GetImmediateResponse()
 		require.NotNil(t, ir)
 		require.Equal(t, typev3.StatusCode_NotFound, ir.GetStatus().GetCode())
-		require.Equal(t, x.ErrNoMatchingRule.Error(), string(ir.GetBody()))
+		require.Equal(t, "application/json", string(ir.GetHeaders().GetSetHeaders()[0].GetHeader().GetRawValue()))
+		require.JSONEq(t, `{"type":"error","error":{"type":"model_not_found","message":"no route for model some-model"}}`, string(ir.GetBody()))
+		require.Equal(t, uint64(1), noMatchingRules.Load())
 	})
 	t.Run("translator not found", func(t *testing.T) {
 		headers := map[string]string{":path": "/foo"}
@@ -236,11 +894,84 @@ func TestChatCompletion_ProcessRequestBody(t *testing.T) {
 		require.Equal(t, "x-ai-gateway-backend-key", hdrs[1].Header.Key)
 		require.Equal(t, "some-backend", string(hdrs[1].Header.RawValue))
 	})
+	t.Run("concurrency limit exceeded", func(t *testing.T) {
+		limiter := newConcurrencyLimiter()
+		_, ok := limiter.acquire("alice", true, 1)
+		require.True(t, ok)
+		config := &processorConfig{
+			concurrencyLimit:   &filterapi.ConcurrencyLimit{IdentityHeaderKey: "x-user-id", MaxStreamingRequests: 1},
+			concurrencyLimiter: limiter,
+		}
+		p := &chatCompletionProcessor{config: config, requestHeaders: map[string]string{"x-user-id": "alice"}, logger: slog.Default()}
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"some-model","messages":[],"stream":true}`)})
+		require.NoError(t, err)
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, typev3.StatusCode_TooManyRequests, ir.GetStatus().GetCode())
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+		require.Equal(t, "rate_limit_error", openAIErr.Error.Type)
+		require.Equal(t, "concurrency_limit_exceeded", *openAIErr.Error.Code)
+		require.Equal(t, "too many concurrent streaming requests: the limit is 1", openAIErr.Error.Message)
+		require.Nil(t, p.releaseConcurrency)
+		p.close()
+		require.Equal(t, 1, limiter.snapshot().Streaming["alice"])
+	})
+	t.Run("concurrency limit released on close", func(t *testing.T) {
+		headers := map[string]string{":path": "/foo", "x-user-id": "alice"}
+		rt := mockRouter{t: t, expHeaders: headers, retErr: x.ErrNoMatchingRule}
+		limiter := newConcurrencyLimiter()
+		config := &processorConfig{
+			router:             rt,
+			concurrencyLimit:   &filterapi.ConcurrencyLimit{IdentityHeaderKey: "x-user-id", MaxNonStreamingRequests: 1},
+			concurrencyLimiter: limiter,
+		}
+		p := &chatCompletionProcessor{config: config, requestHeaders: headers, logger: slog.Default()}
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: bodyFromModel(t, "some-model")})
+		require.NoError(t, err)
+		require.Equal(t, map[string]int{"alice": 1}, limiter.snapshot().NonStreaming)
+		p.close()
+		require.Empty(t, limiter.snapshot().NonStreaming)
+	})
+}
+
+func TestChatCompletion_ProcessResponseBody_partialUsage(t *testing.T) {
+	config := &processorConfig{
+		metadataNamespace: "ai_gateway_llm_ns",
+		requestCosts:      []processorConfigRequestCost{{LLMRequestCost: &filterapi.LLMRequestCost{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total"}}},
+	}
+	newProcessor := func(stream bool, usage translator.LLMTokenUsage) *chatCompletionProcessor {
+		inBody := &extprocv3.HttpBody{Body: []byte("some-chunk")}
+		return &chatCompletionProcessor{
+			config: config, logger: slog.Default(), stream: stream, responseHeaders: map[string]string{":status": "200"},
+			translator: mockTranslator{t: t, expResponseBody: inBody, retUsedToken: usage},
+		}
+	}
+	t.Run("usage in the middle of the stream", func(t *testing.T) {
+		p := newProcessor(true, translator.LLMTokenUsage{TotalTokens: 42})
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("some-chunk")})
+		require.NoError(t, err)
+		require.Equal(t, float64(42), resp.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue().Fields["total"].GetNumberValue())
+		// The stream is closed before the end of the response.
+		p.close()
+	})
+	t.Run("no usage in the chunk", func(t *testing.T) {
+		p := newProcessor(true, translator.LLMTokenUsage{})
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("some-chunk")})
+		require.NoError(t, err)
+		require.Nil(t, resp.DynamicMetadata)
+	})
+	t.Run("non-streaming", func(t *testing.T) {
+		p := newProcessor(false, translator.LLMTokenUsage{TotalTokens: 42})
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{Body: []byte("some-chunk")})
+		require.NoError(t, err)
+		require.Nil(t, resp.DynamicMetadata)
+	})
 }
 
 func TestChatCompletion_ParseBody(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
-		original := openai.ChatCompletionRequest{Model: "llama3.3"}
+		original := openai.ChatCompletionRequest{Model: "llama3.3", Messages: []openai.ChatCompletionMessageParamUnion{}}
 		bytes, err := json.Marshal(original)
 		require.NoError(t, err)
 
@@ -256,3 +987,222 @@ func TestChatCompletion_ParseBody(t *testing.T) {
 		require.Nil(t, rb)
 	})
 }
+
+func TestChatCompletion_pathPrefix(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		PathPrefix:               "/llm",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude"}},
+			},
+		},
+	}))
+	for _, tc := range []struct {
+		path, model, expPath string
+	}{
+		{path: "/llm/v1/chat/completions", model: "gpt-4o", expPath: "/v1/chat/completions"},
+		{path: "/v1/chat/completions", model: "gpt-4o", expPath: "/v1/chat/completions"},
+		{path: "/llm/v1/chat/completions", model: "claude", expPath: "/model/claude/converse"},
+	} {
+		t.Run(tc.path+" "+tc.model, func(t *testing.T) {
+			p, err := s.processorForPath(s.config.Load(), map[string]string{":path": tc.path, ":method": "POST"}, s.logger)
+			require.NoError(t, err)
+			resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+				Body: []byte(`{"model":"` + tc.model + `","messages":[{"role":"user","content":"hi"}]}`),
+			})
+			require.NoError(t, err)
+			headerMutation := resp.GetRequestBody().GetResponse().GetHeaderMutation()
+			require.Equal(t, tc.expPath, headerMutationValue(headerMutation, ":path"))
+		})
+	}
+}
+
+func TestChatCompletion_unsupportedFieldPolicy(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	var rules []filterapi.RouteRule
+	for _, policy := range []filterapi.UnsupportedFieldPolicy{
+		filterapi.UnsupportedFieldPolicyIgnore, filterapi.UnsupportedFieldPolicyWarn, filterapi.UnsupportedFieldPolicyReject,
+	} {
+		rules = append(rules, filterapi.RouteRule{
+			Backends: []filterapi.Backend{{
+				Name:                   "bedrock-" + string(policy),
+				Schema:                 filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+				Weight:                 1,
+				UnsupportedFieldPolicy: policy,
+			}},
+			Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: string(policy)}},
+		})
+	}
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules:                    rules,
+	}))
+	process := func(t *testing.T, model string) (Processor, *extprocv3.ProcessingResponse) {
+		p, err := s.processorForPath(s.config.Load(), map[string]string{":path": "/v1/chat/completions", ":method": "POST"}, s.logger)
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"` + model + `","messages":[{"role":"user","content":"hi"}],"seed":42,"frequency_penalty":0.5}`),
+		})
+		require.NoError(t, err)
+		return p, resp
+	}
+	droppedParams := func(t *testing.T, p Processor) string {
+		resp, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		return headerMutationValue(resp.GetResponseHeaders().GetResponse().GetHeaderMutation(), translator.DroppedParamsHeaderKey)
+	}
+
+	t.Run("ignore", func(t *testing.T) {
+		p, resp := process(t, "Ignore")
+		require.NotNil(t, resp.GetRequestBody())
+		require.Empty(t, droppedParams(t, p))
+	})
+	t.Run("warn", func(t *testing.T) {
+		p, resp := process(t, "Warn")
+		require.NotNil(t, resp.GetRequestBody())
+		require.Equal(t, "seed,frequency_penalty", droppedParams(t, p))
+	})
+	t.Run("reject", func(t *testing.T) {
+		_, resp := process(t, "Reject")
+		ir := resp.GetImmediateResponse()
+		require.Equal(t, typev3.StatusCode_BadRequest, ir.GetStatus().GetCode())
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+		require.Equal(t, "unsupported_parameter", *openAIErr.Error.Code)
+		require.Equal(t, "seed,frequency_penalty", *openAIErr.Error.Param)
+		require.Equal(t, "the fields are not supported by the backend bedrock-Reject: seed, frequency_penalty", openAIErr.Error.Message)
+	})
+}
+
+func TestChatCompletion_audioInput(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "bedrock-model"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o-audio-preview"}},
+			},
+		},
+	}))
+	process := func(t *testing.T, model string) *extprocv3.ProcessingResponse {
+		p, err := s.processorForPath(s.config.Load(), map[string]string{":path": "/v1/chat/completions", ":method": "POST"}, s.logger)
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"` + model + `","messages":[` +
+				`{"role":"user","content":"hi"},` +
+				`{"role":"assistant","content":{"type":"refusal","refusal":"I cannot listen to that."}},` +
+				`{"role":"user","content":[{"type":"text","text":"what is in this recording?"},` +
+				`{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}]}]}`),
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("bedrock", func(t *testing.T) {
+		ir := process(t, "bedrock-model").GetImmediateResponse()
+		require.Equal(t, typev3.StatusCode_BadRequest, ir.GetStatus().GetCode())
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+		require.Equal(t, "invalid_request_error", openAIErr.Error.Type)
+		require.Equal(t, "unsupported_content_type", *openAIErr.Error.Code)
+		require.Equal(t, "messages[2].content[1]", *openAIErr.Error.Param)
+		require.Equal(t, "messages[2].content[1]: audio input (input_audio) is not supported by the selected backend",
+			openAIErr.Error.Message)
+	})
+	t.Run("openai", func(t *testing.T) {
+		resp := process(t, "gpt-4o-audio-preview")
+		require.Nil(t, resp.GetImmediateResponse())
+		// The request body is passed through untouched.
+		require.Nil(t, resp.GetRequestBody().GetResponse().GetBodyMutation())
+	})
+}
+
+func TestChatCompletion_modelPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		policy     *filterapi.ModelPolicy
+		allowed    []string
+		disallowed []string
+	}{
+		{
+			name:       "allow only",
+			policy:     &filterapi.ModelPolicy{Allow: []string{"gpt-4o*", "llama3"}},
+			allowed:    []string{"gpt-4o", "gpt-4o-mini", "llama3"},
+			disallowed: []string{"gpt-3.5-turbo", "llama3.1", "o1"},
+		},
+		{
+			name:       "deny only",
+			policy:     &filterapi.ModelPolicy{Deny: []string{"gpt-4o*", "llama3"}},
+			allowed:    []string{"gpt-3.5-turbo", "llama3.1", "o1"},
+			disallowed: []string{"gpt-4o", "gpt-4o-mini", "llama3"},
+		},
+		{
+			name:       "combined",
+			policy:     &filterapi.ModelPolicy{Allow: []string{"gpt-*"}, Deny: []string{"gpt-4o-mini"}},
+			allowed:    []string{"gpt-4o", "gpt-3.5-turbo"},
+			disallowed: []string{"gpt-4o-mini", "llama3"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewServer(slog.Default())
+			require.NoError(t, err)
+			require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+				Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+				ModelNameHeaderKey:       "x-model-name",
+				SelectedBackendHeaderKey: "x-selected-backend",
+				ModelPolicy:              tc.policy,
+				Rules: []filterapi.RouteRule{{
+					Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+					Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "unused"}},
+				}},
+			}))
+			for _, stream := range []bool{false, true} {
+				process := func(model string) *extprocv3.ImmediateResponse {
+					p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+					require.NoError(t, err)
+					body := fmt.Sprintf(`{"model":%q,"messages":[],"stream":%t}`, model, stream)
+					resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+					require.NoError(t, err)
+					return resp.GetImmediateResponse()
+				}
+				for _, model := range tc.allowed {
+					// The allowed models proceed to the backend selection, which finds no matching rule here.
+					ir := process(model)
+					require.NotNil(t, ir, model)
+					require.Equal(t, typev3.StatusCode_NotFound, ir.GetStatus().GetCode(), model)
+				}
+				for _, model := range tc.disallowed {
+					ir := process(model)
+					require.NotNil(t, ir, model)
+					require.Equal(t, typev3.StatusCode_Forbidden, ir.GetStatus().GetCode(), model)
+					require.JSONEq(t, fmt.Sprintf(`{"type":"error","error":{"type":"invalid_request_error","code":"model_not_found",
+"param":"model","message":"The model `+"`%s`"+` does not exist or you do not have access to it."}}`, model), string(ir.GetBody()))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// The OpenAI error codes of the request validation errors.
+const (
+	validationCodeInvalidJSON      = "invalid_json"
+	validationCodeMissingParameter = "missing_required_parameter"
+	validationCodeInvalidType      = "invalid_type"
+	validationCodeInvalidValue     = "invalid_value"
+)
+
+// requestValidationError is returned by parseOpenAIChatCompletionBody when the request is malformed.
+// The request is rejected with 400 Bad Request instead of failing later in the translation.
+type requestValidationError struct {
+	// code is the OpenAI error code such as "missing_required_parameter".
+	code string
+	// pointer is the JSON pointer (RFC 6901) of the offending field, for example, "/messages/0/role".
+	// Empty when the whole body is invalid.
+	pointer string
+	// message is the human-readable description of the error.
+	message string
+}
+
+// Error implements [error].
+func (e *requestValidationError) Error() string { return e.message }
+
+// toolFunctionNamePattern is the pattern of the function names accepted by OpenAI.
+var toolFunctionNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// validateChatCompletionRequest validates the shape of the chat completion request so that the translators can
+// assume the well-formed input. Only the constraints that the request types cannot express are checked, and the
+// unknown fields are ignored.
+func validateChatCompletionRequest(body []byte) *requestValidationError {
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return &requestValidationError{code: validationCodeInvalidJSON, message: fmt.Sprintf("the request body is not a valid JSON: %v", err)}
+	}
+	req, ok := raw.(map[string]any)
+	if !ok {
+		return &requestValidationError{code: validationCodeInvalidType, message: "the request body must be a JSON object"}
+	}
+	v := &requestValidator{}
+	if model, ok := v.requiredString(req, "", "model"); ok && model == "" {
+		v.fail(validationCodeInvalidValue, "/model", "'model' must not be empty")
+	}
+	if messages, ok := v.requiredArray(req, "", "messages"); ok {
+		for i, m := range messages {
+			v.message(fmt.Sprintf("/messages/%d", i), m)
+		}
+	}
+	tools := v.tools(req)
+	v.toolChoice(req, tools)
+	v.exclusive(req, "tools", "functions")
+	v.exclusive(req, "tool_choice", "function_call")
+	if _, ok := req["parallel_tool_calls"]; ok && len(tools) == 0 {
+		v.fail(validationCodeInvalidValue, "/parallel_tool_calls", "'parallel_tool_calls' is only allowed when 'tools' are specified")
+	}
+	if so, ok := req["stream_options"]; ok && so != nil && req["stream"] != true {
+		v.fail(validationCodeInvalidValue, "/stream_options", "'stream_options' is only allowed when 'stream' is true")
+	}
+	if tl, ok := req["top_logprobs"]; ok && tl != nil && req["logprobs"] != true {
+		v.fail(validationCodeInvalidValue, "/top_logprobs", "'top_logprobs' is only allowed when 'logprobs' is true")
+	}
+	v.metadata(req)
+	v.stop(req)
+	return v.err
+}
+
+// requestValidator accumulates the first error found while validating the request.
+type requestValidator struct {
+	err *requestValidationError
+}
+
+// fail records the error unless another one has already been found.
+func (v *requestValidator) fail(code, pointer, message string) {
+	if v.err == nil {
+		v.err = &requestValidationError{code: code, pointer: pointer, message: message}
+	}
+}
+
+// field returns the value of the required field, recording the error if it is missing or null.
+func (v *requestValidator) field(obj map[string]any, parent, key string) (any, bool) {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		v.fail(validationCodeMissingParameter, parent+"/"+key, fmt.Sprintf("missing required parameter: '%s'", key))
+		return nil, false
+	}
+	return value, true
+}
+
+// requiredString returns the value of the required string field.
+func (v *requestValidator) requiredString(obj map[string]any, parent, key string) (string, bool) {
+	value, ok := v.field(obj, parent, key)
+	if !ok {
+		return "", false
+	}
+	s, ok := value.(string)
+	if !ok {
+		v.fail(validationCodeInvalidType, parent+"/"+key, fmt.Sprintf("'%s' must be a string", key))
+	}
+	return s, ok
+}
+
+// requiredArray returns the value of the required array field.
+func (v *requestValidator) requiredArray(obj map[string]any, parent, key string) ([]any, bool) {
+	value, ok := v.field(obj, parent, key)
+	if !ok {
+		return nil, false
+	}
+	a, ok := value.([]any)
+	if !ok {
+		v.fail(validationCodeInvalidType, parent+"/"+key, fmt.Sprintf("'%s' must be an array", key))
+	}
+	return a, ok
+}
+
+// requiredObject returns the value of the required object field.
+func (v *requestValidator) requiredObject(obj map[string]any, parent, key string) (map[string]any, bool) {
+	value, ok := v.field(obj, parent, key)
+	if !ok {
+		return nil, false
+	}
+	o, ok := value.(map[string]any)
+	if !ok {
+		v.fail(validationCodeInvalidType, parent+"/"+key, fmt.Sprintf("'%s' must be an object", key))
+	}
+	return o, ok
+}
+
+// object returns the value as an object, recording the error if it is not.
+func (v *requestValidator) object(pointer string, value any) (map[string]any, bool) {
+	o, ok := value.(map[string]any)
+	if !ok {
+		v.fail(validationCodeInvalidType, pointer, "must be an object")
+	}
+	return o, ok
+}
+
+// exclusive records the error when both of the mutually exclusive fields are set.
+func (v *requestValidator) exclusive(obj map[string]any, a, b string) {
+	if obj[a] != nil && obj[b] != nil {
+		v.fail(validationCodeInvalidValue, "/"+b, fmt.Sprintf("'%s' and '%s' are mutually exclusive", a, b))
+	}
+}
+
+// message validates the message at the pointer according to its role.
+func (v *requestValidator) message(pointer string, value any) {
+	msg, ok := v.object(pointer, value)
+	if !ok {
+		return
+	}
+	role, ok := v.requiredString(msg, pointer, "role")
+	if !ok {
+		return
+	}
+	switch role {
+	case openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper:
+		if content, ok := v.field(msg, pointer, "content"); ok {
+			v.textContent(pointer+"/content", content)
+		}
+	case openai.ChatMessageRoleUser:
+		if content, ok := v.field(msg, pointer, "content"); ok {
+			v.userContent(pointer+"/content", content)
+		}
+	case openai.ChatMessageRoleAssistant:
+		v.assistantMessage(pointer, msg)
+	case openai.ChatMessageRoleTool:
+		if content, ok := v.field(msg, pointer, "content"); ok {
+			v.textContent(pointer+"/content", content)
+		}
+		if id, ok := v.requiredString(msg, pointer, "tool_call_id"); ok && id == "" {
+			v.fail(validationCodeInvalidValue, pointer+"/tool_call_id", "'tool_call_id' must not be empty")
+		}
+	default:
+		v.fail(validationCodeInvalidValue, pointer+"/role", fmt.Sprintf("invalid role '%s': must be one of %s", role, strings.Join([]string{
+			openai.ChatMessageRoleSystem, openai.ChatMessageRoleDeveloper, openai.ChatMessageRoleUser,
+			openai.ChatMessageRoleAssistant, openai.ChatMessageRoleTool,
+		}, ", ")))
+	}
+}
+
+// textContent validates the content that is either a string or an array of the text content parts.
+func (v *requestValidator) textContent(pointer string, content any) {
+	if _, ok := content.(string); ok {
+		return
+	}
+	parts, ok := content.([]any)
+	if !ok {
+		v.fail(validationCodeInvalidType, pointer, "'content' must be a string or an array of content parts")
+		return
+	}
+	for i, p := range parts {
+		partPointer := fmt.Sprintf("%s/%d", pointer, i)
+		part, ok := v.object(partPointer, p)
+		if !ok {
+			return
+		}
+		typ, ok := v.requiredString(part, partPointer, "type")
+		if !ok {
+			return
+		}
+		if typ != string(openai.ChatCompletionContentPartTextTypeText) {
+			v.fail(validationCodeInvalidValue, partPointer+"/type", fmt.Sprintf("invalid content part type '%s': must be 'text'", typ))
+			return
+		}
+		v.requiredString(part, partPointer, "text")
+	}
+}
+
+// userContent validates the content of the user message that is either a string or an array of the text,
+// image, or audio content parts.
+func (v *requestValidator) userContent(pointer string, content any) {
+	if _, ok := content.(string); ok {
+		return
+	}
+	parts, ok := content.([]any)
+	if !ok {
+		v.fail(validationCodeInvalidType, pointer, "'content' must be a string or an array of content parts")
+		return
+	}
+	for i, p := range parts {
+		partPointer := fmt.Sprintf("%s/%d", pointer, i)
+		part, ok := v.object(partPointer, p)
+		if !ok {
+			return
+		}
+		typ, ok := v.requiredString(part, partPointer, "type")
+		if !ok {
+			return
+		}
+		switch typ {
+		case string(openai.ChatCompletionContentPartTextTypeText):
+			v.requiredString(part, partPointer, "text")
+		case string(openai.ChatCompletionContentPartImageTypeImageURL):
+			if image, ok := v.requiredObject(part, partPointer, "image_url"); ok {
+				v.requiredString(image, partPointer+"/image_url", "url")
+			}
+		case string(openai.ChatCompletionContentPartInputAudioTypeInputAudio):
+			if audio, ok := v.requiredObject(part, partPointer, "input_audio"); ok {
+				v.requiredString(audio, partPointer+"/input_audio", "data")
+				v.requiredString(audio, partPointer+"/input_audio", "format")
+			}
+		default:
+			v.fail(validationCodeInvalidValue, partPointer+"/type",
+				fmt.Sprintf("invalid content part type '%s': must be one of text, image_url, input_audio", typ))
+		}
+	}
+}
+
+// assistantMessage validates the assistant message, whose content is required unless it has the tool calls.
+func (v *requestValidator) assistantMessage(pointer string, msg map[string]any) {
+	var toolCalls []any
+	if tc, ok := msg["tool_calls"]; ok && tc != nil {
+		if toolCalls, ok = tc.([]any); !ok {
+			v.fail(validationCodeInvalidType, pointer+"/tool_calls", "'tool_calls' must be an array")
+			return
+		}
+	}
+	for i, tc := range toolCalls {
+		tcPointer := fmt.Sprintf("%s/tool_calls/%d", pointer, i)
+		call, ok := v.object(tcPointer, tc)
+		if !ok {
+			return
+		}
+		v.requiredString(call, tcPointer, "id")
+		if typ, ok := v.requiredString(call, tcPointer, "type"); ok && typ != string(openai.ChatCompletionMessageToolCallTypeFunction) {
+			v.fail(validationCodeInvalidValue, tcPointer+"/type", fmt.Sprintf("invalid tool call type '%s': must be 'function'", typ))
+		}
+		if fn, ok := v.requiredObject(call, tcPointer, "function"); ok {
+			v.requiredString(fn, tcPointer+"/function", "name")
+			v.requiredString(fn, tcPointer+"/function", "arguments")
+		}
+	}
+
+	content, ok := msg["content"]
+	if !ok || content == nil {
+		if len(toolCalls) == 0 && msg["function_call"] == nil {
+			v.fail(validationCodeMissingParameter, pointer+"/content", "missing required parameter: 'content' unless 'tool_calls' is specified")
+		}
+		return
+	}
+	// The assistant content is a single content part in the request type.
+	part, ok := v.object(pointer+"/content", content)
+	if !ok {
+		return
+	}
+	if typ, ok := v.requiredString(part, pointer+"/content", "type"); ok {
+		switch openai.ChatCompletionAssistantMessageParamContentType(typ) {
+		case openai.ChatCompletionAssistantMessageParamContentTypeText:
+			v.requiredString(part, pointer+"/content", "text")
+		case openai.ChatCompletionAssistantMessageParamContentTypeRefusal:
+			v.requiredString(part, pointer+"/content", "refusal")
+		default:
+			v.fail(validationCodeInvalidValue, pointer+"/content/type", fmt.Sprintf("invalid content type '%s': must be one of text, refusal", typ))
+		}
+	}
+}
+
+// tools validates the tool definitions and returns the names of the functions.
+func (v *requestValidator) tools(req map[string]any) []string {
+	value, ok := req["tools"]
+	if !ok || value == nil {
+		return nil
+	}
+	tools, ok := value.([]any)
+	if !ok {
+		v.fail(validationCodeInvalidType, "/tools", "'tools' must be an array")
+		return nil
+	}
+	names := make([]string, 0, len(tools))
+	for i, t := range tools {
+		pointer := fmt.Sprintf("/tools/%d", i)
+		tool, ok := v.object(pointer, t)
+		if !ok {
+			continue
+		}
+		if typ, ok := v.requiredString(tool, pointer, "type"); ok && typ != string(openai.ToolTypeFunction) {
+			v.fail(validationCodeInvalidValue, pointer+"/type", fmt.Sprintf("invalid tool type '%s': must be 'function'", typ))
+		}
+		fn, ok := v.requiredObject(tool, pointer, "function")
+		if !ok {
+			continue
+		}
+		if name, ok := v.requiredString(fn, pointer+"/function", "name"); ok {
+			if !toolFunctionNamePattern.MatchString(name) {
+				v.fail(validationCodeInvalidValue, pointer+"/function/name",
+					fmt.Sprintf("invalid function name '%s': must be 1 to 64 characters of a-z, A-Z, 0-9, underscores and dashes", name))
+			}
+			names = append(names, name)
+		}
+		if params, ok := fn["parameters"]; ok && params != nil {
+			if _, ok := params.(map[string]any); !ok {
+				v.fail(validationCodeInvalidType, pointer+"/function/parameters", "'parameters' must be an object")
+			}
+		}
+	}
+	return names
+}
+
+// toolChoice validates the tool choice against the names of the tool functions.
+func (v *requestValidator) toolChoice(req map[string]any, toolNames []string) {
+	value, ok := req["tool_choice"]
+	if !ok || value == nil {
+		return
+	}
+	switch choice := value.(type) {
+	case string:
+		switch choice {
+		case "none", "auto":
+		case "required":
+			if len(toolNames) == 0 {
+				v.fail(validationCodeInvalidValue, "/tool_choice", "'tool_choice' is only allowed when 'tools' are specified")
+			}
+		default:
+			v.fail(validationCodeInvalidValue, "/tool_choice", fmt.Sprintf("invalid tool choice '%s': must be one of none, auto, required", choice))
+		}
+	case map[string]any:
+		if typ, ok := v.requiredString(choice, "/tool_choice", "type"); ok && typ != string(openai.ToolTypeFunction) {
+			v.fail(validationCodeInvalidValue, "/tool_choice/type", fmt.Sprintf("invalid tool choice type '%s': must be 'function'", typ))
+		}
+		fn, ok := v.requiredObject(choice, "/tool_choice", "function")
+		if !ok {
+			return
+		}
+		if name, ok := v.requiredString(fn, "/tool_choice/function", "name"); ok && !slices.Contains(toolNames, name) {
+			v.fail(validationCodeInvalidValue, "/tool_choice/function/name", fmt.Sprintf("the function '%s' is not found in 'tools'", name))
+		}
+	default:
+		v.fail(validationCodeInvalidType, "/tool_choice", "'tool_choice' must be a string or an object")
+	}
+}
+
+// metadata validates the size of the metadata. The types of the values are checked when unmarshalling the request.
+func (v *requestValidator) metadata(req map[string]any) {
+	metadata, ok := req["metadata"].(map[string]any)
+	if !ok {
+		return
+	}
+	if len(metadata) > openai.ChatCompletionMetadataMaxKeys {
+		v.fail(validationCodeInvalidValue, "/metadata",
+			fmt.Sprintf("'metadata' must not have more than %d keys, got %d", openai.ChatCompletionMetadataMaxKeys, len(metadata)))
+		return
+	}
+	keys := slices.Sorted(maps.Keys(metadata))
+	for _, key := range keys {
+		if s, ok := metadata[key].(string); ok && utf8.RuneCountInString(s) > openai.ChatCompletionMetadataMaxValueLength {
+			v.fail(validationCodeInvalidValue, "/metadata/"+key,
+				fmt.Sprintf("the value of 'metadata.%s' must not be longer than %d characters", key, openai.ChatCompletionMetadataMaxValueLength))
+			return
+		}
+	}
+}
+
+// stop validates the stop sequences, which can be either a string or an array of strings.
+func (v *requestValidator) stop(req map[string]any) {
+	switch stop := req["stop"].(type) {
+	case nil, string:
+	case []any:
+		if len(stop) > openai.ChatCompletionStopMaxSequences {
+			v.fail(validationCodeInvalidValue, "/stop",
+				fmt.Sprintf("'stop' must not have more than %d sequences, got %d", openai.ChatCompletionStopMaxSequences, len(stop)))
+			return
+		}
+		for i, s := range stop {
+			if _, ok := s.(string); !ok {
+				v.fail(validationCodeInvalidType, fmt.Sprintf("/stop/%d", i), fmt.Sprintf("'stop[%d]' must be a string", i))
+				return
+			}
+		}
+	default:
+		v.fail(validationCodeInvalidType, "/stop", "'stop' must be a string or an array of strings")
+	}
+}
+
+// unmarshalErrorToValidationError converts the error of unmarshalling the validated request into the request types
+// into the validation error, as the request types are stricter than the validation for some fields.
+func unmarshalErrorToValidationError(err error) *requestValidationError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &requestValidationError{
+			code:    validationCodeInvalidType,
+			pointer: "/" + strings.ReplaceAll(typeErr.Field, ".", "/"),
+			message: fmt.Sprintf("invalid type %s for '%s'", typeErr.Value, typeErr.Field),
+		}
+	}
+	return &requestValidationError{code: validationCodeInvalidValue, message: fmt.Sprintf("invalid request: %v", err)}
+}
@@ -0,0 +1,291 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOpenAIChatCompletionBody_validation(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		body       string
+		expCode    string
+		expPointer string
+		expMessage string
+	}{
+		{
+			name:       "not json",
+			body:       `{"model":`,
+			expCode:    "invalid_json",
+			expMessage: "the request body is not a valid JSON",
+		},
+		{
+			name:       "not object",
+			body:       `[]`,
+			expCode:    "invalid_type",
+			expMessage: "the request body must be a JSON object",
+		},
+		{
+			name:       "missing model",
+			body:       `{"messages":[{"role":"user","content":"hi"}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/model",
+			expMessage: "missing required parameter: 'model'",
+		},
+		{
+			name:       "empty model",
+			body:       `{"model":"","messages":[]}`,
+			expCode:    "invalid_value",
+			expPointer: "/model",
+			expMessage: "'model' must not be empty",
+		},
+		{
+			name:       "model not string",
+			body:       `{"model":1,"messages":[]}`,
+			expCode:    "invalid_type",
+			expPointer: "/model",
+			expMessage: "'model' must be a string",
+		},
+		{
+			name:       "missing messages",
+			body:       `{"model":"gpt"}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages",
+			expMessage: "missing required parameter: 'messages'",
+		},
+		{
+			name:       "messages not array",
+			body:       `{"model":"gpt","messages":{"role":"user","content":"hi"}}`,
+			expCode:    "invalid_type",
+			expPointer: "/messages",
+			expMessage: "'messages' must be an array",
+		},
+		{
+			name:       "message not object",
+			body:       `{"model":"gpt","messages":["hi"]}`,
+			expCode:    "invalid_type",
+			expPointer: "/messages/0",
+			expMessage: "must be an object",
+		},
+		{
+			name:       "missing role",
+			body:       `{"model":"gpt","messages":[{"role":"user","content":"hi"},{"content":"hi"}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages/1/role",
+			expMessage: "missing required parameter: 'role'",
+		},
+		{
+			name:       "unknown role",
+			body:       `{"model":"gpt","messages":[{"role":"bot","content":"hi"}]}`,
+			expCode:    "invalid_value",
+			expPointer: "/messages/0/role",
+			expMessage: "invalid role 'bot': must be one of system, developer, user, assistant, tool",
+		},
+		{
+			name:       "missing user content",
+			body:       `{"model":"gpt","messages":[{"role":"user"}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages/0/content",
+			expMessage: "missing required parameter: 'content'",
+		},
+		{
+			name:       "user content not string or array",
+			body:       `{"model":"gpt","messages":[{"role":"user","content":{"text":"hi"}}]}`,
+			expCode:    "invalid_type",
+			expPointer: "/messages/0/content",
+			expMessage: "'content' must be a string or an array of content parts",
+		},
+		{
+			name:       "unknown user content part type",
+			body:       `{"model":"gpt","messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"video"}]}]}`,
+			expCode:    "invalid_value",
+			expPointer: "/messages/0/content/1/type",
+			expMessage: "invalid content part type 'video': must be one of text, image_url, input_audio",
+		},
+		{
+			name:       "image part without url",
+			body:       `{"model":"gpt","messages":[{"role":"user","content":[{"type":"image_url","image_url":{}}]}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages/0/content/0/image_url/url",
+			expMessage: "missing required parameter: 'url'",
+		},
+		{
+			name:       "system content part not text",
+			body:       `{"model":"gpt","messages":[{"role":"system","content":[{"type":"image_url","image_url":{"url":"x"}}]}]}`,
+			expCode:    "invalid_value",
+			expPointer: "/messages/0/content/0/type",
+			expMessage: "invalid content part type 'image_url': must be 'text'",
+		},
+		{
+			name:       "assistant without content nor tool calls",
+			body:       `{"model":"gpt","messages":[{"role":"assistant"}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages/0/content",
+			expMessage: "missing required parameter: 'content' unless 'tool_calls' is specified",
+		},
+		{
+			name:       "assistant tool call without function name",
+			body:       `{"model":"gpt","messages":[{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"arguments":"{}"}}]}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages/0/tool_calls/0/function/name",
+			expMessage: "missing required parameter: 'name'",
+		},
+		{
+			name:       "tool message without tool_call_id",
+			body:       `{"model":"gpt","messages":[{"role":"tool","content":"42"}]}`,
+			expCode:    "missing_required_parameter",
+			expPointer: "/messages/0/tool_call_id",
+			expMessage: "missing required parameter: 'tool_call_id'",
+		},
+		{
+			name:       "tool with invalid function name",
+			body:       `{"model":"gpt","messages":[],"tools":[{"type":"function","function":{"name":"get weather"}}]}`,
+			expCode:    "invalid_value",
+			expPointer: "/tools/0/function/name",
+			expMessage: "invalid function name 'get weather'",
+		},
+		{
+			name:       "tool with non-object parameters",
+			body:       `{"model":"gpt","messages":[],"tools":[{"type":"function","function":{"name":"f","parameters":"{}"}}]}`,
+			expCode:    "invalid_type",
+			expPointer: "/tools/0/function/parameters",
+			expMessage: "'parameters' must be an object",
+		},
+		{
+			name:       "unknown tool type",
+			body:       `{"model":"gpt","messages":[],"tools":[{"type":"retrieval","function":{"name":"f"}}]}`,
+			expCode:    "invalid_value",
+			expPointer: "/tools/0/type",
+			expMessage: "invalid tool type 'retrieval': must be 'function'",
+		},
+		{
+			name:       "tool choice required without tools",
+			body:       `{"model":"gpt","messages":[],"tool_choice":"required"}`,
+			expCode:    "invalid_value",
+			expPointer: "/tool_choice",
+			expMessage: "'tool_choice' is only allowed when 'tools' are specified",
+		},
+		{
+			name:       "tool choice of unknown function",
+			body:       `{"model":"gpt","messages":[],"tools":[{"type":"function","function":{"name":"f"}}],"tool_choice":{"type":"function","function":{"name":"g"}}}`,
+			expCode:    "invalid_value",
+			expPointer: "/tool_choice/function/name",
+			expMessage: "the function 'g' is not found in 'tools'",
+		},
+		{
+			name:       "tools and functions",
+			body:       `{"model":"gpt","messages":[],"tools":[{"type":"function","function":{"name":"f"}}],"functions":[{"name":"f"}]}`,
+			expCode:    "invalid_value",
+			expPointer: "/functions",
+			expMessage: "'tools' and 'functions' are mutually exclusive",
+		},
+		{
+			name:       "parallel tool calls without tools",
+			body:       `{"model":"gpt","messages":[],"parallel_tool_calls":false}`,
+			expCode:    "invalid_value",
+			expPointer: "/parallel_tool_calls",
+			expMessage: "'parallel_tool_calls' is only allowed when 'tools' are specified",
+		},
+		{
+			name:       "stream options without stream",
+			body:       `{"model":"gpt","messages":[],"stream_options":{"include_usage":true}}`,
+			expCode:    "invalid_value",
+			expPointer: "/stream_options",
+			expMessage: "'stream_options' is only allowed when 'stream' is true",
+		},
+		{
+			name:       "top logprobs without logprobs",
+			body:       `{"model":"gpt","messages":[],"top_logprobs":3}`,
+			expCode:    "invalid_value",
+			expPointer: "/top_logprobs",
+			expMessage: "'top_logprobs' is only allowed when 'logprobs' is true",
+		},
+		{
+			name:       "too many metadata keys",
+			body:       `{"model":"gpt","messages":[],"metadata":{"k0":"v","k1":"v","k2":"v","k3":"v","k4":"v","k5":"v","k6":"v","k7":"v","k8":"v","k9":"v","k10":"v","k11":"v","k12":"v","k13":"v","k14":"v","k15":"v","k16":"v"}}`,
+			expCode:    "invalid_value",
+			expPointer: "/metadata",
+			expMessage: "'metadata' must not have more than 16 keys, got 17",
+		},
+		{
+			name:       "too long metadata value",
+			body:       `{"model":"gpt","messages":[],"metadata":{"a":"b","c":"` + strings.Repeat("x", 257) + `"}}`,
+			expCode:    "invalid_value",
+			expPointer: "/metadata/c",
+			expMessage: "the value of 'metadata.c' must not be longer than 256 characters",
+		},
+		{
+			name:       "too many stop sequences",
+			body:       `{"model":"gpt","messages":[],"stop":["a","b","c","d","e"]}`,
+			expCode:    "invalid_value",
+			expPointer: "/stop",
+			expMessage: "'stop' must not have more than 4 sequences, got 5",
+		},
+		{
+			name:       "non-string stop sequence",
+			body:       `{"model":"gpt","messages":[],"stop":["a",1]}`,
+			expCode:    "invalid_type",
+			expPointer: "/stop/1",
+			expMessage: "'stop[1]' must be a string",
+		},
+		{
+			name:       "invalid stop type",
+			body:       `{"model":"gpt","messages":[],"stop":{"a":"b"}}`,
+			expCode:    "invalid_type",
+			expPointer: "/stop",
+			expMessage: "'stop' must be a string or an array of strings",
+		},
+		{
+			name:       "wrong type of the typed field",
+			body:       `{"model":"gpt","messages":[],"temperature":"hot"}`,
+			expCode:    "invalid_type",
+			expPointer: "/temperature",
+			expMessage: "invalid type string for 'temperature'",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := parseOpenAIChatCompletionBody(&extprocv3.HttpBody{Body: []byte(tc.body)})
+			var validationErr *requestValidationError
+			require.True(t, errors.As(err, &validationErr), "unexpected error: %v", err)
+			require.Equal(t, tc.expCode, validationErr.code)
+			require.Equal(t, tc.expPointer, validationErr.pointer)
+			require.Contains(t, validationErr.message, tc.expMessage)
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"model":"gpt","messages":[]}`,
+			`{"model":"gpt","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}],"unknown":1}`,
+			`{"model":"gpt","messages":[{"role":"developer","content":[{"type":"text","text":"be nice"}]},` +
+				`{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA=="}},` +
+				`{"type":"input_audio","input_audio":{"data":"AA==","format":"wav"}}]}]}`,
+			`{"model":"gpt","messages":[{"role":"user","content":"weather?"},` +
+				`{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{}"}}]},` +
+				`{"role":"tool","tool_call_id":"call_1","content":"sunny"},{"role":"assistant","content":{"type":"text","text":"It's sunny."}}],` +
+				`"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object"}}}],` +
+				`"tool_choice":{"type":"function","function":{"name":"get_weather"}},"parallel_tool_calls":true}`,
+			`{"model":"gpt","messages":[],"stream":true,"stream_options":{"include_usage":true},"logprobs":true,"top_logprobs":3}`,
+			`{"model":"gpt","messages":[],"user":"alice","metadata":{"team":"search"}}`,
+			// The length of the metadata values is counted in characters rather than bytes.
+			`{"model":"gpt","messages":[],"metadata":{"team":"` + strings.Repeat("検", 256) + `"}}`,
+			`{"model":"gpt","messages":[],"stop":"END"}`,
+			`{"model":"gpt","messages":[],"stop":["a","b","c","d"]}`,
+			`{"model":"gpt","messages":[],"stop":null}`,
+		} {
+			model, rb, err := parseOpenAIChatCompletionBody(&extprocv3.HttpBody{Body: []byte(body)})
+			require.NoError(t, err, body)
+			require.Equal(t, "gpt", model)
+			require.NotNil(t, rb)
+		}
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// defaultMaxChoices is the default maximum value of the `n` parameter fanned out to the backends
+// that do not support multiple choices natively.
+const defaultMaxChoices = 4
+
+// choicesFanOutTimeout bounds the fanned out requests including reading their responses. They are sent directly
+// by the external processor, so neither the route timeout nor the retries of Envoy apply to them.
+const choicesFanOutTimeout = 2 * time.Minute
+
+// choicesFanOut holds the additional upstream requests issued for the `n` parameter of the request
+// routed to a backend that does not support multiple choices natively, such as AWS Bedrock.
+//
+// The original request is still sent by Envoy and produces the first choice. The remaining n-1 requests
+// are sent directly by the external processor, and their responses are merged into the original one. The choices
+// of the fanned out requests that fail are left out of the response instead of failing the whole request.
+type choicesFanOut struct {
+	backend *filterapi.Backend
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	results []choiceResult
+}
+
+// choiceResult is the result of a single fanned out request.
+type choiceResult struct {
+	status int
+	body   []byte
+	err    error
+}
+
+// bedrockEndpoint returns the URL of the AWS Bedrock runtime endpoint for the given backend and path.
+func bedrockEndpoint(b *filterapi.Backend, path string) (string, bool) {
+	if b.Auth == nil || b.Auth.AWSAuth == nil || b.Auth.AWSAuth.Region == "" {
+		return "", false
+	}
+	return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com%s", b.Auth.AWSAuth.Region, path), true
+}
+
+// startChoicesFanOut sends n additional requests to the url concurrently. The requests carry the headers set by
+// the header mutation of the original request, so they are identical to the one sent by Envoy including the signature.
+func startChoicesFanOut(ctx context.Context, client *http.Client, b *filterapi.Backend, url string,
+	headerMutation *extprocv3.HeaderMutation, body []byte, n int,
+) *choicesFanOut {
+	ctx, cancel := context.WithTimeout(ctx, choicesFanOutTimeout)
+	f := &choicesFanOut{backend: b, cancel: cancel, results: make([]choiceResult, n)}
+	for i := range f.results {
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.results[i] = sendChoiceRequest(ctx, client, url, headerMutation, body)
+		}()
+	}
+	return f
+}
+
+func sendChoiceRequest(ctx context.Context, client *http.Client, url string, headerMutation *extprocv3.HeaderMutation, body []byte) choiceResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return choiceResult{err: fmt.Errorf("cannot create request: %w", err)}
+	}
+	req.Header.Set("content-type", "application/json")
+	for _, h := range headerMutation.GetSetHeaders() {
+		key := h.Header.Key
+		if strings.HasPrefix(key, ":") || strings.EqualFold(key, "content-length") {
+			continue
+		}
+		value := h.Header.Value
+		if len(h.Header.RawValue) > 0 {
+			value = string(h.Header.RawValue)
+		}
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return choiceResult{err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return choiceResult{err: fmt.Errorf("failed to read response body: %w", err)}
+	}
+	return choiceResult{status: resp.StatusCode, body: respBody}
+}
+
+// wait waits for all the fanned out requests to complete and returns their results.
+func (f *choicesFanOut) wait() []choiceResult {
+	f.wg.Wait()
+	return f.results
+}
+
+// close cancels the in-flight fanned out requests, if any.
+func (f *choicesFanOut) close() {
+	f.cancel()
+}
+
+// checkChoicesFanOut checks whether the request with n choices can be fanned out to the backend.
+// This returns the immediate response to reject the request when it cannot, otherwise nil.
+func (c *chatCompletionProcessor) checkChoicesFanOut(b *filterapi.Backend, n int, stream bool) *extprocv3.ProcessingResponse {
+	var message string
+	switch {
+	case stream:
+		message = fmt.Sprintf("n > 1 is not supported for streaming requests to backend %s", b.Name)
+	case n > c.config.maxChoices:
+		message = fmt.Sprintf("n %d exceeds the maximum %d", n, c.config.maxChoices)
+	default:
+		if _, ok := bedrockEndpoint(b, ""); ok {
+			return nil
+		}
+		message = fmt.Sprintf("n > 1 is not supported by backend %s", b.Name)
+	}
+	c.logger.Info("Rejecting request with multiple choices", "backend", b.Name, "n", n, "reason", message)
+	return invalidRequestResponse("unsupported_parameter", "n", message)
+}
+
+// mergeFanOutChoices waits for the fanned out requests and merges their choices and token usage into the
+// translated response of the original request. The choices are indexed in the order of the requests, and the failed
+// requests are logged and skipped so that the client still receives the choices that succeeded.
+func (c *chatCompletionProcessor) mergeFanOutChoices(headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage) (
+	*extprocv3.HeaderMutation, *extprocv3.BodyMutation, translator.LLMTokenUsage, error,
+) {
+	if st, err := strconv.Atoi(c.responseHeaders[":status"]); err != nil || st < 200 || st >= 300 {
+		// The error response of the original request is returned as-is.
+		c.choicesFanOut.close()
+		return headerMutation, bodyMutation, tokenUsage, nil
+	}
+	var resp openai.ChatCompletionResponse
+	if err := json.Unmarshal(bodyMutation.GetBody(), &resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	for i, result := range c.choicesFanOut.wait() {
+		choiceResp, usage, err := c.translateFanOutChoice(result)
+		if err != nil {
+			c.logger.Warn("Leaving out the failed choice of the fanned out request", "backend", c.choicesFanOut.backend.Name,
+				"choice", i+1, "error", err)
+			continue
+		}
+		for _, choice := range choiceResp.Choices {
+			choice.Index = int64(len(resp.Choices))
+			resp.Choices = append(resp.Choices, choice)
+		}
+		resp.Usage.PromptTokens += choiceResp.Usage.PromptTokens
+		resp.Usage.CompletionTokens += choiceResp.Usage.CompletionTokens
+		resp.Usage.TotalTokens += choiceResp.Usage.TotalTokens
+		resp.Usage.CacheReadInputTokens += choiceResp.Usage.CacheReadInputTokens
+		resp.Usage.CacheWriteInputTokens += choiceResp.Usage.CacheWriteInputTokens
+		tokenUsage.InputTokens += usage.InputTokens
+		tokenUsage.OutputTokens += usage.OutputTokens
+		tokenUsage.TotalTokens += usage.TotalTokens
+		tokenUsage.CacheReadInputTokens += usage.CacheReadInputTokens
+		tokenUsage.CacheWriteInputTokens += usage.CacheWriteInputTokens
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	merged := &extprocv3.HeaderMutation{}
+	for _, h := range headerMutation.GetSetHeaders() {
+		if h.Header.Key != "content-length" {
+			merged.SetHeaders = append(merged.SetHeaders, h)
+		}
+	}
+	merged.SetHeaders = append(merged.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "content-length", RawValue: []byte(strconv.Itoa(len(body)))},
+	})
+	return merged, &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: body}}, tokenUsage, nil
+}
+
+// translateFanOutChoice translates the response of the fanned out request.
+func (c *chatCompletionProcessor) translateFanOutChoice(result choiceResult) (*openai.ChatCompletionResponse, translator.LLMTokenUsage, error) {
+	if result.err != nil {
+		return nil, translator.LLMTokenUsage{}, fmt.Errorf("failed to send request: %w", result.err)
+	}
+	if result.status < 200 || result.status >= 300 {
+		return nil, translator.LLMTokenUsage{}, fmt.Errorf("upstream error: status %d", result.status)
+	}
+	t, err := newChatCompletionTranslator(c.config.schema, c.requestHeaders[":path"], c.choicesFanOut.backend, c.config.maxStreamBufferSize)
+	if err != nil {
+		return nil, translator.LLMTokenUsage{}, err
+	}
+	_, mut, usage, err := t.ResponseBody(map[string]string{":status": strconv.Itoa(result.status)}, bytes.NewReader(result.body), true)
+	if err != nil {
+		return nil, translator.LLMTokenUsage{}, fmt.Errorf("failed to transform response: %w", err)
+	}
+	var choiceResp openai.ChatCompletionResponse
+	if err = json.Unmarshal(mut.GetBody(), &choiceResp); err != nil {
+		return nil, translator.LLMTokenUsage{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &choiceResp, usage, nil
+}
+
+// headerMutationValue returns the value of the header set by the header mutation, or empty if not set.
+func headerMutationValue(headerMutation *extprocv3.HeaderMutation, key string) string {
+	for _, h := range headerMutation.GetSetHeaders() {
+		if h.Header.Key == key {
+			if len(h.Header.RawValue) > 0 {
+				return string(h.Header.RawValue)
+			}
+			return h.Header.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// roundTripperFunc implements [http.RoundTripper] with a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements [http.RoundTripper.RoundTrip].
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestChatCompletion_choicesFanOut(t *testing.T) {
+	credentialFile := filepath.Join(t.TempDir(), "aws")
+	require.NoError(t, os.WriteFile(credentialFile, []byte("[default]\nAWS_ACCESS_KEY_ID=test\nAWS_SECRET_ACCESS_KEY=secret\n"), 0o600))
+
+	var (
+		mux          sync.Mutex
+		upstreamReqs []*http.Request
+		upstreamBody []string
+		upstreamResp = func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(
+				`{"output":{"message":{"role":"assistant","content":[{"text":"fanned out"}]}},"stopReason":"end_turn","usage":{"inputTokens":10,"outputTokens":20,"totalTokens":30}}`,
+			))}, nil
+		}
+	)
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.httpClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		mux.Lock()
+		defer mux.Unlock()
+		upstreamReqs = append(upstreamReqs, r)
+		upstreamBody = append(upstreamBody, string(body))
+		return upstreamResp(r)
+	})}
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		MaxChoices:               3,
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{
+					Name:   "bedrock",
+					Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+					Weight: 1,
+					Auth:   &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{CredentialFileName: credentialFile, Region: "us-east-1"}},
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "bedrock-no-auth", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "no-auth"}},
+			},
+		},
+		LLMRequestCosts:   []filterapi.LLMRequestCost{{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total"}},
+		MetadataNamespace: "ai_gateway_llm_ns",
+	}))
+
+	newProcessor := func(t *testing.T) *chatCompletionProcessor {
+		mux.Lock()
+		upstreamReqs, upstreamBody = nil, nil
+		mux.Unlock()
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions", ":method": "POST"}, slog.Default())
+		require.NoError(t, err)
+		t.Cleanup(p.(*chatCompletionProcessor).close)
+		return p.(*chatCompletionProcessor)
+	}
+	requireRejected := func(t *testing.T, resp *extprocv3.ProcessingResponse, message string) {
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, typev3.StatusCode_BadRequest, ir.GetStatus().GetCode())
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+		require.Equal(t, "n", *openAIErr.Error.Param)
+		require.Equal(t, message, openAIErr.Error.Message)
+	}
+
+	t.Run("n=2", func(t *testing.T) {
+		p := newProcessor(t)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"claude","n":2,"messages":[{"role":"user","content":"hi"}]}`)})
+		require.NoError(t, err)
+		reqBodyMut := resp.GetRequestBody().GetResponse()
+		results := p.choicesFanOut.wait()
+		require.Len(t, results, 1)
+
+		require.Len(t, upstreamReqs, 1)
+		upstreamReq := upstreamReqs[0]
+		require.Equal(t, "https://bedrock-runtime.us-east-1.amazonaws.com/model/claude/converse", upstreamReq.URL.String())
+		require.Equal(t, headerMutationValue(reqBodyMut.GetHeaderMutation(), "Authorization"), upstreamReq.Header.Get("Authorization"))
+		require.Equal(t, string(reqBodyMut.GetBodyMutation().GetBody()), upstreamBody[0])
+
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+			Body:        []byte(`{"output":{"message":{"role":"assistant","content":[{"text":"original"}]}},"stopReason":"end_turn","usage":{"inputTokens":10,"outputTokens":5,"totalTokens":15}}`),
+			EndOfStream: true,
+		})
+		require.NoError(t, err)
+		common := resp.GetResponseBody().GetResponse()
+		var openAIResp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(common.GetBodyMutation().GetBody(), &openAIResp))
+		require.Len(t, openAIResp.Choices, 2)
+		require.Equal(t, int64(0), openAIResp.Choices[0].Index)
+		require.Equal(t, "original", *openAIResp.Choices[0].Message.Content)
+		require.Equal(t, int64(1), openAIResp.Choices[1].Index)
+		require.Equal(t, "fanned out", *openAIResp.Choices[1].Message.Content)
+		require.Equal(t, openai.ChatCompletionResponseUsage{PromptTokens: 20, CompletionTokens: 25, TotalTokens: 45}, openAIResp.Usage)
+		require.Equal(t, "content-length", common.GetHeaderMutation().GetSetHeaders()[0].Header.Key)
+		require.Equal(t, strconv.Itoa(len(common.GetBodyMutation().GetBody())), string(common.GetHeaderMutation().GetSetHeaders()[0].Header.RawValue))
+		require.Equal(t, float64(45), resp.DynamicMetadata.Fields["ai_gateway_llm_ns"].GetStructValue().Fields["total"].GetNumberValue())
+	})
+	t.Run("upstream error", func(t *testing.T) {
+		defaultResp := upstreamResp
+		defer func() { upstreamResp = defaultResp }()
+		var calls int
+		upstreamResp = func(r *http.Request) (*http.Response, error) {
+			// The first fanned out request fails, and the second one succeeds.
+			if calls++; calls == 1 {
+				return &http.Response{StatusCode: http.StatusTooManyRequests, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+			}
+			return defaultResp(r)
+		}
+		p := newProcessor(t)
+		_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"claude","n":3,"messages":[]}`)})
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		resp, err := p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+			Body:        []byte(`{"output":{"message":{"role":"assistant","content":[{"text":"original"}]}},"stopReason":"end_turn"}`),
+			EndOfStream: true,
+		})
+		require.NoError(t, err)
+		// The choice of the failed request is left out.
+		var openAIResp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody(), &openAIResp))
+		require.Len(t, openAIResp.Choices, 2)
+		require.Equal(t, "original", *openAIResp.Choices[0].Message.Content)
+		require.Equal(t, int64(1), openAIResp.Choices[1].Index)
+		require.Equal(t, "fanned out", *openAIResp.Choices[1].Message.Content)
+	})
+	t.Run("streaming", func(t *testing.T) {
+		resp, err := newProcessor(t).ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"claude","n":2,"stream":true,"messages":[]}`)})
+		require.NoError(t, err)
+		requireRejected(t, resp, "n > 1 is not supported for streaming requests to backend bedrock")
+		require.Empty(t, upstreamReqs)
+	})
+	t.Run("exceeds max", func(t *testing.T) {
+		resp, err := newProcessor(t).ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"claude","n":4,"messages":[]}`)})
+		require.NoError(t, err)
+		requireRejected(t, resp, "n 4 exceeds the maximum 3")
+	})
+	t.Run("no aws auth", func(t *testing.T) {
+		resp, err := newProcessor(t).ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(`{"model":"no-auth","n":2,"messages":[]}`)})
+		require.NoError(t, err)
+		requireRejected(t, resp, "n > 1 is not supported by backend bedrock-no-auth")
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// concurrencyLimiter tracks the number of in-flight requests per client identity.
+//
+// This is owned by the [Server] and shared across configuration reloads so that the counters
+// of the in-flight streams survive them.
+type concurrencyLimiter struct {
+	mu sync.Mutex
+	// streaming and nonStreaming are the number of in-flight requests keyed by the identity.
+	// Entries are removed when they reach zero so that the maps do not grow unbounded.
+	streaming, nonStreaming map[string]int
+	// stateStoreFallbacks counts the requests limited by the local counters because the state store failed.
+	stateStoreFallbacks atomic.Uint64
+}
+
+// concurrencyLimiterSnapshot is a point-in-time copy of the counters of [concurrencyLimiter].
+type concurrencyLimiterSnapshot struct {
+	Streaming    map[string]int `json:"streaming"`
+	NonStreaming map[string]int `json:"nonStreaming"`
+}
+
+func newConcurrencyLimiter() *concurrencyLimiter {
+	return &concurrencyLimiter{streaming: make(map[string]int), nonStreaming: make(map[string]int)}
+}
+
+// acquire increments the counter of the given identity unless it has already reached the limit.
+// A non-positive limit means unlimited.
+//
+// When ok is true, the returned release function must be called when the request ends. It is safe to call it more than once.
+func (l *concurrencyLimiter) acquire(identity string, streaming bool, limit int) (release func(), ok bool) {
+	counters := l.nonStreaming
+	if streaming {
+		counters = l.streaming
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit > 0 && counters[identity] >= limit {
+		return nil, false
+	}
+	counters[identity]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if counters[identity] <= 1 {
+				delete(counters, identity)
+			} else {
+				counters[identity]--
+			}
+		})
+	}, true
+}
+
+// acquireShared is [concurrencyLimiter.acquire] with the limit applied to the leases in the state store shared by
+// the replicas, where the local counters only track the in-flight requests of this replica. When the state store
+// fails, this falls back to the local counters, i.e. the limit applies per replica, and returns the error of the
+// state store along with the result of the local counters.
+func (l *concurrencyLimiter) acquireShared(ctx context.Context, store *redisStateStore, identity string, streaming bool, limit int) (
+	release func(), ok bool, err error,
+) {
+	key := "concurrency:non-streaming:" + identity
+	if streaming {
+		key = "concurrency:streaming:" + identity
+	}
+	lease, ok, err := store.acquire(ctx, key, limit)
+	if err != nil {
+		l.stateStoreFallbacks.Add(1)
+		release, ok = l.acquire(identity, streaming, limit)
+		return release, ok, err
+	} else if !ok {
+		return nil, false, nil
+	}
+	releaseLocal, _ := l.acquire(identity, streaming, 0)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			releaseLocal()
+			// This is retried after the state store recovers if this fails.
+			_ = store.release(context.Background(), key, lease)
+		})
+	}, true, nil
+}
+
+// snapshot returns the copy of the current counters.
+func (l *concurrencyLimiter) snapshot() concurrencyLimiterSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return concurrencyLimiterSnapshot{Streaming: maps.Clone(l.streaming), NonStreaming: maps.Clone(l.nonStreaming)}
+}
@@ -0,0 +1,147 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestConcurrencyLimiter_acquire(t *testing.T) {
+	t.Run("limit", func(t *testing.T) {
+		l := newConcurrencyLimiter()
+		release1, ok := l.acquire("foo", true, 2)
+		require.True(t, ok)
+		release2, ok := l.acquire("foo", true, 2)
+		require.True(t, ok)
+		_, ok = l.acquire("foo", true, 2)
+		require.False(t, ok)
+
+		// Other identities and non-streaming requests are counted separately.
+		releaseBar, ok := l.acquire("bar", true, 2)
+		require.True(t, ok)
+		releaseNonStreaming, ok := l.acquire("foo", false, 1)
+		require.True(t, ok)
+		require.Equal(t, concurrencyLimiterSnapshot{
+			Streaming:    map[string]int{"foo": 2, "bar": 1},
+			NonStreaming: map[string]int{"foo": 1},
+		}, l.snapshot())
+
+		release1()
+		release1() // Releasing twice must not decrement the counter twice.
+		require.Equal(t, 1, l.snapshot().Streaming["foo"])
+		_, ok = l.acquire("foo", true, 2)
+		require.True(t, ok)
+
+		release2()
+		releaseBar()
+		releaseNonStreaming()
+		require.Equal(t, map[string]int{"foo": 1}, l.snapshot().Streaming)
+		require.Empty(t, l.snapshot().NonStreaming)
+	})
+	t.Run("unlimited", func(t *testing.T) {
+		l := newConcurrencyLimiter()
+		for range 100 {
+			_, ok := l.acquire("foo", true, 0)
+			require.True(t, ok)
+		}
+		require.Equal(t, 100, l.snapshot().Streaming["foo"])
+	})
+	t.Run("parallel", func(t *testing.T) {
+		l := newConcurrencyLimiter()
+		var wg sync.WaitGroup
+		for i := range 100 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				identity := strconv.Itoa(i % 5)
+				for j := range 100 {
+					release, ok := l.acquire(identity, j%2 == 0, 3)
+					if ok {
+						release()
+						if j%3 == 0 {
+							release()
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, concurrencyLimiterSnapshot{Streaming: map[string]int{}, NonStreaming: map[string]int{}}, l.snapshot())
+	})
+}
+
+func TestConcurrencyLimiter_acquireShared(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: mr.Addr(), KeyPrefix: "ai-gw:"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.close() })
+
+	// Two limiters simulate two replicas sharing the state store.
+	l1, l2 := newConcurrencyLimiter(), newConcurrencyLimiter()
+	release1, ok, err := l1.acquireShared(t.Context(), store, "foo", true, 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	release2, ok, err := l2.acquireShared(t.Context(), store, "foo", true, 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = l1.acquireShared(t.Context(), store, "foo", true, 2)
+	require.NoError(t, err)
+	require.False(t, ok)
+	// The non-streaming requests are counted separately.
+	releaseNonStreaming, ok, err := l2.acquireShared(t.Context(), store, "foo", false, 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The local counters only track the requests of the replica.
+	require.Equal(t, map[string]int{"foo": 1}, l1.snapshot().Streaming)
+	require.Equal(t, map[string]int{"foo": 1}, l2.snapshot().Streaming)
+	require.Len(t, mustZMembers(t, mr, "ai-gw:concurrency:streaming:foo"), 2)
+	require.Equal(t, stateStoreLeaseTTL, mr.TTL("ai-gw:concurrency:streaming:foo"))
+
+	release1()
+	release1() // Releasing twice is a no-op.
+	require.Len(t, mustZMembers(t, mr, "ai-gw:concurrency:streaming:foo"), 1)
+	release3, ok, err := l1.acquireShared(t.Context(), store, "foo", true, 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	release2()
+	release3()
+	releaseNonStreaming()
+	require.Empty(t, mr.Keys())
+	require.Empty(t, l1.snapshot().Streaming)
+	require.Zero(t, l1.stateStoreFallbacks.Load())
+
+	t.Run("fallback", func(t *testing.T) {
+		mr.SetError("unavailable")
+		defer mr.SetError("")
+		l := newConcurrencyLimiter()
+		release, ok, err := l.acquireShared(t.Context(), store, "foo", true, 1)
+		require.ErrorContains(t, err, "unavailable")
+		require.True(t, ok)
+		// The local counters apply the limit per replica, and the state store is skipped after the failure.
+		_, ok, err = l.acquireShared(t.Context(), store, "foo", true, 1)
+		require.ErrorIs(t, err, errStateStoreBreakerOpen)
+		require.False(t, ok)
+		require.Equal(t, uint64(2), l.stateStoreFallbacks.Load())
+		release()
+		require.Empty(t, l.snapshot().Streaming)
+	})
+}
+
+// mustZMembers returns the members of the sorted set of the key in the miniredis.
+func mustZMembers(t *testing.T, mr *miniredis.Miniredis, key string) []string {
+	members, err := mr.ZMembers(key)
+	require.NoError(t, err)
+	return members
+}
@@ -0,0 +1,92 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// mergeConfigs merges the dynamic configuration into the static one as follows:
+//
+//   - The rules of the dynamic configuration come first, followed by the ones of the static configuration, so that
+//     the dynamic rules take precedence when both match the request.
+//   - When the backends of the same name are defined in both, the definition in the dynamic configuration replaces
+//     the static ones, keeping the weights of the static rules, since the backend name must refer to the same
+//     backend across the rules.
+//   - The request costs are concatenated, and the dynamic one takes precedence for the same metadata key.
+//   - The schema, the metadata namespace, the model name header key and the selected backend header key must be
+//     the same in both unless either leaves it empty, otherwise this returns an error.
+//   - The rest of the fields are taken from the dynamic configuration.
+//
+// Neither of the configurations is modified.
+func mergeConfigs(static, dynamic *filterapi.Config) (*filterapi.Config, error) {
+	merged := *dynamic
+	var err error
+	if merged.Schema, err = mergeConfigField("schema", static.Schema, dynamic.Schema); err != nil {
+		return nil, err
+	}
+	if merged.MetadataNamespace, err = mergeConfigField("metadataNamespace", static.MetadataNamespace, dynamic.MetadataNamespace); err != nil {
+		return nil, err
+	}
+	if merged.ModelNameHeaderKey, err = mergeConfigField("modelNameHeaderKey", static.ModelNameHeaderKey, dynamic.ModelNameHeaderKey); err != nil {
+		return nil, err
+	}
+	if merged.SelectedBackendHeaderKey, err = mergeConfigField("selectedBackendHeaderKey",
+		static.SelectedBackendHeaderKey, dynamic.SelectedBackendHeaderKey); err != nil {
+		return nil, err
+	}
+
+	dynamicBackends := make(map[string]*filterapi.Backend)
+	for i := range dynamic.Rules {
+		for j := range dynamic.Rules[i].Backends {
+			b := &dynamic.Rules[i].Backends[j]
+			if _, ok := dynamicBackends[b.Name]; !ok {
+				dynamicBackends[b.Name] = b
+			}
+		}
+	}
+	merged.Rules = make([]filterapi.RouteRule, 0, len(dynamic.Rules)+len(static.Rules))
+	merged.Rules = append(merged.Rules, dynamic.Rules...)
+	for _, rule := range static.Rules {
+		rule.Backends = slices.Clone(rule.Backends)
+		for j := range rule.Backends {
+			b := &rule.Backends[j]
+			if d, ok := dynamicBackends[b.Name]; ok {
+				weight := b.Weight
+				*b = *d
+				b.Weight = weight
+			}
+		}
+		merged.Rules = append(merged.Rules, rule)
+	}
+
+	merged.LLMRequestCosts = slices.Clone(dynamic.LLMRequestCosts)
+	for _, c := range static.LLMRequestCosts {
+		if !slices.ContainsFunc(dynamic.LLMRequestCosts, func(d filterapi.LLMRequestCost) bool {
+			return d.MetadataKey == c.MetadataKey
+		}) {
+			merged.LLMRequestCosts = append(merged.LLMRequestCosts, c)
+		}
+	}
+	return &merged, nil
+}
+
+// mergeConfigField returns the value of the field set in either of the static and the dynamic configurations,
+// or an error if both set different values.
+func mergeConfigField[T comparable](name string, static, dynamic T) (T, error) {
+	var zero T
+	switch {
+	case static == zero:
+		return dynamic, nil
+	case dynamic == zero, static == dynamic:
+		return static, nil
+	default:
+		return zero, fmt.Errorf("%s conflicts between the static and the dynamic configurations: %v != %v", name, static, dynamic)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func Test_mergeConfigs(t *testing.T) {
+	openAI := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	bedrock := filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}
+	static := &filterapi.Config{
+		Schema:             openAI,
+		ModelNameHeaderKey: "x-model-name",
+		LLMRequestCosts: []filterapi.LLMRequestCost{
+			{MetadataKey: "total", Type: filterapi.LLMRequestCostTypeInputToken},
+			{MetadataKey: "output", Type: filterapi.LLMRequestCostTypeOutputToken},
+		},
+		MaxChoices: 3,
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "builtin", Schema: openAI, Weight: 1}, {Name: "shared", Schema: openAI, Weight: 5}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "builtin-model"}},
+			},
+		},
+	}
+	dynamic := &filterapi.Config{
+		UUID:                     "dynamic",
+		Schema:                   openAI,
+		SelectedBackendHeaderKey: "x-selected-backend",
+		LLMRequestCosts:          []filterapi.LLMRequestCost{{MetadataKey: "total", Type: filterapi.LLMRequestCostTypeTotalToken}},
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "shared", Schema: bedrock, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "dynamic-model"}},
+			},
+		},
+	}
+
+	merged, err := mergeConfigs(static, dynamic)
+	require.NoError(t, err)
+	require.Equal(t, &filterapi.Config{
+		UUID:                     "dynamic",
+		Schema:                   openAI,
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		LLMRequestCosts: []filterapi.LLMRequestCost{
+			{MetadataKey: "total", Type: filterapi.LLMRequestCostTypeTotalToken},
+			{MetadataKey: "output", Type: filterapi.LLMRequestCostTypeOutputToken},
+		},
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "shared", Schema: bedrock, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "dynamic-model"}},
+			},
+			{
+				// The shared backend is resolved in favor of the dynamic config, keeping the weight of the static rule.
+				Backends: []filterapi.Backend{{Name: "builtin", Schema: openAI, Weight: 1}, {Name: "shared", Schema: bedrock, Weight: 5}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "builtin-model"}},
+			},
+		},
+	}, merged)
+	require.NoError(t, ValidateConfig(merged, nil))
+	// Neither of the configs is modified.
+	require.Equal(t, openAI, static.Rules[0].Backends[1].Schema)
+	require.Len(t, dynamic.LLMRequestCosts, 1)
+
+	t.Run("conflicts", func(t *testing.T) {
+		for _, tc := range []struct {
+			name            string
+			static, dynamic *filterapi.Config
+			expErr          string
+		}{
+			{
+				name:    "schema",
+				static:  &filterapi.Config{Schema: openAI},
+				dynamic: &filterapi.Config{Schema: bedrock},
+				expErr:  "schema conflicts between the static and the dynamic configurations: {OpenAI } != {AWSBedrock }",
+			},
+			{
+				name:    "metadata namespace",
+				static:  &filterapi.Config{MetadataNamespace: "foo"},
+				dynamic: &filterapi.Config{MetadataNamespace: "bar"},
+				expErr:  "metadataNamespace conflicts between the static and the dynamic configurations: foo != bar",
+			},
+			{
+				name:    "model name header key",
+				static:  &filterapi.Config{ModelNameHeaderKey: "x-foo"},
+				dynamic: &filterapi.Config{ModelNameHeaderKey: "x-bar"},
+				expErr:  "modelNameHeaderKey conflicts between the static and the dynamic configurations: x-foo != x-bar",
+			},
+			{
+				name:    "selected backend header key",
+				static:  &filterapi.Config{SelectedBackendHeaderKey: "x-foo"},
+				dynamic: &filterapi.Config{SelectedBackendHeaderKey: "x-bar"},
+				expErr:  "selectedBackendHeaderKey conflicts between the static and the dynamic configurations: x-foo != x-bar",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := mergeConfigs(tc.static, tc.dynamic)
+				require.EqualError(t, err, tc.expErr)
+			})
+		}
+	})
+}
@@ -0,0 +1,308 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
+)
+
+// knownAPISchemaNames is the set of the API schema names supported by the external processor.
+var knownAPISchemaNames = map[filterapi.APISchemaName]struct{}{
+	filterapi.APISchemaOpenAI:     {},
+	filterapi.APISchemaAWSBedrock: {},
+	filterapi.APISchemaCohere:     {},
+	filterapi.APISchemaMistral:    {},
+}
+
+// ConfigValidationError is the error of a single field of the filter configuration found by [ValidateConfig].
+type ConfigValidationError struct {
+	// Field is the path to the invalid field, for example, "rules[0].backends[1].name".
+	Field string
+	// Line is the line number of the field in the configuration file. Zero if unknown.
+	Line int
+	// Message is the human-readable description of the error.
+	Message string
+}
+
+// Error implements [error].
+func (e *ConfigValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ConfigValidationErrors is the list of the errors found by [ValidateConfig].
+type ConfigValidationErrors []*ConfigValidationError
+
+// Error implements [error].
+func (e ConfigValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ValidateConfigFile loads the configuration file at the given path in the same way as the config watcher
+// and validates it with [ValidateConfig].
+func ValidateConfigFile(path string) error {
+	cfg, raw, err := filterapi.UnmarshalConfigYaml(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	return ValidateConfig(cfg, raw)
+}
+
+// ValidateConfig validates the filter configuration beyond what the unmarshalling checks. Notably, this compiles
+// the CEL expressions, checks that the API schema names are known, that the backend names are unique, and that the
+// header match names are not empty.
+//
+// The raw is the YAML that the configuration is unmarshalled from, and used to attribute the line numbers to the
+// errors. It can be nil. This returns [ConfigValidationErrors] if the configuration is invalid, otherwise nil.
+func ValidateConfig(cfg *filterapi.Config, raw []byte) error {
+	v := &configValidator{}
+	if len(raw) > 0 {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(raw, &doc); err == nil && len(doc.Content) > 0 {
+			v.root = doc.Content[0]
+		}
+	}
+
+	if _, ok := knownAPISchemaNames[cfg.Schema.Name]; !ok {
+		v.addf(fieldPath{"schema", "name"}, "unknown API schema name %q", cfg.Schema.Name)
+	}
+
+	// The backend name is used as the key of the backend auth handlers, so the same name in different rules
+	// must refer to the same backend.
+	type definedBackend struct {
+		backend *filterapi.Backend
+		path    fieldPath
+	}
+	backends := make(map[string]definedBackend)
+	defaultRule := -1
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.Default {
+			if defaultRule >= 0 {
+				v.addf(fieldPath{"rules", i, "default"}, "rules[%d] is already the default rule", defaultRule)
+			} else {
+				defaultRule = i
+			}
+		}
+		names := make(map[string]struct{}, len(rule.Backends))
+		for j := range rule.Backends {
+			b := &rule.Backends[j]
+			path := fieldPath{"rules", i, "backends", j}
+			if b.Name == "" {
+				v.addf(path.with("name"), "backend name must not be empty")
+			} else if _, ok := names[b.Name]; ok {
+				v.addf(path.with("name"), "duplicate backend name %q in the rule", b.Name)
+			} else if defined, ok := backends[b.Name]; ok &&
+				(!reflect.DeepEqual(defined.backend.Schema, b.Schema) || !reflect.DeepEqual(defined.backend.Auth, b.Auth)) {
+				v.addf(path.with("name"), "backend name %q is already used by a different backend at %s", b.Name, defined.path)
+			} else if !ok {
+				backends[b.Name] = definedBackend{backend: b, path: path}
+			}
+			names[b.Name] = struct{}{}
+			// The custom translator may support the backend schemas unknown to the built-in ones.
+			if _, ok := knownAPISchemaNames[b.Schema.Name]; !ok && x.NewCustomTranslatorFactory == nil {
+				v.addf(path.with("schema", "name"), "unknown API schema name %q", b.Schema.Name)
+			}
+			switch b.UnsupportedFieldPolicy {
+			case "", filterapi.UnsupportedFieldPolicyIgnore, filterapi.UnsupportedFieldPolicyWarn, filterapi.UnsupportedFieldPolicyReject:
+			default:
+				v.addf(path.with("unsupportedFieldPolicy"), "unknown unsupported field policy %q", b.UnsupportedFieldPolicy)
+			}
+			if pn := b.ParameterNormalization; pn != nil {
+				switch pn.Mode {
+				case filterapi.ParameterNormalizationModeAuto, filterapi.ParameterNormalizationModeExplicit:
+				default:
+					v.addf(path.with("parameterNormalization", "mode"), "unknown parameter normalization mode %q", pn.Mode)
+				}
+			}
+			if b.Auth != nil && b.Auth.APIKey != nil && b.Auth.APIKey.ValueTemplate != "" &&
+				!strings.Contains(b.Auth.APIKey.ValueTemplate, "{key}") {
+				v.addf(path.with("auth", "apiKey", "valueTemplate"), "valueTemplate must contain {key}")
+			}
+			if b.PathOverride != "" {
+				if msg := validatePathOverride(b); msg != "" {
+					v.addf(path.with("pathOverride"), "%s", msg)
+				}
+			}
+		}
+		for j := range rule.Headers {
+			if rule.Headers[j].Name == "" {
+				v.addf(fieldPath{"rules", i, "headers", j, "name"}, "header match name must not be empty")
+			}
+		}
+	}
+
+	for i := range cfg.LLMRequestCosts {
+		c := &cfg.LLMRequestCosts[i]
+		path := fieldPath{"llmRequestCosts", i}
+		switch c.Type {
+		case filterapi.LLMRequestCostTypeInputToken, filterapi.LLMRequestCostTypeOutputToken, filterapi.LLMRequestCostTypeTotalToken:
+		case filterapi.LLMRequestCostTypeCEL:
+			if _, err := llmcostcel.NewProgram(c.CEL); err != nil {
+				v.addf(path.with("cel"), "invalid CEL expression: %v", err)
+			}
+		case filterapi.LLMRequestCostTypeModelPriceTable:
+			v.validateModelPriceTable(path.with("modelPriceTable"), c.ModelPriceTable)
+		default:
+			v.addf(path.with("type"), "unknown request cost type %q", c.Type)
+		}
+	}
+
+	if mp := cfg.ModelPolicy; mp != nil {
+		validatePatterns := func(field string, patterns []string) {
+			for i, pattern := range patterns {
+				if pattern == "" || strings.Contains(strings.TrimSuffix(pattern, "*"), "*") {
+					v.addf(fieldPath{"modelPolicy", field, i}, "model pattern must be non-empty with \"*\" only as the suffix: %q", pattern)
+				}
+			}
+		}
+		validatePatterns("allow", mp.Allow)
+		validatePatterns("deny", mp.Deny)
+	}
+
+	if ss := cfg.StateStore; ss != nil {
+		if ss.Type != filterapi.StateStoreTypeRedis {
+			v.addf(fieldPath{"stateStore", "type"}, "unknown state store type %q", ss.Type)
+		}
+		if _, _, err := net.SplitHostPort(ss.Address); err != nil {
+			v.addf(fieldPath{"stateStore", "address"}, "address must be in the form of host:port: %q", ss.Address)
+		}
+	}
+
+	if al := cfg.AccessLog; al != nil && al.SampleRate != nil && (*al.SampleRate < 0 || *al.SampleRate > 1) {
+		v.addf(fieldPath{"accessLog", "sampleRate"}, "sample rate must be between 0 and 1")
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// configValidator accumulates the validation errors of the configuration.
+type configValidator struct {
+	// root is the root node of the YAML document. Nil if not available.
+	root *yaml.Node
+	errs ConfigValidationErrors
+}
+
+// validateModelPriceTable validates the model price table of the request cost at the path.
+func (v *configValidator) validateModelPriceTable(path fieldPath, t *filterapi.ModelPriceTable) {
+	if t == nil {
+		v.addf(path, "model price table must be set for the ModelPriceTable type")
+		return
+	}
+	if t.Multiplier < 0 {
+		v.addf(path.with("multiplier"), "multiplier must not be negative")
+	}
+	validatePrice := func(path fieldPath, p *filterapi.ModelPrice) {
+		if p.InputTokenPrice < 0 {
+			v.addf(path.with("inputTokenPrice"), "price must not be negative")
+		}
+		if p.OutputTokenPrice < 0 {
+			v.addf(path.with("outputTokenPrice"), "price must not be negative")
+		}
+	}
+	models := make([]string, 0, len(t.Prices))
+	for model := range t.Prices {
+		models = append(models, model)
+	}
+	// Sort the models for the deterministic order of the errors.
+	slices.Sort(models)
+	for _, model := range models {
+		p := t.Prices[model]
+		validatePrice(path.with("prices", model), &p)
+	}
+	if t.Default != nil {
+		validatePrice(path.with("default"), t.Default)
+	}
+}
+
+// addf adds the validation error of the field at the path.
+func (v *configValidator) addf(path fieldPath, format string, args ...any) {
+	v.errs = append(v.errs, &ConfigValidationError{
+		Field:   path.String(),
+		Line:    path.line(v.root),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// fieldPath is the path to a field in the configuration. Each element is either a string key of a mapping
+// or an int index of a sequence.
+type fieldPath []any
+
+// with returns the new path with the given elements appended.
+func (p fieldPath) with(elems ...any) fieldPath {
+	return append(append(fieldPath{}, p...), elems...)
+}
+
+// String implements [fmt.Stringer].
+func (p fieldPath) String() string {
+	var b strings.Builder
+	for _, e := range p {
+		switch e := e.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(e) + "]")
+		case string:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(e)
+		}
+	}
+	return b.String()
+}
+
+// line returns the line number of the field at the path in the YAML document. When the field does not exist,
+// for example, because it is omitted, this returns the line of the closest existing parent.
+func (p fieldPath) line(node *yaml.Node) int {
+	if node == nil {
+		return 0
+	}
+	line := node.Line
+	for _, e := range p {
+		var next *yaml.Node
+		switch e := e.(type) {
+		case int:
+			if node.Kind == yaml.SequenceNode && e < len(node.Content) {
+				next = node.Content[e]
+				line = next.Line
+			}
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == e {
+						next = node.Content[i+1]
+						line = node.Content[i].Line
+						break
+					}
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line
+}
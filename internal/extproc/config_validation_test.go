@@ -0,0 +1,250 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+func TestValidateConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		config  string
+		expErrs ConfigValidationErrors
+	}{
+		{
+			name:   "default",
+			config: filterapi.DefaultConfig,
+		},
+		{
+			name: "valid",
+			config: `schema:
+  name: OpenAI
+llmRequestCosts:
+- metadataKey: total
+  type: TotalToken
+- metadataKey: cel
+  type: CEL
+  cel: "input_tokens + output_tokens"
+rules:
+- backends:
+  - name: kserve
+    schema:
+      name: OpenAI
+  - name: awsbedrock
+    schema:
+      name: AWSBedrock
+    unsupportedFieldPolicy: Warn
+    parameterNormalization:
+      mode: Auto
+  headers:
+  - name: x-model-name
+    value: llama3.3333
+- backends:
+  - name: kserve
+    schema:
+      name: OpenAI
+  headers:
+  - name: x-model-name
+    value: gpt4.4444
+accessLog:
+  sampleRate: 0.5
+modelPolicy:
+  allow:
+  - gpt4*
+  deny:
+  - gpt4.4444
+`,
+		},
+		{
+			name: "invalid",
+			config: `schema:
+  name: Foo
+llmRequestCosts:
+- metadataKey: cel
+  type: CEL
+  cel: "input_tokens +"
+- metadataKey: unknown
+  type: Unknown
+- metadataKey: price
+  type: ModelPriceTable
+  modelPriceTable:
+    prices:
+      gpt-4o:
+        inputTokenPrice: -1
+        outputTokenPrice: 1
+    default:
+      inputTokenPrice: 1
+      outputTokenPrice: -1
+    multiplier: -1
+- metadataKey: no-table
+  type: ModelPriceTable
+rules:
+- backends:
+  - name: kserve
+    schema:
+      name: OpenAI
+  - name: kserve
+    schema:
+      name: OpenAI
+  - schema:
+      name: OpenAI
+  headers:
+  - name: ""
+    value: llama3.3333
+- backends:
+  - name: kserve
+    schema:
+      name: AWSBedrock
+    unsupportedFieldPolicy: Sometimes
+    pathOverride: /converse
+  - name: openai
+  headers:
+  - value: gpt4.4444
+accessLog:
+  sampleRate: 1.5
+modelPolicy:
+  allow:
+  - gpt-*-mini
+  deny:
+  - ""
+`,
+			expErrs: ConfigValidationErrors{
+				{Line: 2, Field: "schema.name", Message: `unknown API schema name "Foo"`},
+				{Line: 27, Field: "rules[0].backends[1].name", Message: `duplicate backend name "kserve" in the rule`},
+				{Line: 30, Field: "rules[0].backends[2].name", Message: "backend name must not be empty"},
+				{Line: 33, Field: "rules[0].headers[0].name", Message: "header match name must not be empty"},
+				{Line: 36, Field: "rules[1].backends[0].name", Message: `backend name "kserve" is already used by a different backend at rules[0].backends[0]`},
+				{Line: 39, Field: "rules[1].backends[0].unsupportedFieldPolicy", Message: `unknown unsupported field policy "Sometimes"`},
+				{Line: 40, Field: "rules[1].backends[0].pathOverride", Message: "path override must contain {model} for the AWSBedrock schema"},
+				{Line: 41, Field: "rules[1].backends[1].schema.name", Message: `unknown API schema name ""`},
+				{Line: 43, Field: "rules[1].headers[0].name", Message: "header match name must not be empty"},
+				{Line: 6, Field: "llmRequestCosts[0].cel", Message: "invalid CEL expression: cannot compile CEL expression: ERROR: <input>:1:15: Syntax error: mismatched input '<EOF>' expecting {'[', '{', '(', '.', '-', '!', 'true', 'false', 'null', NUM_FLOAT, NUM_INT, NUM_UINT, STRING, BYTES, IDENTIFIER}\n | input_tokens +\n | ..............^"},
+				{Line: 8, Field: "llmRequestCosts[1].type", Message: `unknown request cost type "Unknown"`},
+				{Line: 19, Field: "llmRequestCosts[2].modelPriceTable.multiplier", Message: "multiplier must not be negative"},
+				{Line: 14, Field: "llmRequestCosts[2].modelPriceTable.prices.gpt-4o.inputTokenPrice", Message: "price must not be negative"},
+				{Line: 18, Field: "llmRequestCosts[2].modelPriceTable.default.outputTokenPrice", Message: "price must not be negative"},
+				{Line: 20, Field: "llmRequestCosts[3].modelPriceTable", Message: "model price table must be set for the ModelPriceTable type"},
+				{Line: 48, Field: "modelPolicy.allow[0]", Message: `model pattern must be non-empty with "*" only as the suffix: "gpt-*-mini"`},
+				{Line: 50, Field: "modelPolicy.deny[0]", Message: `model pattern must be non-empty with "*" only as the suffix: ""`},
+				{Line: 45, Field: "accessLog.sampleRate", Message: "sample rate must be between 0 and 1"},
+			},
+		},
+		{
+			name: "multiple default rules",
+			config: `schema:
+  name: OpenAI
+rules:
+- backends:
+  - name: kserve
+    schema:
+      name: OpenAI
+  default: true
+- backends:
+  - name: openai
+    schema:
+      name: OpenAI
+  default: true
+`,
+			expErrs: ConfigValidationErrors{
+				{Line: 13, Field: "rules[1].default", Message: "rules[0] is already the default rule"},
+			},
+		},
+		{
+			name: "unknown parameter normalization mode",
+			config: `schema:
+  name: OpenAI
+rules:
+- backends:
+  - name: awsbedrock
+    schema:
+      name: AWSBedrock
+    parameterNormalization:
+      mode: Sometimes
+`,
+			expErrs: ConfigValidationErrors{
+				{Line: 9, Field: "rules[0].backends[0].parameterNormalization.mode", Message: `unknown parameter normalization mode "Sometimes"`},
+			},
+		},
+		{
+			name: "api key value template without the key",
+			config: `schema:
+  name: OpenAI
+rules:
+- backends:
+  - name: localai
+    schema:
+      name: OpenAI
+    auth:
+      apiKey:
+        filename: /etc/api-key
+        headerName: x-api-key
+        valueTemplate: Token
+`,
+			expErrs: ConfigValidationErrors{
+				{Line: 12, Field: "rules[0].backends[0].auth.apiKey.valueTemplate", Message: "valueTemplate must contain {key}"},
+			},
+		},
+		{
+			name: "invalid state store",
+			config: `schema:
+  name: OpenAI
+stateStore:
+  type: Memcached
+  address: redis
+`,
+			expErrs: ConfigValidationErrors{
+				{Line: 4, Field: "stateStore.type", Message: `unknown state store type "Memcached"`},
+				{Line: 5, Field: "stateStore.address", Message: `address must be in the form of host:port: "redis"`},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg filterapi.Config
+			require.NoError(t, yaml.Unmarshal([]byte(tc.config), &cfg))
+			err := ValidateConfig(&cfg, []byte(tc.config))
+			if len(tc.expErrs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tc.expErrs, err)
+		})
+	}
+
+	t.Run("without raw", func(t *testing.T) {
+		err := ValidateConfig(&filterapi.Config{Schema: filterapi.VersionedAPISchema{Name: "Foo"}}, nil)
+		require.EqualError(t, err, `schema.name: unknown API schema name "Foo"`)
+	})
+	t.Run("custom translator", func(t *testing.T) {
+		x.NewCustomTranslatorFactory = func(filterapi.VersionedAPISchema, filterapi.VersionedAPISchema, string) x.Translator { return nil }
+		t.Cleanup(func() { x.NewCustomTranslatorFactory = nil })
+		// The backend schemas unknown to the built-in translators are left to the custom translator.
+		err := ValidateConfig(&filterapi.Config{
+			Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			Rules: []filterapi.RouteRule{{
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "foo"}},
+				Backends: []filterapi.Backend{{Name: "in-house", Schema: filterapi.VersionedAPISchema{Name: "InHouse"}, Weight: 1}},
+			}},
+		}, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.ErrorContains(t, ValidateConfigFile(path), "failed to load config")
+
+	require.NoError(t, os.WriteFile(path, []byte("schema:\n  name: OpenAI\nrules:\n- headers:\n  - value: foo\n"), 0o600))
+	require.EqualError(t, ValidateConfigFile(path), "line 5: rules[0].headers[0].name: header match name must not be empty")
+}
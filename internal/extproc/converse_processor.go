@@ -0,0 +1,172 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// NewConverseProcessor implements [Processor] for the AWS Bedrock /model/{modelId}/converse endpoint.
+//
+// The model name is taken from the request path instead of the body, so that the clients speaking the Converse API
+// can be routed with the same model-based rules as the OpenAI clients.
+func NewConverseProcessor(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger) (Processor, error) {
+	if config.schema.Name != filterapi.APISchemaAWSBedrock {
+		return nil, fmt.Errorf("unsupported API schema: %s", config.schema.Name)
+	}
+	return &converseProcessor{chatCompletionProcessor: &chatCompletionProcessor{
+		config:         config,
+		requestHeaders: requestHeaders,
+		logger:         logger,
+	}}, nil
+}
+
+// converseProcessor handles the processing of the Converse request and response messages for a single stream.
+//
+// The response is handled in the same way as the chat completion, so this only differs in the request processing.
+type converseProcessor struct {
+	*chatCompletionProcessor
+}
+
+// modelFromConversePath extracts the model ID from the path of the form /model/{modelId}/converse.
+func modelFromConversePath(path string) (string, error) {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(segments) != 3 || segments[0] != "model" || segments[2] != "converse" || segments[1] == "" {
+		return "", fmt.Errorf("invalid converse path: %s", path)
+	}
+	// The model ID can be an ARN, which is percent-encoded by the clients.
+	return url.PathUnescape(segments[1])
+}
+
+// newConverseTranslator creates the translator based on the output schema of the backend.
+func newConverseTranslator(b *filterapi.Backend) (translator.Translator, error) {
+	switch out := b.Schema; out.Name {
+	case filterapi.APISchemaAWSBedrock:
+		return translator.NewConverseAWSBedrockToAWSBedrockTranslator(), nil
+	case filterapi.APISchemaOpenAI:
+		return translator.NewConverseAWSBedrockToOpenAITranslator(), nil
+	default:
+		return nil, fmt.Errorf("unsupported API schema: backend=%s", out)
+	}
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (c *converseProcessor) ProcessRequestBody(ctx context.Context, rawBody *extprocv3.HttpBody) (res *extprocv3.ProcessingResponse, err error) {
+	model, err := modelFromConversePath(c.requestHeaders[":path"])
+	if err != nil {
+		return nil, err
+	}
+	var req awsbedrock.ConverseInput
+	if err = json.Unmarshal(rawBody.Body, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request body: %w", err)
+	}
+	req.ModelID = &model
+	c.logger.Info("Processing request", "path", c.requestHeaders[":path"], "model", model)
+	if !modelAllowed(c.config.modelPolicy, model) {
+		c.logger.Info("Rejecting request for the model not allowed by the model policy", "model", model)
+		return modelNotAllowedResponse(model), nil
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(spanAttrModel.String(model), spanAttrStream.Bool(false))
+
+	if resp := c.maybeAcquireConcurrency(false); resp != nil {
+		return resp, nil
+	}
+
+	c.requestHeaders[c.config.modelNameHeaderKey] = model
+	b, err := c.config.router.Calculate(c.requestHeaders)
+	if err != nil {
+		if errors.Is(err, x.ErrNoMatchingRule) {
+			return c.noMatchingRule(model), nil
+		}
+		return nil, fmt.Errorf("failed to calculate route: %w", err)
+	}
+	c.logger.Info("Selected backend", "backend", b.Name)
+	c.backendName = b.Name
+	span.SetAttributes(
+		spanAttrBackend.String(b.Name),
+		spanAttrSchemaInput.String(string(c.config.schema.Name)),
+		spanAttrSchemaOutput.String(string(b.Schema.Name)),
+	)
+	headerModifications := []*filterapi.HeaderModifications{
+		nil, b.HeaderModifications, openAIHeaderModifications(b.OpenAI),
+	}
+	if ruleIndex, ok := c.config.backendRuleIndexes[b]; ok {
+		span.SetAttributes(spanAttrRouteRuleIndex.Int(ruleIndex))
+		headerModifications[0] = c.config.rules[ruleIndex].HeaderModifications
+	}
+
+	if c.translator == nil { // Allows translator injection in tests.
+		if c.translator, err = newConverseTranslator(b); err != nil {
+			return nil, fmt.Errorf("failed to select translator: %w", err)
+		}
+	}
+	headerMutation, bodyMutation, override, err := c.translator.RequestBody(&req)
+	if errors.Is(err, translator.ErrUnsupportedContentBlock) {
+		c.logger.Info("Rejecting request with the unsupported content", "backend", b.Name, "reason", err)
+		return invalidRequestResponse("unsupported_content_block", "messages", err.Error()), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to transform request: %w", err)
+	}
+	if headerMutation == nil {
+		headerMutation = &extprocv3.HeaderMutation{}
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: c.config.modelNameHeaderKey, RawValue: []byte(model)},
+	}, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: c.config.selectedBackendHeaderKey, RawValue: []byte(b.Name)},
+	})
+	// The path and the body are always set explicitly even when the translator passes the request through, since
+	// the AWS request signature of the backend auth covers both. This also removes the path prefix, if any.
+	if headerMutationValue(headerMutation, ":path") == "" {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(c.requestHeaders[":path"])},
+		})
+	}
+	if bodyMutation == nil {
+		bodyMutation = &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: rawBody.Body}}
+	}
+
+	applyHeaderModifications(headerModifications, model, b.Name, c.requestHeaders, headerMutation)
+
+	if authHandler, ok := c.config.backendAuthHandlers[b.Name]; ok {
+		authStart := len(headerMutation.SetHeaders)
+		if err := authHandler.Do(ctx, c.requestHeaders, headerMutation, bodyMutation); err != nil {
+			return nil, fmt.Errorf("failed to do auth request: %w", err)
+		}
+		resolveAuthHeaderConflicts(headerMutation, authStart)
+	}
+
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{
+			RequestBody: &extprocv3.BodyResponse{
+				Response: &extprocv3.CommonResponse{
+					HeaderMutation:  headerMutation,
+					BodyMutation:    bodyMutation,
+					ClearRouteCache: true,
+				},
+			},
+		},
+		ModeOverride: override,
+	}, nil
+}
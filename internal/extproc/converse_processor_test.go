@@ -0,0 +1,139 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func Test_modelFromConversePath(t *testing.T) {
+	for path, exp := range map[string]string{
+		"/model/anthropic.claude-3-sonnet-20240229-v1:0/converse":                             "anthropic.claude-3-sonnet-20240229-v1:0",
+		"/model/arn%3Aaws%3Abedrock%3Aus-east-1%3A123%3Ainference-profile%2Ffoo/converse?x=y": "arn:aws:bedrock:us-east-1:123:inference-profile/foo",
+	} {
+		model, err := modelFromConversePath(path)
+		require.NoError(t, err)
+		require.Equal(t, exp, model)
+	}
+	for _, path := range []string{"/v1/chat/completions", "/model//converse", "/model/foo/converse-stream", "/model/%zz/converse"} {
+		_, err := modelFromConversePath(path)
+		require.Error(t, err, path)
+	}
+}
+
+func TestNewConverseProcessor(t *testing.T) {
+	_, err := NewConverseProcessor(&processorConfig{schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}}, nil, slog.Default())
+	require.ErrorContains(t, err, "unsupported API schema: OpenAI")
+}
+
+func TestConverse_ProcessRequestBody(t *testing.T) {
+	apiKeyFile := t.TempDir() + "/apiKey"
+	require.NoError(t, os.WriteFile(apiKeyFile, []byte("some-api-key"), 0o600))
+
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{
+					Name:   "openai",
+					Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+					Weight: 1,
+					Auth:   &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Filename: apiKeyFile}},
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "anthropic.claude-3-sonnet-20240229-v1:0"}},
+			},
+		},
+	}))
+	body := []byte(`{"messages":[{"role":"user","content":[{"text":"hi"}]}],"inferenceConfig":{"maxTokens":10}}`)
+	process := func(t *testing.T, path string) (*converseProcessor, *extprocv3.ProcessingResponse) {
+		p, err := NewConverseProcessor(s.config.Load(), map[string]string{":path": path, ":method": "POST"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: body})
+		require.NoError(t, err)
+		return p.(*converseProcessor), resp
+	}
+
+	t.Run("bedrock to openai", func(t *testing.T) {
+		p, resp := process(t, "/model/gpt-4o/converse")
+		require.IsType(t, translator.NewConverseAWSBedrockToOpenAITranslator(), p.translator)
+		common := resp.GetRequestBody().GetResponse()
+		require.True(t, common.ClearRouteCache)
+		hm := common.GetHeaderMutation()
+		require.Equal(t, "/v1/chat/completions", headerMutationValue(hm, ":path"))
+		require.Equal(t, "gpt-4o", headerMutationValue(hm, "x-model-name"))
+		require.Equal(t, "openai", headerMutationValue(hm, "x-selected-backend"))
+		require.Equal(t, "Bearer some-api-key", headerMutationValue(hm, "Authorization"))
+		require.JSONEq(t, `{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}],"max_tokens":10}`,
+			string(common.GetBodyMutation().GetBody()))
+
+		// The OpenAI response is translated back to the Converse response.
+		_, err := p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}})
+		require.NoError(t, err)
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{EndOfStream: true, Body: []byte(
+			`{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`,
+		)})
+		require.NoError(t, err)
+		var converseResp awsbedrock.ConverseResponse
+		require.NoError(t, json.Unmarshal(resp.GetResponseBody().GetResponse().GetBodyMutation().GetBody(), &converseResp))
+		require.Equal(t, "hello", *converseResp.Output.Message.Content[0].Text)
+		require.Equal(t, awsbedrock.StopReasonEndTurn, *converseResp.StopReason)
+		require.Equal(t, translator.LLMTokenUsage{InputTokens: 3, OutputTokens: 1, TotalTokens: 4}, p.costs)
+	})
+	t.Run("bedrock to bedrock", func(t *testing.T) {
+		const path = "/model/anthropic.claude-3-sonnet-20240229-v1%3A0/converse"
+		p, resp := process(t, path)
+		require.IsType(t, translator.NewConverseAWSBedrockToAWSBedrockTranslator(), p.translator)
+		common := resp.GetRequestBody().GetResponse()
+		hm := common.GetHeaderMutation()
+		// The path and body are passed through as is.
+		require.Equal(t, path, headerMutationValue(hm, ":path"))
+		require.Equal(t, "anthropic.claude-3-sonnet-20240229-v1:0", headerMutationValue(hm, "x-model-name"))
+		require.Equal(t, "bedrock", headerMutationValue(hm, "x-selected-backend"))
+		require.Equal(t, body, common.GetBodyMutation().GetBody())
+
+		resp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{EndOfStream: true, Body: []byte(
+			`{"output":{"message":{"role":"assistant","content":[{"text":"hello"}]}},"stopReason":"end_turn","usage":{"inputTokens":3,"outputTokens":1,"totalTokens":4}}`,
+		)})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetResponseBody().GetResponse().GetBodyMutation())
+		require.Equal(t, translator.LLMTokenUsage{InputTokens: 3, OutputTokens: 1, TotalTokens: 4}, p.costs)
+	})
+	t.Run("no matching rule", func(t *testing.T) {
+		_, resp := process(t, "/model/unknown/converse")
+		require.Equal(t, typev3.StatusCode_NotFound, resp.GetImmediateResponse().GetStatus().GetCode())
+		require.JSONEq(t, `{"type":"error","error":{"type":"model_not_found","message":"no route for model unknown"}}`,
+			string(resp.GetImmediateResponse().GetBody()))
+	})
+	t.Run("unsupported content block", func(t *testing.T) {
+		p, err := NewConverseProcessor(s.config.Load(), map[string]string{":path": "/model/gpt-4o/converse"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"messages":[{"role":"user","content":[{"document":{"format":"pdf","name":"doc","source":{"bytes":""}}}]}]}`),
+		})
+		require.NoError(t, err)
+		require.Equal(t, typev3.StatusCode_BadRequest, resp.GetImmediateResponse().GetStatus().GetCode())
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// customTranslator implements [translator.Translator] by delegating to the [x.Translator] created by
+// [x.NewCustomTranslatorFactory].
+type customTranslator struct {
+	custom x.Translator
+}
+
+// RequestBody implements [translator.Translator.RequestBody].
+//
+// The custom translator receives the request re-encoded from the parsed one so that the changes made by the filter,
+// such as the model parameter defaults, are reflected.
+func (c *customTranslator) RequestBody(body translator.RequestBody) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, override *extprocv3http.ProcessingMode, err error,
+) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal the request body: %w", err)
+	}
+	path, newBody, err := c.custom.RequestBody(raw)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("custom translator failed to translate the request body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	if path != "" {
+		headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(path)},
+		})
+	}
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: "content-length", RawValue: []byte(strconv.Itoa(len(newBody)))},
+	})
+	if req, ok := body.(*openai.ChatCompletionRequest); ok && req.Stream {
+		override = &extprocv3http.ProcessingMode{
+			ResponseHeaderMode: extprocv3http.ProcessingMode_SEND,
+			ResponseBodyMode:   extprocv3http.ProcessingMode_STREAMED,
+		}
+	}
+	return headerMutation, &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: newBody}}, override, nil
+}
+
+// ResponseHeaders implements [translator.Translator.ResponseHeaders].
+func (c *customTranslator) ResponseHeaders(map[string]string) (*extprocv3.HeaderMutation, error) {
+	// The length of the translated body is not known until the entire body is translated.
+	return &extprocv3.HeaderMutation{RemoveHeaders: []string{"content-length"}}, nil
+}
+
+// ResponseBody implements [translator.Translator.ResponseBody].
+func (c *customTranslator) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage translator.LLMTokenUsage, err error,
+) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to read the response body: %w", err)
+	}
+	newBody, usage, err := c.custom.ResponseBody(respHeaders, raw, endOfStream)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("custom translator failed to translate the response body: %w", err)
+	}
+	tokenUsage = translator.LLMTokenUsage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+	return nil, &extprocv3.BodyMutation{Mutation: &extprocv3.BodyMutation_Body{Body: newBody}}, tokenUsage, nil
+}
+
+// ResponseError implements [translator.Translator.ResponseError].
+// The error responses are translated by [x.Translator.ResponseBody] as well, so this is not used.
+func (c *customTranslator) ResponseError(map[string]string, io.Reader) (*extprocv3.HeaderMutation, *extprocv3.BodyMutation, error) {
+	return nil, nil, nil
+}
@@ -0,0 +1,109 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// fakeCustomTranslator implements [x.Translator] for testing.
+type fakeCustomTranslator struct {
+	path    string
+	gotBody []byte
+	retErr  error
+}
+
+// RequestBody implements [x.Translator.RequestBody].
+func (f *fakeCustomTranslator) RequestBody(body []byte) (string, []byte, error) {
+	f.gotBody = body
+	return f.path, []byte("translated-request"), f.retErr
+}
+
+// ResponseBody implements [x.Translator.ResponseBody].
+func (f *fakeCustomTranslator) ResponseBody(headers map[string]string, body []byte, endOfStream bool) ([]byte, x.TokenUsage, error) {
+	if !endOfStream {
+		return body, x.TokenUsage{}, f.retErr
+	}
+	return append([]byte(headers[":status"]+":"), body...), x.TokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}, f.retErr
+}
+
+func TestNewChatCompletionTranslator_custom(t *testing.T) {
+	custom := &fakeCustomTranslator{}
+	var gotInput, gotOutput filterapi.VersionedAPISchema
+	var gotPath string
+	x.NewCustomTranslatorFactory = func(input, output filterapi.VersionedAPISchema, path string) x.Translator {
+		gotInput, gotOutput, gotPath = input, output, path
+		if output.Name != "InHouse" {
+			return nil
+		}
+		return custom
+	}
+	t.Cleanup(func() { x.NewCustomTranslatorFactory = nil })
+
+	openAISchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	inHouse := &filterapi.Backend{Schema: filterapi.VersionedAPISchema{Name: "InHouse", Version: "v2"}}
+	tr, err := newChatCompletionTranslator(openAISchema, "/v1/chat/completions", inHouse, 0)
+	require.NoError(t, err)
+	require.Equal(t, &customTranslator{custom: custom}, tr)
+	require.Equal(t, openAISchema, gotInput)
+	require.Equal(t, inHouse.Schema, gotOutput)
+	require.Equal(t, "/v1/chat/completions", gotPath)
+
+	// Returning nil falls through to the built-in translators.
+	tr, err = newChatCompletionTranslator(openAISchema, "/v1/chat/completions", &filterapi.Backend{Schema: openAISchema}, 0)
+	require.NoError(t, err)
+	require.IsType(t, translator.NewChatCompletionOpenAIToOpenAITranslator(), tr)
+}
+
+func TestCustomTranslator(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		custom := &fakeCustomTranslator{path: "/generate"}
+		c := &customTranslator{custom: custom}
+		hm, bm, override, err := c.RequestBody(&openai.ChatCompletionRequest{Model: "some-model", Stream: true})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"model":"some-model","messages":null,"stream":true}`, string(custom.gotBody))
+		require.Equal(t, "translated-request", string(bm.GetBody()))
+		require.Equal(t, "/generate", headerMutationValue(hm, ":path"))
+		require.Equal(t, "18", headerMutationValue(hm, "content-length"))
+		require.Equal(t, extprocv3http.ProcessingMode_STREAMED, override.ResponseBodyMode)
+	})
+	t.Run("request error", func(t *testing.T) {
+		c := &customTranslator{custom: &fakeCustomTranslator{retErr: errors.New("boom")}}
+		_, _, _, err := c.RequestBody(&openai.ChatCompletionRequest{Model: "some-model"})
+		require.ErrorContains(t, err, "custom translator failed to translate the request body: boom")
+	})
+	t.Run("response", func(t *testing.T) {
+		c := &customTranslator{custom: &fakeCustomTranslator{}}
+		hm, err := c.ResponseHeaders(map[string]string{":status": "200"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"content-length"}, hm.RemoveHeaders)
+
+		_, bm, usage, err := c.ResponseBody(map[string]string{":status": "200"}, bytes.NewReader([]byte("chunk")), false)
+		require.NoError(t, err)
+		require.Equal(t, "chunk", string(bm.GetBody()))
+		require.Equal(t, translator.LLMTokenUsage{}, usage)
+
+		_, bm, usage, err = c.ResponseBody(map[string]string{":status": "500"}, bytes.NewReader([]byte("last")), true)
+		require.NoError(t, err)
+		require.Equal(t, "500:last", string(bm.GetBody()))
+		require.Equal(t, translator.LLMTokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}, usage)
+	})
+	t.Run("response error", func(t *testing.T) {
+		c := &customTranslator{custom: &fakeCustomTranslator{retErr: errors.New("boom")}}
+		_, _, _, err := c.ResponseBody(nil, bytes.NewReader(nil), true)
+		require.ErrorContains(t, err, "custom translator failed to translate the response body: boom")
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// DebugHandler returns the [http.Handler] that serves the debugging information of the server.
+//
+// This is meant to be served on a separate, non-public address.
+func (s *Server) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/concurrency", s.handleDebugConcurrency)
+	mux.HandleFunc("GET /debug/counters", s.handleDebugCounters)
+	mux.HandleFunc("GET /debug/apikeys", s.handleDebugAPIKeys)
+	mux.HandleFunc("GET /debug/config", s.handleDebugConfig)
+	mux.HandleFunc("GET /debug/dump", s.handleDebugDumpStatus)
+	mux.HandleFunc("POST /debug/dump", s.handleDebugDump)
+	if s.debugTranslate {
+		mux.HandleFunc("POST /debug/translate", s.handleDebugTranslate)
+	}
+	return mux
+}
+
+// handleDebugConcurrency serves the current number of in-flight requests per client identity.
+func (s *Server) handleDebugConcurrency(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.concurrencyLimiter.snapshot()); err != nil {
+		s.logger.Error("cannot encode the concurrency snapshot", "error", err)
+	}
+}
+
+// debugCounters is the response body of the counters debugging endpoint.
+type debugCounters struct {
+	// StreamBufferOverflows is the number of the streaming responses aborted because of exceeding the buffering limit.
+	StreamBufferOverflows uint64 `json:"streamBufferOverflows"`
+	// StreamExceptions is the number of the streaming responses terminated by an exception from the upstream.
+	StreamExceptions uint64 `json:"streamExceptions"`
+	// NoMatchingRules is the number of the requests rejected because no rule matches them.
+	NoMatchingRules uint64 `json:"noMatchingRules"`
+	// BufferedBytes is the current number of the bytes of the request bodies buffered by the in-flight requests.
+	BufferedBytes int64 `json:"bufferedBytes"`
+	// BufferLimitRejections is the number of the requests rejected because of exceeding the buffering limit.
+	BufferLimitRejections uint64 `json:"bufferLimitRejections"`
+	// StateStoreFallbacks is the number of the requests limited by the counters local to the replica because
+	// the state store failed.
+	StateStoreFallbacks uint64 `json:"stateStoreFallbacks"`
+}
+
+// handleDebugCounters serves the counters of the server.
+func (s *Server) handleDebugCounters(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	counters := debugCounters{
+		StreamBufferOverflows: s.streamBufferOverflows.Load(),
+		StreamExceptions:      s.streamExceptions.Load(),
+		NoMatchingRules:       s.noMatchingRules.Load(),
+		BufferedBytes:         s.bufferLimiter.buffered.Load(),
+		BufferLimitRejections: s.bufferLimiter.rejections.Load(),
+		StateStoreFallbacks:   s.concurrencyLimiter.stateStoreFallbacks.Load(),
+	}
+	if err := json.NewEncoder(w).Encode(counters); err != nil {
+		s.logger.Error("cannot encode the counters", "error", err)
+	}
+}
+
+// handleDebugAPIKeys serves the statistics of the API keys rotated per backend, keyed by the backend name.
+func (s *Server) handleDebugAPIKeys(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	stats := map[string][]backendauth.APIKeyStats{}
+	if config := s.config.Load(); config != nil {
+		for name, h := range config.backendAuthHandlers {
+			if r, ok := h.(backendauth.APIKeyStatsReporter); ok {
+				stats[name] = r.APIKeyStats()
+			}
+		}
+	}
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		s.logger.Error("cannot encode the API key stats", "error", err)
+	}
+}
+
+// debugConfig is the response body of the config debugging endpoint.
+type debugConfig struct {
+	// UUID is the UUID of the configuration currently loaded.
+	UUID string `json:"uuid"`
+	// LoadedAt is the time when the configuration was loaded.
+	LoadedAt time.Time `json:"loadedAt"`
+	// Schema is the API schema of the clients.
+	Schema filterapi.VersionedAPISchema `json:"schema"`
+	// Rules is the rules of the configuration in the order of the configuration.
+	Rules []debugConfigRule `json:"rules,omitempty"`
+}
+
+// debugConfigRule is the [filterapi.RouteRule] in the response body of the config debugging endpoint.
+type debugConfigRule struct {
+	Headers  []filterapi.HeaderMatch `json:"headers,omitempty"`
+	Priority int                     `json:"priority,omitempty"`
+	Default  bool                    `json:"default,omitempty"`
+	Backends []debugConfigBackend    `json:"backends"`
+}
+
+// debugConfigBackend is the [filterapi.Backend] in the response body of the config debugging endpoint. The
+// credentials and the other details of the backend are not included.
+type debugConfigBackend struct {
+	Name   string                       `json:"name"`
+	Schema filterapi.VersionedAPISchema `json:"schema"`
+	Weight int                          `json:"weight"`
+	// Translators is the names of the translators selected for the backend, keyed by the API of the requests, that
+	// is, "chatCompletions" and "converse". The APIs not supported by the schema of the backend are omitted.
+	Translators map[string]string `json:"translators,omitempty"`
+}
+
+// handleDebugConfig serves the configuration currently loaded, which can differ from the one in the API server while
+// the configuration is being rolled out.
+func (s *Server) handleDebugConfig(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("content-type", "application/json")
+	var body debugConfig
+	if config := s.config.Load(); config != nil {
+		body = newDebugConfig(config)
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("cannot encode the config", "error", err)
+	}
+}
+
+// newDebugConfig returns the response body of the config debugging endpoint for the configuration.
+func newDebugConfig(config *processorConfig) debugConfig {
+	body := debugConfig{UUID: config.uuid, LoadedAt: config.loadedAt, Schema: config.schema}
+	for i := range config.rules {
+		rule := &config.rules[i]
+		r := debugConfigRule{Headers: rule.Headers, Priority: rule.Priority, Default: rule.Default, Backends: []debugConfigBackend{}}
+		for j := range rule.Backends {
+			b := &rule.Backends[j]
+			backend := debugConfigBackend{Name: b.Name, Schema: b.Schema, Weight: b.Weight, Translators: map[string]string{}}
+			if t, err := newChatCompletionTranslator(config.schema, "/v1/chat/completions", b, config.maxStreamBufferSize); err == nil {
+				backend.Translators["chatCompletions"] = translatorName(t)
+			}
+			if t, err := newConverseTranslator(b); err == nil {
+				backend.Translators["converse"] = translatorName(t)
+			}
+			r.Backends = append(r.Backends, backend)
+		}
+		body.Rules = append(body.Rules, r)
+	}
+	return body
+}
+
+// translatorName returns the name of the translator, which is the name of its type, or "custom" for the one
+// provided by [x.NewCustomTranslatorFactory].
+func translatorName(t translator.Translator) string {
+	if _, ok := t.(*customTranslator); ok {
+		return "custom"
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", t), "*translator.")
+}
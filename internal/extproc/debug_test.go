@@ -0,0 +1,152 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+func TestServer_DebugHandler(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	_, ok := s.concurrencyLimiter.acquire("foo", true, 0)
+	require.True(t, ok)
+	s.streamBufferOverflows.Add(2)
+	s.streamExceptions.Add(5)
+	s.noMatchingRules.Add(6)
+	require.True(t, s.bufferLimiter.reserve(100, 0))
+	s.bufferLimiter.rejections.Add(4)
+	s.concurrencyLimiter.stateStoreFallbacks.Add(7)
+
+	t.Run("concurrency", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/concurrency", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("content-type"))
+		require.JSONEq(t, `{"streaming":{"foo":1},"nonStreaming":{}}`, rec.Body.String())
+	})
+	t.Run("counters", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/counters", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("content-type"))
+		require.JSONEq(t, `{"streamBufferOverflows":2,"streamExceptions":5,"noMatchingRules":6,"bufferedBytes":100,"bufferLimitRejections":4,"stateStoreFallbacks":7}`, rec.Body.String())
+	})
+	t.Run("apikeys", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/apikeys", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{}`, rec.Body.String())
+
+		keyDir := t.TempDir()
+		keyFile := filepath.Join(keyDir, "apiKey-0")
+		require.NoError(t, os.WriteFile(keyFile, []byte("secret"), 0o600))
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			Rules: []filterapi.RouteRule{{Backends: []filterapi.Backend{{
+				Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1,
+				Auth: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Directory: keyDir, Weights: []int{2}}},
+			}}}},
+		}))
+		rec = httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/apikeys", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"openai":[{"index":0,"weight":2,"requests":0,"failures":0,"coolingDown":false}]}`, rec.Body.String())
+	})
+	t.Run("config", func(t *testing.T) {
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{UUID: "some-uuid"}))
+		loadedAt := s.config.Load().loadedAt
+		s.config.Load().loadedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		require.False(t, loadedAt.IsZero())
+
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("content-type"))
+		require.JSONEq(t, `{"uuid":"some-uuid","loadedAt":"2025-01-02T03:04:05Z","schema":{"name":""}}`, rec.Body.String())
+
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			UUID:   "other-uuid",
+			Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			Rules: []filterapi.RouteRule{
+				{
+					Headers:  []filterapi.HeaderMatch{{Name: "x-ai-eg-model", Value: "claude"}},
+					Priority: 1,
+					Backends: []filterapi.Backend{
+						{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1},
+						{
+							Name: "bedrock-invoke", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock},
+							AWSBedrock: &filterapi.AWSBedrockConfig{BedrockAPI: filterapi.AWSBedrockAPIInvokeModel},
+						},
+					},
+				},
+				{
+					Default: true,
+					Backends: []filterapi.Backend{
+						{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1},
+						{Name: "cohere", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaCohere}, Weight: 1},
+					},
+				},
+			},
+		}))
+		s.config.Load().loadedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		rec = httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{
+  "uuid": "other-uuid",
+  "loadedAt": "2025-01-02T03:04:05Z",
+  "schema": {"name": "OpenAI"},
+  "rules": [
+    {
+      "headers": [{"name": "x-ai-eg-model", "value": "claude"}],
+      "priority": 1,
+      "backends": [
+        {
+          "name": "bedrock", "schema": {"name": "AWSBedrock"}, "weight": 1,
+          "translators": {"chatCompletions": "openAIToAWSBedrockTranslatorV1ChatCompletion", "converse": "awsBedrockToAWSBedrockTranslatorConverse"}
+        },
+        {
+          "name": "bedrock-invoke", "schema": {"name": "AWSBedrock"}, "weight": 0,
+          "translators": {"chatCompletions": "openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion", "converse": "awsBedrockToAWSBedrockTranslatorConverse"}
+        }
+      ]
+    },
+    {
+      "default": true,
+      "backends": [
+        {
+          "name": "openai", "schema": {"name": "OpenAI"}, "weight": 1,
+          "translators": {"chatCompletions": "openAIToOpenAITranslatorV1ChatCompletion", "converse": "awsBedrockToOpenAITranslatorConverse"}
+        },
+        {"name": "cohere", "schema": {"name": "Cohere"}, "weight": 1, "translators": {"chatCompletions": "openAIToCohereTranslatorV1ChatCompletion"}}
+      ]
+    }
+  ]
+}`, rec.Body.String())
+	})
+	t.Run("not found", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/unknown", nil))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func Test_translatorName(t *testing.T) {
+	require.Equal(t, "openAIToOpenAITranslatorV1ChatCompletion", translatorName(translator.NewChatCompletionOpenAIToOpenAITranslator()))
+	require.Equal(t, "custom", translatorName(&customTranslator{}))
+}
@@ -0,0 +1,206 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+// debugTranslateRequest is the request body of the translation dry-run debugging endpoint.
+type debugTranslateRequest struct {
+	// InputSchema is the API schema of the client, either OpenAI for the chat completions or AWSBedrock for the
+	// Converse API.
+	InputSchema filterapi.VersionedAPISchema `json:"inputSchema"`
+	// OutputSchema is the API schema of the backend.
+	OutputSchema filterapi.VersionedAPISchema `json:"outputSchema"`
+	// Direction is either "request" to translate the request to the backend, or "response" to translate the
+	// response from the backend.
+	Direction string `json:"direction"`
+	// Headers is the request headers for the "request" direction, or the response headers for the "response"
+	// direction. The ":path" request header defaults to the one of the input schema, and the ":status" response
+	// header to "200".
+	Headers map[string]string `json:"headers,omitempty"`
+	// Body is the body to translate.
+	Body json.RawMessage `json:"body"`
+	// RequestBody is the body of the original request for the "response" direction, which is translated first so
+	// that the translator knows the model and whether the response is streamed. Optional.
+	RequestBody json.RawMessage `json:"requestBody,omitempty"`
+}
+
+// debugTranslateResponse is the response body of the translation dry-run debugging endpoint.
+type debugTranslateResponse struct {
+	// SetHeaders is the headers set by the translator.
+	SetHeaders map[string]string `json:"setHeaders,omitempty"`
+	// RemoveHeaders is the headers removed by the translator.
+	RemoveHeaders []string `json:"removeHeaders,omitempty"`
+	// Body is the translated body, which is the raw JSON if the body is JSON, or the string otherwise, for example,
+	// the server-sent events. Omitted when the body is not mutated.
+	Body any `json:"body,omitempty"`
+	// TokenUsage is the token usage extracted from the response body. Omitted for the "request" direction.
+	TokenUsage *debugTokenUsage `json:"tokenUsage,omitempty"`
+}
+
+// debugTokenUsage is the JSON representation of [translator.LLMTokenUsage].
+type debugTokenUsage struct {
+	InputTokens           uint32 `json:"inputTokens"`
+	OutputTokens          uint32 `json:"outputTokens"`
+	TotalTokens           uint32 `json:"totalTokens"`
+	CacheReadInputTokens  uint32 `json:"cacheReadInputTokens"`
+	CacheWriteInputTokens uint32 `json:"cacheWriteInputTokens"`
+}
+
+// handleDebugTranslate runs the translator selected for the input and output schemas in the same way as the
+// processors on the given request or response, and serves the mutations and the token usage.
+func (s *Server) handleDebugTranslate(w http.ResponseWriter, r *http.Request) {
+	var req debugTranslateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	res, err := s.dryRunTranslate(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err = json.NewEncoder(w).Encode(res); err != nil {
+		s.logger.Error("cannot encode the translation", "error", err)
+	}
+}
+
+// dryRunTranslate translates the request or the response of the debugTranslateRequest.
+func (s *Server) dryRunTranslate(req *debugTranslateRequest) (*debugTranslateResponse, error) {
+	maxStreamBufferSize := translator.DefaultMaxStreamBufferSize
+	if config := s.config.Load(); config != nil {
+		maxStreamBufferSize = config.maxStreamBufferSize
+	}
+	backend := &filterapi.Backend{Name: "debug", Schema: req.OutputSchema}
+
+	var (
+		t           translator.Translator
+		requestBody func(raw []byte) (translator.RequestBody, error)
+		err         error
+	)
+	switch req.InputSchema.Name {
+	case filterapi.APISchemaOpenAI:
+		path := cmp.Or(req.requestHeader(":path"), "/v1/chat/completions")
+		if t, err = newChatCompletionTranslator(req.InputSchema, path, backend, maxStreamBufferSize); err != nil {
+			return nil, err
+		}
+		requestBody = func(raw []byte) (translator.RequestBody, error) {
+			_, body, err := parseOpenAIChatCompletionBody(&extprocv3.HttpBody{Body: raw})
+			return body, err
+		}
+	case filterapi.APISchemaAWSBedrock:
+		model, err := modelFromConversePath(cmp.Or(req.requestHeader(":path"), "/model/debug/converse"))
+		if err != nil {
+			return nil, err
+		}
+		if t, err = newConverseTranslator(backend); err != nil {
+			return nil, err
+		}
+		requestBody = func(raw []byte) (translator.RequestBody, error) {
+			var body awsbedrock.ConverseInput
+			if err := json.Unmarshal(raw, &body); err != nil {
+				return nil, err
+			}
+			body.ModelID = &model
+			return &body, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported input schema: %s", req.InputSchema.Name)
+	}
+
+	res := &debugTranslateResponse{}
+	switch req.Direction {
+	case "request":
+		body, err := requestBody(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse request body: %w", err)
+		}
+		headerMutation, bodyMutation, _, err := t.RequestBody(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform request: %w", err)
+		}
+		res.setMutations(headerMutation, bodyMutation)
+	case "response":
+		if len(req.RequestBody) > 0 {
+			body, err := requestBody(req.RequestBody)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse request body: %w", err)
+			}
+			if _, _, _, err = t.RequestBody(body); err != nil {
+				return nil, fmt.Errorf("failed to transform request: %w", err)
+			}
+		}
+		headers := make(map[string]string, len(req.Headers)+1)
+		for k, v := range req.Headers {
+			headers[k] = v
+		}
+		if _, ok := headers[":status"]; !ok {
+			headers[":status"] = "200"
+		}
+		headerMutation, err := t.ResponseHeaders(headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response headers: %w", err)
+		}
+		res.setMutations(headerMutation, nil)
+		var bodyMutation *extprocv3.BodyMutation
+		var tokenUsage translator.LLMTokenUsage
+		headerMutation, bodyMutation, tokenUsage, err = t.ResponseBody(headers, bytes.NewReader(req.Body), true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform response body: %w", err)
+		}
+		res.TokenUsage = &debugTokenUsage{
+			InputTokens:           tokenUsage.InputTokens,
+			OutputTokens:          tokenUsage.OutputTokens,
+			TotalTokens:           tokenUsage.TotalTokens,
+			CacheReadInputTokens:  tokenUsage.CacheReadInputTokens,
+			CacheWriteInputTokens: tokenUsage.CacheWriteInputTokens,
+		}
+		res.setMutations(headerMutation, bodyMutation)
+	default:
+		return nil, errors.New(`direction must be either "request" or "response"`)
+	}
+	return res, nil
+}
+
+// requestHeader returns the request header of the key, which is only set for the "request" direction.
+func (req *debugTranslateRequest) requestHeader(key string) string {
+	if req.Direction != "request" {
+		return ""
+	}
+	return req.Headers[key]
+}
+
+// setMutations merges the header and body mutations to the response.
+func (res *debugTranslateResponse) setMutations(headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation) {
+	for _, h := range headerMutation.GetSetHeaders() {
+		if res.SetHeaders == nil {
+			res.SetHeaders = make(map[string]string)
+		}
+		res.SetHeaders[h.GetHeader().GetKey()] = cmp.Or(string(h.GetHeader().GetRawValue()), h.GetHeader().GetValue())
+	}
+	res.RemoveHeaders = append(res.RemoveHeaders, headerMutation.GetRemoveHeaders()...)
+	if body := bodyMutation.GetBody(); body != nil {
+		if json.Valid(body) {
+			res.Body = json.RawMessage(body)
+		} else {
+			res.Body = string(body)
+		}
+	}
+}
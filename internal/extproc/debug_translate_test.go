@@ -0,0 +1,110 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestServer_handleDebugTranslate(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	translate := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/translate", strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, translate(t, `{}`).Code)
+	})
+
+	s.SetDebugTranslate(true)
+	t.Run("bedrock request", func(t *testing.T) {
+		rec := translate(t, `{"inputSchema":{"name":"OpenAI"},"outputSchema":{"name":"AWSBedrock"},"direction":"request",
+"body":{"model":"anthropic.claude-3-haiku","messages":[{"role":"user","content":"hello"}],"max_tokens":10}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("content-type"))
+		require.JSONEq(t, `{
+  "setHeaders": {":path": "/model/anthropic.claude-3-haiku/converse", "content-length": "109"},
+  "body": {"inferenceConfig":{"maxTokens":10},"messages":[{"content":[{"text":"hello"}],"role":"user"}],"modelId":null}
+}`, rec.Body.String())
+	})
+	t.Run("bedrock response", func(t *testing.T) {
+		rec := translate(t, `{"inputSchema":{"name":"OpenAI"},"outputSchema":{"name":"AWSBedrock"},"direction":"response",
+"headers":{":status":"200","content-type":"application/json"},
+"requestBody":{"model":"anthropic.claude-3-haiku","messages":[{"role":"user","content":"hello"}]},
+"body":{"output":{"message":{"role":"assistant","content":[{"text":"hi"}]}},"stopReason":"end_turn",
+"usage":{"inputTokens":3,"outputTokens":1,"totalTokens":4,"cacheReadInputTokens":2}}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res struct {
+			SetHeaders map[string]string             `json:"setHeaders"`
+			Body       openai.ChatCompletionResponse `json:"body"`
+			TokenUsage debugTokenUsage               `json:"tokenUsage"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Contains(t, res.SetHeaders, "content-length")
+		// The model is known from the request body translated first.
+		require.Equal(t, "anthropic.claude-3-haiku", res.Body.Model)
+		require.Len(t, res.Body.Choices, 1)
+		require.Equal(t, "hi", *res.Body.Choices[0].Message.Content)
+		require.Equal(t, openai.ChatCompletionChoicesFinishReasonStop, res.Body.Choices[0].FinishReason)
+		require.Equal(t, debugTokenUsage{InputTokens: 3, OutputTokens: 1, TotalTokens: 4, CacheReadInputTokens: 2}, res.TokenUsage)
+	})
+	t.Run("bedrock error response", func(t *testing.T) {
+		rec := translate(t, `{"inputSchema":{"name":"OpenAI"},"outputSchema":{"name":"AWSBedrock"},"direction":"response",
+"headers":{":status":"400","content-type":"application/json","x-amzn-errortype":"ValidationException"},
+"body":{"message":"invalid model"}}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res struct {
+			Body       openai.Error    `json:"body"`
+			TokenUsage debugTokenUsage `json:"tokenUsage"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Equal(t, "ValidationException", res.Body.Error.Type)
+		require.Equal(t, "invalid model", res.Body.Error.Message)
+		require.Zero(t, res.TokenUsage)
+	})
+	for _, tc := range []struct {
+		name, body, expBody string
+	}{
+		{
+			name:    "invalid json",
+			body:    `{`,
+			expBody: "invalid request body: unexpected EOF\n",
+		},
+		{
+			name:    "unsupported input schema",
+			body:    `{"inputSchema":{"name":"Cohere"},"outputSchema":{"name":"OpenAI"},"direction":"request","body":{}}`,
+			expBody: "unsupported input schema: Cohere\n",
+		},
+		{
+			name:    "unsupported output schema",
+			body:    `{"inputSchema":{"name":"AWSBedrock"},"outputSchema":{"name":"Cohere"},"direction":"request","body":{}}`,
+			expBody: "unsupported API schema: backend={Cohere }\n",
+		},
+		{
+			name:    "invalid direction",
+			body:    `{"inputSchema":{"name":"OpenAI"},"outputSchema":{"name":"AWSBedrock"},"direction":"both","body":{}}`,
+			expBody: "direction must be either \"request\" or \"response\"\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := translate(t, tc.body)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+			require.Equal(t, tc.expBody, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// applyHeaderModifications applies the header modifications in order to the request headers and the header mutation.
+// The substitutions "${model}" and "${backend}" in the header values are replaced with the model and backend names.
+//
+// The added header is set to the value joined with the existing value, so every modification is applied as
+// the overwrite of the header, replacing the earlier mutation of the same header.
+func applyHeaderModifications(modifications []*filterapi.HeaderModifications, model, backend string,
+	requestHeaders map[string]string, headerMutation *extprocv3.HeaderMutation,
+) {
+	replacer := strings.NewReplacer("${model}", model, "${backend}", backend)
+	set := func(name, value string) {
+		requestHeaders[name] = value
+		headerMutation.RemoveHeaders = deleteHeaderName(headerMutation.RemoveHeaders, name)
+		headerMutation.SetHeaders = append(deleteSetHeader(headerMutation.SetHeaders, name), &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: name, RawValue: []byte(value)},
+		})
+	}
+	for _, m := range modifications {
+		if m == nil {
+			continue
+		}
+		for _, h := range m.Add {
+			value := replacer.Replace(h.Value)
+			if existing, ok := requestHeaders[h.Name]; ok && existing != "" {
+				value = existing + "," + value
+			}
+			set(h.Name, value)
+		}
+		for _, h := range m.Set {
+			set(h.Name, replacer.Replace(h.Value))
+		}
+		for _, name := range m.Remove {
+			delete(requestHeaders, name)
+			headerMutation.SetHeaders = deleteSetHeader(headerMutation.SetHeaders, name)
+			headerMutation.RemoveHeaders = append(deleteHeaderName(headerMutation.RemoveHeaders, name), name)
+		}
+	}
+}
+
+// openAIHeaderModifications returns the header modifications setting the "OpenAI-Organization" and "OpenAI-Project"
+// headers of the OpenAI configuration of the backend, or nil if not configured.
+func openAIHeaderModifications(config *filterapi.OpenAIConfig) *filterapi.HeaderModifications {
+	if config == nil {
+		return nil
+	}
+	m := &filterapi.HeaderModifications{}
+	if config.Organization != "" {
+		m.Set = append(m.Set, filterapi.Header{Name: "OpenAI-Organization", Value: config.Organization})
+	}
+	if config.Project != "" {
+		m.Set = append(m.Set, filterapi.Header{Name: "OpenAI-Project", Value: config.Project})
+	}
+	return m
+}
+
+// resolveAuthHeaderConflicts drops the mutations of the headers set by the backend auth handler other than the ones
+// set by the handler itself, so that the auth headers are never overridden or removed by the header modifications.
+//
+// authStart is the number of the set headers in the header mutation before the auth handler was invoked.
+func resolveAuthHeaderConflicts(headerMutation *extprocv3.HeaderMutation, authStart int) {
+	authHeaders := headerMutation.SetHeaders[authStart:]
+	if len(authHeaders) == 0 {
+		return
+	}
+	setHeaders := headerMutation.SetHeaders[:authStart]
+	for _, h := range authHeaders {
+		setHeaders = deleteSetHeader(setHeaders, h.Header.Key)
+		headerMutation.RemoveHeaders = deleteHeaderName(headerMutation.RemoveHeaders, h.Header.Key)
+	}
+	headerMutation.SetHeaders = append(setHeaders, authHeaders...)
+}
+
+// deleteSetHeader returns the set headers without the ones of the given name, compared case-insensitively.
+func deleteSetHeader(headers []*corev3.HeaderValueOption, name string) []*corev3.HeaderValueOption {
+	ret := headers[:0]
+	for _, h := range headers {
+		if !strings.EqualFold(h.Header.Key, name) {
+			ret = append(ret, h)
+		}
+	}
+	return ret
+}
+
+// deleteHeaderName returns the header names without the given name, compared case-insensitively.
+func deleteHeaderName(names []string, name string) []string {
+	ret := names[:0]
+	for _, n := range names {
+		if !strings.EqualFold(n, name) {
+			ret = append(ret, n)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,150 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func setHeaders(hm *extprocv3.HeaderMutation) map[string]string {
+	ret := make(map[string]string)
+	for _, h := range hm.SetHeaders {
+		ret[h.Header.Key] = string(h.Header.RawValue)
+	}
+	return ret
+}
+
+func TestApplyHeaderModifications(t *testing.T) {
+	requestHeaders := map[string]string{
+		"anthropic-beta": "tools-2024-04-04",
+		"x-internal":     "secret",
+		"x-team":         "foo",
+	}
+	headerMutation := &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "x-internal", RawValue: []byte("secret")}}},
+	}
+	applyHeaderModifications([]*filterapi.HeaderModifications{
+		{
+			Add: []filterapi.Header{{Name: "anthropic-beta", Value: "prompt-caching-2024-07-31"}},
+			Set: []filterapi.Header{{Name: "x-team", Value: "rule"}},
+		},
+		nil,
+		{
+			Set:    []filterapi.Header{{Name: "x-team", Value: "backend"}, {Name: "x-route", Value: "${model}@${backend}"}},
+			Remove: []string{"x-internal"},
+		},
+	}, "claude", "bedrock.default", requestHeaders, headerMutation)
+
+	require.Equal(t, map[string]string{
+		"anthropic-beta": "tools-2024-04-04,prompt-caching-2024-07-31",
+		"x-team":         "backend",
+		"x-route":        "claude@bedrock.default",
+	}, requestHeaders)
+	require.Equal(t, map[string]string{
+		"anthropic-beta": "tools-2024-04-04,prompt-caching-2024-07-31",
+		"x-team":         "backend",
+		"x-route":        "claude@bedrock.default",
+	}, setHeaders(headerMutation))
+	require.Equal(t, []string{"x-internal"}, headerMutation.RemoveHeaders)
+
+	// Setting the removed header again cancels the removal.
+	applyHeaderModifications([]*filterapi.HeaderModifications{
+		{Set: []filterapi.Header{{Name: "x-internal", Value: "again"}}},
+	}, "claude", "bedrock.default", requestHeaders, headerMutation)
+	require.Empty(t, headerMutation.RemoveHeaders)
+	require.Equal(t, "again", setHeaders(headerMutation)["x-internal"])
+}
+
+func TestResolveAuthHeaderConflicts(t *testing.T) {
+	headerMutation := &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: "authorization", RawValue: []byte("Bearer user")}},
+			{Header: &corev3.HeaderValue{Key: "x-team", RawValue: []byte("foo")}},
+			{Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte("Bearer auth")}},
+		},
+		RemoveHeaders: []string{"x-amz-date", "x-internal"},
+	}
+	resolveAuthHeaderConflicts(headerMutation, 2)
+	require.Equal(t, []string{"x-amz-date", "x-internal"}, headerMutation.RemoveHeaders)
+	require.Len(t, headerMutation.SetHeaders, 2)
+	require.Equal(t, map[string]string{"x-team": "foo", "Authorization": "Bearer auth"}, setHeaders(headerMutation))
+
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders:    []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "Authorization", RawValue: []byte("Bearer auth")}}},
+		RemoveHeaders: []string{"authorization"},
+	}
+	resolveAuthHeaderConflicts(headerMutation, 0)
+	require.Empty(t, headerMutation.RemoveHeaders)
+	require.Equal(t, map[string]string{"Authorization": "Bearer auth"}, setHeaders(headerMutation))
+}
+
+func TestChatCompletion_headerModifications(t *testing.T) {
+	apiKeyFile := t.TempDir() + "/apiKey"
+	require.NoError(t, os.WriteFile(apiKeyFile, []byte("some-api-key"), 0o600))
+
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{
+					Name:   "openai",
+					Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+					Weight: 1,
+					Auth:   &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Filename: apiKeyFile}},
+					HeaderModifications: &filterapi.HeaderModifications{
+						Add:    []filterapi.Header{{Name: "x-tags", Value: "backend=${backend}"}},
+						Set:    []filterapi.Header{{Name: "authorization", Value: "Bearer overridden"}},
+						Remove: []string{"x-internal"},
+					},
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o"}},
+				HeaderModifications: &filterapi.HeaderModifications{
+					Set: []filterapi.Header{{Name: "x-tags", Value: "model=${model}"}},
+				},
+			},
+		},
+	}))
+	p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{
+		":path": "/v1/chat/completions", ":method": "POST", "x-internal": "secret", "x-tags": "client",
+	}, slog.Default())
+	require.NoError(t, err)
+	resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+		Body: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+	})
+	require.NoError(t, err)
+	headerMutation := resp.GetRequestBody().GetResponse().GetHeaderMutation()
+	require.Equal(t, "model=gpt-4o,backend=openai", headerMutationValue(headerMutation, "x-tags"))
+	require.Equal(t, []string{"x-internal"}, headerMutation.RemoveHeaders)
+	// The authorization header set by the APIKey auth takes precedence.
+	require.Empty(t, headerMutationValue(headerMutation, "authorization"))
+	require.Equal(t, "Bearer some-api-key", headerMutationValue(headerMutation, "Authorization"))
+}
+
+func TestOpenAIHeaderModifications(t *testing.T) {
+	require.Nil(t, openAIHeaderModifications(nil))
+	require.Empty(t, openAIHeaderModifications(&filterapi.OpenAIConfig{}).Set)
+	require.Equal(t, []filterapi.Header{{Name: "OpenAI-Project", Value: "proj_bar"}},
+		openAIHeaderModifications(&filterapi.OpenAIConfig{Project: "proj_bar"}).Set)
+
+	requestHeaders := map[string]string{}
+	headerMutation := &extprocv3.HeaderMutation{}
+	applyHeaderModifications([]*filterapi.HeaderModifications{
+		nil, nil, openAIHeaderModifications(&filterapi.OpenAIConfig{Organization: "org-foo", Project: "proj_bar"}),
+	}, "gpt-4o", "openai", requestHeaders, headerMutation)
+	require.Equal(t, map[string]string{"OpenAI-Organization": "org-foo", "OpenAI-Project": "proj_bar"}, setHeaders(headerMutation))
+}
@@ -0,0 +1,114 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+const (
+	// metadataEstimatedInputTokensKey is the dynamic metadata key of the input tokens estimated before the request is
+	// forwarded, and metadataEstimatedInputTokensDeltaKey is the one of the actual input tokens in excess of the estimate.
+	metadataEstimatedInputTokensKey      = "estimated_input_tokens"
+	metadataEstimatedInputTokensDeltaKey = "estimated_input_tokens_delta"
+
+	// charactersPerToken is the rule of thumb of the number of characters per token of the English text with the
+	// tokenizers of the major models.
+	charactersPerToken = 4
+	// tokensPerMessage is the number of tokens counted for each message for the role and the delimiters.
+	tokensPerMessage = 4
+	// tokensPerMediaPart is the number of tokens counted for each image or audio content part, which is the cost of
+	// the low detail image of OpenAI. The size of the encoded data is not relevant to the number of tokens.
+	tokensPerMediaPart = 85
+)
+
+// defaultInputTokenEstimator implements [x.InputTokenEstimator] with the rule of thumb of characters per token.
+type defaultInputTokenEstimator struct{}
+
+// estimationRequest is the subset of the chat completion request relevant to the estimation.
+type estimationRequest struct {
+	Messages []struct {
+		Content   json.RawMessage `json:"content"`
+		ToolCalls json.RawMessage `json:"tool_calls"`
+	} `json:"messages"`
+	Tools json.RawMessage `json:"tools"`
+}
+
+// EstimateInputTokens implements [x.InputTokenEstimator].
+func (defaultInputTokenEstimator) EstimateInputTokens(_ string, body []byte) (uint32, error) {
+	var req estimationRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 0, fmt.Errorf("failed to parse the request body: %w", err)
+	}
+	var characters, tokens int
+	for i := range req.Messages {
+		msg := &req.Messages[i]
+		tokens += tokensPerMessage
+		characters += len(msg.ToolCalls)
+		var text string
+		var parts []struct {
+			Type    string `json:"type"`
+			Text    string `json:"text"`
+			Refusal string `json:"refusal"`
+		}
+		var part struct {
+			Text    string `json:"text"`
+			Refusal string `json:"refusal"`
+		}
+		switch {
+		case len(msg.Content) == 0:
+		case json.Unmarshal(msg.Content, &text) == nil:
+			characters += utf8.RuneCountInString(text)
+		case json.Unmarshal(msg.Content, &parts) == nil:
+			for _, p := range parts {
+				switch p.Type {
+				case string(openai.ChatCompletionContentPartImageTypeImageURL), string(openai.ChatCompletionContentPartInputAudioTypeInputAudio):
+					tokens += tokensPerMediaPart
+				default:
+					characters += utf8.RuneCountInString(p.Text) + utf8.RuneCountInString(p.Refusal)
+				}
+			}
+		case json.Unmarshal(msg.Content, &part) == nil:
+			// The assistant message content is a single content part.
+			characters += utf8.RuneCountInString(part.Text) + utf8.RuneCountInString(part.Refusal)
+		}
+	}
+	characters += len(req.Tools)
+	tokens += (characters + charactersPerToken - 1) / charactersPerToken
+	return uint32(tokens), nil //nolint:gosec
+}
+
+// estimatedInputTokensMetadata builds the dynamic metadata of the estimated input tokens set when the request is
+// forwarded, so that the rate limit can consume the budget before the response.
+func (c *chatCompletionProcessor) estimatedInputTokensMetadata() *structpb.Struct {
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			c.config.metadataNamespace: structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+				metadataEstimatedInputTokensKey: structpb.NewNumberValue(float64(*c.estimatedInputTokens)),
+			}}),
+		},
+	}
+}
+
+// setEstimatedInputTokensDeltaMetadata sets the actual input tokens in excess of the estimated ones to the metadata,
+// if the input tokens were estimated. This is set only once at the end of the response so that the rate limit using it
+// as the request cost counts it once.
+//
+// The delta is clamped at zero since the rate limit can only add to the consumed budget: When the estimate was too
+// large, the excess is not refunded, and the budget is only recovered when the rate limit window resets.
+func (c *chatCompletionProcessor) setEstimatedInputTokensDeltaMetadata(metadata map[string]*structpb.Value) {
+	if c.estimatedInputTokens == nil {
+		return
+	}
+	delta := max(int64(c.costs.InputTokens)-int64(*c.estimatedInputTokens), 0)
+	metadata[metadataEstimatedInputTokensDeltaKey] = structpb.NewNumberValue(float64(delta))
+}
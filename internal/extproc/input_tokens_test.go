@@ -0,0 +1,149 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
+)
+
+func TestDefaultInputTokenEstimator(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		body   string
+		exp    uint32
+		expErr string
+	}{
+		{name: "no messages", body: `{"model":"gpt","messages":[]}`},
+		{
+			name: "text",
+			body: `{"model":"gpt","messages":[{"role":"user","content":"Hello, world!"}]}`,
+			// 4 for the message and 13 characters.
+			exp: 8,
+		},
+		{
+			name: "content parts",
+			body: `{"model":"gpt","messages":[{"role":"system","content":"You are helpful."},{"role":"user","content":[` +
+				`{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,dGVzdA=="}}]}]}`,
+			// 4 for each message, 85 for the image and 29 characters.
+			exp: 101,
+		},
+		{
+			name: "assistant and tools",
+			body: `{"model":"gpt","messages":[{"role":"assistant","content":{"type":"text","text":"Sure."},` +
+				`"tool_calls":[{"id":"1","type":"function","function":{"name":"f","arguments":"{}"}}]}],` +
+				`"tools":[{"type":"function","function":{"name":"f"}}]}`,
+			// 4 for the message, 5 characters, 71 characters of the tool calls and 43 characters of the tools.
+			exp: 35,
+		},
+		{name: "invalid", body: `{"messages":`, expErr: "failed to parse the request body"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens, err := defaultInputTokenEstimator{}.EstimateInputTokens("gpt", []byte(tc.body))
+			if tc.expErr != "" {
+				require.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, tokens)
+		})
+	}
+}
+
+type mockInputTokenEstimator struct {
+	tokens uint32
+	err    error
+}
+
+// EstimateInputTokens implements [x.InputTokenEstimator].
+func (m mockInputTokenEstimator) EstimateInputTokens(string, []byte) (uint32, error) {
+	return m.tokens, m.err
+}
+
+func TestChatCompletion_estimatedInputTokens(t *testing.T) {
+	newProcessor := func(t *testing.T, enabled bool, requestCosts []filterapi.LLMRequestCost) *chatCompletionProcessor {
+		s, err := NewServer(slog.Default())
+		require.NoError(t, err)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+			}},
+			MetadataNamespace:   "ai_gateway_llm_ns",
+			LLMRequestCosts:     requestCosts,
+			EstimateInputTokens: enabled,
+		}))
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+		require.NoError(t, err)
+		return p.(*chatCompletionProcessor)
+	}
+	metadata := func(resp *extprocv3.ProcessingResponse) map[string]any {
+		return resp.GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().AsMap()
+	}
+	process := func(t *testing.T, p *chatCompletionProcessor) (reqResp, resResp *extprocv3.ProcessingResponse) {
+		reqResp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"model":"gpt","messages":[{"role":"user","content":"Hello, world!"}]}`),
+		})
+		require.NoError(t, err)
+		_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":status", Value: "200"}, {Key: "content-type", Value: "application/json"},
+		}})
+		require.NoError(t, err)
+		resResp, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{
+			Body: []byte(`{"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`), EndOfStream: true,
+		})
+		require.NoError(t, err)
+		return
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		reqResp, resResp := process(t, newProcessor(t, false, nil))
+		require.Nil(t, reqResp.GetDynamicMetadata())
+		require.NotContains(t, metadata(resResp), metadataEstimatedInputTokensKey)
+		require.NotContains(t, metadata(resResp), metadataEstimatedInputTokensDeltaKey)
+	})
+	t.Run("enabled", func(t *testing.T) {
+		reqResp, resResp := process(t, newProcessor(t, true, nil))
+		require.Equal(t, map[string]any{metadataEstimatedInputTokensKey: float64(8)}, metadata(reqResp))
+		// The estimate is only set when the request is forwarded.
+		require.NotContains(t, metadata(resResp), metadataEstimatedInputTokensKey)
+		require.Equal(t, float64(2), metadata(resResp)[metadataEstimatedInputTokensDeltaKey])
+	})
+	t.Run("with request costs", func(t *testing.T) {
+		reqResp, resResp := process(t, newProcessor(t, true, []filterapi.LLMRequestCost{
+			{Type: filterapi.LLMRequestCostTypeInputToken, MetadataKey: "input"},
+		}))
+		require.Equal(t, map[string]any{metadataEstimatedInputTokensKey: float64(8)}, metadata(reqResp))
+		require.Equal(t, float64(10), metadata(resResp)["input"])
+		require.Equal(t, float64(2), metadata(resResp)[metadataEstimatedInputTokensDeltaKey])
+	})
+	t.Run("custom estimator", func(t *testing.T) {
+		x.CustomInputTokenEstimator = mockInputTokenEstimator{tokens: 100}
+		t.Cleanup(func() { x.CustomInputTokenEstimator = nil })
+		reqResp, resResp := process(t, newProcessor(t, true, nil))
+		require.Equal(t, map[string]any{metadataEstimatedInputTokensKey: float64(100)}, metadata(reqResp))
+		// The overestimate is not refunded.
+		require.Equal(t, float64(0), metadata(resResp)[metadataEstimatedInputTokensDeltaKey])
+	})
+	t.Run("estimation error", func(t *testing.T) {
+		x.CustomInputTokenEstimator = mockInputTokenEstimator{err: errors.New("boom")}
+		t.Cleanup(func() { x.CustomInputTokenEstimator = nil })
+		reqResp, resResp := process(t, newProcessor(t, true, nil))
+		require.Nil(t, reqResp.GetDynamicMetadata())
+		require.NotContains(t, metadata(resResp), metadataEstimatedInputTokensDeltaKey)
+	})
+}
@@ -0,0 +1,93 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"strconv"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// metadataTimeToFirstTokenKey and metadataUpstreamDurationKey are the dynamic metadata keys of the time to the
+	// first response body chunk and of the total duration of the upstream request, in milliseconds.
+	metadataTimeToFirstTokenKey = "time_to_first_token_ms"
+	metadataUpstreamDurationKey = "upstream_duration_ms"
+	// timeToFirstTokenHeaderKey and upstreamDurationHeaderKey are the header or trailer keys of the same values
+	// emitted when EmitLatencyHeaders is enabled.
+	timeToFirstTokenHeaderKey = "x-ai-eg-time-to-first-token-ms"
+	upstreamDurationHeaderKey = "x-ai-eg-upstream-duration-ms"
+)
+
+// upstreamLatency tracks the timings of the request to the backend.
+//
+// The request is forwarded to the backend once the request body is processed since the body is buffered, so that is
+// the start of both the time to first token and the upstream duration.
+type upstreamLatency struct {
+	// requestSent is the time when the request was forwarded to the backend.
+	requestSent time.Time
+	// firstChunk is the time when the first non-empty response body chunk was received.
+	firstChunk time.Time
+	// completed is the time when the end of the response body was received.
+	completed time.Time
+}
+
+// observeResponseBody records the timings of the response body chunk of the given size received at now.
+func (l *upstreamLatency) observeResponseBody(now time.Time, size int, endOfStream bool) {
+	if l.requestSent.IsZero() {
+		return
+	}
+	if l.firstChunk.IsZero() && size > 0 {
+		l.firstChunk = now
+	}
+	if endOfStream {
+		l.completed = now
+	}
+}
+
+// timeToFirstToken returns the time to the first response body chunk, and false if it is not known yet.
+func (l *upstreamLatency) timeToFirstToken() (time.Duration, bool) {
+	if l.firstChunk.IsZero() {
+		return 0, false
+	}
+	return l.firstChunk.Sub(l.requestSent), true
+}
+
+// duration returns the total duration of the upstream request, and false if the response has not completed yet.
+func (l *upstreamLatency) duration() (time.Duration, bool) {
+	if l.completed.IsZero() {
+		return 0, false
+	}
+	return l.completed.Sub(l.requestSent), true
+}
+
+// setMetadata sets the latencies known so far to the metadata.
+func (l *upstreamLatency) setMetadata(metadata map[string]*structpb.Value) {
+	if ttft, ok := l.timeToFirstToken(); ok {
+		metadata[metadataTimeToFirstTokenKey] = structpb.NewNumberValue(float64(ttft.Milliseconds()))
+	}
+	if d, ok := l.duration(); ok {
+		metadata[metadataUpstreamDurationKey] = structpb.NewNumberValue(float64(d.Milliseconds()))
+	}
+}
+
+// headers returns the headers of the latencies known so far.
+func (l *upstreamLatency) headers() []*corev3.HeaderValueOption {
+	var headers []*corev3.HeaderValueOption
+	if ttft, ok := l.timeToFirstToken(); ok {
+		headers = append(headers, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{
+			Key: timeToFirstTokenHeaderKey, RawValue: []byte(strconv.FormatInt(ttft.Milliseconds(), 10)),
+		}})
+	}
+	if d, ok := l.duration(); ok {
+		headers = append(headers, &corev3.HeaderValueOption{Header: &corev3.HeaderValue{
+			Key: upstreamDurationHeaderKey, RawValue: []byte(strconv.FormatInt(d.Milliseconds(), 10)),
+		}})
+	}
+	return headers
+}
@@ -0,0 +1,54 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestUpstreamLatency(t *testing.T) {
+	start := time.Unix(100, 0)
+	t.Run("not sent", func(t *testing.T) {
+		var l upstreamLatency
+		l.observeResponseBody(start, 10, true)
+		_, ok := l.timeToFirstToken()
+		require.False(t, ok)
+		_, ok = l.duration()
+		require.False(t, ok)
+		require.Empty(t, l.headers())
+	})
+	t.Run("streaming", func(t *testing.T) {
+		l := upstreamLatency{requestSent: start}
+		// The empty chunk is not the first token.
+		l.observeResponseBody(start.Add(100*time.Millisecond), 0, false)
+		l.observeResponseBody(start.Add(250*time.Millisecond), 10, false)
+		l.observeResponseBody(start.Add(500*time.Millisecond), 10, false)
+		ttft, ok := l.timeToFirstToken()
+		require.True(t, ok)
+		require.Equal(t, 250*time.Millisecond, ttft)
+		_, ok = l.duration()
+		require.False(t, ok)
+
+		metadata := map[string]*structpb.Value{}
+		l.setMetadata(metadata)
+		require.Equal(t, map[string]*structpb.Value{"time_to_first_token_ms": structpb.NewNumberValue(250)}, metadata)
+
+		l.observeResponseBody(start.Add(1200*time.Millisecond), 0, true)
+		d, ok := l.duration()
+		require.True(t, ok)
+		require.Equal(t, 1200*time.Millisecond, d)
+		l.setMetadata(metadata)
+		require.Equal(t, structpb.NewNumberValue(1200), metadata["upstream_duration_ms"])
+		require.Equal(t, map[string]string{
+			"x-ai-eg-time-to-first-token-ms": "250",
+			"x-ai-eg-upstream-duration-ms":   "1200",
+		}, headers(l.headers()))
+	})
+}
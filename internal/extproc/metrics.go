@@ -0,0 +1,53 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace is the prefix of the names of the metrics of the external processor.
+const metricsNamespace = "ai_gateway_extproc"
+
+// MetricsHandler returns the [http.Handler] that serves the metrics of the server in the Prometheus format.
+//
+// Unlike [Server.DebugHandler], this is meant to be scraped, so it only serves the metrics.
+func (s *Server) MetricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "state_store_fallbacks_total",
+			Help:      "Number of the requests limited by the counters local to the replica because the state store failed.",
+		}, func() float64 { return float64(s.concurrencyLimiter.stateStoreFallbacks.Load()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "state_store_breaker_open",
+			Help:      "1 while the state store is skipped after a failure, otherwise 0.",
+		}, func() float64 {
+			if store := s.currentStateStore(); store != nil && store.breakerOpen() {
+				return 1
+			}
+			return 0
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "stream_buffer_overflows_total",
+			Help:      "Number of the streaming responses aborted because of exceeding the buffering limit.",
+		}, func() float64 { return float64(s.streamBufferOverflows.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "buffer_limit_rejections_total",
+			Help:      "Number of the requests rejected because of exceeding the buffering limit.",
+		}, func() float64 { return float64(s.bufferLimiter.rejections.Load()) }),
+	)
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return mux
+}
@@ -0,0 +1,43 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestServer_MetricsHandler(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.concurrencyLimiter.stateStoreFallbacks.Add(7)
+	s.streamBufferOverflows.Add(2)
+	s.bufferLimiter.rejections.Add(4)
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		s.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+	body := scrape()
+	require.Contains(t, body, "ai_gateway_extproc_state_store_fallbacks_total 7\n")
+	require.Contains(t, body, "ai_gateway_extproc_state_store_breaker_open 0\n")
+	require.Contains(t, body, "ai_gateway_extproc_stream_buffer_overflows_total 2\n")
+	require.Contains(t, body, "ai_gateway_extproc_buffer_limit_rejections_total 4\n")
+
+	store, err := s.loadStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: "127.0.0.1:6379"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.close() })
+	store.openBreaker()
+	require.Contains(t, scrape(), "ai_gateway_extproc_state_store_breaker_open 1\n")
+}
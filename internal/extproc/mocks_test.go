@@ -9,6 +9,8 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"slices"
+	"sync"
 	"testing"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -65,6 +67,12 @@ func (m mockProcessor) ProcessResponseBody(_ context.Context, body *extprocv3.Ht
 	return m.retProcessingResponse, m.retErr
 }
 
+// ProcessResponseTrailers implements [Processor.ProcessResponseTrailers].
+func (m mockProcessor) ProcessResponseTrailers(_ context.Context, headerMap *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	require.Equal(m.t, m.expHeaderMap, headerMap)
+	return m.retProcessingResponse, m.retErr
+}
+
 // mockTranslator implements [translator.Translator] for testing.
 type mockTranslator struct {
 	t                 *testing.T
@@ -167,3 +175,93 @@ func (m mockExternalProcessingStream) SendMsg(any) error { panic("TODO") }
 func (m mockExternalProcessingStream) RecvMsg(any) error { panic("TODO") }
 
 var _ extprocv3.ExternalProcessor_ProcessServer = &mockExternalProcessingStream{}
+
+// mockScriptedProcessingStream implements [extprocv3.ExternalProcessor_ProcessServer] for testing.
+// This returns reqs in order from Recv, then blocks until unblock is closed and returns retErr.
+type mockScriptedProcessingStream struct {
+	extprocv3.ExternalProcessor_ProcessServer
+	ctx     context.Context
+	reqs    []*extprocv3.ProcessingRequest
+	unblock <-chan struct{}
+	retErr  error
+
+	mu   sync.Mutex
+	sent []*extprocv3.ProcessingResponse
+}
+
+// Context implements [extprocv3.ExternalProcessor_ProcessServer].
+func (m *mockScriptedProcessingStream) Context() context.Context { return m.ctx }
+
+// Send implements [extprocv3.ExternalProcessor_ProcessServer].
+func (m *mockScriptedProcessingStream) Send(response *extprocv3.ProcessingResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, response)
+	return nil
+}
+
+// Recv implements [extprocv3.ExternalProcessor_ProcessServer].
+func (m *mockScriptedProcessingStream) Recv() (*extprocv3.ProcessingRequest, error) {
+	m.mu.Lock()
+	if len(m.reqs) > 0 {
+		req := m.reqs[0]
+		m.reqs = m.reqs[1:]
+		m.mu.Unlock()
+		return req, nil
+	}
+	m.mu.Unlock()
+	if m.unblock != nil {
+		<-m.unblock
+	}
+	return nil, m.retErr
+}
+
+// sentResponses returns the responses sent so far.
+func (m *mockScriptedProcessingStream) sentResponses() []*extprocv3.ProcessingResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.sent)
+}
+
+// mockChannelProcessingStream implements [extprocv3.ExternalProcessor_ProcessServer] for testing.
+// This returns the requests sent to reqs from Recv, and io.EOF once reqs is closed. The responses are sent to sent.
+type mockChannelProcessingStream struct {
+	extprocv3.ExternalProcessor_ProcessServer
+	ctx  context.Context
+	reqs <-chan *extprocv3.ProcessingRequest
+	sent chan<- *extprocv3.ProcessingResponse
+}
+
+// Context implements [extprocv3.ExternalProcessor_ProcessServer].
+func (m *mockChannelProcessingStream) Context() context.Context { return m.ctx }
+
+// Send implements [extprocv3.ExternalProcessor_ProcessServer].
+func (m *mockChannelProcessingStream) Send(response *extprocv3.ProcessingResponse) error {
+	m.sent <- response
+	return nil
+}
+
+// Recv implements [extprocv3.ExternalProcessor_ProcessServer].
+func (m *mockChannelProcessingStream) Recv() (*extprocv3.ProcessingRequest, error) {
+	req, ok := <-m.reqs
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+// mockResponseObserver implements [backendauth.Handler] and [backendauth.ResponseObserver] for testing.
+type mockResponseObserver struct {
+	authorization string
+	status        int
+}
+
+// Do implements [backendauth.Handler.Do].
+func (m *mockResponseObserver) Do(context.Context, map[string]string, *extprocv3.HeaderMutation, *extprocv3.BodyMutation) error {
+	return nil
+}
+
+// ObserveResponse implements [backendauth.ResponseObserver.ObserveResponse].
+func (m *mockResponseObserver) ObserveResponse(requestHeaders map[string]string, status int) {
+	m.authorization, m.status = requestHeaders["Authorization"], status
+}
@@ -0,0 +1,113 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// modelLimitError is returned by applyModelParams when the request exceeds the limits in the strict mode.
+type modelLimitError struct {
+	// param is the name of the request parameter that exceeds the limit.
+	param string
+	// message is the human-readable description of the violation.
+	message string
+}
+
+// Error implements [error].
+func (e *modelLimitError) Error() string { return e.message }
+
+// applyModelParams applies the defaults and the limits of the inference parameters to the request.
+//
+// This returns the values of the JSON fields of the request modified by the defaults or the clamping,
+// keyed by the field name. When the limits are strict, the exceeding value results in [*modelLimitError].
+func applyModelParams(req *openai.ChatCompletionRequest, defaults *filterapi.ModelDefaults, limits *filterapi.ModelLimits) (
+	modified map[string]any, err error,
+) {
+	modified = make(map[string]any)
+	if defaults != nil {
+		if req.Temperature == nil && defaults.Temperature != nil {
+			req.Temperature = ptrCopy(defaults.Temperature)
+			modified["temperature"] = req.Temperature
+		}
+		if req.TopP == nil && defaults.TopP != nil {
+			req.TopP = ptrCopy(defaults.TopP)
+			modified["top_p"] = req.TopP
+		}
+		// The default applies when neither max_completion_tokens nor the deprecated max_tokens is set.
+		if req.MaxOutputTokens() == nil && defaults.MaxTokens != nil {
+			req.MaxTokens = ptrCopy(defaults.MaxTokens)
+			modified["max_tokens"] = req.MaxTokens
+		}
+	}
+	if limits == nil {
+		return modified, nil
+	}
+	if maxValue := limits.MaxTemperature; maxValue != nil && req.Temperature != nil && *req.Temperature > *maxValue {
+		if limits.Strict {
+			return nil, &modelLimitError{param: "temperature", message: fmt.Sprintf("temperature %v exceeds the maximum %v", *req.Temperature, *maxValue)}
+		}
+		req.Temperature = ptrCopy(maxValue)
+		modified["temperature"] = req.Temperature
+	}
+	if maxValue := limits.MaxTopP; maxValue != nil && req.TopP != nil && *req.TopP > *maxValue {
+		if limits.Strict {
+			return nil, &modelLimitError{param: "top_p", message: fmt.Sprintf("top_p %v exceeds the maximum %v", *req.TopP, *maxValue)}
+		}
+		req.TopP = ptrCopy(maxValue)
+		modified["top_p"] = req.TopP
+	}
+	if maxValue := limits.MaxTokens; maxValue != nil {
+		// Both of max_completion_tokens and the deprecated max_tokens are limited since the backends honor either.
+		for _, f := range []struct {
+			param string
+			value **int64
+		}{{"max_completion_tokens", &req.MaxCompletionTokens}, {"max_tokens", &req.MaxTokens}} {
+			if *f.value == nil || **f.value <= *maxValue {
+				continue
+			}
+			if limits.Strict {
+				return nil, &modelLimitError{param: f.param, message: fmt.Sprintf("%s %d exceeds the maximum %d", f.param, **f.value, *maxValue)}
+			}
+			*f.value = ptrCopy(maxValue)
+			modified[f.param] = *f.value
+		}
+	}
+	if maxValue := limits.MaxStopSequences; maxValue != nil && len(req.Stop.Values()) > *maxValue {
+		if limits.Strict {
+			return nil, &modelLimitError{param: "stop", message: fmt.Sprintf("the number of stop sequences %d exceeds the maximum %d", len(req.Stop.Values()), *maxValue)}
+		}
+		req.Stop.Sequences = req.Stop.Sequences[:*maxValue]
+		modified["stop"] = req.Stop
+	}
+	return modified, nil
+}
+
+// patchJSONFields overwrites the given fields of the JSON object while keeping the other fields as-is.
+func patchJSONFields(raw []byte, fields map[string]any) ([]byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	for k, v := range fields {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", k, err)
+		}
+		obj[k] = b
+	}
+	return json.Marshal(obj)
+}
+
+// ptrCopy returns a pointer to the copy of the value so that the request does not share the config.
+func ptrCopy[T any](v *T) *T {
+	c := *v
+	return &c
+}
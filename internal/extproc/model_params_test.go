@@ -0,0 +1,242 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func Test_applyModelParams(t *testing.T) {
+	defaults := &filterapi.ModelDefaults{Temperature: ptr.To(0.2), TopP: ptr.To(0.9), MaxTokens: ptr.To[int64](1024)}
+	limits := &filterapi.ModelLimits{MaxTemperature: ptr.To(1.0), MaxTopP: ptr.To(0.95), MaxTokens: ptr.To[int64](4096), MaxStopSequences: ptr.To(1)}
+	strictLimits := *limits
+	strictLimits.Strict = true
+
+	for _, tc := range []struct {
+		name        string
+		req         openai.ChatCompletionRequest
+		defaults    *filterapi.ModelDefaults
+		limits      *filterapi.ModelLimits
+		exp         openai.ChatCompletionRequest
+		expModified []string
+		expErrParam string
+	}{
+		{
+			name: "no config",
+			req:  openai.ChatCompletionRequest{Temperature: ptr.To(1.5)},
+			exp:  openai.ChatCompletionRequest{Temperature: ptr.To(1.5)},
+		},
+		{
+			name:        "defaults",
+			defaults:    defaults,
+			req:         openai.ChatCompletionRequest{TopP: ptr.To(0.5)},
+			exp:         openai.ChatCompletionRequest{Temperature: ptr.To(0.2), TopP: ptr.To(0.5), MaxTokens: ptr.To[int64](1024)},
+			expModified: []string{"temperature", "max_tokens"},
+		},
+		{
+			name:   "clamp",
+			limits: limits,
+			req: openai.ChatCompletionRequest{
+				Temperature: ptr.To(1.5), TopP: ptr.To(0.99), MaxTokens: ptr.To[int64](10000),
+				Stop: &openai.ChatCompletionStop{Sequences: []string{"a", "b"}},
+			},
+			exp: openai.ChatCompletionRequest{
+				Temperature: ptr.To(1.0), TopP: ptr.To(0.95), MaxTokens: ptr.To[int64](4096),
+				Stop: &openai.ChatCompletionStop{Sequences: []string{"a"}},
+			},
+			expModified: []string{"temperature", "top_p", "max_tokens", "stop"},
+		},
+		{
+			name:     "defaults within limits",
+			defaults: defaults,
+			limits:   limits,
+			req:      openai.ChatCompletionRequest{MaxTokens: ptr.To[int64](100)},
+			exp:      openai.ChatCompletionRequest{Temperature: ptr.To(0.2), TopP: ptr.To(0.9), MaxTokens: ptr.To[int64](100)},
+			expModified: []string{
+				"temperature", "top_p",
+			},
+		},
+		{
+			name:        "strict temperature",
+			limits:      &strictLimits,
+			req:         openai.ChatCompletionRequest{Temperature: ptr.To(1.5)},
+			expErrParam: "temperature",
+		},
+		{
+			name:        "strict top_p",
+			limits:      &strictLimits,
+			req:         openai.ChatCompletionRequest{TopP: ptr.To(1.0)},
+			expErrParam: "top_p",
+		},
+		{
+			name:        "strict max_tokens",
+			limits:      &strictLimits,
+			req:         openai.ChatCompletionRequest{MaxTokens: ptr.To[int64](4097)},
+			expErrParam: "max_tokens",
+		},
+		{
+			name:     "defaults with max_completion_tokens",
+			defaults: defaults,
+			req:      openai.ChatCompletionRequest{MaxCompletionTokens: ptr.To[int64](100)},
+			exp: openai.ChatCompletionRequest{
+				Temperature: ptr.To(0.2), TopP: ptr.To(0.9), MaxCompletionTokens: ptr.To[int64](100),
+			},
+			expModified: []string{"temperature", "top_p"},
+		},
+		{
+			name:   "clamp max_completion_tokens and max_tokens",
+			limits: limits,
+			req:    openai.ChatCompletionRequest{MaxCompletionTokens: ptr.To[int64](10000), MaxTokens: ptr.To[int64](5000)},
+			exp: openai.ChatCompletionRequest{
+				MaxCompletionTokens: ptr.To[int64](4096), MaxTokens: ptr.To[int64](4096),
+			},
+			expModified: []string{"max_completion_tokens", "max_tokens"},
+		},
+		{
+			name:        "strict max_completion_tokens",
+			limits:      &strictLimits,
+			req:         openai.ChatCompletionRequest{MaxCompletionTokens: ptr.To[int64](4097), MaxTokens: ptr.To[int64](100)},
+			expErrParam: "max_completion_tokens",
+		},
+		{
+			name:        "strict stop",
+			limits:      &strictLimits,
+			req:         openai.ChatCompletionRequest{Stop: &openai.ChatCompletionStop{Sequences: []string{"a", "b"}}},
+			expErrParam: "stop",
+		},
+		{
+			name:     "strict within limits",
+			defaults: defaults,
+			limits:   &strictLimits,
+			req:      openai.ChatCompletionRequest{Temperature: ptr.To(1.0), Stop: &openai.ChatCompletionStop{Sequences: []string{"a"}}},
+			exp: openai.ChatCompletionRequest{
+				Temperature: ptr.To(1.0), TopP: ptr.To(0.9), MaxTokens: ptr.To[int64](1024), Stop: &openai.ChatCompletionStop{Sequences: []string{"a"}},
+			},
+			expModified: []string{"top_p", "max_tokens"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			modified, err := applyModelParams(&req, tc.defaults, tc.limits)
+			if tc.expErrParam != "" {
+				var limitErr *modelLimitError
+				require.ErrorAs(t, err, &limitErr)
+				require.Equal(t, tc.expErrParam, limitErr.param)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, req)
+			keys := make([]string, 0, len(modified))
+			for k := range modified {
+				keys = append(keys, k)
+			}
+			require.ElementsMatch(t, tc.expModified, keys)
+		})
+	}
+	t.Run("defaults are not shared", func(t *testing.T) {
+		var req openai.ChatCompletionRequest
+		_, err := applyModelParams(&req, defaults, nil)
+		require.NoError(t, err)
+		*req.Temperature = 100
+		require.Equal(t, 0.2, *defaults.Temperature)
+	})
+}
+
+func Test_patchJSONFields(t *testing.T) {
+	patched, err := patchJSONFields([]byte(`{"model":"m","unknown_field":{"a":1},"temperature":1.5}`), map[string]any{
+		"temperature": ptr.To(1.0),
+		"max_tokens":  ptr.To[int64](10),
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"model":"m","unknown_field":{"a":1},"temperature":1,"max_tokens":10}`, string(patched))
+
+	_, err = patchJSONFields([]byte(`nonjson`), nil)
+	require.ErrorContains(t, err, "failed to unmarshal body")
+}
+
+func TestChatCompletion_ProcessRequestBody_modelParams(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends:      []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:       []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt"}},
+				ModelDefaults: &filterapi.ModelDefaults{Temperature: ptr.To(0.2)},
+				ModelLimits:   &filterapi.ModelLimits{MaxTokens: ptr.To[int64](4096)},
+			},
+			{
+				Backends:      []filterapi.Backend{{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+				Headers:       []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude"}},
+				ModelDefaults: &filterapi.ModelDefaults{Temperature: ptr.To(0.2)},
+				ModelLimits:   &filterapi.ModelLimits{MaxTokens: ptr.To[int64](4096)},
+			},
+			{
+				Backends:    []filterapi.Backend{{Name: "strict", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:     []filterapi.HeaderMatch{{Name: "x-model-name", Value: "strict"}},
+				ModelLimits: &filterapi.ModelLimits{MaxTokens: ptr.To[int64](4096), Strict: true},
+			},
+		},
+	}))
+
+	process := func(t *testing.T, body string) *extprocv3.ProcessingResponse {
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, slog.Default())
+		require.NoError(t, err)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: []byte(body)})
+		require.NoError(t, err)
+		return resp
+	}
+	for _, stream := range []bool{false, true} {
+		streamField := `,"stream":false`
+		if stream {
+			streamField = `,"stream":true`
+		}
+		t.Run("openai", func(t *testing.T) {
+			resp := process(t, `{"model":"gpt","messages":[],"max_tokens":10000,"user":"alice"`+streamField+`}`)
+			common := resp.GetRequestBody().GetResponse()
+			require.JSONEq(t, `{"model":"gpt","messages":[],"max_tokens":4096,"temperature":0.2,"user":"alice"`+streamField+`}`, string(common.GetBodyMutation().GetBody()))
+			require.Equal(t, stream, resp.GetModeOverride() != nil)
+		})
+		t.Run("aws bedrock", func(t *testing.T) {
+			resp := process(t, `{"model":"claude","messages":[],"max_tokens":10000`+streamField+`}`)
+			var converse awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(resp.GetRequestBody().GetResponse().GetBodyMutation().GetBody(), &converse))
+			require.Equal(t, ptr.To(0.2), converse.InferenceConfig.Temperature)
+			require.Equal(t, ptr.To[int64](4096), converse.InferenceConfig.MaxTokens)
+			require.Equal(t, stream, resp.GetModeOverride() != nil)
+		})
+		t.Run("strict", func(t *testing.T) {
+			resp := process(t, `{"model":"strict","messages":[],"max_tokens":10000`+streamField+`}`)
+			ir := resp.GetImmediateResponse()
+			require.NotNil(t, ir)
+			require.Equal(t, typev3.StatusCode_BadRequest, ir.GetStatus().GetCode())
+			var openAIErr openai.Error
+			require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+			require.Equal(t, "invalid_request_error", openAIErr.Error.Type)
+			require.Equal(t, "max_tokens", *openAIErr.Error.Param)
+			require.Equal(t, "max_tokens 10000 exceeds the maximum 4096", openAIErr.Error.Message)
+		})
+		t.Run("strict within limits", func(t *testing.T) {
+			resp := process(t, `{"model":"strict","messages":[],"max_tokens":10`+streamField+`}`)
+			require.Nil(t, resp.GetImmediateResponse())
+			require.Nil(t, resp.GetRequestBody().GetResponse().GetBodyMutation())
+		})
+	}
+}
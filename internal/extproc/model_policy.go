@@ -0,0 +1,75 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// modelAllowed returns true if the model is allowed by the policy, or if the policy is nil.
+func modelAllowed(policy *filterapi.ModelPolicy, model string) bool {
+	if policy == nil {
+		return true
+	}
+	for _, pattern := range policy.Deny {
+		if modelPatternMatches(pattern, model) {
+			return false
+		}
+	}
+	if len(policy.Allow) == 0 {
+		return true
+	}
+	for _, pattern := range policy.Allow {
+		if modelPatternMatches(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelPatternMatches returns true if the model matches the pattern, which is either the exact model name or
+// the prefix followed by "*".
+func modelPatternMatches(pattern, model string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(model, prefix)
+	}
+	return pattern == model
+}
+
+// modelNotAllowedResponse returns the immediate response to reject the request for the model not allowed by
+// the model policy with 403 Forbidden.
+func modelNotAllowedResponse(model string) *extprocv3.ProcessingResponse {
+	code, param := "model_not_found", "model"
+	body, _ := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "invalid_request_error",
+			Code:    &code,
+			Message: fmt.Sprintf("The model `%s` does not exist or you do not have access to it.", model),
+			Param:   &param,
+		},
+	})
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status: &typev3.HttpStatus{Code: typev3.StatusCode_Forbidden},
+				Headers: &extprocv3.HeaderMutation{
+					SetHeaders: []*corev3.HeaderValueOption{{Header: &corev3.HeaderValue{Key: "content-type", RawValue: []byte("application/json")}}},
+				},
+				Body: body,
+			},
+		},
+	}
+}
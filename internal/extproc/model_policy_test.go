@@ -0,0 +1,34 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func Test_modelAllowed(t *testing.T) {
+	require.True(t, modelAllowed(nil, "gpt-4o"))
+	require.True(t, modelAllowed(&filterapi.ModelPolicy{}, "gpt-4o"))
+
+	policy := &filterapi.ModelPolicy{Allow: []string{"gpt-*", "llama3"}, Deny: []string{"gpt-4o-mini*"}}
+	for model, exp := range map[string]bool{
+		"gpt-4o":            true,
+		"gpt-":              true,
+		"llama3":            true,
+		"llama3.1":          false,
+		"gpt-4o-mini":       false,
+		"gpt-4o-mini-audio": false,
+		"":                  false,
+	} {
+		require.Equal(t, exp, modelAllowed(policy, model), model)
+	}
+	// "*" alone matches any model.
+	require.False(t, modelAllowed(&filterapi.ModelPolicy{Deny: []string{"*"}}, "gpt-4o"))
+}
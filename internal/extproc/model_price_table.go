@@ -0,0 +1,61 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// modelPriceTableCost calculates the cost of the request to the model with the price table.
+// The cost is rounded to the nearest integer and saturated at the maximum of uint32.
+func modelPriceTableCost(t *filterapi.ModelPriceTable, model string, inputTokens, outputTokens uint32) (uint32, error) {
+	price, ok := lookupModelPrice(t, model)
+	if !ok {
+		switch {
+		case t.Strict:
+			return 0, fmt.Errorf("no price is defined for model %q", model)
+		case t.Default == nil:
+			return 0, nil
+		}
+		price = t.Default
+	}
+	multiplier := t.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+	cost := (float64(inputTokens)*price.InputTokenPrice + float64(outputTokens)*price.OutputTokenPrice) * float64(multiplier)
+	return uint32(math.Min(math.Round(cost), math.MaxUint32)), nil
+}
+
+// lookupModelPrice returns the price of the model. The exact match takes precedence over the prefix matches,
+// and the longest prefix wins among the prefix matches.
+func lookupModelPrice(t *filterapi.ModelPriceTable, model string) (*filterapi.ModelPrice, bool) {
+	if p, ok := t.Prices[model]; ok && !strings.HasSuffix(model, "*") {
+		return &p, true
+	}
+	var (
+		matched string
+		price   filterapi.ModelPrice
+		found   bool
+	)
+	for key, p := range t.Prices {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if !ok || !strings.HasPrefix(model, prefix) {
+			continue
+		}
+		if !found || len(prefix) > len(matched) {
+			matched, price, found = prefix, p, true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return &price, true
+}
@@ -0,0 +1,95 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func Test_modelPriceTableCost(t *testing.T) {
+	prices := map[string]filterapi.ModelPrice{
+		"gpt-4o":      {InputTokenPrice: 2.5, OutputTokenPrice: 10},
+		"gpt-4o*":     {InputTokenPrice: 0.15, OutputTokenPrice: 0.6},
+		"gpt*":        {InputTokenPrice: 1, OutputTokenPrice: 1},
+		"claude-3-5*": {InputTokenPrice: 0.000003, OutputTokenPrice: 0.000015},
+	}
+	for _, tc := range []struct {
+		name    string
+		table   filterapi.ModelPriceTable
+		model   string
+		expCost uint32
+		expErr  string
+	}{
+		{
+			name:    "exact match",
+			table:   filterapi.ModelPriceTable{Prices: prices},
+			model:   "gpt-4o",
+			expCost: 100*2.5 + 10*10,
+		},
+		{
+			name:    "longest prefix",
+			table:   filterapi.ModelPriceTable{Prices: prices, Multiplier: 100},
+			model:   "gpt-4o-mini",
+			expCost: (100*0.15 + 10*0.6) * 100,
+		},
+		{
+			name:    "shorter prefix",
+			table:   filterapi.ModelPriceTable{Prices: prices},
+			model:   "gpt-3.5-turbo",
+			expCost: 110,
+		},
+		{
+			name:    "rounded",
+			table:   filterapi.ModelPriceTable{Prices: prices, Multiplier: 1000},
+			model:   "claude-3-5-sonnet",
+			expCost: 0, // (100*0.000003 + 10*0.000015) * 1000 = 0.45
+		},
+		{
+			name:    "scaled",
+			table:   filterapi.ModelPriceTable{Prices: prices, Multiplier: 1_000_000},
+			model:   "claude-3-5-sonnet",
+			expCost: 450,
+		},
+		{
+			name:    "default",
+			table:   filterapi.ModelPriceTable{Prices: prices, Default: &filterapi.ModelPrice{InputTokenPrice: 0.5, OutputTokenPrice: 0.5}},
+			model:   "llama3",
+			expCost: 55,
+		},
+		{
+			name:  "no default",
+			table: filterapi.ModelPriceTable{Prices: prices},
+			model: "llama3",
+		},
+		{
+			name:   "strict",
+			table:  filterapi.ModelPriceTable{Prices: prices, Strict: true},
+			model:  "llama3",
+			expErr: `no price is defined for model "llama3"`,
+		},
+		{
+			name:    "saturated",
+			table:   filterapi.ModelPriceTable{Prices: prices, Multiplier: math.MaxInt64},
+			model:   "gpt-4o",
+			expCost: math.MaxUint32,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cost, err := modelPriceTableCost(&tc.table, tc.model, 100, 10)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expCost, cost)
+		})
+	}
+}
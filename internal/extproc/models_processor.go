@@ -92,6 +92,11 @@ func (m *modelsProcessor) ProcessResponseBody(context.Context, *extprocv3.HttpBo
 	return nil, fmt.Errorf("%w: ProcessResponseBody", errUnexpectedCall)
 }
 
+// ProcessResponseTrailers implements [Processor.ProcessResponseTrailers].
+func (m *modelsProcessor) ProcessResponseTrailers(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return nil, fmt.Errorf("%w: ProcessResponseTrailers", errUnexpectedCall)
+}
+
 func setHeader(headers *extprocv3.HeaderMutation, key, value string) {
 	headers.SetHeaders = append(headers.SetHeaders, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{
@@ -55,6 +55,8 @@ func TestModels_UnimplementedMethods(t *testing.T) {
 	require.ErrorIs(t, err, errUnexpectedCall)
 	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
 	require.ErrorIs(t, err, errUnexpectedCall)
+	_, err = p.ProcessResponseTrailers(t.Context(), &corev3.HeaderMap{})
+	require.ErrorIs(t, err, errUnexpectedCall)
 }
 
 func headers(in []*corev3.HeaderValueOption) map[string]string {
@@ -0,0 +1,81 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// notFoundProcessor implements [Processor] for the paths that no processor is registered for when the default
+// route is disabled. This processor returns an immediate response with 404 Not Found in the OpenAI error format.
+// Since it returns an immediate response after processing the headers, the rest of the methods of the
+// Processor are not implemented. Those should never be called.
+type notFoundProcessor struct {
+	logger *slog.Logger
+	path   string
+}
+
+var _ Processor = (*notFoundProcessor)(nil)
+
+// ProcessRequestHeaders implements [Processor.ProcessRequestHeaders].
+func (n *notFoundProcessor) ProcessRequestHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	n.logger.Info("Rejecting request to unknown path", "path", n.path)
+	code := "not_found"
+	body, err := json.Marshal(openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    "invalid_request_error",
+			Code:    &code,
+			Message: fmt.Sprintf("unknown path %s", n.path),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+
+	headerMutation := &extprocv3.HeaderMutation{}
+	setHeader(headerMutation, "content-length", strconv.Itoa(len(body)))
+	setHeader(headerMutation, "content-type", "application/json")
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode_NotFound},
+				Headers: headerMutation,
+				Body:    body,
+			},
+		},
+	}, nil
+}
+
+// ProcessRequestBody implements [Processor.ProcessRequestBody].
+func (n *notFoundProcessor) ProcessRequestBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	return nil, fmt.Errorf("%w: ProcessRequestBody", errUnexpectedCall)
+}
+
+// ProcessResponseHeaders implements [Processor.ProcessResponseHeaders].
+func (n *notFoundProcessor) ProcessResponseHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return nil, fmt.Errorf("%w: ProcessResponseHeaders", errUnexpectedCall)
+}
+
+// ProcessResponseBody implements [Processor.ProcessResponseBody].
+func (n *notFoundProcessor) ProcessResponseBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
+	return nil, fmt.Errorf("%w: ProcessResponseBody", errUnexpectedCall)
+}
+
+// ProcessResponseTrailers implements [Processor.ProcessResponseTrailers].
+func (n *notFoundProcessor) ProcessResponseTrailers(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return nil, fmt.Errorf("%w: ProcessResponseTrailers", errUnexpectedCall)
+}
@@ -0,0 +1,51 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestNotFound_ProcessRequestHeaders(t *testing.T) {
+	p := &notFoundProcessor{logger: slog.Default(), path: "/foo"}
+	res, err := p.ProcessRequestHeaders(t.Context(), &corev3.HeaderMap{})
+	require.NoError(t, err)
+
+	ir := res.GetImmediateResponse()
+	require.NotNil(t, ir)
+	require.Equal(t, typev3.StatusCode_NotFound, ir.Status.Code)
+	respHeaders := headers(ir.Headers.SetHeaders)
+	require.Equal(t, "application/json", respHeaders["content-type"])
+	require.Equal(t, strconv.Itoa(len(ir.Body)), respHeaders["content-length"])
+
+	var openAIErr openai.Error
+	require.NoError(t, json.Unmarshal(ir.Body, &openAIErr))
+	require.Equal(t, "invalid_request_error", openAIErr.Error.Type)
+	require.Equal(t, "not_found", *openAIErr.Error.Code)
+	require.Equal(t, "unknown path /foo", openAIErr.Error.Message)
+}
+
+func TestNotFound_UnimplementedMethods(t *testing.T) {
+	p := &notFoundProcessor{}
+	_, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{})
+	require.ErrorIs(t, err, errUnexpectedCall)
+	_, err = p.ProcessResponseHeaders(t.Context(), &corev3.HeaderMap{})
+	require.ErrorIs(t, err, errUnexpectedCall)
+	_, err = p.ProcessResponseBody(t.Context(), &extprocv3.HttpBody{})
+	require.ErrorIs(t, err, errUnexpectedCall)
+	_, err = p.ProcessResponseTrailers(t.Context(), &corev3.HeaderMap{})
+	require.ErrorIs(t, err, errUnexpectedCall)
+}
@@ -0,0 +1,80 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"net/url"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+// pathOverrideModelPlaceholder is the placeholder of the model in [filterapi.Backend.PathOverride].
+const pathOverrideModelPlaceholder = "{model}"
+
+// bedrockStreamOperations maps the operations of the AWS Bedrock runtime API in the last segment of the path to their
+// streaming counterparts.
+var bedrockStreamOperations = map[string]string{
+	"converse": "converse-stream",
+	"invoke":   "invoke-with-response-stream",
+}
+
+// applyPathOverride replaces the :path header of the request to the backend with its path override, if any.
+//
+// The placeholder is replaced with the escaped model name of the request. For the AWSBedrock schema, this is the model
+// ID in the path set by the translator instead, which may have the model prefix of the backend. The streaming requests
+// of the AWSBedrock schema are sent to a different operation, so the operation at the end of the path override, e.g.,
+// "converse", is replaced with its streaming counterpart when the translator sets the streaming one.
+func applyPathOverride(b *filterapi.Backend, model string, headerMutation *extprocv3.HeaderMutation) {
+	if b.PathOverride == "" {
+		return
+	}
+	escaped := url.PathEscape(model)
+	path := b.PathOverride
+	if b.Schema.Name == filterapi.APISchemaAWSBedrock {
+		translated := headerMutationValue(headerMutation, ":path")
+		if id, ok := bedrockPathModelID(translated); ok {
+			escaped = id
+		}
+		i := strings.LastIndex(path, "/") + 1
+		if stream, ok := bedrockStreamOperations[path[i:]]; ok && strings.HasSuffix(translated, "/"+stream) {
+			path = path[:i] + stream
+		}
+	}
+	path = strings.ReplaceAll(path, pathOverrideModelPlaceholder, escaped)
+	headerMutation.SetHeaders = append(deleteSetHeader(headerMutation.SetHeaders, ":path"), &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(path)},
+	})
+}
+
+// bedrockPathModelID returns the escaped model ID in the path of the AWS Bedrock runtime API, such as
+// "/model/{modelId}/converse".
+func bedrockPathModelID(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(path, "/model/")
+	if !ok {
+		return "", false
+	}
+	id, _, ok := strings.Cut(rest, "/")
+	return id, ok && id != ""
+}
+
+// validatePathOverride returns the description of the error if the path override of the backend is invalid.
+// The path must be absolute without the query or the fragment, and must have the model placeholder for the AWSBedrock
+// schema since the model is in the path.
+func validatePathOverride(b *filterapi.Backend) string {
+	switch {
+	case !strings.HasPrefix(b.PathOverride, "/"):
+		return "path override must start with '/'"
+	case strings.ContainsAny(b.PathOverride, "?# \t\r\n"):
+		return "path override must not contain the query, the fragment, or whitespaces"
+	case b.Schema.Name == filterapi.APISchemaAWSBedrock && !strings.Contains(b.PathOverride, pathOverrideModelPlaceholder):
+		return "path override must contain " + pathOverrideModelPlaceholder + " for the AWSBedrock schema"
+	}
+	return ""
+}
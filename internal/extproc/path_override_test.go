@@ -0,0 +1,119 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestApplyPathOverride(t *testing.T) {
+	openAI := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	bedrock := filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}
+	for _, tc := range []struct {
+		name       string
+		backend    *filterapi.Backend
+		model      string
+		path       string
+		expPath    string
+		expHeaders int
+	}{
+		{
+			name:    "no override",
+			backend: &filterapi.Backend{Schema: openAI},
+			model:   "gpt-4o",
+		},
+		{
+			name:       "openai",
+			backend:    &filterapi.Backend{Schema: openAI, PathOverride: "/serving/v2/chat/completions"},
+			model:      "gpt-4o",
+			expPath:    "/serving/v2/chat/completions",
+			expHeaders: 1,
+		},
+		{
+			name:       "openai model",
+			backend:    &filterapi.Backend{Schema: openAI, PathOverride: "/serving/{model}/chat/completions"},
+			model:      "org/model",
+			expPath:    "/serving/org%2Fmodel/chat/completions",
+			expHeaders: 1,
+		},
+		{
+			name:       "bedrock",
+			backend:    &filterapi.Backend{Schema: bedrock, PathOverride: "/bedrock/{model}/converse"},
+			model:      "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			path:       "/model/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse-stream",
+			expPath:    "/bedrock/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse-stream",
+			expHeaders: 1,
+		},
+		{
+			name:       "bedrock non-streaming",
+			backend:    &filterapi.Backend{Schema: bedrock, PathOverride: "/bedrock/{model}/converse"},
+			model:      "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			path:       "/model/anthropic.claude-3-5-sonnet-20240620-v1:0/converse",
+			expPath:    "/bedrock/anthropic.claude-3-5-sonnet-20240620-v1:0/converse",
+			expHeaders: 1,
+		},
+		{
+			name:       "bedrock invoke streaming",
+			backend:    &filterapi.Backend{Schema: bedrock, PathOverride: "/bedrock/{model}/invoke"},
+			model:      "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			path:       "/model/anthropic.claude-3-5-sonnet-20240620-v1:0/invoke-with-response-stream",
+			expPath:    "/bedrock/anthropic.claude-3-5-sonnet-20240620-v1:0/invoke-with-response-stream",
+			expHeaders: 1,
+		},
+		{
+			name:       "bedrock custom operation",
+			backend:    &filterapi.Backend{Schema: bedrock, PathOverride: "/bedrock/{model}/chat"},
+			model:      "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			path:       "/model/anthropic.claude-3-5-sonnet-20240620-v1:0/converse-stream",
+			expPath:    "/bedrock/anthropic.claude-3-5-sonnet-20240620-v1:0/chat",
+			expHeaders: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			headerMutation := &extprocv3.HeaderMutation{}
+			if tc.path != "" {
+				headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+					Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(tc.path)},
+				})
+			}
+			applyPathOverride(tc.backend, tc.model, headerMutation)
+			if tc.expPath == "" {
+				require.Empty(t, headerMutation.SetHeaders)
+				return
+			}
+			require.Len(t, headerMutation.SetHeaders, tc.expHeaders)
+			require.Equal(t, tc.expPath, headerMutationValue(headerMutation, ":path"))
+		})
+	}
+}
+
+func Test_validatePathOverride(t *testing.T) {
+	openAI := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	bedrock := filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}
+	for _, tc := range []struct {
+		backend *filterapi.Backend
+		expErr  string
+	}{
+		{backend: &filterapi.Backend{Schema: openAI, PathOverride: "/serving/v2/chat/completions"}},
+		{backend: &filterapi.Backend{Schema: bedrock, PathOverride: "/bedrock/{model}/converse"}},
+		{backend: &filterapi.Backend{Schema: openAI, PathOverride: "serving"}, expErr: "path override must start with '/'"},
+		{backend: &filterapi.Backend{Schema: openAI, PathOverride: "/serving?foo=bar"}, expErr: "must not contain the query"},
+		{backend: &filterapi.Backend{Schema: bedrock, PathOverride: "/bedrock/converse"}, expErr: "must contain {model} for the AWSBedrock schema"},
+	} {
+		msg := validatePathOverride(tc.backend)
+		if tc.expErr == "" {
+			require.Empty(t, msg, tc.backend.PathOverride)
+		} else {
+			require.Contains(t, msg, tc.expErr, tc.backend.PathOverride)
+		}
+	}
+}
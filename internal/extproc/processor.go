@@ -8,6 +8,9 @@ package extproc
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
@@ -22,13 +25,45 @@ import (
 // This will be created by the server and passed to the processor when it detects a new configuration.
 type processorConfig struct {
 	uuid                                         string
+	loadedAt                                     time.Time
 	schema                                       filterapi.VersionedAPISchema
 	router                                       x.Router
 	modelNameHeaderKey, selectedBackendHeaderKey string
 	backendAuthHandlers                          map[string]backendauth.Handler
+	backendRuleIndexes                           map[*filterapi.Backend]int
+	rules                                        []filterapi.RouteRule
 	metadataNamespace                            string
 	requestCosts                                 []processorConfigRequestCost
+	emitCostHeaders                              bool
+	emitLatencyHeaders                           bool
+	defaultRouteDisabled                         bool
 	declaredModels                               []string
+	concurrencyLimit                             *filterapi.ConcurrencyLimit
+	concurrencyLimiter                           *concurrencyLimiter
+	maxChoices                                   int
+	maxStreamBufferSize                          int
+	allowRemoteImages                            bool
+	pathPrefix                                   string
+	maxBufferedBytes                             int64
+	streamBufferOverflows                        *atomic.Uint64
+	streamExceptions                             *atomic.Uint64
+	noMatchingRules                              *atomic.Uint64
+	httpClient                                   *http.Client
+	remoteImageClient                            *http.Client
+	routeName                                    string
+	accessLogSink                                x.AccessLogSink
+	accessLogSampleRate                          float64
+	heartbeatInterval                            time.Duration
+	requestIDPropagationDisabled                 bool
+	emitConfigVersionHeader                      bool
+	allowedPaths                                 []string
+	modelPolicy                                  *filterapi.ModelPolicy
+	// stateStore is the store shared by the replicas used for the concurrency limit. Nil when not configured.
+	stateStore *redisStateStore
+	// inputTokenEstimator estimates the input tokens of the requests. Nil unless EstimateInputTokens is enabled.
+	inputTokenEstimator x.InputTokenEstimator
+	// filterConfig is the configuration this is loaded from, which is passed to the custom processors.
+	filterConfig *filterapi.Config
 }
 
 // processorConfigRequestCost is the configuration for the request cost.
@@ -40,17 +75,31 @@ type processorConfigRequestCost struct {
 // ProcessorFactory is the factory function used to create new instances of a processor.
 type ProcessorFactory func(*processorConfig, map[string]string, *slog.Logger) (Processor, error)
 
+// NewCustomProcessorFactory returns the [ProcessorFactory] creating the custom processor registered by
+// [x.RegisterProcessorFactory]. The custom processor is given the configuration loaded at the start of the stream.
+func NewCustomProcessorFactory(newProcessor x.NewCustomProcessorFn) ProcessorFactory {
+	return func(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger) (Processor, error) {
+		return newProcessor(config.filterConfig, requestHeaders, logger)
+	}
+}
+
 // Processor is the interface for the processor.
 // This decouples the processor implementation detail from the server implementation.
-type Processor interface {
-	// ProcessRequestHeaders processes the request headers message.
-	ProcessRequestHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error)
-	// ProcessRequestBody processes the request body message.
-	ProcessRequestBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error)
-	// ProcessResponseHeaders processes the response headers message.
-	ProcessResponseHeaders(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error)
-	// ProcessResponseBody processes the response body message.
-	ProcessResponseBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error)
+//
+// This is an alias of [x.Processor] so that the custom processors can be registered as is.
+type Processor = x.Processor
+
+// processorCloser is optionally implemented by a [Processor] that holds per-stream resources.
+// The server calls close exactly once when the stream ends, regardless of whether it completed or was aborted.
+type processorCloser interface {
+	close()
+}
+
+// processorHeartbeater is optionally implemented by a [Processor] that keeps the idle streaming response alive.
+// The server calls heartbeat periodically while waiting for the next message from Envoy when the heartbeat interval
+// is configured, and sends the returned response, if any.
+type processorHeartbeater interface {
+	heartbeat(now time.Time) *extprocv3.ProcessingResponse
 }
 
 // passThroughProcessor implements the Processor interface.
@@ -75,3 +124,8 @@ func (p passThroughProcessor) ProcessResponseHeaders(context.Context, *corev3.He
 func (p passThroughProcessor) ProcessResponseBody(context.Context, *extprocv3.HttpBody) (*extprocv3.ProcessingResponse, error) {
 	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseBody{}}, nil
 }
+
+// ProcessResponseTrailers implements [Processor.ProcessResponseTrailers].
+func (p passThroughProcessor) ProcessResponseTrailers(context.Context, *corev3.HeaderMap) (*extprocv3.ProcessingResponse, error) {
+	return &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseTrailers{}}, nil
+}
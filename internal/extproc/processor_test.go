@@ -37,4 +37,10 @@ func Test_passThroughProcessor(t *testing.T) { // This is mostly for coverage.
 	require.NotNil(t, resp)
 	_, ok = resp.Response.(*extprocv3.ProcessingResponse_ResponseBody)
 	require.True(t, ok)
+
+	resp, err = p.ProcessResponseTrailers(t.Context(), nil)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	_, ok = resp.Response.(*extprocv3.ProcessingResponse_ResponseTrailers)
+	require.True(t, ok)
 }
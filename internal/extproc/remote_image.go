@@ -0,0 +1,179 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
+)
+
+const (
+	// remoteImageMaxSize is the maximum size of the fetched image, which is the limit of AWS Bedrock Converse.
+	remoteImageMaxSize = 3_750_000
+	// remoteImageTimeout is the timeout of fetching a single image including the redirects and reading the body.
+	remoteImageTimeout = 10 * time.Second
+	// remoteImageMaxRedirects is the maximum number of the redirects followed when fetching a single image.
+	remoteImageMaxRedirects = 3
+)
+
+// remoteImageContentTypes is the content types of the images accepted by the backends.
+var remoteImageContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// remoteImageDeniedPrefixes is the address ranges not covered by the [netip.Addr] predicates checked by
+// checkRemoteImageAddress that the images must not be fetched from.
+var remoteImageDeniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This network".
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT, which some clusters use for the pod network.
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments.
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking.
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reserved, including the broadcast address.
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which can embed any of the IPv4 addresses above.
+}
+
+// errRemoteImageAddressDenied is returned when the image URL resolves to an address that must not be fetched from.
+var errRemoteImageAddressDenied = errors.New("the image address is not allowed")
+
+// newRemoteImageClient returns the HTTP client fetching the images referred by the URLs in the requests. The URLs
+// are given by the clients, so the client refuses to connect to the loopback, private, link-local and other
+// non-public addresses to prevent the requests from reaching the services in the cluster or the cloud metadata
+// endpoints. The addresses are checked right before connecting, after the DNS resolution, so that neither the
+// redirects nor the DNS rebinding can bypass the check. The proxy in the environment is not used for the same reason.
+func newRemoteImageClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: remoteImageTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkRemoteImageAddress(address)
+		},
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   remoteImageTimeout,
+			ResponseHeaderTimeout: remoteImageTimeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: checkRemoteImageRedirect,
+		Timeout:       remoteImageTimeout,
+	}
+}
+
+// checkRemoteImageAddress returns errRemoteImageAddressDenied if the "host:port" address of the connection to fetch
+// the image is not a public address.
+func checkRemoteImageAddress(address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errRemoteImageAddressDenied, address)
+	}
+	addr := addrPort.Addr().Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() ||
+		slices.ContainsFunc(remoteImageDeniedPrefixes, func(p netip.Prefix) bool { return p.Contains(addr) }) {
+		return fmt.Errorf("%w: %s", errRemoteImageAddressDenied, addr)
+	}
+	return nil
+}
+
+// checkRemoteImageRedirect is the [http.Client.CheckRedirect] of the remote image client, which only follows
+// a limited number of the redirects to the http(s) URLs. The addresses redirected to are checked when connecting.
+func checkRemoteImageRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > remoteImageMaxRedirects {
+		return fmt.Errorf("stopped after %d redirects", remoteImageMaxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirected to the unsupported scheme %q", req.URL.Scheme)
+	}
+	return nil
+}
+
+// remoteImageError is returned by inlineRemoteImages when the image cannot be fetched.
+type remoteImageError struct {
+	// param is the path of the image URL in the request.
+	param string
+	// message is the human-readable description of the failure.
+	message string
+}
+
+// Error implements [error].
+func (e *remoteImageError) Error() string { return e.message }
+
+// inlineRemoteImages fetches the images referred by the http(s) URLs in the user messages of the request
+// and replaces the URLs with the data URIs of the fetched images.
+//
+// The returned error is [*remoteImageError] describing the image that cannot be fetched.
+func inlineRemoteImages(ctx context.Context, client *http.Client, req *openai.ChatCompletionRequest) error {
+	for i := range req.Messages {
+		userMessage, ok := req.Messages[i].Value.(openai.ChatCompletionUserMessageParam)
+		if !ok {
+			continue
+		}
+		contents, ok := userMessage.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+		if !ok {
+			continue
+		}
+		for j := range contents {
+			image := contents[j].ImageContent
+			if image == nil || !translator.IsRemoteImageURL(image.ImageURL.URL) {
+				continue
+			}
+			dataURI, err := fetchRemoteImage(ctx, client, image.ImageURL.URL)
+			if err != nil {
+				return &remoteImageError{
+					param:   fmt.Sprintf("messages[%d].content[%d].image_url.url", i, j),
+					message: fmt.Sprintf("failed to fetch the image %s: %v", image.ImageURL.URL, err),
+				}
+			}
+			// The content parts are shared with the request, so this updates the request in place.
+			image.ImageURL.URL = dataURI
+		}
+	}
+	return nil
+}
+
+// fetchRemoteImage fetches the image at the url and returns it as the data URI.
+func fetchRemoteImage(ctx context.Context, client *http.Client, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteImageTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("content-type"))
+	if !slices.Contains(remoteImageContentTypes, contentType) {
+		return "", fmt.Errorf("unsupported content type %q: the image must be one of %v", contentType, remoteImageContentTypes)
+	}
+	if resp.ContentLength > remoteImageMaxSize {
+		return "", fmt.Errorf("the image is larger than %d bytes", remoteImageMaxSize)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, remoteImageMaxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(b) > remoteImageMaxSize {
+		return "", fmt.Errorf("the image is larger than %d bytes", remoteImageMaxSize)
+	}
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,193 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestChatCompletion_remoteImages(t *testing.T) {
+	imageServer := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		switch r.URL.Path {
+		case "/cat.png":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"image/png"}},
+				Body:       io.NopCloser(strings.NewReader("test")),
+			}, nil
+		case "/cat.html":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+				Body:       io.NopCloser(strings.NewReader("<html></html>")),
+			}, nil
+		case "/large.png":
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"image/png"}},
+				Body:       io.NopCloser(strings.NewReader(strings.Repeat("a", remoteImageMaxSize+1))),
+			}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+	})
+	newProcessor := func(t *testing.T, allowRemoteImages bool) *chatCompletionProcessor {
+		s, err := NewServer(slog.Default())
+		require.NoError(t, err)
+		s.remoteImageClient = &http.Client{Transport: imageServer}
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			AllowRemoteImages:        allowRemoteImages,
+			Rules: []filterapi.RouteRule{
+				{
+					Backends: []filterapi.Backend{{Name: "bedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1}},
+					Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude"}},
+				},
+				{
+					Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+					Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o"}},
+				},
+			},
+		}))
+		p, err := NewChatCompletionProcessor(s.config.Load(), map[string]string{":path": "/v1/chat/completions", ":method": "POST"}, slog.Default())
+		require.NoError(t, err)
+		return p.(*chatCompletionProcessor)
+	}
+	requestBody := func(model, url string) []byte {
+		return []byte(`{"model":"` + model + `","messages":[{"role":"user","content":[{"type":"text","text":"what is this?"},` +
+			`{"type":"image_url","image_url":{"url":"` + url + `"}}]}]}`)
+	}
+	requireRejected := func(t *testing.T, resp *extprocv3.ProcessingResponse, code, param, message string) {
+		ir := resp.GetImmediateResponse()
+		require.NotNil(t, ir)
+		require.Equal(t, typev3.StatusCode_BadRequest, ir.GetStatus().GetCode())
+		var openAIErr openai.Error
+		require.NoError(t, json.Unmarshal(ir.GetBody(), &openAIErr))
+		require.Equal(t, "invalid_request_error", openAIErr.Error.Type)
+		require.Equal(t, code, *openAIErr.Error.Code)
+		require.Equal(t, param, *openAIErr.Error.Param)
+		require.Equal(t, message, openAIErr.Error.Message)
+	}
+	requireImageBytes := func(t *testing.T, resp *extprocv3.ProcessingResponse, exp string) {
+		var bedrockReq awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(resp.GetRequestBody().GetResponse().GetBodyMutation().GetBody(), &bedrockReq))
+		require.Len(t, bedrockReq.Messages, 1)
+		require.Len(t, bedrockReq.Messages[0].Content, 2)
+		image := bedrockReq.Messages[0].Content[1].Image
+		require.NotNil(t, image)
+		require.Equal(t, "png", image.Format)
+		require.Equal(t, exp, string(image.Source.Bytes))
+	}
+
+	t.Run("data uri", func(t *testing.T) {
+		p := newProcessor(t, false)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: requestBody("claude", "data:image/png;base64,dGVzdA==")})
+		require.NoError(t, err)
+		requireImageBytes(t, resp, "test")
+	})
+	t.Run("remote url rejected", func(t *testing.T) {
+		p := newProcessor(t, false)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: requestBody("claude", "https://images.example.com/cat.png")})
+		require.NoError(t, err)
+		requireRejected(t, resp, "unsupported_image_url", "messages",
+			"image URL is not a data URI: https://images.example.com/cat.png: only data URIs are supported for AWS Bedrock backends")
+	})
+	t.Run("remote url passed through to openai", func(t *testing.T) {
+		p := newProcessor(t, true)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: requestBody("gpt-4o", "https://images.example.com/cat.png")})
+		require.NoError(t, err)
+		require.Nil(t, resp.GetRequestBody().GetResponse().GetBodyMutation())
+	})
+	t.Run("remote url fetched", func(t *testing.T) {
+		p := newProcessor(t, true)
+		resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: requestBody("claude", "https://images.example.com/cat.png")})
+		require.NoError(t, err)
+		requireImageBytes(t, resp, "test")
+	})
+	for _, tc := range []struct {
+		path, message string
+	}{
+		{path: "/missing.png", message: "unexpected status 404"},
+		{path: "/cat.html", message: `unsupported content type "text/html": the image must be one of [image/png image/jpeg image/gif image/webp]`},
+		{path: "/large.png", message: "the image is larger than " + strconv.Itoa(remoteImageMaxSize) + " bytes"},
+	} {
+		t.Run("remote url fetch error "+tc.path, func(t *testing.T) {
+			p := newProcessor(t, true)
+			url := "https://images.example.com" + tc.path
+			resp, err := p.ProcessRequestBody(t.Context(), &extprocv3.HttpBody{Body: requestBody("claude", url)})
+			require.NoError(t, err)
+			requireRejected(t, resp, "invalid_image_url", "messages[0].content[1].image_url.url",
+				"failed to fetch the image "+url+": "+tc.message)
+		})
+	}
+}
+
+func Test_checkRemoteImageAddress(t *testing.T) {
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1:248:1893:25c8:1946]:443"} {
+		require.NoError(t, checkRemoteImageAddress(address), address)
+	}
+	for _, address := range []string{
+		"127.0.0.1:80",
+		"10.0.0.1:80",
+		"172.16.0.1:80",
+		"192.168.1.1:80",
+		"169.254.169.254:80",
+		"100.64.0.1:80",
+		"0.0.0.0:80",
+		"255.255.255.255:80",
+		"224.0.0.1:80",
+		"[::1]:80",
+		"[::]:80",
+		"[fe80::1]:80",
+		"[fd00::1]:80",
+		"[::ffff:127.0.0.1]:80",
+		"[64:ff9b::a9fe:a9fe]:80",
+		"example.com:80",
+	} {
+		require.ErrorIs(t, checkRemoteImageAddress(address), errRemoteImageAddressDenied, address)
+	}
+}
+
+func Test_checkRemoteImageRedirect(t *testing.T) {
+	req := func(url string) *http.Request {
+		r, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		return r
+	}
+	require.NoError(t, checkRemoteImageRedirect(req("https://example.com/cat.png"), make([]*http.Request, remoteImageMaxRedirects)))
+	require.EqualError(t, checkRemoteImageRedirect(req("https://example.com/cat.png"), make([]*http.Request, remoteImageMaxRedirects+1)),
+		"stopped after 3 redirects")
+	require.EqualError(t, checkRemoteImageRedirect(req("file:///etc/passwd"), nil), `redirected to the unsupported scheme "file"`)
+}
+
+func Test_newRemoteImageClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("content-type", "image/png")
+		_, _ = w.Write([]byte("test"))
+	}))
+	defer server.Close()
+
+	// The image served on the loopback address is not fetched.
+	_, err := fetchRemoteImage(t.Context(), newRemoteImageClient(), server.URL+"/cat.png")
+	require.ErrorIs(t, err, errRemoteImageAddressDenied)
+}
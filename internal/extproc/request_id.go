@@ -0,0 +1,44 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+const (
+	// upstreamRequestIDHeaderKey is the response header key of the request ID assigned by the backend.
+	upstreamRequestIDHeaderKey = "x-ai-eg-upstream-request-id"
+	// metadataRequestIDKey and metadataUpstreamRequestIDKey are the dynamic metadata keys of the request ID sent to
+	// the backend and the one assigned by the backend.
+	metadataRequestIDKey         = "request_id"
+	metadataUpstreamRequestIDKey = "upstream_request_id"
+)
+
+// upstreamRequestIDHeaderKeys are the response headers carrying the request ID assigned by the backend, in the order
+// of precedence. OpenAI returns x-request-id, and AWS returns x-amzn-requestid. The x-request-id comes last since
+// some backends echo back the one sent by the gateway, which must not hide their own request ID.
+var upstreamRequestIDHeaderKeys = []string{"openai-request-id", "x-amzn-requestid", "x-request-id"}
+
+// upstreamRequestID returns the request ID assigned by the backend from the response headers, or empty if absent.
+func upstreamRequestID(responseHeaders map[string]string) string {
+	for _, key := range upstreamRequestIDHeaderKeys {
+		if id := responseHeaders[key]; id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// newRequestID generates a random version 4 UUID used as the request ID when the request does not have one.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,37 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamRequestID(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		exp     string
+	}{
+		{name: "none", headers: map[string]string{":status": "200"}},
+		{name: "openai", headers: map[string]string{"x-request-id": "req_1"}, exp: "req_1"},
+		{name: "openai-request-id", headers: map[string]string{"openai-request-id": "req_2"}, exp: "req_2"},
+		{name: "aws", headers: map[string]string{"x-amzn-requestid": "aws-3"}, exp: "aws-3"},
+		{name: "precedence", headers: map[string]string{"x-amzn-requestid": "aws-4", "x-request-id": "req_4"}, exp: "aws-4"},
+		{name: "echoed", headers: map[string]string{"openai-request-id": "req_5", "x-request-id": "gateway-5"}, exp: "req_5"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exp, upstreamRequestID(tc.headers))
+		})
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	id1, id2 := newRequestID(), newRequestID()
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id1)
+	require.NotEqual(t, id1, id2)
+}
@@ -0,0 +1,125 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package router
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// defaultConsecutiveFailures is the default number of the consecutive 5xx responses ejecting the backend.
+	defaultConsecutiveFailures = 5
+	// defaultEjectionCooldown is the default duration for which the ejected backend is not selected.
+	defaultEjectionCooldown = 30 * time.Second
+)
+
+// ResponseObserver is optionally implemented by a router that reacts to the response of the selected backend.
+type ResponseObserver interface {
+	// ObserveResponse is called with the name of the backend selected for the request and the status code of
+	// the response, including the ones generated by Envoy when the backend cannot be connected.
+	ObserveResponse(backendName string, status int)
+}
+
+// backendHealth tracks the recent failures of the backends and ejects the failing ones from the selection.
+type backendHealth struct {
+	mu                  sync.Mutex
+	backends            map[string]*backendHealthState
+	consecutiveFailures int
+	cooldown            time.Duration
+	now                 func() time.Time
+}
+
+// backendHealthState is the health state of a backend.
+type backendHealthState struct {
+	failures     int
+	ejectedUntil time.Time
+}
+
+// newBackendHealth creates a new backendHealth for the given config, or returns nil if the passive health checking
+// is not configured.
+func newBackendHealth(config *filterapi.PassiveHealthCheck) (*backendHealth, error) {
+	if config == nil {
+		return nil, nil
+	}
+	h := &backendHealth{
+		backends:            make(map[string]*backendHealthState),
+		consecutiveFailures: defaultConsecutiveFailures,
+		cooldown:            defaultEjectionCooldown,
+		now:                 time.Now,
+	}
+	if config.ConsecutiveFailures < 0 {
+		return nil, fmt.Errorf("invalid passive health check consecutive failures: must not be negative: %d", config.ConsecutiveFailures)
+	} else if config.ConsecutiveFailures > 0 {
+		h.consecutiveFailures = config.ConsecutiveFailures
+	}
+	if config.Cooldown != "" {
+		cooldown, err := time.ParseDuration(config.Cooldown)
+		if err != nil {
+			return nil, fmt.Errorf("invalid passive health check cooldown: %w", err)
+		}
+		if cooldown <= 0 {
+			return nil, fmt.Errorf("invalid passive health check cooldown: must be positive: %s", config.Cooldown)
+		}
+		h.cooldown = cooldown
+	}
+	return h, nil
+}
+
+// observe records the response of the backend. The backend is ejected for the cooldown when the number of
+// the consecutive 5xx responses reaches the threshold, and the count starts over after the ejection.
+func (h *backendHealth) observe(backendName string, status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.backends[backendName]
+	if status < 500 {
+		if ok {
+			s.failures = 0
+		}
+		return
+	}
+	if !ok {
+		s = &backendHealthState{}
+		h.backends[backendName] = s
+	}
+	if s.failures++; s.failures >= h.consecutiveFailures {
+		s.failures = 0
+		s.ejectedUntil = h.now().Add(h.cooldown)
+	}
+}
+
+// ejected returns the names of the backends of the rule with positive weight that are currently ejected, and the
+// sum of the weights of the rest. This returns nil when none of them is ejected, or when all of them are ejected
+// so that the rule falls back to selecting any of them.
+func (h *backendHealth) ejected(rule *filterapi.RouteRule) (ejected map[string]struct{}, remainingWeight int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.backends) == 0 {
+		return nil, 0
+	}
+	now := h.now()
+	for i := range rule.Backends {
+		b := &rule.Backends[i]
+		if b.Weight <= 0 {
+			continue
+		}
+		if s, ok := h.backends[b.Name]; ok && now.Before(s.ejectedUntil) {
+			if ejected == nil {
+				ejected = make(map[string]struct{})
+			}
+			ejected[b.Name] = struct{}{}
+			continue
+		}
+		remainingWeight += b.Weight
+	}
+	if ejected == nil || remainingWeight == 0 {
+		return nil, 0
+	}
+	return ejected, remainingWeight
+}
@@ -0,0 +1,137 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func Test_newBackendHealth(t *testing.T) {
+	// The passive health checking is opt-in.
+	h, err := newBackendHealth(nil)
+	require.NoError(t, err)
+	require.Nil(t, h)
+
+	h, err = newBackendHealth(&filterapi.PassiveHealthCheck{})
+	require.NoError(t, err)
+	require.Equal(t, defaultConsecutiveFailures, h.consecutiveFailures)
+	require.Equal(t, defaultEjectionCooldown, h.cooldown)
+
+	h, err = newBackendHealth(&filterapi.PassiveHealthCheck{ConsecutiveFailures: 2, Cooldown: "1m"})
+	require.NoError(t, err)
+	require.Equal(t, 2, h.consecutiveFailures)
+	require.Equal(t, time.Minute, h.cooldown)
+
+	for _, tc := range []struct {
+		config *filterapi.PassiveHealthCheck
+		expErr string
+	}{
+		{config: &filterapi.PassiveHealthCheck{ConsecutiveFailures: -1}, expErr: "must not be negative: -1"},
+		{config: &filterapi.PassiveHealthCheck{Cooldown: "foo"}, expErr: `invalid passive health check cooldown: time: invalid duration "foo"`},
+		{config: &filterapi.PassiveHealthCheck{Cooldown: "0s"}, expErr: "must be positive: 0s"},
+	} {
+		_, err = newBackendHealth(tc.config)
+		require.ErrorContains(t, err, tc.expErr)
+	}
+}
+
+func TestBackendHealth(t *testing.T) {
+	h, err := newBackendHealth(&filterapi.PassiveHealthCheck{ConsecutiveFailures: 3, Cooldown: "10s"})
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	h.now = func() time.Time { return now }
+
+	rule := &filterapi.RouteRule{Backends: []filterapi.Backend{
+		{Name: "foo", Weight: 1},
+		{Name: "bar", Weight: 3},
+		{Name: "zero", Weight: 0},
+	}}
+	requireEjected := func(t *testing.T, exp map[string]struct{}, expWeight int) {
+		ejected, weight := h.ejected(rule)
+		require.Equal(t, exp, ejected)
+		require.Equal(t, expWeight, weight)
+	}
+
+	t.Run("non-consecutive failures", func(t *testing.T) {
+		h.observe("foo", 503)
+		h.observe("foo", 500)
+		h.observe("foo", 200)
+		h.observe("foo", 502)
+		h.observe("foo", 429)
+		requireEjected(t, nil, 0)
+	})
+	t.Run("ejected", func(t *testing.T) {
+		for range 3 {
+			h.observe("foo", 503)
+		}
+		requireEjected(t, map[string]struct{}{"foo": {}}, 3)
+	})
+	t.Run("all ejected", func(t *testing.T) {
+		for range 3 {
+			h.observe("bar", 500)
+		}
+		// Falls back to selecting any of them.
+		requireEjected(t, nil, 0)
+	})
+	t.Run("cooldown elapsed", func(t *testing.T) {
+		now = now.Add(10 * time.Second)
+		requireEjected(t, nil, 0)
+		// The count starts over after the ejection.
+		h.observe("foo", 503)
+		requireEjected(t, nil, 0)
+	})
+}
+
+func TestRouter_Calculate_PassiveHealthCheck(t *testing.T) {
+	_r, err := New(&filterapi.Config{
+		PassiveHealthCheck: &filterapi.PassiveHealthCheck{ConsecutiveFailures: 2},
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{
+					{Name: "foo", Weight: 1},
+					{Name: "bar", Weight: 1},
+				},
+				Headers:         []filterapi.HeaderMatch{{Name: "x-model-name", Value: "llama3.3333"}},
+				SessionAffinity: &filterapi.SessionAffinity{HeaderName: "x-user-id"},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	r, ok := _r.(*router)
+	require.True(t, ok)
+
+	r.ObserveResponse("foo", 503)
+	r.ObserveResponse("foo", 503)
+	for range 100 {
+		b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333"})
+		require.NoError(t, err)
+		require.Equal(t, "bar", b.Name)
+	}
+	// The session affinity does not stick to the ejected backend either.
+	for _, user := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": user})
+		require.NoError(t, err)
+		require.Equal(t, "bar", b.Name)
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		_r, err := New(&filterapi.Config{}, nil)
+		require.NoError(t, err)
+		r, ok := _r.(*router)
+		require.True(t, ok)
+		require.Nil(t, r.health)
+		r.ObserveResponse("foo", 503)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := New(&filterapi.Config{PassiveHealthCheck: &filterapi.PassiveHealthCheck{Cooldown: "-1s"}}, nil)
+		require.ErrorContains(t, err, "must be positive")
+	})
+}
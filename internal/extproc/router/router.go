@@ -6,6 +6,9 @@
 package router
 
 import (
+	"hash/fnv"
+	"math"
+	"strings"
 	"time"
 
 	"golang.org/x/exp/rand"
@@ -14,14 +17,28 @@ import (
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
 )
 
-// router implements [x.Router].
+// router implements [x.Router] and [ResponseObserver].
 type router struct {
 	rules []filterapi.RouteRule
+	// defaultRule is the rule selected when no rule matches. Nil when there is none.
+	defaultRule *filterapi.RouteRule
+	// health is the passive health checking of the backends. Nil when disabled.
+	health *backendHealth
 }
 
 // New creates a new [x.Router] implementation for the given config.
 func New(config *filterapi.Config, newCustomFn x.NewCustomRouterFn) (x.Router, error) {
-	r := &router{rules: config.Rules}
+	health, err := newBackendHealth(config.PassiveHealthCheck)
+	if err != nil {
+		return nil, err
+	}
+	r := &router{rules: config.Rules, health: health}
+	for i := range r.rules {
+		if r.rules[i].Default {
+			r.defaultRule = &r.rules[i]
+			break
+		}
+	}
 	if newCustomFn != nil {
 		customRouter := newCustomFn(r, config)
 		return customRouter, nil
@@ -31,50 +48,141 @@ func New(config *filterapi.Config, newCustomFn x.NewCustomRouterFn) (x.Router, e
 
 // Calculate implements [x.Router.Calculate].
 func (r *router) Calculate(headers map[string]string) (backend *filterapi.Backend, err error) {
-	var rule *filterapi.RouteRule
+	var (
+		rule      *filterapi.RouteRule
+		bestScore = -1
+	)
 	for i := range r.rules {
 		_rule := &r.rules[i]
+		if rule != nil && _rule.Priority < rule.Priority {
+			continue
+		}
 		for _, hdr := range _rule.Headers {
-			v, ok := headers[string(hdr.Name)]
-			// Currently, we only do the exact matching.
-			if ok && v == hdr.Value {
-				rule = _rule
-				break
+			// The rule with the greater priority wins regardless of the score, and the later rule wins when both
+			// the priorities and the scores are the same.
+			score := matchScore(headers, &hdr)
+			if score < 0 {
+				continue
+			}
+			if rule == nil || _rule.Priority > rule.Priority || score >= bestScore {
+				rule, bestScore = _rule, score
 			}
 		}
 	}
+	if rule == nil {
+		rule = r.defaultRule
+	}
 	if rule == nil || len(rule.Backends) == 0 {
 		return nil, x.ErrNoMatchingRule
 	}
-	return r.selectBackendFromRule(rule), nil
+	// The backends with zero weight never receive the traffic as in the Gateway API, so the rule fails closed
+	// when none of the backends has a weight.
+	totalWeight := 0
+	for i := range rule.Backends {
+		totalWeight += max(rule.Backends[i].Weight, 0)
+	}
+	if totalWeight == 0 {
+		return nil, x.ErrNoAvailableBackend
+	}
+	var ejected map[string]struct{}
+	if r.health != nil {
+		var remainingWeight int
+		if ejected, remainingWeight = r.health.ejected(rule); ejected != nil {
+			totalWeight = remainingWeight
+		}
+	}
+	if sa := rule.SessionAffinity; sa != nil {
+		if key, ok := headers[sa.HeaderName]; ok && key != "" {
+			return r.selectBackendFromRuleWithKey(rule, key, ejected), nil
+		}
+	}
+	return r.selectBackendFromRule(rule, totalWeight, ejected), nil
 }
 
-// selectBackendFromRule selects a backend from the given rule. Precondition: len(rule.Backends) > 0.
-func (r *router) selectBackendFromRule(rule *filterapi.RouteRule) (backend *filterapi.Backend) {
-	if len(rule.Backends) == 1 {
-		return &rule.Backends[0]
+// ObserveResponse implements [ResponseObserver.ObserveResponse].
+func (r *router) ObserveResponse(backendName string, status int) {
+	if r.health != nil {
+		r.health.observe(backendName, status)
 	}
+}
 
-	// Each backend has a weight, so we randomly select depending on the weight.
-	// This is a pretty naive implementation and can be buggy, so fix it later.
-	totalWeight := 0
-	for _, b := range rule.Backends {
-		totalWeight += b.Weight
+// matchScore returns the score of the header match against the given headers, or -1 if it does not match.
+// The exact match always wins over the prefix matches, and the longer prefix wins over the shorter ones.
+func matchScore(headers map[string]string, hdr *filterapi.HeaderMatch) int {
+	v, ok := headers[string(hdr.Name)]
+	if !ok {
+		return -1
 	}
-
-	rng := rand.New(rand.NewSource(uint64(time.Now().UnixNano()))) // nolint:gosec
-	// Pick a random backend if none of them have a weight.
-	if totalWeight == 0 {
-		return &rule.Backends[rng.Intn(len(rule.Backends))]
+	if hdr.Type != nil && *hdr.Type == filterapi.HeaderMatchPrefix {
+		if strings.HasPrefix(v, hdr.Value) {
+			return len(hdr.Value)
+		}
+		return -1
+	}
+	// Currently, we only do the exact matching other than the prefix matching.
+	if v == hdr.Value {
+		return math.MaxInt
 	}
+	return -1
+}
 
+// selectBackendFromRule selects a backend from the given rule randomly depending on the weights, skipping the
+// ejected backends. Precondition: totalWeight is the sum of the positive weights of the backends that are not
+// ejected and is greater than zero.
+func (r *router) selectBackendFromRule(rule *filterapi.RouteRule, totalWeight int, ejected map[string]struct{}) (backend *filterapi.Backend) {
+	rng := rand.New(rand.NewSource(uint64(time.Now().UnixNano()))) // nolint:gosec
 	selected := rng.Intn(totalWeight)
 	for i := range rule.Backends {
 		b := &rule.Backends[i]
+		if _, ok := ejected[b.Name]; ok || b.Weight <= 0 {
+			continue
+		}
 		if selected < b.Weight {
 			return b
 		}
 		selected -= b.Weight
 	}
-	return &rule.Backends[0]
+	panic("BUG: the selection must not exceed the total weight")
+}
+
+// selectBackendFromRuleWithKey selects a backend from the given rule deterministically for the given key
+// using the weighted rendezvous hashing, skipping the ejected backends. Precondition: at least one backend that is
+// not ejected has a positive weight.
+//
+// Each backend gets the score of -weight/ln(h) where h is the hash of the pair of the key and the backend name
+// mapped onto (0, 1), and the backend with the highest score is selected. This makes the probability of selecting
+// a backend proportional to its weight across keys, while the selection for a fixed key is stable, and adding or
+// removing a backend only remaps the keys that belong to that backend.
+func (r *router) selectBackendFromRuleWithKey(rule *filterapi.RouteRule, key string, ejected map[string]struct{}) (backend *filterapi.Backend) {
+	bestScore := math.Inf(-1)
+	for i := range rule.Backends {
+		b := &rule.Backends[i]
+		weight := float64(b.Weight)
+		if _, ok := ejected[b.Name]; ok || weight <= 0 {
+			continue
+		}
+		score := -weight / math.Log(hashToUnitInterval(key, b.Name))
+		if backend == nil || score > bestScore {
+			bestScore = score
+			backend = b
+		}
+	}
+	return backend
+}
+
+// hashToUnitInterval hashes the pair of the key and the backend name into the open interval (0, 1).
+func hashToUnitInterval(key, backendName string) float64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(backendName))
+	// FNV alone does not mix the high bits well for similar inputs, so apply the splitmix64 finalizer.
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	// Use the top 53 bits to get a uniformly distributed float64 and shift it by a half step to exclude 0 and 1.
+	return (float64(x>>11) + 0.5) / (1 << 53)
 }
@@ -6,11 +6,14 @@
 package router
 
 import (
+	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
@@ -55,8 +58,8 @@ func TestRouter_Calculate(t *testing.T) {
 			},
 			{
 				Backends: []filterapi.Backend{
-					{Name: "baz", Schema: outSchema},
-					{Name: "qux", Schema: outSchema},
+					{Name: "baz", Schema: outSchema, Weight: 1},
+					{Name: "qux", Schema: outSchema, Weight: 1},
 				},
 				Headers: []filterapi.HeaderMatch{
 					{Name: "x-model-name", Value: "o1"},
@@ -64,12 +67,38 @@ func TestRouter_Calculate(t *testing.T) {
 			},
 			{
 				Backends: []filterapi.Backend{
-					{Name: "openai", Schema: outSchema},
+					{Name: "openai", Schema: outSchema, Weight: 1},
 				},
 				Headers: []filterapi.HeaderMatch{
 					{Name: "x-model-name", Value: "gpt4.4444"},
 				},
 			},
+			{
+				Backends: []filterapi.Backend{
+					{Name: "drained", Schema: outSchema, Weight: 0},
+					{Name: "active", Schema: outSchema, Weight: 2},
+				},
+				Headers: []filterapi.HeaderMatch{
+					{Name: "x-model-name", Value: "mixed"},
+				},
+			},
+			{
+				Backends: []filterapi.Backend{
+					{Name: "drained1", Schema: outSchema},
+					{Name: "drained2", Schema: outSchema, Weight: 0},
+				},
+				Headers: []filterapi.HeaderMatch{
+					{Name: "x-model-name", Value: "all-zero"},
+				},
+			},
+			{
+				Backends: []filterapi.Backend{
+					{Name: "drained", Schema: outSchema, Weight: 0},
+				},
+				Headers: []filterapi.HeaderMatch{
+					{Name: "x-model-name", Value: "single-zero"},
+				},
+			},
 		},
 	}, nil)
 	require.NoError(t, err)
@@ -87,7 +116,21 @@ func TestRouter_Calculate(t *testing.T) {
 		require.Equal(t, "openai", b.Name)
 		require.Equal(t, outSchema, b.Schema)
 	})
-	t.Run("matching rule - multiple unweighted backend choices", func(t *testing.T) {
+	t.Run("matching rule - zero weight backend never selected", func(t *testing.T) {
+		for range 1000 {
+			b, err := r.Calculate(map[string]string{"x-model-name": "mixed"})
+			require.NoError(t, err)
+			require.Equal(t, "active", b.Name)
+		}
+	})
+	t.Run("matching rule - all zero weight backends", func(t *testing.T) {
+		for _, model := range []string{"all-zero", "single-zero"} {
+			b, err := r.Calculate(map[string]string{"x-model-name": model})
+			require.ErrorIs(t, err, x.ErrNoAvailableBackend)
+			require.Nil(t, b)
+		}
+	})
+	t.Run("matching rule - multiple equally weighted backend choices", func(t *testing.T) {
 		chosenNames := make(map[string]int)
 		for i := 0; i < 1000; i++ {
 			b, err := r.Calculate(map[string]string{"x-model-name": "o1"})
@@ -141,6 +184,144 @@ func TestRouter_Calculate(t *testing.T) {
 	})
 }
 
+func TestRouter_Calculate_Prefix(t *testing.T) {
+	prefix := filterapi.HeaderMatchPrefix
+	exact := gwapiv1.HeaderMatchExact
+	_r, err := New(&filterapi.Config{
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "claude-exact", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-3-5-sonnet", Type: &exact}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude-3-5", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-3-5", Type: &prefix}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude", Type: &prefix}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude-3", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-3", Type: &prefix}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "gpt", Weight: 1}},
+				Headers: []filterapi.HeaderMatch{
+					{Name: "x-model-name", Value: "gpt-4o"},
+					{Name: "x-model-name", Value: "gpt-", Type: &prefix},
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		model  string
+		exp    string
+		expErr bool
+	}{
+		{model: "claude-3-5-sonnet", exp: "claude-exact"},
+		{model: "claude-3-5-sonnet-v2", exp: "claude-3-5"},
+		{model: "claude-3-5", exp: "claude-3-5"},
+		{model: "claude-3-opus", exp: "claude-3"},
+		{model: "claude-2", exp: "claude"},
+		{model: "claude", exp: "claude"},
+		{model: "gpt-4o", exp: "gpt"},
+		{model: "gpt-4o-mini", exp: "gpt"},
+		{model: "claud", expErr: true},
+		{model: "llama", expErr: true},
+	} {
+		t.Run(tc.model, func(t *testing.T) {
+			b, err := _r.Calculate(map[string]string{"x-model-name": tc.model})
+			if tc.expErr {
+				require.ErrorIs(t, err, x.ErrNoMatchingRule)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, b.Name)
+		})
+	}
+}
+
+func TestRouter_Calculate_Priority(t *testing.T) {
+	prefix := filterapi.HeaderMatchPrefix
+	_r, err := New(&filterapi.Config{
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "claude-exact", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-3-5-sonnet"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude-prioritized", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude", Type: &prefix}},
+				Priority: 10,
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude-3", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-3", Type: &prefix}},
+				Priority: 10,
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude-3-later", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-3", Type: &prefix}},
+				Priority: 10,
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "claude-deprioritized", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "claude-2"}},
+				Priority: -1,
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		model string
+		exp   string
+	}{
+		// The greater priority wins over the exact match.
+		{model: "claude-3-5-sonnet", exp: "claude-3-later"},
+		{model: "claude-2", exp: "claude-prioritized"},
+		// The longer prefix wins among the same priority, and the later rule wins the ties.
+		{model: "claude-3-opus", exp: "claude-3-later"},
+		{model: "claude-instant", exp: "claude-prioritized"},
+	} {
+		t.Run(tc.model, func(t *testing.T) {
+			b, err := _r.Calculate(map[string]string{"x-model-name": tc.model})
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, b.Name)
+		})
+	}
+}
+
+func TestRouter_Calculate_Default(t *testing.T) {
+	_r, err := New(&filterapi.Config{
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "gpt", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "fallback", Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "llama3"}},
+				Default:  true,
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	for model, exp := range map[string]string{"gpt-4o": "gpt", "llama3": "fallback", "unknown": "fallback"} {
+		b, err := _r.Calculate(map[string]string{"x-model-name": model})
+		require.NoError(t, err)
+		require.Equal(t, exp, b.Name, model)
+	}
+	// The default rule is selected even without the model header.
+	b, err := _r.Calculate(map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, "fallback", b.Name)
+}
+
 func TestRouter_selectBackendFromRule(t *testing.T) {
 	_r, err := New(&filterapi.Config{}, nil)
 	require.NoError(t, err)
@@ -158,11 +339,130 @@ func TestRouter_selectBackendFromRule(t *testing.T) {
 
 	chosenNames := make(map[string]int)
 	for i := 0; i < 1000; i++ {
-		b := r.selectBackendFromRule(rule)
+		b := r.selectBackendFromRule(rule, 4, nil)
 		chosenNames[b.Name]++
 	}
 
 	require.Greater(t, chosenNames["bar"], chosenNames["foo"])
 	require.Greater(t, chosenNames["bar"], 700)
 	require.Greater(t, chosenNames["foo"], 200)
+
+	zeroWeightRule := &filterapi.RouteRule{
+		Backends: []filterapi.Backend{
+			{Name: "foo", Schema: outSchema, Weight: 0},
+			{Name: "bar", Schema: outSchema, Weight: 1},
+			{Name: "baz", Schema: outSchema, Weight: 0},
+		},
+	}
+	for range 1000 {
+		require.Equal(t, "bar", r.selectBackendFromRule(zeroWeightRule, 1, nil).Name)
+	}
+}
+
+func TestRouter_Calculate_SessionAffinity(t *testing.T) {
+	outSchema := filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}
+	_r, err := New(&filterapi.Config{
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{
+					{Name: "foo", Schema: outSchema, Weight: 1},
+					{Name: "bar", Schema: outSchema, Weight: 3},
+				},
+				Headers:         []filterapi.HeaderMatch{{Name: "x-model-name", Value: "llama3.3333"}},
+				SessionAffinity: &filterapi.SessionAffinity{HeaderName: "x-user-id"},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	r, ok := _r.(*router)
+	require.True(t, ok)
+
+	t.Run("sticky for the same key", func(t *testing.T) {
+		for _, key := range []string{"alice", "bob", "charlie"} {
+			first, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": key})
+			require.NoError(t, err)
+			for range 100 {
+				b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": key})
+				require.NoError(t, err)
+				require.Equal(t, first.Name, b.Name)
+			}
+		}
+	})
+	t.Run("distribution across keys follows weights", func(t *testing.T) {
+		chosenNames := make(map[string]int)
+		for i := range 10000 {
+			b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333", "x-user-id": fmt.Sprintf("user-%d", i)})
+			require.NoError(t, err)
+			chosenNames[b.Name]++
+		}
+		// The keys are hashed deterministically, so this is stable. The tolerance is about 3.5 times the standard
+		// deviation of the binomial distribution, sqrt(10000*0.25*0.75) = 43, for a good hash function.
+		require.InDelta(t, 7500, chosenNames["bar"], 150)
+		require.InDelta(t, 2500, chosenNames["foo"], 150)
+	})
+	t.Run("header absent falls back to weighted random", func(t *testing.T) {
+		chosenNames := make(map[string]int)
+		for range 1000 {
+			b, err := r.Calculate(map[string]string{"x-model-name": "llama3.3333"})
+			require.NoError(t, err)
+			chosenNames[b.Name]++
+		}
+		require.Greater(t, chosenNames["bar"], 700)
+		require.Greater(t, chosenNames["foo"], 200)
+	})
+}
+
+func TestRouter_selectBackendFromRuleWithKey(t *testing.T) {
+	_r, err := New(&filterapi.Config{}, nil)
+	require.NoError(t, err)
+	r, ok := _r.(*router)
+	require.True(t, ok)
+
+	t.Run("equal weights", func(t *testing.T) {
+		rule := &filterapi.RouteRule{
+			Backends: []filterapi.Backend{{Name: "foo", Weight: 1}, {Name: "bar", Weight: 1}},
+		}
+		chosenNames := make(map[string]int)
+		for i := range 10000 {
+			b := r.selectBackendFromRuleWithKey(rule, strconv.Itoa(i), nil)
+			chosenNames[b.Name]++
+		}
+		// The keys are hashed deterministically, so this is stable. The tolerance is 3 times the standard deviation
+		// of the binomial distribution, sqrt(10000*0.5*0.5) = 50, for a good hash function.
+		require.InDelta(t, 5000, chosenNames["foo"], 150)
+		require.InDelta(t, 5000, chosenNames["bar"], 150)
+	})
+	t.Run("zero weight backend never selected", func(t *testing.T) {
+		rule := &filterapi.RouteRule{
+			Backends: []filterapi.Backend{{Name: "foo", Weight: 0}, {Name: "bar", Weight: 1}},
+		}
+		for i := range 1000 {
+			b := r.selectBackendFromRuleWithKey(rule, strconv.Itoa(i), nil)
+			require.Equal(t, "bar", b.Name)
+		}
+	})
+	t.Run("removing a backend only remaps its keys", func(t *testing.T) {
+		rule := &filterapi.RouteRule{
+			Backends: []filterapi.Backend{{Name: "foo", Weight: 1}, {Name: "bar", Weight: 1}, {Name: "baz", Weight: 1}},
+		}
+		reduced := &filterapi.RouteRule{Backends: rule.Backends[:2]}
+		for i := range 1000 {
+			key := strconv.Itoa(i)
+			before := r.selectBackendFromRuleWithKey(rule, key, nil)
+			after := r.selectBackendFromRuleWithKey(reduced, key, nil)
+			if before.Name != "baz" {
+				require.Equal(t, before.Name, after.Name)
+			}
+		}
+	})
+}
+
+func Test_hashToUnitInterval(t *testing.T) {
+	for i := range 1000 {
+		h := hashToUnitInterval(strconv.Itoa(i), "backend")
+		require.Greater(t, h, 0.0)
+		require.Less(t, h, 1.0)
+	}
+	require.Equal(t, hashToUnitInterval("key", "backend"), hashToUnitInterval("key", "backend"))
+	require.NotEqual(t, hashToUnitInterval("key", "backend"), hashToUnitInterval("key", "backend2"))
 }
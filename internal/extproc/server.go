@@ -6,18 +6,26 @@
 package extproc
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"os"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode/utf8"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/google/cel-go/cel"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
@@ -27,6 +35,7 @@ import (
 	"github.com/envoyproxy/ai-gateway/filterapi/x"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/backendauth"
 	"github.com/envoyproxy/ai-gateway/internal/extproc/router"
+	"github.com/envoyproxy/ai-gateway/internal/extproc/translator"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
 
@@ -37,20 +46,79 @@ var (
 
 // Server implements the external processor server.
 type Server struct {
-	logger     *slog.Logger
-	config     *processorConfig
-	processors map[string]ProcessorFactory
+	logger *slog.Logger
+	// config is the configuration currently loaded. It is swapped as a whole on reload and never mutated once
+	// stored, and each stream uses the one loaded at its start for its lifetime, so the streams in flight keep
+	// working with the backends removed or renamed by the reload.
+	config             atomic.Pointer[processorConfig]
+	processors         map[string]ProcessorFactory
+	concurrencyLimiter *concurrencyLimiter
+	// streamBufferOverflows counts the streaming responses aborted because of exceeding the buffering limit.
+	streamBufferOverflows atomic.Uint64
+	// streamExceptions counts the streaming responses terminated by an exception from the upstream.
+	streamExceptions atomic.Uint64
+	// noMatchingRules counts the requests rejected because no rule matches them.
+	noMatchingRules atomic.Uint64
+	// bufferLimiter accounts the bytes of the request bodies buffered by the in-flight streams.
+	bufferLimiter bufferLimiter
+	// maxBufferedBytes is the limit of bufferLimiter used when the configuration does not set it.
+	maxBufferedBytes int64
+	tracer           trace.Tracer
+	// httpClient is used to send the requests issued by the external processor itself, such as the choices fan-out.
+	httpClient *http.Client
+	// remoteImageClient is used to fetch the images referred by the URLs in the requests. See newRemoteImageClient.
+	remoteImageClient *http.Client
+	// processorPatterns is the registered paths containing the path parameters, in the order of the registration.
+	processorPatterns []string
+	// accessLogSink is the default sink of the access log records used unless x.CustomAccessLogSink is set.
+	accessLogSink x.AccessLogSink
+	// debugTranslate enables the translation dry-run debugging endpoint.
+	debugTranslate bool
+	// notServing makes the health check report NOT_SERVING, e.g. while the config watcher is down.
+	notServing atomic.Bool
+	// stateStore is the state store of the configuration currently loaded. Nil when not configured.
+	stateStore   *redisStateStore
+	stateStoreMu sync.Mutex
+	// bodyDump is the runtime toggle of the dump of the bodies enabled by the debugging endpoint.
+	bodyDump *bodyDump
 }
 
 // NewServer creates a new external processor server.
 func NewServer(logger *slog.Logger) (*Server, error) {
 	srv := &Server{
-		logger:     logger,
-		processors: make(map[string]ProcessorFactory),
+		logger:             logger,
+		processors:         make(map[string]ProcessorFactory),
+		concurrencyLimiter: newConcurrencyLimiter(),
+		tracer:             defaultTracer(),
+		httpClient:         &http.Client{},
+		remoteImageClient:  newRemoteImageClient(),
+		bodyDump:           newBodyDump(),
 	}
+	srv.accessLogSink = &jsonAccessLogSink{logger: logger, w: os.Stdout}
 	return srv, nil
 }
 
+// SetMaxBufferedBytes sets the maximum number of bytes of the request bodies buffered by all the in-flight streams,
+// used when the configuration does not set filterapi.Config.MaxBufferedBytes. A non-positive value means unlimited.
+//
+// This must be called before the server starts serving.
+func (s *Server) SetMaxBufferedBytes(n int64) {
+	s.maxBufferedBytes = n
+}
+
+// SetDebugTranslate enables the "POST /debug/translate" endpoint of [Server.DebugHandler], which runs the translators
+// on the given request or response without sending anything to the backends. Disabled by default.
+//
+// This must be called before [Server.DebugHandler].
+func (s *Server) SetDebugTranslate(enabled bool) {
+	s.debugTranslate = enabled
+}
+
+// SetServing sets whether the health check reports SERVING or NOT_SERVING. The server reports SERVING by default.
+func (s *Server) SetServing(serving bool) {
+	s.notServing.Store(!serving)
+}
+
 // LoadConfig updates the configuration of the external processor.
 func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error {
 	rt, err := router.New(config, x.NewCustomRouter)
@@ -60,10 +128,14 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 
 	var (
 		backendAuthHandlers = make(map[string]backendauth.Handler)
+		backendRuleIndexes  = make(map[*filterapi.Backend]int)
 		declaredModels      []string
 	)
-	for _, r := range config.Rules {
-		for _, b := range r.Backends {
+	for i := range config.Rules {
+		r := &config.Rules[i]
+		for j := range r.Backends {
+			b := &r.Backends[j]
+			backendRuleIndexes[b] = i
 			if b.Auth != nil {
 				backendAuthHandlers[b.Name], err = backendauth.NewHandler(ctx, b.Auth)
 				if err != nil {
@@ -81,6 +153,10 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 			if h.Type != nil && *h.Type != gwapiv1.HeaderMatchExact {
 				continue
 			}
+			// The models that cannot be requested are not listed either.
+			if !modelAllowed(config.ModelPolicy, h.Value) {
+				continue
+			}
 			declaredModels = append(declaredModels, h.Value)
 		}
 	}
@@ -98,40 +174,235 @@ func (s *Server) LoadConfig(ctx context.Context, config *filterapi.Config) error
 		costs = append(costs, processorConfigRequestCost{LLMRequestCost: c, celProg: prog})
 	}
 
+	var heartbeatInterval time.Duration
+	if sc := config.Streaming; sc != nil && sc.HeartbeatInterval != "" {
+		heartbeatInterval, err = time.ParseDuration(sc.HeartbeatInterval)
+		if err != nil {
+			return fmt.Errorf("invalid streaming heartbeat interval: %w", err)
+		}
+		if heartbeatInterval < 0 {
+			return fmt.Errorf("invalid streaming heartbeat interval: must not be negative: %s", sc.HeartbeatInterval)
+		}
+	}
+
+	stateStore, err := s.loadStateStore(config.StateStore)
+	if err != nil {
+		return err
+	}
+
 	newConfig := &processorConfig{
-		uuid:                     config.UUID,
-		schema:                   config.Schema,
-		router:                   rt,
-		selectedBackendHeaderKey: config.SelectedBackendHeaderKey,
-		modelNameHeaderKey:       config.ModelNameHeaderKey,
-		backendAuthHandlers:      backendAuthHandlers,
-		metadataNamespace:        config.MetadataNamespace,
-		requestCosts:             costs,
-		declaredModels:           declaredModels,
-	}
-	s.config = newConfig // This is racey, but we don't care.
+		uuid:                         config.UUID,
+		loadedAt:                     time.Now(),
+		emitConfigVersionHeader:      config.EmitConfigVersionHeader,
+		allowedPaths:                 config.AllowedPaths,
+		modelPolicy:                  config.ModelPolicy,
+		filterConfig:                 config,
+		schema:                       config.Schema,
+		router:                       rt,
+		selectedBackendHeaderKey:     config.SelectedBackendHeaderKey,
+		modelNameHeaderKey:           config.ModelNameHeaderKey,
+		backendAuthHandlers:          backendAuthHandlers,
+		backendRuleIndexes:           backendRuleIndexes,
+		rules:                        config.Rules,
+		metadataNamespace:            config.MetadataNamespace,
+		requestCosts:                 costs,
+		emitCostHeaders:              config.EmitCostHeaders,
+		emitLatencyHeaders:           config.EmitLatencyHeaders,
+		defaultRouteDisabled:         config.DefaultRouteDisabled,
+		declaredModels:               declaredModels,
+		concurrencyLimiter:           s.concurrencyLimiter,
+		stateStore:                   stateStore,
+		maxChoices:                   cmp.Or(config.MaxChoices, defaultMaxChoices),
+		maxStreamBufferSize:          cmp.Or(config.MaxStreamBufferSize, translator.DefaultMaxStreamBufferSize),
+		allowRemoteImages:            config.AllowRemoteImages,
+		pathPrefix:                   config.PathPrefix,
+		maxBufferedBytes:             cmp.Or(config.MaxBufferedBytes, s.maxBufferedBytes),
+		streamBufferOverflows:        &s.streamBufferOverflows,
+		streamExceptions:             &s.streamExceptions,
+		noMatchingRules:              &s.noMatchingRules,
+		httpClient:                   s.httpClient,
+		remoteImageClient:            s.remoteImageClient,
+		routeName:                    config.RouteName,
+		heartbeatInterval:            heartbeatInterval,
+		requestIDPropagationDisabled: config.RequestIDPropagationDisabled,
+	}
+	if al := config.AccessLog; al != nil {
+		newConfig.accessLogSink = s.accessLogSink
+		if x.CustomAccessLogSink != nil {
+			newConfig.accessLogSink = x.CustomAccessLogSink
+		}
+		newConfig.accessLogSampleRate = 1
+		if al.SampleRate != nil {
+			newConfig.accessLogSampleRate = *al.SampleRate
+		}
+	}
+	if config.EstimateInputTokens {
+		newConfig.inputTokenEstimator = defaultInputTokenEstimator{}
+		if x.CustomInputTokenEstimator != nil {
+			newConfig.inputTokenEstimator = x.CustomInputTokenEstimator
+		}
+	}
+	if cl := config.ConcurrencyLimit; cl != nil {
+		newConfig.concurrencyLimit = &filterapi.ConcurrencyLimit{
+			// Envoy passes the request header names in lower case.
+			IdentityHeaderKey:       strings.ToLower(cl.IdentityHeaderKey),
+			MaxStreamingRequests:    cl.MaxStreamingRequests,
+			MaxNonStreamingRequests: cl.MaxNonStreamingRequests,
+		}
+	}
+	s.config.Store(newConfig)
 	return nil
 }
 
+// loadStateStore returns the state store of the configuration, reusing the current one when the configuration of
+// the store is unchanged. The current one is closed when replaced, and the leases it holds for the streams in flight
+// expire in the store instead of being released.
+func (s *Server) loadStateStore(config *filterapi.StateStore) (*redisStateStore, error) {
+	s.stateStoreMu.Lock()
+	defer s.stateStoreMu.Unlock()
+	if s.stateStore != nil && config != nil && s.stateStore.config == *config {
+		return s.stateStore, nil
+	}
+	var store *redisStateStore
+	if config != nil {
+		var err error
+		if store, err = newRedisStateStore(config); err != nil {
+			return nil, err
+		}
+	}
+	if s.stateStore != nil {
+		if err := s.stateStore.close(); err != nil {
+			s.logger.Error("cannot close the state store", "error", err)
+		}
+	}
+	s.stateStore = store
+	return store, nil
+}
+
+// currentStateStore returns the state store of the configuration currently loaded. Nil when not configured.
+func (s *Server) currentStateStore() *redisStateStore {
+	s.stateStoreMu.Lock()
+	defer s.stateStoreMu.Unlock()
+	return s.stateStore
+}
+
 // Register a new processor for the given request path.
+//
+// The path can contain the path parameters of the form "{name}", each matching a single non-empty path segment,
+// such as "/model/{modelId}/converse". The exact paths take precedence over the ones with the path parameters.
 func (s *Server) Register(path string, newProcessor ProcessorFactory) {
+	if _, ok := s.processors[path]; !ok && strings.Contains(path, "{") {
+		s.processorPatterns = append(s.processorPatterns, path)
+	}
 	s.processors[path] = newProcessor
 }
 
+// lookupProcessor returns the processor factory registered for the given path, if any.
+func (s *Server) lookupProcessor(path string) (ProcessorFactory, bool) {
+	if newProcessor, ok := s.processors[path]; ok {
+		return newProcessor, true
+	}
+	path, _, _ = strings.Cut(path, "?")
+	for _, pattern := range s.processorPatterns {
+		if matchPathPattern(pattern, path) {
+			return s.processors[pattern], true
+		}
+	}
+	return nil, false
+}
+
+// matchPathPattern returns true if the path matches the pattern containing the path parameters.
+func matchPathPattern(pattern, path string) bool {
+	patternSegments, pathSegments := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, seg := range patternSegments {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if pathSegments[i] == "" {
+				return false
+			}
+		} else if seg != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // processorForPath returns the processor for the given path.
-// Only exact path matching is supported currently
-func (s *Server) processorForPath(requestHeaders map[string]string) (Processor, error) {
+//
+// The path prefix of the configuration, if any, is removed from the :path header before the path matching,
+// so that the processor sees the path as if the endpoint were exposed without the prefix.
+func (s *Server) processorForPath(config *processorConfig, requestHeaders map[string]string, logger *slog.Logger) (Processor, error) {
 	path := requestHeaders[":path"]
-	newProcessor, ok := s.processors[path]
+	if prefix := config.pathPrefix; prefix != "" {
+		if trimmed, ok := strings.CutPrefix(path, prefix); ok && strings.HasPrefix(trimmed, "/") {
+			path = trimmed
+			requestHeaders[":path"] = path
+		}
+	}
+	if !pathAllowed(config.allowedPaths, path) {
+		return &notFoundProcessor{logger: logger, path: path}, nil
+	}
+	newProcessor, ok := s.lookupProcessor(path)
 	if !ok {
+		if config.defaultRouteDisabled {
+			// There is no backend to pass the request through to, so reject it here.
+			return &notFoundProcessor{logger: logger, path: path}, nil
+		}
 		return nil, fmt.Errorf("no processor defined for path: %v", path)
 	}
-	return newProcessor(s.config, requestHeaders, s.logger)
+	return newProcessor(config, requestHeaders, logger)
+}
+
+// pathAllowed returns true if the path matches one of the allowed paths, or if allowedPaths is empty.
+func pathAllowed(allowedPaths []string, path string) bool {
+	if len(allowedPaths) == 0 {
+		return true
+	}
+	path, _, _ = strings.Cut(path, "?")
+	for _, allowed := range allowedPaths {
+		if matchPathPattern(allowed, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// heartbeatTicksPerInterval is the number of times per heartbeat interval the processor is asked for a heartbeat,
+// which bounds how late the heartbeat can be relative to the interval.
+const heartbeatTicksPerInterval = 4
+
+// receivedMessage is the result of [extprocv3.ExternalProcessor_ProcessServer.Recv].
+type receivedMessage struct {
+	req *extprocv3.ProcessingRequest
+	err error
+}
+
+// receiveMessages receives the messages from the stream and passes them to received until the stream ends or
+// done is closed.
+func receiveMessages(stream extprocv3.ExternalProcessor_ProcessServer, received chan<- receivedMessage, done <-chan struct{}) {
+	for {
+		req, err := stream.Recv()
+		select {
+		case received <- receivedMessage{req: req, err: err}:
+		case <-done:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
 }
 
 // Process implements [extprocv3.ExternalProcessorServer].
-func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
-	s.logger.Debug("handling a new stream", slog.Any("config_uuid", s.config.uuid))
+func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) (retErr error) {
+	// The configuration may be reloaded during the stream, so the stream uses the one at its start for its lifetime.
+	config := s.config.Load()
+	// Every log entry of the stream carries the UUID of the configuration so that it can be told which
+	// configuration served the request during a rollout.
+	logger := s.logger.With(slog.String("config_uuid", config.uuid))
+	logger.Debug("handling a new stream")
 	ctx := stream.Context()
 
 	// The processor will be instantiated when the first message containing the request headers is received.
@@ -142,6 +413,42 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 	// the request by sending an immediate response. In this case, we will use the passThroughProcessor
 	// to pass the request through without any processing as there would be nothing to process from AI Gateway's perspective.
 	var p Processor = passThroughProcessor{}
+	// The span is started when the request headers are received so that it can join the trace propagated by Envoy.
+	var span trace.Span
+	bufferReservation := &streamBufferReservation{limiter: &s.bufferLimiter}
+	accessLog := &streamAccessLog{}
+	// bodyDump is non-nil when the bodies of the request are sampled to be dumped.
+	var bodyDump *streamBodyDump
+	defer func() {
+		var errMsg string
+		if retErr != nil {
+			errMsg = status.Convert(retErr).Message()
+		}
+		// The stream context may have been canceled at this point.
+		accessLog.maybeEmit(context.WithoutCancel(stream.Context()), config, p, errMsg)
+		// Release the per-stream resources such as the concurrency limit counters however the stream ends.
+		if c, ok := p.(processorCloser); ok {
+			c.close()
+		}
+		bufferReservation.release()
+		if span != nil {
+			span.End()
+		}
+	}()
+
+	// When the heartbeats are enabled, the messages are received in a separate goroutine so that the heartbeats can be
+	// sent while waiting for them. Otherwise, they are received in place.
+	var received chan receivedMessage
+	var heartbeatTicks <-chan time.Time
+	if interval := config.heartbeatInterval; interval > 0 {
+		received = make(chan receivedMessage)
+		receiverDone := make(chan struct{})
+		defer close(receiverDone)
+		go receiveMessages(stream, received, receiverDone)
+		ticker := time.NewTicker(interval / heartbeatTicksPerInterval)
+		defer ticker.Stop()
+		heartbeatTicks = ticker.C
+	}
 
 	for {
 		select {
@@ -150,11 +457,30 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 		default:
 		}
 
-		req, err := stream.Recv()
+		var req *extprocv3.ProcessingRequest
+		var err error
+		if received == nil {
+			req, err = stream.Recv()
+		} else {
+			select {
+			case now := <-heartbeatTicks:
+				if h, ok := p.(processorHeartbeater); ok {
+					if resp := h.heartbeat(now); resp != nil {
+						if err = stream.Send(resp); err != nil {
+							logger.Error("cannot send heartbeat", slog.String("error", err.Error()))
+							return status.Errorf(codes.Unknown, "cannot send heartbeat: %v", err)
+						}
+					}
+				}
+				continue
+			case msg := <-received:
+				req, err = msg.req, msg.err
+			}
+		}
 		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
 			return nil
 		} else if err != nil {
-			s.logger.Error("cannot receive stream request", slog.String("error", err.Error()))
+			logger.Error("cannot receive stream request", slog.String("error", err.Error()))
 			return status.Errorf(codes.Unknown, "cannot receive stream request: %v", err)
 		}
 
@@ -163,50 +489,117 @@ func (s *Server) Process(stream extprocv3.ExternalProcessor_ProcessServer) error
 		// Note that `req.GetRequestHeaders()` will only return non-nil if the request is
 		// of type `ProcessingRequest_RequestHeaders`, so this will be executed only once per
 		// request, and the processor will be instantiated only once.
+		accessLog.observeRequest(req)
 		if headers := req.GetRequestHeaders().GetHeaders(); headers != nil {
-			p, err = s.processorForPath(headersToMap(headers))
+			headersMap := headersToMap(headers)
+			if span == nil {
+				ctx, span = s.startSpan(ctx, headersMap)
+				span.SetAttributes(spanAttrConfigUUID.String(config.uuid))
+			}
+			p, err = s.processorForPath(config, headersMap, logger)
 			if err != nil {
-				s.logger.Error("cannot get processor", slog.String("error", err.Error()))
+				logger.Error("cannot get processor", slog.String("error", err.Error()))
+				recordSpanError(span, err)
 				return status.Error(codes.NotFound, err.Error())
 			}
+			bodyDump = s.bodyDump.newStream(logger, headersMap)
 		}
 
 		// At this point, p is guaranteed to be a valid processor either from the concrete processor or the passThroughProcessor.
 
-		resp, err := s.processMsg(ctx, p, req)
+		if resp := s.maybeRejectOverBufferLimit(config, req, bufferReservation, logger); resp != nil {
+			accessLog.observeResponse(resp)
+			// Envoy ends the stream with the immediate response, so there is nothing more to process.
+			if err := stream.Send(resp); err != nil {
+				logger.Error("cannot send response", slog.String("error", err.Error()))
+				return status.Errorf(codes.Unknown, "cannot send response: %v", err)
+			}
+			return nil
+		}
+
+		resp, err := s.processMsg(ctx, p, req, logger)
 		if err != nil {
-			s.logger.Error("error processing request message", slog.String("error", err.Error()))
+			logger.Error("error processing request message", slog.String("error", err.Error()))
+			if span != nil {
+				recordSpanError(span, err)
+			}
 			return status.Errorf(codes.Unknown, "error processing request message: %v", err)
 		}
+		if config.emitConfigVersionHeader {
+			setConfigVersionHeader(resp, config.uuid)
+		}
+		accessLog.observeResponse(resp)
+		bodyDump.observe(req, resp)
 		if err := stream.Send(resp); err != nil {
-			s.logger.Error("cannot send response", slog.String("error", err.Error()))
+			logger.Error("cannot send response", slog.String("error", err.Error()))
 			return status.Errorf(codes.Unknown, "cannot send response: %v", err)
 		}
 	}
 }
 
-func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.ProcessingRequest) (*extprocv3.ProcessingResponse, error) {
+// configVersionHeaderKey is the response header key of the UUID of the configuration that served the request,
+// set when EmitConfigVersionHeader is enabled.
+const configVersionHeaderKey = "x-ai-eg-config-version"
+
+// setConfigVersionHeader adds the configuration version header to resp if it is the response to the response headers.
+func setConfigVersionHeader(resp *extprocv3.ProcessingResponse, uuid string) {
+	rh := resp.GetResponseHeaders()
+	if rh == nil {
+		return
+	}
+	if rh.Response == nil {
+		rh.Response = &extprocv3.CommonResponse{}
+	}
+	if rh.Response.HeaderMutation == nil {
+		rh.Response.HeaderMutation = &extprocv3.HeaderMutation{}
+	}
+	rh.Response.HeaderMutation.SetHeaders = append(rh.Response.HeaderMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: configVersionHeaderKey, RawValue: []byte(uuid)},
+	})
+}
+
+// maybeRejectOverBufferLimit reserves the bytes of the request body buffered for the stream, and returns the
+// immediate response rejecting the request when the reservation would exceed the limit.
+//
+// The size is reserved from the content-length header when the request headers are received so that the request is
+// rejected before Envoy buffers the body. Otherwise, or when the body turns out larger, it is reserved on the body.
+func (s *Server) maybeRejectOverBufferLimit(config *processorConfig, req *extprocv3.ProcessingRequest, reservation *streamBufferReservation, logger *slog.Logger) *extprocv3.ProcessingResponse {
+	var size int64
+	if headers := req.GetRequestHeaders().GetHeaders(); headers != nil {
+		size, _ = strconv.ParseInt(headersToMap(headers)["content-length"], 10, 64)
+	} else if body := req.GetRequestBody(); body != nil {
+		size = int64(len(body.Body))
+	}
+	limit := config.maxBufferedBytes
+	if size <= 0 || reservation.reserveUpTo(size, limit) {
+		return nil
+	}
+	logger.Info("Rejecting request over the buffering limit", "size", size, "limit", limit)
+	return serverOverloadedResponse(limit)
+}
+
+func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.ProcessingRequest, logger *slog.Logger) (*extprocv3.ProcessingResponse, error) {
 	switch value := req.Request.(type) {
 	case *extprocv3.ProcessingRequest_RequestHeaders:
 		requestHdrs := req.GetRequestHeaders().Headers
 		// If DEBUG log level is enabled, filter sensitive headers before logging.
-		if s.logger.Enabled(ctx, slog.LevelDebug) {
+		if logger.Enabled(ctx, slog.LevelDebug) {
 			filteredHdrs := filterSensitiveHeadersForLogging(requestHdrs, sensitiveHeaderKeys)
-			s.logger.Debug("request headers processing", slog.Any("request_headers", filteredHdrs))
+			logger.Debug("request headers processing", slog.Any("request_headers", filteredHdrs))
 		}
 		resp, err := p.ProcessRequestHeaders(ctx, requestHdrs)
 		if err != nil {
 			return nil, fmt.Errorf("cannot process request headers: %w", err)
 		}
-		s.logger.Debug("request headers processed", slog.Any("response", resp))
+		logger.Debug("request headers processed", slog.Any("response", resp))
 		return resp, nil
 	case *extprocv3.ProcessingRequest_RequestBody:
-		s.logger.Debug("request body processing", slog.Any("request", req))
+		logger.Debug("request body processing", slog.Any("request", req))
 		resp, err := p.ProcessRequestBody(ctx, value.RequestBody)
 		// If DEBUG log level is enabled, filter sensitive body before logging.
-		if s.logger.Enabled(ctx, slog.LevelDebug) {
-			filteredBody := filterSensitiveBodyForLogging(resp, s.logger, sensitiveHeaderKeys)
-			s.logger.Debug("request body processed", slog.Any("response", filteredBody))
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			filteredBody := filterSensitiveBodyForLogging(resp, logger, sensitiveHeaderKeys)
+			logger.Debug("request body processed", slog.Any("response", filteredBody))
 		}
 		if err != nil {
 			return nil, fmt.Errorf("cannot process request body: %w", err)
@@ -214,29 +607,41 @@ func (s *Server) processMsg(ctx context.Context, p Processor, req *extprocv3.Pro
 		return resp, nil
 	case *extprocv3.ProcessingRequest_ResponseHeaders:
 		responseHdrs := req.GetResponseHeaders().Headers
-		s.logger.Debug("response headers processing", slog.Any("response_headers", responseHdrs))
+		logger.Debug("response headers processing", slog.Any("response_headers", responseHdrs))
 		resp, err := p.ProcessResponseHeaders(ctx, responseHdrs)
 		if err != nil {
 			return nil, fmt.Errorf("cannot process response headers: %w", err)
 		}
-		s.logger.Debug("response headers processed", slog.Any("response", resp))
+		logger.Debug("response headers processed", slog.Any("response", resp))
 		return resp, nil
 	case *extprocv3.ProcessingRequest_ResponseBody:
-		s.logger.Debug("response body processing", slog.Any("request", req))
+		logger.Debug("response body processing", slog.Any("request", req))
 		resp, err := p.ProcessResponseBody(ctx, value.ResponseBody)
-		s.logger.Debug("response body processed", slog.Any("response", resp))
+		logger.Debug("response body processed", slog.Any("response", resp))
 		if err != nil {
 			return nil, fmt.Errorf("cannot process response body: %w", err)
 		}
 		return resp, nil
+	case *extprocv3.ProcessingRequest_ResponseTrailers:
+		responseTrailers := req.GetResponseTrailers().Trailers
+		logger.Debug("response trailers processing", slog.Any("response_trailers", responseTrailers))
+		resp, err := p.ProcessResponseTrailers(ctx, responseTrailers)
+		if err != nil {
+			return nil, fmt.Errorf("cannot process response trailers: %w", err)
+		}
+		logger.Debug("response trailers processed", slog.Any("response", resp))
+		return resp, nil
 	default:
-		s.logger.Error("unknown request type", slog.Any("request", value))
+		logger.Error("unknown request type", slog.Any("request", value))
 		return nil, fmt.Errorf("unknown request type: %T", value)
 	}
 }
 
 // Check implements [grpc_health_v1.HealthServer].
 func (s *Server) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if s.notServing.Load() {
+		return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING}, nil
+	}
 	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
 }
 
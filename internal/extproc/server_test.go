@@ -6,21 +6,31 @@
 package extproc
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
 	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/filterapi/x"
 	"github.com/envoyproxy/ai-gateway/internal/llmcostcel"
 )
 
@@ -28,9 +38,9 @@ func requireNewServerWithMockProcessor(t *testing.T) (*Server, *mockProcessor) {
 	s, err := NewServer(slog.Default())
 	require.NoError(t, err)
 	require.NotNil(t, s)
-	s.config = &processorConfig{}
+	s.config.Store(&processorConfig{})
 
-	m := newMockProcessor(s.config, s.logger)
+	m := newMockProcessor(s.config.Load(), s.logger)
 	s.Register("/", func(*processorConfig, map[string]string, *slog.Logger) (Processor, error) { return m, nil })
 
 	return s, m.(*mockProcessor)
@@ -47,11 +57,12 @@ func TestServer_LoadConfig(t *testing.T) {
 			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
 			SelectedBackendHeaderKey: "x-ai-eg-selected-backend",
 			ModelNameHeaderKey:       "x-model-name",
+			DefaultRouteDisabled:     true,
 			Rules: []filterapi.RouteRule{
 				{
 					Backends: []filterapi.Backend{
-						{Name: "kserve", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
-						{Name: "awsbedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}},
+						{Name: "kserve", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1},
+						{Name: "awsbedrock", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaAWSBedrock}, Weight: 1},
 					},
 					Headers: []filterapi.HeaderMatch{
 						{
@@ -62,7 +73,7 @@ func TestServer_LoadConfig(t *testing.T) {
 				},
 				{
 					Backends: []filterapi.Backend{
-						{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}},
+						{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1},
 					},
 					Headers: []filterapi.HeaderMatch{
 						{
@@ -77,23 +88,67 @@ func TestServer_LoadConfig(t *testing.T) {
 		err := s.LoadConfig(t.Context(), config)
 		require.NoError(t, err)
 
-		require.NotNil(t, s.config)
-		require.Equal(t, "ns", s.config.metadataNamespace)
-		require.NotNil(t, s.config.router)
-		require.Equal(t, s.config.schema, config.Schema)
-		require.Equal(t, "x-ai-eg-selected-backend", s.config.selectedBackendHeaderKey)
-		require.Equal(t, "x-model-name", s.config.modelNameHeaderKey)
-
-		require.Len(t, s.config.requestCosts, 2)
-		require.Equal(t, filterapi.LLMRequestCostTypeOutputToken, s.config.requestCosts[0].Type)
-		require.Equal(t, "key", s.config.requestCosts[0].MetadataKey)
-		require.Equal(t, filterapi.LLMRequestCostTypeCEL, s.config.requestCosts[1].Type)
-		require.Equal(t, "1 + 1", s.config.requestCosts[1].CEL)
-		prog := s.config.requestCosts[1].celProg
+		require.NotNil(t, s.config.Load())
+		require.Equal(t, "ns", s.config.Load().metadataNamespace)
+		require.NotNil(t, s.config.Load().router)
+		require.Equal(t, s.config.Load().schema, config.Schema)
+		require.Equal(t, "x-ai-eg-selected-backend", s.config.Load().selectedBackendHeaderKey)
+		require.Equal(t, "x-model-name", s.config.Load().modelNameHeaderKey)
+		require.True(t, s.config.Load().defaultRouteDisabled)
+
+		require.Len(t, s.config.Load().requestCosts, 2)
+		require.Equal(t, filterapi.LLMRequestCostTypeOutputToken, s.config.Load().requestCosts[0].Type)
+		require.Equal(t, "key", s.config.Load().requestCosts[0].MetadataKey)
+		require.Equal(t, filterapi.LLMRequestCostTypeCEL, s.config.Load().requestCosts[1].Type)
+		require.Equal(t, "1 + 1", s.config.Load().requestCosts[1].CEL)
+		prog := s.config.Load().requestCosts[1].celProg
 		require.NotNil(t, prog)
-		val, err := llmcostcel.EvaluateProgram(prog, "", "", 1, 1, 1)
+		val, err := llmcostcel.EvaluateProgram(prog, "", "", "", 1, 1, 1)
 		require.NoError(t, err)
 		require.Equal(t, uint64(2), val)
+		require.Nil(t, s.config.Load().concurrencyLimit)
+		require.Equal(t, s.concurrencyLimiter, s.config.Load().concurrencyLimiter)
+	})
+	t.Run("concurrency limit", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		err := s.LoadConfig(t.Context(), &filterapi.Config{
+			ConcurrencyLimit: &filterapi.ConcurrencyLimit{IdentityHeaderKey: "X-User-Id", MaxStreamingRequests: 3},
+		})
+		require.NoError(t, err)
+		require.Equal(t, &filterapi.ConcurrencyLimit{IdentityHeaderKey: "x-user-id", MaxStreamingRequests: 3}, s.config.Load().concurrencyLimit)
+	})
+	t.Run("state store", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		stateStore := &filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: "127.0.0.1:6379"}
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{StateStore: stateStore}))
+		store := s.config.Load().stateStore
+		require.NotNil(t, store)
+
+		// The store is reused while its configuration is unchanged.
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{StateStore: stateStore, MaxChoices: 2}))
+		require.Same(t, store, s.config.Load().stateStore)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{StateStore: &filterapi.StateStore{
+			Type: filterapi.StateStoreTypeRedis, Address: "127.0.0.1:6379", KeyPrefix: "foo:",
+		}}))
+		require.NotSame(t, store, s.config.Load().stateStore)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{}))
+		require.Nil(t, s.config.Load().stateStore)
+
+		err := s.LoadConfig(t.Context(), &filterapi.Config{StateStore: &filterapi.StateStore{
+			Type: filterapi.StateStoreTypeRedis, Address: "127.0.0.1:6379", PasswordFile: filepath.Join(t.TempDir(), "missing"),
+		}})
+		require.ErrorContains(t, err, "cannot read the state store password file")
+	})
+	t.Run("heartbeat interval", func(t *testing.T) {
+		s, _ := requireNewServerWithMockProcessor(t)
+		err := s.LoadConfig(t.Context(), &filterapi.Config{Streaming: &filterapi.StreamingConfig{HeartbeatInterval: "15s"}})
+		require.NoError(t, err)
+		require.Equal(t, 15*time.Second, s.config.Load().heartbeatInterval)
+
+		err = s.LoadConfig(t.Context(), &filterapi.Config{Streaming: &filterapi.StreamingConfig{HeartbeatInterval: "15"}})
+		require.ErrorContains(t, err, "invalid streaming heartbeat interval")
+		err = s.LoadConfig(t.Context(), &filterapi.Config{Streaming: &filterapi.StreamingConfig{HeartbeatInterval: "-1s"}})
+		require.ErrorContains(t, err, "must not be negative")
 	})
 }
 
@@ -104,6 +159,16 @@ func TestServer_Check(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, res)
 	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.Status)
+
+	s.SetServing(false)
+	res, err = s.Check(t.Context(), nil)
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, res.Status)
+
+	s.SetServing(true)
+	res, err = s.Check(t.Context(), nil)
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, res.Status)
 }
 
 func TestServer_Watch(t *testing.T) {
@@ -117,7 +182,7 @@ func TestServer_Watch(t *testing.T) {
 func TestServer_processMsg(t *testing.T) {
 	t.Run("unknown request type", func(t *testing.T) {
 		s, p := requireNewServerWithMockProcessor(t)
-		_, err := s.processMsg(t.Context(), p, &extprocv3.ProcessingRequest{})
+		_, err := s.processMsg(t.Context(), p, &extprocv3.ProcessingRequest{}, s.logger)
 		require.ErrorContains(t, err, "unknown request type")
 	})
 	t.Run("request headers", func(t *testing.T) {
@@ -131,7 +196,7 @@ func TestServer_processMsg(t *testing.T) {
 		req := &extprocv3.ProcessingRequest{
 			Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{Headers: hm}},
 		}
-		resp, err := s.processMsg(t.Context(), p, req)
+		resp, err := s.processMsg(t.Context(), p, req, s.logger)
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		require.Equal(t, expResponse, resp)
@@ -147,7 +212,7 @@ func TestServer_processMsg(t *testing.T) {
 		req := &extprocv3.ProcessingRequest{
 			Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: reqBody},
 		}
-		resp, err := s.processMsg(t.Context(), p, req)
+		resp, err := s.processMsg(t.Context(), p, req, s.logger)
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		require.Equal(t, expResponse, resp)
@@ -163,7 +228,7 @@ func TestServer_processMsg(t *testing.T) {
 		req := &extprocv3.ProcessingRequest{
 			Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{Headers: hm}},
 		}
-		resp, err := s.processMsg(t.Context(), p, req)
+		resp, err := s.processMsg(t.Context(), p, req, s.logger)
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		require.Equal(t, expResponse, resp)
@@ -179,7 +244,7 @@ func TestServer_processMsg(t *testing.T) {
 		req := &extprocv3.ProcessingRequest{
 			Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{Headers: hm}},
 		}
-		resp, err := s.processMsg(t.Context(), p, req)
+		resp, err := s.processMsg(t.Context(), p, req, s.logger)
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		require.Equal(t, expResponse, resp)
@@ -195,7 +260,23 @@ func TestServer_processMsg(t *testing.T) {
 		req := &extprocv3.ProcessingRequest{
 			Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: reqBody},
 		}
-		resp, err := s.processMsg(t.Context(), p, req)
+		resp, err := s.processMsg(t.Context(), p, req, s.logger)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		require.Equal(t, expResponse, resp)
+	})
+	t.Run("response trailers", func(t *testing.T) {
+		s, p := requireNewServerWithMockProcessor(t)
+
+		hm := &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: "foo", Value: "bar"}}}
+		expResponse := &extprocv3.ProcessingResponse{Response: &extprocv3.ProcessingResponse_ResponseTrailers{}}
+		p.t = t
+		p.expHeaderMap = hm
+		p.retProcessingResponse = expResponse
+		req := &extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_ResponseTrailers{ResponseTrailers: &extprocv3.HttpTrailers{Trailers: hm}},
+		}
+		resp, err := s.processMsg(t.Context(), p, req, s.logger)
 		require.NoError(t, err)
 		require.NotNil(t, resp)
 		require.Equal(t, expResponse, resp)
@@ -265,12 +346,523 @@ func TestServer_Process(t *testing.T) {
 	})
 }
 
+func TestServer_Process_concurrencyLimit(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+		}},
+		ConcurrencyLimit: &filterapi.ConcurrencyLimit{IdentityHeaderKey: "x-user-id", MaxStreamingRequests: 2},
+	}))
+
+	newStream := func(identity string, unblock <-chan struct{}, retErr error) *mockScriptedProcessingStream {
+		return &mockScriptedProcessingStream{
+			ctx: t.Context(),
+			reqs: []*extprocv3.ProcessingRequest{
+				{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+					Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+						{Key: ":path", Value: "/v1/chat/completions"},
+						{Key: "x-user-id", Value: identity},
+					}},
+				}}},
+				{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+					Body: []byte(`{"model":"some-model","messages":[],"stream":true}`),
+				}}},
+			},
+			unblock: unblock,
+			retErr:  retErr,
+		}
+	}
+	isRejected := func(ms *mockScriptedProcessingStream) bool {
+		sent := ms.sentResponses()
+		return len(sent) == 2 && sent[1].GetImmediateResponse().GetStatus().GetCode() == typev3.StatusCode_TooManyRequests
+	}
+
+	t.Run("reject over the limit", func(t *testing.T) {
+		unblock := make(chan struct{})
+		var wg sync.WaitGroup
+		// Two streams are held open, one ends with EOF and the other is aborted by the client.
+		held := []*mockScriptedProcessingStream{
+			newStream("alice", unblock, io.EOF),
+			newStream("alice", unblock, status.Error(codes.Canceled, "client went away")),
+		}
+		for _, ms := range held {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, s.Process(ms))
+			}()
+		}
+		require.Eventually(t, func() bool {
+			return s.concurrencyLimiter.snapshot().Streaming["alice"] == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		rejected := newStream("alice", nil, io.EOF)
+		require.NoError(t, s.Process(rejected))
+		require.True(t, isRejected(rejected))
+
+		// Other identities are not affected.
+		other := newStream("bob", nil, io.EOF)
+		require.NoError(t, s.Process(other))
+		require.False(t, isRejected(other))
+
+		close(unblock)
+		wg.Wait()
+		require.Empty(t, s.concurrencyLimiter.snapshot().Streaming)
+		for _, ms := range held {
+			require.False(t, isRejected(ms))
+		}
+	})
+	t.Run("parallel streams never leak", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 200 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var retErr error = io.EOF
+				if i%2 == 0 {
+					retErr = status.Error(codes.Canceled, "client went away")
+				}
+				_ = s.Process(newStream(fmt.Sprintf("user-%d", i%3), nil, retErr))
+			}()
+		}
+		wg.Wait()
+		require.Empty(t, s.concurrencyLimiter.snapshot().Streaming)
+	})
+}
+
+func TestServer_Process_sharedConcurrencyLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	// Two servers simulate two replicas sharing the state store.
+	newServer := func() *Server {
+		s, err := NewServer(slog.Default())
+		require.NoError(t, err)
+		s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+			}},
+			ConcurrencyLimit: &filterapi.ConcurrencyLimit{IdentityHeaderKey: "x-user-id", MaxStreamingRequests: 1},
+			StateStore:       &filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: mr.Addr()},
+		}))
+		return s
+	}
+	s1, s2 := newServer(), newServer()
+
+	newStream := func(unblock <-chan struct{}) *mockScriptedProcessingStream {
+		return &mockScriptedProcessingStream{
+			ctx: t.Context(),
+			reqs: []*extprocv3.ProcessingRequest{
+				{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+					Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+						{Key: ":path", Value: "/v1/chat/completions"},
+						{Key: "x-user-id", Value: "alice"},
+					}},
+				}}},
+				{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+					Body: []byte(`{"model":"some-model","messages":[],"stream":true}`),
+				}}},
+			},
+			unblock: unblock,
+			retErr:  io.EOF,
+		}
+	}
+	isRejected := func(ms *mockScriptedProcessingStream) bool {
+		sent := ms.sentResponses()
+		return len(sent) == 2 && sent[1].GetImmediateResponse().GetStatus().GetCode() == typev3.StatusCode_TooManyRequests
+	}
+
+	unblock := make(chan struct{})
+	held := newStream(unblock)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, s1.Process(held))
+	}()
+	require.Eventually(t, func() bool {
+		return s1.concurrencyLimiter.snapshot().Streaming["alice"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The limit applies across the servers.
+	rejected := newStream(nil)
+	require.NoError(t, s2.Process(rejected))
+	require.True(t, isRejected(rejected))
+
+	close(unblock)
+	<-done
+	require.False(t, isRejected(held))
+	require.Empty(t, mr.Keys())
+	accepted := newStream(nil)
+	require.NoError(t, s2.Process(accepted))
+	require.False(t, isRejected(accepted))
+
+	// When the state store is unavailable, the limit applies per server.
+	mr.SetError("unavailable")
+	unblock = make(chan struct{})
+	held = newStream(unblock)
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, s1.Process(held))
+	}()
+	require.Eventually(t, func() bool {
+		return s1.concurrencyLimiter.snapshot().Streaming["alice"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	accepted = newStream(nil)
+	require.NoError(t, s2.Process(accepted))
+	require.False(t, isRejected(accepted))
+	rejected = newStream(nil)
+	require.NoError(t, s1.Process(rejected))
+	require.True(t, isRejected(rejected))
+	close(unblock)
+	<-done
+	require.Equal(t, uint64(2), s1.concurrencyLimiter.stateStoreFallbacks.Load())
+	require.Equal(t, uint64(1), s2.concurrencyLimiter.stateStoreFallbacks.Load())
+}
+
+func TestServer_Process_clientCancellation(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		MetadataNamespace:        "ai_gateway_llm_ns",
+		LLMRequestCosts: []filterapi.LLMRequestCost{
+			{MetadataKey: "input_token_usage", Type: filterapi.LLMRequestCostTypeInputToken},
+			{MetadataKey: "output_token_usage", Type: filterapi.LLMRequestCostTypeOutputToken},
+		},
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+		}},
+	}))
+
+	newStream := func(respBody []*extprocv3.HttpBody) *mockScriptedProcessingStream {
+		reqs := []*extprocv3.ProcessingRequest{
+			{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}},
+			}}},
+			{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+				Body: []byte(`{"model":"some-model","messages":[],"stream":true}`),
+			}}},
+			{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+					{Key: ":status", Value: "200"},
+					{Key: "content-type", Value: "text/event-stream"},
+				}},
+			}}},
+		}
+		for _, b := range respBody {
+			reqs = append(reqs, &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: b}})
+		}
+		return &mockScriptedProcessingStream{ctx: t.Context(), reqs: reqs, retErr: status.Error(codes.Canceled, "client went away")}
+	}
+	usageChunk := []byte(`data: {"id":"chatcmpl-foo","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"This"}}],"usage":{"prompt_tokens":10,"completion_tokens":3,"total_tokens":13}}` + "\n\n")
+
+	t.Run("partial usage is set before the cancellation", func(t *testing.T) {
+		ms := newStream([]*extprocv3.HttpBody{{Body: usageChunk}})
+		require.NoError(t, s.Process(ms))
+		// Nothing is sent once the stream is canceled.
+		sent := ms.sentResponses()
+		require.Len(t, sent, 4)
+		md := sent[3].GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+		require.Equal(t, float64(10), md["input_token_usage"].GetNumberValue())
+		require.Equal(t, float64(3), md["output_token_usage"].GetNumberValue())
+	})
+	t.Run("completed response", func(t *testing.T) {
+		ms := newStream([]*extprocv3.HttpBody{{Body: usageChunk}, {Body: []byte("data: [DONE]\n\n"), EndOfStream: true}})
+		require.NoError(t, s.Process(ms))
+		sent := ms.sentResponses()
+		require.Len(t, sent, 5)
+		require.NotNil(t, sent[4].GetDynamicMetadata())
+	})
+	t.Run("canceled before the response", func(t *testing.T) {
+		ms := newStream(nil)
+		ms.reqs = ms.reqs[:2]
+		require.NoError(t, s.Process(ms))
+		require.Len(t, ms.sentResponses(), 2)
+	})
+}
+
+func TestServer_Process_heartbeat(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{{
+			Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+			Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+		}},
+		Streaming: &filterapi.StreamingConfig{HeartbeatInterval: "20ms"},
+	}))
+
+	unblock := make(chan struct{})
+	ms := &mockScriptedProcessingStream{ctx: t.Context(), unblock: unblock, retErr: status.Error(codes.Canceled, "client went away"), reqs: []*extprocv3.ProcessingRequest{
+		{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+			Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}},
+		}}},
+		{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+			Body: []byte(`{"model":"some-model","messages":[],"stream":true}`),
+		}}},
+		{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+			Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+				{Key: ":status", Value: "200"},
+				{Key: "content-type", Value: "text/event-stream"},
+			}},
+		}}},
+		{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{
+			Body: []byte("data: {\"choices\":[]}\n\n"),
+		}}},
+	}}
+	done := make(chan error)
+	go func() { done <- s.Process(ms) }()
+
+	// The backend has not sent anything after the first chunk, so the heartbeats are sent while waiting for it.
+	require.Eventually(t, func() bool { return len(ms.sentResponses()) >= 6 }, 5*time.Second, 10*time.Millisecond)
+	sent := ms.sentResponses()
+	require.Equal(t, extprocv3http.ProcessingMode_FULL_DUPLEX_STREAMED, sent[1].GetModeOverride().GetResponseBodyMode())
+	for _, resp := range sent[4:] {
+		require.Equal(t, ": heartbeat\n\n", string(resp.GetResponseBody().GetResponse().GetBodyMutation().GetStreamedResponse().GetBody()))
+	}
+	close(unblock)
+	require.NoError(t, <-done)
+}
+
+func TestServer_Process_configVersionHeader(t *testing.T) {
+	logs := &bytes.Buffer{}
+	s, err := NewServer(slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	loadConfig := func(uuid string, enabled bool) {
+		require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+			UUID:                     uuid,
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+			}},
+			EmitConfigVersionHeader: enabled,
+		}))
+	}
+	// process runs a request through a new stream and returns the config version header of the response headers.
+	process := func(t *testing.T) string {
+		ms := &mockScriptedProcessingStream{ctx: t.Context(), retErr: io.EOF, reqs: []*extprocv3.ProcessingRequest{
+			{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}},
+			}}},
+			{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+				Body: []byte(`{"model":"some-model","messages":[]}`),
+			}}},
+			{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}}},
+			}}},
+		}}
+		require.NoError(t, s.Process(ms))
+		sent := ms.sentResponses()
+		require.Len(t, sent, 3)
+		for _, resp := range sent[:2] {
+			require.Empty(t, headerMutationValue(resp.GetRequestHeaders().GetResponse().GetHeaderMutation(), configVersionHeaderKey))
+			require.Empty(t, headerMutationValue(resp.GetRequestBody().GetResponse().GetHeaderMutation(), configVersionHeaderKey))
+		}
+		return headerMutationValue(sent[2].GetResponseHeaders().GetResponse().GetHeaderMutation(), configVersionHeaderKey)
+	}
+
+	loadConfig("uuid-1", true)
+	require.Equal(t, "uuid-1", process(t))
+	require.Contains(t, logs.String(), "config_uuid=uuid-1")
+
+	// The header flips once the new configuration is loaded.
+	loadConfig("uuid-2", true)
+	require.Equal(t, "uuid-2", process(t))
+	require.Contains(t, logs.String(), "config_uuid=uuid-2")
+
+	loadConfig("uuid-3", false)
+	require.Empty(t, process(t))
+}
+
+func TestServer_Process_configReloadRemovesBackend(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	keyDir := t.TempDir()
+	keyFile := filepath.Join(keyDir, "apiKey-0")
+	require.NoError(t, os.WriteFile(keyFile, []byte("secret"), 0o600))
+	newConfig := func(backend filterapi.Backend, costs []filterapi.LLMRequestCost) *filterapi.Config {
+		return &filterapi.Config{
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			MetadataNamespace:        "ai_gateway_llm_ns",
+			LLMRequestCosts:          costs,
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{backend},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+			}},
+		}
+	}
+	require.NoError(t, s.LoadConfig(t.Context(), newConfig(filterapi.Backend{
+		Name: "openai-v1", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1,
+		Auth: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{Directory: keyDir, Weights: []int{1}}},
+	}, []filterapi.LLMRequestCost{{Type: filterapi.LLMRequestCostTypeOutputToken, MetadataKey: "output_token_usage"}})))
+
+	reqs := make(chan *extprocv3.ProcessingRequest)
+	sent := make(chan *extprocv3.ProcessingResponse, 1)
+	done := make(chan error)
+	go func() {
+		done <- s.Process(&mockChannelProcessingStream{ctx: t.Context(), reqs: reqs, sent: sent})
+	}()
+	reqs <- &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+		Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}},
+	}}}
+	<-sent
+	reqs <- &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+		Body: []byte(`{"model":"some-model","messages":[]}`),
+	}}}
+	headerMutation := (<-sent).GetRequestBody().GetResponse().GetHeaderMutation()
+	require.Equal(t, "openai-v1", headerMutationValue(headerMutation, "x-selected-backend"))
+	require.Equal(t, "Bearer secret", headerMutationValue(headerMutation, "Authorization"))
+
+	// The backend selected by the stream in flight is removed, as well as its credentials and the request costs.
+	require.NoError(t, s.LoadConfig(t.Context(), newConfig(filterapi.Backend{
+		Name: "openai-v2", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1,
+	}, nil)))
+
+	reqs <- &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+		Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}, {Key: "content-type", Value: "application/json"}}},
+	}}}
+	<-sent
+	reqs <- &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{
+		Body:        []byte(`{"choices":[],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`),
+		EndOfStream: true,
+	}}}
+	md := (<-sent).GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+	require.Equal(t, "openai-v1", md["backend"].GetStringValue())
+	require.Equal(t, float64(2), md["output_token_usage"].GetNumberValue())
+	close(reqs)
+	require.NoError(t, <-done)
+
+	// The new streams use the reloaded configuration.
+	ms := &mockScriptedProcessingStream{ctx: t.Context(), retErr: io.EOF, reqs: []*extprocv3.ProcessingRequest{
+		{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+			Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}},
+		}}},
+		{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+			Body: []byte(`{"model":"some-model","messages":[]}`),
+		}}},
+	}}
+	require.NoError(t, s.Process(ms))
+	headerMutation = ms.sentResponses()[1].GetRequestBody().GetResponse().GetHeaderMutation()
+	require.Equal(t, "openai-v2", headerMutationValue(headerMutation, "x-selected-backend"))
+	require.Empty(t, headerMutationValue(headerMutation, "Authorization"))
+}
+
+func TestServer_Process_concurrentConfigReload(t *testing.T) {
+	s, err := NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	configs := make([]*filterapi.Config, 2)
+	for i := range configs {
+		configs[i] = &filterapi.Config{
+			UUID:                     fmt.Sprintf("uuid-%d", i),
+			Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+			ModelNameHeaderKey:       "x-model-name",
+			SelectedBackendHeaderKey: "x-selected-backend",
+			MetadataNamespace:        "ai_gateway_llm_ns",
+			LLMRequestCosts:          []filterapi.LLMRequestCost{{Type: filterapi.LLMRequestCostTypeTotalToken, MetadataKey: "total"}},
+			Rules: []filterapi.RouteRule{{
+				Backends: []filterapi.Backend{{Name: fmt.Sprintf("openai-%d", i), Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+			}},
+		}
+	}
+	require.NoError(t, s.LoadConfig(t.Context(), configs[0]))
+
+	stop := make(chan struct{})
+	reloaded := make(chan struct{})
+	go func() {
+		defer close(reloaded)
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := s.LoadConfig(t.Context(), configs[i%2]); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				ms := &mockScriptedProcessingStream{ctx: t.Context(), retErr: io.EOF, reqs: []*extprocv3.ProcessingRequest{
+					{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+						Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":path", Value: "/v1/chat/completions"}}},
+					}}},
+					{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+						Body: []byte(`{"model":"some-model","messages":[]}`),
+					}}},
+					{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+						Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: "200"}, {Key: "content-type", Value: "application/json"}}},
+					}}},
+					{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{
+						Body:        []byte(`{"choices":[],"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`),
+						EndOfStream: true,
+					}}},
+				}}
+				if err := s.Process(ms); err != nil {
+					t.Error(err)
+					return
+				}
+				sent := ms.sentResponses()
+				if len(sent) != 4 {
+					t.Errorf("expected 4 responses, got %d", len(sent))
+					return
+				}
+				// The backend selected on the request and the one reported in the metadata must be the same
+				// regardless of the reloads in between.
+				selected := headerMutationValue(sent[1].GetRequestBody().GetResponse().GetHeaderMutation(), "x-selected-backend")
+				md := sent[3].GetDynamicMetadata().GetFields()["ai_gateway_llm_ns"].GetStructValue().GetFields()
+				if selected == "" || md["backend"].GetStringValue() != selected {
+					t.Errorf("selected backend %q but the metadata reports %q", selected, md["backend"].GetStringValue())
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-reloaded
+}
+
 func TestServer_ProcessorSelection(t *testing.T) {
 	s, err := NewServer(slog.Default())
 	require.NoError(t, err)
 	require.NotNil(t, s)
 
-	s.config = &processorConfig{}
+	s.config.Store(&processorConfig{})
 	s.Register("/one", func(*processorConfig, map[string]string, *slog.Logger) (Processor, error) {
 		// Returning nil guarantees that the test will fail if this processor is selected
 		return nil, nil
@@ -302,6 +894,14 @@ func TestServer_ProcessorSelection(t *testing.T) {
 		require.ErrorContains(t, err, "no processor defined for path: /unknown")
 	})
 
+	t.Run("unknown path with default route disabled", func(t *testing.T) {
+		s.config.Store(&processorConfig{defaultRouteDisabled: true})
+		defer func() { s.config.Store(&processorConfig{}) }()
+		p, err := s.processorForPath(s.config.Load(), map[string]string{":path": "/unknown"}, s.logger)
+		require.NoError(t, err)
+		require.Equal(t, &notFoundProcessor{logger: s.logger, path: "/unknown"}, p)
+	})
+
 	t.Run("known path", func(t *testing.T) {
 		ctx, cancel := context.WithTimeout(t.Context(), time.Second)
 		defer cancel()
@@ -319,6 +919,104 @@ func TestServer_ProcessorSelection(t *testing.T) {
 		err = s.Process(ms)
 		require.ErrorContains(t, err, "context deadline exceeded")
 	})
+
+	t.Run("path prefix", func(t *testing.T) {
+		s.config.Store(&processorConfig{pathPrefix: "/llm"})
+		defer func() { s.config.Store(&processorConfig{}) }()
+		var gotPath string
+		s.Register("/v1/chat/completions", func(_ *processorConfig, headers map[string]string, _ *slog.Logger) (Processor, error) {
+			gotPath = headers[":path"]
+			return passThroughProcessor{}, nil
+		})
+		for _, path := range []string{"/llm/v1/chat/completions", "/v1/chat/completions"} {
+			gotPath = ""
+			p, err := s.processorForPath(s.config.Load(), map[string]string{":path": path}, s.logger)
+			require.NoError(t, err)
+			require.Equal(t, passThroughProcessor{}, p)
+			require.Equal(t, "/v1/chat/completions", gotPath)
+		}
+		for _, path := range []string{"/llm", "/llmv1/chat/completions", "/other/v1/chat/completions"} {
+			_, err := s.processorForPath(s.config.Load(), map[string]string{":path": path}, s.logger)
+			require.ErrorContains(t, err, "no processor defined for path: "+path)
+		}
+	})
+
+	t.Run("path parameters", func(t *testing.T) {
+		var gotPath string
+		s.Register("/model/{modelId}/converse", func(_ *processorConfig, headers map[string]string, _ *slog.Logger) (Processor, error) {
+			gotPath = headers[":path"]
+			return passThroughProcessor{}, nil
+		})
+		for _, path := range []string{"/model/some-model/converse", "/model/arn%3Aaws%3Abedrock%2Fsome-model/converse?foo=bar"} {
+			p, err := s.processorForPath(s.config.Load(), map[string]string{":path": path}, s.logger)
+			require.NoError(t, err)
+			require.Equal(t, passThroughProcessor{}, p)
+			require.Equal(t, path, gotPath)
+		}
+		for _, path := range []string{"/model//converse", "/model/some/model/converse", "/model/some-model/converse-stream"} {
+			_, err := s.processorForPath(s.config.Load(), map[string]string{":path": path}, s.logger)
+			require.ErrorContains(t, err, "no processor defined for path: "+path)
+		}
+	})
+
+	t.Run("allowed paths", func(t *testing.T) {
+		s.config.Store(&processorConfig{allowedPaths: []string{"/two", "/model/{modelId}/converse"}, pathPrefix: "/llm"})
+		defer func() { s.config.Store(&processorConfig{}) }()
+		for _, path := range []string{"/two", "/llm/two", "/model/some-model/converse?foo=bar"} {
+			p, err := s.processorForPath(s.config.Load(), map[string]string{":path": path}, s.logger)
+			require.NoError(t, err)
+			_, notFound := p.(*notFoundProcessor)
+			require.False(t, notFound)
+		}
+		// The registered paths are rejected unless allowed.
+		for _, path := range []string{"/v1/chat/completions", "/llm/v1/chat/completions", "/unknown"} {
+			p, err := s.processorForPath(s.config.Load(), map[string]string{":path": path}, s.logger)
+			require.NoError(t, err)
+			require.Equal(t, &notFoundProcessor{logger: s.logger, path: strings.TrimPrefix(path, "/llm")}, p)
+		}
+	})
+}
+
+func TestServer_CustomProcessor(t *testing.T) {
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.Register("/v1/chat/completions", func(*processorConfig, map[string]string, *slog.Logger) (Processor, error) {
+		return passThroughProcessor{}, nil
+	})
+	var gotConfig *filterapi.Config
+	s.Register("/v1/audio/transcriptions", NewCustomProcessorFactory(
+		func(config *filterapi.Config, headers map[string]string, _ *slog.Logger) (x.Processor, error) {
+			require.Equal(t, "/v1/audio/transcriptions", headers[":path"])
+			gotConfig = config
+			return &mockProcessor{t: t}, nil
+		}))
+
+	for _, uuid := range []string{"first", "second"} {
+		config := &filterapi.Config{UUID: uuid}
+		require.NoError(t, s.LoadConfig(t.Context(), config))
+		p, err := s.processorForPath(s.config.Load(), map[string]string{":path": "/v1/audio/transcriptions"}, s.logger)
+		require.NoError(t, err)
+		require.IsType(t, &mockProcessor{}, p)
+		// The reloaded configuration is passed to the custom processor.
+		require.Same(t, config, gotConfig)
+	}
+
+	// The built-in processors are unaffected.
+	gotConfig = nil
+	p, err := s.processorForPath(s.config.Load(), map[string]string{":path": "/v1/chat/completions"}, s.logger)
+	require.NoError(t, err)
+	require.Equal(t, passThroughProcessor{}, p)
+	require.Nil(t, gotConfig)
+}
+
+func Test_pathAllowed(t *testing.T) {
+	require.True(t, pathAllowed(nil, "/v1/chat/completions"))
+	allowed := []string{"/v1/chat/completions", "/model/{modelId}/converse"}
+	require.True(t, pathAllowed(allowed, "/v1/chat/completions"))
+	require.True(t, pathAllowed(allowed, "/v1/chat/completions?foo=bar"))
+	require.True(t, pathAllowed(allowed, "/model/some-model/converse"))
+	require.False(t, pathAllowed(allowed, "/v1/models"))
+	require.False(t, pathAllowed(allowed, "/model/some-model/converse-stream"))
 }
 
 func Test_filterSensitiveHeadersForLogging(t *testing.T) {
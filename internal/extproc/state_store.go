@@ -0,0 +1,195 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+const (
+	// stateStoreTimeout is the timeout of each operation on the state store, which is kept short since the requests
+	// wait for it. The state local to the replica is used instead when it times out.
+	stateStoreTimeout = 200 * time.Millisecond
+	// stateStoreLeaseTTL is the expiry of each lease in the state store, so that the leases leaked by the replicas
+	// gone without releasing them are eventually pruned. The requests in flight longer than this are no longer
+	// counted after it.
+	stateStoreLeaseTTL = 10 * time.Minute
+	// stateStoreBreakerDuration is how long the state store is skipped after an operation fails, so that the requests
+	// do not wait for the timeout one after another while it is unavailable.
+	stateStoreBreakerDuration = 5 * time.Second
+	// stateStoreMaxPendingReleases is the maximum number of the releases kept to retry after the state store recovers.
+	// The leases of the releases beyond this are left to expire.
+	stateStoreMaxPendingReleases = 10000
+)
+
+// errStateStoreBreakerOpen is returned without accessing the state store while it is skipped after a failure.
+var errStateStoreBreakerOpen = errors.New("the state store is skipped after a recent failure")
+
+// acquireLeaseScript adds the lease ARGV[3] to the sorted set of KEYS[1] scored by its expiry unless the set has
+// already reached the limit ARGV[1], where a non-positive limit means unlimited. The leases expired at the time ARGV[2]
+// in milliseconds are pruned first, and the expiry of the set is refreshed to that of the last lease. This returns 1 if
+// added, otherwise 0.
+var acquireLeaseScript = redis.NewScript(`
+local now = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[4])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now)
+local limit = tonumber(ARGV[1])
+if limit > 0 and redis.call("ZCARD", KEYS[1]) >= limit then
+  return 0
+end
+redis.call("ZADD", KEYS[1], now + ttl, ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ttl)
+return 1
+`)
+
+// redisStateStore is the Redis implementation of filterapi.StateStore.
+type redisStateStore struct {
+	// config is the configuration this is created from, which is used to reuse the store across the reloads.
+	config    filterapi.StateStore
+	client    *redis.Client
+	keyPrefix string
+	// breakerOpenUntil is the time in Unix nanoseconds until which the state store is skipped after a failure.
+	breakerOpenUntil atomic.Int64
+	// pendingReleases is the leases that failed to be released, which are retried by the next acquire after the
+	// breaker closes so that they do not hold the slots until they expire.
+	pendingReleases   []stateStoreLease
+	pendingReleasesMu sync.Mutex
+	// now returns the current time, which is replaced in the tests.
+	now func() time.Time
+}
+
+// stateStoreLease is the lease of the key added by [redisStateStore.acquire].
+type stateStoreLease struct{ key, lease string }
+
+// newRedisStateStore creates the Redis state store. This does not connect to Redis until the first operation.
+func newRedisStateStore(config *filterapi.StateStore) (*redisStateStore, error) {
+	opts := &redis.Options{
+		Addr:         config.Address,
+		DialTimeout:  stateStoreTimeout,
+		ReadTimeout:  stateStoreTimeout,
+		WriteTimeout: stateStoreTimeout,
+		// The operation falls back to the local state instead of retrying.
+		MaxRetries: -1,
+	}
+	if config.PasswordFile != "" {
+		password, err := os.ReadFile(config.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the state store password file: %w", err)
+		}
+		opts.Password = strings.TrimSpace(string(password))
+	}
+	if config.TLS {
+		host, _, err := net.SplitHostPort(config.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state store address %q: %w", config.Address, err)
+		}
+		opts.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	return &redisStateStore{config: *config, client: redis.NewClient(opts), keyPrefix: config.KeyPrefix, now: time.Now}, nil
+}
+
+// acquire adds a lease to the key unless the key has already reached the limit of the leases. A non-positive limit
+// means unlimited. When ok is true, the returned lease must be released by [redisStateStore.release].
+func (r *redisStateStore) acquire(ctx context.Context, key string, limit int) (lease string, ok bool, err error) {
+	if err = r.checkBreaker(); err != nil {
+		return "", false, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, stateStoreTimeout)
+	defer cancel()
+	if err = r.releasePending(ctx); err != nil {
+		r.openBreaker()
+		return "", false, err
+	}
+	lease = newRequestID()
+	n, err := acquireLeaseScript.Run(ctx, r.client, []string{r.keyPrefix + key},
+		limit, r.now().UnixMilli(), lease, stateStoreLeaseTTL.Milliseconds()).Int()
+	if err != nil {
+		r.openBreaker()
+		return "", false, err
+	}
+	return lease, n == 1, nil
+}
+
+// release removes the lease of the key added by [redisStateStore.acquire]. When this fails, including while the
+// breaker is open, the release is retried by the next acquire after the breaker closes.
+func (r *redisStateStore) release(ctx context.Context, key, lease string) error {
+	if err := r.checkBreaker(); err != nil {
+		r.addPendingReleases(stateStoreLease{key: key, lease: lease})
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, stateStoreTimeout)
+	defer cancel()
+	if err := r.client.ZRem(ctx, r.keyPrefix+key, lease).Err(); err != nil {
+		r.addPendingReleases(stateStoreLease{key: key, lease: lease})
+		r.openBreaker()
+		return err
+	}
+	return nil
+}
+
+// addPendingReleases keeps the leases to release later, up to stateStoreMaxPendingReleases.
+func (r *redisStateStore) addPendingReleases(leases ...stateStoreLease) {
+	r.pendingReleasesMu.Lock()
+	defer r.pendingReleasesMu.Unlock()
+	n := min(len(leases), stateStoreMaxPendingReleases-len(r.pendingReleases))
+	r.pendingReleases = append(r.pendingReleases, leases[:max(n, 0)]...)
+}
+
+// releasePending removes the leases that failed to be released earlier. The leases are kept to retry if this fails.
+func (r *redisStateStore) releasePending(ctx context.Context) error {
+	r.pendingReleasesMu.Lock()
+	leases := r.pendingReleases
+	r.pendingReleases = nil
+	r.pendingReleasesMu.Unlock()
+	if len(leases) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for _, l := range leases {
+		pipe.ZRem(ctx, r.keyPrefix+l.key, l.lease)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.addPendingReleases(leases...)
+		return err
+	}
+	return nil
+}
+
+// checkBreaker returns errStateStoreBreakerOpen while the state store is skipped after a failure.
+func (r *redisStateStore) checkBreaker() error {
+	if r.now().UnixNano() < r.breakerOpenUntil.Load() {
+		return errStateStoreBreakerOpen
+	}
+	return nil
+}
+
+// openBreaker skips the state store for stateStoreBreakerDuration.
+func (r *redisStateStore) openBreaker() {
+	r.breakerOpenUntil.Store(r.now().Add(stateStoreBreakerDuration).UnixNano())
+}
+
+// breakerOpen returns true while the state store is skipped after a failure.
+func (r *redisStateStore) breakerOpen() bool {
+	return r.checkBreaker() != nil
+}
+
+// close closes the connections to Redis.
+func (r *redisStateStore) close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,155 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestRedisStateStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.RequireAuth("secret")
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+
+	store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: mr.Addr(), PasswordFile: passwordFile})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.close() })
+
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+	var leases []string
+	for range 3 {
+		lease, ok, err := store.acquire(t.Context(), "foo", 3)
+		require.NoError(t, err)
+		require.True(t, ok)
+		leases = append(leases, lease)
+	}
+	_, ok, err := store.acquire(t.Context(), "foo", 3)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ElementsMatch(t, leases, mustZMembers(t, mr, "foo"))
+
+	// The non-positive limit means unlimited.
+	_, ok, err = store.acquire(t.Context(), "foo", 0)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Releasing a lease makes room for another one.
+	require.NoError(t, store.release(t.Context(), "foo", leases[0]))
+	require.Len(t, mustZMembers(t, mr, "foo"), 3)
+
+	// The leases leaked by the replicas gone without releasing them are pruned once expired, even though the key is
+	// kept alive by the newer leases.
+	now = now.Add(stateStoreLeaseTTL / 2)
+	lease, ok, err := store.acquire(t.Context(), "foo", 4)
+	require.NoError(t, err)
+	require.True(t, ok)
+	now = now.Add(stateStoreLeaseTTL/2 + time.Millisecond)
+	_, ok, err = store.acquire(t.Context(), "foo", 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, mustZMembers(t, mr, "foo"), 2)
+	require.Contains(t, mustZMembers(t, mr, "foo"), lease)
+
+	// The key expires with the last lease.
+	mr.FastForward(stateStoreLeaseTTL)
+	require.False(t, mr.Exists("foo"))
+	// Releasing the expired lease leaves nothing.
+	require.NoError(t, store.release(t.Context(), "foo", lease))
+	require.False(t, mr.Exists("foo"))
+
+	t.Run("breaker", func(t *testing.T) {
+		store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: mr.Addr(), PasswordFile: passwordFile})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.close() })
+		now := time.Unix(1700000000, 0)
+		store.now = func() time.Time { return now }
+
+		mr.SetError("unavailable")
+		_, _, err = store.acquire(t.Context(), "bar", 1)
+		require.ErrorContains(t, err, "unavailable")
+		require.True(t, store.breakerOpen())
+		// The state store is skipped even after it recovers until the breaker closes.
+		mr.SetError("")
+		_, _, err = store.acquire(t.Context(), "bar", 1)
+		require.ErrorIs(t, err, errStateStoreBreakerOpen)
+		require.ErrorIs(t, store.release(t.Context(), "bar", "lease"), errStateStoreBreakerOpen)
+
+		now = now.Add(stateStoreBreakerDuration)
+		require.False(t, store.breakerOpen())
+		_, ok, err := store.acquire(t.Context(), "bar", 1)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+	t.Run("release while breaker open", func(t *testing.T) {
+		store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: mr.Addr(), PasswordFile: passwordFile})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.close() })
+		now := time.Unix(1700000000, 0)
+		store.now = func() time.Time { return now }
+
+		lease1, ok, err := store.acquire(t.Context(), "baz", 2)
+		require.NoError(t, err)
+		require.True(t, ok)
+		lease2, ok, err := store.acquire(t.Context(), "baz", 2)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// The release failing in Redis and the one while the breaker is open are both retried after the recovery.
+		mr.SetError("unavailable")
+		require.ErrorContains(t, store.release(t.Context(), "baz", lease1), "unavailable")
+		mr.SetError("")
+		require.ErrorIs(t, store.release(t.Context(), "baz", lease2), errStateStoreBreakerOpen)
+		require.Len(t, mustZMembers(t, mr, "baz"), 2)
+
+		now = now.Add(stateStoreBreakerDuration)
+		for range 2 {
+			_, ok, err = store.acquire(t.Context(), "baz", 2)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		require.NotContains(t, mustZMembers(t, mr, "baz"), lease1)
+		require.NotContains(t, mustZMembers(t, mr, "baz"), lease2)
+	})
+	t.Run("wrong password", func(t *testing.T) {
+		store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: mr.Addr()})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.close() })
+		_, _, err = store.acquire(t.Context(), "foo", 1)
+		require.ErrorContains(t, err, "NOAUTH")
+	})
+	t.Run("missing password file", func(t *testing.T) {
+		_, err := newRedisStateStore(&filterapi.StateStore{
+			Type: filterapi.StateStoreTypeRedis, Address: mr.Addr(), PasswordFile: filepath.Join(t.TempDir(), "missing"),
+		})
+		require.ErrorContains(t, err, "cannot read the state store password file")
+	})
+	t.Run("tls", func(t *testing.T) {
+		store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: "redis.example.com:6380", TLS: true})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.close() })
+		require.Equal(t, "redis.example.com", store.client.Options().TLSConfig.ServerName)
+	})
+	t.Run("unreachable", func(t *testing.T) {
+		unreachable := miniredis.RunT(t)
+		addr := unreachable.Addr()
+		unreachable.Close()
+		store, err := newRedisStateStore(&filterapi.StateStore{Type: filterapi.StateStoreTypeRedis, Address: addr})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = store.close() })
+		_, _, err = store.acquire(t.Context(), "foo", 1)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope name of the spans created by the external processor.
+const tracerName = "github.com/envoyproxy/ai-gateway/internal/extproc"
+
+// The span attribute keys set by the external processor.
+const (
+	spanAttrRouteRuleIndex = attribute.Key("ai_gateway.route_rule_index")
+	spanAttrBackend        = attribute.Key("ai_gateway.backend")
+	spanAttrModel          = attribute.Key("ai_gateway.model")
+	spanAttrStream         = attribute.Key("ai_gateway.stream")
+	spanAttrSchemaInput    = attribute.Key("ai_gateway.schema.input")
+	spanAttrSchemaOutput   = attribute.Key("ai_gateway.schema.output")
+	spanAttrInputTokens    = attribute.Key("ai_gateway.usage.input_tokens")
+	spanAttrOutputTokens   = attribute.Key("ai_gateway.usage.output_tokens")
+	spanAttrTotalTokens    = attribute.Key("ai_gateway.usage.total_tokens")
+	spanAttrConfigUUID     = attribute.Key("ai_gateway.config_uuid")
+)
+
+// defaultTracer returns the tracer backed by the global tracer provider, which is a no-op
+// unless the OTLP exporter is configured in the main function.
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// startSpan starts the span for the request with the given headers. When the request carries the W3C
+// traceparent header, the span joins the trace started by Envoy.
+func (s *Server) startSpan(ctx context.Context, requestHeaders map[string]string) (context.Context, trace.Span) {
+	ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier(requestHeaders))
+	return s.tracer.Start(ctx, "ExtProc "+requestHeaders[":path"], trace.WithSpanKind(trace.SpanKindInternal))
+}
+
+// recordSpanError records the error on the span and marks the span as failed.
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(otelcodes.Error, err.Error())
+}
@@ -0,0 +1,119 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package extproc
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+)
+
+func TestServer_Process_tracing(t *testing.T) {
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(t.Context()) })
+
+	s, err := NewServer(slog.Default())
+	require.NoError(t, err)
+	s.tracer = tp.Tracer(tracerName)
+	s.Register("/v1/chat/completions", NewChatCompletionProcessor)
+	require.NoError(t, s.LoadConfig(t.Context(), &filterapi.Config{
+		UUID:                     "config-uuid",
+		Schema:                   filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI},
+		ModelNameHeaderKey:       "x-model-name",
+		SelectedBackendHeaderKey: "x-selected-backend",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "foo", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "foo-model"}},
+			},
+			{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: filterapi.VersionedAPISchema{Name: filterapi.APISchemaOpenAI}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "some-model"}},
+			},
+		},
+	}))
+
+	requestHeaders := &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestHeaders{RequestHeaders: &extprocv3.HttpHeaders{
+		Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{
+			{Key: ":path", Value: "/v1/chat/completions"},
+			{Key: "traceparent", Value: "00-" + traceID + "-" + parentSpanID + "-01"},
+		}},
+	}}}
+	requestBody := func(body string) *extprocv3.ProcessingRequest {
+		return &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_RequestBody{RequestBody: &extprocv3.HttpBody{
+			Body: []byte(body),
+		}}}
+	}
+	responseHeaders := func(status string) *extprocv3.ProcessingRequest {
+		return &extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseHeaders{ResponseHeaders: &extprocv3.HttpHeaders{
+			Headers: &corev3.HeaderMap{Headers: []*corev3.HeaderValue{{Key: ":status", Value: status}}},
+		}}}
+	}
+	process := func(t *testing.T, reqs ...*extprocv3.ProcessingRequest) sdktrace.ReadOnlySpan {
+		exporter.Reset()
+		_ = s.Process(&mockScriptedProcessingStream{ctx: t.Context(), reqs: reqs, retErr: io.EOF})
+		spans := exporter.GetSpans().Snapshots()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		require.Equal(t, traceID, span.SpanContext().TraceID().String())
+		require.Equal(t, parentSpanID, span.Parent().SpanID().String())
+		require.Equal(t, "ExtProc /v1/chat/completions", span.Name())
+		return span
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		span := process(t,
+			requestHeaders,
+			requestBody(`{"model":"some-model","messages":[]}`),
+			responseHeaders("200"),
+			&extprocv3.ProcessingRequest{Request: &extprocv3.ProcessingRequest_ResponseBody{ResponseBody: &extprocv3.HttpBody{
+				Body:        []byte(`{"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`),
+				EndOfStream: true,
+			}}},
+		)
+		require.Equal(t, otelcodes.Unset, span.Status().Code)
+		require.ElementsMatch(t, []attribute.KeyValue{
+			spanAttrModel.String("some-model"),
+			spanAttrStream.Bool(false),
+			spanAttrBackend.String("openai"),
+			spanAttrSchemaInput.String("OpenAI"),
+			spanAttrSchemaOutput.String("OpenAI"),
+			spanAttrRouteRuleIndex.Int(1),
+			spanAttrInputTokens.Int64(1),
+			spanAttrOutputTokens.Int64(2),
+			spanAttrTotalTokens.Int64(3),
+			spanAttrConfigUUID.String("config-uuid"),
+		}, span.Attributes())
+	})
+	t.Run("upstream error", func(t *testing.T) {
+		span := process(t, requestHeaders, requestBody(`{"model":"some-model","messages":[],"stream":true}`), responseHeaders("503"))
+		require.Equal(t, otelcodes.Error, span.Status().Code)
+		require.Equal(t, "upstream error: status 503", span.Status().Description)
+		require.Contains(t, span.Attributes(), spanAttrStream.Bool(true))
+		require.Len(t, span.Events(), 1)
+	})
+	t.Run("invalid request body", func(t *testing.T) {
+		// The malformed request is rejected with 400 Bad Request, which is not an error of the processing.
+		span := process(t, requestHeaders, requestBody(`{"model":`))
+		require.Equal(t, otelcodes.Unset, span.Status().Code)
+		require.Empty(t, span.Events())
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+)
+
+// NewConverseAWSBedrockToAWSBedrockTranslator implements [Factory] for AWS Bedrock Converse to AWS Bedrock Converse translation.
+func NewConverseAWSBedrockToAWSBedrockTranslator() Translator {
+	return &awsBedrockToAWSBedrockTranslatorConverse{}
+}
+
+// awsBedrockToAWSBedrockTranslatorConverse implements [Translator] for /model/{modelId}/converse.
+//
+// The request and response are passed through untouched, and only the token usage is extracted from the response.
+type awsBedrockToAWSBedrockTranslatorConverse struct{}
+
+// RequestBody implements [Translator.RequestBody].
+func (o *awsBedrockToAWSBedrockTranslatorConverse) RequestBody(body RequestBody) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, override *extprocv3http.ProcessingMode, err error,
+) {
+	if _, ok := body.(*awsbedrock.ConverseInput); !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected body type: %T", body)
+	}
+	return nil, nil, nil, nil
+}
+
+// ResponseHeaders implements [Translator.ResponseHeaders].
+func (o *awsBedrockToAWSBedrockTranslatorConverse) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [Translator.ResponseBody].
+func (o *awsBedrockToAWSBedrockTranslatorConverse) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, false); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
+	if v, ok := respHeaders[statusHeaderName]; ok {
+		if v, err := strconv.Atoi(v); err == nil && !isGoodStatusCode(v) {
+			return nil, nil, LLMTokenUsage{}, nil
+		}
+	}
+	var resp awsbedrock.ConverseResponse
+	if err = json.NewDecoder(body).Decode(&resp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	if usage := resp.Usage; usage != nil {
+		tokenUsage = LLMTokenUsage{
+			InputTokens:  uint32(usage.InputTokens),  //nolint:gosec
+			OutputTokens: uint32(usage.OutputTokens), //nolint:gosec
+			TotalTokens:  uint32(usage.TotalTokens),  //nolint:gosec
+		}
+	}
+	return
+}
+
+// ResponseError implements [Translator.ResponseError].
+// The AWS Bedrock exception of the upstream is passed through untouched.
+func (o *awsBedrockToAWSBedrockTranslatorConverse) ResponseError(map[string]string, io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return nil, nil, nil
+}
@@ -0,0 +1,43 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestAWSBedrockToAWSBedrockTranslatorConverse(t *testing.T) {
+	o := NewConverseAWSBedrockToAWSBedrockTranslator()
+	_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{})
+	require.ErrorContains(t, err, "unexpected body type")
+
+	hm, bm, override, err := o.RequestBody(&awsbedrock.ConverseInput{})
+	require.NoError(t, err)
+	require.Nil(t, hm)
+	require.Nil(t, bm)
+	require.Nil(t, override)
+
+	hm, bm, tokenUsage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(
+		`{"output":{"message":{"role":"assistant","content":[{"text":"hi"}]}},"stopReason":"end_turn","usage":{"inputTokens":3,"outputTokens":2,"totalTokens":5}}`,
+	), true)
+	require.NoError(t, err)
+	require.Nil(t, hm)
+	require.Nil(t, bm)
+	require.Equal(t, LLMTokenUsage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5}, tokenUsage)
+
+	// The error is passed through untouched.
+	hm, bm, tokenUsage, err = o.ResponseBody(map[string]string{":status": "400"}, strings.NewReader(`{"message":"bad"}`), true)
+	require.NoError(t, err)
+	require.Nil(t, hm)
+	require.Nil(t, bm)
+	require.Zero(t, tokenUsage)
+}
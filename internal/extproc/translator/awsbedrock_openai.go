@@ -0,0 +1,377 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// ErrUnsupportedContentBlock is returned by [Translator.RequestBody] when the Converse request contains the content
+// block that cannot be represented in the OpenAI chat completion request, such as the document block.
+var ErrUnsupportedContentBlock = errors.New("unsupported content block")
+
+// NewConverseAWSBedrockToOpenAITranslator implements [Factory] for AWS Bedrock Converse to OpenAI chat completion translation.
+func NewConverseAWSBedrockToOpenAITranslator() Translator {
+	return &awsBedrockToOpenAITranslatorConverse{}
+}
+
+// awsBedrockToOpenAITranslatorConverse implements [Translator] for /model/{modelId}/converse.
+type awsBedrockToOpenAITranslatorConverse struct{}
+
+// openAIAssistantMessage is the assistant message of the chat completion request sent to the OpenAI backend.
+//
+// [openai.ChatCompletionAssistantMessageParam] is not used here as its content is not serialized as the string.
+type openAIAssistantMessage struct {
+	Role      string                                      `json:"role"`
+	Content   *string                                     `json:"content,omitempty"`
+	ToolCalls []openai.ChatCompletionMessageToolCallParam `json:"tool_calls,omitempty"` //nolint:tagliatelle //follow openai api
+}
+
+// RequestBody implements [Translator.RequestBody].
+func (o *awsBedrockToOpenAITranslatorConverse) RequestBody(body RequestBody) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, override *extprocv3http.ProcessingMode, err error,
+) {
+	bedrockReq, ok := body.(*awsbedrock.ConverseInput)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected body type: %T", body)
+	}
+
+	openAIReq := openai.ChatCompletionRequest{Model: ptr.Deref(bedrockReq.ModelID, "")}
+	if len(bedrockReq.System) > 0 {
+		var parts []openai.ChatCompletionContentPartTextParam
+		for _, s := range bedrockReq.System {
+			if s.Text != "" {
+				parts = append(parts, openai.ChatCompletionContentPartTextParam{
+					Text: s.Text, Type: string(openai.ChatCompletionContentPartTextTypeText),
+				})
+			}
+		}
+		openAIReq.Messages = append(openAIReq.Messages, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleSystem,
+			Value: openai.ChatCompletionSystemMessageParam{
+				Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: parts},
+			},
+		})
+	}
+	for i, msg := range bedrockReq.Messages {
+		var messages []openai.ChatCompletionMessageParamUnion
+		switch msg.Role {
+		case awsbedrock.ConversationRoleUser:
+			messages, err = o.bedrockMessageToOpenAIMessagesRoleUser(msg)
+		case awsbedrock.ConversationRoleAssistant:
+			messages, err = o.bedrockMessageToOpenAIMessagesRoleAssistant(msg)
+		default:
+			err = fmt.Errorf("unexpected role: %s", msg.Role)
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to convert messages[%d]: %w", i, err)
+		}
+		openAIReq.Messages = append(openAIReq.Messages, messages...)
+	}
+	if ic := bedrockReq.InferenceConfig; ic != nil {
+		openAIReq.MaxTokens = ic.MaxTokens
+		openAIReq.Temperature = ic.Temperature
+		openAIReq.TopP = ic.TopP
+		for _, stop := range ic.StopSequences {
+			if stop != nil {
+				if openAIReq.Stop == nil {
+					openAIReq.Stop = &openai.ChatCompletionStop{}
+				}
+				openAIReq.Stop.Sequences = append(openAIReq.Stop.Sequences, *stop)
+			}
+		}
+	}
+	if tc := bedrockReq.ToolConfig; tc != nil {
+		o.bedrockToolConfigurationToOpenAITools(tc, &openAIReq)
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(openAIReq); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte("/v1/chat/completions")}},
+		},
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil, nil
+}
+
+// bedrockMessageToOpenAIMessagesRoleUser converts the Bedrock user role message. The tool results are converted to
+// the OpenAI tool messages preceding the user message carrying the rest of the content, if any.
+func (o *awsBedrockToOpenAITranslatorConverse) bedrockMessageToOpenAIMessagesRoleUser(msg *awsbedrock.Message) (
+	[]openai.ChatCompletionMessageParamUnion, error,
+) {
+	var (
+		messages []openai.ChatCompletionMessageParamUnion
+		parts    []openai.ChatCompletionContentPartUserUnionParam
+	)
+	for _, c := range msg.Content {
+		switch {
+		case c.Text != nil:
+			parts = append(parts, openai.ChatCompletionContentPartUserUnionParam{
+				TextContent: &openai.ChatCompletionContentPartTextParam{
+					Text: *c.Text, Type: string(openai.ChatCompletionContentPartTextTypeText),
+				},
+			})
+		case c.Image != nil:
+			parts = append(parts, openai.ChatCompletionContentPartUserUnionParam{
+				ImageContent: &openai.ChatCompletionContentPartImageParam{
+					ImageURL: openai.ChatCompletionContentPartImageImageURLParam{URL: bedrockImageToDataURI(c.Image)},
+					Type:     openai.ChatCompletionContentPartImageTypeImageURL,
+				},
+			})
+		case c.ToolResult != nil:
+			content, err := bedrockToolResultToOpenAIContent(c.ToolResult)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, openai.ChatCompletionMessageParamUnion{
+				Type: openai.ChatMessageRoleTool,
+				Value: openai.ChatCompletionToolMessageParam{
+					Role:       openai.ChatMessageRoleTool,
+					Content:    openai.StringOrArray{Value: content},
+					ToolCallID: ptr.Deref(c.ToolResult.ToolUseID, ""),
+				},
+			})
+		default:
+			return nil, fmt.Errorf("%w: only text, image and tool result are supported in the user message", ErrUnsupportedContentBlock)
+		}
+	}
+	if len(parts) > 0 {
+		messages = append(messages, openai.ChatCompletionMessageParamUnion{
+			Type: openai.ChatMessageRoleUser,
+			Value: openai.ChatCompletionUserMessageParam{
+				Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: parts},
+			},
+		})
+	}
+	return messages, nil
+}
+
+// bedrockMessageToOpenAIMessagesRoleAssistant converts the Bedrock assistant role message.
+func (o *awsBedrockToOpenAITranslatorConverse) bedrockMessageToOpenAIMessagesRoleAssistant(msg *awsbedrock.Message) (
+	[]openai.ChatCompletionMessageParamUnion, error,
+) {
+	assistant := openAIAssistantMessage{Role: openai.ChatMessageRoleAssistant}
+	for _, c := range msg.Content {
+		switch {
+		case c.Text != nil:
+			assistant.Content = ptr.To(ptr.Deref(assistant.Content, "") + *c.Text)
+		case c.ToolUse != nil:
+			arguments, err := json.Marshal(c.ToolUse.Input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal tool use input: %w", err)
+			}
+			assistant.ToolCalls = append(assistant.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+				ID: c.ToolUse.ToolUseID,
+				Function: openai.ChatCompletionMessageToolCallFunctionParam{
+					Name: c.ToolUse.Name, Arguments: string(arguments),
+				},
+				Type: openai.ChatCompletionMessageToolCallTypeFunction,
+			})
+		case c.ReasoningContent != nil:
+			// The reasoning of the previous turns is not sent back to the OpenAI backend.
+		default:
+			return nil, fmt.Errorf("%w: only text and tool use are supported in the assistant message", ErrUnsupportedContentBlock)
+		}
+	}
+	return []openai.ChatCompletionMessageParamUnion{{Type: openai.ChatMessageRoleAssistant, Value: assistant}}, nil
+}
+
+// bedrockImageToDataURI converts the Bedrock image block to the data URI.
+func bedrockImageToDataURI(image *awsbedrock.ImageBlock) string {
+	return "data:image/" + image.Format + ";base64," + base64.StdEncoding.EncodeToString(image.Source.Bytes)
+}
+
+// bedrockToolResultToOpenAIContent converts the content of the Bedrock tool result to the text of the OpenAI tool message.
+func bedrockToolResultToOpenAIContent(result *awsbedrock.ToolResultBlock) (string, error) {
+	var texts []string
+	for _, c := range result.Content {
+		switch {
+		case c.Text != nil:
+			texts = append(texts, *c.Text)
+		case c.JSON != nil:
+			texts = append(texts, *c.JSON)
+		default:
+			return "", fmt.Errorf("%w: only text and json are supported in the tool result", ErrUnsupportedContentBlock)
+		}
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// bedrockToolConfigurationToOpenAITools converts the Bedrock tool configuration to the OpenAI tools and tool choice.
+func (o *awsBedrockToOpenAITranslatorConverse) bedrockToolConfigurationToOpenAITools(tc *awsbedrock.ToolConfiguration,
+	openAIReq *openai.ChatCompletionRequest,
+) {
+	for _, tool := range tc.Tools {
+		spec := tool.ToolSpec
+		if spec == nil {
+			continue
+		}
+		def := &openai.FunctionDefinition{Name: ptr.Deref(spec.Name, ""), Description: ptr.Deref(spec.Description, "")}
+		if spec.InputSchema != nil {
+			def.Parameters = spec.InputSchema.JSON
+		}
+		openAIReq.Tools = append(openAIReq.Tools, openai.Tool{Type: openai.ToolTypeFunction, Function: def})
+	}
+	switch choice := tc.ToolChoice; {
+	case choice == nil:
+	case choice.Auto != nil:
+		openAIReq.ToolChoice = "auto"
+	case choice.Any != nil:
+		openAIReq.ToolChoice = "required"
+	case choice.Tool != nil:
+		openAIReq.ToolChoice = openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: ptr.Deref(choice.Tool.Name, "")},
+		}
+	}
+}
+
+// ResponseHeaders implements [Translator.ResponseHeaders].
+func (o *awsBedrockToOpenAITranslatorConverse) ResponseHeaders(map[string]string) (headerMutation *extprocv3.HeaderMutation, err error) {
+	return nil, nil
+}
+
+// ResponseBody implements [Translator.ResponseBody].
+func (o *awsBedrockToOpenAITranslatorConverse) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, false); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
+	if v, ok := respHeaders[statusHeaderName]; ok {
+		if v, err := strconv.Atoi(v); err == nil && !isGoodStatusCode(v) {
+			headerMutation, bodyMutation, err = o.ResponseError(respHeaders, body)
+			return headerMutation, bodyMutation, LLMTokenUsage{}, err
+		}
+	}
+	var openAIResp openai.ChatCompletionResponse
+	if err = json.NewDecoder(body).Decode(&openAIResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = LLMTokenUsage{
+		InputTokens:  uint32(openAIResp.Usage.PromptTokens),     //nolint:gosec
+		OutputTokens: uint32(openAIResp.Usage.CompletionTokens), //nolint:gosec
+		TotalTokens:  uint32(openAIResp.Usage.TotalTokens),      //nolint:gosec
+	}
+	bedrockResp := awsbedrock.ConverseResponse{
+		Output: &awsbedrock.ConverseOutput{Message: awsbedrock.Message{
+			Role: awsbedrock.ConversationRoleAssistant, Content: []*awsbedrock.ContentBlock{},
+		}},
+		Usage: &awsbedrock.TokenUsage{
+			InputTokens:  openAIResp.Usage.PromptTokens,
+			OutputTokens: openAIResp.Usage.CompletionTokens,
+			TotalTokens:  openAIResp.Usage.TotalTokens,
+		},
+	}
+	// Converse does not support multiple choices, so only the first one is returned.
+	if len(openAIResp.Choices) > 0 {
+		choice := &openAIResp.Choices[0]
+		content := &bedrockResp.Output.Message.Content
+		if choice.Message.Content != nil {
+			*content = append(*content, &awsbedrock.ContentBlock{Text: choice.Message.Content})
+		}
+		for _, call := range choice.Message.ToolCalls {
+			var input map[string]any
+			if err = json.Unmarshal([]byte(call.Function.Arguments), &input); err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal tool call arguments: %w", err)
+			}
+			*content = append(*content, &awsbedrock.ContentBlock{ToolUse: &awsbedrock.ToolUseBlock{
+				Name: call.Function.Name, Input: input, ToolUseID: call.ID,
+			}})
+		}
+		bedrockResp.StopReason = ptr.To(openAIFinishReasonToBedrockStopReason(choice.FinishReason))
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(bedrockResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// openAIFinishReasonToBedrockStopReason converts the OpenAI finish reason to the Bedrock stop reason.
+func openAIFinishReasonToBedrockStopReason(reason openai.ChatCompletionChoicesFinishReason) string {
+	switch reason {
+	case openai.ChatCompletionChoicesFinishReasonLength:
+		return awsbedrock.StopReasonMaxTokens
+	case openai.ChatCompletionChoicesFinishReasonToolCalls:
+		return awsbedrock.StopReasonToolUse
+	case openai.ChatCompletionChoicesFinishReasonContentFilter:
+		return awsbedrock.StopReasonContentFiltered
+	default:
+		return awsbedrock.StopReasonEndTurn
+	}
+}
+
+// ResponseError implements [Translator.ResponseError].
+// Translate the OpenAI error to the AWS Bedrock exception. The exception type is set in the "x-amzn-errortype" header
+// based on the status code as the AWS Bedrock does.
+func (o *awsBedrockToOpenAITranslatorConverse) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	bedrockError := awsbedrock.BedrockException{Message: string(buf)}
+	var openaiError openai.Error
+	if json.Unmarshal(buf, &openaiError) == nil && openaiError.Error.Message != "" {
+		bedrockError.Message = openaiError.Error.Message
+	}
+	status, _ := strconv.Atoi(respHeaders[statusHeaderName])
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(bedrockError); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: contentTypeHeaderName, RawValue: []byte(jsonContentType)},
+	}, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: awsErrorTypeHeaderName, RawValue: []byte(bedrockErrorType(status))},
+	})
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// bedrockErrorType returns the AWS Bedrock exception type corresponding to the HTTP status code.
+func bedrockErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "ValidationException"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "AccessDeniedException"
+	case http.StatusNotFound:
+		return "ResourceNotFoundException"
+	case http.StatusTooManyRequests:
+		return "ThrottlingException"
+	case http.StatusServiceUnavailable:
+		return "ServiceUnavailableException"
+	default:
+		return "InternalServerException"
+	}
+}
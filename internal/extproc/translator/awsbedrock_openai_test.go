@@ -0,0 +1,172 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+)
+
+func TestAWSBedrockToOpenAITranslatorConverse_RequestBody(t *testing.T) {
+	t.Run("invalid body", func(t *testing.T) {
+		o := &awsBedrockToOpenAITranslatorConverse{}
+		_, _, _, err := o.RequestBody(&awsbedrock.ConverseResponse{})
+		require.ErrorContains(t, err, "unexpected body type")
+	})
+	t.Run("valid body", func(t *testing.T) {
+		var req awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal([]byte(`{
+"modelId": "gpt-4o",
+"system": [{"text": "be brief"}, {"text": "be kind"}],
+"messages": [
+  {"role": "user", "content": [{"text": "weather in Tokyo?"}, {"image": {"format": "png", "source": {"bytes": "AAEC"}}}]},
+  {"role": "assistant", "content": [{"text": "let me check"}, {"toolUse": {"toolUseId": "call_1", "name": "weather", "input": {"city": "Tokyo"}}}]},
+  {"role": "user", "content": [{"toolResult": {"toolUseId": "call_1", "content": [{"text": "sunny"}]}}]}
+],
+"inferenceConfig": {"maxTokens": 100, "temperature": 0.5, "topP": 0.9, "stopSequences": ["END"]},
+"toolConfig": {
+  "tools": [{"toolSpec": {"name": "weather", "description": "get weather", "inputSchema": {"json": {"type": "object"}}}}],
+  "toolChoice": {"tool": {"name": "weather"}}
+}
+}`), &req))
+
+		o := &awsBedrockToOpenAITranslatorConverse{}
+		hm, bm, override, err := o.RequestBody(&req)
+		require.NoError(t, err)
+		require.Nil(t, override)
+		require.Len(t, hm.SetHeaders, 2)
+		require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "/v1/chat/completions", string(hm.SetHeaders[0].Header.RawValue))
+		require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+		require.Equal(t, strconv.Itoa(len(bm.GetBody())), string(hm.SetHeaders[1].Header.RawValue))
+		require.JSONEq(t, `{
+"model": "gpt-4o",
+"messages": [
+  {"role": "system", "content": [{"type": "text", "text": "be brief"}, {"type": "text", "text": "be kind"}]},
+  {"role": "user", "content": [{"type": "text", "text": "weather in Tokyo?"}, {"type": "image_url", "image_url": {"url": "data:image/png;base64,AAEC"}}]},
+  {"role": "assistant", "content": "let me check", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Tokyo\"}"}}]},
+  {"role": "tool", "tool_call_id": "call_1", "content": "sunny"}
+],
+"max_tokens": 100,
+"temperature": 0.5,
+"top_p": 0.9,
+"stop": ["END"],
+"tools": [{"type": "function", "function": {"name": "weather", "description": "get weather", "parameters": {"type": "object"}}}],
+"tool_choice": {"type": "function", "function": {"name": "weather"}}
+}`, string(bm.GetBody()))
+	})
+	t.Run("tool choice", func(t *testing.T) {
+		for _, tc := range []struct {
+			choice *awsbedrock.ToolChoice
+			exp    any
+		}{
+			{choice: &awsbedrock.ToolChoice{Auto: &awsbedrock.AutoToolChoice{}}, exp: "auto"},
+			{choice: &awsbedrock.ToolChoice{Any: &awsbedrock.AnyToolChoice{}}, exp: "required"},
+			{choice: nil, exp: nil},
+		} {
+			o := &awsBedrockToOpenAITranslatorConverse{}
+			_, bm, _, err := o.RequestBody(&awsbedrock.ConverseInput{ToolConfig: &awsbedrock.ToolConfiguration{ToolChoice: tc.choice}})
+			require.NoError(t, err)
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &body))
+			require.Equal(t, tc.exp, body["tool_choice"])
+		}
+	})
+	t.Run("unsupported content block", func(t *testing.T) {
+		o := &awsBedrockToOpenAITranslatorConverse{}
+		_, _, _, err := o.RequestBody(&awsbedrock.ConverseInput{Messages: []*awsbedrock.Message{{
+			Role:    awsbedrock.ConversationRoleUser,
+			Content: []*awsbedrock.ContentBlock{{Document: &awsbedrock.DocumentBlock{Format: "pdf", Name: "doc"}}},
+		}}})
+		require.ErrorIs(t, err, ErrUnsupportedContentBlock)
+	})
+}
+
+func TestAWSBedrockToOpenAITranslatorConverse_ResponseBody(t *testing.T) {
+	o := &awsBedrockToOpenAITranslatorConverse{}
+	hm, bm, tokenUsage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(`{
+"id": "chatcmpl-foo",
+"object": "chat.completion",
+"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {"role": "assistant", "content": "checking",
+  "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Tokyo\"}"}}]}}],
+"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+}`), true)
+	require.NoError(t, err)
+	require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, tokenUsage)
+	require.Len(t, hm.SetHeaders, 1)
+	require.Equal(t, strconv.Itoa(len(bm.GetBody())), string(hm.SetHeaders[0].Header.RawValue))
+	require.JSONEq(t, `{
+"metrics": null,
+"output": {"message": {"role": "assistant", "content": [
+  {"text": "checking"},
+  {"toolUse": {"toolUseId": "call_1", "name": "weather", "input": {"city": "Tokyo"}}}
+]}},
+"stopReason": "tool_use",
+"usage": {"inputTokens": 10, "outputTokens": 5, "totalTokens": 15}
+}`, string(bm.GetBody()))
+
+	for reason, exp := range map[string]string{
+		"stop":           awsbedrock.StopReasonEndTurn,
+		"length":         awsbedrock.StopReasonMaxTokens,
+		"content_filter": awsbedrock.StopReasonContentFiltered,
+	} {
+		_, bm, _, err = o.ResponseBody(nil, strings.NewReader(`{"choices":[{"finish_reason":"`+reason+`","message":{}}]}`), true)
+		require.NoError(t, err)
+		var resp awsbedrock.ConverseResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+		require.Equal(t, exp, *resp.StopReason)
+	}
+}
+
+func TestAWSBedrockToOpenAITranslatorConverse_ResponseError(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		status     string
+		body       string
+		expType    string
+		expMessage string
+	}{
+		{
+			name:       "openai error",
+			status:     "429",
+			body:       `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			expType:    "ThrottlingException",
+			expMessage: "slow down",
+		},
+		{
+			name:       "non-json error",
+			status:     "502",
+			body:       "<html>bad gateway</html>",
+			expType:    "InternalServerException",
+			expMessage: "<html>bad gateway</html>",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &awsBedrockToOpenAITranslatorConverse{}
+			hm, bm, _, err := o.ResponseBody(map[string]string{":status": tc.status}, bytes.NewReader([]byte(tc.body)), true)
+			require.NoError(t, err)
+			var bedrockError awsbedrock.BedrockException
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &bedrockError))
+			require.Equal(t, tc.expMessage, bedrockError.Message)
+			headers := map[string]string{}
+			for _, h := range hm.SetHeaders {
+				headers[h.Header.Key] = string(h.Header.RawValue)
+			}
+			require.Equal(t, map[string]string{
+				"content-length":   strconv.Itoa(len(bm.GetBody())),
+				"content-type":     "application/json",
+				"x-amzn-errortype": tc.expType,
+			}, headers)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledJSONBufferSize is the maximum capacity of the buffer returned to jsonBufferPool. Larger buffers, such as
+// the ones used for a request with a large image, are left to the garbage collector so that the pool does not pin them.
+const maxPooledJSONBufferSize = 64 << 10
+
+// jsonBuffer is a buffer with the JSON encoder writing to it, shared across requests via jsonBufferPool.
+type jsonBuffer struct {
+	bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonBufferPool = sync.Pool{New: func() any {
+	b := &jsonBuffer{}
+	b.enc = json.NewEncoder(&b.Buffer)
+	return b
+}}
+
+// getJSONBuffer returns an empty jsonBuffer from the pool. The caller must return it with putJSONBuffer once the bytes
+// are copied out.
+func getJSONBuffer() *jsonBuffer {
+	return jsonBufferPool.Get().(*jsonBuffer)
+}
+
+// putJSONBuffer returns the buffer to the pool.
+func putJSONBuffer(b *jsonBuffer) {
+	if b.Cap() > maxPooledJSONBufferSize {
+		return
+	}
+	b.Reset()
+	jsonBufferPool.Put(b)
+}
+
+// encode writes the JSON encoding of v without the trailing newline, which is identical to [json.Marshal].
+func (b *jsonBuffer) encode(v any) error {
+	if err := b.enc.Encode(v); err != nil {
+		return err
+	}
+	b.Truncate(b.Len() - 1)
+	return nil
+}
+
+// writeSSEData writes the JSON encoding of v as the data of a server-sent event, i.e. "data: <json>\n\n".
+func (b *jsonBuffer) writeSSEData(v any) error {
+	b.WriteString("data: ")
+	// Encode already terminates the value with a newline.
+	if err := b.enc.Encode(v); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+	return nil
+}
+
+// marshalJSON is [json.Marshal] using the pooled buffer, so the only allocation is the returned slice of the exact size.
+func marshalJSON(v any) ([]byte, error) {
+	b := getJSONBuffer()
+	defer putJSONBuffer(b)
+	if err := b.encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(b.Bytes()), nil
+}
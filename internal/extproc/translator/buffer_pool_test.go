@@ -0,0 +1,53 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	for _, v := range []any{
+		nil,
+		"<html> & </html>",
+		map[string]any{"a": 1, "b": []string{"c"}},
+		&openai.ChatCompletionResponseChunk{Object: "chat.completion.chunk", Choices: []openai.ChatCompletionResponseChunkChoice{
+			{Delta: &openai.ChatCompletionResponseChunkChoiceDelta{Content: ptr.To("a > b")}},
+		}},
+	} {
+		expected, err := json.Marshal(v)
+		require.NoError(t, err)
+		actual, err := marshalJSON(v)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+
+	_, err := marshalJSON(func() {})
+	require.Error(t, err)
+}
+
+func TestJSONBuffer_writeSSEData(t *testing.T) {
+	b := getJSONBuffer()
+	defer putJSONBuffer(b)
+	require.NoError(t, b.writeSSEData(map[string]string{"a": "b"}))
+	require.NoError(t, b.writeSSEData(1))
+	require.Equal(t, "data: {\"a\":\"b\"}\n\ndata: 1\n\n", b.String())
+}
+
+func TestPutJSONBuffer(t *testing.T) {
+	b := getJSONBuffer()
+	b.WriteString("leftover")
+	putJSONBuffer(b)
+	b = getJSONBuffer()
+	require.Zero(t, b.Len())
+	putJSONBuffer(b)
+}
@@ -7,13 +7,20 @@ package translator
 
 import (
 	"bytes"
+	"cmp"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -25,19 +32,220 @@ import (
 	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
+// DefaultMaxStreamBufferSize is the default maximum number of the unparsed bytes buffered while translating
+// a streaming response.
+const DefaultMaxStreamBufferSize = 4 << 20
+
+// ErrStreamBufferLimitExceeded is returned by [Translator.ResponseBody] when the unparsed bytes of the streaming
+// response exceed the maximum buffer size, for example, because the upstream sends malformed framing.
+var ErrStreamBufferLimitExceeded = errors.New("streaming response buffer limit exceeded")
+
+// StreamExceptionError is returned by [Translator.ResponseBody] together with the body mutation when the upstream
+// terminates the streaming response with an exception, for example, because of throttling in the middle of the
+// stream. The body mutation carries the exception as the final OpenAI error chunk, so it should still be forwarded.
+type StreamExceptionError struct {
+	// Type is the exception type, for example, "throttlingException".
+	Type string
+	// Message is the human-readable message of the exception.
+	Message string
+}
+
+// Error implements [error].
+func (e *StreamExceptionError) Error() string {
+	return fmt.Sprintf("streaming response terminated with %s: %s", e.Type, e.Message)
+}
+
+// ErrUnsupportedImageURL is returned by [Translator.RequestBody] when the image content part refers to the image
+// by the http(s) URL. AWS Bedrock only accepts the image bytes, so only the data URIs are supported.
+var ErrUnsupportedImageURL = errors.New("image URL is not a data URI")
+
+// ErrRequestMetadataTooManyKeys is returned by [Translator.RequestBody] when the metadata of the request has
+// the maximum number of the keys while the user of the request, which AWS Bedrock receives as one of the keys of
+// the request metadata, is set as well.
+var ErrRequestMetadataTooManyKeys = fmt.Errorf("'metadata' must not have more than %d keys when 'user' is set", bedrockRequestMetadataMaxKeys-1)
+
+// ErrAssistantPrefillWithToolChoice is returned by [Translator.RequestBody] when the last message is an assistant
+// message to prefill the response while the tool_choice forces the tool use, which AWS Bedrock rejects since the
+// forced tool use prefills the response by itself.
+var ErrAssistantPrefillWithToolChoice = errors.New("the assistant message to prefill the response cannot be the last message when tool_choice forces the tool use")
+
 // NewChatCompletionOpenAIToAWSBedrockTranslator implements [Factory] for OpenAI to AWS Bedrock translation.
-func NewChatCompletionOpenAIToAWSBedrockTranslator() Translator {
-	return &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+//
+// The guardrail, if non-nil, is set on every translated Converse request. The maxStreamBufferSize is the maximum
+// number of the unparsed bytes of the streaming response buffered, and defaults to [DefaultMaxStreamBufferSize] if zero.
+// The unsupportedFieldPolicy specifies how the fields that Converse does not support, such as "seed", are handled.
+// The modelPrefix, if non-empty, is prepended to the model ID of the request. See [bedrockModelID].
+// The normalization, if non-nil, rescales the temperature and the top_p of the request for the model.
+// The modelFamilies take precedence over the built-in model families. See [BedrockModelFamily].
+func NewChatCompletionOpenAIToAWSBedrockTranslator(guardrail *awsbedrock.GuardrailConfiguration, maxStreamBufferSize int,
+	unsupportedFieldPolicy UnsupportedFieldPolicy, modelPrefix string, normalization *ParameterNormalization,
+	modelFamilies []BedrockModelFamily,
+) Translator {
+	if maxStreamBufferSize <= 0 {
+		maxStreamBufferSize = DefaultMaxStreamBufferSize
+	}
+	return &openAIToAWSBedrockTranslatorV1ChatCompletion{
+		guardrail: guardrail, maxStreamBufferSize: maxStreamBufferSize, unsupportedFieldPolicy: unsupportedFieldPolicy,
+		modelPrefix: modelPrefix, normalization: normalization, modelFamilies: modelFamilies,
+	}
+}
+
+// newChatCompletionID generates the ID of the chat completion in the same format as OpenAI, i.e.
+// "chatcmpl-<random version 4 UUID>".
+func newChatCompletionID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error.
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("chatcmpl-%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// bedrockModelID returns the model ID of the request prefixed by the modelPrefix, such as the "us." region prefix of
+// the cross-region inference profiles. The model ID is not prefixed when it already has the prefix or is an ARN.
+func bedrockModelID(modelPrefix, model string) string {
+	if modelPrefix == "" || strings.HasPrefix(model, modelPrefix) || strings.HasPrefix(model, "arn:") {
+		return model
+	}
+	return modelPrefix + model
+}
+
+// bedrockModelPath returns the path of the AWS Bedrock API for the model ID formatted with the pathTemplate.
+// The model ID is escaped since the ARNs of the models and the inference profiles contain "/".
+func bedrockModelPath(pathTemplate, modelID string) string {
+	return fmt.Sprintf(pathTemplate, url.PathEscape(modelID))
+}
+
+// isAnthropicClaudeModel returns true if the model ID is of the Anthropic Claude models. The model ID can be prefixed
+// by the region of the cross-region inference profile such as "us.anthropic.claude-3-5-sonnet-20240620-v1:0", or
+// be the ARN of the foundation model or of the inference profile.
+func isAnthropicClaudeModel(modelID string) bool {
+	id := modelID[strings.LastIndexByte(modelID, '/')+1:]
+	if !strings.HasPrefix(id, "anthropic.") {
+		// Strips the region prefix, if any.
+		_, id, _ = strings.Cut(id, ".")
+	}
+	return strings.HasPrefix(id, "anthropic.claude")
+}
+
+// BedrockModelFamily is the capabilities of the Bedrock models of which the model ID, without the region prefix of
+// the cross-region inference profile, starts with the ModelPrefix.
+type BedrockModelFamily struct {
+	// ModelPrefix is the prefix of the model IDs of the family, e.g. "amazon.titan-text". The empty prefix matches
+	// all the models.
+	ModelPrefix string
+	// NoSystemMessages is true when the models reject the system content blocks of Converse. The system and
+	// developer messages are folded into the first user message instead. See [foldBedrockSystem].
+	NoSystemMessages bool
+}
+
+// bedrockModelFamilies is the built-in Bedrock model families known to differ from the defaults. The models not
+// listed here are assumed to support the system content blocks, such as the Anthropic Claude models.
+//
+// No "meta.llama" prefix is listed since Converse documents the system prompts as supported by all the Meta Llama
+// models. The models rejecting them, if any, can be added with the ModelFamilies of the AWS Bedrock backend.
+var bedrockModelFamilies = []BedrockModelFamily{
+	{ModelPrefix: "amazon.titan-text", NoSystemMessages: true},
+	{ModelPrefix: "cohere.command-text", NoSystemMessages: true},
+	{ModelPrefix: "cohere.command-light-text", NoSystemMessages: true},
+	{ModelPrefix: "mistral.mistral-7b-instruct", NoSystemMessages: true},
+	{ModelPrefix: "mistral.mixtral-8x7b-instruct", NoSystemMessages: true},
+}
+
+// bedrockModelFamilyOf returns the model family of the model ID. The overrides are looked up before the built-in
+// model families, and the first matching one is returned. The zero value is returned for the unknown model.
+func bedrockModelFamilyOf(overrides []BedrockModelFamily, modelID string) BedrockModelFamily {
+	id := bedrockBaseModelID(modelID)
+	for _, families := range [][]BedrockModelFamily{overrides, bedrockModelFamilies} {
+		for _, f := range families {
+			if strings.HasPrefix(id, f.ModelPrefix) {
+				return f
+			}
+		}
+	}
+	return BedrockModelFamily{}
+}
+
+// bedrockFoldedSystemDelimiter separates the system and developer messages folded into the first user message from
+// the content of the user message.
+const bedrockFoldedSystemDelimiter = "\n\n---\n\n"
+
+// foldBedrockSystem moves the text of the system content blocks of the request to the beginning of the first user
+// message for the models rejecting the system content blocks. The text is followed by [bedrockFoldedSystemDelimiter],
+// and the cache points of the system are dropped. A user message is prepended when the request has none.
+func foldBedrockSystem(bedrockReq *awsbedrock.ConverseInput) {
+	var texts []string
+	for _, block := range bedrockReq.System {
+		if block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	bedrockReq.System = nil
+	if len(texts) == 0 {
+		return
+	}
+	system := strings.Join(texts, "\n\n")
+	for _, msg := range bedrockReq.Messages {
+		if msg.Role != awsbedrock.ConversationRoleUser {
+			continue
+		}
+		if len(msg.Content) > 0 && msg.Content[0].Text != nil {
+			msg.Content[0].Text = ptr.To(system + bedrockFoldedSystemDelimiter + *msg.Content[0].Text)
+		} else {
+			msg.Content = append([]*awsbedrock.ContentBlock{{Text: ptr.To(system + bedrockFoldedSystemDelimiter)}}, msg.Content...)
+		}
+		return
+	}
+	bedrockReq.Messages = append([]*awsbedrock.Message{{
+		Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To(system)}},
+	}}, bedrockReq.Messages...)
 }
 
 // openAIToAWSBedrockTranslator implements [Translator] for /v1/chat/completions.
 type openAIToAWSBedrockTranslatorV1ChatCompletion struct {
 	stream       bool
 	bufferedBody []byte
-	events       []awsbedrock.ConverseStreamEvent
-	// role is from MessageStartEvent in chunked messages, and used for all openai chat completion chunk choices.
-	// Translator is created for each request/response stream inside external processor, accordingly the role is not reused by multiple streams
-	role string
+	// maxStreamBufferSize is the maximum length of bufferedBody. Zero means [DefaultMaxStreamBufferSize].
+	maxStreamBufferSize int
+	// reader, decoder and payload are reused across the calls to extractAmazonEventStreamEvents.
+	reader  bytes.Reader
+	decoder *eventstream.Decoder
+	payload []byte
+	events  []awsbedrock.ConverseStreamEvent
+	// streamException is the exception message received in the streaming response, if any. Once it is sent to the
+	// client, the rest of the stream is dropped.
+	streamException     *StreamExceptionError
+	streamExceptionSent bool
+	// roleSent and finishSent track whether the chunk carrying the role and the one carrying the finish_reason have
+	// been sent in the streaming response, since OpenAI sends each of them exactly once per stream. The translator is
+	// created for each request/response stream inside external processor, so these are not shared by multiple streams.
+	roleSent   bool
+	finishSent bool
+	// guardrail is the guardrail configuration injected into every request. Optional.
+	guardrail *awsbedrock.GuardrailConfiguration
+	// singleToolCall is true when the request sets parallel_tool_calls to false. Bedrock Converse has no
+	// equivalent constraint, so the response is truncated to the first tool call instead.
+	singleToolCall bool
+	// toolCalls is the number of tool use blocks started so far in the streaming response, and is used
+	// to assign the index of each tool call in the chunks.
+	toolCalls int64
+	// modelPrefix is prepended to the model ID of the request. Optional.
+	modelPrefix string
+	// unsupportedFieldPolicy specifies how the request fields not supported by Converse are handled.
+	unsupportedFieldPolicy UnsupportedFieldPolicy
+	// droppedFields is the unsupported fields dropped from the request under [UnsupportedFieldPolicyWarn].
+	droppedFields []string
+	// normalization rescales the sampling parameters of the request. Optional.
+	normalization *ParameterNormalization
+	// normalizedParams is the adjustments of the sampling parameters recorded in the debug mode of normalization.
+	normalizedParams []string
+	// modelFamilies overrides the built-in model families. Optional.
+	modelFamilies []BedrockModelFamily
+	// id and created are set to the OpenAI response since Bedrock does not return them. They are generated on
+	// the first response body, and shared by all the chunks of the streaming response.
+	id      string
+	created int64
+	// model is the model name requested by the client, which is set to the OpenAI response.
+	model string
 }
 
 // RequestBody implements [Translator.RequestBody].
@@ -49,6 +257,17 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) RequestBody(body RequestB
 		return nil, nil, nil, fmt.Errorf("unexpected body type: %T", body)
 	}
 
+	if unsupported := bedrockUnsupportedFields(openAIReq); len(unsupported) > 0 {
+		switch o.unsupportedFieldPolicy {
+		case UnsupportedFieldPolicyReject:
+			return nil, nil, nil, &UnsupportedFieldsError{Fields: unsupported}
+		case UnsupportedFieldPolicyWarn:
+			o.droppedFields = unsupported
+		}
+	}
+
+	o.model = openAIReq.Model
+	modelID := bedrockModelID(o.modelPrefix, openAIReq.Model)
 	var pathTemplate string
 	if openAIReq.Stream {
 		o.stream = true
@@ -68,7 +287,7 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) RequestBody(body RequestB
 		SetHeaders: []*corev3.HeaderValueOption{
 			{Header: &corev3.HeaderValue{
 				Key:      ":path",
-				RawValue: []byte(fmt.Sprintf(pathTemplate, openAIReq.Model)),
+				RawValue: []byte(bedrockModelPath(pathTemplate, modelID)),
 			}},
 		},
 	}
@@ -76,25 +295,44 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) RequestBody(body RequestB
 	var bedrockReq awsbedrock.ConverseInput
 	// Convert InferenceConfiguration.
 	bedrockReq.InferenceConfig = &awsbedrock.InferenceConfiguration{}
-	bedrockReq.InferenceConfig.MaxTokens = openAIReq.MaxTokens
-	bedrockReq.InferenceConfig.StopSequences = openAIReq.Stop
-	bedrockReq.InferenceConfig.Temperature = openAIReq.Temperature
-	bedrockReq.InferenceConfig.TopP = openAIReq.TopP
+	bedrockReq.InferenceConfig.MaxTokens = openAIReq.MaxOutputTokens()
+	for _, stop := range openAIReq.Stop.Values() {
+		bedrockReq.InferenceConfig.StopSequences = append(bedrockReq.InferenceConfig.StopSequences, &stop)
+	}
+	bedrockReq.InferenceConfig.Temperature, bedrockReq.InferenceConfig.TopP, o.normalizedParams = o.normalization.normalize(
+		modelID, openAIReq.Temperature, openAIReq.TopP)
+	bedrockReq.GuardrailConfig = o.guardrail
+	if bedrockReq.RequestMetadata, err = bedrockRequestMetadata(openAIReq); err != nil {
+		return nil, nil, nil, err
+	}
+	o.singleToolCall = openAIReq.ParallelToolCalls != nil && !*openAIReq.ParallelToolCalls
 	// Convert Chat Completion messages.
 	err = o.openAIMessageToBedrockMessage(openAIReq, &bedrockReq)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	if bedrockModelFamilyOf(o.modelFamilies, modelID).NoSystemMessages {
+		foldBedrockSystem(&bedrockReq)
+	}
 	// Convert ToolConfiguration.
 	if len(openAIReq.Tools) > 0 {
 		err = o.openAIToolsToBedrockToolConfiguration(openAIReq, &bedrockReq)
 		if err != nil {
 			return nil, nil, nil, err
 		}
+		if toolChoice, ok := openAIReq.ToolChoice.(string); ok && toolChoice == "none" && !hasToolContent(bedrockReq.Messages) {
+			// Bedrock has no tool choice to disable the tools, so they are not passed at all. They are kept
+			// when the conversation has the tool use or result, which Bedrock rejects without the tools.
+			bedrockReq.ToolConfig = nil
+		}
+		if bedrockReq.ToolConfig != nil && bedrockReq.ToolConfig.ToolChoice != nil && bedrockReq.ToolConfig.ToolChoice.Auto == nil &&
+			isAssistantPrefill(bedrockReq.Messages) {
+			return nil, nil, nil, ErrAssistantPrefillWithToolChoice
+		}
 	}
 
 	mut := &extprocv3.BodyMutation_Body{}
-	if mut.Body, err = json.Marshal(bedrockReq); err != nil {
+	if mut.Body, err = marshalJSON(bedrockReq); err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to marshal body: %w", err)
 	}
 	setContentLength(headerMutation, mut.Body)
@@ -138,13 +376,15 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIToolsToBedrockToolC
 				bedrockReq.ToolConfig.ToolChoice = &awsbedrock.ToolChoice{
 					Any: &awsbedrock.AnyToolChoice{},
 				}
+			case "none":
+				// Bedrock does not support disabling the tools by the tool choice, which is left unset.
 			default:
 				// Anthropic Claude supports tool_choice parameter with three options.
 				// * `auto` allows Claude to decide whether to call any provided tools or not.
 				// * `any` tells Claude that it must use one of the provided tools, but doesn't force a particular tool.
 				// * `tool` allows us to force Claude to always use a particular tool.
 				// The tool option is only applied to Anthropic Claude.
-				if strings.Contains(openAIReq.Model, "anthropic") && strings.Contains(openAIReq.Model, "claude") {
+				if isAnthropicClaudeModel(bedrockModelID(o.modelPrefix, openAIReq.Model)) {
 					bedrockReq.ToolConfig.ToolChoice = &awsbedrock.ToolChoice{
 						Tool: &awsbedrock.SpecificToolChoice{
 							Name: &toolChoice,
@@ -166,6 +406,24 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIToolsToBedrockToolC
 	return nil
 }
 
+// hasToolContent returns true if any of the messages has the tool use or the tool result.
+func hasToolContent(messages []*awsbedrock.Message) bool {
+	for _, msg := range messages {
+		for _, content := range msg.Content {
+			if content.ToolUse != nil || content.ToolResult != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isAssistantPrefill returns true if the last message is the assistant message that prefills the response.
+// Converse continues the response from it.
+func isAssistantPrefill(messages []*awsbedrock.Message) bool {
+	return len(messages) > 0 && messages[len(messages)-1].Role == openai.ChatMessageRoleAssistant
+}
+
 // regDataURI follows the web uri regex definition.
 // https://developer.mozilla.org/en-US/docs/Web/URI/Schemes/data#syntax
 var regDataURI = regexp.MustCompile(`\Adata:(.+?)?(;base64)?,`)
@@ -185,9 +443,14 @@ func parseDataURI(uri string) (string, []byte, error) {
 	return contentType, bin, nil
 }
 
-// openAIMessageToBedrockMessageRoleUser converts openai user role message.
+// IsRemoteImageURL returns true if the image URL refers to the image by the http(s) URL instead of the data URI.
+func IsRemoteImageURL(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
+
+// openAIMessageToBedrockMessageRoleUser converts openai user role message at the messageIndex of the request.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMessageRoleUser(
-	openAiMessage *openai.ChatCompletionUserMessageParam, role string,
+	openAiMessage *openai.ChatCompletionUserMessageParam, role string, messageIndex int,
 ) (*awsbedrock.Message, error) {
 	if v, ok := openAiMessage.Content.Value.(string); ok {
 		return &awsbedrock.Message{
@@ -206,8 +469,15 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 				chatMessage.Content = append(chatMessage.Content, &awsbedrock.ContentBlock{
 					Text: &textContentPart.Text,
 				})
+				if cachePoint := bedrockCachePoint(textContentPart.CacheControl); cachePoint != nil {
+					chatMessage.Content = append(chatMessage.Content, &awsbedrock.ContentBlock{CachePoint: cachePoint})
+				}
 			} else if contentPart.ImageContent != nil {
 				imageContentPart := contentPart.ImageContent
+				if IsRemoteImageURL(imageContentPart.ImageURL.URL) {
+					return nil, fmt.Errorf("%w: %s: only data URIs are supported for AWS Bedrock backends",
+						ErrUnsupportedImageURL, imageContentPart.ImageURL.URL)
+				}
 				contentType, b, err := parseDataURI(imageContentPart.ImageURL.URL)
 				if err != nil {
 					return nil, fmt.Errorf("failed to parse image URL: %s %w", imageContentPart.ImageURL.URL, err)
@@ -235,6 +505,11 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 						},
 					},
 				})
+				if cachePoint := bedrockCachePoint(imageContentPart.CacheControl); cachePoint != nil {
+					chatMessage.Content = append(chatMessage.Content, &awsbedrock.ContentBlock{CachePoint: cachePoint})
+				}
+			} else if contentPart.InputAudioContent != nil {
+				return nil, &UnsupportedAudioInputError{MessageIndex: messageIndex, PartIndex: i}
 			}
 		}
 		return chatMessage, nil
@@ -242,6 +517,37 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 	return nil, fmt.Errorf("unexpected content type")
 }
 
+// bedrockCachePoint returns the cache point block to be appended after the content part with the given cache control,
+// or nil if the content part is not marked to be cached.
+func bedrockCachePoint(cacheControl *openai.CacheControl) *awsbedrock.CachePointBlock {
+	if cacheControl == nil || cacheControl.Type != openai.CacheControlTypeEphemeral {
+		return nil
+	}
+	return &awsbedrock.CachePointBlock{Type: awsbedrock.CachePointTypeDefault}
+}
+
+// bedrockTokenUsage converts the token usage of AWS Bedrock to [LLMTokenUsage].
+func bedrockTokenUsage(usage *awsbedrock.TokenUsage) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:           uint32(usage.InputTokens),           //nolint:gosec
+		OutputTokens:          uint32(usage.OutputTokens),          //nolint:gosec
+		TotalTokens:           uint32(usage.TotalTokens),           //nolint:gosec
+		CacheReadInputTokens:  uint32(usage.CacheReadInputTokens),  //nolint:gosec
+		CacheWriteInputTokens: uint32(usage.CacheWriteInputTokens), //nolint:gosec
+	}
+}
+
+// openAIUsage converts the token usage of AWS Bedrock to [openai.ChatCompletionResponseUsage].
+func openAIUsage(usage *awsbedrock.TokenUsage) openai.ChatCompletionResponseUsage {
+	return openai.ChatCompletionResponseUsage{
+		TotalTokens:           usage.TotalTokens,
+		PromptTokens:          usage.InputTokens,
+		CompletionTokens:      usage.OutputTokens,
+		CacheReadInputTokens:  usage.CacheReadInputTokens,
+		CacheWriteInputTokens: usage.CacheWriteInputTokens,
+	}
+}
+
 // unmarshalToolCallArguments is a helper method to unmarshal tool call arguments.
 func unmarshalToolCallArguments(arguments string) (map[string]interface{}, error) {
 	var input map[string]interface{}
@@ -257,11 +563,25 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 	openAiMessage *openai.ChatCompletionAssistantMessageParam, role string,
 ) (*awsbedrock.Message, error) {
 	var bedrockMessage *awsbedrock.Message
-	contentBlocks := make([]*awsbedrock.ContentBlock, 1)
+	contentBlocks := make([]*awsbedrock.ContentBlock, 0, 2)
+	// The reasoning must be passed back with its signature as the first content block for the multi-turn tool use.
+	if openAiMessage.ReasoningContent != nil {
+		contentBlocks = append(contentBlocks, &awsbedrock.ContentBlock{
+			ReasoningContent: &awsbedrock.ReasoningContentBlock{
+				ReasoningText: &awsbedrock.ReasoningTextBlock{
+					Text:      *openAiMessage.ReasoningContent,
+					Signature: openAiMessage.ReasoningSignature,
+				},
+			},
+		})
+	}
 	if openAiMessage.Content.Type == openai.ChatCompletionAssistantMessageParamContentTypeRefusal {
-		contentBlocks[0] = &awsbedrock.ContentBlock{Text: openAiMessage.Content.Refusal}
+		contentBlocks = append(contentBlocks, &awsbedrock.ContentBlock{Text: openAiMessage.Content.Refusal})
 	} else {
-		contentBlocks[0] = &awsbedrock.ContentBlock{Text: openAiMessage.Content.Text}
+		contentBlocks = append(contentBlocks, &awsbedrock.ContentBlock{Text: openAiMessage.Content.Text})
+	}
+	if cachePoint := bedrockCachePoint(openAiMessage.Content.CacheControl); cachePoint != nil {
+		contentBlocks = append(contentBlocks, &awsbedrock.ContentBlock{CachePoint: cachePoint})
 	}
 	bedrockMessage = &awsbedrock.Message{
 		Role:    role,
@@ -300,6 +620,9 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 			*bedrockSystem = append(*bedrockSystem, &awsbedrock.SystemContentBlock{
 				Text: textContentPart,
 			})
+			if cachePoint := bedrockCachePoint(contentPart.CacheControl); cachePoint != nil {
+				*bedrockSystem = append(*bedrockSystem, &awsbedrock.SystemContentBlock{CachePoint: cachePoint})
+			}
 		}
 	} else {
 		return fmt.Errorf("unexpected content type for system message")
@@ -341,32 +664,30 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 	bedrockReq.Messages = make([]*awsbedrock.Message, 0, len(openAIReq.Messages))
 	for i := range openAIReq.Messages {
 		msg := &openAIReq.Messages[i]
-		switch msg.Type {
-		case openai.ChatMessageRoleUser:
-			userMessage := msg.Value.(openai.ChatCompletionUserMessageParam)
-			bedrockMessage, err := o.openAIMessageToBedrockMessageRoleUser(&userMessage, msg.Type)
+		// The request has been validated, but the message is checked against the type of the value rather than
+		// the role so that the unexpected value results in an error instead of a panic.
+		switch message := msg.Value.(type) {
+		case openai.ChatCompletionUserMessageParam:
+			bedrockMessage, err := o.openAIMessageToBedrockMessageRoleUser(&message, openai.ChatMessageRoleUser, i)
 			if err != nil {
 				return err
 			}
 			bedrockReq.Messages = append(bedrockReq.Messages, bedrockMessage)
-		case openai.ChatMessageRoleAssistant:
-			assistantMessage := msg.Value.(openai.ChatCompletionAssistantMessageParam)
-			bedrockMessage, err := o.openAIMessageToBedrockMessageRoleAssistant(&assistantMessage, msg.Type)
+		case openai.ChatCompletionAssistantMessageParam:
+			bedrockMessage, err := o.openAIMessageToBedrockMessageRoleAssistant(&message, openai.ChatMessageRoleAssistant)
 			if err != nil {
 				return err
 			}
 			bedrockReq.Messages = append(bedrockReq.Messages, bedrockMessage)
-		case openai.ChatMessageRoleSystem:
+		case openai.ChatCompletionSystemMessageParam:
 			if bedrockReq.System == nil {
 				bedrockReq.System = make([]*awsbedrock.SystemContentBlock, 0)
 			}
-			systemMessage := msg.Value.(openai.ChatCompletionSystemMessageParam)
-			err := o.openAIMessageToBedrockMessageRoleSystem(&systemMessage, &bedrockReq.System)
+			err := o.openAIMessageToBedrockMessageRoleSystem(&message, &bedrockReq.System)
 			if err != nil {
 				return err
 			}
-		case openai.ChatMessageRoleDeveloper:
-			message := msg.Value.(openai.ChatCompletionDeveloperMessageParam)
+		case openai.ChatCompletionDeveloperMessageParam:
 			if bedrockReq.System == nil {
 				bedrockReq.System = []*awsbedrock.SystemContentBlock{}
 			}
@@ -383,15 +704,17 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 						bedrockReq.System = append(bedrockReq.System, &awsbedrock.SystemContentBlock{
 							Text: textContentPart,
 						})
+						if cachePoint := bedrockCachePoint(contentPart.CacheControl); cachePoint != nil {
+							bedrockReq.System = append(bedrockReq.System, &awsbedrock.SystemContentBlock{CachePoint: cachePoint})
+						}
 					}
 				} else {
 					return fmt.Errorf("unexpected content type for developer message")
 				}
 			}
-		case openai.ChatMessageRoleTool:
-			toolMessage := msg.Value.(openai.ChatCompletionToolMessageParam)
+		case openai.ChatCompletionToolMessageParam:
 			// Bedrock does not support tool role, merging to the user role.
-			bedrockMessage, err := o.openAIMessageToBedrockMessageRoleTool(&toolMessage, awsbedrock.ConversationRoleUser)
+			bedrockMessage, err := o.openAIMessageToBedrockMessageRoleTool(&message, awsbedrock.ConversationRoleUser)
 			if err != nil {
 				return err
 			}
@@ -407,18 +730,79 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) openAIMessageToBedrockMes
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseHeaders(headers map[string]string) (
 	headerMutation *extprocv3.HeaderMutation, err error,
 ) {
-	if o.stream {
-		contentType := headers["content-type"]
-		if contentType == "application/vnd.amazon.eventstream" {
-			// We need to change the content-type to text/event-stream for streaming responses.
-			return &extprocv3.HeaderMutation{
-				SetHeaders: []*corev3.HeaderValueOption{
-					{Header: &corev3.HeaderValue{Key: "content-type", Value: "text/event-stream"}},
-				},
-			}, nil
-		}
+	var setHeaders []*corev3.HeaderValueOption
+	if o.stream && headers["content-type"] == "application/vnd.amazon.eventstream" {
+		// We need to change the content-type to text/event-stream for streaming responses.
+		setHeaders = append(setHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: "content-type", Value: "text/event-stream"},
+		})
+	}
+	if len(o.droppedFields) > 0 {
+		setHeaders = append(setHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: DroppedParamsHeaderKey, Value: strings.Join(o.droppedFields, ",")},
+		})
+	}
+	if len(o.normalizedParams) > 0 {
+		setHeaders = append(setHeaders, normalizedParamsHeader(o.normalizedParams))
+	}
+	if len(setHeaders) == 0 {
+		return nil, nil
+	}
+	return &extprocv3.HeaderMutation{SetHeaders: setHeaders}, nil
+}
+
+const (
+	// bedrockUserRequestMetadataKey is the key of the requestMetadata of Bedrock carrying the user of the request.
+	bedrockUserRequestMetadataKey = "user"
+	// bedrockRequestMetadataMaxKeys is the maximum number of the keys in the requestMetadata of Bedrock.
+	bedrockRequestMetadataMaxKeys = 16
+)
+
+// bedrockRequestMetadata returns the requestMetadata of Bedrock from the metadata and the user of the request.
+// The user does not override the metadata of the same key. Since the user takes one of the keys, this returns
+// [ErrRequestMetadataTooManyKeys] when the metadata already has the maximum number of the keys.
+func bedrockRequestMetadata(req *openai.ChatCompletionRequest) (map[string]string, error) {
+	if len(req.Metadata) == 0 && req.User == "" {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(req.Metadata)+1)
+	maps.Copy(metadata, req.Metadata)
+	if _, ok := metadata[bedrockUserRequestMetadataKey]; !ok && req.User != "" {
+		metadata[bedrockUserRequestMetadataKey] = req.User
+	}
+	if len(metadata) > bedrockRequestMetadataMaxKeys {
+		return nil, ErrRequestMetadataTooManyKeys
+	}
+	return metadata, nil
+}
+
+// bedrockUnsupportedFields returns the names of the fields set in the request that Converse does not support.
+func bedrockUnsupportedFields(req *openai.ChatCompletionRequest) (fields []string) {
+	if len(req.LogitBias) > 0 {
+		fields = append(fields, "logit_bias")
+	}
+	if req.Seed != nil {
+		fields = append(fields, "seed")
+	}
+	if req.FrequencyPenalty != nil {
+		fields = append(fields, "frequency_penalty")
+	}
+	if req.PresencePenalty != nil {
+		fields = append(fields, "presence_penalty")
 	}
-	return nil, nil
+	return append(fields, bedrockLogProbsFields(req)...)
+}
+
+// bedrockLogProbsFields returns the names of the log probability fields set in the request. Bedrock returns no log
+// probabilities with either of Converse and InvokeModel. The "logprobs": false is the same as not setting it.
+func bedrockLogProbsFields(req *openai.ChatCompletionRequest) (fields []string) {
+	if ptr.Deref(req.LogProbs, false) {
+		fields = append(fields, "logprobs")
+	}
+	if req.TopLogProbs != nil {
+		fields = append(fields, "top_logprobs")
+	}
+	return
 }
 
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) bedrockStopReasonToOpenAIStopReason(
@@ -433,7 +817,7 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) bedrockStopReasonToOpenAI
 		return openai.ChatCompletionChoicesFinishReasonStop
 	case awsbedrock.StopReasonMaxTokens:
 		return openai.ChatCompletionChoicesFinishReasonLength
-	case awsbedrock.StopReasonContentFiltered:
+	case awsbedrock.StopReasonContentFiltered, awsbedrock.StopReasonGuardrailIntervened:
 		return openai.ChatCompletionChoicesFinishReasonContentFilter
 	case awsbedrock.StopReasonToolUse:
 		return openai.ChatCompletionChoicesFinishReasonToolCalls
@@ -468,6 +852,14 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) bedrockToolUseToOpenAICal
 // If AWS Bedrock connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return bedrockResponseError(respHeaders, body)
+}
+
+// bedrockResponseError translates the error response of AWS Bedrock, which is common to all of its APIs, to the
+// OpenAI error.
+func bedrockResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
 	statusCode := respHeaders[statusHeaderName]
 	var openaiError openai.Error
@@ -513,6 +905,10 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseError(respHeaders
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
 ) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, o.stream); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
 	if statusStr, ok := respHeaders[statusHeaderName]; ok {
 		var status int
 		if status, err = strconv.Atoi(statusStr); err == nil {
@@ -522,6 +918,9 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders
 			}
 		}
 	}
+	if o.id == "" {
+		o.id, o.created = newChatCompletionID(), time.Now().Unix()
+	}
 	mut := &extprocv3.BodyMutation_Body{}
 	if o.stream {
 		var buf []byte
@@ -529,36 +928,50 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders
 		if err != nil {
 			return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
 		}
+		if o.streamExceptionSent {
+			// The stream has already been terminated with the error chunk and [DONE].
+			return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+		}
 		o.bufferedBody = append(o.bufferedBody, buf...)
 		o.extractAmazonEventStreamEvents()
+		if limit := cmp.Or(o.maxStreamBufferSize, DefaultMaxStreamBufferSize); len(o.bufferedBody) > limit {
+			// Drop the buffer as the stream is aborted anyway.
+			o.bufferedBody = nil
+			return nil, nil, tokenUsage, fmt.Errorf("%w: more than %d bytes are not parsed", ErrStreamBufferLimitExceeded, limit)
+		}
 
+		out := getJSONBuffer()
+		defer putJSONBuffer(out)
 		for i := range o.events {
 			event := &o.events[i]
 			if usage := event.Usage; usage != nil {
-				tokenUsage = LLMTokenUsage{
-					InputTokens:  uint32(usage.InputTokens),  //nolint:gosec
-					OutputTokens: uint32(usage.OutputTokens), //nolint:gosec
-					TotalTokens:  uint32(usage.TotalTokens),  //nolint:gosec
-				}
+				tokenUsage = bedrockTokenUsage(usage)
 			}
 			oaiEvent, ok := o.convertEvent(event)
 			if !ok {
 				continue
 			}
-			var oaiEventBytes []byte
-			oaiEventBytes, err = json.Marshal(oaiEvent)
-			if err != nil {
+			if err = out.writeSSEData(oaiEvent); err != nil {
 				panic(fmt.Errorf("failed to marshal event: %w", err))
 			}
-			mut.Body = append(mut.Body, []byte("data: ")...)
-			mut.Body = append(mut.Body, oaiEventBytes...)
-			mut.Body = append(mut.Body, []byte("\n\n")...)
 		}
 
+		if e := o.streamException; e != nil {
+			if err = out.writeSSEData(bedrockStreamExceptionToOpenAIError(e)); err != nil {
+				panic(fmt.Errorf("failed to marshal error: %w", err))
+			}
+			o.streamExceptionSent = true
+			o.bufferedBody = nil
+			endOfStream = true
+			err = e
+		}
 		if endOfStream {
-			mut.Body = append(mut.Body, []byte("data: [DONE]\n")...)
+			out.WriteString("data: [DONE]\n")
+		}
+		if out.Len() > 0 {
+			mut.Body = bytes.Clone(out.Bytes())
 		}
-		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, err
 	}
 
 	var bedrockResp awsbedrock.ConverseResponse
@@ -567,21 +980,16 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders
 	}
 
 	openAIResp := openai.ChatCompletionResponse{
+		ID:      o.id,
+		Created: o.created,
+		Model:   o.model,
 		Object:  "chat.completion",
 		Choices: make([]openai.ChatCompletionResponseChoice, 0),
 	}
 	// Convert token usage.
 	if bedrockResp.Usage != nil {
-		tokenUsage = LLMTokenUsage{
-			InputTokens:  uint32(bedrockResp.Usage.InputTokens),  //nolint:gosec
-			OutputTokens: uint32(bedrockResp.Usage.OutputTokens), //nolint:gosec
-			TotalTokens:  uint32(bedrockResp.Usage.TotalTokens),  //nolint:gosec
-		}
-		openAIResp.Usage = openai.ChatCompletionResponseUsage{
-			TotalTokens:      bedrockResp.Usage.TotalTokens,
-			PromptTokens:     bedrockResp.Usage.InputTokens,
-			CompletionTokens: bedrockResp.Usage.OutputTokens,
-		}
+		tokenUsage = bedrockTokenUsage(bedrockResp.Usage)
+		openAIResp.Usage = openAIUsage(bedrockResp.Usage)
 	}
 	// AWS Bedrock does not support N(multiple choices) > 0, so there could be only one choice.
 	choice := openai.ChatCompletionResponseChoice{
@@ -593,12 +1001,20 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders
 	}
 	for _, output := range bedrockResp.Output.Message.Content {
 		if toolCall := o.bedrockToolUseToOpenAICalls(output.ToolUse); toolCall != nil {
-			choice.Message.ToolCalls = []openai.ChatCompletionMessageToolCallParam{*toolCall}
+			if o.singleToolCall && len(choice.Message.ToolCalls) > 0 {
+				continue
+			}
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, *toolCall)
 		} else if output.Text != nil {
 			// For the converse response the assumption is that there is only one text content block, we take the first one.
 			if choice.Message.Content == nil {
 				choice.Message.Content = output.Text
 			}
+		} else if reasoning := output.ReasoningContent; reasoning != nil && reasoning.ReasoningText != nil {
+			choice.Message.ReasoningContent = ptr.To(ptr.Deref(choice.Message.ReasoningContent, "") + reasoning.ReasoningText.Text)
+			if reasoning.ReasoningText.Signature != nil {
+				choice.Message.ReasoningSignature = reasoning.ReasoningText.Signature
+			}
 		}
 	}
 	openAIResp.Choices = append(openAIResp.Choices, choice)
@@ -615,13 +1031,15 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) ResponseBody(respHeaders
 // extractAmazonEventStreamEvents extracts [awsbedrock.ConverseStreamEvent] from the buffered body.
 // The extracted events are stored in the processor's events field.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) extractAmazonEventStreamEvents() {
-	// TODO: Maybe reuse the reader and decoder.
-	r := bytes.NewReader(o.bufferedBody)
-	dec := eventstream.NewDecoder()
+	if o.decoder == nil {
+		o.decoder = eventstream.NewDecoder()
+	}
+	r := &o.reader
+	r.Reset(o.bufferedBody)
 	o.events = o.events[:0]
 	var lastRead int64
 	for {
-		msg, err := dec.Decode(r, nil)
+		msg, err := o.decoder.Decode(r, o.payload[:0])
 		if err != nil {
 			// When failed, we stop processing the events.
 			// Copy the unread bytes to the beginning of the buffer.
@@ -629,51 +1047,132 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) extractAmazonEventStreamE
 			o.bufferedBody = o.bufferedBody[:len(o.bufferedBody)-int(lastRead)]
 			return
 		}
+		// The payload is only used until the event is unmarshalled, so its buffer is reused for the next message.
+		o.payload = msg.Payload
+		lastRead = r.Size() - int64(r.Len())
+		if e := bedrockStreamException(&msg); e != nil {
+			// No events follow the exception, so the rest of the buffer is dropped.
+			o.streamException = e
+			o.bufferedBody = o.bufferedBody[:0]
+			return
+		}
 		var event awsbedrock.ConverseStreamEvent
 		if err := json.Unmarshal(msg.Payload, &event); err == nil {
 			o.events = append(o.events, event)
 		}
-		lastRead = r.Size() - int64(r.Len())
+	}
+}
+
+// bedrockStreamException returns the exception carried by the eventstream message, or nil if the message is
+// an ordinary event.
+//
+// See https://docs.aws.amazon.com/bedrock/latest/APIReference/API_runtime_ConverseStream.html for the exceptions.
+func bedrockStreamException(msg *eventstream.Message) *StreamExceptionError {
+	headerString := func(name string) string {
+		if v := msg.Headers.Get(name); v != nil {
+			return v.String()
+		}
+		return ""
+	}
+	switch headerString(":message-type") {
+	case "exception":
+		e := &StreamExceptionError{Type: headerString(":exception-type")}
+		var bedrockError awsbedrock.BedrockException
+		if err := json.Unmarshal(msg.Payload, &bedrockError); err == nil {
+			e.Message = bedrockError.Message
+		} else {
+			e.Message = string(msg.Payload)
+		}
+		return e
+	case "error":
+		return &StreamExceptionError{Type: headerString(":error-code"), Message: headerString(":error-message")}
+	default:
+		return nil
+	}
+}
+
+// bedrockStreamExceptionToOpenAIError converts the exception of the streaming response to the OpenAI error chunk.
+// The code is the HTTP status code that AWS Bedrock would have responded with if the exception had occurred before
+// the response started.
+func bedrockStreamExceptionToOpenAIError(e *StreamExceptionError) *openai.Error {
+	var status int
+	switch strings.ToLower(e.Type) {
+	case "validationexception":
+		status = http.StatusBadRequest
+	case "modelstreamerrorexception":
+		status = http.StatusFailedDependency
+	case "throttlingexception":
+		status = http.StatusTooManyRequests
+	case "serviceunavailableexception":
+		status = http.StatusServiceUnavailable
+	default:
+		status = http.StatusInternalServerError
+	}
+	return &openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    e.Type,
+			Message: e.Message,
+			Code:    ptr.To(strconv.Itoa(status)),
+		},
 	}
 }
 
 var emptyString = ""
 
 // convertEvent converts an [awsbedrock.ConverseStreamEvent] to an [openai.ChatCompletionResponseChunk].
-// This is a static method and does not require a receiver, but defined as a method for namespacing.
+//
+// Following OpenAI, the role is only set in the first chunk, the finish_reason is only set in a single chunk, and
+// the chunk carrying the usage has the empty choices.
 func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) convertEvent(event *awsbedrock.ConverseStreamEvent) (openai.ChatCompletionResponseChunk, bool) {
 	const object = "chat.completion.chunk"
-	chunk := openai.ChatCompletionResponseChunk{Object: object}
+	chunk := openai.ChatCompletionResponseChunk{ID: o.id, Created: o.created, Model: o.model, Object: object}
 
 	switch {
 	case event.Usage != nil:
-		chunk.Usage = &openai.ChatCompletionResponseUsage{
-			TotalTokens:      event.Usage.TotalTokens,
-			PromptTokens:     event.Usage.InputTokens,
-			CompletionTokens: event.Usage.OutputTokens,
-		}
+		usage := openAIUsage(event.Usage)
+		chunk.Usage = &usage
+		chunk.Choices = []openai.ChatCompletionResponseChunkChoice{}
+		return chunk, true
 	case event.Role != nil:
+		if o.roleSent {
+			return chunk, false
+		}
 		chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
 			Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
 				Role:    *event.Role,
 				Content: &emptyString,
 			},
 		})
-		o.role = *event.Role
 	case event.Delta != nil:
 		if event.Delta.Text != nil {
 			chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
 				Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
-					Role:    o.role,
 					Content: event.Delta.Text,
 				},
 			})
+		} else if reasoning := event.Delta.ReasoningContent; reasoning != nil {
+			// The redacted reasoning is encrypted, so it is not useful for the clients.
+			if reasoning.Text == nil && reasoning.Signature == nil {
+				return chunk, false
+			}
+			chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
+				Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
+					ReasoningContent:   reasoning.Text,
+					ReasoningSignature: reasoning.Signature,
+				},
+			})
 		} else if event.Delta.ToolUse != nil {
+			// Content blocks are streamed one after another, so the delta belongs to the last started tool use.
+			index := max(o.toolCalls-1, 0)
+			if o.singleToolCall && index > 0 {
+				return chunk, false
+			}
 			chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
 				Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
-					Role: o.role,
 					ToolCalls: []openai.ChatCompletionMessageToolCallParam{
 						{
+							Index: ptr.To(index),
 							Function: openai.ChatCompletionMessageToolCallFunctionParam{
 								Arguments: event.Delta.ToolUse.Input,
 							},
@@ -685,12 +1184,17 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) convertEvent(event *awsbe
 		}
 	case event.Start != nil:
 		if event.Start.ToolUse != nil {
+			index := o.toolCalls
+			o.toolCalls++
+			if o.singleToolCall && index > 0 {
+				return chunk, false
+			}
 			chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
 				Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
-					Role: o.role,
 					ToolCalls: []openai.ChatCompletionMessageToolCallParam{
 						{
-							ID: event.Start.ToolUse.ToolUseID,
+							Index: ptr.To(index),
+							ID:    event.Start.ToolUse.ToolUseID,
 							Function: openai.ChatCompletionMessageToolCallFunctionParam{
 								Name: event.Start.ToolUse.Name,
 							},
@@ -701,15 +1205,22 @@ func (o *openAIToAWSBedrockTranslatorV1ChatCompletion) convertEvent(event *awsbe
 			})
 		}
 	case event.StopReason != nil:
+		if o.finishSent {
+			return chunk, false
+		}
+		o.finishSent = true
 		chunk.Choices = append(chunk.Choices, openai.ChatCompletionResponseChunkChoice{
-			Delta: &openai.ChatCompletionResponseChunkChoiceDelta{
-				Role:    o.role,
-				Content: ptr.To(emptyString),
-			},
+			Delta:        &openai.ChatCompletionResponseChunkChoiceDelta{},
 			FinishReason: o.bedrockStopReasonToOpenAIStopReason(event.StopReason),
 		})
-	default:
+	}
+	if len(chunk.Choices) == 0 {
 		return chunk, false
 	}
+	// The role is set in the first chunk even when AWS Bedrock does not start the stream with the messageStart.
+	if !o.roleSent {
+		chunk.Choices[0].Delta.Role = cmp.Or(chunk.Choices[0].Delta.Role, awsbedrock.ConversationRoleAssistant)
+		o.roleSent = true
+	}
 	return chunk, true
 }
@@ -0,0 +1,753 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// ErrUnsupportedInvokeModelFamily is returned by [Translator.RequestBody] of the InvokeModel translator when the
+// request body format of the model is not known.
+var ErrUnsupportedInvokeModelFamily = errors.New("unsupported model for the AWS Bedrock InvokeModel API")
+
+// defaultInvokeModelAnthropicMaxTokens is the "max_tokens" of the Anthropic Claude requests when the OpenAI request
+// sets neither "max_completion_tokens" nor "max_tokens", since it is required by the Anthropic Messages API.
+const defaultInvokeModelAnthropicMaxTokens = 4096
+
+// invokeModelFamily is the family of the models sharing the request and response body format of the InvokeModel API.
+type invokeModelFamily int
+
+const (
+	// invokeModelFamilyAnthropic is the Anthropic Claude models using the Anthropic Messages API format.
+	invokeModelFamilyAnthropic invokeModelFamily = iota + 1
+	// invokeModelFamilyTitanText is the Amazon Titan Text models.
+	invokeModelFamilyTitanText
+)
+
+// invokeModelFamilyOf returns the family of the model ID, which can be prefixed by the region of the cross-region
+// inference profile such as "us.anthropic.claude-3-haiku-20240307-v1:0".
+func invokeModelFamilyOf(model string) (invokeModelFamily, error) {
+	switch {
+	case strings.Contains(model, "anthropic."):
+		return invokeModelFamilyAnthropic, nil
+	case strings.Contains(model, "amazon.titan-text"):
+		return invokeModelFamilyTitanText, nil
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedInvokeModelFamily, model)
+	}
+}
+
+// NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator implements [Factory] for OpenAI to AWS Bedrock translation
+// using the InvokeModel API instead of the Converse API, for the models not supported by Converse.
+//
+// The request body format is selected by the model family. Currently, the Anthropic Claude and the Amazon Titan Text
+// models are supported. The maxStreamBufferSize, the unsupportedFieldPolicy, the modelPrefix and the normalization
+// are the same as [NewChatCompletionOpenAIToAWSBedrockTranslator], though only the log probabilities are treated as
+// unsupported.
+func NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(maxStreamBufferSize int,
+	unsupportedFieldPolicy UnsupportedFieldPolicy, modelPrefix string, normalization *ParameterNormalization,
+) Translator {
+	if maxStreamBufferSize <= 0 {
+		maxStreamBufferSize = DefaultMaxStreamBufferSize
+	}
+	return &openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion{
+		maxStreamBufferSize: maxStreamBufferSize, unsupportedFieldPolicy: unsupportedFieldPolicy,
+		modelPrefix: modelPrefix, normalization: normalization,
+	}
+}
+
+// openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion implements [Translator] for /v1/chat/completions.
+type openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion struct {
+	family       invokeModelFamily
+	stream       bool
+	bufferedBody []byte
+	// maxStreamBufferSize is the maximum length of bufferedBody.
+	maxStreamBufferSize int
+	// unsupportedFieldPolicy specifies how the fields that InvokeModel does not support are handled.
+	unsupportedFieldPolicy UnsupportedFieldPolicy
+	// droppedFields is the unsupported fields dropped from the request under [UnsupportedFieldPolicyWarn].
+	droppedFields []string
+	// modelPrefix is prepended to the model ID of the request. Optional.
+	modelPrefix string
+	// normalization rescales the sampling parameters of the request. Optional.
+	normalization *ParameterNormalization
+	// normalizedParams is the adjustments of the sampling parameters recorded in the debug mode of normalization.
+	normalizedParams []string
+	// reader, decoder and payload are reused across the calls to extractChunks.
+	reader  bytes.Reader
+	decoder *eventstream.Decoder
+	payload []byte
+	// chunks is the model-specific JSON chunks extracted from the buffered body.
+	chunks [][]byte
+	// toolCalls is the number of tool use blocks started so far in the streaming response of the Anthropic models,
+	// and is used to assign the index of each tool call in the chunks.
+	toolCalls int64
+	// roleSent and finishSent track whether the chunk carrying the role and the one carrying the finish_reason have
+	// been sent in the streaming response, since OpenAI sends each of them exactly once per stream.
+	roleSent   bool
+	finishSent bool
+	// id and created are set to the OpenAI response since InvokeModel does not return them. They are generated on
+	// the first response body, and shared by all the chunks of the streaming response.
+	id      string
+	created int64
+	// model is the model name requested by the client, which is set to the OpenAI response.
+	model string
+}
+
+// RequestBody implements [Translator.RequestBody].
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) RequestBody(body RequestBody) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, override *extprocv3http.ProcessingMode, err error,
+) {
+	openAIReq, ok := body.(*openai.ChatCompletionRequest)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected body type: %T", body)
+	}
+	o.model = openAIReq.Model
+	modelID := bedrockModelID(o.modelPrefix, openAIReq.Model)
+	if o.family, err = invokeModelFamilyOf(modelID); err != nil {
+		return nil, nil, nil, err
+	}
+	if unsupported := bedrockLogProbsFields(openAIReq); len(unsupported) > 0 {
+		switch o.unsupportedFieldPolicy {
+		case UnsupportedFieldPolicyReject:
+			return nil, nil, nil, &UnsupportedFieldsError{Fields: unsupported}
+		case UnsupportedFieldPolicyWarn:
+			o.droppedFields = unsupported
+		}
+	}
+
+	pathTemplate := "/model/%s/invoke"
+	if openAIReq.Stream {
+		o.stream = true
+		override = &extprocv3http.ProcessingMode{
+			ResponseHeaderMode: extprocv3http.ProcessingMode_SEND,
+			ResponseBodyMode:   extprocv3http.ProcessingMode_STREAMED,
+		}
+		pathTemplate = "/model/%s/invoke-with-response-stream"
+	}
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte(bedrockModelPath(pathTemplate, modelID))}},
+		},
+	}
+
+	if o.normalization != nil {
+		// The request is copied so that the normalized values are not visible to the caller.
+		normalized := *openAIReq
+		normalized.Temperature, normalized.TopP, o.normalizedParams = o.normalization.normalize(
+			modelID, openAIReq.Temperature, openAIReq.TopP)
+		openAIReq = &normalized
+	}
+
+	var req any
+	switch o.family {
+	case invokeModelFamilyAnthropic:
+		req, err = openAIToInvokeModelAnthropicRequest(openAIReq)
+	case invokeModelFamilyTitanText:
+		req, err = openAIToInvokeModelTitanTextRequest(openAIReq)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(req); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, override, nil
+}
+
+// openAIToInvokeModelAnthropicRequest converts the OpenAI request to the Anthropic Messages API request.
+// The system and developer messages are joined into the system prompt, and the consecutive messages of the same role,
+// such as the results of the parallel tool calls, are merged as required by the Anthropic Messages API.
+func openAIToInvokeModelAnthropicRequest(openAIReq *openai.ChatCompletionRequest) (*awsbedrock.InvokeModelAnthropicRequest, error) {
+	req := &awsbedrock.InvokeModelAnthropicRequest{
+		AnthropicVersion: awsbedrock.InvokeModelAnthropicVersion,
+		MaxTokens:        ptr.Deref(openAIReq.MaxOutputTokens(), defaultInvokeModelAnthropicMaxTokens),
+		Temperature:      openAIReq.Temperature,
+		TopP:             openAIReq.TopP,
+		StopSequences:    openAIReq.Stop.Values(),
+	}
+	var system []string
+	appendBlocks := func(role string, blocks ...awsbedrock.InvokeModelAnthropicContentBlock) {
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content = append(req.Messages[n-1].Content, blocks...)
+			return
+		}
+		req.Messages = append(req.Messages, awsbedrock.InvokeModelAnthropicMessage{Role: role, Content: blocks})
+	}
+	for i := range openAIReq.Messages {
+		msg := &openAIReq.Messages[i]
+		switch message := msg.Value.(type) {
+		case openai.ChatCompletionSystemMessageParam:
+			text, err := openAITextContent(message.Content.Value)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected content type for system message")
+			}
+			system = append(system, text)
+		case openai.ChatCompletionDeveloperMessageParam:
+			text, err := openAITextContent(message.Content.Value)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected content type for developer message")
+			}
+			system = append(system, text)
+		case openai.ChatCompletionUserMessageParam:
+			blocks, err := openAIUserContentToInvokeModelAnthropic(message.Content.Value, i)
+			if err != nil {
+				return nil, err
+			}
+			appendBlocks(awsbedrock.ConversationRoleUser, blocks...)
+		case openai.ChatCompletionAssistantMessageParam:
+			assistantMessage := &message
+			var blocks []awsbedrock.InvokeModelAnthropicContentBlock
+			text := assistantMessage.Content.Text
+			if assistantMessage.Content.Type == openai.ChatCompletionAssistantMessageParamContentTypeRefusal {
+				text = assistantMessage.Content.Refusal
+			}
+			if ptr.Deref(text, "") != "" {
+				blocks = append(blocks, awsbedrock.InvokeModelAnthropicContentBlock{Type: "text", Text: *text})
+			}
+			for j := range assistantMessage.ToolCalls {
+				toolCall := &assistantMessage.ToolCalls[j]
+				input, err := unmarshalToolCallArguments(toolCall.Function.Arguments)
+				if err != nil {
+					return nil, err
+				}
+				blocks = append(blocks, awsbedrock.InvokeModelAnthropicContentBlock{
+					Type: "tool_use", ID: toolCall.ID, Name: toolCall.Function.Name, Input: input,
+				})
+			}
+			appendBlocks(awsbedrock.ConversationRoleAssistant, blocks...)
+		case openai.ChatCompletionToolMessageParam:
+			toolMessage := &message
+			text, err := openAITextContent(toolMessage.Content.Value)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected content type for tool message")
+			}
+			appendBlocks(awsbedrock.ConversationRoleUser, awsbedrock.InvokeModelAnthropicContentBlock{
+				Type: "tool_result", ToolUseID: toolMessage.ToolCallID, Content: text,
+			})
+		default:
+			return nil, fmt.Errorf("unexpected role: %s", msg.Type)
+		}
+	}
+	req.System = strings.Join(system, "\n")
+
+	for i := range openAIReq.Tools {
+		f := openAIReq.Tools[i].Function
+		if f == nil {
+			continue
+		}
+		tool := awsbedrock.InvokeModelAnthropicTool{Name: f.Name, Description: f.Description, InputSchema: f.Parameters}
+		if tool.InputSchema == nil {
+			// The input schema is required by the Anthropic Messages API.
+			tool.InputSchema = map[string]any{"type": "object"}
+		}
+		req.Tools = append(req.Tools, tool)
+	}
+	switch toolChoice := openAIReq.ToolChoice.(type) {
+	case nil:
+	case string:
+		switch toolChoice {
+		case "auto":
+			req.ToolChoice = &awsbedrock.InvokeModelAnthropicToolChoice{Type: "auto"}
+		case "required":
+			req.ToolChoice = &awsbedrock.InvokeModelAnthropicToolChoice{Type: "any"}
+		case "none":
+			// The Anthropic Messages API has no equivalent, so the tools are not sent at all.
+			req.Tools = nil
+		}
+	case openai.ToolChoice:
+		req.ToolChoice = &awsbedrock.InvokeModelAnthropicToolChoice{Type: "tool", Name: toolChoice.Function.Name}
+	default:
+		return nil, fmt.Errorf("unexpected type: %T", openAIReq.ToolChoice)
+	}
+	return req, nil
+}
+
+// openAIUserContentToInvokeModelAnthropic converts the content of the OpenAI user message at the messageIndex of the
+// request to the Anthropic content blocks.
+func openAIUserContentToInvokeModelAnthropic(content any, messageIndex int) ([]awsbedrock.InvokeModelAnthropicContentBlock, error) {
+	if v, ok := content.(string); ok {
+		return []awsbedrock.InvokeModelAnthropicContentBlock{{Type: "text", Text: v}}, nil
+	}
+	contents, ok := content.([]openai.ChatCompletionContentPartUserUnionParam)
+	if !ok {
+		return nil, fmt.Errorf("unexpected content type")
+	}
+	blocks := make([]awsbedrock.InvokeModelAnthropicContentBlock, 0, len(contents))
+	for i := range contents {
+		contentPart := &contents[i]
+		if contentPart.TextContent != nil {
+			blocks = append(blocks, awsbedrock.InvokeModelAnthropicContentBlock{Type: "text", Text: contentPart.TextContent.Text})
+		} else if contentPart.ImageContent != nil {
+			url := contentPart.ImageContent.ImageURL.URL
+			if IsRemoteImageURL(url) {
+				return nil, fmt.Errorf("%w: %s: only data URIs are supported for AWS Bedrock backends", ErrUnsupportedImageURL, url)
+			}
+			contentType, b, err := parseDataURI(url)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse image URL: %s %w", url, err)
+			}
+			blocks = append(blocks, awsbedrock.InvokeModelAnthropicContentBlock{
+				Type:   "image",
+				Source: &awsbedrock.InvokeModelAnthropicImageSource{Type: "base64", MediaType: contentType, Data: b},
+			})
+		} else if contentPart.InputAudioContent != nil {
+			return nil, &UnsupportedAudioInputError{MessageIndex: messageIndex, PartIndex: i}
+		}
+	}
+	return blocks, nil
+}
+
+// openAIToInvokeModelTitanTextRequest converts the OpenAI request to the Amazon Titan Text request.
+//
+// Titan Text takes a single prompt, so the conversation is rendered in the "User: ...\nBot: ..." format recommended
+// for the Titan Text models, ending with "Bot:" for the model to continue.
+func openAIToInvokeModelTitanTextRequest(openAIReq *openai.ChatCompletionRequest) (*awsbedrock.InvokeModelTitanTextRequest, error) {
+	var prompt strings.Builder
+	for i := range openAIReq.Messages {
+		msg := &openAIReq.Messages[i]
+		var text string
+		var err error
+		switch message := msg.Value.(type) {
+		case openai.ChatCompletionSystemMessageParam:
+			text, err = openAITextContent(message.Content.Value)
+		case openai.ChatCompletionDeveloperMessageParam:
+			text, err = openAITextContent(message.Content.Value)
+		case openai.ChatCompletionUserMessageParam:
+			text, err = openAIUserTextContent(message.Content.Value, i)
+			var audioErr *UnsupportedAudioInputError
+			if errors.As(err, &audioErr) {
+				return nil, err
+			}
+			text = "User: " + text
+		case openai.ChatCompletionAssistantMessageParam:
+			text = "Bot: " + ptr.Deref(message.Content.Text, "")
+		default:
+			// Titan Text does not support the tool use.
+			return nil, fmt.Errorf("unsupported role for the Titan Text models: %s", msg.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unexpected content type for %s message", msg.Type)
+		}
+		prompt.WriteString(text)
+		prompt.WriteString("\n")
+	}
+	prompt.WriteString("Bot:")
+	return &awsbedrock.InvokeModelTitanTextRequest{
+		InputText: prompt.String(),
+		TextGenerationConfig: &awsbedrock.InvokeModelTitanTextGeneration{
+			MaxTokenCount: openAIReq.MaxOutputTokens(),
+			Temperature:   openAIReq.Temperature,
+			TopP:          openAIReq.TopP,
+			StopSequences: openAIReq.Stop.Values(),
+		},
+	}, nil
+}
+
+// openAIUserTextContent returns the text of the OpenAI user message at the messageIndex of the request, failing if
+// the message has non-text parts.
+func openAIUserTextContent(content any, messageIndex int) (string, error) {
+	if v, ok := content.(string); ok {
+		return v, nil
+	}
+	contents, ok := content.([]openai.ChatCompletionContentPartUserUnionParam)
+	if !ok {
+		return "", fmt.Errorf("unexpected content type: %T", content)
+	}
+	texts := make([]string, 0, len(contents))
+	for i := range contents {
+		if contents[i].InputAudioContent != nil {
+			return "", &UnsupportedAudioInputError{MessageIndex: messageIndex, PartIndex: i}
+		}
+		if contents[i].TextContent == nil {
+			return "", fmt.Errorf("only text content is supported")
+		}
+		texts = append(texts, contents[i].TextContent.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// ResponseHeaders implements [Translator.ResponseHeaders].
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) ResponseHeaders(headers map[string]string) (
+	headerMutation *extprocv3.HeaderMutation, err error,
+) {
+	var setHeaders []*corev3.HeaderValueOption
+	if o.stream && headers["content-type"] == "application/vnd.amazon.eventstream" {
+		setHeaders = append(setHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: "content-type", Value: "text/event-stream"},
+		})
+	}
+	if len(o.droppedFields) > 0 {
+		setHeaders = append(setHeaders, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{Key: DroppedParamsHeaderKey, Value: strings.Join(o.droppedFields, ",")},
+		})
+	}
+	if len(o.normalizedParams) > 0 {
+		setHeaders = append(setHeaders, normalizedParamsHeader(o.normalizedParams))
+	}
+	if len(setHeaders) == 0 {
+		return nil, nil
+	}
+	return &extprocv3.HeaderMutation{SetHeaders: setHeaders}, nil
+}
+
+// ResponseError implements [Translator.ResponseError].
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	return bedrockResponseError(respHeaders, body)
+}
+
+// ResponseBody implements [Translator.ResponseBody].
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, o.stream); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
+	if status, convErr := strconv.Atoi(respHeaders[statusHeaderName]); convErr == nil && !isGoodStatusCode(status) {
+		headerMutation, bodyMutation, err = o.ResponseError(respHeaders, body)
+		return headerMutation, bodyMutation, LLMTokenUsage{}, err
+	}
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+	}
+	if o.id == "" {
+		o.id, o.created = newChatCompletionID(), time.Now().Unix()
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if o.stream {
+		o.bufferedBody = append(o.bufferedBody, buf...)
+		o.extractChunks()
+		if limit := o.maxStreamBufferSize; len(o.bufferedBody) > limit {
+			o.bufferedBody = nil
+			return nil, nil, tokenUsage, fmt.Errorf("%w: more than %d bytes are not parsed", ErrStreamBufferLimitExceeded, limit)
+		}
+		out := getJSONBuffer()
+		defer putJSONBuffer(out)
+		for _, chunk := range o.chunks {
+			var oaiChunk *openai.ChatCompletionResponseChunk
+			var metrics *awsbedrock.InvokeModelInvocationMetrics
+			switch o.family {
+			case invokeModelFamilyAnthropic:
+				oaiChunk, metrics = o.convertAnthropicStreamEvent(chunk)
+			case invokeModelFamilyTitanText:
+				oaiChunk, metrics = o.convertTitanTextStreamChunk(chunk)
+			}
+			if metrics != nil {
+				tokenUsage = invocationMetricsTokenUsage(metrics)
+				if oaiChunk == nil {
+					oaiChunk = &openai.ChatCompletionResponseChunk{
+						Object: "chat.completion.chunk", Choices: []openai.ChatCompletionResponseChunkChoice{},
+					}
+				}
+				oaiChunk.Usage = &openai.ChatCompletionResponseUsage{
+					PromptTokens:     metrics.InputTokenCount,
+					CompletionTokens: metrics.OutputTokenCount,
+					TotalTokens:      metrics.InputTokenCount + metrics.OutputTokenCount,
+				}
+			}
+			if oaiChunk == nil {
+				continue
+			}
+			oaiChunk.ID, oaiChunk.Created, oaiChunk.Model = o.id, o.created, o.model
+			if err = out.writeSSEData(oaiChunk); err != nil {
+				return nil, nil, tokenUsage, fmt.Errorf("failed to marshal chunk: %w", err)
+			}
+		}
+		if endOfStream {
+			out.WriteString("data: [DONE]\n")
+		}
+		if out.Len() > 0 {
+			mut.Body = bytes.Clone(out.Bytes())
+		}
+		return nil, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+	}
+
+	var openAIResp *openai.ChatCompletionResponse
+	switch o.family {
+	case invokeModelFamilyAnthropic:
+		openAIResp, tokenUsage, err = o.convertAnthropicResponse(buf)
+	case invokeModelFamilyTitanText:
+		openAIResp, tokenUsage, err = o.convertTitanTextResponse(buf)
+	}
+	if err != nil {
+		return nil, nil, tokenUsage, err
+	}
+	// The token counts in the response headers take precedence over the ones in the body, as they are reported
+	// uniformly for all the model families.
+	if input, output, ok := invokeModelTokenCountHeaders(respHeaders); ok {
+		tokenUsage.InputTokens, tokenUsage.OutputTokens = input, output
+		tokenUsage.TotalTokens = input + output
+	}
+	openAIResp.Usage.PromptTokens = int(tokenUsage.InputTokens)
+	openAIResp.Usage.CompletionTokens = int(tokenUsage.OutputTokens)
+	openAIResp.Usage.TotalTokens = int(tokenUsage.TotalTokens)
+	openAIResp.ID, openAIResp.Created, openAIResp.Model = o.id, o.created, o.model
+	if mut.Body, err = json.Marshal(openAIResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// invokeModelTokenCountHeaders returns the token counts reported in the response headers of the InvokeModel API.
+func invokeModelTokenCountHeaders(respHeaders map[string]string) (input, output uint32, ok bool) {
+	in, err := strconv.ParseUint(respHeaders[awsbedrock.InvokeModelInputTokenCountHeader], 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	out, err := strconv.ParseUint(respHeaders[awsbedrock.InvokeModelOutputTokenCountHeader], 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint32(in), uint32(out), true
+}
+
+// invocationMetricsTokenUsage converts the invocation metrics of the streaming response to [LLMTokenUsage].
+func invocationMetricsTokenUsage(metrics *awsbedrock.InvokeModelInvocationMetrics) LLMTokenUsage {
+	return LLMTokenUsage{
+		InputTokens:  uint32(metrics.InputTokenCount),                            //nolint:gosec
+		OutputTokens: uint32(metrics.OutputTokenCount),                           //nolint:gosec
+		TotalTokens:  uint32(metrics.InputTokenCount + metrics.OutputTokenCount), //nolint:gosec
+	}
+}
+
+// extractChunks extracts the model-specific JSON chunks from the event stream messages in the buffered body.
+// The extracted chunks are stored in the chunks field.
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) extractChunks() {
+	if o.decoder == nil {
+		o.decoder = eventstream.NewDecoder()
+	}
+	r := &o.reader
+	r.Reset(o.bufferedBody)
+	o.chunks = o.chunks[:0]
+	var lastRead int64
+	for {
+		msg, err := o.decoder.Decode(r, o.payload[:0])
+		if err != nil {
+			copy(o.bufferedBody, o.bufferedBody[lastRead:])
+			o.bufferedBody = o.bufferedBody[:len(o.bufferedBody)-int(lastRead)]
+			return
+		}
+		o.payload = msg.Payload
+		var chunk awsbedrock.InvokeModelStreamChunk
+		if err := json.Unmarshal(msg.Payload, &chunk); err == nil && len(chunk.Bytes) > 0 {
+			o.chunks = append(o.chunks, chunk.Bytes)
+		}
+		lastRead = r.Size() - int64(r.Len())
+	}
+}
+
+// anthropicStopReasonToOpenAI converts the stop reason of the Anthropic Messages API to the OpenAI finish reason.
+func anthropicStopReasonToOpenAI(stopReason *string) openai.ChatCompletionChoicesFinishReason {
+	switch ptr.Deref(stopReason, "") {
+	case awsbedrock.InvokeModelAnthropicStopReasonMaxTokens:
+		return openai.ChatCompletionChoicesFinishReasonLength
+	case awsbedrock.InvokeModelAnthropicStopReasonToolUse:
+		return openai.ChatCompletionChoicesFinishReasonToolCalls
+	default:
+		return openai.ChatCompletionChoicesFinishReasonStop
+	}
+}
+
+// titanTextCompletionReasonToOpenAI converts the completion reason of the Titan Text models to the OpenAI finish reason.
+func titanTextCompletionReasonToOpenAI(completionReason *string) openai.ChatCompletionChoicesFinishReason {
+	switch ptr.Deref(completionReason, "") {
+	case awsbedrock.InvokeModelTitanTextCompletionReasonLength:
+		return openai.ChatCompletionChoicesFinishReasonLength
+	case awsbedrock.InvokeModelTitanTextCompletionReasonContentFiltered:
+		return openai.ChatCompletionChoicesFinishReasonContentFilter
+	default:
+		return openai.ChatCompletionChoicesFinishReasonStop
+	}
+}
+
+// convertAnthropicResponse converts the Anthropic Messages API response to the OpenAI response.
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) convertAnthropicResponse(body []byte) (
+	*openai.ChatCompletionResponse, LLMTokenUsage, error,
+) {
+	var resp awsbedrock.InvokeModelAnthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, LLMTokenUsage{}, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	var tokenUsage LLMTokenUsage
+	if u := resp.Usage; u != nil {
+		tokenUsage = LLMTokenUsage{
+			InputTokens:           uint32(u.InputTokens),                  //nolint:gosec
+			OutputTokens:          uint32(u.OutputTokens),                 //nolint:gosec
+			TotalTokens:           uint32(u.InputTokens + u.OutputTokens), //nolint:gosec
+			CacheReadInputTokens:  uint32(u.CacheReadInputTokens),         //nolint:gosec
+			CacheWriteInputTokens: uint32(u.CacheCreationInputTokens),     //nolint:gosec
+		}
+	}
+	choice := openai.ChatCompletionResponseChoice{
+		Message:      openai.ChatCompletionResponseChoiceMessage{Role: cmp.Or(resp.Role, awsbedrock.ConversationRoleAssistant)},
+		FinishReason: anthropicStopReasonToOpenAI(resp.StopReason),
+	}
+	for i := range resp.Content {
+		block := &resp.Content[i]
+		switch block.Type {
+		case "text":
+			choice.Message.Content = ptr.To(ptr.Deref(choice.Message.Content, "") + block.Text)
+		case "tool_use":
+			arguments, err := json.Marshal(block.Input)
+			if err != nil {
+				return nil, tokenUsage, fmt.Errorf("failed to marshal tool use input: %w", err)
+			}
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+				ID:       block.ID,
+				Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: block.Name, Arguments: string(arguments)},
+				Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+			})
+		}
+	}
+	return &openai.ChatCompletionResponse{
+		Object:  "chat.completion",
+		Choices: []openai.ChatCompletionResponseChoice{choice},
+		Usage: openai.ChatCompletionResponseUsage{
+			CacheReadInputTokens:  int(tokenUsage.CacheReadInputTokens),
+			CacheWriteInputTokens: int(tokenUsage.CacheWriteInputTokens),
+		},
+	}, tokenUsage, nil
+}
+
+// convertTitanTextResponse converts the Titan Text response to the OpenAI response.
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) convertTitanTextResponse(body []byte) (
+	*openai.ChatCompletionResponse, LLMTokenUsage, error,
+) {
+	var resp awsbedrock.InvokeModelTitanTextResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, LLMTokenUsage{}, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage := LLMTokenUsage{InputTokens: uint32(resp.InputTextTokenCount)} //nolint:gosec
+	openAIResp := &openai.ChatCompletionResponse{Object: "chat.completion"}
+	for i := range resp.Results {
+		result := &resp.Results[i]
+		tokenUsage.OutputTokens += uint32(result.TokenCount) //nolint:gosec
+		openAIResp.Choices = append(openAIResp.Choices, openai.ChatCompletionResponseChoice{
+			Index: int64(i),
+			Message: openai.ChatCompletionResponseChoiceMessage{
+				Role:    awsbedrock.ConversationRoleAssistant,
+				Content: ptr.To(strings.TrimSpace(result.OutputText)),
+			},
+			FinishReason: titanTextCompletionReasonToOpenAI(result.CompletionReason),
+		})
+	}
+	tokenUsage.TotalTokens = tokenUsage.InputTokens + tokenUsage.OutputTokens
+	return openAIResp, tokenUsage, nil
+}
+
+// newChunk returns the chunk with a single choice of the given delta. Following OpenAI, the role is only set in the
+// first chunk, and the finish_reason is only set once even if the model reports it again.
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) newChunk(
+	delta *openai.ChatCompletionResponseChunkChoiceDelta, finishReason openai.ChatCompletionChoicesFinishReason,
+) *openai.ChatCompletionResponseChunk {
+	if !o.roleSent {
+		delta.Role = awsbedrock.ConversationRoleAssistant
+		o.roleSent = true
+	}
+	if finishReason != "" {
+		if o.finishSent {
+			finishReason = ""
+		}
+		o.finishSent = true
+	}
+	return &openai.ChatCompletionResponseChunk{
+		Object:  "chat.completion.chunk",
+		Choices: []openai.ChatCompletionResponseChunkChoice{{Delta: delta, FinishReason: finishReason}},
+	}
+}
+
+// convertAnthropicStreamEvent converts the Anthropic stream event to the OpenAI chunk, which is nil if the event
+// has nothing to send. The invocation metrics are returned for the last event.
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) convertAnthropicStreamEvent(raw []byte) (
+	*openai.ChatCompletionResponseChunk, *awsbedrock.InvokeModelInvocationMetrics,
+) {
+	var event awsbedrock.InvokeModelAnthropicStreamEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return nil, nil
+	}
+	switch event.Type {
+	case "message_start":
+		return o.newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{Content: &emptyString}, ""), nil
+	case "content_block_start":
+		if block := event.ContentBlock; block != nil && block.Type == "tool_use" {
+			index := o.toolCalls
+			o.toolCalls++
+			return o.newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{
+				ToolCalls: []openai.ChatCompletionMessageToolCallParam{{
+					Index:    ptr.To(index),
+					ID:       block.ID,
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: block.Name},
+					Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+				}},
+			}, ""), nil
+		}
+	case "content_block_delta":
+		if delta := event.Delta; delta != nil {
+			switch delta.Type {
+			case "text_delta":
+				return o.newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{Content: ptr.To(delta.Text)}, ""), nil
+			case "input_json_delta":
+				return o.newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{
+					ToolCalls: []openai.ChatCompletionMessageToolCallParam{{
+						Index:    ptr.To(max(o.toolCalls-1, 0)),
+						Function: openai.ChatCompletionMessageToolCallFunctionParam{Arguments: delta.PartialJSON},
+						Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+					}},
+				}, ""), nil
+			}
+		}
+	case "message_delta":
+		if delta := event.Delta; delta != nil && delta.StopReason != nil && !o.finishSent {
+			return o.newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{Content: &emptyString},
+				anthropicStopReasonToOpenAI(delta.StopReason)), nil
+		}
+	case "message_stop":
+		return nil, event.InvocationMetrics
+	}
+	return nil, nil
+}
+
+// convertTitanTextStreamChunk converts the Titan Text stream chunk to the OpenAI chunk. The invocation metrics are
+// returned for the last chunk.
+func (o *openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion) convertTitanTextStreamChunk(raw []byte) (
+	*openai.ChatCompletionResponseChunk, *awsbedrock.InvokeModelInvocationMetrics,
+) {
+	var chunk awsbedrock.InvokeModelTitanTextStreamChunk
+	if err := json.Unmarshal(raw, &chunk); err != nil {
+		return nil, nil
+	}
+	var finishReason openai.ChatCompletionChoicesFinishReason
+	if chunk.CompletionReason != nil {
+		finishReason = titanTextCompletionReasonToOpenAI(chunk.CompletionReason)
+	}
+	return o.newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{Content: ptr.To(chunk.OutputText)}, finishReason),
+		chunk.InvocationMetrics
+}
@@ -0,0 +1,353 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+// encodeInvokeModelStream encodes the model-specific JSON chunks into the event stream of the
+// InvokeModelWithResponseStream API.
+func encodeInvokeModelStream(t *testing.T, chunks ...string) *bytes.Buffer {
+	buf := bytes.NewBuffer(nil)
+	e := eventstream.NewEncoder()
+	for _, chunk := range chunks {
+		payload := `{"bytes":"` + base64.StdEncoding.EncodeToString([]byte(chunk)) + `"}`
+		require.NoError(t, e.Encode(buf, eventstream.Message{
+			Headers: eventstream.Headers{{Name: ":event-type", Value: eventstream.StringValue("chunk")}},
+			Payload: []byte(payload),
+		}))
+	}
+	return buf
+}
+
+func TestOpenAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion_UnsupportedModel(t *testing.T) {
+	o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+	_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "meta.llama3-8b-instruct-v1:0"})
+	require.ErrorIs(t, err, ErrUnsupportedInvokeModelFamily)
+}
+
+func TestOpenAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion_UnsupportedFields(t *testing.T) {
+	req := &openai.ChatCompletionRequest{
+		Model: "anthropic.claude-v2",
+		Messages: []openai.ChatCompletionMessageParamUnion{{
+			Value: openai.ChatCompletionUserMessageParam{Content: openai.StringOrUserRoleContentUnion{Value: "hi"}},
+			Type:  openai.ChatMessageRoleUser,
+		}},
+		LogProbs:    ptr.To(true),
+		TopLogProbs: ptr.To(3),
+	}
+	t.Run("ignore", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+		_, bm, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		require.NotContains(t, string(bm.GetBody()), "logprobs")
+		hm, err := o.ResponseHeaders(map[string]string{})
+		require.NoError(t, err)
+		require.Nil(t, hm)
+	})
+	t.Run("warn", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyWarn, "", nil)
+		_, _, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		hm, err := o.ResponseHeaders(map[string]string{})
+		require.NoError(t, err)
+		require.Len(t, hm.SetHeaders, 1)
+		require.Equal(t, DroppedParamsHeaderKey, hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "logprobs,top_logprobs", hm.SetHeaders[0].Header.Value)
+	})
+	t.Run("reject", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyReject, "", nil)
+		_, _, _, err := o.RequestBody(req)
+		var unsupportedErr *UnsupportedFieldsError
+		require.ErrorAs(t, err, &unsupportedErr)
+		require.Equal(t, []string{"logprobs", "top_logprobs"}, unsupportedErr.Fields)
+
+		// The request explicitly disabling the log probabilities is not rejected.
+		_, _, _, err = o.RequestBody(&openai.ChatCompletionRequest{Model: "anthropic.claude-v2", LogProbs: ptr.To(false)})
+		require.NoError(t, err)
+	})
+}
+
+func TestOpenAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion_Anthropic(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+		hm, bm, override, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model:       "anthropic.claude-v2",
+			Temperature: ptr.To(0.5),
+			Stop:        &openai.ChatCompletionStop{Sequences: []string{"END"}},
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{Type: openai.ChatMessageRoleSystem, Value: openai.ChatCompletionSystemMessageParam{
+					Content: openai.StringOrArray{Value: "You are a weather bot."},
+				}},
+				{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+					Content: openai.StringOrUserRoleContentUnion{Value: "Weather in Tokyo and Paris?"},
+				}},
+				{Type: openai.ChatMessageRoleAssistant, Value: openai.ChatCompletionAssistantMessageParam{
+					ToolCalls: []openai.ChatCompletionMessageToolCallParam{
+						{ID: "call_1", Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "weather", Arguments: `{"city":"Tokyo"}`}},
+						{ID: "call_2", Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "weather", Arguments: `{"city":"Paris"}`}},
+					},
+				}},
+				{Type: openai.ChatMessageRoleTool, Value: openai.ChatCompletionToolMessageParam{
+					ToolCallID: "call_1", Content: openai.StringOrArray{Value: "sunny"},
+				}},
+				{Type: openai.ChatMessageRoleTool, Value: openai.ChatCompletionToolMessageParam{
+					ToolCallID: "call_2", Content: openai.StringOrArray{Value: "rainy"},
+				}},
+			},
+			Tools: []openai.Tool{
+				{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "weather", Description: "Get the weather"}},
+			},
+			ToolChoice: "required",
+		})
+		require.NoError(t, err)
+		require.Nil(t, override)
+		require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "/model/anthropic.claude-v2/invoke", string(hm.SetHeaders[0].Header.RawValue))
+		require.JSONEq(t, `{
+			"anthropic_version": "bedrock-2023-05-31",
+			"max_tokens": 4096,
+			"system": "You are a weather bot.",
+			"temperature": 0.5,
+			"stop_sequences": ["END"],
+			"messages": [
+				{"role": "user", "content": [{"type": "text", "text": "Weather in Tokyo and Paris?"}]},
+				{"role": "assistant", "content": [
+					{"type": "tool_use", "id": "call_1", "name": "weather", "input": {"city": "Tokyo"}},
+					{"type": "tool_use", "id": "call_2", "name": "weather", "input": {"city": "Paris"}}
+				]},
+				{"role": "user", "content": [
+					{"type": "tool_result", "tool_use_id": "call_1", "content": "sunny"},
+					{"type": "tool_result", "tool_use_id": "call_2", "content": "rainy"}
+				]}
+			],
+			"tools": [{"name": "weather", "description": "Get the weather", "input_schema": {"type": "object"}}],
+			"tool_choice": {"type": "any"}
+		}`, string(bm.GetBody()))
+	})
+
+	t.Run("response", func(t *testing.T) {
+		o := &openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion{
+			family: invokeModelFamilyAnthropic, id: "chatcmpl-123", created: 1735689600, model: "some-model",
+		}
+		const body = `{"id":"msg_1","type":"message","role":"assistant","content":[` +
+			`{"type":"text","text":"Let me check."},` +
+			`{"type":"tool_use","id":"toolu_1","name":"weather","input":{"city":"Tokyo"}}],` +
+			`"stop_reason":"tool_use","usage":{"input_tokens":20,"output_tokens":10,"cache_read_input_tokens":5}}`
+		hm, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 20, OutputTokens: 10, TotalTokens: 30, CacheReadInputTokens: 5}, usage)
+		require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+		require.JSONEq(t, `{
+			"id": "chatcmpl-123",
+			"created": 1735689600,
+			"model": "some-model",
+			"object": "chat.completion",
+			"choices": [{
+				"index": 0,
+				"finish_reason": "tool_calls",
+				"logprobs": {},
+				"message": {
+					"role": "assistant",
+					"content": "Let me check.",
+					"tool_calls": [{"id": "toolu_1", "type": "function", "function": {"name": "weather", "arguments": "{\"city\":\"Tokyo\"}"}}]
+				}
+			}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 10, "total_tokens": 30, "cache_read_input_tokens": 5}
+		}`, string(bm.GetBody()))
+	})
+
+	t.Run("response token count headers", func(t *testing.T) {
+		o := &openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion{family: invokeModelFamilyAnthropic}
+		const body = `{"role":"assistant","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}`
+		_, _, usage, err := o.ResponseBody(map[string]string{
+			":status":                           "200",
+			"x-amzn-bedrock-input-token-count":  "7",
+			"x-amzn-bedrock-output-token-count": "3",
+		}, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 7, OutputTokens: 3, TotalTokens: 10}, usage)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+		hm, _, override, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model:  "us.anthropic.claude-3-haiku-20240307-v1:0",
+			Stream: true,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+					Content: openai.StringOrUserRoleContentUnion{Value: "Hi"},
+				}},
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, override)
+		require.Equal(t, "/model/us.anthropic.claude-3-haiku-20240307-v1:0/invoke-with-response-stream",
+			string(hm.SetHeaders[0].Header.RawValue))
+
+		// Fix the generated identity for the golden expectations.
+		tr := o.(*openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion)
+		tr.id, tr.created = "chatcmpl-123", 1735689600
+
+		hm, err = o.ResponseHeaders(map[string]string{"content-type": "application/vnd.amazon.eventstream"})
+		require.NoError(t, err)
+		require.Equal(t, "text/event-stream", hm.SetHeaders[0].Header.Value)
+
+		buf := encodeInvokeModelStream(t,
+			`{"type":"message_start","message":{"id":"msg_1","role":"assistant","content":[],"usage":{"input_tokens":8,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"weather","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Tokyo\"}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}`,
+			// The finish_reason is only sent once.
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}`,
+			`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":8,"outputTokenCount":12,"invocationLatency":500,"firstByteLatency":100}}`,
+		)
+		// Split the stream in the middle of a message to check the buffering.
+		raw := buf.Bytes()
+		_, bm1, usage, err := o.ResponseBody(nil, bytes.NewReader(raw[:100]), false)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{}, usage)
+		_, bm2, usage, err := o.ResponseBody(nil, bytes.NewReader(raw[100:]), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 8, OutputTokens: 12, TotalTokens: 20}, usage)
+		require.Equal(t,
+			`data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[{"delta":{"content":"Hello"}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[{"delta":{"tool_calls":[{"index":0,"id":"toolu_1","function":{"arguments":"","name":"weather"},"type":"function"}]}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[{"delta":{"tool_calls":[{"index":0,"id":"","function":{"arguments":"{\"city\":","name":""},"type":"function"}]}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[{"delta":{"tool_calls":[{"index":0,"id":"","function":{"arguments":"\"Tokyo\"}","name":""},"type":"function"}]}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[{"delta":{"content":""},"finish_reason":"tool_calls"}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"us.anthropic.claude-3-haiku-20240307-v1:0","choices":[],"object":"chat.completion.chunk","usage":{"completion_tokens":12,"prompt_tokens":8,"total_tokens":20}}
+
+data: [DONE]
+`, string(bm1.GetBody())+string(bm2.GetBody()))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		o := &openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion{family: invokeModelFamilyAnthropic}
+		_, bm, _, err := o.ResponseBody(map[string]string{
+			":status":          "400",
+			"content-type":     "application/json",
+			"x-amzn-errortype": "ValidationException",
+		}, strings.NewReader(`{"message":"max_tokens: field required"}`), true)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"error","error":{"type":"ValidationException","message":"max_tokens: field required","code":"400"}}`,
+			string(bm.GetBody()))
+	})
+}
+
+func TestOpenAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion_TitanText(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+		hm, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model:     "amazon.titan-text-express-v1",
+			MaxTokens: ptr.To[int64](100),
+			TopP:      ptr.To(0.9),
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{Type: openai.ChatMessageRoleSystem, Value: openai.ChatCompletionSystemMessageParam{
+					Content: openai.StringOrArray{Value: "Answer briefly."},
+				}},
+				{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+					Content: openai.StringOrUserRoleContentUnion{Value: "Hi"},
+				}},
+				{Type: openai.ChatMessageRoleAssistant, Value: openai.ChatCompletionAssistantMessageParam{
+					Content: openai.ChatCompletionAssistantMessageParamContent{Text: ptr.To("Hello!")},
+				}},
+				{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+					Content: openai.StringOrUserRoleContentUnion{Value: "What is 2+2?"},
+				}},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "/model/amazon.titan-text-express-v1/invoke", string(hm.SetHeaders[0].Header.RawValue))
+		require.JSONEq(t, `{
+			"inputText": "Answer briefly.\nUser: Hi\nBot: Hello!\nUser: What is 2+2?\nBot:",
+			"textGenerationConfig": {"maxTokenCount": 100, "topP": 0.9}
+		}`, string(bm.GetBody()))
+	})
+
+	t.Run("request with tools", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+		_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model: "amazon.titan-text-lite-v1",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{Type: openai.ChatMessageRoleTool, Value: openai.ChatCompletionToolMessageParam{
+					ToolCallID: "call_1", Content: openai.StringOrArray{Value: "sunny"},
+				}},
+			},
+		})
+		require.EqualError(t, err, "unsupported role for the Titan Text models: tool")
+	})
+
+	t.Run("response", func(t *testing.T) {
+		o := &openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion{family: invokeModelFamilyTitanText}
+		const body = `{"inputTextTokenCount":15,"results":[{"tokenCount":4,"outputText":" 2+2 is 4.","completionReason":"FINISH"}]}`
+		_, bm, usage, err := o.ResponseBody(map[string]string{":status": "200"}, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 15, OutputTokens: 4, TotalTokens: 19}, usage)
+		var resp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+		require.Len(t, resp.Choices, 1)
+		require.Equal(t, "2+2 is 4.", *resp.Choices[0].Message.Content)
+		require.Equal(t, openai.ChatCompletionChoicesFinishReasonStop, resp.Choices[0].FinishReason)
+		require.Equal(t, openai.ChatCompletionResponseUsage{PromptTokens: 15, CompletionTokens: 4, TotalTokens: 19}, resp.Usage)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		o := &openAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion{
+			family: invokeModelFamilyTitanText, stream: true, id: "chatcmpl-123", created: 1735689600, model: "some-model",
+		}
+		buf := encodeInvokeModelStream(t,
+			`{"outputText":"2+2","index":0,"totalOutputTextTokenCount":2,"completionReason":null,"inputTextTokenCount":15}`,
+			`{"outputText":" is 4.","index":0,"totalOutputTextTokenCount":4,"completionReason":"LENGTH",`+
+				`"amazon-bedrock-invocationMetrics":{"inputTokenCount":15,"outputTokenCount":4}}`,
+		)
+		_, bm, usage, err := o.ResponseBody(nil, buf, true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{InputTokens: 15, OutputTokens: 4, TotalTokens: 19}, usage)
+		require.Equal(t,
+			`data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"2+2","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" is 4."},"finish_reason":"length"}],"object":"chat.completion.chunk","usage":{"completion_tokens":4,"prompt_tokens":15,"total_tokens":19}}
+
+data: [DONE]
+`, string(bm.GetBody()))
+	})
+}
+
+func TestOpenAIToAWSBedrockInvokeModelTranslatorV1ChatCompletion_AudioInput(t *testing.T) {
+	for _, model := range []string{"anthropic.claude-3-5-sonnet-20240620-v1:0", "amazon.titan-text-express-v1"} {
+		t.Run(model, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+			_, _, _, err := o.RequestBody(audioInputRequest(model))
+			var audioErr *UnsupportedAudioInputError
+			require.ErrorAs(t, err, &audioErr)
+			require.Equal(t, &UnsupportedAudioInputError{MessageIndex: 1, PartIndex: 1}, audioErr)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
@@ -663,7 +664,38 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody(t *testing.T)
 						}, Type: openai.ChatMessageRoleUser,
 					},
 				},
-				Stop: []*string{ptr.To("stop_only")},
+				Stop: &openai.ChatCompletionStop{Sequences: []string{"stop_only"}},
+			},
+			output: awsbedrock.ConverseInput{
+				InferenceConfig: &awsbedrock.InferenceConfiguration{
+					StopSequences: []*string{ptr.To("stop_only")},
+				},
+				Messages: []*awsbedrock.Message{
+					{
+						Role: openai.ChatMessageRoleUser,
+						Content: []*awsbedrock.ContentBlock{
+							{
+								Text: ptr.To("from-user"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "test stop word string",
+			input: openai.ChatCompletionRequest{
+				Model: "gpt-4o",
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					{
+						Value: openai.ChatCompletionUserMessageParam{
+							Content: openai.StringOrUserRoleContentUnion{
+								Value: "from-user",
+							},
+						}, Type: openai.ChatMessageRoleUser,
+					},
+				},
+				Stop: &openai.ChatCompletionStop{Sequences: []string{"stop_only"}, IsString: true},
 			},
 			output: awsbedrock.ConverseInput{
 				InferenceConfig: &awsbedrock.InferenceConfiguration{
@@ -719,6 +751,482 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody(t *testing.T)
 	}
 }
 
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_Guardrail(t *testing.T) {
+	guardrail := &awsbedrock.GuardrailConfiguration{
+		GuardrailIdentifier: ptr.To("arn:aws:bedrock:us-east-1:123456789012:guardrail/abc"),
+		GuardrailVersion:    ptr.To("1"),
+		Trace:               ptr.To("enabled"),
+	}
+	o := NewChatCompletionOpenAIToAWSBedrockTranslator(guardrail, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+	for _, stream := range []bool{false, true} {
+		_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model:  "gpt-4o",
+			Stream: stream,
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{
+					Value: openai.ChatCompletionUserMessageParam{
+						Content: openai.StringOrUserRoleContentUnion{Value: "from-user"},
+					}, Type: openai.ChatMessageRoleUser,
+				},
+			},
+		})
+		require.NoError(t, err)
+		var awsReq awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &awsReq))
+		require.Equal(t, guardrail, awsReq.GuardrailConfig)
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_RemoteImageURL(t *testing.T) {
+	o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+	_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{
+				Value: openai.ChatCompletionUserMessageParam{
+					Content: openai.StringOrUserRoleContentUnion{
+						Value: []openai.ChatCompletionContentPartUserUnionParam{
+							{ImageContent: &openai.ChatCompletionContentPartImageParam{
+								ImageURL: openai.ChatCompletionContentPartImageImageURLParam{URL: "https://example.com/cat.png"},
+							}},
+						},
+					},
+				}, Type: openai.ChatMessageRoleUser,
+			},
+		},
+	})
+	require.ErrorIs(t, err, ErrUnsupportedImageURL)
+	require.ErrorContains(t, err, "https://example.com/cat.png: only data URIs are supported for AWS Bedrock backends")
+}
+
+// audioInputRequest returns the request whose second message has the input_audio content part at index 1.
+func audioInputRequest(model string) *openai.ChatCompletionRequest {
+	return &openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{Value: openai.ChatCompletionAssistantMessageParam{
+				Content: openai.ChatCompletionAssistantMessageParamContent{
+					Type: openai.ChatCompletionAssistantMessageParamContentTypeRefusal, Refusal: ptr.To("I cannot help with that."),
+				},
+			}, Type: openai.ChatMessageRoleAssistant},
+			{Value: openai.ChatCompletionUserMessageParam{
+				Content: openai.StringOrUserRoleContentUnion{
+					Value: []openai.ChatCompletionContentPartUserUnionParam{
+						{TextContent: &openai.ChatCompletionContentPartTextParam{Type: "text", Text: "what is in this recording?"}},
+						{InputAudioContent: &openai.ChatCompletionContentPartInputAudioParam{
+							Type: openai.ChatCompletionContentPartInputAudioTypeInputAudio,
+							InputAudio: openai.ChatCompletionContentPartInputAudioInputAudioParam{
+								Data: "UklGRg==", Format: openai.ChatCompletionContentPartInputAudioInputAudioFormatWAV,
+							},
+						}},
+					},
+				},
+			}, Type: openai.ChatMessageRoleUser},
+		},
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_AudioInput(t *testing.T) {
+	o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+	_, _, _, err := o.RequestBody(audioInputRequest("gpt-4o-audio-preview"))
+	var audioErr *UnsupportedAudioInputError
+	require.ErrorAs(t, err, &audioErr)
+	require.Equal(t, &UnsupportedAudioInputError{MessageIndex: 1, PartIndex: 1}, audioErr)
+	require.Equal(t, "messages[1].content[1]", audioErr.Param())
+	require.EqualError(t, err, "messages[1].content[1]: audio input (input_audio) is not supported by the selected backend")
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_UnexpectedMessage(t *testing.T) {
+	o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+	_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{Value: "not a message", Type: openai.ChatMessageRoleUser},
+		},
+	})
+	require.ErrorContains(t, err, "unexpected role: user")
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_ModelPath(t *testing.T) {
+	for _, tc := range []struct {
+		name, modelPrefix, model string
+		stream                   bool
+		expPath                  string
+		expToolChoice            bool
+	}{
+		{
+			name:          "inference profile",
+			model:         "us.anthropic.claude-3-5-sonnet-20240620-v1:0",
+			expPath:       "/model/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse",
+			expToolChoice: true,
+		},
+		{
+			name:          "foundation model arn",
+			model:         "arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude-3-5-sonnet-20240620-v1:0",
+			stream:        true,
+			expPath:       "/model/arn:aws:bedrock:us-east-1::foundation-model%2Fanthropic.claude-3-5-sonnet-20240620-v1:0/converse-stream",
+			expToolChoice: true,
+		},
+		{
+			name:          "inference profile arn",
+			model:         "arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.anthropic.claude-3-5-sonnet-20240620-v1:0",
+			expPath:       "/model/arn:aws:bedrock:us-east-1:123456789012:inference-profile%2Fus.anthropic.claude-3-5-sonnet-20240620-v1:0/converse",
+			expToolChoice: true,
+		},
+		{
+			name:          "model prefix",
+			modelPrefix:   "us.",
+			model:         "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			expPath:       "/model/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse",
+			expToolChoice: true,
+		},
+		{
+			name:          "model prefix already prefixed",
+			modelPrefix:   "us.",
+			model:         "us.anthropic.claude-3-5-sonnet-20240620-v1:0",
+			expPath:       "/model/us.anthropic.claude-3-5-sonnet-20240620-v1:0/converse",
+			expToolChoice: true,
+		},
+		{
+			name:        "model prefix not applied to arn",
+			modelPrefix: "us.",
+			model:       "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc",
+			expPath:     "/model/arn:aws:bedrock:us-east-1:123456789012:application-inference-profile%2Fabc/converse",
+		},
+		{
+			name:    "not claude",
+			model:   "us.meta.llama3-2-1b-instruct-v1:0",
+			expPath: "/model/us.meta.llama3-2-1b-instruct-v1:0/converse",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, tc.modelPrefix, nil, nil)
+			hm, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+				Model:  tc.model,
+				Stream: tc.stream,
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					{
+						Value: openai.ChatCompletionUserMessageParam{
+							Content: openai.StringOrUserRoleContentUnion{Value: "from-user"},
+						}, Type: openai.ChatMessageRoleUser,
+					},
+				},
+				Tools: []openai.Tool{
+					{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{Name: "get_weather"}},
+				},
+				ToolChoice: "get_weather",
+			})
+			require.NoError(t, err)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, tc.expPath, string(hm.SetHeaders[0].Header.RawValue))
+			var awsReq awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+			if tc.expToolChoice {
+				require.Equal(t, "get_weather", *awsReq.ToolConfig.ToolChoice.Tool.Name)
+			} else {
+				require.Nil(t, awsReq.ToolConfig.ToolChoice)
+			}
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_RequestMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		user        string
+		metadata    map[string]string
+		expMetadata map[string]string
+		expErr      error
+	}{
+		{name: "none"},
+		{name: "user", user: "alice", expMetadata: map[string]string{"user": "alice"}},
+		{
+			name:        "metadata",
+			metadata:    map[string]string{"team": "search"},
+			expMetadata: map[string]string{"team": "search"},
+		},
+		{
+			name:        "user and metadata",
+			user:        "alice",
+			metadata:    map[string]string{"team": "search", "user": "bob"},
+			expMetadata: map[string]string{"team": "search", "user": "bob"},
+		},
+		{
+			name:     "user and too many metadata keys",
+			user:     "alice",
+			metadata: metadataWithKeys(16),
+			expErr:   ErrRequestMetadataTooManyKeys,
+		},
+		{
+			name:        "user in the maximum metadata keys",
+			user:        "alice",
+			metadata:    metadataWithKeys(15),
+			expMetadata: func() map[string]string { m := metadataWithKeys(15); m["user"] = "alice"; return m }(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+			_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+				Model:    "anthropic.claude-3-5-sonnet-20240620-v1:0",
+				User:     tc.user,
+				Metadata: tc.metadata,
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					{
+						Value: openai.ChatCompletionUserMessageParam{
+							Content: openai.StringOrUserRoleContentUnion{Value: "from-user"},
+						}, Type: openai.ChatMessageRoleUser,
+					},
+				},
+			})
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			var awsReq awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+			require.Equal(t, tc.expMetadata, awsReq.RequestMetadata)
+		})
+	}
+}
+
+// metadataWithKeys returns the metadata with n keys.
+func metadataWithKeys(n int) map[string]string {
+	metadata := make(map[string]string, n)
+	for i := range n {
+		metadata[fmt.Sprintf("k%d", i)] = "v"
+	}
+	return metadata
+}
+
+func Test_isAnthropicClaudeModel(t *testing.T) {
+	for _, model := range []string{
+		"anthropic.claude-3-5-sonnet-20240620-v1:0",
+		"us.anthropic.claude-3-5-sonnet-20240620-v1:0",
+		"apac.anthropic.claude-3-haiku-20240307-v1:0",
+		"arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude-v2:1",
+		"arn:aws:bedrock:us-east-1:123456789012:inference-profile/eu.anthropic.claude-3-haiku-20240307-v1:0",
+	} {
+		require.True(t, isAnthropicClaudeModel(model), model)
+	}
+	for _, model := range []string{
+		"meta.llama3-2-1b-instruct-v1:0",
+		"us.meta.llama3-2-1b-instruct-v1:0",
+		"my-anthropic-claude-finetune",
+		"arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abc",
+	} {
+		require.False(t, isAnthropicClaudeModel(model), model)
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_MaxTokens(t *testing.T) {
+	for _, tc := range []struct {
+		name                           string
+		maxTokens, maxCompletionTokens *int64
+		exp                            *int64
+	}{
+		{name: "none"},
+		{name: "max_tokens", maxTokens: ptr.To[int64](100), exp: ptr.To[int64](100)},
+		{name: "max_completion_tokens", maxCompletionTokens: ptr.To[int64](200), exp: ptr.To[int64](200)},
+		{name: "both", maxTokens: ptr.To[int64](100), maxCompletionTokens: ptr.To[int64](200), exp: ptr.To[int64](200)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &openai.ChatCompletionRequest{
+				Model: "anthropic.claude-3-5-sonnet-20240620-v1:0", MaxTokens: tc.maxTokens, MaxCompletionTokens: tc.maxCompletionTokens,
+				Messages: []openai.ChatCompletionMessageParamUnion{{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+					Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "hi"},
+				}}},
+			}
+			o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+			_, bm, _, err := o.RequestBody(req)
+			require.NoError(t, err)
+			var converse awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &converse))
+			require.Equal(t, tc.exp, converse.InferenceConfig.MaxTokens)
+
+			o = NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+			_, bm, _, err = o.RequestBody(req)
+			require.NoError(t, err)
+			var anthropic awsbedrock.InvokeModelAnthropicRequest
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &anthropic))
+			require.Equal(t, ptr.Deref(tc.exp, defaultInvokeModelAnthropicMaxTokens), anthropic.MaxTokens)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_SystemMessages(t *testing.T) {
+	systemMessage := openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleSystem, Value: openai.ChatCompletionSystemMessageParam{
+		Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: "be brief"},
+	}}
+	developerMessage := openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleDeveloper, Value: openai.ChatCompletionDeveloperMessageParam{
+		Role: openai.ChatMessageRoleDeveloper, Content: openai.StringOrArray{Value: "answer in English"},
+	}}
+	userMessage := openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+		Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "hi"},
+	}}
+	assistantMessage := openai.ChatCompletionMessageParamUnion{Type: openai.ChatMessageRoleAssistant, Value: openai.ChatCompletionAssistantMessageParam{
+		Role: openai.ChatMessageRoleAssistant, Content: openai.ChatCompletionAssistantMessageParamContent{Type: openai.ChatCompletionAssistantMessageParamContentTypeText, Text: ptr.To("hello")},
+	}}
+	for _, tc := range []struct {
+		name          string
+		model         string
+		modelFamilies []BedrockModelFamily
+		messages      []openai.ChatCompletionMessageParamUnion
+		expSystem     []*awsbedrock.SystemContentBlock
+		expMessages   []*awsbedrock.Message
+	}{
+		{
+			name:      "claude keeps the system",
+			model:     "anthropic.claude-3-5-sonnet-20240620-v1:0",
+			messages:  []openai.ChatCompletionMessageParamUnion{systemMessage, developerMessage, userMessage},
+			expSystem: []*awsbedrock.SystemContentBlock{{Text: "be brief"}, {Text: "answer in English"}},
+			expMessages: []*awsbedrock.Message{
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("hi")}}},
+			},
+		},
+		{
+			name:     "titan folds the system",
+			model:    "amazon.titan-text-express-v1",
+			messages: []openai.ChatCompletionMessageParamUnion{systemMessage, developerMessage, userMessage, assistantMessage, userMessage},
+			expMessages: []*awsbedrock.Message{
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{
+					{Text: ptr.To("be brief\n\nanswer in English\n\n---\n\nhi")},
+				}},
+				{Role: awsbedrock.ConversationRoleAssistant, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("hello")}}},
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("hi")}}},
+			},
+		},
+		{
+			name:     "titan arn of the inference profile",
+			model:    "arn:aws:bedrock:us-east-1:123456789012:inference-profile/us.amazon.titan-text-premier-v1:0",
+			messages: []openai.ChatCompletionMessageParamUnion{userMessage, systemMessage},
+			expMessages: []*awsbedrock.Message{
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("be brief\n\n---\n\nhi")}}},
+			},
+		},
+		{
+			name:     "titan without user message",
+			model:    "amazon.titan-text-express-v1",
+			messages: []openai.ChatCompletionMessageParamUnion{systemMessage},
+			expMessages: []*awsbedrock.Message{
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("be brief")}}},
+			},
+		},
+		{
+			name:          "configured family folds the system",
+			model:         "us.meta.llama3-2-1b-instruct-v1:0",
+			modelFamilies: []BedrockModelFamily{{ModelPrefix: "meta.llama3-2-1b", NoSystemMessages: true}},
+			messages:      []openai.ChatCompletionMessageParamUnion{systemMessage, userMessage},
+			expMessages: []*awsbedrock.Message{
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("be brief\n\n---\n\nhi")}}},
+			},
+		},
+		{
+			name:          "configured family overrides the built-in one",
+			model:         "amazon.titan-text-premier-v1:0",
+			modelFamilies: []BedrockModelFamily{{ModelPrefix: "amazon.titan-text-premier"}},
+			messages:      []openai.ChatCompletionMessageParamUnion{systemMessage, userMessage},
+			expSystem:     []*awsbedrock.SystemContentBlock{{Text: "be brief"}},
+			expMessages: []*awsbedrock.Message{
+				{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("hi")}}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, tc.modelFamilies)
+			_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: tc.model, Messages: tc.messages})
+			require.NoError(t, err)
+			var awsReq awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+			require.Equal(t, tc.expSystem, awsReq.System)
+			require.Equal(t, tc.expMessages, awsReq.Messages)
+		})
+	}
+}
+
+func Test_foldBedrockSystem(t *testing.T) {
+	image := &awsbedrock.ContentBlock{Image: &awsbedrock.ImageBlock{Format: "png", Source: awsbedrock.ImageSource{Bytes: []byte("png")}}}
+	req := &awsbedrock.ConverseInput{
+		System: []*awsbedrock.SystemContentBlock{{Text: "be brief"}, {CachePoint: &awsbedrock.CachePointBlock{Type: "default"}}},
+		Messages: []*awsbedrock.Message{
+			{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{image, {Text: ptr.To("what is this?")}}},
+		},
+	}
+	foldBedrockSystem(req)
+	require.Nil(t, req.System)
+	require.Equal(t, []*awsbedrock.ContentBlock{{Text: ptr.To("be brief\n\n---\n\n")}, image, {Text: ptr.To("what is this?")}}, req.Messages[0].Content)
+
+	// The cache points alone are dropped without touching the messages.
+	req = &awsbedrock.ConverseInput{
+		System:   []*awsbedrock.SystemContentBlock{{CachePoint: &awsbedrock.CachePointBlock{Type: "default"}}},
+		Messages: []*awsbedrock.Message{{Role: awsbedrock.ConversationRoleUser, Content: []*awsbedrock.ContentBlock{{Text: ptr.To("hi")}}}},
+	}
+	foldBedrockSystem(req)
+	require.Nil(t, req.System)
+	require.Equal(t, []*awsbedrock.ContentBlock{{Text: ptr.To("hi")}}, req.Messages[0].Content)
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_UnsupportedFields(t *testing.T) {
+	req := &openai.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{
+				Value: openai.ChatCompletionUserMessageParam{
+					Content: openai.StringOrUserRoleContentUnion{Value: "from-user"},
+				}, Type: openai.ChatMessageRoleUser,
+			},
+		},
+		LogitBias:       map[string]int{"1234": -100},
+		Seed:            ptr.To(42),
+		PresencePenalty: ptr.To[float32](0.5),
+		LogProbs:        ptr.To(true),
+		TopLogProbs:     ptr.To(3),
+	}
+	t.Run("ignore", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+		_, bm, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		require.NotContains(t, string(bm.GetBody()), "seed")
+		hm, err := o.ResponseHeaders(map[string]string{})
+		require.NoError(t, err)
+		require.Nil(t, hm)
+	})
+	t.Run("warn", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyWarn, "", nil, nil)
+		_, bm, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		require.NotContains(t, string(bm.GetBody()), "seed")
+		require.NotContains(t, string(bm.GetBody()), "logprobs")
+		hm, err := o.ResponseHeaders(map[string]string{})
+		require.NoError(t, err)
+		require.Len(t, hm.SetHeaders, 1)
+		require.Equal(t, DroppedParamsHeaderKey, hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "logit_bias,seed,presence_penalty,logprobs,top_logprobs", hm.SetHeaders[0].Header.Value)
+	})
+	t.Run("reject", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyReject, "", nil, nil)
+		_, _, _, err := o.RequestBody(req)
+		var unsupportedErr *UnsupportedFieldsError
+		require.ErrorAs(t, err, &unsupportedErr)
+		require.Equal(t, []string{"logit_bias", "seed", "presence_penalty", "logprobs", "top_logprobs"}, unsupportedErr.Fields)
+		require.EqualError(t, err, "unsupported fields: logit_bias, seed, presence_penalty, logprobs, top_logprobs")
+
+		// The request without the unsupported fields is not rejected.
+		_, _, _, err = o.RequestBody(&openai.ChatCompletionRequest{Model: "gpt-4o"})
+		require.NoError(t, err)
+		// Neither is the one explicitly disabling the log probabilities.
+		_, _, _, err = o.RequestBody(&openai.ChatCompletionRequest{Model: "gpt-4o", LogProbs: ptr.To(false)})
+		require.NoError(t, err)
+	})
+}
+
+func TestIsRemoteImageURL(t *testing.T) {
+	require.True(t, IsRemoteImageURL("https://example.com/cat.png"))
+	require.True(t, IsRemoteImageURL("http://example.com/cat.png"))
+	require.False(t, IsRemoteImageURL("data:image/png;base64,dGVzdA=="))
+	require.False(t, IsRemoteImageURL("ftp://example.com/cat.png"))
+}
+
 func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseHeaders(t *testing.T) {
 	t.Run("streaming", func(t *testing.T) {
 		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true}
@@ -742,7 +1250,7 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseHeaders(t *testing
 
 func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_Streaming_ResponseBody(t *testing.T) {
 	t.Run("streaming", func(t *testing.T) {
-		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true}
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true, id: "chatcmpl-123", created: 1735689600, model: "some-model"}
 		buf, err := base64.StdEncoding.DecodeString(base64RealStreamingEvents)
 		require.NoError(t, err)
 
@@ -765,51 +1273,51 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_Streaming_ResponseBody(t *
 		result := strings.Join(results, "")
 
 		require.Equal(t,
-			`data: {"choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
+			`data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"To","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"To"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" calculate the cosine","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" calculate the cosine"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" of 7,","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" of 7,"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" we can use the","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" we can use the"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" \"","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" \""}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"cosine\" function","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"cosine\" function"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" that","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" that"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" is","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" is"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" available to","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" available to"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" us.","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" us."}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" Let","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" Let"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"'s use","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"'s use"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" this","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" this"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" function to","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" function to"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" get","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" get"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" the result","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":" the result"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":".","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"."}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"role":"assistant","tool_calls":[{"id":"tooluse_QklrEHKjRu6Oc4BQUfy7ZQ","function":{"arguments":"","name":"cosine"},"type":"function"}]}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"tool_calls":[{"index":0,"id":"tooluse_QklrEHKjRu6Oc4BQUfy7ZQ","function":{"arguments":"","name":"cosine"},"type":"function"}]}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"role":"assistant","tool_calls":[{"id":"","function":{"arguments":"","name":""},"type":"function"}]}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"tool_calls":[{"index":0,"id":"","function":{"arguments":"","name":""},"type":"function"}]}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"role":"assistant","tool_calls":[{"id":"","function":{"arguments":"{\"x\": 7}","name":""},"type":"function"}]}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"tool_calls":[{"index":0,"id":"","function":{"arguments":"{\"x\": 7}","name":""},"type":"function"}]}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"","role":"assistant"},"finish_reason":"tool_calls"}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{},"finish_reason":"tool_calls"}],"object":"chat.completion.chunk"}
 
-data: {"object":"chat.completion.chunk","usage":{"completion_tokens":75,"prompt_tokens":386,"total_tokens":461}}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[],"object":"chat.completion.chunk","usage":{"completion_tokens":75,"prompt_tokens":386,"total_tokens":461}}
 
 data: [DONE]
 `, result)
@@ -917,7 +1425,10 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody(t *testing.T)
 				},
 			},
 			output: openai.ChatCompletionResponse{
-				Object: "chat.completion",
+				ID:      "chatcmpl-123",
+				Created: 1735689600,
+				Model:   "some-model",
+				Object:  "chat.completion",
 				Usage: openai.ChatCompletionResponseUsage{
 					TotalTokens:      30,
 					PromptTokens:     10,
@@ -935,6 +1446,46 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody(t *testing.T)
 				},
 			},
 		},
+		{
+			name: "guardrail intervened",
+			input: awsbedrock.ConverseResponse{
+				Usage: &awsbedrock.TokenUsage{
+					InputTokens:  10,
+					OutputTokens: 5,
+					TotalTokens:  15,
+				},
+				StopReason: ptr.To(awsbedrock.StopReasonGuardrailIntervened),
+				Output: &awsbedrock.ConverseOutput{
+					Message: awsbedrock.Message{
+						Role: awsbedrock.ConversationRoleAssistant,
+						Content: []*awsbedrock.ContentBlock{
+							{Text: ptr.To("Sorry, the model cannot answer this question.")},
+						},
+					},
+				},
+			},
+			output: openai.ChatCompletionResponse{
+				ID:      "chatcmpl-123",
+				Created: 1735689600,
+				Model:   "some-model",
+				Object:  "chat.completion",
+				Usage: openai.ChatCompletionResponseUsage{
+					TotalTokens:      15,
+					PromptTokens:     10,
+					CompletionTokens: 5,
+				},
+				Choices: []openai.ChatCompletionResponseChoice{
+					{
+						Index: 0,
+						Message: openai.ChatCompletionResponseChoiceMessage{
+							Content: ptr.To("Sorry, the model cannot answer this question."),
+							Role:    awsbedrock.ConversationRoleAssistant,
+						},
+						FinishReason: openai.ChatCompletionChoicesFinishReasonContentFilter,
+					},
+				},
+			},
+		},
 		{
 			name: "test stop reason",
 			input: awsbedrock.ConverseResponse{
@@ -954,7 +1505,10 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody(t *testing.T)
 				},
 			},
 			output: openai.ChatCompletionResponse{
-				Object: "chat.completion",
+				ID:      "chatcmpl-123",
+				Created: 1735689600,
+				Model:   "some-model",
+				Object:  "chat.completion",
 				Usage: openai.ChatCompletionResponseUsage{
 					TotalTokens:      30,
 					PromptTokens:     10,
@@ -996,7 +1550,10 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody(t *testing.T)
 				},
 			},
 			output: openai.ChatCompletionResponse{
-				Object: "chat.completion",
+				ID:      "chatcmpl-123",
+				Created: 1735689600,
+				Model:   "some-model",
+				Object:  "chat.completion",
 				Choices: []openai.ChatCompletionResponseChoice{
 					{
 						Index:        0,
@@ -1026,7 +1583,7 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody(t *testing.T)
 			body, err := json.Marshal(tt.input)
 			require.NoError(t, err)
 
-			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{id: "chatcmpl-123", created: 1735689600, model: "some-model"}
 			hm, bm, usedToken, err := o.ResponseBody(nil, bytes.NewBuffer(body), false)
 			require.NoError(t, err)
 			require.NotNil(t, bm)
@@ -1056,6 +1613,166 @@ func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody(t *testing.T)
 	}
 }
 
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody_MultipleToolCalls(t *testing.T) {
+	toolUse := func(i int) *awsbedrock.ContentBlock {
+		return &awsbedrock.ContentBlock{ToolUse: &awsbedrock.ToolUseBlock{
+			Name:      fmt.Sprintf("tool_%d", i),
+			ToolUseID: fmt.Sprintf("call_%d", i),
+			Input:     map[string]any{"x": i},
+		}}
+	}
+	toolCall := func(i int) openai.ChatCompletionMessageToolCallParam {
+		return openai.ChatCompletionMessageToolCallParam{
+			ID: fmt.Sprintf("call_%d", i),
+			Function: openai.ChatCompletionMessageToolCallFunctionParam{
+				Name:      fmt.Sprintf("tool_%d", i),
+				Arguments: fmt.Sprintf(`{"x":%d}`, i),
+			},
+			Type: openai.ChatCompletionMessageToolCallTypeFunction,
+		}
+	}
+	for _, tc := range []struct {
+		name           string
+		singleToolCall bool
+		content        []*awsbedrock.ContentBlock
+		exp            []openai.ChatCompletionMessageToolCallParam
+	}{
+		{
+			name:    "two tool calls",
+			content: []*awsbedrock.ContentBlock{{Text: ptr.To("calling tools")}, toolUse(1), toolUse(2)},
+			exp:     []openai.ChatCompletionMessageToolCallParam{toolCall(1), toolCall(2)},
+		},
+		{
+			name:    "three tool calls",
+			content: []*awsbedrock.ContentBlock{toolUse(1), toolUse(2), toolUse(3)},
+			exp:     []openai.ChatCompletionMessageToolCallParam{toolCall(1), toolCall(2), toolCall(3)},
+		},
+		{
+			name:           "three tool calls without parallel tool calls",
+			singleToolCall: true,
+			content:        []*awsbedrock.ContentBlock{toolUse(1), toolUse(2), toolUse(3)},
+			exp:            []openai.ChatCompletionMessageToolCallParam{toolCall(1)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := json.Marshal(awsbedrock.ConverseResponse{
+				StopReason: ptr.To(awsbedrock.StopReasonToolUse),
+				Output: &awsbedrock.ConverseOutput{
+					Message: awsbedrock.Message{Role: awsbedrock.ConversationRoleAssistant, Content: tc.content},
+				},
+			})
+			require.NoError(t, err)
+
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{singleToolCall: tc.singleToolCall}
+			_, bm, _, err := o.ResponseBody(nil, bytes.NewBuffer(body), false)
+			require.NoError(t, err)
+
+			var openAIResp openai.ChatCompletionResponse
+			require.NoError(t, json.Unmarshal(bm.Mutation.(*extprocv3.BodyMutation_Body).Body, &openAIResp))
+			require.Len(t, openAIResp.Choices, 1)
+			require.Equal(t, openai.ChatCompletionChoicesFinishReasonToolCalls, openAIResp.Choices[0].FinishReason)
+			require.Equal(t, tc.exp, openAIResp.Choices[0].Message.ToolCalls)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_ParallelToolCalls(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		parallelToolCalls *bool
+		expSingleToolCall bool
+	}{
+		{name: "unset"},
+		{name: "true", parallelToolCalls: ptr.To(true)},
+		{name: "false", parallelToolCalls: ptr.To(false), expSingleToolCall: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+			_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "gpt-4o", ParallelToolCalls: tc.parallelToolCalls})
+			require.NoError(t, err)
+			require.Equal(t, tc.expSingleToolCall, o.singleToolCall)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_ToolChoiceNone(t *testing.T) {
+	const tools = `"tools":[{"type":"function","function":{"name":"cosine","parameters":{"type":"object"}}}]`
+	for _, tc := range []struct {
+		name          string
+		body          string
+		expToolConfig *awsbedrock.ToolConfiguration
+	}{
+		{
+			name: "tools stripped",
+			body: `{"model":"anthropic.claude-3-5-sonnet","tool_choice":"none",` + tools + `,
+"messages":[{"role":"user","content":"What is the cosine of 7?"}]}`,
+		},
+		{
+			name: "tools kept for the tool use in the conversation",
+			body: `{"model":"anthropic.claude-3-5-sonnet","tool_choice":"none",` + tools + `,
+"messages":[{"role":"user","content":"What is the cosine of 7?"},
+{"role":"assistant","content":{"type":"text","text":"Let me calculate."},"tool_calls":[{"id":"call_1","type":"function","function":{"name":"cosine","arguments":"{\"x\":7}"}}]},
+{"role":"tool","tool_call_id":"call_1","content":"0.75"}]}`,
+			expToolConfig: &awsbedrock.ToolConfiguration{Tools: []*awsbedrock.Tool{{ToolSpec: &awsbedrock.ToolSpecification{
+				Name: ptr.To("cosine"), Description: ptr.To(""), InputSchema: &awsbedrock.ToolInputSchema{JSON: map[string]any{"type": "object"}},
+			}}}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var openAIReq openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &openAIReq))
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+			_, bm, _, err := o.RequestBody(&openAIReq)
+			require.NoError(t, err)
+			var bedrockReq awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &bedrockReq))
+			require.Equal(t, tc.expToolConfig, bedrockReq.ToolConfig)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_RequestBody_AssistantPrefill(t *testing.T) {
+	const messages = `"messages":[{"role":"user","content":"List the colors in JSON."},{"role":"assistant","content":{"type":"text","text":"{\"colors\":["}}]`
+	const tools = `"tools":[{"type":"function","function":{"name":"colors","parameters":{"type":"object"}}}]`
+	for _, tc := range []struct {
+		name   string
+		body   string
+		expErr error
+	}{
+		{name: "without tools", body: `{"model":"anthropic.claude-3-5-sonnet",` + messages + `}`},
+		{name: "tool_choice auto", body: `{"model":"anthropic.claude-3-5-sonnet","tool_choice":"auto",` + tools + `,` + messages + `}`},
+		{name: "tool_choice none", body: `{"model":"anthropic.claude-3-5-sonnet","tool_choice":"none",` + tools + `,` + messages + `}`},
+		{
+			name:   "tool_choice required",
+			body:   `{"model":"anthropic.claude-3-5-sonnet","tool_choice":"required",` + tools + `,` + messages + `}`,
+			expErr: ErrAssistantPrefillWithToolChoice,
+		},
+		{
+			name:   "specific tool",
+			body:   `{"model":"anthropic.claude-3-5-sonnet","tool_choice":"colors",` + tools + `,` + messages + `}`,
+			expErr: ErrAssistantPrefillWithToolChoice,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var openAIReq openai.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tc.body), &openAIReq))
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+			_, bm, _, err := o.RequestBody(&openAIReq)
+			if tc.expErr != nil {
+				require.ErrorIs(t, err, tc.expErr)
+				return
+			}
+			require.NoError(t, err)
+			var bedrockReq awsbedrock.ConverseInput
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &bedrockReq))
+			// The assistant message is passed through as the last message for Converse to continue from it.
+			require.Len(t, bedrockReq.Messages, 2)
+			require.Equal(t, openai.ChatMessageRoleAssistant, bedrockReq.Messages[1].Role)
+			require.Equal(t, `{"colors":[`, *bedrockReq.Messages[1].Content[0].Text)
+		})
+	}
+}
+
 // base64RealStreamingEvents is the base64 encoded raw binary response from bedrock anthropic.claude model.
 // The request is to find the cosine of number 7 with a tool configuration.
 /*
@@ -1171,6 +1888,157 @@ func TestOpenAIToAWSBedrockTranslatorExtractAmazonEventStreamEvents(t *testing.T
 	})
 }
 
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody_StreamBufferLimit(t *testing.T) {
+	o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 64, UnsupportedFieldPolicyIgnore, "", nil, nil).(*openAIToAWSBedrockTranslatorV1ChatCompletion)
+	o.stream = true
+	garbage := bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 8)
+
+	// The unparsable bytes are buffered until the limit is reached.
+	_, _, _, err := o.ResponseBody(nil, bytes.NewReader(garbage), false)
+	require.NoError(t, err)
+	require.Len(t, o.bufferedBody, 32)
+	_, _, _, err = o.ResponseBody(nil, bytes.NewReader(garbage), false)
+	require.NoError(t, err)
+	require.Len(t, o.bufferedBody, 64)
+
+	_, _, _, err = o.ResponseBody(nil, bytes.NewReader(garbage), false)
+	require.ErrorIs(t, err, ErrStreamBufferLimitExceeded)
+	require.ErrorContains(t, err, "more than 64 bytes are not parsed")
+	require.Empty(t, o.bufferedBody)
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseBody_StreamException(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	e := eventstream.NewEncoder()
+	require.NoError(t, e.Encode(buf, eventstream.Message{
+		Headers: eventstream.Headers{
+			{Name: ":event-type", Value: eventstream.StringValue("contentBlockDelta")},
+			{Name: ":message-type", Value: eventstream.StringValue("event")},
+		},
+		Payload: []byte(`{"contentBlockIndex":0,"delta":{"text":"Hello"}}`),
+	}))
+	require.NoError(t, e.Encode(buf, eventstream.Message{
+		Headers: eventstream.Headers{
+			{Name: ":exception-type", Value: eventstream.StringValue("throttlingException")},
+			{Name: ":message-type", Value: eventstream.StringValue("exception")},
+		},
+		Payload: []byte(`{"message":"Too many tokens, please wait before trying again."}`),
+	}))
+
+	o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true, id: "chatcmpl-123", created: 1735689600, model: "some-model"}
+	_, bm, _, err := o.ResponseBody(nil, bytes.NewReader(buf.Bytes()), false)
+	var streamException *StreamExceptionError
+	require.ErrorAs(t, err, &streamException)
+	require.Equal(t, "throttlingException", streamException.Type)
+	require.Equal(t, "Too many tokens, please wait before trying again.", streamException.Message)
+	require.Equal(t, `data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"Hello","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"type":"error","error":{"type":"throttlingException","code":"429","message":"Too many tokens, please wait before trying again."}}
+
+data: [DONE]
+`, string(bm.GetBody()))
+
+	// The rest of the stream is dropped.
+	_, bm, _, err = o.ResponseBody(nil, bytes.NewReader(buf.Bytes()), true)
+	require.NoError(t, err)
+	require.Empty(t, bm.GetBody())
+
+	t.Run("error message", func(t *testing.T) {
+		require.Equal(t, &StreamExceptionError{Type: "InternalFailure", Message: "boom"}, bedrockStreamException(&eventstream.Message{
+			Headers: eventstream.Headers{
+				{Name: ":error-code", Value: eventstream.StringValue("InternalFailure")},
+				{Name: ":error-message", Value: eventstream.StringValue("boom")},
+				{Name: ":message-type", Value: eventstream.StringValue("error")},
+			},
+		}))
+		require.Nil(t, bedrockStreamException(&eventstream.Message{}))
+	})
+	t.Run("status codes", func(t *testing.T) {
+		for typ, exp := range map[string]string{
+			"validationException":         "400",
+			"modelStreamErrorException":   "424",
+			"throttlingException":         "429",
+			"serviceUnavailableException": "503",
+			"internalServerException":     "500",
+		} {
+			require.Equal(t, exp, *bedrockStreamExceptionToOpenAIError(&StreamExceptionError{Type: typ}).Error.Code, typ)
+		}
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_ResponseIdentity(t *testing.T) {
+	t.Run("non-streaming", func(t *testing.T) {
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "claude-3-5-sonnet"})
+		require.NoError(t, err)
+		before := time.Now().Unix()
+		_, bm, _, err := o.ResponseBody(nil, strings.NewReader(`{"output":{"message":{"content":[{"text":"hi"}],"role":"assistant"}}}`), true)
+		require.NoError(t, err)
+		var resp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+		require.Regexp(t, `^chatcmpl-[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, resp.ID)
+		require.GreaterOrEqual(t, resp.Created, before)
+		require.Equal(t, "claude-3-5-sonnet", resp.Model)
+	})
+	t.Run("streaming", func(t *testing.T) {
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "claude-3-5-sonnet", Stream: true})
+		require.NoError(t, err)
+		eventBytes, err := base64.StdEncoding.DecodeString(base64RealStreamingEvents)
+		require.NoError(t, err)
+		// The chunks of all the response body calls share the same identity.
+		var chunks []openai.ChatCompletionResponseChunk
+		for i, part := range [][]byte{eventBytes[:len(eventBytes)/2], eventBytes[len(eventBytes)/2:]} {
+			_, bm, _, err := o.ResponseBody(nil, bytes.NewReader(part), i == 1)
+			require.NoError(t, err)
+			for _, line := range strings.Split(string(bm.GetBody()), "\n") {
+				data, ok := strings.CutPrefix(line, "data: ")
+				if !ok || data == "[DONE]" {
+					continue
+				}
+				var chunk openai.ChatCompletionResponseChunk
+				require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+				chunks = append(chunks, chunk)
+			}
+		}
+		require.Greater(t, len(chunks), 2)
+		require.NotEmpty(t, chunks[0].ID)
+		require.NotZero(t, chunks[0].Created)
+		for _, chunk := range chunks {
+			require.Equal(t, chunks[0].ID, chunk.ID)
+			require.Equal(t, chunks[0].Created, chunk.Created)
+			require.Equal(t, "claude-3-5-sonnet", chunk.Model)
+		}
+	})
+}
+
+func BenchmarkOpenAIToAWSBedrockTranslatorExtractAmazonEventStreamEvents(b *testing.B) {
+	eventBytes, err := base64.StdEncoding.DecodeString(base64RealStreamingEvents)
+	require.NoError(b, err)
+	o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		o.events = o.events[:0]
+		o.bufferedBody = append(o.bufferedBody[:0], eventBytes...)
+		o.extractAmazonEventStreamEvents()
+	}
+}
+
+func BenchmarkBedrockStreamingTranslate(b *testing.B) {
+	eventBytes, err := base64.StdEncoding.DecodeString(base64RealStreamingEvents)
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true}
+		_, bm, _, err := o.ResponseBody(nil, bytes.NewReader(eventBytes), true)
+		if err != nil || len(bm.GetBody()) == 0 {
+			b.Fatalf("unexpected result: %v", err)
+		}
+	}
+}
+
 func TestOpenAIToAWSBedrockTranslator_convertEvent(t *testing.T) {
 	ptrOf := func(s string) *string { return &s }
 	for _, tc := range []struct {
@@ -1188,7 +2056,8 @@ func TestOpenAIToAWSBedrockTranslator_convertEvent(t *testing.T) {
 				},
 			},
 			out: &openai.ChatCompletionResponseChunk{
-				Object: "chat.completion.chunk",
+				Object:  "chat.completion.chunk",
+				Choices: []openai.ChatCompletionResponseChunkChoice{},
 				Usage: &openai.ChatCompletionResponseUsage{
 					TotalTokens:      30,
 					PromptTokens:     10,
@@ -1229,9 +2098,25 @@ func TestOpenAIToAWSBedrockTranslator_convertEvent(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "guardrail intervened",
+			in: awsbedrock.ConverseStreamEvent{
+				StopReason: ptr.To(awsbedrock.StopReasonGuardrailIntervened),
+			},
+			out: &openai.ChatCompletionResponseChunk{
+				Object: "chat.completion.chunk",
+				Choices: []openai.ChatCompletionResponseChunkChoice{
+					{
+						Delta:        &openai.ChatCompletionResponseChunkChoiceDelta{},
+						FinishReason: openai.ChatCompletionChoicesFinishReasonContentFilter,
+					},
+				},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+			// The role has been sent except for the role event, so that the event is converted as in the middle of the stream.
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{roleSent: tc.in.Role == nil}
 			chunk, ok := o.convertEvent(&tc.in)
 			if tc.out == nil {
 				require.False(t, ok)
@@ -1241,3 +2126,279 @@ func TestOpenAIToAWSBedrockTranslator_convertEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestOpenAIToAWSBedrockTranslator_convertEvent_RoleAndFinishReasonOnce(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		events []awsbedrock.ConverseStreamEvent
+		exp    []string
+	}{
+		{
+			name: "duplicated messageStart and messageStop",
+			events: []awsbedrock.ConverseStreamEvent{
+				{Role: ptr.To("assistant")},
+				{Role: ptr.To("assistant")},
+				{Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To("Hi")}},
+				{StopReason: ptr.To(awsbedrock.StopReasonEndTurn)},
+				{StopReason: ptr.To(awsbedrock.StopReasonEndTurn)},
+				{Usage: &awsbedrock.TokenUsage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}},
+			},
+			exp: []string{
+				`{"choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}`,
+				`{"choices":[{"delta":{"content":"Hi"}}],"object":"chat.completion.chunk"}`,
+				`{"choices":[{"delta":{},"finish_reason":"stop"}],"object":"chat.completion.chunk"}`,
+				`{"choices":[],"object":"chat.completion.chunk","usage":{"completion_tokens":2,"prompt_tokens":1,"total_tokens":3}}`,
+			},
+		},
+		{
+			name: "without messageStart",
+			events: []awsbedrock.ConverseStreamEvent{
+				{Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To("Hi")}},
+				{Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{Text: ptr.To(" there")}},
+				{StopReason: ptr.To(awsbedrock.StopReasonEndTurn)},
+			},
+			exp: []string{
+				`{"choices":[{"delta":{"content":"Hi","role":"assistant"}}],"object":"chat.completion.chunk"}`,
+				`{"choices":[{"delta":{"content":" there"}}],"object":"chat.completion.chunk"}`,
+				`{"choices":[{"delta":{},"finish_reason":"stop"}],"object":"chat.completion.chunk"}`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true}
+			var chunks []string
+			for i := range tc.events {
+				chunk, ok := o.convertEvent(&tc.events[i])
+				if !ok {
+					continue
+				}
+				buf, err := json.Marshal(chunk)
+				require.NoError(t, err)
+				chunks = append(chunks, string(buf))
+			}
+			require.Equal(t, tc.exp, chunks)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslator_convertEvent_MultipleToolCalls(t *testing.T) {
+	events := func(n int) []awsbedrock.ConverseStreamEvent {
+		var ret []awsbedrock.ConverseStreamEvent
+		for i := 0; i < n; i++ {
+			ret = append(ret,
+				awsbedrock.ConverseStreamEvent{ContentBlockIndex: i, Start: &awsbedrock.ContentBlockStart{
+					ToolUse: &awsbedrock.ToolUseBlockStart{Name: fmt.Sprintf("tool_%d", i), ToolUseID: fmt.Sprintf("call_%d", i)},
+				}},
+				awsbedrock.ConverseStreamEvent{ContentBlockIndex: i, Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{
+					ToolUse: &awsbedrock.ToolUseBlockDelta{Input: fmt.Sprintf(`{"x": %d}`, i)},
+				}},
+			)
+		}
+		return ret
+	}
+	for _, tc := range []struct {
+		name           string
+		n              int
+		singleToolCall bool
+		expIndexes     []int64
+	}{
+		{name: "two tool calls", n: 2, expIndexes: []int64{0, 0, 1, 1}},
+		{name: "three tool calls", n: 3, expIndexes: []int64{0, 0, 1, 1, 2, 2}},
+		{name: "three tool calls without parallel tool calls", n: 3, singleToolCall: true, expIndexes: []int64{0, 0}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true, singleToolCall: tc.singleToolCall}
+			var indexes []int64
+			for _, event := range events(tc.n) {
+				chunk, ok := o.convertEvent(&event)
+				if !ok {
+					continue
+				}
+				require.Len(t, chunk.Choices, 1)
+				require.Len(t, chunk.Choices[0].Delta.ToolCalls, 1)
+				toolCall := chunk.Choices[0].Delta.ToolCalls[0]
+				require.NotNil(t, toolCall.Index)
+				if event.Start != nil {
+					require.Equal(t, event.Start.ToolUse.ToolUseID, toolCall.ID)
+				} else {
+					require.Equal(t, event.Delta.ToolUse.Input, toolCall.Function.Arguments)
+				}
+				indexes = append(indexes, *toolCall.Index)
+			}
+			require.Equal(t, tc.expIndexes, indexes)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_Reasoning(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model: "us.anthropic.claude-3-7-sonnet-20250219-v1:0",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				{
+					Value: openai.ChatCompletionAssistantMessageParam{
+						Content:            openai.ChatCompletionAssistantMessageParamContent{Text: ptr.To("Let me check.")},
+						ReasoningContent:   ptr.To("The user asks for the weather."),
+						ReasoningSignature: ptr.To("EqoBCkgIARABGAIiQ"),
+					},
+					Type: openai.ChatMessageRoleAssistant,
+				},
+			},
+		})
+		require.NoError(t, err)
+		var awsReq awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+		require.Len(t, awsReq.Messages, 1)
+		require.Equal(t, []*awsbedrock.ContentBlock{
+			{ReasoningContent: &awsbedrock.ReasoningContentBlock{ReasoningText: &awsbedrock.ReasoningTextBlock{
+				Text: "The user asks for the weather.", Signature: ptr.To("EqoBCkgIARABGAIiQ"),
+			}}},
+			{Text: ptr.To("Let me check.")},
+		}, awsReq.Messages[0].Content)
+	})
+
+	t.Run("response", func(t *testing.T) {
+		// Recorded from us.anthropic.claude-3-7-sonnet-20250219-v1:0 with the thinking enabled.
+		const body = `{"metrics":{"latencyMs":2381},"output":{"message":{"content":[` +
+			`{"reasoningContent":{"reasoningText":{"signature":"EqoBCkgIARABGAIiQ","text":"The user wants 2+2. That is 4."}}},` +
+			`{"text":"2 + 2 = 4"}],"role":"assistant"}},"stopReason":"end_turn",` +
+			`"usage":{"inputTokens":45,"outputTokens":52,"totalTokens":97}}`
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		_, bm, _, err := o.ResponseBody(nil, strings.NewReader(body), true)
+		require.NoError(t, err)
+		var resp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &resp))
+		require.Len(t, resp.Choices, 1)
+		require.Equal(t, openai.ChatCompletionResponseChoiceMessage{
+			Role:               "assistant",
+			Content:            ptr.To("2 + 2 = 4"),
+			ReasoningContent:   ptr.To("The user wants 2+2. That is 4."),
+			ReasoningSignature: ptr.To("EqoBCkgIARABGAIiQ"),
+		}, resp.Choices[0].Message)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		// Recorded from us.anthropic.claude-3-7-sonnet-20250219-v1:0 with the thinking enabled.
+		payloads := []string{
+			`{"p":"abcd","role":"assistant"}`,
+			`{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":"The user wants 2+2."}},"p":"abcd"}`,
+			`{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":" That is 4."}},"p":"abcd"}`,
+			`{"contentBlockIndex":0,"delta":{"reasoningContent":{"signature":"EqoBCkgIARABGAIiQ"}},"p":"abcd"}`,
+			`{"contentBlockIndex":0,"p":"abcd"}`,
+			`{"contentBlockIndex":1,"delta":{"text":"2 + 2 = 4"},"p":"abcd"}`,
+			`{"contentBlockIndex":1,"p":"abcd"}`,
+			`{"p":"abcd","stopReason":"end_turn"}`,
+		}
+		buf := bytes.NewBuffer(nil)
+		e := eventstream.NewEncoder()
+		for _, payload := range payloads {
+			require.NoError(t, e.Encode(buf, eventstream.Message{
+				Headers: eventstream.Headers{{Name: "event-type", Value: eventstream.StringValue("content")}},
+				Payload: []byte(payload),
+			}))
+		}
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{stream: true, id: "chatcmpl-123", created: 1735689600, model: "some-model"}
+		_, bm, _, err := o.ResponseBody(nil, buf, true)
+		require.NoError(t, err)
+		require.Equal(t,
+			`data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"reasoning_content":"The user wants 2+2."}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"reasoning_content":" That is 4."}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"reasoning_signature":"EqoBCkgIARABGAIiQ"}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{"content":"2 + 2 = 4"}}],"object":"chat.completion.chunk"}
+
+data: {"id":"chatcmpl-123","created":1735689600,"model":"some-model","choices":[{"delta":{},"finish_reason":"stop"}],"object":"chat.completion.chunk"}
+
+data: [DONE]
+`, string(bm.GetBody()))
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		_, ok := o.convertEvent(&awsbedrock.ConverseStreamEvent{
+			Delta: &awsbedrock.ConverseStreamEventContentBlockDelta{
+				ReasoningContent: &awsbedrock.ReasoningContentBlockDelta{RedactedContent: []byte("encrypted")},
+			},
+		})
+		require.False(t, ok)
+	})
+}
+
+func TestOpenAIToAWSBedrockTranslatorV1ChatCompletion_PromptCaching(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(`{
+  "model": "claude",
+  "messages": [
+    {"role": "system", "content": [{"type": "text", "text": "long instructions", "cache_control": {"type": "ephemeral"}}]},
+    {"role": "user", "content": [
+      {"type": "text", "text": "long document", "cache_control": {"type": "ephemeral"}},
+      {"type": "text", "text": "question"}
+    ]},
+    {"role": "assistant", "content": {"type": "text", "text": "answer", "cache_control": {"type": "ephemeral"}}},
+    {"role": "user", "content": "follow-up"}
+  ]
+}`), &req))
+		o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+		_, bm, _, err := o.RequestBody(&req)
+		require.NoError(t, err)
+		var awsReq awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &awsReq))
+		cachePoint := &awsbedrock.CachePointBlock{Type: awsbedrock.CachePointTypeDefault}
+		require.Equal(t, []*awsbedrock.SystemContentBlock{
+			{Text: "long instructions"},
+			{CachePoint: cachePoint},
+		}, awsReq.System)
+		require.Equal(t, []*awsbedrock.Message{
+			{Role: "user", Content: []*awsbedrock.ContentBlock{
+				{Text: ptr.To("long document")},
+				{CachePoint: cachePoint},
+				{Text: ptr.To("question")},
+			}},
+			{Role: "assistant", Content: []*awsbedrock.ContentBlock{
+				{Text: ptr.To("answer")},
+				{CachePoint: cachePoint},
+			}},
+			{Role: "user", Content: []*awsbedrock.ContentBlock{{Text: ptr.To("follow-up")}}},
+		}, awsReq.Messages)
+		require.Contains(t, string(bm.GetBody()), `{"cachePoint":{"type":"default"}}`)
+	})
+	t.Run("response usage", func(t *testing.T) {
+		body, err := json.Marshal(awsbedrock.ConverseResponse{
+			Usage: &awsbedrock.TokenUsage{
+				InputTokens: 10, OutputTokens: 20, TotalTokens: 30,
+				CacheReadInputTokens: 100, CacheWriteInputTokens: 5,
+			},
+			Output: &awsbedrock.ConverseOutput{Message: awsbedrock.Message{Role: "assistant", Content: []*awsbedrock.ContentBlock{{Text: ptr.To("ok")}}}},
+		})
+		require.NoError(t, err)
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		_, bm, usage, err := o.ResponseBody(nil, bytes.NewReader(body), true)
+		require.NoError(t, err)
+		require.Equal(t, LLMTokenUsage{
+			InputTokens: 10, OutputTokens: 20, TotalTokens: 30,
+			CacheReadInputTokens: 100, CacheWriteInputTokens: 5,
+		}, usage)
+		var openAIResp openai.ChatCompletionResponse
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIResp))
+		require.Equal(t, openai.ChatCompletionResponseUsage{
+			PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30,
+			CacheReadInputTokens: 100, CacheWriteInputTokens: 5,
+		}, openAIResp.Usage)
+	})
+	t.Run("streaming usage", func(t *testing.T) {
+		o := &openAIToAWSBedrockTranslatorV1ChatCompletion{}
+		chunk, ok := o.convertEvent(&awsbedrock.ConverseStreamEvent{Usage: &awsbedrock.TokenUsage{
+			InputTokens: 10, OutputTokens: 20, TotalTokens: 30, CacheReadInputTokens: 100,
+		}})
+		require.True(t, ok)
+		require.Equal(t, &openai.ChatCompletionResponseUsage{
+			PromptTokens: 10, CompletionTokens: 20, TotalTokens: 30, CacheReadInputTokens: 100,
+		}, chunk.Usage)
+	})
+}
@@ -0,0 +1,552 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+var cohereBackendError = "CohereBackendError"
+
+// NewChatCompletionOpenAIToCohereTranslator implements [Factory] for OpenAI to Cohere translation.
+func NewChatCompletionOpenAIToCohereTranslator() Translator {
+	return &openAIToCohereTranslatorV1ChatCompletion{}
+}
+
+// openAIToCohereTranslatorV1ChatCompletion implements [Translator] for /v1/chat/completions.
+type openAIToCohereTranslatorV1ChatCompletion struct {
+	stream bool
+	// buffered is the incomplete line of the newline-delimited JSON events in the streaming response.
+	buffered []byte
+	// toolCalls is the number of tool calls streamed so far, and is used to assign the index of each tool call in the chunks.
+	toolCalls int64
+}
+
+// RequestBody implements [Translator.RequestBody].
+func (o *openAIToCohereTranslatorV1ChatCompletion) RequestBody(body RequestBody) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, override *extprocv3http.ProcessingMode, err error,
+) {
+	openAIReq, ok := body.(*openai.ChatCompletionRequest)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected body type: %T", body)
+	}
+
+	if openAIReq.Stream {
+		o.stream = true
+		// We need to change the processing mode for streaming requests.
+		override = &extprocv3http.ProcessingMode{
+			ResponseHeaderMode: extprocv3http.ProcessingMode_SEND,
+			ResponseBodyMode:   extprocv3http.ProcessingMode_STREAMED,
+		}
+	}
+
+	headerMutation = &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: ":path", RawValue: []byte("/v1/chat")}},
+		},
+	}
+
+	cohereReq := cohere.ChatRequest{
+		Model:            openAIReq.Model,
+		Stream:           openAIReq.Stream,
+		MaxTokens:        openAIReq.MaxOutputTokens(),
+		Temperature:      openAIReq.Temperature,
+		P:                openAIReq.TopP,
+		Seed:             openAIReq.Seed,
+		FrequencyPenalty: openAIReq.FrequencyPenalty,
+		PresencePenalty:  openAIReq.PresencePenalty,
+	}
+	cohereReq.StopSequences = openAIReq.Stop.Values()
+	if err = o.openAIMessagesToCohereChat(openAIReq, &cohereReq); err != nil {
+		return nil, nil, nil, err
+	}
+	if err = o.openAIToolsToCohereTools(openAIReq, &cohereReq); err != nil {
+		return nil, nil, nil, err
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(cohereReq); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, override, nil
+}
+
+// openAIMessagesToCohereChat converts openai ChatCompletion messages to the Cohere chat request.
+// The system and developer messages are joined into the preamble, the last user message becomes the message,
+// and the trailing tool messages become the tool results. All the other messages become the chat history.
+func (o *openAIToCohereTranslatorV1ChatCompletion) openAIMessagesToCohereChat(openAIReq *openai.ChatCompletionRequest,
+	cohereReq *cohere.ChatRequest,
+) error {
+	// toolCalls is used to find the tool call that a tool message is the result of.
+	toolCalls := make(map[string]cohere.ToolCall)
+	var preamble []string
+	// last is the index of the last message to be sent as the message or tool results, not as the chat history.
+	last := -1
+	for i := len(openAIReq.Messages) - 1; i >= 0; i-- {
+		msg := &openAIReq.Messages[i]
+		if msg.Type == openai.ChatMessageRoleUser || msg.Type == openai.ChatMessageRoleTool {
+			last = i
+		}
+		if msg.Type != openai.ChatMessageRoleTool {
+			break
+		}
+	}
+
+	for i := range openAIReq.Messages {
+		msg := &openAIReq.Messages[i]
+		switch msg.Type {
+		case openai.ChatMessageRoleSystem:
+			systemMessage := msg.Value.(openai.ChatCompletionSystemMessageParam)
+			text, err := openAITextContent(systemMessage.Content.Value)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for system message")
+			}
+			preamble = append(preamble, text)
+		case openai.ChatMessageRoleDeveloper:
+			developerMessage := msg.Value.(openai.ChatCompletionDeveloperMessageParam)
+			text, err := openAITextContent(developerMessage.Content.Value)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for developer message")
+			}
+			preamble = append(preamble, text)
+		case openai.ChatMessageRoleUser:
+			userMessage := msg.Value.(openai.ChatCompletionUserMessageParam)
+			text, err := o.openAIUserContentToCohereMessage(&userMessage)
+			if err != nil {
+				return err
+			}
+			if i == last {
+				cohereReq.Message = text
+			} else {
+				cohereReq.ChatHistory = append(cohereReq.ChatHistory, cohere.ChatMessage{Role: cohere.ChatRoleUser, Message: text})
+			}
+		case openai.ChatMessageRoleAssistant:
+			assistantMessage := msg.Value.(openai.ChatCompletionAssistantMessageParam)
+			chatMessage := cohere.ChatMessage{Role: cohere.ChatRoleChatbot}
+			if assistantMessage.Content.Text != nil {
+				chatMessage.Message = *assistantMessage.Content.Text
+			} else if assistantMessage.Content.Refusal != nil {
+				chatMessage.Message = *assistantMessage.Content.Refusal
+			}
+			for j := range assistantMessage.ToolCalls {
+				toolCall := &assistantMessage.ToolCalls[j]
+				input, err := unmarshalToolCallArguments(toolCall.Function.Arguments)
+				if err != nil {
+					return err
+				}
+				call := cohere.ToolCall{Name: toolCall.Function.Name, Parameters: input}
+				toolCalls[toolCall.ID] = call
+				chatMessage.ToolCalls = append(chatMessage.ToolCalls, call)
+			}
+			cohereReq.ChatHistory = append(cohereReq.ChatHistory, chatMessage)
+		case openai.ChatMessageRoleTool:
+			toolMessage := msg.Value.(openai.ChatCompletionToolMessageParam)
+			call, ok := toolCalls[toolMessage.ToolCallID]
+			if !ok {
+				return fmt.Errorf("tool call %q is not found in the previous assistant messages", toolMessage.ToolCallID)
+			}
+			text, err := openAITextContent(toolMessage.Content.Value)
+			if err != nil {
+				return fmt.Errorf("unexpected content type for tool message")
+			}
+			result := cohere.ToolResult{Call: call, Outputs: []map[string]any{{"result": text}}}
+			if last >= 0 && i >= last {
+				cohereReq.ToolResults = append(cohereReq.ToolResults, result)
+			} else if n := len(cohereReq.ChatHistory); n > 0 && cohereReq.ChatHistory[n-1].Role == cohere.ChatRoleTool {
+				// Consecutive tool messages are the results of the tool calls of the same turn.
+				cohereReq.ChatHistory[n-1].ToolResults = append(cohereReq.ChatHistory[n-1].ToolResults, result)
+			} else {
+				cohereReq.ChatHistory = append(cohereReq.ChatHistory, cohere.ChatMessage{
+					Role: cohere.ChatRoleTool, ToolResults: []cohere.ToolResult{result},
+				})
+			}
+		default:
+			return fmt.Errorf("unexpected role: %s", msg.Type)
+		}
+	}
+	if last < 0 {
+		return fmt.Errorf("the last message must be either a user or tool message")
+	}
+	cohereReq.Preamble = strings.Join(preamble, "\n")
+	return nil
+}
+
+// openAIUserContentToCohereMessage converts the content of openai user role message to the Cohere message.
+// Cohere chat only supports text, so the text parts are joined and the other parts are rejected.
+func (o *openAIToCohereTranslatorV1ChatCompletion) openAIUserContentToCohereMessage(
+	openAiMessage *openai.ChatCompletionUserMessageParam,
+) (string, error) {
+	if v, ok := openAiMessage.Content.Value.(string); ok {
+		return v, nil
+	} else if contents, ok := openAiMessage.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam); ok {
+		texts := make([]string, 0, len(contents))
+		for i := range contents {
+			contentPart := &contents[i]
+			if contentPart.TextContent == nil {
+				return "", fmt.Errorf("unsupported content part in user message: only text is supported")
+			}
+			texts = append(texts, contentPart.TextContent.Text)
+		}
+		return strings.Join(texts, "\n"), nil
+	}
+	return "", fmt.Errorf("unexpected content type")
+}
+
+// openAITextContent returns the text of the content that is either a string or a list of text parts.
+func openAITextContent(content any) (string, error) {
+	if v, ok := content.(string); ok {
+		return v, nil
+	} else if contents, ok := content.([]openai.ChatCompletionContentPartTextParam); ok {
+		texts := make([]string, 0, len(contents))
+		for i := range contents {
+			texts = append(texts, contents[i].Text)
+		}
+		return strings.Join(texts, "\n"), nil
+	}
+	return "", fmt.Errorf("unexpected content type: %T", content)
+}
+
+// openAIToolsToCohereTools converts openai ChatCompletion tools to Cohere tools.
+// The JSON schema of the function parameters is flattened into the Cohere parameter definitions,
+// which only support the top-level properties.
+func (o *openAIToCohereTranslatorV1ChatCompletion) openAIToolsToCohereTools(openAIReq *openai.ChatCompletionRequest,
+	cohereReq *cohere.ChatRequest,
+) error {
+	for i := range openAIReq.Tools {
+		toolDefinition := &openAIReq.Tools[i]
+		if toolDefinition.Function == nil {
+			continue
+		}
+		tool := cohere.Tool{Name: toolDefinition.Function.Name, Description: toolDefinition.Function.Description}
+		if toolDefinition.Function.Parameters != nil {
+			raw, err := json.Marshal(toolDefinition.Function.Parameters)
+			if err != nil {
+				return fmt.Errorf("failed to marshal parameters of tool %s: %w", tool.Name, err)
+			}
+			var schema struct {
+				Properties map[string]struct {
+					Type        string `json:"type"`
+					Description string `json:"description"`
+				} `json:"properties"`
+				Required []string `json:"required"`
+			}
+			if err = json.Unmarshal(raw, &schema); err != nil {
+				return fmt.Errorf("failed to unmarshal parameters of tool %s: %w", tool.Name, err)
+			}
+			if len(schema.Properties) > 0 {
+				tool.ParameterDefinitions = make(map[string]cohere.ToolParameterDefinition, len(schema.Properties))
+			}
+			for name, property := range schema.Properties {
+				tool.ParameterDefinitions[name] = cohere.ToolParameterDefinition{
+					Description: property.Description,
+					Type:        jsonSchemaTypeToCohereType(property.Type),
+				}
+			}
+			for _, name := range schema.Required {
+				if def, ok := tool.ParameterDefinitions[name]; ok {
+					def.Required = true
+					tool.ParameterDefinitions[name] = def
+				}
+			}
+		}
+		cohereReq.Tools = append(cohereReq.Tools, tool)
+	}
+	return nil
+}
+
+// jsonSchemaTypeToCohereType converts the JSON schema type to the Python type name used by the Cohere tools.
+func jsonSchemaTypeToCohereType(t string) string {
+	switch t {
+	case "string":
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "list"
+	case "object":
+		return "dict"
+	default:
+		return t
+	}
+}
+
+// ResponseHeaders implements [Translator.ResponseHeaders].
+func (o *openAIToCohereTranslatorV1ChatCompletion) ResponseHeaders(map[string]string) (
+	headerMutation *extprocv3.HeaderMutation, err error,
+) {
+	if o.stream {
+		// Cohere streams newline-delimited JSON, so we need to change the content-type to text/event-stream.
+		return &extprocv3.HeaderMutation{
+			SetHeaders: []*corev3.HeaderValueOption{
+				{Header: &corev3.HeaderValue{Key: "content-type", Value: "text/event-stream"}},
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+// ResponseError implements [Translator.ResponseError]
+// Translate Cohere errors to OpenAI error type.
+// If the Cohere connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+func (o *openAIToCohereTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	statusCode := respHeaders[statusHeaderName]
+	var message string
+	if isJSONContentType(respHeaders[contentTypeHeaderName]) {
+		var cohereError cohere.Error
+		if err = json.NewDecoder(body).Decode(&cohereError); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal error body: %w", err)
+		}
+		message = cohereError.Message
+	} else {
+		var buf []byte
+		buf, err = io.ReadAll(body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+		}
+		message = string(buf)
+	}
+	openaiError := openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    cohereBackendError,
+			Message: message,
+			Code:    &statusCode,
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	mut.Body, err = json.Marshal(openaiError)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// ResponseBody implements [Translator.ResponseBody].
+func (o *openAIToCohereTranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, o.stream); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
+	if statusStr, ok := respHeaders[statusHeaderName]; ok {
+		var status int
+		if status, err = strconv.Atoi(statusStr); err == nil {
+			if !isGoodStatusCode(status) {
+				headerMutation, bodyMutation, err = o.ResponseError(respHeaders, body)
+				return headerMutation, bodyMutation, LLMTokenUsage{}, err
+			}
+		}
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	if o.stream {
+		var buf []byte
+		buf, err = io.ReadAll(body)
+		if err != nil {
+			return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
+		}
+		o.buffered = append(o.buffered, buf...)
+		for _, event := range o.extractStreamEvents() {
+			if event.Response != nil {
+				tokenUsage = cohereTokenUsage(event.Response.Meta)
+			}
+			for _, chunk := range o.convertEvent(&event) {
+				var chunkBytes []byte
+				chunkBytes, err = json.Marshal(chunk)
+				if err != nil {
+					return nil, nil, tokenUsage, fmt.Errorf("failed to marshal event: %w", err)
+				}
+				mut.Body = append(mut.Body, []byte("data: ")...)
+				mut.Body = append(mut.Body, chunkBytes...)
+				mut.Body = append(mut.Body, []byte("\n\n")...)
+			}
+		}
+		if endOfStream {
+			mut.Body = append(mut.Body, []byte("data: [DONE]\n")...)
+		}
+		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+	}
+
+	var cohereResp cohere.ChatResponse
+	if err = json.NewDecoder(body).Decode(&cohereResp); err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	tokenUsage = cohereTokenUsage(cohereResp.Meta)
+	openAIResp := openai.ChatCompletionResponse{
+		Object: "chat.completion",
+		Usage: openai.ChatCompletionResponseUsage{
+			PromptTokens:     int(tokenUsage.InputTokens),
+			CompletionTokens: int(tokenUsage.OutputTokens),
+			TotalTokens:      int(tokenUsage.TotalTokens),
+		},
+	}
+	// Cohere does not support N(multiple choices) > 0, so there could be only one choice.
+	choice := openai.ChatCompletionResponseChoice{
+		Index:        0,
+		Message:      openai.ChatCompletionResponseChoiceMessage{Role: openai.ChatMessageRoleAssistant},
+		FinishReason: cohereFinishReasonToOpenAIFinishReason(cohereResp.FinishReason, len(cohereResp.ToolCalls) > 0),
+	}
+	if cohereResp.Text != "" {
+		choice.Message.Content = ptr.To(cohereResp.Text)
+	}
+	for i := range cohereResp.ToolCalls {
+		toolCall, err := cohereToolCallToOpenAIToolCall(&cohereResp.ToolCalls[i], int64(i))
+		if err != nil {
+			return nil, nil, tokenUsage, err
+		}
+		toolCall.Index = nil
+		choice.Message.ToolCalls = append(choice.Message.ToolCalls, toolCall)
+	}
+	openAIResp.Choices = append(openAIResp.Choices, choice)
+
+	mut.Body, err = json.Marshal(openAIResp)
+	if err != nil {
+		return nil, nil, tokenUsage, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, tokenUsage, nil
+}
+
+// extractStreamEvents extracts the complete newline-delimited [cohere.StreamEvent] from the buffered body,
+// and keeps the incomplete line in the buffer. Lines that are not valid events are skipped.
+func (o *openAIToCohereTranslatorV1ChatCompletion) extractStreamEvents() []cohere.StreamEvent {
+	var events []cohere.StreamEvent
+	for {
+		i := bytes.IndexByte(o.buffered, '\n')
+		if i < 0 {
+			return events
+		}
+		line := bytes.TrimSpace(o.buffered[:i])
+		o.buffered = o.buffered[i+1:]
+		if len(line) == 0 {
+			continue
+		}
+		var event cohere.StreamEvent
+		if err := json.Unmarshal(line, &event); err == nil {
+			events = append(events, event)
+		}
+	}
+}
+
+// convertEvent converts a [cohere.StreamEvent] to zero or more [openai.ChatCompletionResponseChunk].
+func (o *openAIToCohereTranslatorV1ChatCompletion) convertEvent(event *cohere.StreamEvent) []openai.ChatCompletionResponseChunk {
+	const object = "chat.completion.chunk"
+	newChunk := func(delta *openai.ChatCompletionResponseChunkChoiceDelta) openai.ChatCompletionResponseChunk {
+		return openai.ChatCompletionResponseChunk{
+			Object:  object,
+			Choices: []openai.ChatCompletionResponseChunkChoice{{Delta: delta}},
+		}
+	}
+	switch event.EventType {
+	case cohere.StreamEventTypeStreamStart:
+		return []openai.ChatCompletionResponseChunk{newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{
+			Role: openai.ChatMessageRoleAssistant, Content: ptr.To(""),
+		})}
+	case cohere.StreamEventTypeTextGeneration:
+		return []openai.ChatCompletionResponseChunk{newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{
+			Role: openai.ChatMessageRoleAssistant, Content: ptr.To(event.Text),
+		})}
+	case cohere.StreamEventTypeToolCallsGeneration:
+		delta := &openai.ChatCompletionResponseChunkChoiceDelta{Role: openai.ChatMessageRoleAssistant}
+		for i := range event.ToolCalls {
+			toolCall, err := cohereToolCallToOpenAIToolCall(&event.ToolCalls[i], o.toolCalls)
+			if err != nil {
+				continue
+			}
+			o.toolCalls++
+			delta.ToolCalls = append(delta.ToolCalls, toolCall)
+		}
+		if len(delta.ToolCalls) == 0 {
+			return nil
+		}
+		return []openai.ChatCompletionResponseChunk{newChunk(delta)}
+	case cohere.StreamEventTypeStreamEnd:
+		finish := newChunk(&openai.ChatCompletionResponseChunkChoiceDelta{
+			Role: openai.ChatMessageRoleAssistant, Content: ptr.To(""),
+		})
+		finish.Choices[0].FinishReason = cohereFinishReasonToOpenAIFinishReason(event.FinishReason, o.toolCalls > 0)
+		chunks := []openai.ChatCompletionResponseChunk{finish}
+		if event.Response != nil && event.Response.Meta != nil && event.Response.Meta.BilledUnits != nil {
+			usage := cohereTokenUsage(event.Response.Meta)
+			chunks = append(chunks, openai.ChatCompletionResponseChunk{
+				Object:  object,
+				Choices: []openai.ChatCompletionResponseChunkChoice{},
+				Usage: &openai.ChatCompletionResponseUsage{
+					PromptTokens:     int(usage.InputTokens),
+					CompletionTokens: int(usage.OutputTokens),
+					TotalTokens:      int(usage.TotalTokens),
+				},
+			})
+		}
+		return chunks
+	default:
+		return nil
+	}
+}
+
+// cohereToolCallToOpenAIToolCall converts a [cohere.ToolCall] to the openai tool call at the index.
+// Cohere does not assign an ID to the tool calls, so the ID is derived from the index.
+func cohereToolCallToOpenAIToolCall(toolCall *cohere.ToolCall, index int64) (openai.ChatCompletionMessageToolCallParam, error) {
+	arguments, err := json.Marshal(toolCall.Parameters)
+	if err != nil {
+		return openai.ChatCompletionMessageToolCallParam{}, fmt.Errorf("failed to marshal tool call parameters: %w", err)
+	}
+	return openai.ChatCompletionMessageToolCallParam{
+		Index: ptr.To(index),
+		ID:    fmt.Sprintf("call_%d", index),
+		Function: openai.ChatCompletionMessageToolCallFunctionParam{
+			Name:      toolCall.Name,
+			Arguments: string(arguments),
+		},
+		Type: openai.ChatCompletionMessageToolCallTypeFunction,
+	}, nil
+}
+
+// cohereFinishReasonToOpenAIFinishReason converts the Cohere finish reason to the openai one.
+func cohereFinishReasonToOpenAIFinishReason(reason string, toolCalls bool) openai.ChatCompletionChoicesFinishReason {
+	switch {
+	case toolCalls:
+		return openai.ChatCompletionChoicesFinishReasonToolCalls
+	case reason == cohere.FinishReasonMaxTokens:
+		return openai.ChatCompletionChoicesFinishReasonLength
+	case reason == cohere.FinishReasonErrorToxic:
+		return openai.ChatCompletionChoicesFinishReasonContentFilter
+	default:
+		return openai.ChatCompletionChoicesFinishReasonStop
+	}
+}
+
+// cohereTokenUsage converts the billed units of the Cohere response to [LLMTokenUsage].
+func cohereTokenUsage(meta *cohere.ResponseMeta) LLMTokenUsage {
+	if meta == nil || meta.BilledUnits == nil {
+		return LLMTokenUsage{}
+	}
+	in, out := uint32(meta.BilledUnits.InputTokens), uint32(meta.BilledUnits.OutputTokens)
+	return LLMTokenUsage{InputTokens: in, OutputTokens: out, TotalTokens: in + out}
+}
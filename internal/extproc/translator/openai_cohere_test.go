@@ -0,0 +1,418 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/cohere"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToCohereTranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	t.Run("invalid body", func(t *testing.T) {
+		o := &openAIToCohereTranslatorV1ChatCompletion{}
+		_, _, _, err := o.RequestBody(&extprocv3.HttpBody{Body: []byte("invalid")})
+		require.Error(t, err)
+	})
+	userMessage := func(content any) openai.ChatCompletionMessageParamUnion {
+		return openai.ChatCompletionMessageParamUnion{
+			Value: openai.ChatCompletionUserMessageParam{Content: openai.StringOrUserRoleContentUnion{Value: content}},
+			Type:  openai.ChatMessageRoleUser,
+		}
+	}
+	assistantToolCall := openai.ChatCompletionMessageParamUnion{
+		Value: openai.ChatCompletionAssistantMessageParam{
+			Content: openai.ChatCompletionAssistantMessageParamContent{Text: ptr.To("checking")},
+			ToolCalls: []openai.ChatCompletionMessageToolCallParam{
+				{
+					ID:       "call_0",
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "get_weather", Arguments: `{"location":"Queens"}`},
+					Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+				},
+			},
+		},
+		Type: openai.ChatMessageRoleAssistant,
+	}
+	toolMessage := openai.ChatCompletionMessageParamUnion{
+		Value: openai.ChatCompletionToolMessageParam{Content: openai.StringOrArray{Value: "70F"}, ToolCallID: "call_0"},
+		Type:  openai.ChatMessageRoleTool,
+	}
+	weatherCall := cohere.ToolCall{Name: "get_weather", Parameters: map[string]any{"location": "Queens"}}
+
+	tests := []struct {
+		name   string
+		input  openai.ChatCompletionRequest
+		output cohere.ChatRequest
+		expErr string
+	}{
+		{
+			name: "basic test",
+			input: openai.ChatCompletionRequest{
+				Model:       "command-r-plus",
+				MaxTokens:   ptr.To[int64](100),
+				Temperature: ptr.To(0.5),
+				TopP:        ptr.To(0.9),
+				Stop:        &openai.ChatCompletionStop{Sequences: []string{"END"}},
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					{
+						Value: openai.ChatCompletionSystemMessageParam{Content: openai.StringOrArray{Value: "from-system"}},
+						Type:  openai.ChatMessageRoleSystem,
+					},
+					{
+						Value: openai.ChatCompletionDeveloperMessageParam{Content: openai.StringOrArray{
+							Value: []openai.ChatCompletionContentPartTextParam{{Text: "from-developer"}},
+						}},
+						Type: openai.ChatMessageRoleDeveloper,
+					},
+					userMessage("first"),
+					{
+						Value: openai.ChatCompletionAssistantMessageParam{
+							Content: openai.ChatCompletionAssistantMessageParamContent{Text: ptr.To("answer")},
+						},
+						Type: openai.ChatMessageRoleAssistant,
+					},
+					userMessage([]openai.ChatCompletionContentPartUserUnionParam{
+						{TextContent: &openai.ChatCompletionContentPartTextParam{Text: "part1"}},
+						{TextContent: &openai.ChatCompletionContentPartTextParam{Text: "part2"}},
+					}),
+				},
+			},
+			output: cohere.ChatRequest{
+				Model:         "command-r-plus",
+				Message:       "part1\npart2",
+				Preamble:      "from-system\nfrom-developer",
+				MaxTokens:     ptr.To[int64](100),
+				Temperature:   ptr.To(0.5),
+				P:             ptr.To(0.9),
+				StopSequences: []string{"END"},
+				ChatHistory: []cohere.ChatMessage{
+					{Role: cohere.ChatRoleUser, Message: "first"},
+					{Role: cohere.ChatRoleChatbot, Message: "answer"},
+				},
+			},
+		},
+		{
+			name: "tools",
+			input: openai.ChatCompletionRequest{
+				Model:  "command-r",
+				Stream: true,
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					userMessage("weather?"),
+					assistantToolCall,
+					toolMessage,
+				},
+				Tools: []openai.Tool{
+					{
+						Type: "function",
+						Function: &openai.FunctionDefinition{
+							Name:        "get_weather",
+							Description: "Get the current weather",
+							Parameters: map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"location": map[string]any{"type": "string", "description": "The city"},
+									"days":     map[string]any{"type": "integer"},
+								},
+								"required": []any{"location"},
+							},
+						},
+					},
+				},
+			},
+			output: cohere.ChatRequest{
+				Model:  "command-r",
+				Stream: true,
+				ChatHistory: []cohere.ChatMessage{
+					{Role: cohere.ChatRoleUser, Message: "weather?"},
+					{Role: cohere.ChatRoleChatbot, Message: "checking", ToolCalls: []cohere.ToolCall{weatherCall}},
+				},
+				ToolResults: []cohere.ToolResult{{Call: weatherCall, Outputs: []map[string]any{{"result": "70F"}}}},
+				Tools: []cohere.Tool{
+					{
+						Name:        "get_weather",
+						Description: "Get the current weather",
+						ParameterDefinitions: map[string]cohere.ToolParameterDefinition{
+							"location": {Description: "The city", Type: "str", Required: true},
+							"days":     {Type: "int"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "tool results in history",
+			input: openai.ChatCompletionRequest{
+				Model: "command-r",
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					assistantToolCall,
+					toolMessage,
+					userMessage("thanks"),
+				},
+			},
+			output: cohere.ChatRequest{
+				Model:   "command-r",
+				Message: "thanks",
+				ChatHistory: []cohere.ChatMessage{
+					{Role: cohere.ChatRoleChatbot, Message: "checking", ToolCalls: []cohere.ToolCall{weatherCall}},
+					{Role: cohere.ChatRoleTool, ToolResults: []cohere.ToolResult{{Call: weatherCall, Outputs: []map[string]any{{"result": "70F"}}}}},
+				},
+			},
+		},
+		{
+			name: "last message from assistant",
+			input: openai.ChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessageParamUnion{userMessage("hi"), assistantToolCall},
+			},
+			expErr: "the last message must be either a user or tool message",
+		},
+		{
+			name: "unknown tool call",
+			input: openai.ChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessageParamUnion{toolMessage},
+			},
+			expErr: `tool call "call_0" is not found in the previous assistant messages`,
+		},
+		{
+			name: "image",
+			input: openai.ChatCompletionRequest{
+				Messages: []openai.ChatCompletionMessageParamUnion{
+					userMessage([]openai.ChatCompletionContentPartUserUnionParam{
+						{ImageContent: &openai.ChatCompletionContentPartImageParam{}},
+					}),
+				},
+			},
+			expErr: "unsupported content part in user message: only text is supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &openAIToCohereTranslatorV1ChatCompletion{}
+			hm, bm, mode, err := o.RequestBody(&tt.input)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.input.Stream {
+				require.True(t, o.stream)
+				require.NotNil(t, mode)
+			} else {
+				require.Nil(t, mode)
+			}
+			require.NotNil(t, hm)
+			require.Len(t, hm.SetHeaders, 2)
+			require.Equal(t, ":path", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, "/v1/chat", string(hm.SetHeaders[0].Header.RawValue))
+			require.Equal(t, "content-length", hm.SetHeaders[1].Header.Key)
+			newBody := bm.Mutation.(*extprocv3.BodyMutation_Body).Body
+			require.Equal(t, strconv.Itoa(len(newBody)), string(hm.SetHeaders[1].Header.RawValue))
+
+			var cohereReq cohere.ChatRequest
+			require.NoError(t, json.Unmarshal(newBody, &cohereReq))
+			if !cmp.Equal(cohereReq, tt.output) {
+				t.Errorf("ConvertOpenAIToCohere(), diff(got, expected) = %s\n", cmp.Diff(cohereReq, tt.output))
+			}
+		})
+	}
+}
+
+func TestOpenAIToCohereTranslatorV1ChatCompletion_ResponseHeaders(t *testing.T) {
+	t.Run("streaming", func(t *testing.T) {
+		o := &openAIToCohereTranslatorV1ChatCompletion{stream: true}
+		hm, err := o.ResponseHeaders(map[string]string{"content-type": "application/stream+json"})
+		require.NoError(t, err)
+		require.NotNil(t, hm)
+		require.Len(t, hm.SetHeaders, 1)
+		require.Equal(t, "content-type", hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "text/event-stream", hm.SetHeaders[0].Header.Value)
+	})
+	t.Run("non-streaming", func(t *testing.T) {
+		o := &openAIToCohereTranslatorV1ChatCompletion{}
+		hm, err := o.ResponseHeaders(nil)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+	})
+}
+
+func TestOpenAIToCohereTranslatorV1ChatCompletion_ResponseBody(t *testing.T) {
+	t.Run("invalid body", func(t *testing.T) {
+		o := &openAIToCohereTranslatorV1ChatCompletion{}
+		_, _, _, err := o.ResponseBody(nil, bytes.NewBuffer([]byte("invalid")), false)
+		require.Error(t, err)
+	})
+	tests := []struct {
+		name     string
+		input    string
+		output   openai.ChatCompletionResponse
+		expUsage LLMTokenUsage
+	}{
+		{
+			name:  "text",
+			input: `{"text":"Hello!","generation_id":"g1","finish_reason":"COMPLETE","meta":{"billed_units":{"input_tokens":10,"output_tokens":5}}}`,
+			output: openai.ChatCompletionResponse{
+				Object: "chat.completion",
+				Usage:  openai.ChatCompletionResponseUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+				Choices: []openai.ChatCompletionResponseChoice{
+					{
+						Message:      openai.ChatCompletionResponseChoiceMessage{Role: "assistant", Content: ptr.To("Hello!")},
+						FinishReason: openai.ChatCompletionChoicesFinishReasonStop,
+					},
+				},
+			},
+			expUsage: LLMTokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		},
+		{
+			name:  "max tokens",
+			input: `{"text":"Hel","finish_reason":"MAX_TOKENS"}`,
+			output: openai.ChatCompletionResponse{
+				Object: "chat.completion",
+				Choices: []openai.ChatCompletionResponseChoice{
+					{
+						Message:      openai.ChatCompletionResponseChoiceMessage{Role: "assistant", Content: ptr.To("Hel")},
+						FinishReason: openai.ChatCompletionChoicesFinishReasonLength,
+					},
+				},
+			},
+		},
+		{
+			name:  "tool calls",
+			input: `{"text":"","finish_reason":"COMPLETE","tool_calls":[{"name":"get_weather","parameters":{"location":"Queens"}}],"meta":{"billed_units":{"input_tokens":20,"output_tokens":7}}}`,
+			output: openai.ChatCompletionResponse{
+				Object: "chat.completion",
+				Usage:  openai.ChatCompletionResponseUsage{PromptTokens: 20, CompletionTokens: 7, TotalTokens: 27},
+				Choices: []openai.ChatCompletionResponseChoice{
+					{
+						Message: openai.ChatCompletionResponseChoiceMessage{
+							Role: "assistant",
+							ToolCalls: []openai.ChatCompletionMessageToolCallParam{
+								{
+									ID:       "call_0",
+									Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "get_weather", Arguments: `{"location":"Queens"}`},
+									Type:     openai.ChatCompletionMessageToolCallTypeFunction,
+								},
+							},
+						},
+						FinishReason: openai.ChatCompletionChoicesFinishReasonToolCalls,
+					},
+				},
+			},
+			expUsage: LLMTokenUsage{InputTokens: 20, OutputTokens: 7, TotalTokens: 27},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &openAIToCohereTranslatorV1ChatCompletion{}
+			hm, bm, usage, err := o.ResponseBody(nil, strings.NewReader(tt.input), true)
+			require.NoError(t, err)
+			require.Equal(t, tt.expUsage, usage)
+			newBody := bm.Mutation.(*extprocv3.BodyMutation_Body).Body
+			require.Len(t, hm.SetHeaders, 1)
+			require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, strconv.Itoa(len(newBody)), string(hm.SetHeaders[0].Header.RawValue))
+
+			var openAIResp openai.ChatCompletionResponse
+			require.NoError(t, json.Unmarshal(newBody, &openAIResp))
+			if !cmp.Equal(openAIResp, tt.output) {
+				t.Errorf("ConvertCohereToOpenAI(), diff(got, expected) = %s\n", cmp.Diff(openAIResp, tt.output))
+			}
+		})
+	}
+}
+
+func TestOpenAIToCohereTranslatorV1ChatCompletion_Streaming_ResponseBody(t *testing.T) {
+	const events = `{"is_finished":false,"event_type":"stream-start","generation_id":"g1"}
+{"is_finished":false,"event_type":"text-generation","text":"Hello"}
+{"is_finished":false,"event_type":"text-generation","text":" world"}
+{"is_finished":false,"event_type":"tool-calls-generation","tool_calls":[{"name":"get_weather","parameters":{"location":"Queens"}}]}
+{"is_finished":true,"event_type":"stream-end","finish_reason":"COMPLETE","response":{"text":"Hello world","meta":{"billed_units":{"input_tokens":12,"output_tokens":3}}}}
+`
+	o := &openAIToCohereTranslatorV1ChatCompletion{stream: true}
+	var results []string
+	for i := 0; i < len(events); i++ {
+		hm, bm, tokenUsage, err := o.ResponseBody(nil, bytes.NewBuffer([]byte{events[i]}), i == len(events)-1)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		newBody := bm.Mutation.(*extprocv3.BodyMutation_Body).Body
+		if len(newBody) > 0 {
+			results = append(results, string(newBody))
+		}
+		if tokenUsage.OutputTokens > 0 {
+			require.Equal(t, LLMTokenUsage{InputTokens: 12, OutputTokens: 3, TotalTokens: 15}, tokenUsage)
+		}
+	}
+
+	require.Equal(t,
+		`data: {"choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"choices":[{"delta":{"content":"Hello","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"choices":[{"delta":{"content":" world","role":"assistant"}}],"object":"chat.completion.chunk"}
+
+data: {"choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_0","function":{"arguments":"{\"location\":\"Queens\"}","name":"get_weather"},"type":"function"}]}}],"object":"chat.completion.chunk"}
+
+data: {"choices":[{"delta":{"content":"","role":"assistant"},"finish_reason":"tool_calls"}],"object":"chat.completion.chunk"}
+
+data: {"choices":[],"object":"chat.completion.chunk","usage":{"completion_tokens":3,"prompt_tokens":12,"total_tokens":15}}
+
+data: [DONE]
+`, strings.Join(results, ""))
+}
+
+func TestOpenAIToCohereTranslator_ResponseError(t *testing.T) {
+	tests := []struct {
+		name            string
+		responseHeaders map[string]string
+		input           io.Reader
+		output          openai.Error
+	}{
+		{
+			name:            "test unhealthy upstream",
+			responseHeaders: map[string]string{":status": "503", "content-type": "text/plain"},
+			input:           bytes.NewBuffer([]byte("service not available")),
+			output: openai.Error{
+				Type:  "error",
+				Error: openai.ErrorType{Type: cohereBackendError, Code: ptr.To("503"), Message: "service not available"},
+			},
+		},
+		{
+			name:            "test Cohere rate limit error response",
+			responseHeaders: map[string]string{":status": "429", "content-type": "application/json; charset=utf-8"},
+			input:           bytes.NewBuffer([]byte(`{"message": "too many requests"}`)),
+			output: openai.Error{
+				Type:  "error",
+				Error: openai.ErrorType{Type: cohereBackendError, Code: ptr.To("429"), Message: "too many requests"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &openAIToCohereTranslatorV1ChatCompletion{}
+			hm, bm, _, err := o.ResponseBody(tt.responseHeaders, tt.input, true)
+			require.NoError(t, err)
+			newBody := bm.Mutation.(*extprocv3.BodyMutation_Body).Body
+			require.Len(t, hm.SetHeaders, 1)
+			require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+			require.Equal(t, strconv.Itoa(len(newBody)), string(hm.SetHeaders[0].Header.RawValue))
+
+			var openAIError openai.Error
+			require.NoError(t, json.Unmarshal(newBody, &openAIError))
+			if !cmp.Equal(openAIError, tt.output) {
+				t.Errorf("ConvertCohereErrorResp(), diff(got, expected) = %s\n", cmp.Diff(openAIError, tt.output))
+			}
+		})
+	}
+}
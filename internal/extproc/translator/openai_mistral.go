@@ -0,0 +1,218 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/mistral"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+var mistralBackendError = "MistralBackendError"
+
+// NewChatCompletionOpenAIToMistralTranslator implements [Factory] for OpenAI to Mistral translation.
+// The unsupportedFieldPolicy specifies how the fields that Mistral does not support, such as "user", are handled.
+func NewChatCompletionOpenAIToMistralTranslator(unsupportedFieldPolicy UnsupportedFieldPolicy) Translator {
+	return &openAIToMistralTranslatorV1ChatCompletion{unsupportedFieldPolicy: unsupportedFieldPolicy}
+}
+
+// openAIToMistralTranslatorV1ChatCompletion implements [Translator] for /v1/chat/completions.
+//
+// The Mistral API is compatible with OpenAI except for a few fields and the error body, so the successful
+// responses are handled by the embedded OpenAI passthrough translator. Mistral always sends the usage in the last
+// chunk of the streaming response, which the passthrough extracts in the same way as the OpenAI one.
+type openAIToMistralTranslatorV1ChatCompletion struct {
+	openAIToOpenAITranslatorV1ChatCompletion
+	// unsupportedFieldPolicy specifies how the request fields not supported by Mistral are handled.
+	unsupportedFieldPolicy UnsupportedFieldPolicy
+	// droppedFields is the unsupported fields dropped from the request under [UnsupportedFieldPolicyWarn].
+	droppedFields []string
+	// rawRequestBody is the original request body set by [RawRequestBodyTranslator.SetRawRequestBody].
+	rawRequestBody []byte
+}
+
+// mistralUnsupportedFieldNames is the names of the OpenAI request fields that Mistral does not support.
+var mistralUnsupportedFieldNames = []string{"logit_bias", "logprobs", "top_logprobs", "user", "metadata"}
+
+// SetRawRequestBody implements [RawRequestBodyTranslator.SetRawRequestBody].
+func (o *openAIToMistralTranslatorV1ChatCompletion) SetRawRequestBody(raw []byte) {
+	o.rawRequestBody = raw
+}
+
+// RequestBody implements [Translator.RequestBody].
+//
+// The body is forwarded untouched unless it needs to be normalized for Mistral, in which case only the fields to be
+// normalized are patched in the original body so that the Mistral specific fields, such as "safe_prompt", are kept.
+// The parsed request is encoded instead when the original body is not set.
+func (o *openAIToMistralTranslatorV1ChatCompletion) RequestBody(body RequestBody) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, override *extprocv3http.ProcessingMode, err error,
+) {
+	openAIReq, ok := body.(*openai.ChatCompletionRequest)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected body type: %T", body)
+	}
+
+	unsupported := mistralUnsupportedFields(openAIReq)
+	if len(unsupported) > 0 {
+		switch o.unsupportedFieldPolicy {
+		case UnsupportedFieldPolicyReject:
+			return nil, nil, nil, &UnsupportedFieldsError{Fields: unsupported}
+		case UnsupportedFieldPolicyWarn:
+			o.droppedFields = unsupported
+		}
+	}
+
+	if _, _, override, err = o.openAIToOpenAITranslatorV1ChatCompletion.RequestBody(openAIReq); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(unsupported) == 0 && openAIReq.Seed == nil && openAIReq.StreamOptions == nil && openAIReq.ToolChoice != "required" &&
+		openAIReq.MaxCompletionTokens == nil {
+		return nil, nil, override, nil
+	}
+
+	raw := o.rawRequestBody
+	if raw == nil {
+		if raw, err = json.Marshal(openAIReq); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+	}
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(raw, &fields); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to unmarshal body: %w", err)
+	}
+	delete(fields, "seed")
+	if openAIReq.Seed != nil {
+		fields["random_seed"] = json.RawMessage(strconv.Itoa(*openAIReq.Seed))
+	}
+	// Mistral always sends the usage in the last chunk of the streaming response, and does not accept the option.
+	delete(fields, "stream_options")
+	if openAIReq.ToolChoice == "required" {
+		fields["tool_choice"] = json.RawMessage(strconv.Quote(mistral.ToolChoiceAny))
+	}
+	// Mistral only has max_tokens.
+	delete(fields, "max_completion_tokens")
+	if maxTokens := openAIReq.MaxOutputTokens(); maxTokens != nil {
+		fields["max_tokens"] = json.RawMessage(strconv.FormatInt(*maxTokens, 10))
+	}
+	for _, name := range mistralUnsupportedFieldNames {
+		delete(fields, name)
+	}
+
+	mut := &extprocv3.BodyMutation_Body{}
+	if mut.Body, err = json.Marshal(fields); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, override, nil
+}
+
+// mistralUnsupportedFields returns the names of the fields set in the request that Mistral does not support.
+func mistralUnsupportedFields(req *openai.ChatCompletionRequest) (fields []string) {
+	if len(req.LogitBias) > 0 {
+		fields = append(fields, "logit_bias")
+	}
+	if req.LogProbs != nil {
+		fields = append(fields, "logprobs")
+	}
+	if req.TopLogProbs != nil {
+		fields = append(fields, "top_logprobs")
+	}
+	if req.User != "" {
+		fields = append(fields, "user")
+	}
+	if len(req.Metadata) > 0 {
+		fields = append(fields, "metadata")
+	}
+	return
+}
+
+// ResponseHeaders implements [Translator.ResponseHeaders].
+func (o *openAIToMistralTranslatorV1ChatCompletion) ResponseHeaders(map[string]string) (
+	headerMutation *extprocv3.HeaderMutation, err error,
+) {
+	if len(o.droppedFields) == 0 {
+		return nil, nil
+	}
+	return &extprocv3.HeaderMutation{
+		SetHeaders: []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: DroppedParamsHeaderKey, Value: strings.Join(o.droppedFields, ",")}},
+		},
+	}, nil
+}
+
+// ResponseError implements [Translator.ResponseError].
+// Translate Mistral errors to OpenAI error type. The validation errors of the 422 responses carry the details object
+// instead of the message, which is passed as the message in JSON. The non-JSON error body, such as the one for
+// HTTP 503 or 504, is wrapped as is.
+func (o *openAIToMistralTranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
+) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	statusCode := respHeaders[statusHeaderName]
+	openaiError := openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    mistralBackendError,
+			Message: string(buf),
+			Code:    &statusCode,
+		},
+	}
+	var mistralError mistral.Error
+	if isJSONContentType(respHeaders[contentTypeHeaderName]) && json.Unmarshal(buf, &mistralError) == nil {
+		if mistralError.Type != "" {
+			openaiError.Error.Type = mistralError.Type
+		}
+		openaiError.Error.Param = mistralError.Param
+		var message string
+		if json.Unmarshal(mistralError.Message, &message) == nil {
+			openaiError.Error.Message = message
+		} else if len(mistralError.Message) > 0 && !bytes.Equal(mistralError.Message, []byte("null")) {
+			openaiError.Error.Message = string(mistralError.Message)
+		}
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	mut.Body, err = json.Marshal(openaiError)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
+	}
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: contentTypeHeaderName, RawValue: []byte(jsonContentType)},
+	})
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+}
+
+// ResponseBody implements [Translator.ResponseBody].
+func (o *openAIToMistralTranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, endOfStream bool) (
+	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
+) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, o.stream); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
+	if statusStr, ok := respHeaders[statusHeaderName]; ok {
+		if status, err := strconv.Atoi(statusStr); err == nil && !isGoodStatusCode(status) {
+			headerMutation, bodyMutation, err = o.ResponseError(respHeaders, body)
+			return headerMutation, bodyMutation, LLMTokenUsage{}, err
+		}
+	}
+	return o.openAIToOpenAITranslatorV1ChatCompletion.ResponseBody(respHeaders, body, endOfStream)
+}
@@ -0,0 +1,301 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestOpenAIToMistralTranslatorV1ChatCompletion_RequestBody(t *testing.T) {
+	messages := []openai.ChatCompletionMessageParamUnion{
+		{
+			Value: openai.ChatCompletionUserMessageParam{
+				Role:    openai.ChatMessageRoleUser,
+				Content: openai.StringOrUserRoleContentUnion{Value: "from-user"},
+			}, Type: openai.ChatMessageRoleUser,
+		},
+	}
+	t.Run("invalid body", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		_, _, _, err := o.RequestBody("invalid")
+		require.ErrorContains(t, err, "unexpected body type")
+	})
+	t.Run("passthrough", func(t *testing.T) {
+		for _, stream := range []bool{true, false} {
+			t.Run(strconv.FormatBool(stream), func(t *testing.T) {
+				o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+				hm, bm, mode, err := o.RequestBody(&openai.ChatCompletionRequest{
+					Model: "mistral-large-latest", Messages: messages, Stream: stream, ToolChoice: "auto",
+				})
+				require.NoError(t, err)
+				require.Nil(t, hm)
+				require.Nil(t, bm)
+				if stream {
+					require.NotNil(t, mode)
+					require.Equal(t, extprocv3http.ProcessingMode_STREAMED, mode.ResponseBodyMode)
+				} else {
+					require.Nil(t, mode)
+				}
+			})
+		}
+	})
+	t.Run("normalized", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		hm, bm, mode, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model:         "mistral-large-latest",
+			Messages:      messages,
+			Stream:        true,
+			StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+			Seed:          ptr.To(42),
+			ToolChoice:    "required",
+			User:          "alice",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, mode)
+		require.NotNil(t, bm)
+		require.Len(t, hm.SetHeaders, 1)
+		require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
+		require.Equal(t, strconv.Itoa(len(bm.GetBody())), string(hm.SetHeaders[0].Header.RawValue))
+
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &got))
+		require.Equal(t, "any", got["tool_choice"])
+		require.Equal(t, float64(42), got["random_seed"])
+		require.Equal(t, true, got["stream"])
+		require.Equal(t, "mistral-large-latest", got["model"])
+		require.Len(t, got["messages"], 1)
+		for _, field := range []string{"seed", "stream_options", "user"} {
+			require.NotContains(t, got, field)
+		}
+	})
+	t.Run("raw body", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		o.(RawRequestBodyTranslator).SetRawRequestBody([]byte(`{"model":"mistral-large-latest","messages":[],"seed":42,` +
+			`"user":"alice","safe_prompt":true,"prediction":{"type":"content","content":"foo"}}`))
+		_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model: "mistral-large-latest", Seed: ptr.To(42), User: "alice",
+		})
+		require.NoError(t, err)
+		// The fields unknown to the parser are kept as-is.
+		require.JSONEq(t, `{"model":"mistral-large-latest","messages":[],"random_seed":42,`+
+			`"safe_prompt":true,"prediction":{"type":"content","content":"foo"}}`, string(bm.GetBody()))
+	})
+	t.Run("max_completion_tokens", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model: "mistral-large-latest", Messages: messages, MaxTokens: ptr.To[int64](100), MaxCompletionTokens: ptr.To[int64](200),
+		})
+		require.NoError(t, err)
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &got))
+		// Mistral only has max_tokens, to which max_completion_tokens is mapped taking precedence.
+		require.Equal(t, float64(200), got["max_tokens"])
+		require.NotContains(t, got, "max_completion_tokens")
+	})
+	t.Run("tool choice function", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		toolChoice := openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: "get_weather"}}
+		_, bm, _, err := o.RequestBody(&openai.ChatCompletionRequest{
+			Model: "mistral-large-latest", Messages: messages, ToolChoice: toolChoice,
+		})
+		require.NoError(t, err)
+		// The same format is accepted by Mistral.
+		require.Nil(t, bm)
+	})
+}
+
+func TestOpenAIToMistralTranslatorV1ChatCompletion_RequestBody_UnsupportedFields(t *testing.T) {
+	req := &openai.ChatCompletionRequest{
+		Model: "mistral-large-latest",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{
+				Value: openai.ChatCompletionUserMessageParam{
+					Role:    openai.ChatMessageRoleUser,
+					Content: openai.StringOrUserRoleContentUnion{Value: "from-user"},
+				}, Type: openai.ChatMessageRoleUser,
+			},
+		},
+		LogitBias: map[string]int{"1234": -100},
+		LogProbs:  ptr.To(true),
+		User:      "alice",
+		Metadata:  map[string]string{"foo": "bar"},
+	}
+	t.Run("ignore", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		_, bm, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		var got map[string]any
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &got))
+		for _, field := range []string{"logit_bias", "logprobs", "user", "metadata"} {
+			require.NotContains(t, got, field)
+		}
+		hm, err := o.ResponseHeaders(map[string]string{})
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		// The request is not modified.
+		require.Equal(t, "alice", req.User)
+	})
+	t.Run("warn", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyWarn)
+		_, bm, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		require.NotContains(t, string(bm.GetBody()), `"user":`)
+		hm, err := o.ResponseHeaders(map[string]string{})
+		require.NoError(t, err)
+		require.Len(t, hm.SetHeaders, 1)
+		require.Equal(t, DroppedParamsHeaderKey, hm.SetHeaders[0].Header.Key)
+		require.Equal(t, "logit_bias,logprobs,user,metadata", hm.SetHeaders[0].Header.Value)
+	})
+	t.Run("reject", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyReject)
+		_, _, _, err := o.RequestBody(req)
+		var unsupportedErr *UnsupportedFieldsError
+		require.ErrorAs(t, err, &unsupportedErr)
+		require.Equal(t, []string{"logit_bias", "logprobs", "user", "metadata"}, unsupportedErr.Fields)
+
+		// The request without the unsupported fields is not rejected.
+		_, _, _, err = o.RequestBody(&openai.ChatCompletionRequest{Model: "mistral-large-latest", Seed: ptr.To(1)})
+		require.NoError(t, err)
+	})
+}
+
+func TestOpenAIToMistralTranslator_ResponseError(t *testing.T) {
+	tests := []struct {
+		name            string
+		responseHeaders map[string]string
+		input           string
+		output          openai.Error
+	}{
+		{
+			name:            "mistral error",
+			responseHeaders: map[string]string{statusHeaderName: "400", contentTypeHeaderName: "application/json"},
+			input:           `{"object":"error","message":"Invalid model: foo","type":"invalid_model","param":null,"code":"1500"}`,
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    "invalid_model",
+					Code:    ptr.To("400"),
+					Message: "Invalid model: foo",
+				},
+			},
+		},
+		{
+			name:            "validation error",
+			responseHeaders: map[string]string{statusHeaderName: "422", contentTypeHeaderName: "application/json; charset=utf-8"},
+			input:           `{"object":"error","message":{"detail":[{"type":"missing","loc":["body","messages"],"msg":"Field required"}]},"type":"invalid_request_message_error","param":null,"code":null}`,
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    "invalid_request_message_error",
+					Code:    ptr.To("422"),
+					Message: `{"detail":[{"type":"missing","loc":["body","messages"],"msg":"Field required"}]}`,
+				},
+			},
+		},
+		{
+			name:            "error without type",
+			responseHeaders: map[string]string{statusHeaderName: "401", contentTypeHeaderName: "application/json"},
+			input:           `{"message":"Unauthorized","request_id":"abc"}`,
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    mistralBackendError,
+					Code:    ptr.To("401"),
+					Message: "Unauthorized",
+				},
+			},
+		},
+		{
+			name:            "non-json error",
+			responseHeaders: map[string]string{statusHeaderName: "503", contentTypeHeaderName: "text/plain"},
+			input:           "service not available",
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    mistralBackendError,
+					Code:    ptr.To("503"),
+					Message: "service not available",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+			hm, bm, err := o.ResponseError(tt.responseHeaders, strings.NewReader(tt.input))
+			require.NoError(t, err)
+			require.NotNil(t, bm)
+			require.NotNil(t, hm)
+			var openAIError openai.Error
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIError))
+			require.Equal(t, tt.output, openAIError)
+
+			// The error is also translated when it is passed to the ResponseBody.
+			_, bm, usage, err := o.ResponseBody(tt.responseHeaders, strings.NewReader(tt.input), true)
+			require.NoError(t, err)
+			require.Equal(t, LLMTokenUsage{}, usage)
+			openAIError = openai.Error{}
+			require.NoError(t, json.Unmarshal(bm.GetBody(), &openAIError))
+			require.Equal(t, tt.output, openAIError)
+		})
+	}
+}
+
+func TestOpenAIToMistralTranslatorV1ChatCompletion_ResponseBody(t *testing.T) {
+	t.Run("streaming", func(t *testing.T) {
+		// Mistral sends the usage in the last chunk with the finish reason, not in a separate chunk.
+		body := []byte(`data: {"id":"cmpl-foo","object":"chat.completion.chunk","created":1735689600,"model":"mistral-large-latest","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}
+
+data: {"id":"cmpl-foo","object":"chat.completion.chunk","created":1735689600,"model":"mistral-large-latest","choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null}]}
+
+data: {"id":"cmpl-foo","object":"chat.completion.chunk","created":1735689600,"model":"mistral-large-latest","choices":[{"index":0,"delta":{"content":"!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"total_tokens":12,"completion_tokens":2}}
+
+data: [DONE]
+
+`)
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "mistral-large-latest", Stream: true})
+		require.NoError(t, err)
+		var usage LLMTokenUsage
+		for i := 0; i < len(body); i += 7 {
+			hm, bm, tokenUsage, err := o.ResponseBody(map[string]string{statusHeaderName: "200"},
+				bytes.NewReader(body[i:min(i+7, len(body))]), false)
+			require.NoError(t, err)
+			require.Nil(t, hm)
+			require.Nil(t, bm)
+			if tokenUsage.TotalTokens > 0 {
+				usage = tokenUsage
+			}
+		}
+		require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}, usage)
+	})
+	t.Run("non-streaming", func(t *testing.T) {
+		body := `{"id":"cmpl-foo","object":"chat.completion","created":1735689600,"model":"mistral-large-latest","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!","tool_calls":null},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"total_tokens":12,"completion_tokens":2}}`
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		_, _, _, err := o.RequestBody(&openai.ChatCompletionRequest{Model: "mistral-large-latest"})
+		require.NoError(t, err)
+		hm, bm, usage, err := o.ResponseBody(map[string]string{statusHeaderName: "200"}, strings.NewReader(body), true)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Equal(t, LLMTokenUsage{InputTokens: 10, OutputTokens: 2, TotalTokens: 12}, usage)
+	})
+	t.Run("invalid body", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore)
+		_, _, _, err := o.ResponseBody(nil, strings.NewReader("invalid"), true)
+		require.Error(t, err)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"io"
 	"strconv"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 
@@ -26,7 +27,7 @@ func NewChatCompletionOpenAIToOpenAITranslator() Translator {
 // openAIToOpenAITranslatorV1ChatCompletion implements [Translator] for /v1/chat/completions.
 type openAIToOpenAITranslatorV1ChatCompletion struct {
 	stream        bool
-	buffered      []byte
+	sse           sseParser
 	bufferingDone bool
 }
 
@@ -50,37 +51,40 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) RequestBody(body RequestBody)
 	return nil, nil, override, nil
 }
 
-// ResponseError implements [Translator.ResponseError]
-// For OpenAI based backend we return the OpenAI error type as is.
-// If connection fails the error body is translated to OpenAI error type for events such as HTTP 503 or 504.
+// ResponseError implements [Translator.ResponseError].
+// The OpenAI error response of the upstream is passed through untouched with the status code preserved.
+// The non-JSON error body, such as the HTML page returned by an intermediary for HTTP 502, is wrapped into
+// the OpenAI error type.
 func (o *openAIToOpenAITranslatorV1ChatCompletion) ResponseError(respHeaders map[string]string, body io.Reader) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, err error,
 ) {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read error body: %w", err)
+	}
+	if ct, ok := respHeaders[contentTypeHeaderName]; (!ok || isJSONContentType(ct)) && json.Valid(buf) {
+		return nil, nil, nil
+	}
 	statusCode := respHeaders[statusHeaderName]
-	if v, ok := respHeaders[contentTypeHeaderName]; ok && v != jsonContentType {
-		var openaiError openai.Error
-		buf, err := io.ReadAll(body)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to read error body: %w", err)
-		}
-		openaiError = openai.Error{
-			Type: "error",
-			Error: openai.ErrorType{
-				Type:    openAIBackendError,
-				Message: string(buf),
-				Code:    &statusCode,
-			},
-		}
-		mut := &extprocv3.BodyMutation_Body{}
-		mut.Body, err = json.Marshal(openaiError)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
-		}
-		headerMutation = &extprocv3.HeaderMutation{}
-		setContentLength(headerMutation, mut.Body)
-		return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
+	openaiError := openai.Error{
+		Type: "error",
+		Error: openai.ErrorType{
+			Type:    openAIBackendError,
+			Message: string(buf),
+			Code:    &statusCode,
+		},
+	}
+	mut := &extprocv3.BodyMutation_Body{}
+	mut.Body, err = json.Marshal(openaiError)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal error body: %w", err)
 	}
-	return nil, nil, nil
+	headerMutation = &extprocv3.HeaderMutation{}
+	setContentLength(headerMutation, mut.Body)
+	headerMutation.SetHeaders = append(headerMutation.SetHeaders, &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: contentTypeHeaderName, RawValue: []byte(jsonContentType)},
+	})
+	return headerMutation, &extprocv3.BodyMutation{Mutation: mut}, nil
 }
 
 // ResponseHeaders implements [Translator.ResponseHeaders].
@@ -92,6 +96,10 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) ResponseHeaders(map[string]st
 func (o *openAIToOpenAITranslatorV1ChatCompletion) ResponseBody(respHeaders map[string]string, body io.Reader, _ bool) (
 	headerMutation *extprocv3.HeaderMutation, bodyMutation *extprocv3.BodyMutation, tokenUsage LLMTokenUsage, err error,
 ) {
+	var empty bool
+	if body, empty = emptyResponseBody(respHeaders, body, o.stream); empty {
+		return nil, nil, LLMTokenUsage{}, nil
+	}
 	if v, ok := respHeaders[statusHeaderName]; ok {
 		if v, err := strconv.Atoi(v); err == nil {
 			if !isGoodStatusCode(v) {
@@ -106,40 +114,44 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) ResponseBody(respHeaders map[
 			if err != nil {
 				return nil, nil, tokenUsage, fmt.Errorf("failed to read body: %w", err)
 			}
-			o.buffered = append(o.buffered, buf...)
-			tokenUsage = o.extractUsageFromBufferEvent()
+			tokenUsage = o.extractUsageFromEvents(buf)
 		}
 		return
 	}
-	var resp openai.ChatCompletionResponse
+	var resp openAIUsageOnly
 	if err := json.NewDecoder(body).Decode(&resp); err != nil {
 		return nil, nil, tokenUsage, fmt.Errorf("failed to unmarshal body: %w", err)
 	}
-	tokenUsage = LLMTokenUsage{
-		InputTokens:  uint32(resp.Usage.PromptTokens),     //nolint:gosec
-		OutputTokens: uint32(resp.Usage.CompletionTokens), //nolint:gosec
-		TotalTokens:  uint32(resp.Usage.TotalTokens),      //nolint:gosec
+	if usage := resp.Usage; usage != nil {
+		tokenUsage = LLMTokenUsage{
+			InputTokens:  uint32(usage.PromptTokens),     //nolint:gosec
+			OutputTokens: uint32(usage.CompletionTokens), //nolint:gosec
+			TotalTokens:  uint32(usage.TotalTokens),      //nolint:gosec
+		}
 	}
 	return
 }
 
-var dataPrefix = []byte("data: ")
+// openAIUsageOnly is the subset of [openai.ChatCompletionResponse] and [openai.ChatCompletionResponseChunk] that the
+// passthrough translator needs. The body is forwarded untouched, so decoding only the usage avoids allocating the
+// choices and the message contents.
+type openAIUsageOnly struct {
+	Usage *openai.ChatCompletionResponseUsage `json:"usage,omitempty"`
+}
 
-// extractUsageFromBufferEvent extracts the token usage from the buffered event.
-// Once the usage is extracted, it returns the number of tokens used, and bufferingDone is set to true.
-func (o *openAIToOpenAITranslatorV1ChatCompletion) extractUsageFromBufferEvent() (tokenUsage LLMTokenUsage) {
-	for {
-		i := bytes.IndexByte(o.buffered, '\n')
-		if i == -1 {
-			return
-		}
-		line := o.buffered[:i]
-		o.buffered = o.buffered[i+1:]
-		if !bytes.HasPrefix(line, dataPrefix) {
-			continue
+// sseDoneData is the data of the last event of the OpenAI event stream.
+var sseDoneData = []byte("[DONE]")
+
+// extractUsageFromEvents parses the chunk of the event stream and extracts the token usage from the completed events.
+// Once the usage is extracted or the stream is done, bufferingDone is set to true and the rest of the stream is not parsed.
+func (o *openAIToOpenAITranslatorV1ChatCompletion) extractUsageFromEvents(chunk []byte) (tokenUsage LLMTokenUsage) {
+	for _, ev := range o.sse.feed(chunk) {
+		if bytes.Equal(ev.data, sseDoneData) {
+			o.bufferingDone = true
+			break
 		}
-		var event openai.ChatCompletionResponseChunk
-		if err := json.Unmarshal(bytes.TrimPrefix(line, dataPrefix), &event); err != nil {
+		var event openAIUsageOnly
+		if err := json.Unmarshal(ev.data, &event); err != nil {
 			continue
 		}
 		if usage := event.Usage; usage != nil {
@@ -149,8 +161,11 @@ func (o *openAIToOpenAITranslatorV1ChatCompletion) extractUsageFromBufferEvent()
 				TotalTokens:  uint32(usage.TotalTokens),      //nolint:gosec
 			}
 			o.bufferingDone = true
-			o.buffered = nil
-			return
+			break
 		}
 	}
+	if o.bufferingDone {
+		o.sse = sseParser{}
+	}
+	return
 }
@@ -9,8 +9,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"strconv"
+	"strings"
 	"testing"
 
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
@@ -31,7 +31,11 @@ func TestOpenAIToOpenAITranslatorV1ChatCompletionRequestBody(t *testing.T) {
 	t.Run("valid body", func(t *testing.T) {
 		for _, stream := range []bool{true, false} {
 			t.Run(fmt.Sprintf("stream=%t", stream), func(t *testing.T) {
-				originalReq := &openai.ChatCompletionRequest{Model: "foo-bar-ai", Stream: stream}
+				originalReq := &openai.ChatCompletionRequest{
+					Model: "foo-bar-ai", Stream: stream, LogProbs: ptr.To(true), TopLogProbs: ptr.To(2),
+					// Both are passed through untouched to OpenAI, which decides the precedence by itself.
+					MaxTokens: ptr.To[int64](100), MaxCompletionTokens: ptr.To[int64](200),
+				}
 
 				o := &openAIToOpenAITranslatorV1ChatCompletion{}
 				hm, bm, mode, err := o.RequestBody(RequestBody(originalReq))
@@ -50,24 +54,48 @@ func TestOpenAIToOpenAITranslatorV1ChatCompletionRequestBody(t *testing.T) {
 			})
 		}
 	})
+	t.Run("audio input and refusal", func(t *testing.T) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.Unmarshal([]byte(`{"model":"gpt-4o-audio-preview","messages":[`+
+			`{"role":"assistant","content":{"type":"refusal","refusal":"I cannot help with that."}},`+
+			`{"role":"user","content":[{"type":"text","text":"what is in this recording?"},`+
+			`{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}]}]}`), &req))
+		// Both content types are understood by the schema, so they survive the re-encoding of the request.
+		assistant := req.Messages[0].Value.(openai.ChatCompletionAssistantMessageParam)
+		require.Equal(t, "I cannot help with that.", *assistant.Content.Refusal)
+		user := req.Messages[1].Value.(openai.ChatCompletionUserMessageParam)
+		parts := user.Content.Value.([]openai.ChatCompletionContentPartUserUnionParam)
+		require.Equal(t, "UklGRg==", parts[1].InputAudioContent.InputAudio.Data)
+		encoded, err := json.Marshal(parts[1])
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}`, string(encoded))
+
+		// The OpenAI backends receive the request untouched.
+		o := &openAIToOpenAITranslatorV1ChatCompletion{}
+		hm, bm, mode, err := o.RequestBody(&req)
+		require.NoError(t, err)
+		require.Nil(t, hm)
+		require.Nil(t, bm)
+		require.Nil(t, mode)
+	})
 }
 
 func TestOpenAIToOpenAITranslator_ResponseError(t *testing.T) {
 	tests := []struct {
 		name            string
 		responseHeaders map[string]string
-		input           io.Reader
-		contentType     string
-		output          openai.Error
+		input           string
+		// passThrough is true if the error body is expected to be forwarded untouched.
+		passThrough bool
+		output      openai.Error
 	}{
 		{
-			name:        "test unhealthy upstream",
-			contentType: "text/plain",
+			name: "test unhealthy upstream",
 			responseHeaders: map[string]string{
 				":status":      "503",
 				"content-type": "text/plain",
 			},
-			input: bytes.NewBuffer([]byte("service not available")),
+			input: "service not available",
 			output: openai.Error{
 				Type: "error",
 				Error: openai.ErrorType{
@@ -77,14 +105,46 @@ func TestOpenAIToOpenAITranslator_ResponseError(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test HTML error page from intermediary",
+			responseHeaders: map[string]string{
+				":status":      "502",
+				"content-type": "text/html; charset=utf-8",
+			},
+			input: "<html><body><h1>502 Bad Gateway</h1></body></html>",
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    openAIBackendError,
+					Code:    ptr.To("502"),
+					Message: "<html><body><h1>502 Bad Gateway</h1></body></html>",
+				},
+			},
+		},
+		{
+			name: "test invalid JSON with JSON content type",
+			responseHeaders: map[string]string{
+				":status":      "500",
+				"content-type": "application/json",
+			},
+			input: `{"error":`,
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    openAIBackendError,
+					Code:    ptr.To("500"),
+					Message: `{"error":`,
+				},
+			},
+		},
 		{
 			name: "test OpenAI missing required field error",
 			responseHeaders: map[string]string{
 				":status":      "400",
 				"content-type": "application/json",
 			},
-			contentType: "application/json",
-			input:       bytes.NewBuffer([]byte(`{"error": {"message": "missing required field", "type": "BadRequestError", "code": "400"}}`)),
+			input:       `{"error": {"message": "missing required field", "type": "BadRequestError", "code": "400"}}`,
+			passThrough: true,
 			output: openai.Error{
 				Error: openai.ErrorType{
 					Type:    "BadRequestError",
@@ -93,19 +153,46 @@ func TestOpenAIToOpenAITranslator_ResponseError(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "test OpenAI rate limit error with charset",
+			responseHeaders: map[string]string{
+				":status":      "429",
+				"content-type": "application/json; charset=utf-8",
+			},
+			input:       `{"error": {"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			passThrough: true,
+			output: openai.Error{
+				Error: openai.ErrorType{
+					Type:    "requests",
+					Code:    ptr.To("rate_limit_exceeded"),
+					Message: "Rate limit reached",
+				},
+			},
+		},
+		{
+			name:            "test overloaded error without content type",
+			responseHeaders: map[string]string{":status": "529"},
+			input:           `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`,
+			passThrough:     true,
+			output: openai.Error{
+				Type: "error",
+				Error: openai.ErrorType{
+					Type:    "overloaded_error",
+					Message: "Overloaded",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body, err := json.Marshal(tt.input)
-			require.NoError(t, err)
-			fmt.Println(string(body))
-
 			o := &openAIToOpenAITranslatorV1ChatCompletion{}
-			hm, bm, err := o.ResponseError(tt.responseHeaders, tt.input)
+			hm, bm, err := o.ResponseError(tt.responseHeaders, strings.NewReader(tt.input))
 			require.NoError(t, err)
 			var newBody []byte
-			if tt.contentType == jsonContentType {
-				newBody = tt.input.(*bytes.Buffer).Bytes()
+			if tt.passThrough {
+				require.Nil(t, hm)
+				require.Nil(t, bm)
+				newBody = []byte(tt.input)
 			} else {
 				require.NotNil(t, bm)
 				require.NotNil(t, bm.Mutation)
@@ -114,16 +201,18 @@ func TestOpenAIToOpenAITranslator_ResponseError(t *testing.T) {
 				require.NotNil(t, newBody)
 				require.NotNil(t, hm)
 				require.NotNil(t, hm.SetHeaders)
-				require.Len(t, hm.SetHeaders, 1)
+				require.Len(t, hm.SetHeaders, 2)
 				require.Equal(t, "content-length", hm.SetHeaders[0].Header.Key)
 				require.Equal(t, strconv.Itoa(len(newBody)), string(hm.SetHeaders[0].Header.RawValue))
+				require.Equal(t, "content-type", hm.SetHeaders[1].Header.Key)
+				require.Equal(t, "application/json", string(hm.SetHeaders[1].Header.RawValue))
 			}
 
 			var openAIError openai.Error
 			err = json.Unmarshal(newBody, &openAIError)
 			require.NoError(t, err)
 			if !cmp.Equal(openAIError, tt.output) {
-				t.Errorf("ConvertAWSBedrockErrorResp(), diff(got, expected) = %s\n", cmp.Diff(openAIError, tt.output))
+				t.Errorf("ResponseError(), diff(got, expected) = %s\n", cmp.Diff(openAIError, tt.output))
 			}
 		})
 	}
@@ -167,15 +256,29 @@ data: [DONE]
 
 `)
 
-		o := &openAIToOpenAITranslatorV1ChatCompletion{stream: true}
-		for i := 0; i < len(wholeBody); i++ {
-			hm, bm, tokenUsage, err := o.ResponseBody(nil, bytes.NewReader(wholeBody[i:i+1]), false)
-			require.NoError(t, err)
-			require.Nil(t, hm)
-			require.Nil(t, bm)
-			if tokenUsage.OutputTokens > 0 {
-				require.Equal(t, uint32(12), tokenUsage.OutputTokens)
-			}
+		for _, tc := range []struct {
+			name string
+			body []byte
+		}{
+			{name: "lf", body: wholeBody},
+			{name: "crlf", body: bytes.ReplaceAll(wholeBody, []byte("\n"), []byte("\r\n"))},
+			// Upstreams like LiteLLM interleave the keep-alive comments between the events.
+			{name: "keep-alive comments", body: bytes.ReplaceAll(wholeBody, []byte("\n\n"), []byte("\n\n: keep-alive\n\n"))},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				o := &openAIToOpenAITranslatorV1ChatCompletion{stream: true}
+				var usage LLMTokenUsage
+				for i := 0; i < len(tc.body); i++ {
+					hm, bm, tokenUsage, err := o.ResponseBody(nil, bytes.NewReader(tc.body[i:i+1]), false)
+					require.NoError(t, err)
+					require.Nil(t, hm)
+					require.Nil(t, bm)
+					if tokenUsage.OutputTokens > 0 {
+						usage = tokenUsage
+					}
+				}
+				require.Equal(t, LLMTokenUsage{InputTokens: 13, OutputTokens: 12, TotalTokens: 25}, usage)
+			})
 		}
 	})
 	t.Run("non-streaming", func(t *testing.T) {
@@ -195,48 +298,84 @@ data: [DONE]
 			require.Equal(t, LLMTokenUsage{TotalTokens: 42}, usedToken)
 		})
 	})
+	t.Run("logprobs", func(t *testing.T) {
+		// The log probabilities are passed through untouched, that is, the body is never mutated.
+		t.Run("streaming", func(t *testing.T) {
+			body := []byte(`data: {"id":"chatcmpl-foo","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.25,"bytes":[72,105],"top_logprobs":[{"token":"Hi","logprob":-0.25,"bytes":[72,105]}]}],"refusal":null},"finish_reason":null}],"usage":null}
+
+data: [DONE]
+
+`)
+			o := &openAIToOpenAITranslatorV1ChatCompletion{stream: true}
+			hm, bm, _, err := o.ResponseBody(nil, bytes.NewReader(body), true)
+			require.NoError(t, err)
+			require.Nil(t, hm)
+			require.Nil(t, bm)
+		})
+		t.Run("non-streaming", func(t *testing.T) {
+			body := []byte(`{"choices":[{"index":0,"message":{"content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.25,"bytes":[72,105],"top_logprobs":[]}],"refusal":null},"finish_reason":"stop"}]}`)
+			o := &openAIToOpenAITranslatorV1ChatCompletion{}
+			hm, bm, _, err := o.ResponseBody(nil, bytes.NewReader(body), true)
+			require.NoError(t, err)
+			require.Nil(t, hm)
+			require.Nil(t, bm)
+		})
+	})
 }
 
-func TestExtractUsageFromBufferEvent(t *testing.T) {
+func TestExtractUsageFromEvents(t *testing.T) {
 	t.Run("valid usage data", func(t *testing.T) {
 		o := &openAIToOpenAITranslatorV1ChatCompletion{}
-		o.buffered = []byte("data: {\"usage\": {\"total_tokens\": 42}}\n")
-		usedToken := o.extractUsageFromBufferEvent()
+		usedToken := o.extractUsageFromEvents([]byte("data: {\"usage\": {\"total_tokens\": 42}}\n\n"))
 		require.Equal(t, LLMTokenUsage{TotalTokens: 42}, usedToken)
 		require.True(t, o.bufferingDone)
-		require.Nil(t, o.buffered)
+		require.Nil(t, o.sse.buffered)
 	})
 
 	t.Run("valid usage data after invalid", func(t *testing.T) {
 		o := &openAIToOpenAITranslatorV1ChatCompletion{}
-		o.buffered = []byte("data: invalid\ndata: {\"usage\": {\"total_tokens\": 42}}\n")
-		usedToken := o.extractUsageFromBufferEvent()
+		usedToken := o.extractUsageFromEvents([]byte("data: invalid\n\ndata: {\"usage\": {\"total_tokens\": 42}}\n\n"))
 		require.Equal(t, LLMTokenUsage{TotalTokens: 42}, usedToken)
 		require.True(t, o.bufferingDone)
-		require.Nil(t, o.buffered)
+		require.Nil(t, o.sse.buffered)
 	})
 
 	t.Run("no usage data and then become valid", func(t *testing.T) {
 		o := &openAIToOpenAITranslatorV1ChatCompletion{}
-		o.buffered = []byte("data: {}\n\ndata: ")
-		usedToken := o.extractUsageFromBufferEvent()
+		usedToken := o.extractUsageFromEvents([]byte("data: {}\n\ndata: "))
 		require.Equal(t, LLMTokenUsage{}, usedToken)
 		require.False(t, o.bufferingDone)
-		require.NotNil(t, o.buffered)
+		require.NotNil(t, o.sse.buffered)
 
-		o.buffered = append(o.buffered, []byte("{\"usage\": {\"total_tokens\": 42}}\n")...)
-		usedToken = o.extractUsageFromBufferEvent()
+		usedToken = o.extractUsageFromEvents([]byte("{\"usage\": {\"total_tokens\": 42}}\n\n"))
 		require.Equal(t, LLMTokenUsage{TotalTokens: 42}, usedToken)
 		require.True(t, o.bufferingDone)
-		require.Nil(t, o.buffered)
+		require.Nil(t, o.sse.buffered)
+	})
+
+	t.Run("multi-line data with comments", func(t *testing.T) {
+		o := &openAIToOpenAITranslatorV1ChatCompletion{}
+		usedToken := o.extractUsageFromEvents([]byte(": keep-alive\n\nevent: chunk\ndata: {\"usage\":\n: keep-alive\ndata: {\"total_tokens\": 42}}\n"))
+		require.Equal(t, LLMTokenUsage{}, usedToken)
+		require.False(t, o.bufferingDone)
+
+		usedToken = o.extractUsageFromEvents([]byte("\n"))
+		require.Equal(t, LLMTokenUsage{TotalTokens: 42}, usedToken)
+		require.True(t, o.bufferingDone)
+	})
+
+	t.Run("done", func(t *testing.T) {
+		o := &openAIToOpenAITranslatorV1ChatCompletion{}
+		usedToken := o.extractUsageFromEvents([]byte("data: {}\n\ndata: [DONE]\n\ndata: {\"usage\": {\"total_tokens\": 42}}\n\n"))
+		require.Equal(t, LLMTokenUsage{}, usedToken)
+		require.True(t, o.bufferingDone)
+		require.Nil(t, o.sse.buffered)
 	})
 
 	t.Run("invalid JSON", func(t *testing.T) {
 		o := &openAIToOpenAITranslatorV1ChatCompletion{}
-		o.buffered = []byte("data: invalid\n")
-		usedToken := o.extractUsageFromBufferEvent()
+		usedToken := o.extractUsageFromEvents([]byte("data: invalid\n\n"))
 		require.Equal(t, LLMTokenUsage{}, usedToken)
 		require.False(t, o.bufferingDone)
-		require.NotNil(t, o.buffered)
 	})
 }
@@ -0,0 +1,141 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"k8s.io/utils/ptr"
+)
+
+// NormalizedParamsHeaderKey is the response header listing the parameters adjusted by [ParameterNormalization]
+// when [ParameterNormalization.Debug] is set, for example, "temperature=1.6->0.8,top_p=1.2->1".
+const NormalizedParamsHeaderKey = "x-ai-eg-normalized-params"
+
+// openAIMaxTemperature is the maximum temperature accepted by OpenAI.
+const openAIMaxTemperature = 2.0
+
+// ParameterNormalization rescales the temperature and the top_p of the OpenAI requests to the ranges of the models
+// of the backend. The nil *ParameterNormalization leaves the values as-is.
+type ParameterNormalization struct {
+	// Auto derives the scale factors from the model family of the request. When set, the scale factors below are
+	// not used.
+	Auto bool
+	// TemperatureScale is the factor multiplied to the temperature. Nil leaves the temperature as-is.
+	TemperatureScale *float64
+	// TopPScale is the factor multiplied to the top_p. Nil leaves the top_p as-is.
+	TopPScale *float64
+	// Debug records the adjusted parameters in the [NormalizedParamsHeaderKey] response header.
+	Debug bool
+}
+
+// bedrockSamplingRange is the valid range of the sampling parameters of a Bedrock model family.
+type bedrockSamplingRange struct {
+	// modelPrefix is the prefix of the model ID of the family without the region prefix, e.g. "amazon.titan".
+	modelPrefix string
+	// maxTemperature is the maximum temperature of the family. The minimum is zero.
+	maxTemperature float64
+}
+
+// bedrockSamplingRanges is the sampling ranges of the Bedrock model families known to differ from OpenAI. The top_p
+// of all of them ranges from 0 to 1 as OpenAI.
+var bedrockSamplingRanges = []bedrockSamplingRange{
+	{modelPrefix: "amazon.titan", maxTemperature: 1},
+	{modelPrefix: "amazon.nova", maxTemperature: 1},
+	{modelPrefix: "anthropic.claude", maxTemperature: 1},
+	{modelPrefix: "meta.llama", maxTemperature: 1},
+	{modelPrefix: "mistral.", maxTemperature: 1},
+}
+
+// bedrockCrossRegionPrefixes is the region prefixes of the cross-region inference profiles.
+var bedrockCrossRegionPrefixes = []string{"us.", "us-gov.", "eu.", "apac.", "ca.", "jp.", "au.", "global."}
+
+// bedrockBaseModelID returns the model ID without the region prefix of the cross-region inference profile, if any.
+// The ARN of the foundation model or of the inference profile is reduced to the model ID it contains.
+func bedrockBaseModelID(modelID string) string {
+	id := modelID[strings.LastIndexByte(modelID, '/')+1:]
+	for _, prefix := range bedrockCrossRegionPrefixes {
+		if trimmed, ok := strings.CutPrefix(id, prefix); ok {
+			return trimmed
+		}
+	}
+	return id
+}
+
+// bedrockSamplingRangeOf returns the sampling range of the model ID, which can be prefixed by the region of the
+// cross-region inference profile or be the ARN of the foundation model or of the inference profile.
+func bedrockSamplingRangeOf(modelID string) (bedrockSamplingRange, bool) {
+	id := bedrockBaseModelID(modelID)
+	for _, r := range bedrockSamplingRanges {
+		if strings.HasPrefix(id, r.modelPrefix) {
+			return r, true
+		}
+	}
+	return bedrockSamplingRange{}, false
+}
+
+// normalize returns the temperature and the top_p rescaled for the model ID, clamped to the range of the model when
+// the model family is known. The adjustments are returned in the form of "<param>=<original>-><normalized>" when
+// [ParameterNormalization.Debug] is set. The given pointers are never modified, and the unset values stay nil.
+func (n *ParameterNormalization) normalize(modelID string, temperature, topP *float64) (
+	normalizedTemperature, normalizedTopP *float64, adjustments []string,
+) {
+	if n == nil {
+		return temperature, topP, nil
+	}
+	samplingRange, known := bedrockSamplingRangeOf(modelID)
+	temperatureScale, topPScale := n.TemperatureScale, n.TopPScale
+	if n.Auto {
+		if !known {
+			return temperature, topP, nil
+		}
+		temperatureScale = ptr.To(samplingRange.maxTemperature / openAIMaxTemperature)
+		topPScale = nil
+	}
+	maxTemperature, maxTopP := -1.0, -1.0
+	if known {
+		maxTemperature, maxTopP = samplingRange.maxTemperature, 1
+	}
+	normalizedTemperature = n.rescale("temperature", temperature, temperatureScale, maxTemperature, &adjustments)
+	normalizedTopP = n.rescale("top_p", topP, topPScale, maxTopP, &adjustments)
+	return normalizedTemperature, normalizedTopP, adjustments
+}
+
+// rescale multiplies the value by the scale, if any, and clamps it to the range from 0 to maxValue unless maxValue
+// is negative. The adjustment is appended to the adjustments when the value changes in the debug mode.
+func (n *ParameterNormalization) rescale(param string, value, scale *float64, maxValue float64, adjustments *[]string) *float64 {
+	if value == nil {
+		return nil
+	}
+	v := *value
+	if scale != nil {
+		v *= *scale
+	}
+	if maxValue >= 0 {
+		v = min(max(v, 0), maxValue)
+	}
+	if v == *value {
+		return value
+	}
+	if n.Debug {
+		*adjustments = append(*adjustments, param+"="+formatFloat(*value)+"->"+formatFloat(v))
+	}
+	return &v
+}
+
+// normalizedParamsHeader returns the [NormalizedParamsHeaderKey] header listing the adjustments.
+func normalizedParamsHeader(adjustments []string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{Key: NormalizedParamsHeaderKey, Value: strings.Join(adjustments, ",")},
+	}
+}
+
+// formatFloat formats the float in the shortest representation, e.g. "0.8".
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
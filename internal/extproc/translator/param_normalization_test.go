@@ -0,0 +1,224 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/awsbedrock"
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
+)
+
+func TestBedrockSamplingRangeOf(t *testing.T) {
+	for _, tc := range []struct {
+		modelID string
+		known   bool
+	}{
+		{modelID: "amazon.titan-text-express-v1", known: true},
+		{modelID: "us.amazon.nova-lite-v1:0", known: true},
+		{modelID: "anthropic.claude-3-5-sonnet-20240620-v1:0", known: true},
+		{modelID: "apac.anthropic.claude-3-haiku-20240307-v1:0", known: true},
+		{modelID: "arn:aws:bedrock:us-east-1::foundation-model/meta.llama3-8b-instruct-v1:0", known: true},
+		{modelID: "arn:aws:bedrock:us-east-1:123456789012:inference-profile/eu.mistral.pixtral-large-2502-v1:0", known: true},
+		{modelID: "ai21.jamba-1-5-large-v1:0"},
+		{modelID: "arn:aws:bedrock:us-east-1:123456789012:application-inference-profile/abcdef"},
+	} {
+		t.Run(tc.modelID, func(t *testing.T) {
+			r, known := bedrockSamplingRangeOf(tc.modelID)
+			require.Equal(t, tc.known, known)
+			if known {
+				require.Equal(t, 1.0, r.maxTemperature)
+			}
+		})
+	}
+}
+
+func TestParameterNormalization_normalize(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		normalization     *ParameterNormalization
+		modelID           string
+		temperature, topP *float64
+		expTemperature    *float64
+		expTopP           *float64
+		expAdjustments    []string
+	}{
+		{
+			name:           "nil normalization",
+			modelID:        "amazon.titan-text-express-v1",
+			temperature:    ptr.To(1.6),
+			topP:           ptr.To(0.9),
+			expTemperature: ptr.To(1.6),
+			expTopP:        ptr.To(0.9),
+		},
+		{
+			name:           "auto titan",
+			normalization:  &ParameterNormalization{Auto: true, Debug: true},
+			modelID:        "amazon.titan-text-express-v1",
+			temperature:    ptr.To(1.6),
+			topP:           ptr.To(0.9),
+			expTemperature: ptr.To(0.8),
+			expTopP:        ptr.To(0.9),
+			expAdjustments: []string{"temperature=1.6->0.8"},
+		},
+		{
+			name:           "auto claude with the region prefix",
+			normalization:  &ParameterNormalization{Auto: true, Debug: true},
+			modelID:        "us.anthropic.claude-3-5-sonnet-20240620-v1:0",
+			temperature:    ptr.To(2.0),
+			expTemperature: ptr.To(1.0),
+			expAdjustments: []string{"temperature=2->1"},
+		},
+		{
+			name:           "auto unknown family",
+			normalization:  &ParameterNormalization{Auto: true, Debug: true},
+			modelID:        "ai21.jamba-1-5-large-v1:0",
+			temperature:    ptr.To(1.6),
+			topP:           ptr.To(0.9),
+			expTemperature: ptr.To(1.6),
+			expTopP:        ptr.To(0.9),
+		},
+		{
+			name:          "auto nil parameters",
+			normalization: &ParameterNormalization{Auto: true, Debug: true},
+			modelID:       "amazon.titan-text-express-v1",
+		},
+		{
+			name:           "auto ignores the explicit scales",
+			normalization:  &ParameterNormalization{Auto: true, TemperatureScale: ptr.To(0.1), TopPScale: ptr.To(0.1)},
+			modelID:        "meta.llama3-8b-instruct-v1:0",
+			temperature:    ptr.To(1.0),
+			topP:           ptr.To(0.8),
+			expTemperature: ptr.To(0.5),
+			expTopP:        ptr.To(0.8),
+		},
+		{
+			name:           "explicit scaling and clamping",
+			normalization:  &ParameterNormalization{TemperatureScale: ptr.To(0.75), TopPScale: ptr.To(1.5), Debug: true},
+			modelID:        "mistral.mistral-large-2402-v1:0",
+			temperature:    ptr.To(1.6),
+			topP:           ptr.To(0.8),
+			expTemperature: ptr.To(1.0),
+			expTopP:        ptr.To(1.0),
+			expAdjustments: []string{"temperature=1.6->1", "top_p=0.8->1"},
+		},
+		{
+			name:           "explicit unknown family is not clamped",
+			normalization:  &ParameterNormalization{TemperatureScale: ptr.To(1.5), Debug: true},
+			modelID:        "ai21.jamba-1-5-large-v1:0",
+			temperature:    ptr.To(1.5),
+			topP:           ptr.To(0.8),
+			expTemperature: ptr.To(2.25),
+			expTopP:        ptr.To(0.8),
+			expAdjustments: []string{"temperature=1.5->2.25"},
+		},
+		{
+			name:           "explicit nil scales only clamp",
+			normalization:  &ParameterNormalization{Debug: true},
+			modelID:        "amazon.titan-text-express-v1",
+			temperature:    ptr.To(1.5),
+			topP:           ptr.To(0.5),
+			expTemperature: ptr.To(1.0),
+			expTopP:        ptr.To(0.5),
+			expAdjustments: []string{"temperature=1.5->1"},
+		},
+		{
+			name:           "without debug",
+			normalization:  &ParameterNormalization{Auto: true},
+			modelID:        "amazon.titan-text-express-v1",
+			temperature:    ptr.To(1.0),
+			expTemperature: ptr.To(0.5),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var origTemperature, origTopP *float64
+			if tc.temperature != nil {
+				origTemperature = ptr.To(*tc.temperature)
+			}
+			if tc.topP != nil {
+				origTopP = ptr.To(*tc.topP)
+			}
+			temperature, topP, adjustments := tc.normalization.normalize(tc.modelID, tc.temperature, tc.topP)
+			require.Equal(t, tc.expTemperature, temperature)
+			require.Equal(t, tc.expTopP, topP)
+			require.Equal(t, tc.expAdjustments, adjustments)
+			// The given values must not be modified.
+			require.Equal(t, origTemperature, tc.temperature)
+			require.Equal(t, origTopP, tc.topP)
+		})
+	}
+}
+
+func TestOpenAIToAWSBedrockTranslator_parameterNormalization(t *testing.T) {
+	req := &openai.ChatCompletionRequest{
+		Model:       "anthropic.claude-3-5-sonnet-20240620-v1:0",
+		Temperature: ptr.To(1.2),
+		TopP:        ptr.To(0.9),
+		Messages: []openai.ChatCompletionMessageParamUnion{{
+			Type:  openai.ChatMessageRoleUser,
+			Value: openai.ChatCompletionUserMessageParam{Role: openai.ChatMessageRoleUser, Content: openai.StringOrUserRoleContentUnion{Value: "hi"}},
+		}},
+	}
+
+	t.Run("converse", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "",
+			&ParameterNormalization{Auto: true, Debug: true}, nil)
+		_, bm, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		var converse awsbedrock.ConverseInput
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &converse))
+		require.Equal(t, ptr.To(0.6), converse.InferenceConfig.Temperature)
+		require.Equal(t, ptr.To(0.9), converse.InferenceConfig.TopP)
+		// The request of the client is left as-is.
+		require.Equal(t, ptr.To(1.2), req.Temperature)
+
+		hm, err := o.ResponseHeaders(map[string]string{"content-type": "application/json"})
+		require.NoError(t, err)
+		require.Equal(t, []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: NormalizedParamsHeaderKey, Value: "temperature=1.2->0.6"}},
+		}, hm.SetHeaders)
+	})
+
+	t.Run("invoke model", func(t *testing.T) {
+		titanReq := *req
+		titanReq.Model = "amazon.titan-text-express-v1"
+		o := NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "",
+			&ParameterNormalization{TemperatureScale: ptr.To(0.25), Debug: true})
+		_, bm, _, err := o.RequestBody(&titanReq)
+		require.NoError(t, err)
+		var titan struct {
+			TextGenerationConfig struct {
+				Temperature *float64 `json:"temperature"`
+				TopP        *float64 `json:"topP"`
+			} `json:"textGenerationConfig"`
+		}
+		require.NoError(t, json.Unmarshal(bm.GetBody(), &titan))
+		require.Equal(t, ptr.To(0.3), titan.TextGenerationConfig.Temperature)
+		require.Equal(t, ptr.To(0.9), titan.TextGenerationConfig.TopP)
+		require.Equal(t, ptr.To(1.2), titanReq.Temperature)
+
+		hm, err := o.ResponseHeaders(map[string]string{"content-type": "application/json"})
+		require.NoError(t, err)
+		require.Equal(t, []*corev3.HeaderValueOption{
+			{Header: &corev3.HeaderValue{Key: NormalizedParamsHeaderKey, Value: "temperature=1.2->0.3"}},
+		}, hm.SetHeaders)
+	})
+
+	t.Run("without debug", func(t *testing.T) {
+		o := NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "",
+			&ParameterNormalization{Auto: true}, nil)
+		_, _, _, err := o.RequestBody(req)
+		require.NoError(t, err)
+		hm, err := o.ResponseHeaders(map[string]string{"content-type": "application/json"})
+		require.NoError(t, err)
+		require.Nil(t, hm)
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import "bytes"
+
+// sseEvent is a server-sent event dispatched by [sseParser].
+type sseEvent struct {
+	// event is the value of the "event" field. Empty if not specified.
+	event string
+	// data is the concatenation of the "data" fields of the event joined with "\n".
+	data []byte
+}
+
+// sseParser incrementally parses the server-sent events stream as specified in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation.
+//
+// The stream can be fed in arbitrary chunks, so the lines and events split across the chunks
+// are buffered until they are complete. Comment lines as well as the "id" and "retry" fields are ignored.
+type sseParser struct {
+	// buffered is the incomplete line carried over to the next chunk.
+	buffered []byte
+	// event and data are the fields of the event being parsed.
+	event   string
+	data    []byte
+	hasData bool
+}
+
+// feed parses the chunk and returns the events completed by it.
+func (p *sseParser) feed(chunk []byte) (events []sseEvent) {
+	p.buffered = append(p.buffered, chunk...)
+	for {
+		i := bytes.IndexAny(p.buffered, "\r\n")
+		if i == -1 {
+			break
+		}
+		next := i + 1
+		if p.buffered[i] == '\r' {
+			if next == len(p.buffered) {
+				// Wait for the next chunk to tell "\r" from "\r\n".
+				break
+			}
+			if p.buffered[next] == '\n' {
+				next++
+			}
+		}
+		if ev, ok := p.processLine(p.buffered[:i]); ok {
+			events = append(events, ev)
+		}
+		p.buffered = p.buffered[next:]
+	}
+	if len(p.buffered) == 0 {
+		// Release the consumed chunks.
+		p.buffered = nil
+	}
+	return
+}
+
+// processLine processes a single line without the line ending, and returns the event if the line dispatches it.
+func (p *sseParser) processLine(line []byte) (sseEvent, bool) {
+	if len(line) == 0 {
+		if !p.hasData {
+			p.event = ""
+			return sseEvent{}, false
+		}
+		ev := sseEvent{event: p.event, data: p.data}
+		p.event, p.data, p.hasData = "", nil, false
+		return ev, true
+	}
+	if line[0] == ':' {
+		// Comment line such as ": keep-alive".
+		return sseEvent{}, false
+	}
+	field, value, _ := bytes.Cut(line, []byte(":"))
+	value = bytes.TrimPrefix(value, []byte(" "))
+	switch string(field) {
+	case "data":
+		if p.hasData {
+			p.data = append(p.data, '\n')
+		}
+		p.data = append(p.data, value...)
+		p.hasData = true
+	case "event":
+		p.event = string(value)
+	}
+	return sseEvent{}, false
+}
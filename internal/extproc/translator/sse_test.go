@@ -0,0 +1,81 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSEParser(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		stream string
+		exp    []sseEvent
+	}{
+		{
+			name:   "single line data",
+			stream: "data: {\"a\":1}\n\ndata: [DONE]\n\n",
+			exp:    []sseEvent{{data: []byte(`{"a":1}`)}, {data: []byte("[DONE]")}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: {\"a\":\ndata:1}\n\n",
+			exp:    []sseEvent{{data: []byte("{\"a\":\n1}")}},
+		},
+		{
+			name:   "comments and event names",
+			stream: ": keep-alive\n\nevent: message\n: keep-alive\ndata: foo\nid: 1\nretry: 1000\n\n:\n\n",
+			exp:    []sseEvent{{event: "message", data: []byte("foo")}},
+		},
+		{
+			name:   "event without data is not dispatched",
+			stream: "event: ping\n\ndata: foo\n\n",
+			exp:    []sseEvent{{data: []byte("foo")}},
+		},
+		{
+			name:   "empty data",
+			stream: "data\n\ndata:\n\n",
+			exp:    []sseEvent{{data: nil}, {data: nil}},
+		},
+		{
+			name:   "crlf and cr line endings",
+			stream: "data: foo\r\n\r\ndata: bar\r\rdata: baz\r\n\n",
+			exp:    []sseEvent{{data: []byte("foo")}, {data: []byte("bar")}, {data: []byte("baz")}},
+		},
+		{
+			name:   "incomplete event",
+			stream: "data: foo\n\ndata: bar\n",
+			exp:    []sseEvent{{data: []byte("foo")}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("whole", func(t *testing.T) {
+				var p sseParser
+				require.Equal(t, tc.exp, p.feed([]byte(tc.stream)))
+			})
+			t.Run("byte by byte", func(t *testing.T) {
+				var (
+					p      sseParser
+					events []sseEvent
+				)
+				for i := range len(tc.stream) {
+					events = append(events, p.feed([]byte{tc.stream[i]})...)
+				}
+				require.Equal(t, tc.exp, events)
+			})
+		})
+	}
+
+	t.Run("buffer is released", func(t *testing.T) {
+		var p sseParser
+		require.Empty(t, p.feed([]byte("data: fo")))
+		require.Equal(t, []byte("data: fo"), p.buffered)
+		require.Equal(t, []sseEvent{{data: []byte("foo")}}, p.feed([]byte("o\n\n")))
+		require.Nil(t, p.buffered)
+	})
+}
@@ -6,8 +6,14 @@
 package translator
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	extprocv3http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
@@ -15,12 +21,13 @@ import (
 )
 
 var (
-	statusHeaderName       = ":status"
-	contentTypeHeaderName  = "content-type"
-	awsErrorTypeHeaderName = "x-amzn-errortype"
-	jsonContentType        = "application/json"
-	openAIBackendError     = "OpenAIBackendError"
-	awsBedrockBackendError = "AWSBedrockBackendError"
+	statusHeaderName        = ":status"
+	contentTypeHeaderName   = "content-type"
+	contentLengthHeaderName = "content-length"
+	awsErrorTypeHeaderName  = "x-amzn-errortype"
+	jsonContentType         = "application/json"
+	openAIBackendError      = "OpenAIBackendError"
+	awsBedrockBackendError  = "AWSBedrockBackendError"
 )
 
 // isGoodStatusCode checks if the HTTP status code of the upstream response is successful.
@@ -30,6 +37,40 @@ func isGoodStatusCode(code int) bool {
 	return code >= 200 && code < 300
 }
 
+// isJSONContentType checks if the content-type header value is JSON, ignoring the parameters such as charset.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == jsonContentType
+}
+
+// emptyResponseBody returns true if the response has no body to translate, in which case the response is passed
+// through untouched, preserving the status of the upstream. That is the case for the 204 and 304 responses, the
+// responses with content-length 0, and the buffered bodies that end immediately, such as the response to a HEAD request.
+//
+// The chunks of the streamed successful response are not checked since the empty chunk, such as the last one, is
+// part of the stream. The returned reader must be used in place of the body as the first byte may have been read.
+func emptyResponseBody(respHeaders map[string]string, body io.Reader, stream bool) (io.Reader, bool) {
+	status, err := strconv.Atoi(respHeaders[statusHeaderName])
+	if err == nil && (status == http.StatusNoContent || status == http.StatusNotModified) {
+		return body, true
+	}
+	if respHeaders[contentLengthHeaderName] == "0" {
+		return body, true
+	}
+	// The error response of the streaming request is buffered as a whole.
+	if stream && (err != nil || isGoodStatusCode(status)) {
+		return body, false
+	}
+	if r, ok := body.(interface{ Len() int }); ok {
+		return body, r.Len() == 0
+	}
+	br := bufio.NewReader(body)
+	if _, err = br.Peek(1); errors.Is(err, io.EOF) {
+		return br, true
+	}
+	return br, false
+}
+
 // RequestBody is the union of all request body types. TODO: maybe we should just define Translator interface per endpoint.
 type RequestBody any
 
@@ -83,6 +124,13 @@ type Translator interface {
 	)
 }
 
+// RawRequestBodyTranslator is implemented by the [Translator] that patches the original request body instead of
+// re-encoding the parsed one, so that the fields unknown to the parser are forwarded as-is.
+type RawRequestBodyTranslator interface {
+	// SetRawRequestBody sets the original request body. This is called before [Translator.RequestBody].
+	SetRawRequestBody(raw []byte)
+}
+
 func setContentLength(headers *extprocv3.HeaderMutation, body []byte) {
 	headers.SetHeaders = append(headers.SetHeaders, &corev3.HeaderValueOption{
 		Header: &corev3.HeaderValue{
@@ -92,6 +140,52 @@ func setContentLength(headers *extprocv3.HeaderMutation, body []byte) {
 	})
 }
 
+// UnsupportedFieldPolicy specifies how [Translator.RequestBody] handles the request fields not supported by the backend.
+type UnsupportedFieldPolicy int
+
+const (
+	// UnsupportedFieldPolicyIgnore silently drops the unsupported fields.
+	UnsupportedFieldPolicyIgnore UnsupportedFieldPolicy = iota
+	// UnsupportedFieldPolicyWarn drops the unsupported fields and lists them in the [DroppedParamsHeaderKey] response header.
+	UnsupportedFieldPolicyWarn
+	// UnsupportedFieldPolicyReject makes [Translator.RequestBody] return [UnsupportedFieldsError].
+	UnsupportedFieldPolicyReject
+)
+
+// DroppedParamsHeaderKey is the response header listing the request fields dropped under [UnsupportedFieldPolicyWarn].
+const DroppedParamsHeaderKey = "x-ai-eg-dropped-params"
+
+// UnsupportedFieldsError is returned by [Translator.RequestBody] under [UnsupportedFieldPolicyReject] when the request
+// sets the fields not supported by the backend.
+type UnsupportedFieldsError struct {
+	// Fields is the names of the unsupported fields set in the request.
+	Fields []string
+}
+
+// Error implements [error].
+func (e *UnsupportedFieldsError) Error() string {
+	return fmt.Sprintf("unsupported fields: %s", strings.Join(e.Fields, ", "))
+}
+
+// UnsupportedAudioInputError is returned by [Translator.RequestBody] when a user message has the input_audio content
+// part while the backend does not accept the audio input, such as AWS Bedrock.
+type UnsupportedAudioInputError struct {
+	// MessageIndex is the index of the message in the request.
+	MessageIndex int
+	// PartIndex is the index of the content part in the message.
+	PartIndex int
+}
+
+// Param returns the path of the content part in the request, e.g. "messages[1].content[0]".
+func (e *UnsupportedAudioInputError) Param() string {
+	return fmt.Sprintf("messages[%d].content[%d]", e.MessageIndex, e.PartIndex)
+}
+
+// Error implements [error].
+func (e *UnsupportedAudioInputError) Error() string {
+	return fmt.Sprintf("%s: audio input (input_audio) is not supported by the selected backend", e.Param())
+}
+
 // LLMTokenUsage represents the token usage reported usually by the backend API in the response body.
 type LLMTokenUsage struct {
 	// InputTokens is the number of tokens consumed from the input.
@@ -100,4 +194,8 @@ type LLMTokenUsage struct {
 	OutputTokens uint32
 	// TotalTokens is the total number of tokens consumed.
 	TotalTokens uint32
+	// CacheReadInputTokens is the number of the input tokens read from the prompt cache of the backend.
+	CacheReadInputTokens uint32
+	// CacheWriteInputTokens is the number of the input tokens written to the prompt cache of the backend.
+	CacheWriteInputTokens uint32
 }
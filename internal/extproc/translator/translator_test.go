@@ -6,10 +6,16 @@
 package translator
 
 import (
+	"bytes"
+	"io"
+	"strings"
 	"testing"
 
 	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
 	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+
+	"github.com/envoyproxy/ai-gateway/internal/apischema/openai"
 )
 
 func TestIsGoodStatusCode(t *testing.T) {
@@ -32,3 +38,111 @@ func TestSetContentLength(t *testing.T) {
 	require.Len(t, hm.SetHeaders, 1)
 	require.Equal(t, "4", string(hm.SetHeaders[0].Header.RawValue))
 }
+
+func Test_emptyResponseBody(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		respHeaders map[string]string
+		body        io.Reader
+		stream      bool
+		expEmpty    bool
+	}{
+		{name: "200 empty", respHeaders: map[string]string{":status": "200"}, body: bytes.NewReader(nil), expEmpty: true},
+		{name: "200 eof", respHeaders: map[string]string{":status": "200"}, body: strings.NewReader(""), expEmpty: true},
+		{name: "200 eof without len", respHeaders: map[string]string{":status": "200"}, body: io.MultiReader(), expEmpty: true},
+		{name: "204", respHeaders: map[string]string{":status": "204"}, body: strings.NewReader("ignored"), expEmpty: true},
+		{name: "304", respHeaders: map[string]string{":status": "304"}, body: strings.NewReader(""), expEmpty: true},
+		{name: "content-length 0", respHeaders: map[string]string{":status": "200", "content-length": "0"}, body: io.MultiReader(), expEmpty: true},
+		{
+			// The response to a HEAD request has the content-length of the body that would have been sent.
+			name:        "head",
+			respHeaders: map[string]string{":status": "200", "content-length": "123", "content-type": "application/json"},
+			body:        io.MultiReader(),
+			expEmpty:    true,
+		},
+		{name: "200 body", respHeaders: map[string]string{":status": "200"}, body: io.MultiReader(strings.NewReader("{}"))},
+		{name: "streamed empty chunk", respHeaders: map[string]string{":status": "200"}, body: io.MultiReader(), stream: true},
+		{name: "streamed 204", respHeaders: map[string]string{":status": "204"}, body: io.MultiReader(), stream: true, expEmpty: true},
+		{name: "streamed error", respHeaders: map[string]string{":status": "500"}, body: io.MultiReader(), stream: true, expEmpty: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			body, empty := emptyResponseBody(tc.respHeaders, tc.body, tc.stream)
+			require.Equal(t, tc.expEmpty, empty)
+			if !empty && !tc.stream {
+				// The peeked byte is not lost.
+				b, err := io.ReadAll(body)
+				require.NoError(t, err)
+				require.Equal(t, "{}", string(b))
+			}
+		})
+	}
+}
+
+func TestTranslator_ResponseBody_EmptyBody(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func() Translator
+	}{
+		{name: "openai", new: NewChatCompletionOpenAIToOpenAITranslator},
+		{name: "aws bedrock", new: func() Translator {
+			return NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyIgnore, "", nil, nil)
+		}},
+		{name: "aws bedrock invoke", new: func() Translator {
+			return NewChatCompletionOpenAIToAWSBedrockInvokeModelTranslator(0, UnsupportedFieldPolicyIgnore, "", nil)
+		}},
+		{name: "cohere", new: NewChatCompletionOpenAIToCohereTranslator},
+		{name: "mistral", new: func() Translator { return NewChatCompletionOpenAIToMistralTranslator(UnsupportedFieldPolicyIgnore) }},
+		{name: "converse to aws bedrock", new: NewConverseAWSBedrockToAWSBedrockTranslator},
+		{name: "converse to openai", new: NewConverseAWSBedrockToOpenAITranslator},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, respHeaders := range []map[string]string{
+				{":status": "200"},
+				{":status": "204"},
+				{":status": "200", "content-length": "0"},
+				{":status": "200", "content-length": "123", "content-type": "application/json"},
+				{":status": "502", "content-type": "application/json"},
+			} {
+				hm, bm, usage, err := tc.new().ResponseBody(respHeaders, bytes.NewReader(nil), true)
+				require.NoError(t, err, respHeaders)
+				require.Nil(t, hm, respHeaders)
+				require.Nil(t, bm, respHeaders)
+				require.Equal(t, LLMTokenUsage{}, usage, respHeaders)
+			}
+		})
+	}
+}
+
+func BenchmarkRequestBody(b *testing.B) {
+	content := openai.StringOrUserRoleContentUnion{Value: "What is the capital of France? Answer in one word."}
+	req := &openai.ChatCompletionRequest{
+		Model:     "gpt-4o-mini",
+		MaxTokens: ptr.To[int64](1024),
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			{Type: openai.ChatMessageRoleSystem, Value: openai.ChatCompletionSystemMessageParam{
+				Role: openai.ChatMessageRoleSystem, Content: openai.StringOrArray{Value: "You are a helpful assistant."},
+			}},
+			{Type: openai.ChatMessageRoleUser, Value: openai.ChatCompletionUserMessageParam{
+				Role: openai.ChatMessageRoleUser, Content: content,
+			}},
+		},
+	}
+	for _, bc := range []struct {
+		name    string
+		factory func() Translator
+	}{
+		{name: "openai", factory: NewChatCompletionOpenAIToOpenAITranslator},
+		{name: "awsbedrock", factory: func() Translator {
+			return NewChatCompletionOpenAIToAWSBedrockTranslator(nil, 0, UnsupportedFieldPolicyReject, "", nil, nil)
+		}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if _, _, _, err := bc.factory().RequestBody(req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -7,7 +7,10 @@ package extproc
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"strings"
@@ -24,92 +27,263 @@ type ConfigReceiver interface {
 }
 
 type configWatcher struct {
+	// lastMod is the modification time of the file at path last loaded, including the invalid one so that it is not
+	// reported on every tick.
 	lastMod         time.Time
 	path            string
 	rcv             ConfigReceiver
 	l               *slog.Logger
 	current         string
 	usingDefaultCfg bool
+	// loaded is true once the config at path has been loaded successfully. After that, the file disappearing is
+	// the config becoming unavailable rather than the extproc being unconfigured.
+	loaded bool
+	// staticPath is the path to the static config file merged with the one at path. Optional.
+	staticPath    string
+	staticLastMod time.Time
+}
+
+// ErrConfigFileUnavailable is returned when a config file cannot be read, for example, when the static config file
+// does not exist, or the config file is deleted after it has been loaded. Unlike an invalid config, which keeps the last valid config active, the watcher cannot make progress
+// until the file becomes available again.
+var ErrConfigFileUnavailable = errors.New("config file is unavailable")
+
+// ConfigWatcher periodically checks the config files for changes and updates the [ConfigReceiver].
+// This is created by [NewConfigWatcher] or [NewMergedConfigWatcher] and driven by [ConfigWatcher.Run].
+type ConfigWatcher struct {
+	cw   *configWatcher
+	tick time.Duration
+}
+
+// NewConfigWatcher creates a [ConfigWatcher] for the given path and Receiver, and loads the initial config.
+func NewConfigWatcher(ctx context.Context, path string, rcv ConfigReceiver, l *slog.Logger, tick time.Duration) (*ConfigWatcher, error) {
+	return newConfigWatcher(ctx, &configWatcher{rcv: rcv, l: l, path: path}, tick)
+}
+
+// NewMergedConfigWatcher is the same as [NewConfigWatcher] except that the config loaded from the path is merged
+// with the static config at the staticPath as documented in [StartMergedConfigWatcher].
+func NewMergedConfigWatcher(ctx context.Context, staticPath, path string, rcv ConfigReceiver, l *slog.Logger, tick time.Duration) (*ConfigWatcher, error) {
+	return newConfigWatcher(ctx, &configWatcher{rcv: rcv, l: l, path: path, staticPath: staticPath}, tick)
+}
+
+func newConfigWatcher(ctx context.Context, cw *configWatcher, tick time.Duration) (*ConfigWatcher, error) {
+	if err := cw.loadConfig(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load initial config: %w", err)
+	}
+	return &ConfigWatcher{cw: cw, tick: tick}, nil
 }
 
 // StartConfigWatcher starts a watcher for the given path and Receiver.
 // Periodically checks the file for changes and calls the Receiver's UpdateConfig method.
 func StartConfigWatcher(ctx context.Context, path string, rcv ConfigReceiver, l *slog.Logger, tick time.Duration) error {
-	cw := &configWatcher{rcv: rcv, l: l, path: path}
-
-	if err := cw.loadConfig(ctx); err != nil {
-		return fmt.Errorf("failed to load initial config: %w", err)
+	w, err := NewConfigWatcher(ctx, path, rcv, l, tick)
+	if err != nil {
+		return err
 	}
+	go w.runForever(ctx)
+	return nil
+}
 
-	l.Info("start watching the config file", slog.String("path", path), slog.String("interval", tick.String()))
-	go cw.watch(ctx, tick)
+// StartMergedConfigWatcher is the same as [StartConfigWatcher] except that the config loaded from the path is merged
+// with the static config at the staticPath as documented in [mergeConfigs]. Both files are watched, and the merge is
+// re-applied whenever either of them changes. When either file is invalid or the two conflict, the last valid merged
+// config is kept. When the file at the path does not exist yet, the static config is used as is.
+func StartMergedConfigWatcher(ctx context.Context, staticPath, path string, rcv ConfigReceiver, l *slog.Logger, tick time.Duration) error {
+	w, err := NewMergedConfigWatcher(ctx, staticPath, path, rcv, l, tick)
+	if err != nil {
+		return err
+	}
+	go w.runForever(ctx)
 	return nil
 }
 
-// watch periodically checks the file for changes and calls the update method.
-func (cw *configWatcher) watch(ctx context.Context, tick time.Duration) {
-	ticker := time.NewTicker(tick)
+// Load checks the config files for changes once and updates the Receiver if so.
+func (w *ConfigWatcher) Load(ctx context.Context) error {
+	return w.cw.loadConfig(ctx)
+}
+
+// Run periodically checks the config files for changes until the context is done, in which case this returns nil.
+// Invalid configs are logged and the last valid config is kept. When a config file cannot be read, this stops
+// watching and returns the error wrapping [ErrConfigFileUnavailable] so that the caller can decide how to recover.
+func (w *ConfigWatcher) Run(ctx context.Context) error {
+	w.cw.l.Info("start watching the config file", slog.String("path", w.cw.path), slog.String("staticPath", w.cw.staticPath),
+		slog.String("interval", w.tick.String()))
+	ticker := time.NewTicker(w.tick)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
-			cw.l.Info("stop watching the config file", slog.String("path", cw.path))
-			return
+			w.cw.l.Info("stop watching the config file", slog.String("path", w.cw.path))
+			return nil
 		case <-ticker.C:
-			if err := cw.loadConfig(ctx); err != nil {
-				cw.l.Error("failed to update config", slog.String("error", err.Error()))
+			if err := w.cw.loadConfig(ctx); err != nil {
+				if errors.Is(err, ErrConfigFileUnavailable) {
+					return err
+				}
+				w.cw.l.Error("failed to update config", slog.String("error", err.Error()))
 			}
 		}
 	}
 }
 
-// loadConfig loads a new config from the given path and updates the Receiver by
-// calling the [Receiver.Load].
-func (cw *configWatcher) loadConfig(ctx context.Context) error {
-	var (
-		cfg *filterapi.Config
-		raw []byte
-	)
+// runForever runs the watcher until the context is done, resuming it on the next tick whenever a config file is
+// unavailable.
+func (w *ConfigWatcher) runForever(ctx context.Context) {
+	for {
+		err := w.Run(ctx)
+		if err == nil {
+			return
+		}
+		w.cw.l.Error("failed to update config", slog.String("error", err.Error()))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.tick):
+		}
+	}
+}
 
-	stat, err := os.Stat(cw.path)
-	switch {
-	case err != nil && os.IsNotExist(err):
-		// If the file does not exist, do not fail (which could lead to the extproc process to terminate).
-		// Instead, load the default configuration and keep running unconfigured.
-		cfg, raw = filterapi.MustLoadDefaultConfig()
-	case err != nil:
+// loadConfig loads a new config from the given path, merges it with the static config if any, and updates
+// the Receiver by calling the [Receiver.Load].
+//
+// Both files are read again whenever either of them changes, and nothing is applied unless both files and the merged
+// config are valid, so that the change of either file is never lost when the other one is invalid at the time.
+func (cw *configWatcher) loadConfig(ctx context.Context) error {
+	changed, err := cw.checkChanged()
+	if err != nil || !changed {
 		return err
 	}
 
-	if cfg != nil {
-		if cw.usingDefaultCfg { // Do not re-reload the same thing on every tick.
-			return nil
+	var dynamic, static *filterapi.Config
+	var dynamicRaw, staticRaw []byte
+	if !cw.usingDefaultCfg {
+		cw.l.Info("loading a new config", slog.String("path", cw.path))
+		if dynamic, dynamicRaw, err = cw.readConfig(cw.path); err != nil {
+			if errors.Is(err, ErrConfigFileUnavailable) {
+				cw.lastMod = time.Time{} // Read the file again once it becomes available.
+			}
+			return err
 		}
-		cw.l.Info("config file does not exist; loading default config", slog.String("path", cw.path))
-		cw.lastMod = time.Now()
-		cw.usingDefaultCfg = true
 	} else {
-		cw.usingDefaultCfg = false
-		if stat.ModTime().Sub(cw.lastMod) <= 0 {
-			return nil
-		}
-		cw.l.Info("loading a new config", slog.String("path", cw.path))
-		cw.lastMod = stat.ModTime()
-		cfg, raw, err = filterapi.UnmarshalConfigYaml(cw.path)
-		if err != nil {
+		cw.l.Info("config file does not exist; loading default config", slog.String("path", cw.path))
+	}
+	if cw.staticPath != "" {
+		cw.l.Info("loading a new static config", slog.String("path", cw.staticPath))
+		if static, staticRaw, err = cw.readConfig(cw.staticPath); err != nil {
+			if errors.Is(err, ErrConfigFileUnavailable) {
+				cw.staticLastMod = time.Time{} // Read the file again once it becomes available.
+			}
 			return err
 		}
 	}
 
+	cfg := dynamic
+	switch {
+	case cw.staticPath != "":
+		var uuid string
+		if dynamic == nil {
+			cfg = static
+		} else {
+			if cfg, err = mergeConfigs(static, dynamic); err != nil {
+				return fmt.Errorf("failed to merge config %s into static config %s: %w", cw.path, cw.staticPath, err)
+			}
+			if err = cw.validate(cw.path+" merged with "+cw.staticPath, cfg, nil); err != nil {
+				return err
+			}
+			uuid = dynamic.UUID
+		}
+		// The UUID identifies the version of the config, so it must change when only the static config does.
+		staticHash := sha256.Sum256(staticRaw)
+		cfg.UUID = fmt.Sprintf("%s+%x", uuid, staticHash[:8])
+	case dynamic == nil:
+		cfg, _ = filterapi.MustLoadDefaultConfig()
+	}
+	if err = cw.rcv.LoadConfig(ctx, cfg); err != nil {
+		return err
+	}
+	cw.loaded = cw.loaded || dynamic != nil
+
 	// Print the diff between the old and new config.
 	if cw.l.Enabled(ctx, slog.LevelDebug) {
-		// Re-hydrate the current config file for later diffing.
 		previous := cw.current
-		cw.current = string(raw)
+		cw.current = string(dynamicRaw)
 		cw.diff(previous, cw.current)
 	}
+	return nil
+}
 
-	return cw.rcv.LoadConfig(ctx, cfg)
+// checkChanged returns true if either of the config files has changed since they were last loaded, and records
+// their modification times. The file at the path not existing is a change from the file existing and vice versa,
+// unless the file has been loaded before, in which case the file is unavailable.
+func (cw *configWatcher) checkChanged() (bool, error) {
+	var changed bool
+	stat, err := os.Stat(cw.path)
+	switch {
+	case err != nil && os.IsNotExist(err) && cw.loaded:
+		// Silently falling back to the default config would drop all the routes, so keep the last config instead.
+		cw.lastMod = time.Time{} // Read the file again once it becomes available.
+		return false, fmt.Errorf("%w: %w", ErrConfigFileUnavailable, err)
+	case err != nil && os.IsNotExist(err):
+		// If the file does not exist, do not fail (which could lead to the extproc process to terminate).
+		// Instead, load the default configuration and keep running unconfigured.
+		changed = !cw.usingDefaultCfg
+		cw.usingDefaultCfg = true
+		cw.lastMod = time.Time{}
+	case err != nil:
+		return false, fmt.Errorf("%w: %w", ErrConfigFileUnavailable, err)
+	default:
+		changed = cw.usingDefaultCfg || stat.ModTime().Sub(cw.lastMod) > 0
+		cw.usingDefaultCfg = false
+		cw.lastMod = stat.ModTime()
+	}
+	if cw.staticPath != "" {
+		// Unlike the config at the path, the static config file must exist.
+		stat, err = os.Stat(cw.staticPath)
+		if err != nil {
+			return false, fmt.Errorf("%w: %w", ErrConfigFileUnavailable, err)
+		}
+		changed = changed || stat.ModTime().Sub(cw.staticLastMod) > 0
+		cw.staticLastMod = stat.ModTime()
+	}
+	return changed, nil
+}
+
+// readConfig reads and validates the config at the path. The error wraps [ErrConfigFileUnavailable] if the file
+// cannot be read.
+func (cw *configWatcher) readConfig(path string) (*filterapi.Config, []byte, error) {
+	cfg, raw, err := filterapi.UnmarshalConfigYaml(path)
+	if err != nil {
+		if isReadError(err) {
+			return nil, nil, fmt.Errorf("%w: %w", ErrConfigFileUnavailable, err)
+		}
+		return nil, nil, err
+	}
+	// Validate the config before swapping it in so that a bad reload keeps the previous config active.
+	if err = cw.validate(path, cfg, raw); err != nil {
+		return nil, nil, err
+	}
+	return cfg, raw, nil
+}
+
+// isReadError returns true if the error is from reading the config file rather than from parsing it.
+func isReadError(err error) bool {
+	var pathErr *fs.PathError
+	return errors.As(err, &pathErr)
+}
+
+// validate validates the config loaded from the path, and logs the structured errors if it is invalid.
+func (cw *configWatcher) validate(path string, cfg *filterapi.Config, raw []byte) error {
+	if err := ValidateConfig(cfg, raw); err != nil {
+		var validationErrs ConfigValidationErrors
+		if errors.As(err, &validationErrs) {
+			for _, e := range validationErrs {
+				cw.l.Error("invalid config", slog.String("path", path), slog.Int("line", e.Line),
+					slog.String("field", e.Field), slog.String("error", e.Message))
+			}
+		}
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return nil
 }
 
 func (cw *configWatcher) diff(oldConfig, newConfig string) {
@@ -133,7 +133,7 @@ rules:
 
 	// Initial loading should have happened.
 	require.Eventually(t, func() bool {
-		return rcv.getConfig() != defaultCfg
+		return len(rcv.getConfig().Rules) == 2
 	}, 1*time.Second, tickInterval)
 	firstCfg := rcv.getConfig()
 	require.NotNil(t, firstCfg)
@@ -194,3 +194,252 @@ func TestDiff(t *testing.T) {
 		return strings.Contains(buf.String(), expectedLog)
 	}, 1*time.Second, 100*time.Millisecond, buf.String())
 }
+
+func TestStartConfigWatcher_invalidConfig(t *testing.T) {
+	path := t.TempDir() + "/config.yaml"
+	rcv := &mockReceiver{}
+	const tickInterval = time.Millisecond * 100
+	logger, buf := newTestLoggerWithBuffer()
+
+	require.NoError(t, os.WriteFile(path, []byte("schema:\n  name: Foo\n"), 0o600))
+	err := StartConfigWatcher(t.Context(), path, rcv, logger, tickInterval)
+	require.ErrorContains(t, err, `line 2: schema.name: unknown API schema name "Foo"`)
+	require.Nil(t, rcv.getConfig())
+
+	require.NoError(t, os.WriteFile(path, []byte("schema:\n  name: OpenAI\n"), 0o600))
+	require.NoError(t, StartConfigWatcher(t.Context(), path, rcv, logger, tickInterval))
+	validCfg := rcv.getConfig()
+	require.NotNil(t, validCfg)
+
+	// A bad reload keeps the previous config active and logs the structured errors.
+	require.NoError(t, os.WriteFile(path, []byte("schema:\n  name: OpenAI\nrules:\n- headers:\n  - value: foo\n"), 0o600))
+	// Ensure the modification time is updated as the file system might have a coarse granularity.
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `msg="invalid config" path=`+path+` line=5 field=rules[0].headers[0].name error="header match name must not be empty"`)
+	}, 1*time.Second, tickInterval, buf.String())
+	require.Same(t, validCfg, rcv.getConfig())
+	require.Equal(t, int32(1), rcv.loadCount.Load())
+}
+
+func TestStartMergedConfigWatcher(t *testing.T) {
+	tmpdir := t.TempDir()
+	staticPath, path := tmpdir+"/static.yaml", tmpdir+"/config.yaml"
+	rcv := &mockReceiver{}
+	const tickInterval = time.Millisecond * 100
+	logger, buf := newTestLoggerWithBuffer()
+	// Ensure the modification time is updated as the file system might have a coarse granularity.
+	modTime := time.Now()
+	writeFile := func(path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		modTime = modTime.Add(time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	requireBackends := func(exp ...string) {
+		require.EventuallyWithT(t, func(c *assert.CollectT) {
+			var names []string
+			for _, r := range rcv.getConfig().Rules {
+				for _, b := range r.Backends {
+					names = append(names, b.Name+":"+string(b.Schema.Name))
+				}
+			}
+			assert.Equal(c, exp, names)
+		}, 1*time.Second, tickInterval, buf.String())
+	}
+
+	// The static config file must exist.
+	err := StartMergedConfigWatcher(t.Context(), staticPath, path, rcv, logger, tickInterval)
+	require.ErrorContains(t, err, "failed to load initial config")
+
+	writeFile(staticPath, `
+schema:
+  name: OpenAI
+modelNameHeaderKey: x-model-name
+rules:
+- backends:
+  - name: builtin
+    schema:
+      name: OpenAI
+  - name: shared
+    schema:
+      name: OpenAI
+`)
+	require.NoError(t, StartMergedConfigWatcher(t.Context(), staticPath, path, rcv, logger, tickInterval))
+	// The static config is used as is when the config file does not exist.
+	requireBackends("builtin:OpenAI", "shared:OpenAI")
+
+	writeFile(path, `
+schema:
+  name: OpenAI
+rules:
+- backends:
+  - name: shared
+    schema:
+      name: AWSBedrock
+`)
+	requireBackends("shared:AWSBedrock", "builtin:OpenAI", "shared:AWSBedrock")
+
+	// The merge is re-applied when the static config changes.
+	writeFile(staticPath, `
+schema:
+  name: OpenAI
+rules:
+- backends:
+  - name: builtin
+    schema:
+      name: OpenAI
+`)
+	requireBackends("shared:AWSBedrock", "builtin:OpenAI")
+	mergedCfg := rcv.getConfig()
+	loadCount := rcv.loadCount.Load()
+
+	// The conflicts and the invalid files keep the last merged config.
+	writeFile(path, "schema:\n  name: AWSBedrock\n")
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "schema conflicts between the static and the dynamic configurations")
+	}, 1*time.Second, tickInterval, buf.String())
+	writeFile(staticPath, "schema:\n  name: Foo\n")
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), `msg="invalid config" path=`+staticPath)
+	}, 1*time.Second, tickInterval, buf.String())
+	require.Same(t, mergedCfg, rcv.getConfig())
+	require.Equal(t, loadCount, rcv.loadCount.Load())
+}
+
+func TestConfigWatcher_Run(t *testing.T) {
+	tmpdir := t.TempDir()
+	staticPath, path := tmpdir+"/static.yaml", tmpdir+"/config.yaml"
+	rcv := &mockReceiver{}
+	const tickInterval = time.Millisecond * 10
+	logger, buf := newTestLoggerWithBuffer()
+	// Ensure the modification time is updated as the file system might have a coarse granularity.
+	modTime := time.Now()
+	touch := func(path string) {
+		modTime = modTime.Add(time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	const staticConfig = "schema:\n  name: OpenAI\nrules:\n- backends:\n  - name: builtin\n    schema:\n      name: OpenAI\n"
+	require.NoError(t, os.WriteFile(staticPath, []byte(staticConfig), 0o600))
+
+	w, err := NewMergedConfigWatcher(t.Context(), staticPath, path, rcv, logger, tickInterval)
+	require.NoError(t, err)
+	require.Equal(t, "builtin", rcv.getConfig().Rules[0].Backends[0].Name)
+
+	t.Run("invalid config keeps running", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("schema:\n  name: Foo\n"), 0o600))
+		touch(path)
+		defer func() { require.NoError(t, os.Remove(path)) }()
+		ctx, cancel := context.WithCancel(t.Context())
+		errCh := make(chan error, 1)
+		go func() { errCh <- w.Run(ctx) }()
+		require.Eventually(t, func() bool {
+			return strings.Contains(buf.String(), `msg="invalid config" path=`+path)
+		}, 1*time.Second, tickInterval, buf.String())
+		cancel()
+		require.NoError(t, <-errCh)
+	})
+
+	t.Run("static config file unavailable", func(t *testing.T) {
+		require.NoError(t, os.Remove(staticPath))
+		err := w.Run(t.Context())
+		require.ErrorIs(t, err, ErrConfigFileUnavailable)
+		require.ErrorIs(t, w.Load(t.Context()), ErrConfigFileUnavailable)
+
+		// The watcher loads the file again once it becomes available.
+		require.NoError(t, os.WriteFile(staticPath, []byte(staticConfig), 0o600))
+		touch(staticPath)
+		require.NoError(t, w.Load(t.Context()))
+	})
+
+	t.Run("config file unreadable", func(t *testing.T) {
+		// The directory at the path cannot be read as a file.
+		require.NoError(t, os.Mkdir(path, 0o700))
+		touch(path)
+		defer func() { require.NoError(t, os.Remove(path)) }()
+		err := w.Load(t.Context())
+		require.ErrorIs(t, err, ErrConfigFileUnavailable)
+	})
+}
+
+func TestConfigWatcher_Load_merged(t *testing.T) {
+	tmpdir := t.TempDir()
+	staticPath, path := tmpdir+"/static.yaml", tmpdir+"/config.yaml"
+	rcv := &mockReceiver{}
+	logger, buf := newTestLoggerWithBuffer()
+	// Ensure the modification time is updated as the file system might have a coarse granularity.
+	modTime := time.Now()
+	writeFile := func(path, content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		modTime = modTime.Add(time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	backendNames := func() (names []string) {
+		for _, r := range rcv.getConfig().Rules {
+			for _, b := range r.Backends {
+				names = append(names, b.Name)
+			}
+		}
+		return
+	}
+	const staticConfig = "schema:\n  name: OpenAI\nrules:\n- backends:\n  - name: builtin\n    schema:\n      name: OpenAI\n"
+	writeFile(staticPath, staticConfig)
+	writeFile(path, "uuid: v1\nschema:\n  name: OpenAI\nrules:\n- backends:\n  - name: foo\n    schema:\n      name: OpenAI\n")
+	w, err := NewMergedConfigWatcher(t.Context(), staticPath, path, rcv, logger, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo", "builtin"}, backendNames())
+	uuid := rcv.getConfig().UUID
+	require.True(t, strings.HasPrefix(uuid, "v1+"), uuid)
+
+	// The change of the config is not lost while the static config is invalid.
+	writeFile(path, "uuid: v2\nschema:\n  name: OpenAI\nrules:\n- backends:\n  - name: bar\n    schema:\n      name: OpenAI\n")
+	writeFile(staticPath, "schema:\n  name: Foo\n")
+	require.ErrorContains(t, w.Load(t.Context()), "invalid config "+staticPath)
+	require.Equal(t, []string{"foo", "builtin"}, backendNames())
+	writeFile(staticPath, staticConfig)
+	require.NoError(t, w.Load(t.Context()))
+	require.Equal(t, []string{"bar", "builtin"}, backendNames())
+	uuid = rcv.getConfig().UUID
+	require.True(t, strings.HasPrefix(uuid, "v2+"), uuid)
+
+	// The UUID changes when only the static config does.
+	writeFile(staticPath, staticConfig+"- backends:\n  - name: builtin2\n    schema:\n      name: OpenAI\n")
+	require.NoError(t, w.Load(t.Context()))
+	require.Equal(t, []string{"bar", "builtin", "builtin2"}, backendNames())
+	require.True(t, strings.HasPrefix(rcv.getConfig().UUID, "v2+"))
+	require.NotEqual(t, uuid, rcv.getConfig().UUID)
+	loadCount := rcv.loadCount.Load()
+
+	// The merged config is validated as well, e.g., both files cannot have the default rule.
+	writeFile(staticPath, "schema:\n  name: OpenAI\nrules:\n- default: true\n  backends:\n  - name: builtin\n    schema:\n      name: OpenAI\n")
+	writeFile(path, "schema:\n  name: OpenAI\nrules:\n- default: true\n  backends:\n  - name: bar\n    schema:\n      name: OpenAI\n")
+	require.ErrorContains(t, w.Load(t.Context()), "invalid config "+path+" merged with "+staticPath)
+	require.Contains(t, buf.String(), `field=rules[1].default error="rules[0] is already the default rule"`)
+	require.Equal(t, loadCount, rcv.loadCount.Load())
+	// Nothing is loaded again until either file changes.
+	require.NoError(t, w.Load(t.Context()))
+	require.Equal(t, loadCount, rcv.loadCount.Load())
+}
+
+func TestConfigWatcher_Load_deleted(t *testing.T) {
+	path := t.TempDir() + "/config.yaml"
+	rcv := &mockReceiver{}
+	logger, _ := newTestLoggerWithBuffer()
+	const config = "schema:\n  name: OpenAI\nrules:\n- backends:\n  - name: foo\n    schema:\n      name: OpenAI\n"
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+	w, err := NewConfigWatcher(t.Context(), path, rcv, logger, time.Minute)
+	require.NoError(t, err)
+	loaded := rcv.getConfig()
+	require.Equal(t, "foo", loaded.Rules[0].Backends[0].Name)
+
+	// The config file disappearing after it has been loaded does not fall back to the default config.
+	require.NoError(t, os.Remove(path))
+	require.ErrorIs(t, w.Load(t.Context()), ErrConfigFileUnavailable)
+	require.ErrorIs(t, w.Load(t.Context()), ErrConfigFileUnavailable)
+	require.Same(t, loaded, rcv.getConfig())
+
+	// The file is loaded again once it becomes available even with the same modification time.
+	require.NoError(t, os.WriteFile(path, []byte(config), 0o600))
+	require.NoError(t, w.Load(t.Context()))
+	require.NotSame(t, loaded, rcv.getConfig())
+	require.Equal(t, int32(2), rcv.loadCount.Load())
+}
@@ -21,6 +21,7 @@ const (
 	celInputTokensKey  = "input_tokens"
 	celOutputTokensKey = "output_tokens"
 	celTotalTokensKey  = "total_tokens"
+	celUserKey         = "user"
 )
 
 var env *cel.Env
@@ -33,6 +34,7 @@ func init() {
 		cel.Variable(celInputTokensKey, cel.UintType),
 		cel.Variable(celOutputTokensKey, cel.UintType),
 		cel.Variable(celTotalTokensKey, cel.UintType),
+		cel.Variable(celUserKey, cel.StringType),
 	)
 	if err != nil {
 		panic(fmt.Sprintf("cannot create CEL environment: %v", err))
@@ -52,7 +54,7 @@ func NewProgram(expr string) (prog cel.Program, err error) {
 	}
 
 	// Sanity check by evaluating the expression with some dummy values.
-	_, err = EvaluateProgram(prog, "dummy", "dummy", 0, 0, 0)
+	_, err = EvaluateProgram(prog, "dummy", "dummy", "dummy", 0, 0, 0)
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate CEL expression: %w", err)
 	}
@@ -60,10 +62,11 @@ func NewProgram(expr string) (prog cel.Program, err error) {
 }
 
 // EvaluateProgram evaluates the given CEL program with the given variables.
-func EvaluateProgram(prog cel.Program, modelName, backend string, inputTokens, outputTokens, totalTokens uint32) (uint64, error) {
+func EvaluateProgram(prog cel.Program, modelName, backend, user string, inputTokens, outputTokens, totalTokens uint32) (uint64, error) {
 	out, _, err := prog.Eval(map[string]interface{}{
 		celModelNameKey:    modelName,
 		celBackendKey:      backend,
+		celUserKey:         user,
 		celInputTokensKey:  inputTokens,
 		celOutputTokensKey: outputTokens,
 		celTotalTokensKey:  totalTokens,
@@ -28,11 +28,22 @@ func TestNewProgram(t *testing.T) {
 	t.Run("variables", func(t *testing.T) {
 		prog, err := NewProgram("model == 'cool_model' ?  input_tokens * output_tokens : total_tokens")
 		require.NoError(t, err)
-		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3)
+		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", "", 100, 2, 3)
 		require.NoError(t, err)
 		require.Equal(t, uint64(200), v)
 
-		v, err = EvaluateProgram(prog, "not_cool_model", "cool_backend", 100, 2, 3)
+		v, err = EvaluateProgram(prog, "not_cool_model", "cool_backend", "", 100, 2, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), v)
+	})
+	t.Run("user", func(t *testing.T) {
+		prog, err := NewProgram("user == 'free_tier_user' ? total_tokens * uint(2) : total_tokens")
+		require.NoError(t, err)
+		v, err := EvaluateProgram(prog, "cool_model", "cool_backend", "free_tier_user", 100, 2, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint64(6), v)
+
+		v, err = EvaluateProgram(prog, "cool_model", "cool_backend", "", 100, 2, 3)
 		require.NoError(t, err)
 		require.Equal(t, uint64(3), v)
 	})
@@ -61,13 +72,13 @@ func TestEvaluateProgram(t *testing.T) {
 	t.Run("signed integer negative", func(t *testing.T) {
 		prog, err := NewProgram("int(input_tokens) - int(output_tokens)")
 		require.NoError(t, err)
-		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", "", 100, 2000, 3)
 		require.ErrorContains(t, err, "CEL expression result is negative (-1900)")
 	})
 	t.Run("unsigned integer overflow", func(t *testing.T) {
 		prog, err := NewProgram("input_tokens - output_tokens")
 		require.NoError(t, err)
-		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2000, 3)
+		_, err = EvaluateProgram(prog, "cool_model", "cool_backend", "", 100, 2000, 3)
 		require.ErrorContains(t, err, "failed to evaluate CEL expression: unsigned integer overflow")
 	})
 	t.Run("ensure concurrency safety", func(t *testing.T) {
@@ -80,7 +91,7 @@ func TestEvaluateProgram(t *testing.T) {
 		for i := 0; i < 100; i++ {
 			go func() {
 				defer wg.Done()
-				v, err := EvaluateProgram(prog, "cool_model", "cool_backend", 100, 2, 3)
+				v, err := EvaluateProgram(prog, "cool_model", "cool_backend", "", 100, 2, 3)
 				require.NoError(t, err)
 				require.Equal(t, uint64(200), v)
 			}()
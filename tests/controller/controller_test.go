@@ -11,10 +11,18 @@
 package controller
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -24,17 +32,27 @@ import (
 	"go.uber.org/goleak"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1a2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gwapiv1b1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	aigv1a1 "github.com/envoyproxy/ai-gateway/api/v1alpha1"
 	"github.com/envoyproxy/ai-gateway/internal/controller"
+	"github.com/envoyproxy/ai-gateway/internal/extensionserver"
 	internaltesting "github.com/envoyproxy/ai-gateway/internal/testing"
 	testsinternal "github.com/envoyproxy/ai-gateway/tests/internal"
 )
@@ -87,6 +105,11 @@ func TestStartControllers(t *testing.T) {
 	}
 	t.Run("setup routes", func(t *testing.T) {
 		for _, route := range []string{"route1", "route2"} {
+			// route1 routes the unmatched requests to backend2, and route2 rejects them.
+			defaultBackend, disableDefaultRoute := "backend2", false
+			if route == "route2" {
+				defaultBackend, disableDefaultRoute = "", true
+			}
 			err := c.Create(ctx, &aigv1a1.AIGatewayRoute{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: route, Namespace: "default",
@@ -104,8 +127,8 @@ func TestStartControllers(t *testing.T) {
 						{
 							Matches: []aigv1a1.AIGatewayRouteRuleMatch{},
 							BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-								{Name: "backend1", Weight: 1},
-								{Name: "backend2", Weight: 1},
+								{Name: "backend1", Weight: ptr.To[int32](1)},
+								{Name: "backend2", Weight: ptr.To[int32](1)},
 							},
 						},
 					},
@@ -115,6 +138,8 @@ func TestStartControllers(t *testing.T) {
 							Replicas: ptr.To[int32](5), Resources: resourceReq,
 						},
 					},
+					DefaultBackend:      defaultBackend,
+					DisableDefaultRoute: disableDefaultRoute,
 				},
 			})
 			require.NoError(t, err)
@@ -221,6 +246,13 @@ func TestStartControllers(t *testing.T) {
 				require.Len(t, httpRoute.Spec.Rules[1].Matches[0].Headers, 1)
 				require.Equal(t, "x-ai-eg-selected-backend", string(httpRoute.Spec.Rules[1].Matches[0].Headers[0].Name))
 				require.Equal(t, "backend2.default", httpRoute.Spec.Rules[1].Matches[0].Headers[0].Value)
+				require.Equal(t, "/", *httpRoute.Spec.Rules[2].Matches[0].Path.Value)
+				if route == "route1" {
+					require.Len(t, httpRoute.Spec.Rules[2].BackendRefs, 1)
+					require.Equal(t, "backend2", string(httpRoute.Spec.Rules[2].BackendRefs[0].Name))
+				} else {
+					require.Empty(t, httpRoute.Spec.Rules[2].BackendRefs)
+				}
 
 				// Check all rule has the host rewrite filter.
 				for _, rule := range httpRoute.Spec.Rules {
@@ -248,6 +280,71 @@ func TestStartControllers(t *testing.T) {
 		}, 30*time.Second, 200*time.Millisecond)
 	})
 
+	t.Run("hostname rewrite disabled", func(t *testing.T) {
+		setHostnameRewrite := func(rewrite aigv1a1.HostnameRewrite) {
+			var backend aigv1a1.AIServiceBackend
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "backend2", Namespace: "default"}, &backend))
+			backend.Spec.HostnameRewrite = rewrite
+			require.NoError(t, c.Update(ctx, &backend))
+		}
+		// ruleFilters returns the number of filters of the rules of route1: backend1, backend2, and the default rule
+		// routing to backend2.
+		ruleFilters := func() []int {
+			var httpRoute gwapiv1.HTTPRoute
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "route1", Namespace: "default"}, &httpRoute))
+			var filters []int
+			for _, rule := range httpRoute.Spec.Rules {
+				filters = append(filters, len(rule.Filters))
+			}
+			return filters
+		}
+
+		setHostnameRewrite(aigv1a1.HostnameRewriteDisabled)
+		require.Eventually(t, func() bool {
+			filters := ruleFilters()
+			t.Logf("filters: %v", filters)
+			return slices.Equal(filters, []int{1, 0, 0})
+		}, 30*time.Second, 200*time.Millisecond)
+
+		setHostnameRewrite(aigv1a1.HostnameRewriteEnabled)
+		require.Eventually(t, func() bool {
+			filters := ruleFilters()
+			t.Logf("filters: %v", filters)
+			return slices.Equal(filters, []int{1, 1, 1})
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("health check", func(t *testing.T) {
+		var backend aigv1a1.AIServiceBackend
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "backend1", Namespace: "default"}, &backend))
+		backend.Spec.TrafficPolicy = &aigv1a1.AIServiceBackendTrafficPolicy{
+			HealthCheck: &aigv1a1.AIServiceBackendHealthCheck{Path: "/health", ExpectedStatuses: []int32{200, 401}},
+		}
+		require.NoError(t, c.Update(ctx, &backend))
+
+		// The health check of backend1 is passed to the extension server for the first rule of route1, with the defaults
+		// applied by the API server.
+		require.Eventually(t, func() bool {
+			var httpRoute gwapiv1.HTTPRoute
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "route1", Namespace: "default"}, &httpRoute))
+			var policies []*aigv1a1.AIServiceBackendTrafficPolicy
+			require.NoError(t, json.Unmarshal([]byte(httpRoute.Annotations[extensionserver.TrafficPoliciesAnnotationKey]), &policies))
+			if len(policies) != 3 || policies[0] == nil || policies[0].HealthCheck == nil {
+				return false
+			}
+			require.Equal(t, &aigv1a1.AIServiceBackendHealthCheck{
+				Path:               "/health",
+				Interval:           &metav1.Duration{Duration: 10 * time.Second},
+				Timeout:            &metav1.Duration{Duration: time.Second},
+				HealthyThreshold:   ptr.To[uint32](1),
+				UnhealthyThreshold: ptr.To[uint32](3),
+				ExpectedStatuses:   []int32{200, 401},
+			}, policies[0].HealthCheck)
+			require.Nil(t, policies[1])
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
 	t.Run("verify resources created by AIGatewayRoute controller are recreated if deleted", func(t *testing.T) {
 		routeName := "route1"
 		routeNamespace := "default"
@@ -326,12 +423,138 @@ func TestStartControllers(t *testing.T) {
 			return true
 		}, 30*time.Second, 200*time.Millisecond)
 	})
+
+	t.Run("route created before its Gateway", func(t *testing.T) {
+		route := &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "early-route", Namespace: "default"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				APISchema: defaultSchema,
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+					{
+						LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+							Name: "late-gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+						},
+					},
+				},
+			},
+		}
+		require.NoError(t, c.Create(ctx, route))
+
+		requireAccepted := func(status metav1.ConditionStatus, reason string) {
+			require.Eventually(t, func() bool {
+				var r aigv1a1.AIGatewayRoute
+				require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(route), &r))
+				cond := meta.FindStatusCondition(r.Status.Conditions, string(gwapiv1.RouteConditionAccepted))
+				if cond == nil {
+					return false
+				}
+				t.Logf("Accepted condition: %s/%s: %s", cond.Status, cond.Reason, cond.Message)
+				return cond.Status == status && cond.Reason == reason
+			}, 30*time.Second, 200*time.Millisecond)
+		}
+		requireAccepted(metav1.ConditionFalse, string(gwapiv1.RouteReasonNoMatchingParent))
+
+		// Creating the Gateway afterward must be picked up without touching the AIGatewayRoute.
+		require.NoError(t, c.Create(ctx, &gwapiv1.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "late-eg"},
+			Spec:       gwapiv1.GatewayClassSpec{ControllerName: egv1a1.GatewayControllerName},
+		}))
+		require.NoError(t, c.Create(ctx, &gwapiv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "late-gtw", Namespace: "default"},
+			Spec: gwapiv1.GatewaySpec{
+				GatewayClassName: "late-eg",
+				Listeners:        []gwapiv1.Listener{{Name: "http", Port: 80, Protocol: gwapiv1.HTTPProtocolType}},
+			},
+		}))
+		requireAccepted(metav1.ConditionTrue, string(gwapiv1.RouteReasonAccepted))
+	})
+}
+
+// TestStartControllers_namespaceScoping tests that [controller.StartControllers] only reconciles the resources in
+// the namespaces selected by the WatchNamespaces and the NamespaceLabelSelector options.
+func TestStartControllers_namespaceScoping(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+	opts := controller.Options{
+		ExtProcImage:           "envoyproxy/ai-gateway-extproc:foo",
+		WatchNamespaces:        []string{"watched", "unlabeled"},
+		NamespaceLabelSelector: labels.SelectorFromSet(labels.Set{"team": "ai"}),
+	}
+
+	ctx := t.Context()
+	go func() {
+		err := controller.StartControllers(ctx, cfg, defaultLogger(), opts)
+		require.NoError(t, err)
+	}()
+
+	// "unlabeled" is watched but does not match the label selector, and "unwatched" matches the label selector
+	// but is not watched.
+	namespaces := map[string]map[string]string{
+		"watched":   {"team": "ai"},
+		"unlabeled": nil,
+		"unwatched": {"team": "ai"},
+	}
+	for ns, nsLabels := range namespaces {
+		require.NoError(t, c.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns, Labels: nsLabels}}))
+		require.NoError(t, c.Create(ctx, &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: ns},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema:  defaultSchema,
+				BackendRef: gwapiv1.BackendObjectReference{Name: "backend", Port: ptr.To[gwapiv1.PortNumber](8080)},
+			},
+		}))
+		require.NoError(t, c.Create(ctx, &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: ns},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+					{
+						LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+							Name: "gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+						},
+					},
+				},
+				APISchema: defaultSchema,
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "backend", Weight: ptr.To[int32](1)}}},
+				},
+			},
+		}))
+	}
+
+	require.Eventually(t, func() bool {
+		_, err := k.AppsV1().Deployments("watched").Get(ctx, extProcName("route"), metav1.GetOptions{})
+		if err != nil {
+			t.Logf("failed to get deployment %s: %v", extProcName("route"), err)
+			return false
+		}
+		return true
+	}, 30*time.Second, 200*time.Millisecond)
+
+	require.Never(t, func() bool {
+		for _, ns := range []string{"unlabeled", "unwatched"} {
+			_, err := k.AppsV1().Deployments(ns).Get(ctx, extProcName("route"), metav1.GetOptions{})
+			if !apierrors.IsNotFound(err) {
+				t.Logf("unexpected deployment in namespace %s: %v", ns, err)
+				return true
+			}
+			var extPolicy egv1a1.EnvoyExtensionPolicy
+			err = c.Get(ctx, client.ObjectKey{Name: extProcName("route"), Namespace: ns}, &extPolicy)
+			if !apierrors.IsNotFound(err) {
+				t.Logf("unexpected extension policy in namespace %s: %v", ns, err)
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 200*time.Millisecond)
 }
 
 func TestAIGatewayRouteController(t *testing.T) {
 	c, cfg, k := testsinternal.NewEnvTest(t)
 
-	rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), "gcr.io/ai-gateway/extproc:latest", "info")
+	defaultResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+	}
+	rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), &record.FakeRecorder{}, "gcr.io/ai-gateway/extproc:latest", "default-pull-secret", "info", defaultResources)
 
 	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
 	mgr, err := ctrl.NewManager(cfg, opt)
@@ -370,8 +593,8 @@ func TestAIGatewayRouteController(t *testing.T) {
 				{
 					Matches: []aigv1a1.AIGatewayRouteRuleMatch{},
 					BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{
-						{Name: "backend1", Weight: 1},
-						{Name: "backend2", Weight: 1},
+						{Name: "backend1", Weight: ptr.To[int32](1)},
+						{Name: "backend2", Weight: ptr.To[int32](1)},
 					},
 				},
 			},
@@ -379,6 +602,12 @@ func TestAIGatewayRouteController(t *testing.T) {
 				Type: aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
 				ExternalProcessor: &aigv1a1.AIGatewayFilterConfigExternalProcessor{
 					Replicas: ptr.To[int32](5), Resources: resourceReq,
+					NodeSelector: map[string]string{"pool": "ai"},
+					Tolerations: []corev1.Toleration{
+						{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "ai", Effect: corev1.TaintEffectNoSchedule},
+					},
+					PodAnnotations:     map[string]string{"foo": "bar"},
+					ServiceAccountName: "extproc",
 				},
 			},
 		},
@@ -404,7 +633,7 @@ func TestAIGatewayRouteController(t *testing.T) {
 		var r aigv1a1.AIGatewayRoute
 		err = c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r)
 		require.NoError(t, err)
-		require.Equal(t, origin, &r)
+		require.Equal(t, origin.Spec, r.Spec)
 
 		// Verify that the deployment, service, extension policy, and configmap are created.
 		require.Eventually(t, func() bool {
@@ -420,6 +649,16 @@ func TestAIGatewayRouteController(t *testing.T) {
 			require.True(t, *deployment.OwnerReferences[0].Controller)
 			require.Equal(t, int32(5), *deployment.Spec.Replicas)
 			require.Equal(t, resourceReq, &deployment.Spec.Template.Spec.Containers[0].Resources)
+			require.Equal(t, []corev1.LocalObjectReference{{Name: "default-pull-secret"}}, deployment.Spec.Template.Spec.ImagePullSecrets)
+			require.Equal(t, map[string]string{"pool": "ai"}, deployment.Spec.Template.Spec.NodeSelector)
+			require.Len(t, deployment.Spec.Template.Spec.Tolerations, 1)
+			require.Equal(t, "dedicated", deployment.Spec.Template.Spec.Tolerations[0].Key)
+			require.Equal(t, map[string]string{"foo": "bar", "aigateway.envoyproxy.io/pod-annotation-keys": "foo"}, deployment.Spec.Template.Annotations)
+			require.Equal(t, "extproc", deployment.Spec.Template.Spec.ServiceAccountName)
+			require.Equal(t, appsv1.RollingUpdateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+			require.Equal(t, ptr.To(intstr.FromInt32(0)), deployment.Spec.Strategy.RollingUpdate.MaxUnavailable)
+			require.Equal(t, ptr.To(intstr.FromInt32(1)), deployment.Spec.Strategy.RollingUpdate.MaxSurge)
+			require.Equal(t, int32(10), *deployment.Spec.RevisionHistoryLimit)
 
 			service, err := k.CoreV1().Services("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
 			if err != nil {
@@ -462,8 +701,17 @@ func TestAIGatewayRouteController(t *testing.T) {
 				corev1.ResourceMemory: resource.MustParse("32Mi"),
 			},
 		}
+		// The finalizer added by the controller updates the resource version.
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, origin))
 		origin.Spec.FilterConfig.ExternalProcessor.Replicas = ptr.To[int32](3)
 		origin.Spec.FilterConfig.ExternalProcessor.Resources = newResource
+		origin.Spec.FilterConfig.ExternalProcessor.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "route-pull-secret"}}
+		origin.Spec.FilterConfig.ExternalProcessor.NodeSelector = map[string]string{"pool": "gpu"}
+		origin.Spec.FilterConfig.ExternalProcessor.Tolerations = nil
+		origin.Spec.FilterConfig.ExternalProcessor.PodAnnotations = map[string]string{"foo": "baz"}
+		origin.Spec.FilterConfig.ExternalProcessor.ServiceAccountName = "extproc-2"
+		origin.Spec.FilterConfig.ExternalProcessor.DeploymentStrategy = &appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+		origin.Spec.FilterConfig.ExternalProcessor.RevisionHistoryLimit = ptr.To[int32](3)
 		err := c.Update(t.Context(), origin)
 		require.NoError(t, err)
 
@@ -485,6 +733,713 @@ func TestAIGatewayRouteController(t *testing.T) {
 			require.True(t, *deployment.OwnerReferences[0].Controller)
 			require.Equal(t, int32(3), *deployment.Spec.Replicas)
 			require.Equal(t, newResource, &deployment.Spec.Template.Spec.Containers[0].Resources)
+			if len(deployment.Spec.Template.Spec.Tolerations) != 0 {
+				t.Logf("tolerations are not updated yet")
+				return false
+			}
+			require.Equal(t, []corev1.LocalObjectReference{{Name: "route-pull-secret"}}, deployment.Spec.Template.Spec.ImagePullSecrets)
+			require.Equal(t, map[string]string{"pool": "gpu"}, deployment.Spec.Template.Spec.NodeSelector)
+			require.Equal(t, map[string]string{"foo": "baz", "aigateway.envoyproxy.io/pod-annotation-keys": "foo"}, deployment.Spec.Template.Annotations)
+			require.Equal(t, "extproc-2", deployment.Spec.Template.Spec.ServiceAccountName)
+			require.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+			require.Nil(t, deployment.Spec.Strategy.RollingUpdate)
+			require.Equal(t, int32(3), *deployment.Spec.RevisionHistoryLimit)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("cors and allowed endpoints", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.CORS = &egv1a1.CORS{AllowOrigins: []egv1a1.Origin{"https://*.example.com"}, AllowMethods: []string{"POST"}}
+		r.Spec.AllowedEndpoints = []aigv1a1.AIGatewayRouteEndpoint{aigv1a1.AIGatewayRouteEndpointChatCompletions}
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		policyKey := client.ObjectKey{Name: "ai-eg-route-cors-myroute", Namespace: "default"}
+		require.Eventually(t, func() bool {
+			var policy egv1a1.SecurityPolicy
+			if err := c.Get(t.Context(), policyKey, &policy); err != nil {
+				t.Logf("failed to get security policy: %v", err)
+				return false
+			}
+			require.Len(t, policy.OwnerReferences, 1)
+			require.Equal(t, "myroute", policy.OwnerReferences[0].Name)
+			require.Equal(t, []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "myroute"},
+			}}, policy.Spec.TargetRefs)
+			require.Equal(t, r.Spec.CORS, policy.Spec.CORS)
+
+			configMap, err := k.CoreV1().ConfigMaps("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			require.NoError(t, err)
+			if !strings.Contains(configMap.Data["extproc-config.yaml"], "/v1/chat/completions") {
+				t.Logf("allowed paths are not updated yet")
+				return false
+			}
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.CORS = nil
+		r.Spec.AllowedEndpoints = nil
+		require.NoError(t, c.Update(t.Context(), &r))
+		require.Eventually(t, func() bool {
+			err := c.Get(t.Context(), policyKey, &egv1a1.SecurityPolicy{})
+			return apierrors.IsNotFound(err)
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.LLMRequestCosts = []aigv1a1.LLMRequestCost{{MetadataKey: "llm_total_token", Type: aigv1a1.LLMRequestCostTypeTotalToken}}
+		r.Spec.RateLimit = &aigv1a1.AIGatewayRouteRateLimit{
+			ClientSelectorHeader: "x-user-id",
+			Limits: []aigv1a1.AIGatewayRouteRateLimitRule{
+				{MetadataKey: "llm_total_token", Requests: 1000, Unit: egv1a1.RateLimitUnitHour},
+			},
+		}
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		expSpec := egv1a1.BackendTrafficPolicySpec{
+			PolicyTargetReferences: egv1a1.PolicyTargetReferences{
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+					LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: "myroute"},
+				}},
+			},
+			RateLimit: &egv1a1.RateLimitSpec{
+				Type: egv1a1.GlobalRateLimitType,
+				Global: &egv1a1.GlobalRateLimit{Rules: []egv1a1.RateLimitRule{{
+					ClientSelectors: []egv1a1.RateLimitSelectCondition{{
+						Headers: []egv1a1.HeaderMatch{{Name: "x-user-id", Type: ptr.To(egv1a1.HeaderMatchDistinct)}},
+					}},
+					Limit: egv1a1.RateLimitValue{Requests: 1000, Unit: egv1a1.RateLimitUnitHour},
+					Cost: &egv1a1.RateLimitCost{
+						Request: &egv1a1.RateLimitCostSpecifier{From: egv1a1.RateLimitCostFromNumber, Number: ptr.To[uint64](0)},
+						Response: &egv1a1.RateLimitCostSpecifier{
+							From:     egv1a1.RateLimitCostFromMetadata,
+							Metadata: &egv1a1.RateLimitCostMetadata{Namespace: "io.envoy.ai_gateway", Key: "llm_total_token"},
+						},
+					},
+				}}},
+			},
+		}
+		policyKey := client.ObjectKey{Name: "ai-eg-route-traffic-myroute", Namespace: "default"}
+		require.Eventually(t, func() bool {
+			var policy egv1a1.BackendTrafficPolicy
+			if err := c.Get(t.Context(), policyKey, &policy); err != nil {
+				t.Logf("failed to get backend traffic policy: %v", err)
+				return false
+			}
+			require.Len(t, policy.OwnerReferences, 1)
+			require.Equal(t, "myroute", policy.OwnerReferences[0].Name)
+			require.Equal(t, expSpec, policy.Spec)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+
+		// Changing the limit updates the policy.
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.RateLimit.Limits[0].Requests = 2000
+		require.NoError(t, c.Update(t.Context(), &r))
+		expSpec.RateLimit.Global.Rules[0].Limit.Requests = 2000
+		require.Eventually(t, func() bool {
+			var policy egv1a1.BackendTrafficPolicy
+			require.NoError(t, c.Get(t.Context(), policyKey, &policy))
+			if policy.Spec.RateLimit.Global.Rules[0].Limit.Requests != 2000 {
+				t.Logf("limit is not updated yet")
+				return false
+			}
+			require.Equal(t, expSpec, policy.Spec)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.LLMRequestCosts = nil
+		r.Spec.RateLimit = nil
+		require.NoError(t, c.Update(t.Context(), &r))
+		require.Eventually(t, func() bool {
+			err := c.Get(t.Context(), policyKey, &egv1a1.BackendTrafficPolicy{})
+			return apierrors.IsNotFound(err)
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("exclude paths", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.ExcludePaths = []aigv1a1.AIGatewayRouteExcludePath{{Value: "/health"}}
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		key := client.ObjectKey{Name: "ai-eg-route-excluded-myroute", Namespace: "default"}
+		require.Eventually(t, func() bool {
+			var httpRoute gwapiv1.HTTPRoute
+			if err := c.Get(t.Context(), key, &httpRoute); err != nil {
+				t.Logf("failed to get http route: %v", err)
+				return false
+			}
+			require.Len(t, httpRoute.OwnerReferences, 1)
+			require.Equal(t, "myroute", httpRoute.OwnerReferences[0].Name)
+			require.Len(t, httpRoute.Spec.Rules, 1)
+			require.Equal(t, []gwapiv1.HTTPRouteMatch{
+				{Path: &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchPathPrefix), Value: ptr.To("/health")}},
+			}, httpRoute.Spec.Rules[0].Matches)
+
+			var policy egv1a1.EnvoyExtensionPolicy
+			if err := c.Get(t.Context(), key, &policy); err != nil {
+				t.Logf("failed to get envoy extension policy: %v", err)
+				return false
+			}
+			require.Len(t, policy.OwnerReferences, 1)
+			require.Equal(t, "myroute", policy.OwnerReferences[0].Name)
+			require.Nil(t, policy.Spec.ExtProc)
+			require.Equal(t, []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{{
+				LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+					Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Name: gwapiv1.ObjectName(key.Name),
+				},
+			}}, policy.Spec.TargetRefs)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.ExcludePaths = nil
+		require.NoError(t, c.Update(t.Context(), &r))
+		require.Eventually(t, func() bool {
+			return apierrors.IsNotFound(c.Get(t.Context(), key, &gwapiv1.HTTPRoute{})) &&
+				apierrors.IsNotFound(c.Get(t.Context(), key, &egv1a1.EnvoyExtensionPolicy{}))
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("default resources", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.Resources = nil
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			deployment, err := k.AppsV1().Deployments("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			if err != nil {
+				t.Logf("failed to get deployment %s: %v", extProcName("myroute"), err)
+				return false
+			}
+			resources := deployment.Spec.Template.Spec.Containers[0].Resources
+			if !equality.Semantic.DeepEqual(defaultResources, resources) {
+				t.Logf("resources are not updated yet: %v", resources)
+				return false
+			}
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("enable horizontal pod autoscaler", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.Replicas = nil
+		r.Spec.FilterConfig.ExternalProcessor.HorizontalPodAutoscaler = &aigv1a1.AIGatewayFilterConfigExternalProcessorHPA{
+			MinReplicas: ptr.To[int32](2),
+			MaxReplicas: 10,
+		}
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			hpa, err := k.AutoscalingV2().HorizontalPodAutoscalers("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			if err != nil {
+				t.Logf("failed to get horizontal pod autoscaler %s: %v", extProcName("myroute"), err)
+				return false
+			}
+			require.Equal(t, extProcName("myroute"), hpa.Spec.ScaleTargetRef.Name)
+			require.Equal(t, "Deployment", hpa.Spec.ScaleTargetRef.Kind)
+			require.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+			require.Equal(t, int32(10), hpa.Spec.MaxReplicas)
+			require.Len(t, hpa.OwnerReferences, 1)
+			require.Equal(t, "myroute", hpa.OwnerReferences[0].Name)
+			require.True(t, *hpa.OwnerReferences[0].Controller)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+
+		// The controller must not reconcile the replicas managed by the HorizontalPodAutoscaler.
+		deployment, err := k.AppsV1().Deployments("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Equal(t, int32(3), *deployment.Spec.Replicas)
+	})
+
+	t.Run("update horizontal pod autoscaler", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.HorizontalPodAutoscaler.MaxReplicas = 20
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			hpa, err := k.AutoscalingV2().HorizontalPodAutoscalers("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			if err != nil {
+				t.Logf("failed to get horizontal pod autoscaler %s: %v", extProcName("myroute"), err)
+				return false
+			}
+			return hpa.Spec.MaxReplicas == 20
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("delete horizontal pod autoscaler", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.HorizontalPodAutoscaler = nil
+		r.Spec.FilterConfig.ExternalProcessor.Replicas = ptr.To[int32](4)
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			_, err := k.AutoscalingV2().HorizontalPodAutoscalers("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			if !apierrors.IsNotFound(err) {
+				t.Logf("horizontal pod autoscaler %s still exists: %v", extProcName("myroute"), err)
+				return false
+			}
+			deployment, err := k.AppsV1().Deployments("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			require.NoError(t, err)
+			return *deployment.Spec.Replicas == 4
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("create pod disruption budget", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.PodDisruptionBudget = &aigv1a1.AIGatewayFilterConfigExternalProcessorPDB{
+			MinAvailable: ptr.To(intstr.FromInt32(2)),
+		}
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			pdb, err := k.PolicyV1().PodDisruptionBudgets("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			if err != nil {
+				t.Logf("failed to get pod disruption budget %s: %v", extProcName("myroute"), err)
+				return false
+			}
+			require.Equal(t, ptr.To(intstr.FromInt32(2)), pdb.Spec.MinAvailable)
+			require.Equal(t, extProcName("myroute"), pdb.Spec.Selector.MatchLabels["app"])
+			require.Len(t, pdb.OwnerReferences, 1)
+			require.Equal(t, "myroute", pdb.OwnerReferences[0].Name)
+			require.True(t, *pdb.OwnerReferences[0].Controller)
+
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+			cond := meta.FindStatusCondition(r.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed)
+			if cond == nil {
+				t.Logf("condition %s is not set yet", aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed)
+				return false
+			}
+			// The deployment runs 4 replicas.
+			require.Equal(t, metav1.ConditionTrue, cond.Status)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("update pod disruption budget", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.Replicas = ptr.To[int32](1)
+		r.Spec.FilterConfig.ExternalProcessor.PodDisruptionBudget = &aigv1a1.AIGatewayFilterConfigExternalProcessorPDB{
+			MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+		}
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			pdb, err := k.PolicyV1().PodDisruptionBudgets("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			require.NoError(t, err)
+			if pdb.Spec.MaxUnavailable == nil {
+				t.Logf("pod disruption budget %s is not updated yet", extProcName("myroute"))
+				return false
+			}
+			require.Nil(t, pdb.Spec.MinAvailable)
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+			cond := meta.FindStatusCondition(r.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed)
+			require.NotNil(t, cond)
+			return cond.Status == metav1.ConditionFalse && cond.Reason == aigv1a1.AIGatewayRouteReasonSingleReplica
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("delete pod disruption budget", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		r.Spec.FilterConfig.ExternalProcessor.PodDisruptionBudget = nil
+		require.NoError(t, c.Update(t.Context(), &r))
+
+		require.Eventually(t, func() bool {
+			_, err := k.PolicyV1().PodDisruptionBudgets("default").Get(t.Context(), extProcName("myroute"), metav1.GetOptions{})
+			if !apierrors.IsNotFound(err) {
+				t.Logf("pod disruption budget %s still exists: %v", extProcName("myroute"), err)
+				return false
+			}
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+			return meta.FindStatusCondition(r.Status.Conditions, aigv1a1.AIGatewayRouteConditionExtProcDisruptionAllowed) == nil
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("delete last route", func(t *testing.T) {
+		var r aigv1a1.AIGatewayRoute
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r))
+		require.Contains(t, r.Finalizers, "aigateway.envoyproxy.io/ai-gateway-route-finalizer")
+		var filter egv1a1.HTTPRouteFilter
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "ai-eg-host-rewrite", Namespace: "default"}, &filter))
+
+		require.NoError(t, c.Delete(t.Context(), &r))
+		require.Eventually(t, func() bool {
+			err := c.Get(t.Context(), client.ObjectKey{Name: "myroute", Namespace: "default"}, &r)
+			if !apierrors.IsNotFound(err) {
+				t.Logf("route myroute still exists: %v", err)
+				return false
+			}
+			err = c.Get(t.Context(), client.ObjectKey{Name: "ai-eg-host-rewrite", Namespace: "default"}, &filter)
+			if !apierrors.IsNotFound(err) {
+				t.Logf("host rewrite filter still exists: %v", err)
+				return false
+			}
+			var policy egv1a1.EnvoyExtensionPolicy
+			err = c.Get(t.Context(), client.ObjectKey{Name: extProcName("myroute"), Namespace: "default"}, &policy)
+			if !apierrors.IsNotFound(err) {
+				t.Logf("extension policy still exists: %v", err)
+				return false
+			}
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+}
+
+// TestAIGatewayRouteController_extProcImageUpdate tests that the changes of the controller flags such as the
+// extproc image are propagated to the existing extproc deployment.
+func TestAIGatewayRouteController_extProcImageUpdate(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	startController := func(image string) (stop func()) {
+		rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), &record.FakeRecorder{}, image, "", "info", corev1.ResourceRequirements{})
+		opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+		mgr, err := ctrl.NewManager(cfg, opt)
+		require.NoError(t, err)
+		require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.AIGatewayRoute{}).Complete(rc))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			require.NoError(t, mgr.Start(ctx))
+		}()
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+	requireImage := func(image string) {
+		require.Eventually(t, func() bool {
+			deployment, err := k.AppsV1().Deployments("default").Get(t.Context(), extProcName("image-route"), metav1.GetOptions{})
+			if err != nil {
+				t.Logf("failed to get deployment %s: %v", extProcName("image-route"), err)
+				return false
+			}
+			return deployment.Spec.Template.Spec.Containers[0].Image == image
+		}, 30*time.Second, 200*time.Millisecond)
+	}
+
+	stop := startController("gcr.io/ai-gateway/extproc:a")
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "image-route", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: defaultSchema,
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+				{
+					LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+						Name: "gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, c.Create(t.Context(), route))
+	requireImage("gcr.io/ai-gateway/extproc:a")
+	stop()
+
+	stop = startController("gcr.io/ai-gateway/extproc:b")
+	defer stop()
+	// Touch the route to trigger the reconciliation.
+	require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), route))
+	route.Annotations = map[string]string{"touched": "true"}
+	require.NoError(t, c.Update(t.Context(), route))
+	requireImage("gcr.io/ai-gateway/extproc:b")
+}
+
+func TestAIGatewayRouteController_targetSectionName(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), &record.FakeRecorder{}, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
+	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+	mgr, err := ctrl.NewManager(cfg, opt)
+	require.NoError(t, err)
+	require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.AIGatewayRoute{}).Complete(rc))
+	go func() {
+		require.NoError(t, mgr.Start(t.Context()))
+	}()
+
+	require.NoError(t, c.Create(t.Context(), &gwapiv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-gtw", Namespace: "default"},
+		Spec: gwapiv1.GatewaySpec{
+			GatewayClassName: "eg",
+			Listeners: []gwapiv1.Listener{
+				{Name: "http", Port: 80, Protocol: gwapiv1.HTTPProtocolType},
+				{Name: "https", Port: 443, Protocol: gwapiv1.HTTPSProtocolType, TLS: &gwapiv1.GatewayTLSConfig{
+					CertificateRefs: []gwapiv1.SecretObjectReference{{Name: "cert"}},
+				}},
+			},
+		},
+	}))
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "section-route", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: defaultSchema,
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+				{
+					LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+						Name: "shared-gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+					},
+					SectionName: ptr.To[gwapiv1.SectionName]("https"),
+				},
+			},
+		},
+	}
+	require.NoError(t, c.Create(t.Context(), route))
+
+	requireResolvedRefs := func(status metav1.ConditionStatus) {
+		require.Eventually(t, func() bool {
+			var r aigv1a1.AIGatewayRoute
+			require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), &r))
+			for _, cond := range r.Status.Conditions {
+				if cond.Type == "ResolvedRefs" && cond.ObservedGeneration == r.Generation {
+					return cond.Status == status
+				}
+			}
+			return false
+		}, 30*time.Second, 200*time.Millisecond)
+	}
+
+	t.Run("existing section", func(t *testing.T) {
+		requireResolvedRefs(metav1.ConditionTrue)
+		// The HTTPRoute is only attached to the https listener so that the traffic on the other listeners is not captured.
+		require.Eventually(t, func() bool {
+			var httpRoute gwapiv1.HTTPRoute
+			if err := c.Get(t.Context(), client.ObjectKeyFromObject(route), &httpRoute); err != nil {
+				t.Logf("failed to get HTTPRoute: %v", err)
+				return false
+			}
+			require.Len(t, httpRoute.Spec.ParentRefs, 1)
+			require.Equal(t, "shared-gtw", string(httpRoute.Spec.ParentRefs[0].Name))
+			require.Equal(t, ptr.To[gwapiv1.SectionName]("https"), httpRoute.Spec.ParentRefs[0].SectionName)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+
+		var extPolicy egv1a1.EnvoyExtensionPolicy
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: extProcName("section-route"), Namespace: "default"}, &extPolicy))
+		require.Len(t, extPolicy.Spec.TargetRefs, 1)
+		// EnvoyExtensionPolicy does not support the section name, so the policy targets the whole Gateway.
+		require.Equal(t, gwapiv1.ObjectName("shared-gtw"), extPolicy.Spec.TargetRefs[0].Name)
+		require.Nil(t, extPolicy.Spec.TargetRefs[0].SectionName)
+	})
+	t.Run("missing section", func(t *testing.T) {
+		require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), route))
+		route.Spec.TargetRefs[0].SectionName = ptr.To[gwapiv1.SectionName]("grpc")
+		require.NoError(t, c.Update(t.Context(), route))
+		requireResolvedRefs(metav1.ConditionFalse)
+	})
+}
+
+func TestAIGatewayRouteController_events(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	recorder := record.NewFakeRecorder(100)
+	rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), recorder, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
+	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+	mgr, err := ctrl.NewManager(cfg, opt)
+	require.NoError(t, err)
+	require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.AIGatewayRoute{}).Complete(rc))
+	go func() {
+		require.NoError(t, mgr.Start(t.Context()))
+	}()
+
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "events-route", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: defaultSchema,
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "events-missing-backend"}}},
+			},
+		},
+	}
+	require.NoError(t, c.Create(t.Context(), route))
+
+	// The sync failure is surfaced as a Warning event with the stable reason, and the reconciliation is retried.
+	select {
+	case event := <-recorder.Events:
+		require.Equal(t, "Warning BackendNotFound AIServiceBackend events-missing-backend.default not found", event)
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+}
+
+func TestAIGatewayRouteController_failureMode(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), &record.FakeRecorder{}, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
+	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+	mgr, err := ctrl.NewManager(cfg, opt)
+	require.NoError(t, err)
+	require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.AIGatewayRoute{}).Complete(rc))
+	go func() {
+		require.NoError(t, mgr.Start(t.Context()))
+	}()
+
+	for name, schema := range map[string]aigv1a1.APISchema{"openai": aigv1a1.APISchemaOpenAI, "bedrock": aigv1a1.APISchemaAWSBedrock} {
+		require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema:  aigv1a1.VersionedAPISchema{Name: schema},
+				BackendRef: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(name), Port: ptr.To[gwapiv1.PortNumber](8080)},
+			},
+		}))
+	}
+	route := &aigv1a1.AIGatewayRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "fail-open-route", Namespace: "default"},
+		Spec: aigv1a1.AIGatewayRouteSpec{
+			APISchema: defaultSchema,
+			TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+				{
+					LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+						Name: "gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+					},
+				},
+			},
+			Rules: []aigv1a1.AIGatewayRouteRule{
+				{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: "bedrock", Weight: ptr.To[int32](1)}, {Name: "openai", Weight: ptr.To[int32](1)}}},
+			},
+			FilterConfig: &aigv1a1.AIGatewayFilterConfig{
+				Type:        aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
+				FailureMode: aigv1a1.AIGatewayFilterConfigFailureModeFailOpen,
+			},
+		},
+	}
+	require.NoError(t, c.Create(t.Context(), route))
+
+	t.Run("non-openai default backend", func(t *testing.T) {
+		// The first backend, the default backend, has the AWSBedrock schema, so the route is rejected.
+		require.Never(t, func() bool {
+			var extPolicy egv1a1.EnvoyExtensionPolicy
+			err := c.Get(t.Context(), client.ObjectKey{Name: extProcName("fail-open-route"), Namespace: "default"}, &extPolicy)
+			return err == nil
+		}, 3*time.Second, 200*time.Millisecond)
+	})
+	t.Run("openai default backend", func(t *testing.T) {
+		require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), route))
+		route.Spec.DefaultBackend = "openai"
+		require.NoError(t, c.Update(t.Context(), route))
+		require.Eventually(t, func() bool {
+			var extPolicy egv1a1.EnvoyExtensionPolicy
+			if err := c.Get(t.Context(), client.ObjectKey{Name: extProcName("fail-open-route"), Namespace: "default"}, &extPolicy); err != nil {
+				t.Logf("failed to get extension policy: %v", err)
+				return false
+			}
+			require.Len(t, extPolicy.Spec.ExtProc, 1)
+			require.Equal(t, ptr.To(true), extPolicy.Spec.ExtProc[0].FailOpen)
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+	t.Run("fail closed", func(t *testing.T) {
+		require.NoError(t, c.Get(t.Context(), client.ObjectKeyFromObject(route), route))
+		route.Spec.FilterConfig.FailureMode = aigv1a1.AIGatewayFilterConfigFailureModeFailClosed
+		require.NoError(t, c.Update(t.Context(), route))
+		require.Eventually(t, func() bool {
+			var extPolicy egv1a1.EnvoyExtensionPolicy
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: extProcName("fail-open-route"), Namespace: "default"}, &extPolicy))
+			return !*extPolicy.Spec.ExtProc[0].FailOpen
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+}
+
+// TestAIGatewayRouteController_sharedDeployment tests that the AIGatewayRoutes with the shared deployment run in
+// a single extproc Deployment whose ConfigMap contains the merged configuration.
+func TestAIGatewayRouteController_sharedDeployment(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	rc := controller.NewAIGatewayRouteController(c, k, defaultLogger(), &record.FakeRecorder{}, "gcr.io/ai-gateway/extproc:latest", "", "info", corev1.ResourceRequirements{})
+	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+	mgr, err := ctrl.NewManager(cfg, opt)
+	require.NoError(t, err)
+	require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.AIGatewayRoute{}).Complete(rc))
+	go func() {
+		require.NoError(t, mgr.Start(t.Context()))
+	}()
+
+	const sharedName = "ai-eg-shared-extproc"
+	for _, name := range []string{"apple", "orange"} {
+		require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema:  defaultSchema,
+				BackendRef: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(name), Port: ptr.To[gwapiv1.PortNumber](8080)},
+			},
+		}))
+		require.NoError(t, c.Create(t.Context(), &aigv1a1.AIGatewayRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aigv1a1.AIGatewayRouteSpec{
+				APISchema: defaultSchema,
+				TargetRefs: []gwapiv1a2.LocalPolicyTargetReferenceWithSectionName{
+					{
+						LocalPolicyTargetReference: gwapiv1a2.LocalPolicyTargetReference{
+							Name: "gtw", Kind: "Gateway", Group: "gateway.networking.k8s.io",
+						},
+					},
+				},
+				Rules: []aigv1a1.AIGatewayRouteRule{
+					{BackendRefs: []aigv1a1.AIGatewayRouteRuleBackendRef{{Name: name}}},
+				},
+				FilterConfig: &aigv1a1.AIGatewayFilterConfig{
+					Type:             aigv1a1.AIGatewayFilterConfigTypeExternalProcessor,
+					SharedDeployment: true,
+				},
+			},
+		}))
+	}
+
+	t.Run("single deployment", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			deployments, err := k.AppsV1().Deployments("default").List(t.Context(), metav1.ListOptions{})
+			require.NoError(t, err)
+			if len(deployments.Items) != 1 {
+				t.Logf("expected a single deployment, got %d", len(deployments.Items))
+				return false
+			}
+			require.Equal(t, sharedName, deployments.Items[0].Name)
+			configMap, err := k.CoreV1().ConfigMaps("default").Get(t.Context(), sharedName, metav1.GetOptions{})
+			if err != nil {
+				t.Logf("failed to get configmap: %v", err)
+				return false
+			}
+			config := configMap.Data["extproc-config.yaml"]
+			if !strings.Contains(config, "apple.default") || !strings.Contains(config, "orange.default") {
+				t.Logf("configmap does not contain the rules of both routes: %s", config)
+				return false
+			}
+			for _, name := range []string{"apple", "orange"} {
+				var extPolicy egv1a1.EnvoyExtensionPolicy
+				if err = c.Get(t.Context(), client.ObjectKey{Name: extProcName(name), Namespace: "default"}, &extPolicy); err != nil {
+					t.Logf("failed to get extension policy: %v", err)
+					return false
+				}
+				require.Equal(t, sharedName, string(extPolicy.Spec.ExtProc[0].BackendRefs[0].Name))
+			}
+			return true
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("delete one route", func(t *testing.T) {
+		require.NoError(t, c.Delete(t.Context(), &aigv1a1.AIGatewayRoute{ObjectMeta: metav1.ObjectMeta{Name: "apple", Namespace: "default"}}))
+		require.Eventually(t, func() bool {
+			configMap, err := k.CoreV1().ConfigMaps("default").Get(t.Context(), sharedName, metav1.GetOptions{})
+			require.NoError(t, err)
+			config := configMap.Data["extproc-config.yaml"]
+			if strings.Contains(config, "apple.default") {
+				t.Logf("configmap still contains the rules of the deleted route: %s", config)
+				return false
+			}
+			require.Contains(t, config, "orange.default")
 			return true
 		}, 30*time.Second, 200*time.Millisecond)
 	})
@@ -500,7 +1455,7 @@ func TestBackendSecurityPolicyController(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, controller.ApplyIndexing(t.Context(), mgr.GetFieldIndexer().IndexField))
 
-	pc := controller.NewBackendSecurityPolicyController(mgr.GetClient(), k, defaultLogger(), syncAIServiceBackend.Sync)
+	pc := controller.NewBackendSecurityPolicyController(mgr.GetClient(), k, defaultLogger(), &record.FakeRecorder{}, syncAIServiceBackend.Sync)
 	err = ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.BackendSecurityPolicy{}).Complete(pc)
 	require.NoError(t, err)
 
@@ -610,6 +1565,85 @@ func TestBackendSecurityPolicyController(t *testing.T) {
 	})
 }
 
+func TestBackendSecurityPolicyController_referenceGrant(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+	mgr, err := ctrl.NewManager(cfg, opt)
+	require.NoError(t, err)
+	require.NoError(t, controller.ApplyIndexing(t.Context(), mgr.GetFieldIndexer().IndexField))
+
+	syncAIServiceBackend := internaltesting.NewSyncFnImpl[aigv1a1.AIServiceBackend]()
+	pc := controller.NewBackendSecurityPolicyController(mgr.GetClient(), k, defaultLogger(), &record.FakeRecorder{}, syncAIServiceBackend.Sync)
+	require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.BackendSecurityPolicy{}).Complete(pc))
+
+	bspSyncFn := internaltesting.NewSyncFnImpl[aigv1a1.BackendSecurityPolicy]()
+	sc := controller.NewSecretController(mgr.GetClient(), k, defaultLogger(), bspSyncFn.Sync)
+	require.NoError(t, ctrl.NewControllerManagedBy(mgr).For(&corev1.Secret{}).
+		Watches(&gwapiv1b1.ReferenceGrant{}, handler.EnqueueRequestsFromMapFunc(sc.ReferenceGrantToSecrets)).
+		Complete(sc))
+
+	go func() { require.NoError(t, mgr.Start(t.Context())) }()
+
+	const policyNamespace, secretNamespace = "team-a", "team-b"
+	for _, ns := range []string{policyNamespace, secretNamespace} {
+		require.NoError(t, c.Create(t.Context(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}))
+	}
+	require.NoError(t, c.Create(t.Context(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysecret", Namespace: secretNamespace},
+		StringData: map[string]string{"apiKey": "value"},
+	}))
+	require.NoError(t, c.Create(t.Context(), &aigv1a1.BackendSecurityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "bsp", Namespace: policyNamespace},
+		Spec: aigv1a1.BackendSecurityPolicySpec{
+			Type: aigv1a1.BackendSecurityPolicyTypeAPIKey,
+			APIKey: &aigv1a1.BackendSecurityPolicyAPIKey{
+				SecretRef: &gwapiv1.SecretObjectReference{Name: "mysecret", Namespace: ptr.To[gwapiv1.Namespace](secretNamespace)},
+			},
+		},
+	}))
+	resolvedRefs := func() *metav1.Condition {
+		var policy aigv1a1.BackendSecurityPolicy
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "bsp", Namespace: policyNamespace}, &policy))
+		return meta.FindStatusCondition(policy.Status.Conditions, aigv1a1.BackendSecurityPolicyConditionResolvedRefs)
+	}
+
+	t.Run("without grant", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			cond := resolvedRefs()
+			return cond != nil && cond.Status == metav1.ConditionFalse && cond.Reason == "RefNotPermitted"
+		}, 5*time.Second, 200*time.Millisecond)
+	})
+
+	bspSyncFn.Reset()
+	t.Run("with grant", func(t *testing.T) {
+		require.NoError(t, c.Create(t.Context(), &gwapiv1b1.ReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: secretNamespace},
+			Spec: gwapiv1b1.ReferenceGrantSpec{
+				From: []gwapiv1b1.ReferenceGrantFrom{{Group: aigv1a1.GroupName, Kind: "BackendSecurityPolicy", Namespace: policyNamespace}},
+				To:   []gwapiv1b1.ReferenceGrantTo{{Kind: "Secret"}},
+			},
+		}))
+
+		// The grant re-triggers the sync of the policy through the secret it permits.
+		require.Eventually(t, func() bool {
+			for _, bsp := range bspSyncFn.GetItems() {
+				if bsp.Name == "bsp" && bsp.Namespace == policyNamespace {
+					return true
+				}
+			}
+			return false
+		}, 5*time.Second, 200*time.Millisecond)
+
+		// Touch the policy so that the BackendSecurityPolicy controller re-evaluates the condition.
+		var policy aigv1a1.BackendSecurityPolicy
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: "bsp", Namespace: policyNamespace}, &policy))
+		policy.Labels = map[string]string{"touched": "true"}
+		require.NoError(t, c.Update(t.Context(), &policy))
+		require.Eventually(t, func() bool { return resolvedRefs() == nil }, 5*time.Second, 200*time.Millisecond)
+	})
+}
+
 func TestAIServiceBackendController(t *testing.T) {
 	c, cfg, k := testsinternal.NewEnvTest(t)
 
@@ -620,7 +1654,7 @@ func TestAIServiceBackendController(t *testing.T) {
 	require.NoError(t, err)
 	require.NoError(t, controller.ApplyIndexing(t.Context(), mgr.GetFieldIndexer().IndexField))
 
-	bc := controller.NewAIServiceBackendController(mgr.GetClient(), k, defaultLogger(), syncAIGatewayRoute.Sync)
+	bc := controller.NewAIServiceBackendController(mgr.GetClient(), k, defaultLogger(), &record.FakeRecorder{}, syncAIGatewayRoute.Sync)
 	err = ctrl.NewControllerManagedBy(mgr).For(&aigv1a1.AIServiceBackend{}).Complete(bc)
 	require.NoError(t, err)
 
@@ -726,6 +1760,118 @@ func TestAIServiceBackendController(t *testing.T) {
 	})
 }
 
+func TestAIServiceBackendController_backendRefs(t *testing.T) {
+	c, cfg, k := testsinternal.NewEnvTest(t)
+
+	opt := ctrl.Options{Scheme: c.Scheme(), LeaderElection: false, Controller: config.Controller{SkipNameValidation: ptr.To(true)}}
+	mgr, err := ctrl.NewManager(cfg, opt)
+	require.NoError(t, err)
+	require.NoError(t, controller.ApplyIndexing(t.Context(), mgr.GetFieldIndexer().IndexField))
+
+	syncAIGatewayRoute := internaltesting.NewSyncFnImpl[aigv1a1.AIGatewayRoute]()
+	bc := controller.NewAIServiceBackendController(mgr.GetClient(), k, defaultLogger(), &record.FakeRecorder{}, syncAIGatewayRoute.Sync)
+	err = ctrl.NewControllerManagedBy(mgr).
+		For(&aigv1a1.AIServiceBackend{}).
+		Watches(&egv1a1.Backend{}, handler.EnqueueRequestsFromMapFunc(bc.EnvoyGatewayBackendToAIServiceBackends)).
+		Complete(bc)
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, mgr.Start(t.Context()))
+	}()
+
+	requireCondition := func(t *testing.T, name, conditionType string, status metav1.ConditionStatus, reason, message string) {
+		require.Eventually(t, func() bool {
+			var aiBackend aigv1a1.AIServiceBackend
+			if err := c.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "default"}, &aiBackend); err != nil {
+				return false
+			}
+			cond := meta.FindStatusCondition(aiBackend.Status.Conditions, conditionType)
+			if cond == nil {
+				t.Logf("condition %s is not set yet", conditionType)
+				return false
+			}
+			t.Logf("condition %s: %s %s %s", conditionType, cond.Status, cond.Reason, cond.Message)
+			return cond.Status == status && cond.Reason == reason && cond.Message == message
+		}, 30*time.Second, 200*time.Millisecond)
+	}
+
+	t.Run("service", func(t *testing.T) {
+		require.NoError(t, c.Create(t.Context(), &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "backendrefs-svc", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 8080}}},
+		}))
+		for name, svc := range map[string]string{
+			"backendrefs-svc-backend":         "backendrefs-svc",
+			"backendrefs-missing-svc-backend": "backendrefs-missing-svc",
+		} {
+			require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: aigv1a1.AIServiceBackendSpec{
+					APISchema:  defaultSchema,
+					BackendRef: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(svc), Port: ptr.To[gwapiv1.PortNumber](8080)},
+				},
+			}))
+		}
+		requireCondition(t, "backendrefs-svc-backend", "ResolvedRefs", metav1.ConditionTrue,
+			"ResolvedRefs", "The backend reference is resolved")
+		requireCondition(t, "backendrefs-missing-svc-backend", "ResolvedRefs", metav1.ConditionFalse,
+			"BackendNotFound", "Service backendrefs-missing-svc.default not found")
+	})
+
+	t.Run("fqdn backend", func(t *testing.T) {
+		const name = "backendrefs-fqdn-backend"
+		require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema: defaultSchema,
+				BackendRef: gwapiv1.BackendObjectReference{
+					Name: "backendrefs-fqdn", Group: ptr.To[gwapiv1.Group]("gateway.envoyproxy.io"), Kind: ptr.To[gwapiv1.Kind]("Backend"),
+				},
+			},
+		}))
+		requireCondition(t, name, "ResolvedRefs", metav1.ConditionFalse,
+			"BackendNotFound", "Backend backendrefs-fqdn.default not found")
+
+		// The AIServiceBackend is reconciled once the Backend is created.
+		require.NoError(t, c.Create(t.Context(), &egv1a1.Backend{
+			ObjectMeta: metav1.ObjectMeta{Name: "backendrefs-fqdn", Namespace: "default"},
+			Spec: egv1a1.BackendSpec{
+				Endpoints: []egv1a1.BackendEndpoint{{FQDN: &egv1a1.FQDNEndpoint{Hostname: "api.openai.com", Port: 443}}},
+			},
+		}))
+		requireCondition(t, name, "ResolvedRefs", metav1.ConditionTrue,
+			"ResolvedRefs", "The backend reference is resolved")
+		requireCondition(t, name, aigv1a1.AIServiceBackendConditionPortDefaulted, metav1.ConditionTrue,
+			aigv1a1.AIServiceBackendReasonFQDNBackend, "The port is not set and defaulted to 443 for the FQDN Backend")
+
+		// The condition is removed once the port is set.
+		var aiBackend aigv1a1.AIServiceBackend
+		require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "default"}, &aiBackend))
+		aiBackend.Spec.BackendRef.Port = ptr.To[gwapiv1.PortNumber](443)
+		require.NoError(t, c.Update(t.Context(), &aiBackend))
+		require.Eventually(t, func() bool {
+			require.NoError(t, c.Get(t.Context(), client.ObjectKey{Name: name, Namespace: "default"}, &aiBackend))
+			return meta.FindStatusCondition(aiBackend.Status.Conditions, aigv1a1.AIServiceBackendConditionPortDefaulted) == nil
+		}, 30*time.Second, 200*time.Millisecond)
+	})
+
+	t.Run("invalid kind", func(t *testing.T) {
+		const name = "backendrefs-invalid-kind-backend"
+		require.NoError(t, c.Create(t.Context(), &aigv1a1.AIServiceBackend{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aigv1a1.AIServiceBackendSpec{
+				APISchema: defaultSchema,
+				BackendRef: gwapiv1.BackendObjectReference{
+					Name: "foo", Group: ptr.To[gwapiv1.Group]("example.com"), Kind: ptr.To[gwapiv1.Kind]("Foo"),
+					Port: ptr.To[gwapiv1.PortNumber](8080),
+				},
+			},
+		}))
+		requireCondition(t, name, "ResolvedRefs", metav1.ConditionFalse,
+			"InvalidKind", "unsupported backend kind example.com/Foo: must be Service or gateway.envoyproxy.io/Backend")
+	})
+}
+
 func TestSecretController(t *testing.T) {
 	c, cfg, k := testsinternal.NewEnvTest(t)
 
@@ -812,6 +1958,55 @@ func TestSecretController(t *testing.T) {
 	})
 }
 
+// TestStartControllers_healthProbes tests the /healthz and /readyz endpoints served by [controller.StartControllers],
+// including the given checks such as the one of the extension server.
+func TestStartControllers_healthProbes(t *testing.T) {
+	_, cfg, _ := testsinternal.NewEnvTest(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	var extensionServerErr atomic.Pointer[error]
+	opts := controller.Options{
+		ExtProcImage:           "envoyproxy/ai-gateway-extproc:foo",
+		HealthProbeBindAddress: addr,
+		MetricsBindAddress:     "0",
+		HealthChecks: map[string]healthz.Checker{"extension-server": func(*http.Request) error {
+			if err := extensionServerErr.Load(); err != nil {
+				return *err
+			}
+			return nil
+		}},
+	}
+
+	ctx := t.Context()
+	go func() {
+		err := controller.StartControllers(ctx, cfg, defaultLogger(), opts)
+		require.NoError(t, err)
+	}()
+
+	statusOf := func(path string) int {
+		res, err := http.Get("http://" + addr + path)
+		if err != nil {
+			return 0
+		}
+		defer func() { _ = res.Body.Close() }()
+		return res.StatusCode
+	}
+	for _, path := range []string{"/healthz", "/readyz", "/healthz/ping", "/readyz/extension-server"} {
+		require.Eventually(t, func() bool {
+			return statusOf(path) == http.StatusOK
+		}, 10*time.Second, 100*time.Millisecond, path)
+	}
+
+	extensionServerErr.Store(ptr.To(errors.New("not serving")))
+	for _, path := range []string{"/healthz", "/readyz", "/readyz/extension-server"} {
+		require.Equal(t, http.StatusInternalServerError, statusOf(path), path)
+	}
+	require.Equal(t, http.StatusOK, statusOf("/healthz/ping"))
+}
+
 func defaultLogger() logr.Logger {
 	return logr.FromSlogHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{}))
 }
@@ -32,17 +32,105 @@ func TestAIGatewayRoutes(t *testing.T) {
 	}{
 		{name: "basic.yaml"},
 		{name: "llmcosts.yaml"},
+		{name: "llmcosts_model_price_table.yaml"},
 		{
-			name:   "non_openai_schema.yaml",
-			expErr: `spec.schema: Invalid value: "object": failed rule: self.name == 'OpenAI'`,
+			name:   "llmcosts_model_price_table_strict_default.yaml",
+			expErr: "spec.llmRequestCosts[0].modelPriceTable: Invalid value: \"object\": default cannot be set in the strict mode",
+		},
+		{name: "hpa.yaml"},
+		{
+			name:   "hpa_with_replicas.yaml",
+			expErr: "spec.filterConfig.externalProcessor: Invalid value: \"object\": replicas and horizontalPodAutoscaler are mutually exclusive",
+		},
+		{
+			name:   "hpa_invalid_range.yaml",
+			expErr: "spec.filterConfig.externalProcessor.horizontalPodAutoscaler: Invalid value: \"object\": minReplicas must be less than or equal to maxReplicas",
+		},
+		{name: "pdb.yaml"},
+		{
+			name:   "pdb_invalid.yaml",
+			expErr: "spec.filterConfig.externalProcessor.podDisruptionBudget: Invalid value: \"object\": exactly one of minAvailable and maxUnavailable must be set",
+		},
+		{
+			name:   "pdb_sidecar.yaml",
+			expErr: "spec.filterConfig.externalProcessor: Invalid value: \"object\": podDisruptionBudget cannot be set in the Sidecar deployment mode",
+		},
+		{name: "aws_bedrock_schema.yaml"},
+		{
+			name:   "cohere_schema.yaml",
+			expErr: `spec.schema: Invalid value: "object": failed rule: self.name == 'OpenAI' || self.name == 'AWSBedrock'`,
 		},
 		{
 			name:   "unknown_schema.yaml",
-			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\"",
+			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\", \"Cohere\", \"Mistral\"",
 		},
 		{
 			name:   "unsupported_match.yaml",
-			expErr: "spec.rules[0].matches[0].headers: Invalid value: \"array\": currently only exact match is supported",
+			expErr: "spec.rules[0].matches[0].headers: Invalid value: \"array\": currently only exact and prefix matches are supported",
+		},
+		{name: "prefix_match.yaml"},
+		{
+			name:   "prefix_match_non_model_header.yaml",
+			expErr: "spec.rules[0].matches[0].headers: Invalid value: \"array\": prefix match is only supported for the x-ai-eg-model header",
+		},
+		{name: "sidecar.yaml"},
+		{
+			name:   "sidecar_with_replicas.yaml",
+			expErr: "spec.filterConfig.externalProcessor: Invalid value: \"object\": replicas and horizontalPodAutoscaler cannot be set in the Sidecar deployment mode",
+		},
+		{name: "shared_deployment.yaml"},
+		{
+			name:   "shared_deployment_sidecar.yaml",
+			expErr: "spec.filterConfig: Invalid value: \"object\": sharedDeployment cannot be used with the Sidecar deployment mode or horizontalPodAutoscaler",
+		},
+		{name: "rate_limit.yaml"},
+		{
+			name:   "rate_limit_invalid_unit.yaml",
+			expErr: "spec.rateLimit.limits[0].unit: Unsupported value: \"Week\"",
+		},
+		{name: "model_header_name.yaml"},
+		{
+			name:   "model_header_name_invalid.yaml",
+			expErr: "spec.modelHeaderName: Invalid value: \"x tenant model\"",
+		},
+		{
+			name:   "override_error_responses_bedrock.yaml",
+			expErr: "spec: Invalid value: \"object\": overrideErrorResponses is only supported for the OpenAI schema",
+		},
+		{name: "fail_open.yaml"},
+		{
+			name:   "failure_mode_invalid.yaml",
+			expErr: "spec.filterConfig.failureMode: Unsupported value: \"FailSometimes\": supported values: \"FailClosed\", \"FailOpen\"",
+		},
+		{name: "default_route.yaml"},
+		{
+			name:   "default_route_conflict.yaml",
+			expErr: "spec: Invalid value: \"object\": defaultBackend cannot be set when disableDefaultRoute is true",
+		},
+		{name: "model_params.yaml"},
+		{
+			name:   "model_params_invalid_temperature.yaml",
+			expErr: "spec.rules[0].modelDefaults.temperature: Invalid value: \"abc\": spec.rules[0].modelDefaults.temperature in body should match",
+		},
+		{name: "path_prefix.yaml"},
+		{
+			name:   "path_prefix_invalid.yaml",
+			expErr: "spec.pathPrefix: Invalid value: \"/llm/\": spec.pathPrefix in body should match",
+		},
+		{name: "exclude_paths.yaml"},
+		{
+			name:   "exclude_paths_invalid.yaml",
+			expErr: "spec.excludePaths[0].value: Invalid value: \"health\": spec.excludePaths[0].value in body should match",
+		},
+		{name: "cors_allowed_endpoints.yaml"},
+		{
+			name:   "allowed_endpoints_unknown.yaml",
+			expErr: `spec.allowedEndpoints[1]: Unsupported value: "Images": supported values: "ChatCompletions", "Completions", "Embeddings", "Models", "Converse"`,
+		},
+		{name: "model_policy.yaml"},
+		{
+			name:   "model_policy_invalid.yaml",
+			expErr: "spec.modelPolicy.allow[0]: Invalid value: \"llama3-*-instruct\": spec.modelPolicy.allow[0] in body should match",
 		},
 		{
 			name:   "no_target_refs.yaml",
@@ -79,7 +167,45 @@ func TestAIServiceBackends(t *testing.T) {
 		{name: "basic-eg-backend.yaml"},
 		{
 			name:   "unknown_schema.yaml",
-			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\"",
+			expErr: "spec.schema.name: Unsupported value: \"SomeRandomVendor\": supported values: \"OpenAI\", \"AWSBedrock\", \"Cohere\", \"Mistral\"",
+		},
+		{name: "guardrail.yaml"},
+		{
+			name:   "guardrail_non_bedrock.yaml",
+			expErr: "guardrailConfig is only supported for the AWSBedrock schema",
+		},
+		{name: "openai.yaml"},
+		{
+			name:   "openai_non_openai.yaml",
+			expErr: "openAI is only supported for the OpenAI schema",
+		},
+		{name: "aws_bedrock_invoke_model.yaml"},
+		{
+			name:   "aws_bedrock_non_bedrock.yaml",
+			expErr: "awsBedrock is only supported for the AWSBedrock schema",
+		},
+		{name: "parameter_normalization.yaml"},
+		{
+			name:   "parameter_normalization_non_bedrock.yaml",
+			expErr: "parameterNormalization is only supported for the AWSBedrock schema",
+		},
+		{
+			name:   "parameter_normalization_auto_scale.yaml",
+			expErr: "temperatureScale and topPScale can only be set in the Explicit mode",
+		},
+		{name: "traffic_policy.yaml"},
+		{
+			name:   "traffic_policy_max_requests_per_connection.yaml",
+			expErr: "maxRequestsPerConnection is not supported",
+		},
+		{name: "path_override.yaml"},
+		{
+			name:   "path_override_bedrock_no_model.yaml",
+			expErr: "pathOverride must contain {model} for the AWSBedrock schema",
+		},
+		{
+			name:   "path_override_invalid.yaml",
+			expErr: "spec.pathOverride: Invalid value: \"serving/v2/chat/completions\": spec.pathOverride in body should match",
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
@@ -111,18 +237,32 @@ func TestBackendSecurityPolicies(t *testing.T) {
 		{name: "basic.yaml"},
 		{
 			name:   "unknown_provider.yaml",
-			expErr: "spec.type: Unsupported value: \"UnknownType\": supported values: \"APIKey\", \"AWSCredentials\"",
+			expErr: "spec.type: Unsupported value: \"UnknownType\": supported values: \"APIKey\", \"AWSCredentials\", \"AzureCredentials\"",
 		},
 		{
 			name:   "missing_type.yaml",
-			expErr: "spec.type: Unsupported value: \"\": supported values: \"APIKey\", \"AWSCredentials\"",
+			expErr: "spec.type: Unsupported value: \"\": supported values: \"APIKey\", \"AWSCredentials\", \"AzureCredentials\"",
 		},
 		{
 			name:   "multiple_security_policies.yaml",
 			expErr: "Too many: 3: must have at most 2 items",
 		},
+		{
+			name:   "api_key_invalid_weight.yaml",
+			expErr: "spec.apiKey.keys.weights[1] in body should be greater than or equal to 1",
+		},
+		{name: "api_key_custom_header.yaml"},
+		{
+			name:   "api_key_invalid_value_template.yaml",
+			expErr: "spec.apiKey.valueTemplate: Invalid value: \"string\": valueTemplate must contain {key}",
+		},
 		{name: "aws_credential_file.yaml"},
 		{name: "aws_oidc.yaml"},
+		{name: "azure_client_secret.yaml"},
+		{
+			name:   "azure_missing_credentials.yaml",
+			expErr: "spec.azureCredentials: Invalid value: \"object\": exactly one of clientSecretRef or oidcExchangeToken must be specified",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			data, err := testdata.ReadFile(path.Join("testdata/backendsecuritypolicies", tc.name))
@@ -10,6 +10,8 @@ package e2e
 import (
 	"context"
 	"encoding/base64"
+	"io"
+	"net/http"
 	"testing"
 	"time"
 
@@ -53,6 +55,16 @@ func TestTranslationWithTestUpstream(t *testing.T) {
 				expPath:           "/model/another-cool-model/converse",
 				fakeResponseBody:  `{"output":{"message":{"content":[{"text":"response"},{"text":"from"},{"text":"assistant"}],"role":"assistant"}},"stopReason":null,"usage":{"inputTokens":10,"outputTokens":20,"totalTokens":30}}`,
 			},
+			{
+				// The model matching no rule is routed to the default backend, the same as the catch-all rule of
+				// the HTTPRoute, and translated for it.
+				name:              "unknown model",
+				modelName:         "unknown-model",
+				expTestUpstreamID: "primary",
+				expPath:           "/v1/chat/completions",
+				expHost:           "testupstream.default.svc.cluster.local",
+				fakeResponseBody:  `{"choices":[{"message":{"content":"This is a test."}}]}`,
+			},
 		} {
 			t.Run(tc.name, func(t *testing.T) {
 				require.Eventually(t, func() bool {
@@ -96,4 +108,27 @@ func TestTranslationWithTestUpstream(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("excluded path", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			fwd := requireNewHTTPPortForwarder(t, egNamespace, egSelector, egDefaultPort)
+			defer fwd.kill()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, fwd.address()+"/health", nil)
+			require.NoError(t, err)
+			// The external processor sets these headers to every request it processes.
+			req.Header.Set(testupstreamlib.NonExpectedRequestHeadersKey,
+				base64.StdEncoding.EncodeToString([]byte("x-ai-eg-model,x-ai-eg-selected-backend")))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Logf("error: %v", err)
+				return false
+			}
+			defer func() { _ = resp.Body.Close() }()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			t.Logf("status: %d, body: %s", resp.StatusCode, body)
+			return resp.StatusCode == http.StatusOK
+		}, 30*time.Second, 1*time.Second)
+	})
 }
@@ -0,0 +1,61 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+//go:build test_e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpstreamUnavailable tests that the responses generated by Envoy when the backend cannot be reached are
+// overridden with the OpenAI error.
+func TestUpstreamUnavailable(t *testing.T) {
+	const manifest = "testdata/upstream_unavailable.yaml"
+	require.NoError(t, kubectlApplyManifest(t.Context(), manifest))
+
+	const egSelector = "gateway.envoyproxy.io/owning-gateway-name=upstream-unavailable"
+	requireWaitForPodReady(t, egNamespace, egSelector)
+
+	require.Eventually(t, func() bool {
+		fwd := requireNewHTTPPortForwarder(t, egNamespace, egSelector, egDefaultPort)
+		defer fwd.kill()
+
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, fwd.address()+"/v1/chat/completions",
+			bytes.NewReader([]byte(`{"model":"black-hole-model","messages":[{"role":"user","content":"Say this is a test"}]}`)))
+		require.NoError(t, err)
+		req.Header.Set("content-type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Logf("error: %v", err)
+			return false
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		t.Logf("status: %d, body: %s", resp.StatusCode, body)
+		if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("content-type") != "application/json" {
+			return false
+		}
+		var openAIError struct {
+			Error struct {
+				Type string `json:"type"`
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(body, &openAIError))
+		require.Equal(t, "upstream_unavailable", openAIError.Error.Type)
+		require.Equal(t, "503", openAIError.Error.Code)
+		return true
+	}, 1*time.Minute, 1*time.Second)
+}
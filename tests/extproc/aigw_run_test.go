@@ -0,0 +1,99 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+//go:build test_extproc
+
+package extproc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/tests/internal/testupstreamlib"
+)
+
+// TestAIGWRun tests that "aigw run" serves the external processor with the simplified configuration, and that
+// the Envoy configuration written by it routes a chat completion to the test upstream.
+func TestAIGWRun(t *testing.T) {
+	requireBinaries(t)
+	_, err := os.Stat(aigwExecutablePath())
+	require.NoError(t, err, "aigw binary not found in the root of the repository")
+	requireTestUpstream(t)
+
+	tmpDir := t.TempDir()
+	configPath := tmpDir + "/aigw-run.yaml"
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+backends:
+  - name: testupstream
+    schema: OpenAI
+    address: http://127.0.0.1:8080
+    apiKeyEnv: TEST_AIGW_RUN_API_KEY
+rules:
+  - model: some-model
+    backends:
+      - name: testupstream
+`), 0o600))
+	envoyConfigPath := tmpDir + "/envoy.yaml"
+	const listenerPort = 1975
+
+	cmd := exec.CommandContext(t.Context(), aigwExecutablePath(), "run", // #nosec G204
+		"-extProcAddr", "127.0.0.1:1073",
+		"-envoyConfig", envoyConfigPath,
+		"-listenerPort", strconv.Itoa(listenerPort),
+		configPath,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "TEST_AIGW_RUN_API_KEY=sk-aigw-run")
+	require.NoError(t, cmd.Start())
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(envoyConfigPath)
+		return err == nil
+	}, 30*time.Second, 200*time.Millisecond)
+
+	envoy := exec.CommandContext(t.Context(), "envoy", "-c", envoyConfigPath, "--log-level", "warn",
+		"--base-id", "1") // #nosec G204
+	envoy.Stdout = os.Stdout
+	envoy.Stderr = os.Stderr
+	require.NoError(t, envoy.Start())
+
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://localhost:%d/v1/chat/completions", listenerPort),
+			strings.NewReader(`{"model":"some-model","messages":[{"role":"user","content":"Hi"}]}`))
+		require.NoError(t, err)
+		req.Header.Set(testupstreamlib.ExpectedPathHeaderKey, base64.StdEncoding.EncodeToString([]byte("/v1/chat/completions")))
+		req.Header.Set(testupstreamlib.ExpectedHeadersKey, base64.StdEncoding.EncodeToString([]byte("Authorization:Bearer sk-aigw-run")))
+		req.Header.Set(testupstreamlib.ResponseBodyHeaderKey, base64.StdEncoding.EncodeToString(
+			[]byte(`{"choices":[{"message":{"role":"assistant","content":"Hello!"}}],"usage":{"total_tokens":3}}`)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Logf("error: %v", err)
+			return false
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		if resp.StatusCode != http.StatusOK {
+			t.Logf("unexpected status code: %d, body: %s", resp.StatusCode, body)
+			return false
+		}
+		return strings.Contains(string(body), "Hello!")
+	}, 30*time.Second, 500*time.Millisecond)
+}
+
+func aigwExecutablePath() string {
+	return fmt.Sprintf("../../out/aigw-%s-%s", runtime.GOOS, runtime.GOARCH)
+}
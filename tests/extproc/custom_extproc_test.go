@@ -10,8 +10,11 @@ package extproc
 import (
 	"encoding/base64"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -36,7 +39,7 @@ func TestExtProcCustomRouter(t *testing.T) {
 		ModelNameHeaderKey:       "x-model-name",
 		Rules: []filterapi.RouteRule{
 			{
-				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema}},
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema, Weight: 1}},
 				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "something-cool"}},
 			},
 		},
@@ -81,3 +84,108 @@ func TestExtProcCustomRouter(t *testing.T) {
 		require.Contains(t, string(stdout), "model name: something-cool") // This must be logged by the custom router.
 	}()
 }
+
+// TestExtProcCustomTranslator tests examples/extproc_custom_translator.
+func TestExtProcCustomTranslator(t *testing.T) {
+	requireBinaries(t)
+	requireRunEnvoy(t, "/dev/null")
+	requireTestUpstream(t)
+	configPath := t.TempDir() + "/extproc-config.yaml"
+	requireWriteFilterConfig(t, configPath, &filterapi.Config{
+		Schema:                   openAISchema,
+		SelectedBackendHeaderKey: "x-selected-backend-name",
+		ModelNameHeaderKey:       "x-model-name",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: filterapi.VersionedAPISchema{Name: "Toy"}, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "toy-model"}},
+			},
+		},
+	})
+	requireExtProc(t, os.Stdout, fmt.Sprintf("../../out/extproc_custom_translator-%s-%s",
+		runtime.GOOS, runtime.GOARCH), configPath)
+
+	require.Eventually(t, func() bool {
+		client := openai.NewClient(option.WithBaseURL(listenerAddress+"/v1/"),
+			option.WithHeader(
+				testupstreamlib.ExpectedPathHeaderKey, base64.StdEncoding.EncodeToString([]byte("/generate"))),
+			option.WithHeader(testupstreamlib.ExpectedRequestBodyHeaderKey,
+				base64.StdEncoding.EncodeToString([]byte(`{"model":"toy-model","prompt":"user: Say this is a test\n"}`))),
+			option.WithHeader(testupstreamlib.ResponseBodyHeaderKey,
+				base64.StdEncoding.EncodeToString([]byte(`{"output":"This is a test.","input_tokens":5,"output_tokens":4}`)),
+			))
+		chatCompletion, err := client.Chat.Completions.New(t.Context(), openai.ChatCompletionNewParams{
+			Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+				openai.UserMessage("Say this is a test"),
+			}),
+			Model: openai.F("toy-model"),
+		})
+		if err != nil {
+			t.Logf("error: %v", err)
+			return false
+		}
+		require.Len(t, chatCompletion.Choices, 1)
+		require.Equal(t, "This is a test.", chatCompletion.Choices[0].Message.Content)
+		require.Equal(t, int64(9), chatCompletion.Usage.TotalTokens)
+		return true
+	}, 10*time.Second, 1*time.Second)
+}
+
+// TestExtProcCustomProcessor tests examples/extproc_custom_processor.
+func TestExtProcCustomProcessor(t *testing.T) {
+	requireBinaries(t)
+	requireRunEnvoy(t, "/dev/null")
+	requireTestUpstream(t)
+	configPath := t.TempDir() + "/extproc-config.yaml"
+	requireWriteFilterConfig(t, configPath, &filterapi.Config{
+		Schema:                   openAISchema,
+		SelectedBackendHeaderKey: "x-selected-backend-name",
+		ModelNameHeaderKey:       "x-model-name",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-model-name", Value: "whisper-1"}},
+			},
+		},
+	})
+	requireExtProc(t, os.Stdout, fmt.Sprintf("../../out/extproc_custom_processor-%s-%s",
+		runtime.GOOS, runtime.GOARCH), configPath)
+
+	for _, tc := range []struct {
+		name      string
+		model     string
+		expStatus int
+	}{
+		{name: "routed", model: "whisper-1", expStatus: http.StatusOK},
+		{name: "unknown model", model: "unknown", expStatus: http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Eventually(t, func() bool {
+				req, err := http.NewRequestWithContext(t.Context(), http.MethodPost,
+					listenerAddress+"/v1/audio/transcriptions", strings.NewReader("some-audio"))
+				require.NoError(t, err)
+				req.Header.Set("x-model-name", tc.model)
+				req.Header.Set(testupstreamlib.ExpectedPathHeaderKey,
+					base64.StdEncoding.EncodeToString([]byte("/v1/audio/transcriptions")))
+				req.Header.Set(testupstreamlib.ResponseBodyHeaderKey,
+					base64.StdEncoding.EncodeToString([]byte(`{"text":"This is a test."}`)))
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Logf("error: %v", err)
+					return false
+				}
+				defer func() { _ = resp.Body.Close() }()
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				if resp.StatusCode != tc.expStatus {
+					t.Logf("unexpected status %d: %s", resp.StatusCode, body)
+					return false
+				}
+				if tc.expStatus == http.StatusOK {
+					require.JSONEq(t, `{"text":"This is a test."}`, string(body))
+				}
+				return true
+			}, 10*time.Second, 1*time.Second)
+		})
+	}
+}
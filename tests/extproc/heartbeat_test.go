@@ -0,0 +1,88 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+//go:build test_extproc
+
+package extproc
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/tests/internal/testupstreamlib"
+)
+
+// TestWithTestUpstream_Heartbeat tests that the external processor injects the SSE heartbeats into the streaming
+// response while the test upstream delays the chunks after the first one, and never before the first chunk.
+func TestWithTestUpstream_Heartbeat(t *testing.T) {
+	requireBinaries(t)
+	requireRunEnvoy(t, "/dev/null")
+	requireTestUpstream(t)
+
+	configPath := t.TempDir() + "/extproc-config.yaml"
+	requireWriteFilterConfig(t, configPath, &filterapi.Config{
+		Schema: openAISchema,
+		// This can be any header key, but it must match the envoy.yaml routing configuration.
+		SelectedBackendHeaderKey: "x-selected-backend-name",
+		ModelNameHeaderKey:       "x-model-name",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "openai"}},
+			},
+		},
+		Streaming: &filterapi.StreamingConfig{HeartbeatInterval: "1s"},
+	})
+	requireExtProc(t, os.Stdout, extProcExecutablePath(), configPath)
+
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodPost, listenerAddress+"/v1/chat/completions",
+			strings.NewReader(`{"model":"something","messages":[{"role":"user","content":"Hi"}],"stream":true}`))
+		require.NoError(t, err)
+		req.Header.Set("x-test-backend", "openai")
+		req.Header.Set(testupstreamlib.ResponseTypeKey, "sse")
+		req.Header.Set(testupstreamlib.ResponseDelayKey, "3500ms")
+		req.Header.Set(testupstreamlib.ResponseBodyHeaderKey, base64.StdEncoding.EncodeToString([]byte(
+			`{"choices":[{"index":0,"delta":{"content":"Hello"}}]}`+"\n"+
+				`{"choices":[{"index":0,"delta":{"content":"!"}}]}`+"\n"+
+				"[DONE]")))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Logf("error: %v", err)
+			return false
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Logf("unexpected status %d", resp.StatusCode)
+			return false
+		}
+
+		var heartbeats, data int
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			switch line := scanner.Text(); {
+			case line == ": heartbeat":
+				// The heartbeats are only sent while the backend is idle after the first chunk.
+				require.Equal(t, 1, data, "heartbeat before the first chunk or after the delay")
+				heartbeats++
+			case strings.HasPrefix(line, "data: "):
+				data++
+			}
+		}
+		require.NoError(t, scanner.Err())
+		t.Logf("heartbeats: %d, data: %d", heartbeats, data)
+		require.GreaterOrEqual(t, heartbeats, 2)
+		require.Equal(t, 3, data)
+		return true
+	}, 30*time.Second, 1*time.Second)
+}
@@ -0,0 +1,101 @@
+// Copyright Envoy AI Gateway Authors
+// SPDX-License-Identifier: Apache-2.0
+// The full text of the Apache license is available in the LICENSE file at
+// the root of the repo.
+
+//go:build test_extproc
+
+package extproc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/envoyproxy/ai-gateway/filterapi"
+	"github.com/envoyproxy/ai-gateway/tests/internal/testupstreamlib"
+)
+
+// TestWithTestUpstream_Latency tests that the time to first token and the upstream duration of the streaming
+// response are set to the dynamic metadata and logged by Envoy. The test upstream sleeps for the streaming interval,
+// 200ms by default, before sending each event.
+func TestWithTestUpstream_Latency(t *testing.T) {
+	requireBinaries(t)
+	accessLogPath := t.TempDir() + "/access.log"
+	requireRunEnvoy(t, accessLogPath)
+	requireTestUpstream(t)
+
+	configPath := t.TempDir() + "/extproc-config.yaml"
+	requireWriteFilterConfig(t, configPath, &filterapi.Config{
+		MetadataNamespace: "ai_gateway_llm_ns",
+		Schema:            openAISchema,
+		// This can be any header key, but it must match the envoy.yaml routing configuration.
+		SelectedBackendHeaderKey: "x-selected-backend-name",
+		ModelNameHeaderKey:       "x-model-name",
+		Rules: []filterapi.RouteRule{
+			{
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema, Weight: 1}},
+				Headers:  []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "openai"}},
+			},
+		},
+	})
+	requireExtProc(t, os.Stdout, extProcExecutablePath(), configPath)
+
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodPost, listenerAddress+"/v1/chat/completions",
+			strings.NewReader(`{"model":"something","messages":[{"role":"user","content":"Hi"}],"stream":true}`))
+		require.NoError(t, err)
+		req.Header.Set("x-test-backend", "openai")
+		req.Header.Set(testupstreamlib.ResponseTypeKey, "sse")
+		req.Header.Set(testupstreamlib.ResponseBodyHeaderKey, base64.StdEncoding.EncodeToString([]byte(
+			`{"choices":[{"index":0,"delta":{"content":"Hello"}}]}`+"\n"+
+				`{"choices":[{"index":0,"delta":{"content":"!"}}]}`+"\n"+
+				"[DONE]")))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Logf("error: %v", err)
+			return false
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			t.Logf("unexpected status %d", resp.StatusCode)
+			return false
+		}
+		_, err = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, err)
+		return true
+	}, 30*time.Second, 1*time.Second)
+
+	require.Eventually(t, func() bool {
+		accessLog, err := os.ReadFile(accessLogPath)
+		require.NoError(t, err)
+		scanner := bufio.NewScanner(bytes.NewReader(accessLog))
+		for scanner.Scan() {
+			var line struct {
+				TimeToFirstTokenMillis float64 `json:"time_to_first_token_ms"`
+				UpstreamDurationMillis float64 `json:"upstream_duration_ms"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil || line.UpstreamDurationMillis == 0 {
+				continue
+			}
+			t.Logf("time to first token: %vms, upstream duration: %vms", line.TimeToFirstTokenMillis, line.UpstreamDurationMillis)
+			// The first event is sent after one interval, and the last one after three.
+			require.GreaterOrEqual(t, line.TimeToFirstTokenMillis, float64(200))
+			require.Less(t, line.TimeToFirstTokenMillis, float64(600))
+			require.GreaterOrEqual(t, line.UpstreamDurationMillis, float64(600))
+			require.Less(t, line.UpstreamDurationMillis, float64(5000))
+			return true
+		}
+		t.Log("the latencies are not logged yet")
+		return false
+	}, 10*time.Second, 500*time.Millisecond)
+}
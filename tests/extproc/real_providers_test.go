@@ -46,14 +46,14 @@ func TestWithRealProviders(t *testing.T) {
 		ModelNameHeaderKey:       "x-model-name",
 		Rules: []filterapi.RouteRule{
 			{
-				Backends: []filterapi.Backend{{Name: "openai", Schema: openAISchema, Auth: &filterapi.BackendAuth{
+				Backends: []filterapi.Backend{{Name: "openai", Schema: openAISchema, Weight: 1, Auth: &filterapi.BackendAuth{
 					APIKey: &filterapi.APIKeyAuth{Filename: cc.openAIAPIKeyFilePath},
 				}}},
 				Headers: []filterapi.HeaderMatch{{Name: "x-model-name", Value: "gpt-4o-mini"}},
 			},
 			{
 				Backends: []filterapi.Backend{
-					{Name: "aws-bedrock", Schema: awsBedrockSchema, Auth: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{
+					{Name: "aws-bedrock", Schema: awsBedrockSchema, Weight: 1, Auth: &filterapi.BackendAuth{AWSAuth: &filterapi.AWSAuth{
 						CredentialFileName: cc.awsFilePath,
 						Region:             "us-east-1",
 					}}},
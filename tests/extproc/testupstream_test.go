@@ -14,6 +14,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -35,6 +36,8 @@ func TestWithTestUpstream(t *testing.T) {
 	requireRunEnvoy(t, accessLogPath)
 	configPath := t.TempDir() + "/extproc-config.yaml"
 	requireTestUpstream(t)
+	apiKeyPath := t.TempDir() + "/api-key"
+	require.NoError(t, os.WriteFile(apiKeyPath, []byte("test-api-key\n"), 0o600))
 
 	requireWriteFilterConfig(t, configPath, &filterapi.Config{
 		MetadataNamespace: "ai_gateway_llm_ns",
@@ -47,13 +50,41 @@ func TestWithTestUpstream(t *testing.T) {
 		ModelNameHeaderKey:       "x-model-name",
 		Rules: []filterapi.RouteRule{
 			{
-				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema}},
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: openAISchema, Weight: 1}},
 				Headers:  []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "openai"}},
 			},
 			{
-				Backends: []filterapi.Backend{{Name: "testupstream", Schema: awsBedrockSchema}},
+				Backends: []filterapi.Backend{{Name: "testupstream", Schema: awsBedrockSchema, Weight: 1}},
 				Headers:  []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "aws-bedrock"}},
 			},
+			{
+				Backends: []filterapi.Backend{{
+					Name: "testupstream", Schema: openAISchema, Weight: 1,
+					OpenAI: &filterapi.OpenAIConfig{Organization: "org-foo", Project: "proj_bar"},
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "openai-org"}},
+			},
+			{
+				Backends: []filterapi.Backend{{
+					Name: "testupstream", Schema: openAISchema, Weight: 1, PathOverride: "/serving/v2/{model}/chat/completions",
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "openai-path-override"}},
+			},
+			{
+				Backends: []filterapi.Backend{{
+					Name: "testupstream", Schema: awsBedrockSchema, Weight: 1, PathOverride: "/bedrock/{model}/converse",
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "aws-bedrock-path-override"}},
+			},
+			{
+				Backends: []filterapi.Backend{{
+					Name: "testupstream", Schema: openAISchema, Weight: 1,
+					Auth: &filterapi.BackendAuth{APIKey: &filterapi.APIKeyAuth{
+						Filename: apiKeyPath, HeaderName: "x-api-key", ValueTemplate: "{key}",
+					}},
+				}},
+				Headers: []filterapi.HeaderMatch{{Name: "x-test-backend", Value: "openai-x-api-key"}},
+			},
 		},
 	})
 
@@ -62,6 +93,10 @@ func TestWithTestUpstream(t *testing.T) {
 		Data: []openai.Model{
 			{ID: "openai", Object: "model", OwnedBy: "Envoy AI Gateway"},
 			{ID: "aws-bedrock", Object: "model", OwnedBy: "Envoy AI Gateway"},
+			{ID: "openai-org", Object: "model", OwnedBy: "Envoy AI Gateway"},
+			{ID: "openai-path-override", Object: "model", OwnedBy: "Envoy AI Gateway"},
+			{ID: "aws-bedrock-path-override", Object: "model", OwnedBy: "Envoy AI Gateway"},
+			{ID: "openai-x-api-key", Object: "model", OwnedBy: "Envoy AI Gateway"},
 		},
 	}
 
@@ -88,8 +123,18 @@ func TestWithTestUpstream(t *testing.T) {
 		// The value is a base64 encoded string of comma separated key-value pairs.
 		// E.g. "key1:value1,key2:value2".
 		responseHeaders,
+		// responseAWSError is the AWS error response in the format of "<type>:<status>:<message>" as implemented
+		// by the test upstream. This takes precedence over the response status, type and body.
+		responseAWSError,
 		// expPath is the expected path to be sent to the test upstream.
-		expPath string
+		expPath,
+		// expHeaders are the expected headers to be sent to the test upstream, in the format of "key1:value1,key2:value2".
+		expHeaders,
+		// nonExpHeaders are the headers expected to be absent in the request to the test upstream, in the format of
+		// "key1,key2".
+		nonExpHeaders string
+		// requestHeaders are the additional headers sent by the client.
+		requestHeaders map[string]string
 		// expRequestBody is the expected body to be sent to the test upstream.
 		// This can be used to test the request body translation.
 		expRequestBody string
@@ -112,15 +157,41 @@ func TestWithTestUpstream(t *testing.T) {
 			expStatus:      http.StatusInternalServerError,
 		},
 		{
-			name:            "aws system role - /v1/chat/completions",
-			backend:         "aws-bedrock",
+			name:                "aws system role - /v1/chat/completions",
+			backend:             "aws-bedrock",
+			path:                "/v1/chat/completions",
+			requestBody:         `{"model":"something","messages":[{"role":"system","content":"You are a chatbot."}]}`,
+			expPath:             "/model/something/converse",
+			responseBody:        `{"output":{"message":{"content":[{"text":"response"},{"text":"from"},{"text":"assistant"}],"role":"assistant"}},"stopReason":null,"usage":{"inputTokens":10,"outputTokens":20,"totalTokens":30}}`,
+			expRequestBody:      `{"inferenceConfig":{},"messages":[],"modelId":null,"system":[{"text":"You are a chatbot."}]}`,
+			expStatus:           http.StatusOK,
+			expResponseBodyFunc: checkBodyIgnoringIdentity(`{"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"finish_reason":"stop","index":0,"logprobs":{},"message":{"content":"response","role":"assistant"}}],"object":"chat.completion","usage":{"completion_tokens":20,"prompt_tokens":10,"total_tokens":30}}`),
+		},
+		{
+			name:            "openai - /v1/chat/completions - organization and project",
+			backend:         "openai-org",
 			path:            "/v1/chat/completions",
+			method:          http.MethodPost,
 			requestBody:     `{"model":"something","messages":[{"role":"system","content":"You are a chatbot."}]}`,
-			expPath:         "/model/something/converse",
-			responseBody:    `{"output":{"message":{"content":[{"text":"response"},{"text":"from"},{"text":"assistant"}],"role":"assistant"}},"stopReason":null,"usage":{"inputTokens":10,"outputTokens":20,"totalTokens":30}}`,
-			expRequestBody:  `{"inferenceConfig":{},"messages":[],"modelId":null,"system":[{"text":"You are a chatbot."}]}`,
+			expPath:         "/v1/chat/completions",
+			expHeaders:      "OpenAI-Organization:org-foo,OpenAI-Project:proj_bar",
+			responseBody:    `{"choices":[{"message":{"content":"This is a test."}}]}`,
 			expStatus:       http.StatusOK,
-			expResponseBody: `{"choices":[{"finish_reason":"stop","index":0,"logprobs":{},"message":{"content":"response","role":"assistant"}}],"object":"chat.completion","usage":{"completion_tokens":20,"prompt_tokens":10,"total_tokens":30}}`,
+			expResponseBody: `{"choices":[{"message":{"content":"This is a test."}}]}`,
+		},
+		{
+			name:            "openai - /v1/chat/completions - custom API key header",
+			backend:         "openai-x-api-key",
+			path:            "/v1/chat/completions",
+			method:          http.MethodPost,
+			requestBody:     `{"model":"something","messages":[{"role":"system","content":"You are a chatbot."}]}`,
+			requestHeaders:  map[string]string{"Authorization": "Bearer client-token"},
+			expPath:         "/v1/chat/completions",
+			expHeaders:      "x-api-key:test-api-key",
+			nonExpHeaders:   "Authorization",
+			responseBody:    `{"choices":[{"message":{"content":"This is a test."}}]}`,
+			expStatus:       http.StatusOK,
+			expResponseBody: `{"choices":[{"message":{"content":"This is a test."}}]}`,
 		},
 		{
 			name:            "openai - /v1/chat/completions",
@@ -133,6 +204,29 @@ func TestWithTestUpstream(t *testing.T) {
 			expStatus:       http.StatusOK,
 			expResponseBody: `{"choices":[{"message":{"content":"This is a test."}}]}`,
 		},
+		{
+			name:            "openai - /v1/chat/completions - path override",
+			backend:         "openai-path-override",
+			path:            "/v1/chat/completions",
+			method:          http.MethodPost,
+			requestBody:     `{"model":"something","messages":[{"role":"system","content":"You are a chatbot."}]}`,
+			expPath:         "/serving/v2/something/chat/completions",
+			responseBody:    `{"choices":[{"message":{"content":"This is a test."}}]}`,
+			expStatus:       http.StatusOK,
+			expResponseBody: `{"choices":[{"message":{"content":"This is a test."}}]}`,
+		},
+		{
+			name:                "aws - /v1/chat/completions - path override",
+			backend:             "aws-bedrock-path-override",
+			path:                "/v1/chat/completions",
+			method:              http.MethodPost,
+			requestBody:         `{"model":"something","messages":[{"role":"system","content":"You are a chatbot."}]}`,
+			expPath:             "/bedrock/something/converse",
+			responseBody:        `{"output":{"message":{"content":[{"text":"response"}],"role":"assistant"}},"stopReason":null,"usage":{"inputTokens":10,"outputTokens":20,"totalTokens":30}}`,
+			expRequestBody:      `{"inferenceConfig":{},"messages":[],"modelId":null,"system":[{"text":"You are a chatbot."}]}`,
+			expStatus:           http.StatusOK,
+			expResponseBodyFunc: checkBodyIgnoringIdentity(`{"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"finish_reason":"stop","index":0,"logprobs":{},"message":{"content":"response","role":"assistant"}}],"object":"chat.completion","usage":{"completion_tokens":20,"prompt_tokens":10,"total_tokens":30}}`),
+		},
 		{
 			name:           "aws - /v1/chat/completions - streaming",
 			backend:        "aws-bedrock",
@@ -151,22 +245,22 @@ func TestWithTestUpstream(t *testing.T) {
 {"usage":{"inputTokens":41, "outputTokens":36, "totalTokens":77}}
 `,
 			expStatus: http.StatusOK,
-			expResponseBody: `data: {"choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
+			expResponseBodyFunc: checkBodyIgnoringIdentity(`data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"delta":{"content":"","role":"assistant"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"role":"assistant","tool_calls":[{"id":"tooluse_QklrEHKjRu6Oc4BQUfy7ZQ","function":{"arguments":"","name":"cosine"},"type":"function"}]}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"delta":{"tool_calls":[{"id":"tooluse_QklrEHKjRu6Oc4BQUfy7ZQ","function":{"arguments":"","name":"cosine"},"type":"function"}]}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"Don","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"delta":{"content":"Don"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"'t worry,  I'm here to help. It","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"delta":{"content":"'t worry,  I'm here to help. It"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":" seems like you're testing my ability to respond appropriately","role":"assistant"}}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"delta":{"content":" seems like you're testing my ability to respond appropriately"}}],"object":"chat.completion.chunk"}
 
-data: {"choices":[{"delta":{"content":"","role":"assistant"},"finish_reason":"tool_calls"}],"object":"chat.completion.chunk"}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[{"delta":{},"finish_reason":"tool_calls"}],"object":"chat.completion.chunk"}
 
-data: {"object":"chat.completion.chunk","usage":{"completion_tokens":36,"prompt_tokens":41,"total_tokens":77}}
+data: {"id":"chatcmpl-123","created":1735689600,"model":"something","choices":[],"object":"chat.completion.chunk","usage":{"completion_tokens":36,"prompt_tokens":41,"total_tokens":77}}
 
 data: [DONE]
-`,
+`),
 		},
 		{
 			name:         "openai - /v1/chat/completions - streaming",
@@ -188,6 +282,38 @@ data: {"id":"chatcmpl-foo","object":"chat.completion.chunk","created":1731618222
 
 data: [DONE]
 
+`,
+		},
+		{
+			name:            "openai - /v1/chat/completions - logprobs",
+			backend:         "openai",
+			path:            "/v1/chat/completions",
+			method:          http.MethodPost,
+			requestBody:     `{"model":"something","messages":[{"role":"user","content":"Hi"}],"logprobs":true,"top_logprobs":1}`,
+			expRequestBody:  `{"model":"something","messages":[{"role":"user","content":"Hi"}],"logprobs":true,"top_logprobs":1}`,
+			expPath:         "/v1/chat/completions",
+			responseBody:    `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"logprobs":{"content":[{"token":"Hello","logprob":-0.0001,"bytes":[72,101,108,108,111],"top_logprobs":[{"token":"Hello","logprob":-0.0001,"bytes":[72,101,108,108,111]}]}],"refusal":null},"finish_reason":"stop"}]}`,
+			expStatus:       http.StatusOK,
+			expResponseBody: `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello"},"logprobs":{"content":[{"token":"Hello","logprob":-0.0001,"bytes":[72,101,108,108,111],"top_logprobs":[{"token":"Hello","logprob":-0.0001,"bytes":[72,101,108,108,111]}]}],"refusal":null},"finish_reason":"stop"}]}`,
+		},
+		{
+			name:           "openai - /v1/chat/completions - streaming logprobs",
+			backend:        "openai",
+			path:           "/v1/chat/completions",
+			responseType:   "sse",
+			method:         http.MethodPost,
+			requestBody:    `{"model":"something","messages":[{"role":"user","content":"Hi"}],"stream":true,"logprobs":true}`,
+			expRequestBody: `{"model":"something","messages":[{"role":"user","content":"Hi"}],"stream":true,"logprobs":true}`,
+			expPath:        "/v1/chat/completions",
+			responseBody: `
+{"id":"chatcmpl-foo","object":"chat.completion.chunk","created":1731618222,"model":"gpt-4o-mini-2024-07-18","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"},"logprobs":{"content":[{"token":"Hello","logprob":-0.0001,"bytes":[72,101,108,108,111],"top_logprobs":[]}],"refusal":null},"finish_reason":null}],"usage":null}
+[DONE]
+`,
+			expStatus: http.StatusOK,
+			expResponseBody: `data: {"id":"chatcmpl-foo","object":"chat.completion.chunk","created":1731618222,"model":"gpt-4o-mini-2024-07-18","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"},"logprobs":{"content":[{"token":"Hello","logprob":-0.0001,"bytes":[72,101,108,108,111],"top_logprobs":[]}],"refusal":null},"finish_reason":null}],"usage":null}
+
+data: [DONE]
+
 `,
 		},
 		{
@@ -203,6 +329,20 @@ data: [DONE]
 			responseBody:    `{"error": {"message": "missing required field", "type": "BadRequestError", "code": "400"}}`,
 			expResponseBody: `{"error": {"message": "missing required field", "type": "BadRequestError", "code": "400"}}`,
 		},
+		{
+			name:            "openai - /v1/chat/completions - HTML error response",
+			backend:         "openai",
+			path:            "/v1/chat/completions",
+			responseType:    "",
+			method:          http.MethodPost,
+			requestBody:     `{"model":"something","messages":[{"role":"system","content":"You are a chatbot."}], "stream": true}`,
+			expPath:         "/v1/chat/completions",
+			responseStatus:  "502",
+			expStatus:       http.StatusBadGateway,
+			responseHeaders: "content-type:text/html",
+			responseBody:    `<html><body>Bad Gateway</body></html>`,
+			expResponseBody: `{"type":"error","error":{"type":"OpenAIBackendError","code":"502","message":"\u003chtml\u003e\u003cbody\u003eBad Gateway\u003c/body\u003e\u003c/html\u003e"}}`,
+		},
 		{
 			name:            "aws-bedrock - /v1/chat/completions - error response",
 			backend:         "aws-bedrock",
@@ -217,6 +357,28 @@ data: [DONE]
 			responseBody:    `{"message": "aws bedrock rate limit exceeded"}`,
 			expResponseBody: `{"type":"error","error":{"type":"ThrottledException","code":"429","message":"aws bedrock rate limit exceeded"}}`,
 		},
+		{
+			name:             "aws-bedrock - /v1/chat/completions - exception",
+			backend:          "aws-bedrock",
+			path:             "/v1/chat/completions",
+			method:           http.MethodPost,
+			requestBody:      `{"model":"something","messages":[{"role":"user","content":"Hi"}]}`,
+			expPath:          "/model/something/converse",
+			responseAWSError: "ThrottledException:429:Too many requests, please wait before trying again.",
+			expStatus:        http.StatusTooManyRequests,
+			expResponseBody:  `{"type":"error","error":{"type":"ThrottledException","code":"429","message":"Too many requests, please wait before trying again."}}`,
+		},
+		{
+			name:             "aws-bedrock - /v1/chat/completions - plain text 503",
+			backend:          "aws-bedrock",
+			path:             "/v1/chat/completions",
+			method:           http.MethodPost,
+			requestBody:      `{"model":"something","messages":[{"role":"user","content":"Hi"}]}`,
+			expPath:          "/model/something/converse",
+			responseAWSError: ":503:no healthy upstream",
+			expStatus:        http.StatusServiceUnavailable,
+			expResponseBody:  `{"type":"error","error":{"type":"AWSBedrockBackendError","code":"503","message":"no healthy upstream"}}`,
+		},
 		{
 			name:                "openai - /v1/models",
 			backend:             "openai",
@@ -240,6 +402,18 @@ data: [DONE]
 				if tc.responseHeaders != "" {
 					req.Header.Set("x-response-headers", base64.StdEncoding.EncodeToString([]byte(tc.responseHeaders)))
 				}
+				if tc.responseAWSError != "" {
+					req.Header.Set(testupstreamlib.ResponseAWSErrorKey, tc.responseAWSError)
+				}
+				if tc.expHeaders != "" {
+					req.Header.Set(testupstreamlib.ExpectedHeadersKey, base64.StdEncoding.EncodeToString([]byte(tc.expHeaders)))
+				}
+				if tc.nonExpHeaders != "" {
+					req.Header.Set(testupstreamlib.NonExpectedRequestHeadersKey, base64.StdEncoding.EncodeToString([]byte(tc.nonExpHeaders)))
+				}
+				for k, v := range tc.requestHeaders {
+					req.Header.Set(k, v)
+				}
 				if tc.expRequestBody != "" {
 					req.Header.Set("x-expected-request-body", base64.StdEncoding.EncodeToString([]byte(tc.expRequestBody)))
 				}
@@ -274,6 +448,18 @@ data: [DONE]
 	}
 }
 
+// checkBodyIgnoringIdentity returns a function to check the response body translated from AWS Bedrock, ignoring
+// the generated ID and the creation timestamp. The want body must have "chatcmpl-123" and 1735689600 for them.
+func checkBodyIgnoringIdentity(want string) func(t require.TestingT, body []byte) {
+	return func(t require.TestingT, body []byte) {
+		got := chatCompletionIdentityPattern.ReplaceAllString(string(body), `"id":"chatcmpl-123","created":1735689600,`)
+		require.Equal(t, want, got)
+	}
+}
+
+// chatCompletionIdentityPattern matches the ID and the creation timestamp generated for the chat completion.
+var chatCompletionIdentityPattern = regexp.MustCompile(`"id":"chatcmpl-[0-9a-f-]+","created":[0-9]+,`)
+
 func checkModelsIgnoringTimestamps(want openai.ModelList) func(t require.TestingT, body []byte) {
 	return func(t require.TestingT, body []byte) {
 		var models openai.ModelList
@@ -41,7 +41,12 @@ func NewEnvTest(t *testing.T) (c client.Client, cfg *rest.Config, k kubernetes.I
 	for _, url := range []string{
 		egURLBase + "gateway.envoyproxy.io_envoyextensionpolicies.yaml",
 		egURLBase + "gateway.envoyproxy.io_httproutefilters.yaml",
+		egURLBase + "gateway.envoyproxy.io_envoyproxies.yaml",
+		egURLBase + "gateway.envoyproxy.io_backends.yaml",
+		egURLBase + "gateway.envoyproxy.io_securitypolicies.yaml",
+		egURLBase + "gateway.envoyproxy.io_backendtrafficpolicies.yaml",
 		gwAPIURLBase + "gateway.networking.k8s.io_httproutes.yaml",
+		gwAPIURLBase + "gateway.networking.k8s.io_referencegrants.yaml",
 	} {
 		path := filepath.Base(url) + "_for_tests.yaml"
 		crds = append(crds, requireThirdPartyCRDDownloaded(t, path, url))
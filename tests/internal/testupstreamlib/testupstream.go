@@ -24,6 +24,9 @@ const (
 	// ExpectedRequestBodyHeaderKey is the key for the expected request body in the request.
 	// The value is a base64 encoded.
 	ExpectedRequestBodyHeaderKey = "x-expected-request-body"
+	// ExpectedRequestBodyModeHeaderKey is the key for the mode of the comparison of the expected request body,
+	// either ExpectedRequestBodyModeExact or ExpectedRequestBodyModeJSON. The default is ExpectedRequestBodyModeExact.
+	ExpectedRequestBodyModeHeaderKey = "x-expected-request-body-mode"
 	// ResponseStatusKey is the key for the response status in the response, default is 200 if not set.
 	ResponseStatusKey = "x-response-status"
 	// ResponseHeadersKey is the key for the response headers in the response.
@@ -43,4 +46,22 @@ const (
 	ExpectedTestUpstreamIDKey = "x-expected-testupstream-id"
 	// ExpectedHostKey is the key for the expected host in the request.
 	ExpectedHostKey = "x-expected-host"
+	// ResponseDelayKey is the key for the delay after the first line of the "sse" response body is sent,
+	// in the Go duration format.
+	ResponseDelayKey = "x-response-delay"
+	// ResponseAWSErrorKey is the key for the AWS error response in the format of "<type>:<status>:<message>",
+	// e.g. "ThrottledException:429:Too many requests". The response is sent with the status, the x-amzn-errortype
+	// header of the type and the JSON body {"message": "<message>"} in the same way as the AWS Bedrock exceptions.
+	// When the type is empty, e.g. ":503:no healthy upstream", the message is sent as the plain text body instead,
+	// in the same way as the errors from the proxies in front of the backend. This takes precedence over
+	// the response status, type and body.
+	ResponseAWSErrorKey = "x-response-aws-error"
+)
+
+const (
+	// ExpectedRequestBodyModeExact compares the request body with the expected one byte by byte.
+	ExpectedRequestBodyModeExact = "exact"
+	// ExpectedRequestBodyModeJSON compares the request body with the expected one as JSON values, so that the key
+	// ordering and the whitespaces do not matter. The bodies are compared byte by byte if either is not valid JSON.
+	ExpectedRequestBodyModeJSON = "json"
 )
@@ -8,6 +8,7 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -15,9 +16,11 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/google/go-cmp/cmp"
 	"golang.org/x/exp/rand"
 
 	"github.com/envoyproxy/ai-gateway/internal/version"
@@ -52,7 +55,11 @@ func doMain(l net.Listener) {
 		}
 	}
 	defer l.Close()
-	http.HandleFunc("/health", func(writer http.ResponseWriter, _ *http.Request) { writer.WriteHeader(http.StatusOK) })
+	http.HandleFunc("/health", func(writer http.ResponseWriter, r *http.Request) {
+		if checkNonExpectedHeaders(writer, r) {
+			writer.WriteHeader(http.StatusOK)
+		}
+	})
 	http.HandleFunc("/", handler)
 	if err := http.Serve(l, nil); err != nil { // nolint: gosec
 		logger.Printf("failed to serve: %v", err)
@@ -103,27 +110,8 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		logger.Println("no expected headers")
 	}
 
-	if v := r.Header.Get(testupstreamlib.NonExpectedRequestHeadersKey); v != "" {
-		nonExpectedHeaders, err := base64.StdEncoding.DecodeString(v)
-		if err != nil {
-			logger.Println("failed to decode the non-expected headers")
-			http.Error(w, "failed to decode the non-expected headers", http.StatusBadRequest)
-			return
-		}
-		logger.Println("non-expected headers", string(nonExpectedHeaders))
-
-		// Comma separated key-value pairs.
-		for _, kv := range bytes.Split(nonExpectedHeaders, []byte(",")) {
-			key := string(kv)
-			if r.Header.Get(key) != "" {
-				logger.Printf("unexpected header %q presence with value %q\n", key, r.Header.Get(key))
-				http.Error(w, "unexpected header "+key+" presence with value "+r.Header.Get(key), http.StatusBadRequest)
-				return
-			}
-			logger.Printf("header %q absent\n", key)
-		}
-	} else {
-		logger.Println("no non-expected headers in the request")
+	if !checkNonExpectedHeaders(w, r) {
+		return
 	}
 
 	if v := r.Header.Get(testupstreamlib.ExpectedTestUpstreamIDKey); v != "" {
@@ -169,9 +157,22 @@ func handler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if string(expectedBody) != string(requestBody) {
-			logger.Println("unexpected request body: got", string(requestBody), "expected", string(expectedBody))
-			http.Error(w, "unexpected request body: got "+string(requestBody)+", expected "+string(expectedBody), http.StatusBadRequest)
+		switch mode := r.Header.Get(testupstreamlib.ExpectedRequestBodyModeHeaderKey); mode {
+		case "", testupstreamlib.ExpectedRequestBodyModeExact:
+			if string(expectedBody) != string(requestBody) {
+				logger.Println("unexpected request body: got", string(requestBody), "expected", string(expectedBody))
+				http.Error(w, "unexpected request body: got "+string(requestBody)+", expected "+string(expectedBody), http.StatusBadRequest)
+				return
+			}
+		case testupstreamlib.ExpectedRequestBodyModeJSON:
+			if diff := jsonBodyDiff(expectedBody, requestBody); diff != "" {
+				logger.Println("unexpected request body:", diff)
+				http.Error(w, "unexpected request body: "+diff, http.StatusBadRequest)
+				return
+			}
+		default:
+			logger.Println("invalid expected request body mode", mode)
+			http.Error(w, "invalid expected request body mode "+mode, http.StatusBadRequest)
 			return
 		}
 	} else {
@@ -205,6 +206,10 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		logger.Println("no response headers")
 	}
 	w.Header().Set("testupstream-id", os.Getenv("TESTUPSTREAM_ID"))
+	if v := r.Header.Get(testupstreamlib.ResponseAWSErrorKey); v != "" {
+		writeAWSError(w, v)
+		return
+	}
 	status := http.StatusOK
 	if v := r.Header.Get(testupstreamlib.ResponseStatusKey); v != "" {
 		status, err = strconv.Atoi(v)
@@ -226,13 +231,27 @@ func handler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		var delay time.Duration
+		if v := r.Header.Get(testupstreamlib.ResponseDelayKey); v != "" {
+			delay, err = time.ParseDuration(v)
+			if err != nil {
+				logger.Println("failed to parse the response delay")
+				http.Error(w, "failed to parse the response delay", http.StatusBadRequest)
+				return
+			}
+		}
+
 		w.WriteHeader(status)
-		for _, line := range bytes.Split(expResponseBody, []byte("\n")) {
+		for i, line := range bytes.Split(expResponseBody, []byte("\n")) {
 			line := string(line)
 			if line == "" {
 				continue
 			}
 			time.Sleep(streamingInterval)
+			if i == 1 {
+				// The client sees an idle response body during the delay after the first line.
+				time.Sleep(delay)
+			}
 
 			if _, err = w.Write([]byte(fmt.Sprintf("data: %s\n\n", line))); err != nil {
 				logger.Println("failed to write the response body")
@@ -353,6 +372,36 @@ var chatCompletionFakeResponses = []string{
 	`Expecto Patronum!`,
 }
 
+// writeAWSError writes the AWS error response specified by the value of [testupstreamlib.ResponseAWSErrorKey].
+func writeAWSError(w http.ResponseWriter, v string) {
+	parts := strings.SplitN(v, ":", 3)
+	if len(parts) != 3 {
+		logger.Println("invalid aws error", v)
+		http.Error(w, "invalid aws error "+v, http.StatusBadRequest)
+		return
+	}
+	errorType, message := parts[0], parts[2]
+	status, err := strconv.Atoi(parts[1])
+	if err != nil {
+		logger.Println("failed to parse the aws error status")
+		http.Error(w, "failed to parse the aws error status", http.StatusBadRequest)
+		return
+	}
+
+	var body []byte
+	if errorType == "" {
+		w.Header().Set("Content-Type", "text/plain")
+		body = []byte(message)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-amzn-errortype", errorType)
+		body, _ = json.Marshal(map[string]string{"message": message})
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+	logger.Println("aws error sent:", string(body))
+}
+
 func getFakeResponse(path string) ([]byte, error) {
 	switch path {
 	case "/v1/chat/completions":
@@ -366,3 +415,47 @@ func getFakeResponse(path string) ([]byte, error) {
 		return nil, fmt.Errorf("unknown path: %s", path)
 	}
 }
+
+// checkNonExpectedHeaders responds with 400 and returns false if any of the headers listed in the
+// NonExpectedRequestHeadersKey header is present in the request.
+func checkNonExpectedHeaders(w http.ResponseWriter, r *http.Request) bool {
+	if v := r.Header.Get(testupstreamlib.NonExpectedRequestHeadersKey); v != "" {
+		nonExpectedHeaders, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			logger.Println("failed to decode the non-expected headers")
+			http.Error(w, "failed to decode the non-expected headers", http.StatusBadRequest)
+			return false
+		}
+		logger.Println("non-expected headers", string(nonExpectedHeaders))
+
+		// Comma separated key-value pairs.
+		for _, kv := range bytes.Split(nonExpectedHeaders, []byte(",")) {
+			key := string(kv)
+			if r.Header.Get(key) != "" {
+				logger.Printf("unexpected header %q presence with value %q\n", key, r.Header.Get(key))
+				http.Error(w, "unexpected header "+key+" presence with value "+r.Header.Get(key), http.StatusBadRequest)
+				return false
+			}
+			logger.Printf("header %q absent\n", key)
+		}
+	} else {
+		logger.Println("no non-expected headers in the request")
+	}
+	return true
+}
+
+// jsonBodyDiff compares the expected and actual bodies as JSON values and returns the difference, which is empty
+// if they are equal. The bodies are compared byte by byte if either is not valid JSON.
+func jsonBodyDiff(expected, actual []byte) string {
+	var expectedValue, actualValue any
+	if json.Unmarshal(expected, &expectedValue) != nil || json.Unmarshal(actual, &actualValue) != nil {
+		if bytes.Equal(expected, actual) {
+			return ""
+		}
+		return "got " + string(actual) + ", expected " + string(expected)
+	}
+	if diff := cmp.Diff(expectedValue, actualValue); diff != "" {
+		return "(-expected +got):\n" + diff
+	}
+	return ""
+}
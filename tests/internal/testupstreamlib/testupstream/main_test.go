@@ -73,6 +73,37 @@ func Test_main(t *testing.T) {
 		}
 	})
 
+	t.Run("sse with delay", func(t *testing.T) {
+		t.Parallel()
+		request, err := http.NewRequest("GET", "http://"+l.Addr().String()+"/sse", nil)
+		require.NoError(t, err)
+		request.Header.Set(testupstreamlib.ResponseTypeKey, "sse")
+		request.Header.Set(testupstreamlib.ResponseDelayKey, "1s")
+		request.Header.Set(testupstreamlib.ResponseBodyHeaderKey, base64.StdEncoding.EncodeToString([]byte("1\n2")))
+
+		now := time.Now()
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer func() {
+			_ = response.Body.Close()
+		}()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		reader := bufio.NewReader(response.Body)
+		dataLine, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "data: 1\n", dataLine)
+		// The first line is sent before the delay.
+		require.Less(t, time.Since(now), 500*time.Millisecond, time.Since(now).String())
+
+		_, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		dataLine, err = reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "data: 2\n", dataLine)
+		require.Greater(t, time.Since(now), time.Second, time.Since(now).String())
+	})
+
 	t.Run("health", func(t *testing.T) {
 		t.Parallel()
 		request, err := http.NewRequest("GET", "http://"+l.Addr().String()+"/health", nil)
@@ -85,6 +116,21 @@ func Test_main(t *testing.T) {
 		require.Equal(t, http.StatusOK, response.StatusCode)
 	})
 
+	t.Run("health with non-expected header", func(t *testing.T) {
+		t.Parallel()
+		request, err := http.NewRequest("GET", "http://"+l.Addr().String()+"/health", nil)
+		require.NoError(t, err)
+		request.Header.Set(testupstreamlib.NonExpectedRequestHeadersKey,
+			base64.StdEncoding.EncodeToString([]byte("x-ai-eg-model")))
+		request.Header.Set("x-ai-eg-model", "some-model")
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer func() {
+			_ = response.Body.Close()
+		}()
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
 	t.Run("not expected path", func(t *testing.T) {
 		t.Parallel()
 		request, err := http.NewRequest("GET",
@@ -133,6 +179,101 @@ func Test_main(t *testing.T) {
 		require.Equal(t, "unexpected request body: got not expected request body, expected expected request body\n", string(responseBody))
 	})
 
+	t.Run("json body", func(t *testing.T) {
+		t.Parallel()
+		for _, tc := range []struct {
+			name        string
+			body        string
+			expBody     string
+			expStatus   int
+			expResponse string
+			// expResponseContains is used instead of expResponse when the response is not stable.
+			expResponseContains []string
+		}{
+			{
+				name:      "match",
+				body:      `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`,
+				expBody:   `{ "messages": [ {"content": "hi", "role": "user"} ], "model": "gpt-4o" }`,
+				expStatus: http.StatusOK,
+			},
+			{
+				name:      "mismatch",
+				body:      `{"model":"gpt-4o","stream":true}`,
+				expBody:   `{"model":"gpt-4o","stream":false}`,
+				expStatus: http.StatusBadRequest,
+				// The output of the diff is not stable, so only the lines with the difference are checked.
+				expResponseContains: []string{"unexpected request body: (-expected +got):", `"stream": bool(false)`, `"stream": bool(true)`},
+			},
+			{
+				name:      "invalid json match",
+				body:      `not json`,
+				expBody:   `not json`,
+				expStatus: http.StatusOK,
+			},
+			{
+				name:        "invalid json mismatch",
+				body:        `{"model":"gpt-4o"}`,
+				expBody:     `{"model":`,
+				expStatus:   http.StatusBadRequest,
+				expResponse: "unexpected request body: got {\"model\":\"gpt-4o\"}, expected {\"model\":\n",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				request, err := http.NewRequest("GET",
+					"http://"+l.Addr().String()+"/", bytes.NewBuffer([]byte(tc.body)))
+				require.NoError(t, err)
+
+				request.Header.Set(testupstreamlib.ExpectedPathHeaderKey,
+					base64.StdEncoding.EncodeToString([]byte("/")))
+				request.Header.Set(testupstreamlib.ExpectedRequestBodyHeaderKey,
+					base64.StdEncoding.EncodeToString([]byte(tc.expBody)))
+				request.Header.Set(testupstreamlib.ExpectedRequestBodyModeHeaderKey, testupstreamlib.ExpectedRequestBodyModeJSON)
+				request.Header.Set(testupstreamlib.ResponseBodyHeaderKey,
+					base64.StdEncoding.EncodeToString([]byte("response body")))
+
+				response, err := http.DefaultClient.Do(request)
+				require.NoError(t, err)
+				defer func() {
+					_ = response.Body.Close()
+				}()
+				require.Equal(t, tc.expStatus, response.StatusCode)
+
+				responseBody, err := io.ReadAll(response.Body)
+				require.NoError(t, err)
+				if tc.expResponse != "" {
+					require.Equal(t, tc.expResponse, string(responseBody))
+				}
+				for _, exp := range tc.expResponseContains {
+					require.Contains(t, string(responseBody), exp)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid expected request body mode", func(t *testing.T) {
+		t.Parallel()
+		request, err := http.NewRequest("GET",
+			"http://"+l.Addr().String()+"/", bytes.NewBuffer([]byte("expected request body")))
+		require.NoError(t, err)
+
+		request.Header.Set(testupstreamlib.ExpectedPathHeaderKey,
+			base64.StdEncoding.EncodeToString([]byte("/")))
+		request.Header.Set(testupstreamlib.ExpectedRequestBodyHeaderKey,
+			base64.StdEncoding.EncodeToString([]byte("expected request body")))
+		request.Header.Set(testupstreamlib.ExpectedRequestBodyModeHeaderKey, "yaml")
+
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		defer func() {
+			_ = response.Body.Close()
+		}()
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		responseBody, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "invalid expected request body mode yaml\n", string(responseBody))
+	})
+
 	t.Run("not expected header", func(t *testing.T) {
 		t.Parallel()
 		request, err := http.NewRequest("GET",
@@ -494,4 +635,53 @@ func Test_main(t *testing.T) {
 		}()
 		require.Equal(t, http.StatusBadRequest, response.StatusCode)
 	})
+
+	t.Run("aws error", func(t *testing.T) {
+		t.Parallel()
+		for _, tc := range []struct {
+			name, awsError, expContentType, expErrorType, expBody string
+			expStatus                                             int
+		}{
+			{
+				name:           "exception",
+				awsError:       "ThrottledException:429:Too many requests: please wait",
+				expStatus:      http.StatusTooManyRequests,
+				expContentType: "application/json",
+				expErrorType:   "ThrottledException",
+				expBody:        `{"message":"Too many requests: please wait"}`,
+			},
+			{
+				name:           "plain text",
+				awsError:       ":503:no healthy upstream",
+				expStatus:      http.StatusServiceUnavailable,
+				expContentType: "text/plain",
+				expBody:        "no healthy upstream",
+			},
+			{
+				name:           "invalid",
+				awsError:       "ThrottledException",
+				expStatus:      http.StatusBadRequest,
+				expContentType: "text/plain; charset=utf-8",
+				expBody:        "invalid aws error ThrottledException\n",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				request, err := http.NewRequest("POST", "http://"+l.Addr().String()+"/model/foo/converse", nil)
+				require.NoError(t, err)
+				request.Header.Set(testupstreamlib.ResponseAWSErrorKey, tc.awsError)
+
+				response, err := http.DefaultClient.Do(request)
+				require.NoError(t, err)
+				defer func() {
+					_ = response.Body.Close()
+				}()
+				require.Equal(t, tc.expStatus, response.StatusCode)
+				require.Equal(t, tc.expContentType, response.Header.Get("Content-Type"))
+				require.Equal(t, tc.expErrorType, response.Header.Get("x-amzn-errortype"))
+				body, err := io.ReadAll(response.Body)
+				require.NoError(t, err)
+				require.Equal(t, tc.expBody, string(body))
+			})
+		}
+	})
 }